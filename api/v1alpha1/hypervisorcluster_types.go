@@ -42,6 +42,15 @@ type HypervisorClusterSpec struct {
 	// +optional
 	TLS *TLSConfig `json:"tls,omitempty"`
 
+	// ConnectionTimeout bounds establishing a connection to the hypervisor API,
+	// including the TLS handshake. Defaults to 10s.
+	// +optional
+	ConnectionTimeout *metav1.Duration `json:"connectionTimeout,omitempty"`
+
+	// RequestTimeout bounds each individual hypervisor API request. Defaults to 60s.
+	// +optional
+	RequestTimeout *metav1.Duration `json:"requestTimeout,omitempty"`
+
 	// Nodes is a list of hypervisor nodes available in this cluster
 	// +kubebuilder:validation:MinItems=1
 	Nodes []string `json:"nodes"`
@@ -54,6 +63,30 @@ type HypervisorClusterSpec struct {
 	// +kubebuilder:validation:Required
 	DefaultNetwork string `json:"defaultNetwork"`
 
+	// DefaultPool specifies the resource pool cloned VMs are placed in
+	// when the machine template does not set one
+	// +optional
+	DefaultPool string `json:"defaultPool,omitempty"`
+
+	// PoolQuotas limit the resources used by the VMs in a resource pool. Proxmox does not
+	// enforce pool limits itself, so clones that would exceed a quota are not attempted.
+	// +listType=map
+	// +listMapKey=pool
+	// +optional
+	PoolQuotas []PoolQuota `json:"poolQuotas,omitempty"`
+
+	// MaxConcurrentClones limits how many VMs are cloned on this cluster at once,
+	// protecting the hypervisor's storage from bursts of simultaneous clones
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=4
+	// +optional
+	MaxConcurrentClones int32 `json:"maxConcurrentClones,omitempty"`
+
+	// DefaultSSHAuthorizedKeys are public keys authorized to log in to VMs created on this
+	// cluster when the machine template does not set its own
+	// +optional
+	DefaultSSHAuthorizedKeys []string `json:"defaultSSHAuthorizedKeys,omitempty"`
+
 	// DNS configuration for VMs created on this cluster
 	// +optional
 	DNS *DNSConfig `json:"dns,omitempty"`
@@ -61,6 +94,39 @@ type HypervisorClusterSpec struct {
 	// Tags are key-value pairs applied to all VMs created on this cluster
 	// +optional
 	Tags map[string]string `json:"tags,omitempty"`
+
+	// RunnerLabels are added to every runner registered from a VM on this cluster, e.g. its
+	// region, after the template's own labels
+	// +optional
+	RunnerLabels []string `json:"runnerLabels,omitempty"`
+
+	// ManagedBy identifies this operator in the managed-by tag applied to every VM it creates,
+	// which also selects the VMs it lists and garbage collects. Give each operator sharing a
+	// Proxmox cluster its own value so they leave each other's VMs alone. Defaults to "hyperfleet".
+	// +kubebuilder:validation:Pattern=`^[a-z0-9_+.-]+$`
+	// +optional
+	ManagedBy string `json:"managedBy,omitempty"`
+}
+
+// PoolQuota limits the resources used by the VMs in a resource pool. Unset limits are unlimited.
+type PoolQuota struct {
+	// Pool is the name of the resource pool
+	// +kubebuilder:validation:Required
+	Pool string `json:"pool"`
+
+	// MaxVMs limits the number of VMs in the pool
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxVMs int32 `json:"maxVMs,omitempty"`
+
+	// MaxCPUs limits the total vCPUs of the VMs in the pool
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxCPUs int32 `json:"maxCPUs,omitempty"`
+
+	// MaxMemory limits the total memory of the VMs in the pool
+	// +optional
+	MaxMemory *resource.Quantity `json:"maxMemory,omitempty"`
 }
 
 // HypervisorCredentials defines authentication methods for hypervisor access.
@@ -163,6 +229,62 @@ type HypervisorClusterStatus struct {
 	// LastSyncTime is the last time the cluster status was synchronized
 	// +optional
 	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+
+	// Phase summarizes the aggregate result of the cluster health checks
+	// +optional
+	Phase ClusterPhase `json:"phase,omitempty"`
+
+	// Checks reports the result of each individual health check
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Checks []HealthCheckStatus `json:"checks,omitempty"`
+
+	// NodeVersions reports the hypervisor version each online node runs
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	NodeVersions []NodeVersionStatus `json:"nodeVersions,omitempty"`
+
+	// EffectiveTags are the formatted tags stamped on every VM created on this cluster: spec.tags
+	// and the managed-by tag. Claim tags and the operator's instance tag are added per VM.
+	// +optional
+	EffectiveTags []string `json:"effectiveTags,omitempty"`
+}
+
+// ClusterPhase describes the aggregate health of a HypervisorCluster.
+// +kubebuilder:validation:Enum=Ready;Degraded;NotReady
+type ClusterPhase string
+
+const (
+	// ClusterPhaseReady means every health check passed
+	ClusterPhaseReady ClusterPhase = "Ready"
+	// ClusterPhaseDegraded means the cluster is usable but some non-critical checks failed
+	ClusterPhaseDegraded ClusterPhase = "Degraded"
+	// ClusterPhaseNotReady means a critical check failed and the cluster cannot be used
+	ClusterPhaseNotReady ClusterPhase = "NotReady"
+)
+
+// HealthCheckStatus reports the result of a single cluster health check.
+type HealthCheckStatus struct {
+	// Name identifies the health check (e.g., "Connection")
+	Name string `json:"name"`
+
+	// Passed indicates whether the check succeeded
+	Passed bool `json:"passed"`
+
+	// Message provides details about the check result
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// NodeVersionStatus reports the hypervisor version a node runs.
+type NodeVersionStatus struct {
+	// Name of the node
+	Name string `json:"name"`
+
+	// Version of the hypervisor software on the node (e.g., "8.2.4")
+	Version string `json:"version"`
 }
 
 // ResourceSummary represents available resources in the hypervisor cluster.
@@ -186,7 +308,9 @@ type ResourceSummary struct {
 // +kubebuilder:printcolumn:name="Provider",type="string",JSONPath=".spec.provider"
 // +kubebuilder:printcolumn:name="Endpoint",type="string",JSONPath=".spec.endpoint"
 // +kubebuilder:printcolumn:name="Nodes",type="integer",JSONPath=".status.connectedNodes"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
 // +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+// +kubebuilder:printcolumn:name="Tags",type="string",JSONPath=".status.effectiveTags",priority=1
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 // HypervisorCluster is the Schema for the hypervisorclusters API.
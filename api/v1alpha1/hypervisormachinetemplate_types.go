@@ -17,6 +17,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -46,7 +47,8 @@ type SecretKeySelector struct {
 
 // HypervisorMachineTemplateSpec defines the desired state of HypervisorMachineTemplate.
 type HypervisorMachineTemplateSpec struct {
-	// HypervisorClusterRef references the target hypervisor cluster
+	// HypervisorClusterRef references the target hypervisor cluster. A cluster in another
+	// namespace is only used when the operator allows cross-namespace cluster references.
 	// +kubebuilder:validation:Required
 	HypervisorClusterRef ObjectReference `json:"hypervisorClusterRef"`
 
@@ -58,6 +60,12 @@ type HypervisorMachineTemplateSpec struct {
 	// +kubebuilder:validation:Required
 	Resources ResourceRequirements `json:"resources"`
 
+	// DriftPolicy controls what happens when a VM's CPU or memory no longer matches Resources,
+	// whether changed on the hypervisor or by an update to this template
+	// +kubebuilder:default=Warn
+	// +optional
+	DriftPolicy DriftPolicy `json:"driftPolicy,omitempty"`
+
 	// Attestation configures VM identity verification
 	// +kubebuilder:validation:Required
 	Attestation AttestationSpec `json:"attestation"`
@@ -89,6 +97,44 @@ type ProxmoxTemplateSpec struct {
 
 	// LinkedClone enables linked clone for faster provisioning
 	LinkedClone bool `json:"linkedClone,omitempty"`
+
+	// Pool is the resource pool for cloned VMs, overriding the cluster's DefaultPool
+	// +optional
+	Pool string `json:"pool,omitempty"`
+
+	// VGA is the display type of cloned VMs. The default serial0 uses the first serial port as the
+	// display, which suits headless runners and keeps console output on the serial log.
+	// +kubebuilder:validation:Enum=serial0;serial1;serial2;serial3;std;cirrus;vmware;qxl;virtio;none
+	// +kubebuilder:default=serial0
+	// +optional
+	VGA string `json:"vga,omitempty"`
+
+	// GuestAgent enables the QEMU guest agent on cloned VMs, which IP discovery and graceful
+	// shutdown rely on, regardless of the template's setting. Set false to disable it.
+	// +kubebuilder:default=true
+	// +optional
+	GuestAgent *bool `json:"guestAgent,omitempty"`
+
+	// OnBoot starts cloned VMs when their host boots, regardless of the template's setting.
+	// Runner VMs are ephemeral, so it defaults to false and stale runners stay stopped after a host reboot.
+	// +kubebuilder:default=false
+	// +optional
+	OnBoot *bool `json:"onBoot,omitempty"`
+
+	// HotplugCPU lets running cloned VMs take CPU count changes without a restart, up to the
+	// CPUs they booted with
+	// +optional
+	HotplugCPU bool `json:"hotplugCPU,omitempty"`
+
+	// HotplugMemory lets running cloned VMs take memory increases without a restart. It enables
+	// NUMA on the VMs and needs guest support for memory hot-plug.
+	// +optional
+	HotplugMemory bool `json:"hotplugMemory,omitempty"`
+
+	// NestedVirtualization exposes the node's virtualization extension (vmx or svm) to cloned VMs,
+	// so runners can start KVM guests of their own. Clones onto nodes whose CPU lacks it are rejected.
+	// +optional
+	NestedVirtualization bool `json:"nestedVirtualization,omitempty"`
 }
 
 // ResourceRequirements defines VM resource specifications
@@ -102,9 +148,58 @@ type ResourceRequirements struct {
 	// +kubebuilder:validation:Pattern=`^[0-9]+[KMGT]i?$`
 	Memory string `json:"memory"`
 
+	// MemoryMin is the memory guaranteed to the VM (e.g., "2Gi"), at most Memory. When set, the
+	// VM's memory balloons between MemoryMin and Memory, and placement only reserves MemoryMin.
+	// +kubebuilder:validation:Pattern=`^[0-9]+[KMGT]i?$`
+	// +optional
+	MemoryMin string `json:"memoryMin,omitempty"`
+
+	// CPULimit caps the CPU time of the VM, in CPUs (e.g., "1500m" for one and a half), at most CPU.
+	// Unset leaves the VM unlimited.
+	// +optional
+	CPULimit *resource.Quantity `json:"cpuLimit,omitempty"`
+
 	// Disk size for the VM (e.g., "50G", "100G")
 	// +kubebuilder:validation:Pattern=`^[0-9]+G$`
 	Disk string `json:"disk"`
+
+	// Disks lists additional data disks, attached in order after the boot disk
+	// +kubebuilder:validation:MaxItems=30
+	// +optional
+	Disks []DiskSpec `json:"disks,omitempty"`
+}
+
+// DriftPolicy controls how resource drift between a VM and its template is handled
+// +kubebuilder:validation:Enum=Ignore;Warn;Reconcile;ReconcileWithReboot
+type DriftPolicy string
+
+const (
+	// DriftPolicyIgnore does not check VMs for drift
+	DriftPolicyIgnore DriftPolicy = "Ignore"
+	// DriftPolicyWarn reports drift in the claim's ResourcesInSync condition
+	DriftPolicyWarn DriftPolicy = "Warn"
+	// DriftPolicyReconcile reconfigures drifted VMs to match the template
+	DriftPolicyReconcile DriftPolicy = "Reconcile"
+	// DriftPolicyReconcileWithReboot reconfigures drifted VMs like DriftPolicyReconcile and
+	// reboots those that only take the change at their next boot, interrupting any running job
+	DriftPolicyReconcileWithReboot DriftPolicy = "ReconcileWithReboot"
+)
+
+// DiskSpec defines an additional VM data disk
+type DiskSpec struct {
+	// Size of the disk (e.g., "50G", "100G")
+	// +kubebuilder:validation:Pattern=`^[0-9]+G$`
+	Size string `json:"size"`
+
+	// Storage is the storage pool for the disk, defaulting to the cluster's DefaultStorage
+	// +optional
+	Storage string `json:"storage,omitempty"`
+
+	// Format is the disk image format. qcow2, needed for snapshots on file-based storage, and
+	// vmdk require a file-based storage such as dir or NFS; block storage only holds raw disks.
+	// +kubebuilder:validation:Enum=raw;qcow2;vmdk
+	// +optional
+	Format string `json:"format,omitempty"`
 }
 
 // AttestationSpec configures VM identity verification
@@ -150,7 +245,8 @@ type GitHubConfig struct {
 	// PAT configuration for Personal Access Token (development)
 	PAT *SecretKeySelector `json:"pat,omitempty"`
 
-	// Repository or organization URL for runner registration
+	// Repository, organization or enterprise URL for runner registration, e.g.
+	// https://github.com/owner/repo, https://github.com/org or https://github.com/enterprises/name
 	URL string `json:"url"`
 
 	// Runner configuration
@@ -182,6 +278,11 @@ type GitHubRunnerConfig struct {
 
 	// Labels for runner registration
 	Labels []string `json:"labels,omitempty"`
+
+	// Group is the runner group the runner joins. Only organization and enterprise runners
+	// have groups, so it is ignored, with a warning, when URL is a repository.
+	// +optional
+	Group string `json:"group,omitempty"`
 }
 
 // NetworkSpec defines network configuration
@@ -194,6 +295,30 @@ type NetworkSpec struct {
 
 	// DHCP provides DHCP-specific configuration
 	DHCP *DHCPConfig `json:"dhcp,omitempty"`
+
+	// Interfaces reconfigures network interfaces the VM inherits from its template;
+	// interfaces not listed keep the template's settings
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Interfaces []NetworkInterfaceSpec `json:"interfaces,omitempty"`
+}
+
+// NetworkInterfaceSpec configures a network interface inherited from the template
+type NetworkInterfaceSpec struct {
+	// Name is the interface's device name, e.g. "net0"
+	// +kubebuilder:validation:Pattern=`^net[0-9]+$`
+	Name string `json:"name"`
+
+	// Firewall enables the Proxmox firewall on the interface
+	// +kubebuilder:default=false
+	// +optional
+	Firewall bool `json:"firewall,omitempty"`
+
+	// Bridge is the network bridge the interface is attached to. The primary interface, net0,
+	// defaults to the cluster's DefaultNetwork; other interfaces default to the template's bridge.
+	// +optional
+	Bridge string `json:"bridge,omitempty"`
 }
 
 // StaticNetworkConfig defines static IP configuration
@@ -227,6 +352,20 @@ type CloudInitSpec struct {
 
 	// MetaData provides cloud-init meta data
 	MetaData string `json:"metaData,omitempty"`
+
+	// SSHAuthorizedKeys are public keys authorized to log in to the VM for debugging,
+	// overriding the cluster's DefaultSSHAuthorizedKeys
+	// +optional
+	SSHAuthorizedKeys []string `json:"sshAuthorizedKeys,omitempty"`
+
+	// User is the default user the hypervisor's cloud-init data creates, e.g. for console access;
+	// empty keeps the template's
+	// +optional
+	User string `json:"user,omitempty"`
+
+	// PasswordSecretRef references the password of the cloud-init user; unset keeps the template's
+	// +optional
+	PasswordSecretRef *SecretKeySelector `json:"passwordSecretRef,omitempty"`
 }
 
 // HypervisorMachineTemplateStatus defines the observed state of HypervisorMachineTemplate.
@@ -237,11 +376,24 @@ type HypervisorMachineTemplateStatus struct {
 	// TemplateAvailable indicates if the referenced template exists
 	TemplateAvailable bool `json:"templateAvailable,omitempty"`
 
+	// TemplateName is the hypervisor's name for the source template, set when validation succeeds
+	// +optional
+	TemplateName string `json:"templateName,omitempty"`
+
+	// TemplateNode is the node hosting the source template, set when validation succeeds
+	// +optional
+	TemplateNode string `json:"templateNode,omitempty"`
+
 	// ValidationStatus indicates template validation result
 	ValidationStatus string `json:"validationStatus,omitempty"`
 
-	// LastValidated timestamp of last validation
+	// LastValidated timestamp of last validation against the hypervisor
 	LastValidated *metav1.Time `json:"lastValidated,omitempty"`
+
+	// ObservedClusterGeneration is the generation of the HypervisorCluster the last successful
+	// validation ran against
+	// +optional
+	ObservedClusterGeneration int64 `json:"observedClusterGeneration,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -20,22 +20,79 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-// EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
-// NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
-
 // MachineClaimSpec defines the desired state of MachineClaim.
 type MachineClaimSpec struct {
-	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	// TemplateRef references the HypervisorMachineTemplate used to provision the VM
+	// +kubebuilder:validation:Required
+	TemplateRef ObjectReference `json:"templateRef"`
+
+	// RunnerName is the name the runner registers with, defaults to the claim name
+	// +optional
+	RunnerName string `json:"runnerName,omitempty"`
 
-	// Foo is an example field of MachineClaim. Edit machineclaim_types.go to remove/update
-	Foo string `json:"foo,omitempty"`
+	// Tags are key-value pairs applied to the claim's VM in addition to the
+	// cluster's tags, overriding cluster tags with the same key
+	// +optional
+	Tags map[string]string `json:"tags,omitempty"`
 }
 
 // MachineClaimStatus defines the observed state of MachineClaim.
 type MachineClaimStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	// Conditions represent the latest available observations
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// BootstrapSecretName is the Secret holding the rendered runner bootstrap config
+	// +optional
+	BootstrapSecretName string `json:"bootstrapSecretName,omitempty"`
+
+	// RunnerDownloadURL is the runner download URL resolved when the bootstrap config was
+	// rendered: the template's, or the bootstrap service's default when the template sets none
+	// +optional
+	RunnerDownloadURL string `json:"runnerDownloadURL,omitempty"`
+
+	// RunnerVersion is the runner version of RunnerDownloadURL, when its release archive
+	// name carries one
+	// +optional
+	RunnerVersion string `json:"runnerVersion,omitempty"`
+
+	// VMRef identifies the VM provisioned for this claim
+	// +optional
+	VMRef *VMReference `json:"vmRef,omitempty"`
+
+	// PendingVMRef identifies the VM being cloned for this claim. It is recorded before the
+	// clone starts so a retried clone reuses the VM ID, and cleared once VMRef is set.
+	// +optional
+	PendingVMRef *VMReference `json:"pendingVMRef,omitempty"`
+
+	// ClusterRef is the HypervisorCluster hosting the claim's VM. It is recorded with
+	// PendingVMRef so the VM can still be deleted once its template is gone.
+	// +optional
+	ClusterRef *ObjectReference `json:"clusterRef,omitempty"`
+
+	// AppliedTags are the VM tags last applied by the operator. Tags that drop out
+	// of the desired set are removed from the VM; other tags on the VM are kept.
+	// +optional
+	AppliedTags []string `json:"appliedTags,omitempty"`
+
+	// MACAddress is the MAC address of the VM's primary interface (net0). It is recorded
+	// when the template requests a static DHCP lease so a reservation can be created for it.
+	// +optional
+	MACAddress string `json:"macAddress,omitempty"`
+
+	// DeleteTaskID is the hypervisor task deleting the claim's VM. It is recorded so a
+	// deletion in progress is awaited on later reconciles rather than started again.
+	// +optional
+	DeleteTaskID string `json:"deleteTaskID,omitempty"`
+}
+
+// VMReference identifies a VM on a hypervisor node
+type VMReference struct {
+	// Node is the hypervisor node hosting the VM
+	Node string `json:"node"`
+
+	// ID is the hypervisor VM ID
+	ID int `json:"id"`
 }
 
 // +kubebuilder:object:root=true
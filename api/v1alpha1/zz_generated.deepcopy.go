@@ -21,8 +21,8 @@ limitations under the License.
 package v1alpha1
 
 import (
-	"k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -96,6 +96,16 @@ func (in *BootstrapSpec) DeepCopy() *BootstrapSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CloudInitSpec) DeepCopyInto(out *CloudInitSpec) {
 	*out = *in
+	if in.SSHAuthorizedKeys != nil {
+		in, out := &in.SSHAuthorizedKeys, &out.SSHAuthorizedKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PasswordSecretRef != nil {
+		in, out := &in.PasswordSecretRef, &out.PasswordSecretRef
+		*out = new(SecretKeySelector)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudInitSpec.
@@ -169,17 +179,17 @@ func (in *DNSProviderCredentials) DeepCopyInto(out *DNSProviderCredentials) {
 	*out = *in
 	if in.AccessKeyID != nil {
 		in, out := &in.AccessKeyID, &out.AccessKeyID
-		*out = new(v1.SecretKeySelector)
+		*out = new(corev1.SecretKeySelector)
 		(*in).DeepCopyInto(*out)
 	}
 	if in.SecretAccessKey != nil {
 		in, out := &in.SecretAccessKey, &out.SecretAccessKey
-		*out = new(v1.SecretKeySelector)
+		*out = new(corev1.SecretKeySelector)
 		(*in).DeepCopyInto(*out)
 	}
 	if in.APIToken != nil {
 		in, out := &in.APIToken, &out.APIToken
-		*out = new(v1.SecretKeySelector)
+		*out = new(corev1.SecretKeySelector)
 		(*in).DeepCopyInto(*out)
 	}
 }
@@ -194,6 +204,21 @@ func (in *DNSProviderCredentials) DeepCopy() *DNSProviderCredentials {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DiskSpec) DeepCopyInto(out *DiskSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiskSpec.
+func (in *DiskSpec) DeepCopy() *DiskSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DiskSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitHubAppConfig) DeepCopyInto(out *GitHubAppConfig) {
 	*out = *in
@@ -258,6 +283,21 @@ func (in *GitHubRunnerConfig) DeepCopy() *GitHubRunnerConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HealthCheckStatus) DeepCopyInto(out *HealthCheckStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HealthCheckStatus.
+func (in *HealthCheckStatus) DeepCopy() *HealthCheckStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(HealthCheckStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HypervisorCluster) DeepCopyInto(out *HypervisorCluster) {
 	*out = *in
@@ -326,11 +366,33 @@ func (in *HypervisorClusterSpec) DeepCopyInto(out *HypervisorClusterSpec) {
 		*out = new(TLSConfig)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ConnectionTimeout != nil {
+		in, out := &in.ConnectionTimeout, &out.ConnectionTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.RequestTimeout != nil {
+		in, out := &in.RequestTimeout, &out.RequestTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
 	if in.Nodes != nil {
 		in, out := &in.Nodes, &out.Nodes
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.PoolQuotas != nil {
+		in, out := &in.PoolQuotas, &out.PoolQuotas
+		*out = make([]PoolQuota, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DefaultSSHAuthorizedKeys != nil {
+		in, out := &in.DefaultSSHAuthorizedKeys, &out.DefaultSSHAuthorizedKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.DNS != nil {
 		in, out := &in.DNS, &out.DNS
 		*out = new(DNSConfig)
@@ -343,6 +405,11 @@ func (in *HypervisorClusterSpec) DeepCopyInto(out *HypervisorClusterSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.RunnerLabels != nil {
+		in, out := &in.RunnerLabels, &out.RunnerLabels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HypervisorClusterSpec.
@@ -360,7 +427,7 @@ func (in *HypervisorClusterStatus) DeepCopyInto(out *HypervisorClusterStatus) {
 	*out = *in
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
-		*out = make([]metav1.Condition, len(*in))
+		*out = make([]v1.Condition, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -374,6 +441,21 @@ func (in *HypervisorClusterStatus) DeepCopyInto(out *HypervisorClusterStatus) {
 		in, out := &in.LastSyncTime, &out.LastSyncTime
 		*out = (*in).DeepCopy()
 	}
+	if in.Checks != nil {
+		in, out := &in.Checks, &out.Checks
+		*out = make([]HealthCheckStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.NodeVersions != nil {
+		in, out := &in.NodeVersions, &out.NodeVersions
+		*out = make([]NodeVersionStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.EffectiveTags != nil {
+		in, out := &in.EffectiveTags, &out.EffectiveTags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HypervisorClusterStatus.
@@ -391,22 +473,22 @@ func (in *HypervisorCredentials) DeepCopyInto(out *HypervisorCredentials) {
 	*out = *in
 	if in.TokenID != nil {
 		in, out := &in.TokenID, &out.TokenID
-		*out = new(v1.SecretKeySelector)
+		*out = new(corev1.SecretKeySelector)
 		(*in).DeepCopyInto(*out)
 	}
 	if in.TokenSecret != nil {
 		in, out := &in.TokenSecret, &out.TokenSecret
-		*out = new(v1.SecretKeySelector)
+		*out = new(corev1.SecretKeySelector)
 		(*in).DeepCopyInto(*out)
 	}
 	if in.Username != nil {
 		in, out := &in.Username, &out.Username
-		*out = new(v1.SecretKeySelector)
+		*out = new(corev1.SecretKeySelector)
 		(*in).DeepCopyInto(*out)
 	}
 	if in.Password != nil {
 		in, out := &in.Password, &out.Password
-		*out = new(v1.SecretKeySelector)
+		*out = new(corev1.SecretKeySelector)
 		(*in).DeepCopyInto(*out)
 	}
 }
@@ -485,14 +567,14 @@ func (in *HypervisorMachineTemplateSpec) DeepCopyInto(out *HypervisorMachineTemp
 	*out = *in
 	out.HypervisorClusterRef = in.HypervisorClusterRef
 	in.Template.DeepCopyInto(&out.Template)
-	out.Resources = in.Resources
+	in.Resources.DeepCopyInto(&out.Resources)
 	out.Attestation = in.Attestation
 	in.Bootstrap.DeepCopyInto(&out.Bootstrap)
 	in.Network.DeepCopyInto(&out.Network)
 	if in.CloudInit != nil {
 		in, out := &in.CloudInit, &out.CloudInit
 		*out = new(CloudInitSpec)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 }
 
@@ -511,7 +593,7 @@ func (in *HypervisorMachineTemplateStatus) DeepCopyInto(out *HypervisorMachineTe
 	*out = *in
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
-		*out = make([]metav1.Condition, len(*in))
+		*out = make([]v1.Condition, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -537,8 +619,8 @@ func (in *MachineClaim) DeepCopyInto(out *MachineClaim) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
-	out.Status = in.Status
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineClaim.
@@ -594,6 +676,14 @@ func (in *MachineClaimList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MachineClaimSpec) DeepCopyInto(out *MachineClaimSpec) {
 	*out = *in
+	out.TemplateRef = in.TemplateRef
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineClaimSpec.
@@ -609,6 +699,33 @@ func (in *MachineClaimSpec) DeepCopy() *MachineClaimSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MachineClaimStatus) DeepCopyInto(out *MachineClaimStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.VMRef != nil {
+		in, out := &in.VMRef, &out.VMRef
+		*out = new(VMReference)
+		**out = **in
+	}
+	if in.PendingVMRef != nil {
+		in, out := &in.PendingVMRef, &out.PendingVMRef
+		*out = new(VMReference)
+		**out = **in
+	}
+	if in.ClusterRef != nil {
+		in, out := &in.ClusterRef, &out.ClusterRef
+		*out = new(ObjectReference)
+		**out = **in
+	}
+	if in.AppliedTags != nil {
+		in, out := &in.AppliedTags, &out.AppliedTags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineClaimStatus.
@@ -621,6 +738,21 @@ func (in *MachineClaimStatus) DeepCopy() *MachineClaimStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkInterfaceSpec) DeepCopyInto(out *NetworkInterfaceSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkInterfaceSpec.
+func (in *NetworkInterfaceSpec) DeepCopy() *NetworkInterfaceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkInterfaceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NetworkSpec) DeepCopyInto(out *NetworkSpec) {
 	*out = *in
@@ -634,6 +766,11 @@ func (in *NetworkSpec) DeepCopyInto(out *NetworkSpec) {
 		*out = new(DHCPConfig)
 		**out = **in
 	}
+	if in.Interfaces != nil {
+		in, out := &in.Interfaces, &out.Interfaces
+		*out = make([]NetworkInterfaceSpec, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkSpec.
@@ -646,6 +783,21 @@ func (in *NetworkSpec) DeepCopy() *NetworkSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeVersionStatus) DeepCopyInto(out *NodeVersionStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeVersionStatus.
+func (in *NodeVersionStatus) DeepCopy() *NodeVersionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeVersionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ObjectReference) DeepCopyInto(out *ObjectReference) {
 	*out = *in
@@ -661,9 +813,39 @@ func (in *ObjectReference) DeepCopy() *ObjectReference {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PoolQuota) DeepCopyInto(out *PoolQuota) {
+	*out = *in
+	if in.MaxMemory != nil {
+		in, out := &in.MaxMemory, &out.MaxMemory
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PoolQuota.
+func (in *PoolQuota) DeepCopy() *PoolQuota {
+	if in == nil {
+		return nil
+	}
+	out := new(PoolQuota)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ProxmoxTemplateSpec) DeepCopyInto(out *ProxmoxTemplateSpec) {
 	*out = *in
+	if in.GuestAgent != nil {
+		in, out := &in.GuestAgent, &out.GuestAgent
+		*out = new(bool)
+		**out = **in
+	}
+	if in.OnBoot != nil {
+		in, out := &in.OnBoot, &out.OnBoot
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProxmoxTemplateSpec.
@@ -679,6 +861,16 @@ func (in *ProxmoxTemplateSpec) DeepCopy() *ProxmoxTemplateSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourceRequirements) DeepCopyInto(out *ResourceRequirements) {
 	*out = *in
+	if in.CPULimit != nil {
+		in, out := &in.CPULimit, &out.CPULimit
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Disks != nil {
+		in, out := &in.Disks, &out.Disks
+		*out = make([]DiskSpec, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceRequirements.
@@ -850,7 +1042,7 @@ func (in *TLSConfig) DeepCopyInto(out *TLSConfig) {
 	*out = *in
 	if in.CACertificate != nil {
 		in, out := &in.CACertificate, &out.CACertificate
-		*out = new(v1.SecretKeySelector)
+		*out = new(corev1.SecretKeySelector)
 		(*in).DeepCopyInto(*out)
 	}
 }
@@ -871,7 +1063,7 @@ func (in *TemplateSpec) DeepCopyInto(out *TemplateSpec) {
 	if in.Proxmox != nil {
 		in, out := &in.Proxmox, &out.Proxmox
 		*out = new(ProxmoxTemplateSpec)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 }
 
@@ -884,3 +1076,18 @@ func (in *TemplateSpec) DeepCopy() *TemplateSpec {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VMReference) DeepCopyInto(out *VMReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VMReference.
+func (in *VMReference) DeepCopy() *VMReference {
+	if in == nil {
+		return nil
+	}
+	out := new(VMReference)
+	in.DeepCopyInto(out)
+	return out
+}
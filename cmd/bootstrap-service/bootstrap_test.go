@@ -6,11 +6,16 @@ import (
 	"compress/gzip"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
+	"maps"
 	"net/http"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"strings"
 	"syscall"
 	"testing"
@@ -578,6 +583,67 @@ func TestRealImplementations(t *testing.T) {
 	logger.Printf("Test message: %s", "hello")
 }
 
+func TestParseLogLevel(t *testing.T) {
+	tests := []struct {
+		name        string
+		expected    LogLevel
+		expectError bool
+	}{
+		{name: "", expected: LogLevelInfo},
+		{name: "error", expected: LogLevelError},
+		{name: "info", expected: LogLevelInfo},
+		{name: "DEBUG", expected: LogLevelDebug},
+		{name: "verbose", expectError: true},
+	}
+
+	for _, tt := range tests {
+		level, err := ParseLogLevel(tt.name)
+		if (err != nil) != tt.expectError {
+			t.Errorf("ParseLogLevel(%q): expected error %v, got %v", tt.name, tt.expectError, err)
+		}
+		if err == nil && level != tt.expected {
+			t.Errorf("ParseLogLevel(%q): expected level %d, got %d", tt.name, tt.expected, level)
+		}
+	}
+}
+
+func TestRealLoggerLevels(t *testing.T) {
+	tests := []struct {
+		level       LogLevel
+		expectInfo  bool
+		expectDebug bool
+	}{
+		{level: LogLevelError},
+		{level: LogLevelInfo, expectInfo: true},
+		{level: LogLevelDebug, expectInfo: true, expectDebug: true},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		config := &RunnerConfig{}
+		config.Runner.OS, config.Runner.Arch = "linux", "amd64"
+		logger := &RealLogger{logger: log.New(&buf, "", 0), level: tt.level}
+		bootstrap := NewGitHubBootstrap(config, logger, &MockHTTPClient{},
+			NewMockFileSystem(), NewMockCommandExecutor(), NewMockSystemOperations())
+
+		// The constructed download URL is a debug-only message
+		bootstrap.buildDownloadURL()
+		logger.Printf("info message")
+		logger.Errorf("error message")
+
+		output := buf.String()
+		if !strings.Contains(output, "error message") {
+			t.Errorf("level %d: expected errors to be logged, got %q", tt.level, output)
+		}
+		if strings.Contains(output, "info message") != tt.expectInfo {
+			t.Errorf("level %d: expected info logged=%v, got %q", tt.level, tt.expectInfo, output)
+		}
+		if strings.Contains(output, "Constructed download URL") != tt.expectDebug {
+			t.Errorf("level %d: expected debug logged=%v, got %q", tt.level, tt.expectDebug, output)
+		}
+	}
+}
+
 func TestMainFunctionWithMocks(t *testing.T) {
 	// Test the main function logic by creating a config file and testing the switch logic
 	tempDir := t.TempDir()
@@ -629,6 +695,60 @@ func TestMainFunctionWithMocks(t *testing.T) {
 	}
 }
 
+func TestRunnerEnvPassedToCommands(t *testing.T) {
+	config := &RunnerConfig{
+		Method:          runnerTokenMethod,
+		RunnerToken:     "test-token",
+		RegistrationURL: "https://github.com/test/repo",
+		RunnerName:      "test-runner",
+	}
+	config.Runner.InstallPath = testInstallPath
+	config.Runner.Env = map[string]string{
+		"HTTPS_PROXY":            "http://proxy.internal:3128",
+		"NODE_EXTRA_CA_CERTS":    "/etc/ssl/certs/internal-ca.pem",
+		"RUNNER_ALLOW_RUNASROOT": "1",
+	}
+
+	executor := NewMockCommandExecutor()
+	bootstrap := NewGitHubBootstrap(config, NewMockLogger(), &MockHTTPClient{}, NewMockFileSystem(), executor, NewMockSystemOperations())
+
+	ctx := context.Background()
+	if err := bootstrap.configureRunner(ctx); err != nil {
+		t.Fatalf("Expected no error configuring, got: %v", err)
+	}
+	if err := bootstrap.runAndMonitor(ctx); err != nil {
+		t.Fatalf("Expected no error running, got: %v", err)
+	}
+
+	expectedEnv := []string{
+		"HTTPS_PROXY=http://proxy.internal:3128",
+		"NODE_EXTRA_CA_CERTS=/etc/ssl/certs/internal-ca.pem",
+		"RUNNER_ALLOW_RUNASROOT=1",
+	}
+	if len(executor.ExecutedCommands) != 2 {
+		t.Fatalf("Expected config and run commands, got %d commands", len(executor.ExecutedCommands))
+	}
+	for _, cmd := range executor.ExecutedCommands {
+		if !slices.Equal(cmd.Env, expectedEnv) {
+			t.Errorf("Expected env %v for %s, got %v", expectedEnv, cmd.Name, cmd.Env)
+		}
+	}
+
+	t.Run("invalid variable name", func(t *testing.T) {
+		config.Runner.Env = map[string]string{"BAD=NAME": "value"}
+		executor := NewMockCommandExecutor()
+		bootstrap := NewGitHubBootstrap(config, NewMockLogger(), &MockHTTPClient{}, NewMockFileSystem(), executor, NewMockSystemOperations())
+
+		err := bootstrap.configureRunner(ctx)
+		if err == nil || !strings.Contains(err.Error(), "invalid runner environment variable name") {
+			t.Errorf("Expected invalid name error, got: %v", err)
+		}
+		if len(executor.ExecutedCommands) != 0 {
+			t.Errorf("Expected no commands to run, got %d", len(executor.ExecutedCommands))
+		}
+	})
+}
+
 func TestDownloadGitHubRunnerErrorHandling(t *testing.T) {
 	config := &RunnerConfig{}
 	logger := NewMockLogger()
@@ -678,6 +798,88 @@ func TestDownloadGitHubRunnerErrorHandling(t *testing.T) {
 	}
 }
 
+func TestConfigureRunnerFetchesToken(t *testing.T) {
+	const tokenURL = "http://169.254.169.254/hyperfleet/runner-token"
+
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		expectError string
+	}{
+		{
+			name:   "successful fetch",
+			status: http.StatusOK,
+			body:   `{"token": "fetched-token", "expires_at": "2025-03-14T10:26:53Z"}`,
+		},
+		{
+			name:        "non-200",
+			status:      http.StatusForbidden,
+			body:        `{"message": "forbidden"}`,
+			expectError: "HTTP 403",
+		},
+		{
+			name:        "malformed response",
+			status:      http.StatusOK,
+			body:        `fetched-token`,
+			expectError: "failed to parse registration token response",
+		},
+		{
+			name:        "response without a token",
+			status:      http.StatusOK,
+			body:        `{"expires_at": "2025-03-14T10:26:53Z"}`,
+			expectError: "has no token",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &RunnerConfig{
+				Method:          runnerTokenMethod,
+				RunnerToken:     "stale-token",
+				RunnerTokenURL:  tokenURL,
+				RegistrationURL: "https://github.com/test/repo",
+				RunnerName:      "test-runner",
+			}
+			config.Runner.InstallPath = testInstallPath
+			config.Runner.WorkDir = testWorkDir
+
+			var requested []string
+			httpClient := &MockHTTPClient{DoFunc: func(req *http.Request) (*http.Response, error) {
+				requested = append(requested, req.Method+" "+req.URL.String())
+				return &http.Response{StatusCode: tt.status, Body: io.NopCloser(strings.NewReader(tt.body))}, nil
+			}}
+			executor := NewMockCommandExecutor()
+
+			bootstrap := NewGitHubBootstrap(config, NewMockLogger(), httpClient, NewMockFileSystem(), executor, NewMockSystemOperations())
+			err := bootstrap.configureRunner(context.Background())
+
+			if !slices.Equal(requested, []string{"GET " + tokenURL}) {
+				t.Errorf("Expected the token to be fetched once, got %v", requested)
+			}
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Fatalf("Expected error containing %q, got %v", tt.expectError, err)
+				}
+				if len(executor.ExecutedCommands) != 0 {
+					t.Errorf("Expected the runner not to be configured, got %v", executor.ExecutedCommands)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+
+			if config.RunnerToken != "fetched-token" || config.ExpiresAt != "2025-03-14T10:26:53Z" {
+				t.Errorf("Expected the fetched token and expiry, got %q and %q", config.RunnerToken, config.ExpiresAt)
+			}
+			if len(executor.ExecutedCommands) != 1 || !slices.Contains(executor.ExecutedCommands[0].Args, "fetched-token") {
+				t.Errorf("Expected the runner to be configured with the fetched token, got %v", executor.ExecutedCommands)
+			}
+		})
+	}
+}
+
 func TestConfigureRunnerErrorHandling(t *testing.T) {
 	config := &RunnerConfig{
 		Method:          "runner-token",
@@ -754,6 +956,274 @@ func TestRunAndMonitorErrorHandling(t *testing.T) {
 	}
 }
 
+func TestCleanupDeregistersRunner(t *testing.T) {
+	config := &RunnerConfig{RunnerName: "test-runner", RemoveToken: "remove-token"}
+	config.Runner.InstallPath = testInstallPath
+	config.Runner.WorkDir = testWorkDir
+
+	var events []string
+	executor := NewMockCommandExecutor()
+	executor.CommandContextFunc = func(ctx context.Context, name string, args ...string) Command {
+		return &MockCommand{
+			name:     name,
+			args:     args,
+			executor: executor,
+			RunFunc: func() error {
+				events = append(events, "remove")
+				return nil
+			},
+		}
+	}
+	system := NewMockSystemOperations()
+	system.SleepFunc = func(duration int) {
+		events = append(events, fmt.Sprintf("sleep %d", duration))
+	}
+	system.RebootFunc = func(cmd int) error {
+		events = append(events, "shutdown")
+		return nil
+	}
+
+	bootstrap := NewGitHubBootstrap(config, NewMockLogger(), &MockHTTPClient{}, NewMockFileSystem(), executor, system)
+	if err := bootstrap.cleanup(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if len(executor.ExecutedCommands) != 1 {
+		t.Fatalf("Expected 1 command execution, got %d", len(executor.ExecutedCommands))
+	}
+	cmd := executor.ExecutedCommands[0]
+	expectedArgs := []string{"remove", "--token", "remove-token"}
+	if cmd.Name != testConfigScript || !slices.Equal(cmd.Args, expectedArgs) {
+		t.Errorf("Expected %s %v, got %s %v", testConfigScript, expectedArgs, cmd.Name, cmd.Args)
+	}
+
+	expectedEvents := []string{"remove", fmt.Sprintf("sleep %d", DeregisterGraceSeconds), fmt.Sprintf("sleep %d", CleanupDelaySeconds), "shutdown"}
+	if !slices.Equal(events, expectedEvents) {
+		t.Errorf("Expected events %v, got %v", expectedEvents, events)
+	}
+
+	t.Run("removal fails then succeeds", func(t *testing.T) {
+		events = nil
+		attempts := 0
+		executor.CommandContextFunc = func(ctx context.Context, name string, args ...string) Command {
+			return &MockCommand{RunFunc: func() error {
+				attempts++
+				events = append(events, "remove")
+				if attempts < 2 {
+					return fmt.Errorf("exit status 1")
+				}
+				return nil
+			}}
+		}
+
+		if err := bootstrap.cleanup(context.Background()); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		expectedEvents := []string{
+			"remove", fmt.Sprintf("sleep %d", DeregisterRetryDelaySeconds), "remove",
+			fmt.Sprintf("sleep %d", DeregisterGraceSeconds), fmt.Sprintf("sleep %d", CleanupDelaySeconds), "shutdown",
+		}
+		if !slices.Equal(events, expectedEvents) {
+			t.Errorf("Expected events %v, got %v", expectedEvents, events)
+		}
+	})
+
+	t.Run("removal fails", func(t *testing.T) {
+		events = nil
+		executor.CommandContextFunc = func(ctx context.Context, name string, args ...string) Command {
+			return &MockCommand{RunFunc: func() error {
+				events = append(events, "remove")
+				return fmt.Errorf("exit status 1")
+			}}
+		}
+
+		if err := bootstrap.cleanup(context.Background()); err != nil {
+			t.Fatalf("Expected deregistration failures to be non-fatal, got: %v", err)
+		}
+		// Each retry waits twice as long; no grace period is needed when nothing was removed
+		expectedEvents := []string{
+			"remove", fmt.Sprintf("sleep %d", DeregisterRetryDelaySeconds),
+			"remove", fmt.Sprintf("sleep %d", DeregisterRetryDelaySeconds*ConfigureBackoffFactor),
+			"remove", fmt.Sprintf("sleep %d", CleanupDelaySeconds), "shutdown",
+		}
+		if !slices.Equal(events, expectedEvents) {
+			t.Errorf("Expected events %v, got %v", expectedEvents, events)
+		}
+	})
+
+	t.Run("configured attempts", func(t *testing.T) {
+		events = nil
+		config.Runner.DeregisterMaxAttempts = 1
+		defer func() { config.Runner.DeregisterMaxAttempts = 0 }()
+
+		if err := bootstrap.cleanup(context.Background()); err != nil {
+			t.Fatalf("Expected deregistration failures to be non-fatal, got: %v", err)
+		}
+		expectedEvents := []string{"remove", fmt.Sprintf("sleep %d", CleanupDelaySeconds), "shutdown"}
+		if !slices.Equal(events, expectedEvents) {
+			t.Errorf("Expected events %v, got %v", expectedEvents, events)
+		}
+	})
+}
+
+func TestCleanupProviderShutdownMode(t *testing.T) {
+	completionFile := "/run/test/completion.json"
+	config := &RunnerConfig{RunnerName: "test-runner", ShutdownMode: ShutdownModeProvider, CompletionFile: completionFile}
+	config.Runner.InstallPath = testInstallPath
+	config.Runner.WorkDir = testWorkDir
+
+	fileSystem := NewMockFileSystem()
+	executor := NewMockCommandExecutor()
+	system := NewMockSystemOperations()
+
+	bootstrap := NewGitHubBootstrap(config, NewMockLogger(), &MockHTTPClient{}, fileSystem, executor, system)
+	if err := bootstrap.cleanup(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	// No in-guest shutdown is attempted
+	if system.RebootCalled {
+		t.Error("Expected no shutdown syscall in provider shutdown mode")
+	}
+	if !slices.Equal(fileSystem.OpenedFiles, []string{completionFile}) {
+		t.Errorf("Expected only the completion file to be opened, got %v", fileSystem.OpenedFiles)
+	}
+	if len(executor.ExecutedCommands) != 0 {
+		t.Errorf("Expected no shutdown command, got %v", executor.ExecutedCommands)
+	}
+
+	// The installation is still cleaned up and completion is signaled
+	if !slices.Contains(fileSystem.RemovedPaths, testInstallPath) || !slices.Contains(fileSystem.RemovedPaths, testWorkDir) {
+		t.Errorf("Expected the runner to be cleaned up, got %v", fileSystem.RemovedPaths)
+	}
+	if !slices.Contains(fileSystem.CreatedDirs, filepath.Dir(completionFile)) {
+		t.Errorf("Expected the completion file directory to be created, got %v", fileSystem.CreatedDirs)
+	}
+	var result CompletionResult
+	if err := json.Unmarshal([]byte(fileSystem.WrittenData[completionFile]), &result); err != nil {
+		t.Fatalf("Expected a JSON completion result, got %q: %v", fileSystem.WrittenData[completionFile], err)
+	}
+	if result != (CompletionResult{RunnerName: "test-runner", Phase: PhaseCompleted}) {
+		t.Errorf("Unexpected completion result: %+v", result)
+	}
+
+	t.Run("default completion file", func(t *testing.T) {
+		config.CompletionFile = ""
+		if err := bootstrap.cleanup(context.Background()); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if _, exists := fileSystem.WrittenData[DefaultCompletionFile]; !exists {
+			t.Errorf("Expected completion to be signaled in %s", DefaultCompletionFile)
+		}
+	})
+
+	t.Run("completion file cannot be written", func(t *testing.T) {
+		fileSystem.OpenFileFunc = func(name string, flag int, perm os.FileMode) (io.WriteCloser, error) {
+			return nil, os.ErrPermission
+		}
+		if err := bootstrap.cleanup(context.Background()); err == nil || !errors.Is(err, os.ErrPermission) {
+			t.Errorf("Expected a completion file error, got %v", err)
+		}
+		if system.RebootCalled {
+			t.Error("Expected no fallback to an in-guest shutdown")
+		}
+	})
+}
+
+func TestCleanupExportsLogs(t *testing.T) {
+	exportPath := "/mnt/logs"
+	config := &RunnerConfig{RunnerName: "test-runner", LogExportPath: exportPath}
+	config.Runner.InstallPath = testInstallPath
+	config.Runner.WorkDir = testWorkDir
+	logFile := filepath.Join(exportPath, "test-runner", LogExportLogFile)
+	statusFile := filepath.Join(exportPath, "test-runner", LogExportStatusFile)
+
+	executor := NewMockCommandExecutor()
+	executor.CommandContextFunc = func(ctx context.Context, name string, args ...string) Command {
+		return &MockCommand{name: name, args: args, executor: executor, Output: "Job build completed with result: Succeeded\n"}
+	}
+	fileSystem := NewMockFileSystem()
+	system := NewMockSystemOperations()
+
+	// The logs must already be on the volume when the VM powers off
+	var exportedAtShutdown map[string]string
+	system.RebootFunc = func(cmd int) error {
+		exportedAtShutdown = maps.Clone(fileSystem.WrittenData)
+		return nil
+	}
+
+	bootstrap := NewGitHubBootstrap(config, NewMockLogger(), &MockHTTPClient{}, fileSystem, executor, system)
+	if err := bootstrap.runAndMonitor(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if err := bootstrap.cleanup(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if !system.RebootCalled {
+		t.Fatal("Expected a shutdown to be attempted")
+	}
+	if got := exportedAtShutdown[logFile]; got != "Job build completed with result: Succeeded\n" {
+		t.Errorf("Expected the runner output in %s before shutdown, got %q", logFile, got)
+	}
+	var result CompletionResult
+	if err := json.Unmarshal([]byte(exportedAtShutdown[statusFile]), &result); err != nil {
+		t.Fatalf("Expected a JSON status in %s before shutdown, got %q: %v", statusFile, exportedAtShutdown[statusFile], err)
+	}
+	if result != (CompletionResult{RunnerName: "test-runner", Phase: PhaseCompleted}) {
+		t.Errorf("Unexpected exported status: %+v", result)
+	}
+
+	t.Run("export failure does not prevent shutdown", func(t *testing.T) {
+		fileSystem.MkdirAllFunc = func(path string, perm os.FileMode) error { return os.ErrPermission }
+		system.RebootCalled = false
+		if err := bootstrap.cleanup(context.Background()); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if !system.RebootCalled {
+			t.Error("Expected a shutdown to be attempted")
+		}
+	})
+
+	t.Run("no export path", func(t *testing.T) {
+		fileSystem := NewMockFileSystem()
+		config := &RunnerConfig{RunnerName: "test-runner"}
+		bootstrap := NewGitHubBootstrap(config, NewMockLogger(), &MockHTTPClient{}, fileSystem, NewMockCommandExecutor(), NewMockSystemOperations())
+		if err := bootstrap.cleanup(context.Background()); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if len(fileSystem.OpenedFiles) != 0 {
+			t.Errorf("Expected nothing to be exported, got %v", fileSystem.OpenedFiles)
+		}
+	})
+}
+
+func TestLogTail(t *testing.T) {
+	tail := newLogTail(8)
+	for _, chunk := range []string{"abc", "defg", "hijkl"} {
+		if n, err := tail.Write([]byte(chunk)); err != nil || n != len(chunk) {
+			t.Fatalf("Expected %d bytes written, got %d (%v)", len(chunk), n, err)
+		}
+	}
+	if got := tail.String(); got != "efghijkl" {
+		t.Errorf("Expected the last 8 bytes to be kept, got %q", got)
+	}
+}
+
+func TestRunRejectsInvalidShutdownMode(t *testing.T) {
+	config := &RunnerConfig{RunnerName: "test-runner", ShutdownMode: "halt"}
+	executor := NewMockCommandExecutor()
+
+	bootstrap := NewGitHubBootstrap(config, NewMockLogger(), &MockHTTPClient{}, NewMockFileSystem(), executor, NewMockSystemOperations())
+	err := bootstrap.Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), `invalid shutdown_mode "halt"`) {
+		t.Fatalf("Expected an invalid shutdown mode error, got %v", err)
+	}
+	if len(executor.ExecutedCommands) != 0 {
+		t.Errorf("Expected the bootstrap to stop before running anything, got %v", executor.ExecutedCommands)
+	}
+}
+
 func TestCleanupErrorHandling(t *testing.T) {
 	config := &RunnerConfig{}
 
@@ -1168,6 +1638,202 @@ func TestDownloadGitHubRunnerInvalidTarPath(t *testing.T) {
 	}
 }
 
+func TestDownloadGitHubRunnerExtractionLimits(t *testing.T) {
+	// Zeros compress well, as in a decompression bomb: 3 files of 1 KiB each
+	var buf bytes.Buffer
+	gzWriter := gzip.NewWriter(&buf)
+	tarWriter := tar.NewWriter(gzWriter)
+	for _, name := range []string{"bin/a", "bin/b", "bin/c"} {
+		_ = tarWriter.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: 1024, Typeflag: tar.TypeReg})
+		_, _ = tarWriter.Write(make([]byte, 1024))
+	}
+	_ = tarWriter.Close()
+	_ = gzWriter.Close()
+	archive := buf.Bytes()
+
+	tests := []struct {
+		name        string
+		maxTotal    int64
+		maxFile     int64
+		expectError string
+	}{
+		{name: "within limits", maxTotal: 3 * 1024, maxFile: 1024},
+		{name: "default limits"},
+		{name: "total size exceeded", maxTotal: 2 * 1024, maxFile: 1024, expectError: "archive exceeds the maximum extracted size of 2048 bytes"},
+		{name: "file size exceeded", maxTotal: 3 * 1024, maxFile: 1000, expectError: "archive file bin/a exceeds the maximum extracted file size of 1000 bytes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &RunnerConfig{}
+			config.Runner.InstallPath = testInstallPath
+			config.Runner.MaxExtractedBytes = tt.maxTotal
+			config.Runner.MaxExtractedFileBytes = tt.maxFile
+
+			httpClient := &MockHTTPClient{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(archive))}, nil
+				},
+			}
+			fileSystem := NewMockFileSystem()
+			bootstrap := NewGitHubBootstrap(config, NewMockLogger(), httpClient, fileSystem, NewMockCommandExecutor(), NewMockSystemOperations())
+
+			err := bootstrap.downloadGitHubRunner(context.Background())
+			if tt.expectError == "" {
+				if err != nil {
+					t.Fatalf("Expected archive to be extracted, got: %v", err)
+				}
+				if data := fileSystem.WrittenData[filepath.Join(testInstallPath, "bin/c")]; len(data) != 1024 {
+					t.Errorf("Expected bin/c to be fully extracted, got %d bytes", len(data))
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+				t.Errorf("Expected error containing %q, got: %v", tt.expectError, err)
+			}
+		})
+	}
+}
+
+func TestDownloadGitHubRunnerRestrictExtraction(t *testing.T) {
+	var buf bytes.Buffer
+	gzWriter := gzip.NewWriter(&buf)
+	tarWriter := tar.NewWriter(gzWriter)
+	_ = tarWriter.WriteHeader(&tar.Header{Name: "./", Mode: 0755, Typeflag: tar.TypeDir})
+	_ = tarWriter.WriteHeader(&tar.Header{Name: "./bin/", Mode: 0755, Typeflag: tar.TypeDir})
+	_ = tarWriter.WriteHeader(&tar.Header{Name: "./.hidden/", Mode: 0755, Typeflag: tar.TypeDir})
+	for _, name := range []string{"./bin/Runner.Listener", "./externals/node20/bin/node", "./config.sh", "./run.sh", "./.hidden/payload", "./bin.sh", "./cron.d"} {
+		_ = tarWriter.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: 4, Typeflag: tar.TypeReg})
+		_, _ = tarWriter.Write([]byte("data"))
+	}
+	_ = tarWriter.Close()
+	_ = gzWriter.Close()
+	archive := buf.Bytes()
+
+	expected := []string{"bin/Runner.Listener", "externals/node20/bin/node", "config.sh", "run.sh"}
+	unexpected := []string{".hidden/payload", "bin.sh", "cron.d"}
+
+	for _, restrict := range []bool{false, true} {
+		t.Run(fmt.Sprintf("restrict_extraction=%v", restrict), func(t *testing.T) {
+			config := &RunnerConfig{}
+			config.Runner.InstallPath = testInstallPath
+			config.Runner.RestrictExtraction = restrict
+
+			httpClient := &MockHTTPClient{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(archive))}, nil
+				},
+			}
+			fileSystem := NewMockFileSystem()
+			logger := NewMockLogger()
+			bootstrap := NewGitHubBootstrap(config, logger, httpClient, fileSystem, NewMockCommandExecutor(), NewMockSystemOperations())
+
+			if err := bootstrap.downloadGitHubRunner(context.Background()); err != nil {
+				t.Fatalf("Expected archive to be extracted, got: %v", err)
+			}
+			for _, name := range expected {
+				if _, ok := fileSystem.WrittenData[filepath.Join(testInstallPath, name)]; !ok {
+					t.Errorf("Expected %s to be extracted", name)
+				}
+			}
+			for _, name := range unexpected {
+				_, extracted := fileSystem.WrittenData[filepath.Join(testInstallPath, name)]
+				if extracted == restrict {
+					t.Errorf("Expected %s extracted=%v, got %v", name, !restrict, extracted)
+				}
+				warned := slices.ContainsFunc(logger.Messages, func(msg string) bool {
+					return strings.Contains(msg, "outside the runner layout: ./"+name)
+				})
+				if warned != restrict {
+					t.Errorf("Expected a warning for %s to be logged=%v, got %v", name, restrict, warned)
+				}
+			}
+		})
+	}
+}
+
+func TestInRunnerLayout(t *testing.T) {
+	settings := RunnerSettings{ConfigScript: "scripts/configure.sh"}
+	tests := []struct {
+		name     string
+		expected bool
+	}{
+		{name: "./", expected: true},
+		{name: "bin", expected: true},
+		{name: "./bin/Runner.Worker", expected: true},
+		{name: "externals/node20/bin/node", expected: true},
+		{name: "./run-helper.sh.template", expected: true},
+		{name: "scripts/configure.sh", expected: true},
+		{name: "scripts/other.sh", expected: false},
+		{name: "binaries/tool", expected: false},
+		{name: "etc/cron.d/job", expected: false},
+	}
+
+	for _, tt := range tests {
+		if got := inRunnerLayout(tt.name, settings); got != tt.expected {
+			t.Errorf("inRunnerLayout(%q) = %v, expected %v", tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestDownloadGitHubRunnerCache(t *testing.T) {
+	const cachePath = "/opt/hyperfleet-runner"
+	const mirrorURL = "https://mirror.example.com/runner.tar.gz"
+
+	tests := []struct {
+		name          string
+		marker        string // content of the cache marker, empty for no marker
+		downloadURL   string
+		version       string
+		expectCache   bool
+		expectInstall string
+	}{
+		{name: "cache hit", marker: "2.311.0\n", expectCache: true, expectInstall: cachePath},
+		{name: "cache hit with v prefix", marker: "v2.311.0", expectCache: true, expectInstall: cachePath},
+		{name: "cache miss", expectInstall: testInstallPath},
+		{name: "version mismatch", marker: "2.300.0", expectInstall: testInstallPath},
+		{name: "explicit version for mirror", marker: "2.320.0", downloadURL: mirrorURL, version: "2.320.0", expectCache: true, expectInstall: cachePath},
+		{name: "unknown version", marker: "2.320.0", downloadURL: mirrorURL, expectInstall: testInstallPath},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &RunnerConfig{}
+			config.Runner.InstallPath = testInstallPath
+			config.Runner.CachePath = cachePath
+			config.Runner.DownloadURL = tt.downloadURL
+			config.Runner.Version = tt.version
+			config.Runner.AllowedDownloadHosts = []string{"mirror.example.com"}
+
+			downloads := 0
+			httpClient := &MockHTTPClient{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					downloads++
+					return runnerArchiveResponse(), nil
+				},
+			}
+			fileSystem := NewMockFileSystem()
+			if tt.marker != "" {
+				fileSystem.WrittenData[filepath.Join(cachePath, RunnerCacheMarker)] = tt.marker
+			}
+			bootstrap := NewGitHubBootstrap(config, NewMockLogger(), httpClient, fileSystem, NewMockCommandExecutor(), NewMockSystemOperations())
+
+			if err := bootstrap.downloadGitHubRunner(context.Background()); err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			if tt.expectCache && downloads != 0 {
+				t.Errorf("Expected no download on a cache hit, got %d", downloads)
+			}
+			if !tt.expectCache && downloads != 1 {
+				t.Errorf("Expected the runner to be downloaded on a cache miss, got %d downloads", downloads)
+			}
+			if installPath := bootstrap.installPath(); installPath != tt.expectInstall {
+				t.Errorf("Expected runner installed at %s, got %s", tt.expectInstall, installPath)
+			}
+		})
+	}
+}
+
 func TestDownloadGitHubRunnerFileCreationError(t *testing.T) {
 	config := &RunnerConfig{}
 	config.Runner.InstallPath = testInstallPath
@@ -1200,9 +1866,12 @@ func TestDownloadGitHubRunnerFileCreationError(t *testing.T) {
 		},
 	}
 
-	// Test file creation failure
+	// Test file creation failure for extracted files
 	fileSystem := NewMockFileSystem()
 	fileSystem.OpenFileFunc = func(name string, flag int, perm os.FileMode) (io.WriteCloser, error) {
+		if strings.HasSuffix(name, PartialDownloadSuffix) {
+			return &MockWriteCloser{name: name, fs: fileSystem}, nil
+		}
 		return nil, fmt.Errorf("permission denied")
 	}
 	executor := NewMockCommandExecutor()
@@ -1486,6 +2155,33 @@ func TestBuildDownloadURLWithCustomURL(t *testing.T) {
 	}
 }
 
+func TestBuildDownloadURLWithVersion(t *testing.T) {
+	testCases := []struct {
+		name     string
+		version  string
+		expected string
+	}{
+		{"prefixed version", "v2.320.0", "https://github.com/actions/runner/releases/download/v2.320.0/actions-runner-linux-x64-2.320.0.tar.gz"},
+		{"unprefixed version", "2.320.0", "https://github.com/actions/runner/releases/download/v2.320.0/actions-runner-linux-x64-2.320.0.tar.gz"},
+		{"default version", "", "https://github.com/actions/runner/releases/download/v2.311.0/actions-runner-linux-x64-2.311.0.tar.gz"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := &RunnerConfig{}
+			config.Runner.OS = "linux"
+			config.Runner.Arch = "amd64"
+			config.Runner.Version = tc.version
+
+			bootstrap := NewGitHubBootstrap(config, NewMockLogger(), &MockHTTPClient{}, NewMockFileSystem(), NewMockCommandExecutor(), NewMockSystemOperations())
+
+			if url := bootstrap.buildDownloadURL(); url != tc.expected {
+				t.Errorf("Expected URL %s, got: %s", tc.expected, url)
+			}
+		})
+	}
+}
+
 func TestBuildDownloadURLWithDifferentArchitectures(t *testing.T) {
 	testCases := []struct {
 		os       string
@@ -1536,7 +2232,7 @@ func TestPerformSPIFFEAttestationMissingConfig(t *testing.T) {
 
 	bootstrap := NewGitHubBootstrap(config, logger, httpClient, fileSystem, executor, system)
 
-	err := bootstrap.performSPIFFEAttestation()
+	err := bootstrap.performSPIFFEAttestation(context.Background())
 
 	if err == nil {
 		t.Error("Expected error due to missing SPIFFE configuration")
@@ -1769,6 +2465,9 @@ func TestDownloadGitHubRunnerFileCopyError(t *testing.T) {
 	// Mock file that fails to write
 	fileSystem := NewMockFileSystem()
 	fileSystem.OpenFileFunc = func(name string, flag int, perm os.FileMode) (io.WriteCloser, error) {
+		if strings.HasSuffix(name, PartialDownloadSuffix) {
+			return &MockWriteCloser{name: name, fs: fileSystem}, nil
+		}
 		return &FailingWriteCloser{}, nil
 	}
 	executor := NewMockCommandExecutor()
@@ -1822,6 +2521,9 @@ func TestDownloadGitHubRunnerFileCloseError(t *testing.T) {
 	// Mock file that fails to close
 	fileSystem := NewMockFileSystem()
 	fileSystem.OpenFileFunc = func(name string, flag int, perm os.FileMode) (io.WriteCloser, error) {
+		if strings.HasSuffix(name, PartialDownloadSuffix) {
+			return &MockWriteCloser{name: name, fs: fileSystem}, nil
+		}
 		return &FailingCloseWriteCloser{}, nil
 	}
 	executor := NewMockCommandExecutor()
@@ -1918,3 +2620,1632 @@ func (f *FailingCloseWriteCloser) Write(p []byte) (n int, err error) {
 func (f *FailingCloseWriteCloser) Close() error {
 	return fmt.Errorf("close failed")
 }
+
+func TestRunAndMonitorPersistentCleansWorkDirBetweenJobs(t *testing.T) {
+	ephemeral := false
+	config := &RunnerConfig{}
+	config.Runner.InstallPath = testInstallPath
+	config.Runner.WorkDir = testWorkDir
+	config.Runner.Ephemeral = &ephemeral
+	config.Runner.CleanWorkDir = true
+
+	logger := NewMockLogger()
+	httpClient := &MockHTTPClient{}
+	fileSystem := NewMockFileSystem()
+	system := NewMockSystemOperations()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	const jobs = 3
+	jobsRun := 0
+	executor := NewMockCommandExecutor()
+	executor.CommandContextFunc = func(ctx context.Context, name string, args ...string) Command {
+		return &MockCommand{
+			name:     name,
+			args:     args,
+			executor: executor,
+			RunFunc: func() error {
+				// The work dir must be untouched while a job is running
+				if len(fileSystem.RemovedPaths) != jobsRun {
+					t.Errorf("Job %d: expected work dir to be cleaned %d times, got %d",
+						jobsRun+1, jobsRun, len(fileSystem.RemovedPaths))
+				}
+				jobsRun++
+				if jobsRun == jobs {
+					cancel()
+				}
+				return nil
+			},
+		}
+	}
+
+	bootstrap := NewGitHubBootstrap(config, logger, httpClient, fileSystem, executor, system)
+
+	if err := bootstrap.runAndMonitor(ctx); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if len(executor.ExecutedCommands) != jobs {
+		t.Fatalf("Expected %d job runs, got %d", jobs, len(executor.ExecutedCommands))
+	}
+	for _, cmd := range executor.ExecutedCommands {
+		if cmd.Name != testRunScript {
+			t.Errorf("Expected command '%s', got '%s'", testRunScript, cmd.Name)
+		}
+		if len(cmd.Args) != 1 || cmd.Args[0] != "--once" {
+			t.Errorf("Expected persistent runner to run with --once, got %v", cmd.Args)
+		}
+	}
+
+	// Work dir is cleared and recreated after every job
+	if len(fileSystem.RemovedPaths) != jobs {
+		t.Errorf("Expected work dir to be cleaned %d times, got %d", jobs, len(fileSystem.RemovedPaths))
+	}
+	for _, path := range fileSystem.RemovedPaths {
+		if path != testWorkDir {
+			t.Errorf("Expected only work dir to be removed, got %s", path)
+		}
+	}
+	if len(fileSystem.CreatedDirs) != jobs {
+		t.Errorf("Expected work dir to be recreated %d times, got %d", jobs, len(fileSystem.CreatedDirs))
+	}
+}
+
+func TestRunAndMonitorPersistentWithoutCleanWorkDir(t *testing.T) {
+	ephemeral := false
+	config := &RunnerConfig{}
+	config.Runner.InstallPath = testInstallPath
+	config.Runner.WorkDir = testWorkDir
+	config.Runner.Ephemeral = &ephemeral
+
+	logger := NewMockLogger()
+	httpClient := &MockHTTPClient{}
+	fileSystem := NewMockFileSystem()
+	system := NewMockSystemOperations()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	jobsRun := 0
+	executor := NewMockCommandExecutor()
+	executor.CommandContextFunc = func(ctx context.Context, name string, args ...string) Command {
+		return &MockCommand{
+			name:     name,
+			args:     args,
+			executor: executor,
+			RunFunc: func() error {
+				jobsRun++
+				if jobsRun == 2 {
+					cancel()
+				}
+				return nil
+			},
+		}
+	}
+
+	bootstrap := NewGitHubBootstrap(config, logger, httpClient, fileSystem, executor, system)
+
+	if err := bootstrap.runAndMonitor(ctx); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if len(executor.ExecutedCommands) != 2 {
+		t.Errorf("Expected 2 job runs, got %d", len(executor.ExecutedCommands))
+	}
+	if len(fileSystem.RemovedPaths) != 0 {
+		t.Errorf("Expected work dir to be preserved, got removals: %v", fileSystem.RemovedPaths)
+	}
+}
+
+func TestRunAndMonitorPersistentCleanWorkDirError(t *testing.T) {
+	ephemeral := false
+	config := &RunnerConfig{}
+	config.Runner.WorkDir = testWorkDir
+	config.Runner.Ephemeral = &ephemeral
+	config.Runner.CleanWorkDir = true
+
+	logger := NewMockLogger()
+	httpClient := &MockHTTPClient{}
+	fileSystem := NewMockFileSystem()
+	fileSystem.RemoveAllFunc = func(path string) error {
+		return fmt.Errorf("permission denied")
+	}
+	executor := NewMockCommandExecutor()
+	system := NewMockSystemOperations()
+
+	bootstrap := NewGitHubBootstrap(config, logger, httpClient, fileSystem, executor, system)
+
+	err := bootstrap.runAndMonitor(context.Background())
+	if err == nil {
+		t.Fatal("Expected error when the work dir cannot be cleaned")
+	}
+	if !strings.Contains(err.Error(), "failed to clean work dir") {
+		t.Errorf("Expected clean work dir error, got: %v", err)
+	}
+
+	// A dirty work dir must never be handed to another job
+	if len(executor.ExecutedCommands) != 1 {
+		t.Errorf("Expected 1 job run, got %d", len(executor.ExecutedCommands))
+	}
+}
+
+func TestConfigureRunnerEphemeralFlag(t *testing.T) {
+	persistent := false
+	ephemeral := true
+
+	tests := []struct {
+		name            string
+		ephemeral       *bool
+		expectEphemeral bool
+	}{
+		{name: "default is ephemeral", ephemeral: nil, expectEphemeral: true},
+		{name: "explicitly ephemeral", ephemeral: &ephemeral, expectEphemeral: true},
+		{name: "persistent", ephemeral: &persistent, expectEphemeral: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &RunnerConfig{}
+			config.Runner.Ephemeral = tt.ephemeral
+
+			executor := NewMockCommandExecutor()
+			bootstrap := NewGitHubBootstrap(config, NewMockLogger(), &MockHTTPClient{},
+				NewMockFileSystem(), executor, NewMockSystemOperations())
+
+			if err := bootstrap.configureRunner(context.Background()); err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+
+			hasFlag := false
+			for _, arg := range executor.ExecutedCommands[0].Args {
+				if arg == "--ephemeral" {
+					hasFlag = true
+				}
+			}
+			if hasFlag != tt.expectEphemeral {
+				t.Errorf("Expected --ephemeral present=%v, got %v", tt.expectEphemeral, hasFlag)
+			}
+		})
+	}
+}
+
+func TestConfigureRunnerReplaceFlag(t *testing.T) {
+	for _, replace := range []bool{false, true} {
+		t.Run(fmt.Sprintf("replace_existing=%v", replace), func(t *testing.T) {
+			config := &RunnerConfig{}
+			config.Runner.ReplaceExisting = replace
+
+			executor := NewMockCommandExecutor()
+			bootstrap := NewGitHubBootstrap(config, NewMockLogger(), &MockHTTPClient{},
+				NewMockFileSystem(), executor, NewMockSystemOperations())
+
+			if err := bootstrap.configureRunner(context.Background()); err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+
+			if hasFlag := slices.Contains(executor.ExecutedCommands[0].Args, "--replace"); hasFlag != replace {
+				t.Errorf("Expected --replace present=%v, got %v", replace, hasFlag)
+			}
+		})
+	}
+}
+
+func TestConfigureRunnerGroupFlag(t *testing.T) {
+	for _, group := range []string{"", "linux-fleet"} {
+		t.Run(fmt.Sprintf("runner_group=%q", group), func(t *testing.T) {
+			config := &RunnerConfig{RunnerGroup: group}
+
+			executor := NewMockCommandExecutor()
+			bootstrap := NewGitHubBootstrap(config, NewMockLogger(), &MockHTTPClient{},
+				NewMockFileSystem(), executor, NewMockSystemOperations())
+
+			if err := bootstrap.configureRunner(context.Background()); err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+
+			args := executor.ExecutedCommands[0].Args
+			index := slices.Index(args, "--runnergroup")
+			if group == "" {
+				if index >= 0 {
+					t.Errorf("Expected no --runnergroup, got %v", args)
+				}
+				return
+			}
+			if index < 0 || index+1 >= len(args) || args[index+1] != group {
+				t.Errorf("Expected --runnergroup %s, got %v", group, args)
+			}
+		})
+	}
+}
+
+func TestValidateScripts(t *testing.T) {
+	tests := []struct {
+		name        string
+		present     bool
+		run         func(bootstrap *GitHubBootstrap) error
+		script      string
+		expectedErr string
+	}{
+		{
+			name:        "missing config script",
+			run:         func(bootstrap *GitHubBootstrap) error { return bootstrap.configureRunner(context.Background()) },
+			script:      "/opt/runner/config.sh",
+			expectedErr: "config script not found at /opt/runner/config.sh",
+		},
+		{
+			name:    "present config script",
+			present: true,
+			run:     func(bootstrap *GitHubBootstrap) error { return bootstrap.configureRunner(context.Background()) },
+			script:  "/opt/runner/config.sh",
+		},
+		{
+			name:        "missing run script",
+			run:         func(bootstrap *GitHubBootstrap) error { return bootstrap.runAndMonitor(context.Background()) },
+			script:      "/opt/runner/run.sh",
+			expectedErr: "run script not found at /opt/runner/run.sh",
+		},
+		{
+			name:    "present run script",
+			present: true,
+			run:     func(bootstrap *GitHubBootstrap) error { return bootstrap.runAndMonitor(context.Background()) },
+			script:  "/opt/runner/run.sh",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &RunnerConfig{}
+			config.Runner.InstallPath = "/opt/runner"
+			config.Runner.ValidateScripts = true
+
+			fileSystem := NewMockFileSystem()
+			if tt.present {
+				fileSystem.WrittenData[tt.script] = "#!/bin/bash"
+			}
+			executor := NewMockCommandExecutor()
+			bootstrap := NewGitHubBootstrap(config, NewMockLogger(), &MockHTTPClient{}, fileSystem, executor, NewMockSystemOperations())
+
+			err := tt.run(bootstrap)
+			if tt.expectedErr != "" {
+				if err == nil || err.Error() != tt.expectedErr {
+					t.Fatalf("Expected error %q, got: %v", tt.expectedErr, err)
+				}
+				if len(executor.ExecutedCommands) != 0 {
+					t.Errorf("Expected no script to run, got %v", executor.ExecutedCommands)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			if len(executor.ExecutedCommands) == 0 || executor.ExecutedCommands[0].Name != tt.script {
+				t.Errorf("Expected %s to run, got %v", tt.script, executor.ExecutedCommands)
+			}
+		})
+	}
+}
+
+// flakyReader returns data up to limit bytes and then fails, simulating a dropped connection
+type flakyReader struct {
+	data  []byte
+	limit int
+	read  int
+}
+
+func (f *flakyReader) Read(p []byte) (int, error) {
+	if f.read >= f.limit {
+		return 0, fmt.Errorf("connection reset by peer")
+	}
+	end := f.limit
+	if end > len(f.data) {
+		end = len(f.data)
+	}
+	n := copy(p, f.data[f.read:end])
+	f.read += n
+	return n, nil
+}
+
+func TestDownloadGitHubRunnerResume(t *testing.T) {
+	// Build a runner archive with a single file
+	var archive bytes.Buffer
+	gzWriter := gzip.NewWriter(&archive)
+	tarWriter := tar.NewWriter(gzWriter)
+	content := strings.Repeat("runner-binary-", 256)
+	_ = tarWriter.WriteHeader(&tar.Header{Name: "run.sh", Mode: 0755, Size: int64(len(content))})
+	_, _ = tarWriter.Write([]byte(content))
+	_ = tarWriter.Close()
+	_ = gzWriter.Close()
+	archiveBytes := archive.Bytes()
+	half := len(archiveBytes) / 2
+
+	tests := []struct {
+		name          string
+		acceptRanges  bool
+		honorRange    bool
+		expectRange   string
+		expectResumed bool
+	}{
+		{
+			name:          "server supports ranges resumes download",
+			acceptRanges:  true,
+			honorRange:    true,
+			expectRange:   fmt.Sprintf("bytes=%d-", half),
+			expectResumed: true,
+		},
+		{
+			name:         "server without range support restarts download",
+			acceptRanges: false,
+			expectRange:  "",
+		},
+		{
+			name:         "server ignoring range request restarts download",
+			acceptRanges: true,
+			honorRange:   false,
+			expectRange:  fmt.Sprintf("bytes=%d-", half),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &RunnerConfig{}
+			config.Runner.InstallPath = testInstallPath
+			config.Runner.DownloadURL = "https://example.com/runner.tar.gz"
+			config.Runner.AllowedDownloadHosts = []string{"example.com"}
+
+			var rangeHeaders []string
+			httpClient := &MockHTTPClient{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					rangeHeaders = append(rangeHeaders, req.Header.Get("Range"))
+
+					header := http.Header{}
+					if tt.acceptRanges {
+						header.Set("Accept-Ranges", "bytes")
+					}
+
+					// First request drops halfway through the transfer
+					if len(rangeHeaders) == 1 {
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Header:     header,
+							Body:       io.NopCloser(&flakyReader{data: archiveBytes, limit: half}),
+						}, nil
+					}
+
+					if req.Header.Get("Range") != "" && tt.honorRange {
+						return &http.Response{
+							StatusCode: http.StatusPartialContent,
+							Header:     header,
+							Body:       io.NopCloser(bytes.NewReader(archiveBytes[half:])),
+						}, nil
+					}
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Header:     header,
+						Body:       io.NopCloser(bytes.NewReader(archiveBytes)),
+					}, nil
+				},
+			}
+			logger := NewMockLogger()
+			fileSystem := NewMockFileSystem()
+			system := NewMockSystemOperations()
+
+			bootstrap := NewGitHubBootstrap(config, logger, httpClient, fileSystem, NewMockCommandExecutor(), system)
+
+			if err := bootstrap.downloadGitHubRunner(context.Background()); err != nil {
+				t.Fatalf("Expected download to succeed after retry, got: %v", err)
+			}
+
+			if len(rangeHeaders) != 2 {
+				t.Fatalf("Expected 2 requests, got %d", len(rangeHeaders))
+			}
+			if rangeHeaders[0] != "" {
+				t.Errorf("Expected first request without Range header, got %q", rangeHeaders[0])
+			}
+			if rangeHeaders[1] != tt.expectRange {
+				t.Errorf("Expected retry Range header %q, got %q", tt.expectRange, rangeHeaders[1])
+			}
+
+			archivePath := testInstallPath + PartialDownloadSuffix
+			if fileSystem.WrittenData[archivePath] != string(archiveBytes) {
+				t.Errorf("Expected downloaded archive to match original (%d bytes), got %d bytes",
+					len(archiveBytes), len(fileSystem.WrittenData[archivePath]))
+			}
+			if fileSystem.WrittenData[filepath.Join(testInstallPath, "run.sh")] != content {
+				t.Error("Expected runner archive to be extracted")
+			}
+
+			resumed := false
+			for _, msg := range logger.Messages {
+				if strings.Contains(msg, "Resuming download") {
+					resumed = true
+				}
+			}
+			if resumed != tt.expectResumed {
+				t.Errorf("Expected resumed=%v, got %v", tt.expectResumed, resumed)
+			}
+
+			if !system.SleepCalled || system.SleepDuration != DownloadRetryDelaySeconds {
+				t.Errorf("Expected retry delay of %d seconds", DownloadRetryDelaySeconds)
+			}
+		})
+	}
+}
+
+func TestDownloadGitHubRunnerRetriesExhausted(t *testing.T) {
+	config := &RunnerConfig{}
+	config.Runner.InstallPath = testInstallPath
+
+	requests := 0
+	httpClient := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			requests++
+			return nil, fmt.Errorf("network error")
+		},
+	}
+
+	bootstrap := NewGitHubBootstrap(config, NewMockLogger(), httpClient, NewMockFileSystem(),
+		NewMockCommandExecutor(), NewMockSystemOperations())
+
+	err := bootstrap.downloadGitHubRunner(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "network error") {
+		t.Errorf("Expected network error, got: %v", err)
+	}
+	if requests != DownloadMaxAttempts {
+		t.Errorf("Expected %d attempts, got %d", DownloadMaxAttempts, requests)
+	}
+}
+
+func TestDownloadGitHubRunnerHTTPStatusNotRetried(t *testing.T) {
+	config := &RunnerConfig{}
+	config.Runner.InstallPath = testInstallPath
+
+	requests := 0
+	httpClient := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			requests++
+			return &http.Response{
+				StatusCode: http.StatusNotFound,
+				Body:       io.NopCloser(strings.NewReader("")),
+			}, nil
+		},
+	}
+
+	bootstrap := NewGitHubBootstrap(config, NewMockLogger(), httpClient, NewMockFileSystem(),
+		NewMockCommandExecutor(), NewMockSystemOperations())
+
+	if err := bootstrap.downloadGitHubRunner(context.Background()); err == nil {
+		t.Error("Expected error due to HTTP 404")
+	}
+	if requests != 1 {
+		t.Errorf("Expected HTTP status errors not to be retried, got %d requests", requests)
+	}
+}
+
+func TestDownloadGitHubRunnerAttestation(t *testing.T) {
+	var archive bytes.Buffer
+	gzWriter := gzip.NewWriter(&archive)
+	tarWriter := tar.NewWriter(gzWriter)
+	_ = tarWriter.WriteHeader(&tar.Header{Name: "run.sh", Mode: 0755, Size: 4})
+	_, _ = tarWriter.Write([]byte("test"))
+	_ = tarWriter.Close()
+	_ = gzWriter.Close()
+	archiveBytes := archive.Bytes()
+
+	tests := []struct {
+		name          string
+		enabled       bool
+		verifyErr     error
+		expectError   bool
+		expectVerify  bool
+		expectExtract bool
+	}{
+		{
+			name:          "valid attestation proceeds",
+			enabled:       true,
+			expectVerify:  true,
+			expectExtract: true,
+		},
+		{
+			name:         "invalid attestation fails",
+			enabled:      true,
+			verifyErr:    fmt.Errorf("signature does not match"),
+			expectError:  true,
+			expectVerify: true,
+		},
+		{
+			name:         "missing attestation fails",
+			enabled:      true,
+			verifyErr:    fmt.Errorf("no attestations found"),
+			expectError:  true,
+			expectVerify: true,
+		},
+		{
+			name:          "verification disabled",
+			enabled:       false,
+			expectExtract: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &RunnerConfig{}
+			config.Runner.InstallPath = testInstallPath
+			config.Runner.VerifyAttestation = tt.enabled
+
+			httpClient := &MockHTTPClient{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body:       io.NopCloser(bytes.NewReader(archiveBytes)),
+					}, nil
+				},
+			}
+			fileSystem := NewMockFileSystem()
+			verifier := &MockAttestationVerifier{
+				VerifyFunc: func(ctx context.Context, artifactPath string) error {
+					return tt.verifyErr
+				},
+			}
+
+			bootstrap := NewGitHubBootstrap(config, NewMockLogger(), httpClient, fileSystem,
+				NewMockCommandExecutor(), NewMockSystemOperations())
+			bootstrap.verifier = verifier
+
+			err := bootstrap.downloadGitHubRunner(context.Background())
+
+			if tt.expectError {
+				if err == nil || !strings.Contains(err.Error(), "failed to verify runner attestation") {
+					t.Errorf("Expected attestation error, got: %v", err)
+				}
+			} else if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+
+			if verified := len(verifier.VerifiedPaths) > 0; verified != tt.expectVerify {
+				t.Errorf("Expected verify called=%v, got %v", tt.expectVerify, verified)
+			}
+			if tt.expectVerify && verifier.VerifiedPaths[0] != testInstallPath+PartialDownloadSuffix {
+				t.Errorf("Expected downloaded archive to be verified, got %s", verifier.VerifiedPaths[0])
+			}
+
+			_, extracted := fileSystem.WrittenData[filepath.Join(testInstallPath, "run.sh")]
+			if extracted != tt.expectExtract {
+				t.Errorf("Expected extracted=%v, got %v", tt.expectExtract, extracted)
+			}
+		})
+	}
+}
+
+func TestDownloadGitHubRunnerAttestationWithoutVerifier(t *testing.T) {
+	config := &RunnerConfig{}
+	config.Runner.InstallPath = testInstallPath
+	config.Runner.VerifyAttestation = true
+
+	bootstrap := NewGitHubBootstrap(config, NewMockLogger(), &MockHTTPClient{}, NewMockFileSystem(),
+		NewMockCommandExecutor(), NewMockSystemOperations())
+	bootstrap.verifier = nil
+
+	err := bootstrap.downloadGitHubRunner(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "no verifier is configured") {
+		t.Errorf("Expected missing verifier error, got: %v", err)
+	}
+}
+
+func TestDownloadGitHubRunnerVerifiesArch(t *testing.T) {
+	otherArch := "arm64"
+	if runtime.GOARCH == otherArch {
+		otherArch = "amd64"
+	}
+
+	tests := []struct {
+		name        string
+		enabled     bool
+		detector    *MockArchDetector
+		expectError string
+	}{
+		{
+			name:     "matching architecture",
+			enabled:  true,
+			detector: &MockArchDetector{Arch: runtime.GOARCH},
+		},
+		{
+			name:        "mismatched architecture",
+			enabled:     true,
+			detector:    &MockArchDetector{Arch: otherArch},
+			expectError: fmt.Sprintf("is built for %s but this VM is %s", otherArch, runtime.GOARCH),
+		},
+		{
+			name:        "undetectable architecture",
+			enabled:     true,
+			detector:    &MockArchDetector{Err: fmt.Errorf("not an ELF file")},
+			expectError: "failed to detect runner architecture",
+		},
+		{
+			name:     "check disabled",
+			detector: &MockArchDetector{Arch: otherArch},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &RunnerConfig{}
+			config.Runner.InstallPath = testInstallPath
+			config.Runner.VerifyArch = tt.enabled
+
+			httpClient := &MockHTTPClient{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					return runnerArchiveResponse(), nil
+				},
+			}
+			bootstrap := NewGitHubBootstrap(config, NewMockLogger(), httpClient, NewMockFileSystem(),
+				NewMockCommandExecutor(), NewMockSystemOperations())
+			bootstrap.archDetector = tt.detector
+
+			err := bootstrap.downloadGitHubRunner(context.Background())
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Errorf("Expected error containing %q, got: %v", tt.expectError, err)
+				}
+			} else if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+
+			if checked := len(tt.detector.DetectedPaths) > 0; checked != tt.enabled {
+				t.Fatalf("Expected architecture checked=%v, got %v", tt.enabled, checked)
+			}
+			if tt.enabled && tt.detector.DetectedPaths[0] != filepath.Join(testInstallPath, RunnerArchProbe) {
+				t.Errorf("Expected %s to be checked, got %s", RunnerArchProbe, tt.detector.DetectedPaths[0])
+			}
+		})
+	}
+}
+
+func TestELFArchDetector(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the test binary is only an ELF file on Linux")
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatalf("Failed to locate test binary: %v", err)
+	}
+
+	arch, err := ELFArchDetector{}.DetectArch(executable)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if arch != runtime.GOARCH {
+		t.Errorf("Expected %s, got %s", runtime.GOARCH, arch)
+	}
+
+	script := filepath.Join(t.TempDir(), "run.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/bash\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write script: %v", err)
+	}
+	if _, err := (ELFArchDetector{}).DetectArch(script); err == nil {
+		t.Errorf("Expected error for a non-ELF file")
+	}
+}
+
+func TestGHAttestationVerifier(t *testing.T) {
+	executor := NewMockCommandExecutor()
+	verifier := NewGHAttestationVerifier(executor, RunnerAttestationRepo)
+
+	if err := verifier.Verify(context.Background(), "/tmp/runner.tar.gz"); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if len(executor.ExecutedCommands) != 1 {
+		t.Fatalf("Expected 1 command, got %d", len(executor.ExecutedCommands))
+	}
+	cmd := executor.ExecutedCommands[0]
+	expectedArgs := []string{"attestation", "verify", "/tmp/runner.tar.gz", "--repo", "actions/runner"}
+	if cmd.Name != "gh" || strings.Join(cmd.Args, " ") != strings.Join(expectedArgs, " ") {
+		t.Errorf("Expected 'gh %v', got '%s %v'", expectedArgs, cmd.Name, cmd.Args)
+	}
+
+	// A failing gh command must fail verification
+	failing := NewMockCommandExecutor()
+	failing.CommandContextFunc = func(ctx context.Context, name string, args ...string) Command {
+		return &MockCommand{
+			name:     name,
+			args:     args,
+			executor: failing,
+			RunFunc: func() error {
+				return fmt.Errorf("exit status 1")
+			},
+		}
+	}
+	if err := NewGHAttestationVerifier(failing, RunnerAttestationRepo).Verify(context.Background(), "/tmp/runner.tar.gz"); err == nil {
+		t.Error("Expected verification to fail when gh exits non-zero")
+	}
+}
+
+func TestConfigureRunnerRetry(t *testing.T) {
+	tests := []struct {
+		name             string
+		failures         int
+		output           string
+		maxAttempts      int
+		expectError      string
+		expectAttempts   int
+		expectSleepCalls []int
+	}{
+		{
+			name:             "transient failure then success",
+			failures:         1,
+			output:           "Http response code: InternalServerError from 'POST https://api.github.com/actions/runner-registration'",
+			expectAttempts:   2,
+			expectSleepCalls: []int{ConfigureRetryDelaySeconds},
+		},
+		{
+			name:           "invalid token is not retried",
+			failures:       ConfigureMaxAttempts,
+			output:         "Http response code: NotFound from 'POST https://api.github.com/actions/runner-registration'",
+			expectError:    "failed permanently",
+			expectAttempts: 1,
+		},
+		{
+			name:             "retries exhausted with backoff",
+			failures:         4,
+			maxAttempts:      4,
+			expectError:      "failed after 4 attempts",
+			expectAttempts:   4,
+			expectSleepCalls: []int{ConfigureRetryDelaySeconds, ConfigureRetryDelaySeconds * 2, ConfigureRetryDelaySeconds * 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &RunnerConfig{
+				Method:          runnerTokenMethod,
+				RunnerToken:     "test-token",
+				RegistrationURL: "https://github.com/test/repo",
+				RunnerName:      "test-runner",
+				Runner:          RunnerSettings{ConfigureMaxAttempts: tt.maxAttempts},
+			}
+
+			executor := NewMockCommandExecutor()
+			attempts := 0
+			executor.CommandContextFunc = func(ctx context.Context, name string, args ...string) Command {
+				attempts++
+				cmd := &MockCommand{name: name, args: args, executor: executor}
+				if attempts <= tt.failures {
+					cmd.Output = tt.output
+					cmd.RunFunc = func() error { return errors.New("exit status 1") }
+				}
+				return cmd
+			}
+
+			var sleeps []int
+			system := NewMockSystemOperations()
+			system.SleepFunc = func(duration int) { sleeps = append(sleeps, duration) }
+
+			bootstrap := NewGitHubBootstrap(config, NewMockLogger(), &MockHTTPClient{}, NewMockFileSystem(), executor, system)
+			err := bootstrap.configureRunner(context.Background())
+
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Errorf("Expected error containing %q, got: %v", tt.expectError, err)
+				}
+			} else if err != nil {
+				t.Errorf("Expected no error, got: %v", err)
+			}
+
+			if attempts != tt.expectAttempts {
+				t.Errorf("Expected %d attempts, got %d", tt.expectAttempts, attempts)
+			}
+			if len(sleeps) != len(tt.expectSleepCalls) {
+				t.Fatalf("Expected sleeps %v, got %v", tt.expectSleepCalls, sleeps)
+			}
+			for i := range sleeps {
+				if sleeps[i] != tt.expectSleepCalls[i] {
+					t.Errorf("Sleep %d: expected %ds, got %ds", i, tt.expectSleepCalls[i], sleeps[i])
+				}
+			}
+		})
+	}
+}
+
+func TestIsRetryableRegistrationError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		output   string
+		expected bool
+	}{
+		{"generic failure", errors.New("exit status 1"), "", true},
+		{"server error", errors.New("exit status 1"), "Http response code: ServiceUnavailable", true},
+		{"invalid token", errors.New("exit status 1"), "Http response code: NotFound from 'POST https://api.github.com/actions/runner-registration'", false},
+		{"already configured", errors.New("exit status 1"), "Cannot configure the runner because it is already configured.", false},
+		{"missing script", fmt.Errorf("fork/exec config.sh: %w", os.ErrNotExist), "", false},
+		{"context cancelled", context.Canceled, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryableRegistrationError(tt.err, tt.output); got != tt.expected {
+				t.Errorf("isRetryableRegistrationError() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDownloadGitHubRunnerModeOverrides(t *testing.T) {
+	// Archive with an overly permissive directory, a restrictive script and a world-writable file
+	var buf bytes.Buffer
+	gzWriter := gzip.NewWriter(&buf)
+	tarWriter := tar.NewWriter(gzWriter)
+	_ = tarWriter.WriteHeader(&tar.Header{Name: "bin/", Typeflag: tar.TypeDir, Mode: 0777})
+	_ = tarWriter.WriteHeader(&tar.Header{Name: "config.sh", Typeflag: tar.TypeReg, Mode: 0700, Size: 2})
+	_, _ = tarWriter.Write([]byte("#!"))
+	_ = tarWriter.WriteHeader(&tar.Header{Name: "README", Typeflag: tar.TypeReg, Mode: 0666, Size: 2})
+	_, _ = tarWriter.Write([]byte("hi"))
+	_ = tarWriter.Close()
+	_ = gzWriter.Close()
+	archive := buf.Bytes()
+
+	tests := []struct {
+		name       string
+		settings   RunnerSettings
+		expectDir  os.FileMode
+		expectExec os.FileMode
+		expectFile os.FileMode
+	}{
+		{
+			name:       "archive modes by default",
+			expectDir:  0777,
+			expectExec: 0700,
+			expectFile: 0666,
+		},
+		{
+			name:       "all overrides",
+			settings:   RunnerSettings{DirMode: "0755", FileMode: "0644", ExecFileMode: "0755"},
+			expectDir:  0755,
+			expectExec: 0755,
+			expectFile: 0644,
+		},
+		{
+			name:       "file mode without exec mode applies to every file",
+			settings:   RunnerSettings{FileMode: "0640"},
+			expectDir:  0777,
+			expectExec: 0640,
+			expectFile: 0640,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &RunnerConfig{Runner: tt.settings}
+			config.Runner.InstallPath = testInstallPath
+
+			httpClient := &MockHTTPClient{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					return &http.Response{StatusCode: 200, Body: io.NopCloser(bytes.NewReader(archive))}, nil
+				},
+			}
+
+			modes := make(map[string]os.FileMode)
+			fileSystem := NewMockFileSystem()
+			fileSystem.MkdirAllFunc = func(path string, perm os.FileMode) error {
+				modes[path] = perm
+				return nil
+			}
+			fileSystem.OpenFileFunc = func(name string, flag int, perm os.FileMode) (io.WriteCloser, error) {
+				modes[name] = perm
+				return &MockWriteCloser{name: name, fs: fileSystem}, nil
+			}
+
+			bootstrap := NewGitHubBootstrap(config, NewMockLogger(), httpClient, fileSystem, NewMockCommandExecutor(), NewMockSystemOperations())
+			if err := bootstrap.downloadGitHubRunner(context.Background()); err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+
+			expected := map[string]os.FileMode{
+				filepath.Join(testInstallPath, "bin"):       tt.expectDir,
+				filepath.Join(testInstallPath, "config.sh"): tt.expectExec,
+				filepath.Join(testInstallPath, "README"):    tt.expectFile,
+			}
+			for path, mode := range expected {
+				if modes[path] != mode {
+					t.Errorf("Expected mode %o for %s, got %o", mode, path, modes[path])
+				}
+			}
+		})
+	}
+}
+
+func TestParseExtractModes(t *testing.T) {
+	modes, err := parseExtractModes(RunnerSettings{DirMode: "755", FileMode: "0644"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if modes.dir != 0755 || modes.file != 0644 || modes.execFile != 0 {
+		t.Errorf("Unexpected modes: %+v", modes)
+	}
+
+	for _, value := range []string{"rwxr-xr-x", "0999", "01777", "0"} {
+		if _, err := parseExtractModes(RunnerSettings{DirMode: value}); err == nil {
+			t.Errorf("Expected error for dir_mode %q", value)
+		}
+	}
+}
+
+func TestDownloadGitHubRunnerHostAllowlist(t *testing.T) {
+	tests := []struct {
+		name         string
+		downloadURL  string
+		allowedHosts []string
+		expectError  string
+	}{
+		{
+			name: "default github download",
+		},
+		{
+			name:         "configured mirror",
+			downloadURL:  "https://mirror.internal.example:8443/actions-runner.tar.gz",
+			allowedHosts: []string{"Mirror.Internal.Example"},
+		},
+		{
+			name:        "host not on allowlist",
+			downloadURL: "https://attacker.example.com/actions-runner.tar.gz",
+			expectError: `download host "attacker.example.com" is not allowed`,
+		},
+		{
+			name:         "lookalike of allowed host",
+			downloadURL:  "https://github.com.attacker.example/actions-runner.tar.gz",
+			allowedHosts: []string{"mirror.internal.example"},
+			expectError:  "is not allowed",
+		},
+		{
+			name:        "URL without host",
+			downloadURL: "file:///tmp/actions-runner.tar.gz",
+			expectError: "invalid download URL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &RunnerConfig{}
+			config.Runner.InstallPath = testInstallPath
+			config.Runner.DownloadURL = tt.downloadURL
+			config.Runner.AllowedDownloadHosts = tt.allowedHosts
+
+			var requested []string
+			httpClient := &MockHTTPClient{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					requested = append(requested, req.URL.String())
+					return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader(""))}, nil
+				},
+			}
+
+			bootstrap := NewGitHubBootstrap(config, NewMockLogger(), httpClient, NewMockFileSystem(), NewMockCommandExecutor(), NewMockSystemOperations())
+			err := bootstrap.downloadGitHubRunner(context.Background())
+
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Errorf("Expected error containing %q, got: %v", tt.expectError, err)
+				}
+				if len(requested) != 0 {
+					t.Errorf("Expected no download request, got %v", requested)
+				}
+				return
+			}
+
+			// Allowed hosts reach the HTTP client; the mocked 404 then fails the download
+			if len(requested) != 1 {
+				t.Errorf("Expected one download request, got %v", requested)
+			}
+		})
+	}
+}
+
+func TestRealFileSystemWithRoot(t *testing.T) {
+	root := t.TempDir()
+	fs := NewRealFileSystemWithRoot(root)
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"/opt/actions-runner", filepath.Join(root, "opt", "actions-runner")},
+		{"relative/dir", filepath.Join(root, "relative", "dir")},
+		{"/opt/../../../etc/passwd", filepath.Join(root, "etc", "passwd")},
+	}
+	for _, tt := range tests {
+		if got := fs.resolve(tt.path); got != tt.expected {
+			t.Errorf("resolve(%q) = %q, want %q", tt.path, got, tt.expected)
+		}
+	}
+
+	// Without a root, paths are used as given
+	if got := NewRealFileSystem().resolve("/opt/actions-runner"); got != "/opt/actions-runner" {
+		t.Errorf("Expected unrooted path to be unchanged, got %q", got)
+	}
+}
+
+func TestRunWithRootedFileSystem(t *testing.T) {
+	const installPath = "/opt/actions-runner"
+	const workDir = "/var/lib/runner-work"
+
+	var buf bytes.Buffer
+	gzWriter := gzip.NewWriter(&buf)
+	tarWriter := tar.NewWriter(gzWriter)
+	_ = tarWriter.WriteHeader(&tar.Header{Name: "bin/", Typeflag: tar.TypeDir, Mode: 0755})
+	_ = tarWriter.WriteHeader(&tar.Header{Name: "bin/Runner.Listener", Typeflag: tar.TypeReg, Mode: 0755, Size: 8})
+	_, _ = tarWriter.Write([]byte("listener"))
+	_ = tarWriter.WriteHeader(&tar.Header{Name: "config.sh", Typeflag: tar.TypeReg, Mode: 0755, Size: 9})
+	_, _ = tarWriter.Write([]byte("#!/bin/sh"))
+	_ = tarWriter.Close()
+	_ = gzWriter.Close()
+	archive := buf.Bytes()
+
+	config := &RunnerConfig{
+		Method:          runnerTokenMethod,
+		RunnerToken:     "test-token",
+		RegistrationURL: "https://github.com/test/repo",
+		RunnerName:      "test-runner",
+		Runner: RunnerSettings{
+			InstallPath: installPath,
+			WorkDir:     workDir,
+		},
+	}
+
+	root := t.TempDir()
+	fileSystem := NewRealFileSystemWithRoot(root)
+	httpClient := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(archive))}, nil
+		},
+	}
+
+	// Inspect the extracted runner when config.sh runs, and leave job output behind from run.sh
+	var extracted map[string]string
+	executor := NewMockCommandExecutor()
+	executor.CommandContextFunc = func(ctx context.Context, name string, args ...string) Command {
+		cmd := &MockCommand{name: name, args: args, executor: executor}
+		switch filepath.Base(name) {
+		case DefaultConfigScript:
+			cmd.RunFunc = func() error {
+				extracted = make(map[string]string)
+				for _, rel := range []string{"config.sh", "bin/Runner.Listener"} {
+					data, err := os.ReadFile(filepath.Join(root, installPath, rel))
+					if err != nil {
+						return err
+					}
+					extracted[rel] = string(data)
+				}
+				return nil
+			}
+		case DefaultRunScript:
+			cmd.RunFunc = func() error {
+				if err := fileSystem.MkdirAll(workDir+"/_work", DirPermissions); err != nil {
+					return err
+				}
+				file, err := fileSystem.OpenFile(workDir+"/_work/job.log", os.O_CREATE|os.O_WRONLY, FilePermissions)
+				if err != nil {
+					return err
+				}
+				_, _ = fileSystem.WriteString(file, "done")
+				return file.Close()
+			}
+		}
+		return cmd
+	}
+
+	system := NewMockSystemOperations()
+	bootstrap := NewGitHubBootstrap(config, NewMockLogger(), httpClient, fileSystem, executor, system)
+	if err := bootstrap.Run(context.Background()); err != nil {
+		t.Fatalf("Expected successful run, got: %v", err)
+	}
+
+	if extracted["config.sh"] != "#!/bin/sh" || extracted["bin/Runner.Listener"] != "listener" {
+		t.Errorf("Unexpected extracted files: %v", extracted)
+	}
+
+	// Cleanup removed the install and work directories, including the downloaded archive
+	for _, path := range []string{installPath, installPath + PartialDownloadSuffix, workDir} {
+		if _, err := os.Stat(filepath.Join(root, path)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed under the root, got: %v", path, err)
+		}
+	}
+	if !system.RebootCalled {
+		t.Error("Expected VM shutdown after cleanup")
+	}
+}
+
+// runnerArchiveResponse returns a response holding a minimal runner tar.gz archive
+func runnerArchiveResponse() *http.Response {
+	var buf bytes.Buffer
+	gzWriter := gzip.NewWriter(&buf)
+	tarWriter := tar.NewWriter(gzWriter)
+	_ = tarWriter.WriteHeader(&tar.Header{Name: "test-file", Mode: 0644, Size: 4})
+	_, _ = tarWriter.Write([]byte("test"))
+	_ = tarWriter.Close()
+	_ = gzWriter.Close()
+
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(buf.Bytes()))}
+}
+
+func TestReportCompletion(t *testing.T) {
+	const webhookURL = "https://orchestrator.example.com/hooks/runner"
+
+	tests := []struct {
+		name     string
+		phase    string
+		runErr   error
+		expected CompletionResult
+	}{
+		{
+			name:     "completed",
+			phase:    PhaseCompleted,
+			expected: CompletionResult{RunnerName: "test-runner", Phase: PhaseCompleted},
+		},
+		{
+			name:     "failed",
+			phase:    PhaseConfigure,
+			runErr:   errors.New("failed to configure runner: token expired"),
+			expected: CompletionResult{RunnerName: "test-runner", Phase: PhaseConfigure, Error: "failed to configure runner: token expired"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []*http.Request
+			var body []byte
+			httpClient := &MockHTTPClient{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					requests = append(requests, req)
+					body, _ = io.ReadAll(req.Body)
+					return &http.Response{StatusCode: http.StatusNoContent, Body: io.NopCloser(strings.NewReader(""))}, nil
+				},
+			}
+			config := &RunnerConfig{RunnerName: "test-runner", CompletionWebhookURL: webhookURL}
+			bootstrap := NewGitHubBootstrap(config, NewMockLogger(), httpClient,
+				NewMockFileSystem(), NewMockCommandExecutor(), NewMockSystemOperations())
+
+			bootstrap.reportCompletion(context.Background(), tt.phase, tt.runErr)
+
+			if len(requests) != 1 {
+				t.Fatalf("Expected 1 webhook request, got %d", len(requests))
+			}
+			req := requests[0]
+			if req.Method != http.MethodPost || req.URL.String() != webhookURL {
+				t.Errorf("Expected POST %s, got %s %s", webhookURL, req.Method, req.URL)
+			}
+			if req.Header.Get("Content-Type") != "application/json" {
+				t.Errorf("Expected JSON content type, got %q", req.Header.Get("Content-Type"))
+			}
+
+			var result CompletionResult
+			if err := json.Unmarshal(body, &result); err != nil {
+				t.Fatalf("Failed to parse webhook body %q: %v", body, err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected webhook body %+v, got %+v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestReportCompletionNotConfigured(t *testing.T) {
+	httpClient := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			t.Errorf("Expected no webhook request, got %s %s", req.Method, req.URL)
+			return nil, errors.New("unexpected request")
+		},
+	}
+	bootstrap := NewGitHubBootstrap(&RunnerConfig{RunnerName: "test-runner"}, NewMockLogger(), httpClient,
+		NewMockFileSystem(), NewMockCommandExecutor(), NewMockSystemOperations())
+
+	bootstrap.reportCompletion(context.Background(), PhaseCompleted, nil)
+}
+
+func TestRunReportsFailedPhaseToWebhook(t *testing.T) {
+	const webhookURL = "https://orchestrator.example.com/hooks/runner"
+
+	var result CompletionResult
+	httpClient := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			if req.URL.String() != webhookURL {
+				return runnerArchiveResponse(), nil
+			}
+			if err := json.NewDecoder(req.Body).Decode(&result); err != nil {
+				t.Errorf("Failed to parse webhook body: %v", err)
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(""))}, nil
+		},
+	}
+
+	executor := NewMockCommandExecutor()
+	executor.CommandContextFunc = func(ctx context.Context, name string, args ...string) Command {
+		return &MockCommand{name: name, args: args, executor: executor, RunFunc: func() error {
+			return errors.New("runner crashed")
+		}}
+	}
+	config := &RunnerConfig{
+		Method:               runnerTokenMethod,
+		RunnerToken:          "test-token",
+		RegistrationURL:      "https://github.com/test/repo",
+		RunnerName:           "test-runner",
+		CompletionWebhookURL: webhookURL,
+		Runner:               RunnerSettings{ConfigureMaxAttempts: 1},
+	}
+	bootstrap := NewGitHubBootstrap(config, NewMockLogger(), httpClient, NewMockFileSystem(), executor, NewMockSystemOperations())
+
+	err := bootstrap.Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "failed to configure runner") {
+		t.Fatalf("Expected configuration failure, got: %v", err)
+	}
+	if result.Phase != PhaseConfigure || result.RunnerName != "test-runner" || result.Error != err.Error() {
+		t.Errorf("Expected webhook to report the configure failure, got %+v", result)
+	}
+}
+
+func TestRunPrepareOnly(t *testing.T) {
+	const webhookURL = "https://orchestrator.example.com/hooks/runner"
+
+	var result CompletionResult
+	httpClient := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			if req.URL.String() != webhookURL {
+				return runnerArchiveResponse(), nil
+			}
+			if err := json.NewDecoder(req.Body).Decode(&result); err != nil {
+				t.Errorf("Failed to parse webhook body: %v", err)
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(""))}, nil
+		},
+	}
+	config := &RunnerConfig{
+		Method:               runnerTokenMethod,
+		RunnerToken:          "test-token",
+		RemoveToken:          "remove-token",
+		RegistrationURL:      "https://github.com/test/repo",
+		RunnerName:           "test-runner",
+		CompletionWebhookURL: webhookURL,
+	}
+	config.Runner.InstallPath = testInstallPath
+	config.Runner.WorkDir = testWorkDir
+
+	executor := NewMockCommandExecutor()
+	fileSystem := NewMockFileSystem()
+	system := NewMockSystemOperations()
+	bootstrap := NewGitHubBootstrap(config, NewMockLogger(), httpClient, fileSystem, executor, system)
+	bootstrap.prepareOnly = true
+
+	if err := bootstrap.Run(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	// The runner is configured, then only deregistered; run.sh never starts
+	var commands []string
+	for _, cmd := range executor.ExecutedCommands {
+		commands = append(commands, cmd.Name+" "+cmd.Args[0])
+	}
+	expected := []string{testConfigScript + " --url", testConfigScript + " remove"}
+	if !slices.Equal(commands, expected) {
+		t.Errorf("Expected commands %v, got %v", expected, commands)
+	}
+
+	// No shutdown or cleanup is attempted
+	if system.RebootCalled {
+		t.Error("Expected no shutdown in prepare-only mode")
+	}
+	if slices.Contains(fileSystem.RemovedPaths, testInstallPath) || slices.Contains(fileSystem.RemovedPaths, testWorkDir) {
+		t.Errorf("Expected the prepared runner to be kept, got removals %v", fileSystem.RemovedPaths)
+	}
+
+	if result.Phase != PhasePrepared || result.Error != "" {
+		t.Errorf("Expected the webhook to report the prepared phase, got %+v", result)
+	}
+}
+
+func TestRunWebhookFailureIsNonFatal(t *testing.T) {
+	const webhookURL = "https://orchestrator.example.com/hooks/runner"
+
+	webhookCalled := false
+	httpClient := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			if req.URL.String() != webhookURL {
+				return runnerArchiveResponse(), nil
+			}
+			webhookCalled = true
+			return nil, errors.New("connection refused")
+		},
+	}
+	config := &RunnerConfig{
+		Method:               runnerTokenMethod,
+		RunnerToken:          "test-token",
+		RegistrationURL:      "https://github.com/test/repo",
+		RunnerName:           "test-runner",
+		CompletionWebhookURL: webhookURL,
+	}
+	logger := NewMockLogger()
+	fileSystem := NewMockFileSystem()
+	bootstrap := NewGitHubBootstrap(config, logger, httpClient, fileSystem, NewMockCommandExecutor(), NewMockSystemOperations())
+
+	if err := bootstrap.Run(context.Background()); err != nil {
+		t.Fatalf("Expected webhook failure to be non-fatal, got: %v", err)
+	}
+	if !webhookCalled {
+		t.Error("Expected the completion webhook to be called")
+	}
+	if len(fileSystem.RemovedPaths) == 0 {
+		t.Error("Expected cleanup to run after the webhook failed")
+	}
+
+	found := false
+	for _, msg := range logger.Messages {
+		if strings.Contains(msg, "completion webhook failed") {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Errorf("Expected webhook failure to be logged, got %v", logger.Messages)
+	}
+}
+
+// steppedClock returns a clock reporting the given offsets from a fixed start, one per call
+func steppedClock(offsets ...time.Duration) func() time.Time {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	return func() time.Time {
+		offset := offsets[0]
+		offsets = offsets[1:]
+		return start.Add(offset)
+	}
+}
+
+func TestRunEmitsPhaseMetrics(t *testing.T) {
+	const (
+		pushgatewayURL = "http://pushgateway.internal:9091/"
+		textfilePath   = "/var/lib/node_exporter/textfile/hyperfleet.prom"
+	)
+
+	tests := []struct {
+		name         string
+		configureErr error
+		offsets      []time.Duration
+		expected     []string
+	}{
+		{
+			name:    "successful run",
+			offsets: []time.Duration{0, 12 * time.Second, 12 * time.Second, 15500 * time.Millisecond, 16 * time.Second, 76 * time.Second},
+			expected: []string{
+				`hyperfleet_bootstrap_phase_duration_seconds{runner="test-runner",phase="download",outcome="success"} 12`,
+				`hyperfleet_bootstrap_phase_duration_seconds{runner="test-runner",phase="configure",outcome="success"} 3.5`,
+				`hyperfleet_bootstrap_phase_duration_seconds{runner="test-runner",phase="run",outcome="success"} 60`,
+			},
+		},
+		{
+			name:         "failed configure",
+			configureErr: errors.New("registration rejected"),
+			offsets:      []time.Duration{0, 5 * time.Second, 5 * time.Second, 7 * time.Second},
+			expected: []string{
+				`hyperfleet_bootstrap_phase_duration_seconds{runner="test-runner",phase="download",outcome="success"} 5`,
+				`hyperfleet_bootstrap_phase_duration_seconds{runner="test-runner",phase="configure",outcome="failure"} 2`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var pushed *http.Request
+			var pushedBody string
+			httpClient := &MockHTTPClient{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					if req.URL.Host != "pushgateway.internal:9091" {
+						return runnerArchiveResponse(), nil
+					}
+					body, _ := io.ReadAll(req.Body)
+					pushed, pushedBody = req, string(body)
+					return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(""))}, nil
+				},
+			}
+			executor := NewMockCommandExecutor()
+			executor.CommandContextFunc = func(ctx context.Context, name string, args ...string) Command {
+				return &MockCommand{name: name, args: args, executor: executor, RunFunc: func() error {
+					if strings.HasSuffix(name, DefaultConfigScript) {
+						return tt.configureErr
+					}
+					return nil
+				}}
+			}
+			config := &RunnerConfig{
+				Method:          runnerTokenMethod,
+				RunnerToken:     "test-token",
+				RegistrationURL: "https://github.com/test/repo",
+				RunnerName:      "test-runner",
+				Runner:          RunnerSettings{ConfigureMaxAttempts: 1},
+				Metrics:         MetricsSettings{PushgatewayURL: pushgatewayURL, TextfilePath: textfilePath},
+			}
+			fileSystem := NewMockFileSystem()
+			bootstrap := NewGitHubBootstrap(config, NewMockLogger(), httpClient, fileSystem, executor, NewMockSystemOperations())
+			bootstrap.now = steppedClock(tt.offsets...)
+
+			err := bootstrap.Run(context.Background())
+			if (err != nil) != (tt.configureErr != nil) {
+				t.Fatalf("Unexpected run result: %v", err)
+			}
+
+			textfile := fileSystem.WrittenData[textfilePath]
+			for _, line := range tt.expected {
+				if !strings.Contains(textfile, line+"\n") {
+					t.Errorf("Expected textfile to contain %q, got:\n%s", line, textfile)
+				}
+			}
+			if got := strings.Count(textfile, "hyperfleet_bootstrap_phase_duration_seconds{"); got != len(tt.expected) {
+				t.Errorf("Expected %d samples, got %d:\n%s", len(tt.expected), got, textfile)
+			}
+
+			if pushed == nil {
+				t.Fatal("Expected metrics to be pushed")
+			}
+			expectedURL := "http://pushgateway.internal:9091/metrics/job/hyperfleet_bootstrap/instance/test-runner"
+			if pushed.Method != http.MethodPut || pushed.URL.String() != expectedURL {
+				t.Errorf("Expected PUT %s, got %s %s", expectedURL, pushed.Method, pushed.URL)
+			}
+			if pushedBody != textfile {
+				t.Errorf("Expected the pushed metrics to match the textfile, got:\n%s", pushedBody)
+			}
+		})
+	}
+}
+
+func TestRunMetricsDisabledByDefault(t *testing.T) {
+	httpClient := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			if req.Method != http.MethodGet {
+				t.Errorf("Expected no metrics push, got %s %s", req.Method, req.URL)
+			}
+			return runnerArchiveResponse(), nil
+		},
+	}
+	config := &RunnerConfig{
+		Method:          runnerTokenMethod,
+		RunnerToken:     "test-token",
+		RegistrationURL: "https://github.com/test/repo",
+		RunnerName:      "test-runner",
+	}
+	fileSystem := NewMockFileSystem()
+	bootstrap := NewGitHubBootstrap(config, NewMockLogger(), httpClient, fileSystem, NewMockCommandExecutor(), NewMockSystemOperations())
+
+	if err := bootstrap.Run(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	for path := range fileSystem.WrittenData {
+		if strings.HasSuffix(path, ".prom") {
+			t.Errorf("Expected no metrics textfile, got %s", path)
+		}
+	}
+}
+
+func TestQuoteLabelValue(t *testing.T) {
+	if got := quoteLabelValue("a\"b\\c\nd"); got != `"a\"b\\c\nd"` {
+		t.Errorf("Unexpected quoted label value: %s", got)
+	}
+}
+
+func TestRunPreStartHooks(t *testing.T) {
+	tests := []struct {
+		name          string
+		failingHook   string
+		expectedCalls []string
+		expectedError string
+	}{
+		{
+			name:          "hooks run in order before the runner",
+			expectedCalls: []string{testConfigScript, "mount /dev/vdb /var/cache/runner", "systemctl start docker", testRunScript},
+		},
+		{
+			name:          "failing hook aborts before the runner starts",
+			failingHook:   "mount /dev/vdb /var/cache/runner",
+			expectedCalls: []string{testConfigScript, "mount /dev/vdb /var/cache/runner"},
+			expectedError: "pre-start hook 1 failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			executor := NewMockCommandExecutor()
+			executor.CommandContextFunc = func(ctx context.Context, name string, args ...string) Command {
+				call := name
+				if name == HookShell {
+					if len(args) != 2 || args[0] != "-c" {
+						t.Errorf("Expected hook to run with %s -c, got %v", HookShell, args)
+					}
+					call = args[len(args)-1]
+				}
+				return &MockCommand{name: name, args: args, executor: executor, RunFunc: func() error {
+					calls = append(calls, call)
+					if call == tt.failingHook {
+						return errors.New("exit status 32")
+					}
+					return nil
+				}}
+			}
+			config := &RunnerConfig{
+				Method:          runnerTokenMethod,
+				RunnerToken:     "test-token",
+				RegistrationURL: "https://github.com/test/repo",
+				RunnerName:      "test-runner",
+			}
+			config.Runner.InstallPath = testInstallPath
+			config.Runner.PreStartHooks = []string{"mount /dev/vdb /var/cache/runner", "systemctl start docker"}
+			httpClient := &MockHTTPClient{DoFunc: func(req *http.Request) (*http.Response, error) {
+				return runnerArchiveResponse(), nil
+			}}
+
+			bootstrap := NewGitHubBootstrap(config, NewMockLogger(), httpClient, NewMockFileSystem(), executor, NewMockSystemOperations())
+			phase, err := bootstrap.runLifecycle(context.Background())
+
+			if tt.expectedError == "" {
+				if err != nil || phase != PhaseCompleted {
+					t.Fatalf("Expected completed lifecycle, got %s: %v", phase, err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.expectedError) || phase != PhasePreStart {
+				t.Fatalf("Expected %s failure containing %q, got %s: %v", PhasePreStart, tt.expectedError, phase, err)
+			}
+			if !slices.Equal(calls, tt.expectedCalls) {
+				t.Errorf("Expected calls %v, got %v", tt.expectedCalls, calls)
+			}
+			for _, cmd := range executor.ExecutedCommands {
+				if cmd.Name == HookShell && cmd.Dir != testInstallPath {
+					t.Errorf("Expected hook to run in %s, got %s", testInstallPath, cmd.Dir)
+				}
+			}
+		})
+	}
+}
+
+func TestRandomizedWorkDir(t *testing.T) {
+	runBoot := func() (string, []string) {
+		var workDir string
+		executor := NewMockCommandExecutor()
+		executor.CommandContextFunc = func(ctx context.Context, name string, args ...string) Command {
+			if filepath.Base(name) == DefaultConfigScript {
+				if i := slices.Index(args, "--work"); i >= 0 && i+1 < len(args) {
+					workDir = args[i+1]
+				}
+			}
+			return &MockCommand{name: name, args: args, executor: executor}
+		}
+		config := &RunnerConfig{
+			Method:          runnerTokenMethod,
+			RunnerToken:     "test-token",
+			RegistrationURL: "https://github.com/test/repo",
+			RunnerName:      "test-runner",
+		}
+		config.Runner.InstallPath = testInstallPath
+		config.Runner.WorkDir = testWorkDir
+		config.Runner.RandomizeWorkDir = true
+		httpClient := &MockHTTPClient{DoFunc: func(req *http.Request) (*http.Response, error) {
+			return runnerArchiveResponse(), nil
+		}}
+		fileSystem := NewMockFileSystem()
+
+		bootstrap := NewGitHubBootstrap(config, NewMockLogger(), httpClient, fileSystem, executor, NewMockSystemOperations())
+		if err := bootstrap.Run(context.Background()); err != nil {
+			t.Fatalf("Expected successful run, got: %v", err)
+		}
+		return workDir, fileSystem.RemovedPaths
+	}
+
+	first, firstRemoved := runBoot()
+	second, _ := runBoot()
+
+	if filepath.Dir(first) != testWorkDir || !strings.HasPrefix(filepath.Base(first), BootWorkDirPrefix) {
+		t.Errorf("Expected a %s* directory under %s, got %q", BootWorkDirPrefix, testWorkDir, first)
+	}
+	if first == second {
+		t.Errorf("Expected a unique work directory per boot, got %q twice", first)
+	}
+	if !slices.Contains(firstRemoved, first) {
+		t.Errorf("Expected cleanup to remove %s, removed %v", first, firstRemoved)
+	}
+	if slices.Contains(firstRemoved, testWorkDir) {
+		t.Errorf("Expected cleanup to keep the base directory %s", testWorkDir)
+	}
+}
@@ -2,11 +2,16 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"debug/elf"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 )
@@ -22,28 +27,63 @@ func NewRealHTTPClient(timeout time.Duration) *RealHTTPClient {
 	}
 }
 
+// NewRealHTTPClientWithTLS returns a RealHTTPClient whose connections use tlsConfig
+func NewRealHTTPClientWithTLS(timeout time.Duration, tlsConfig *tls.Config) *RealHTTPClient {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return &RealHTTPClient{
+		client: &http.Client{Timeout: timeout, Transport: transport},
+	}
+}
+
 func (c *RealHTTPClient) Do(req *http.Request) (*http.Response, error) {
 	return c.client.Do(req)
 }
 
 // RealFileSystem implements FileSystem using the standard os package
-type RealFileSystem struct{}
+type RealFileSystem struct {
+	root string
+}
 
 func NewRealFileSystem() *RealFileSystem {
 	return &RealFileSystem{}
 }
 
+// NewRealFileSystemWithRoot returns a RealFileSystem that rebases every path under root,
+// so the real implementation can be exercised inside a sandbox directory such as t.TempDir().
+// Paths seen by executed commands are not rebased.
+func NewRealFileSystemWithRoot(root string) *RealFileSystem {
+	return &RealFileSystem{root: root}
+}
+
+// resolve maps a path onto the filesystem root; ".." cannot climb above the root
+func (fs *RealFileSystem) resolve(path string) string {
+	if fs.root == "" {
+		return path
+	}
+	return filepath.Join(fs.root, filepath.Clean(string(filepath.Separator)+path))
+}
+
 func (fs *RealFileSystem) MkdirAll(path string, perm os.FileMode) error {
-	return os.MkdirAll(path, perm)
+	return os.MkdirAll(fs.resolve(path), perm)
 }
 
 func (fs *RealFileSystem) RemoveAll(path string) error {
-	return os.RemoveAll(path)
+	return os.RemoveAll(fs.resolve(path))
 }
 
 func (fs *RealFileSystem) OpenFile(name string, flag int, perm os.FileMode) (io.WriteCloser, error) {
 	// #nosec G304 - File path is validated by caller, needed for legitimate file operations
-	return os.OpenFile(name, flag, perm)
+	return os.OpenFile(fs.resolve(name), flag, perm)
+}
+
+func (fs *RealFileSystem) Open(name string) (io.ReadCloser, error) {
+	// #nosec G304 - File path is validated by caller, needed for legitimate file operations
+	return os.Open(fs.resolve(name))
+}
+
+func (fs *RealFileSystem) Stat(name string) (os.FileInfo, error) {
+	return os.Stat(fs.resolve(name))
 }
 
 func (fs *RealFileSystem) WriteString(file io.WriteCloser, data string) (int, error) {
@@ -86,6 +126,10 @@ func (c *RealCommand) SetStderr(stderr io.Writer) {
 	c.cmd.Stderr = stderr
 }
 
+func (c *RealCommand) SetEnv(env []string) {
+	c.cmd.Env = append(os.Environ(), env...)
+}
+
 // RealSystemOperations implements SystemOperations using syscalls
 type RealSystemOperations struct{}
 
@@ -105,17 +149,121 @@ func (s *RealSystemOperations) Sleep(duration int) {
 	time.Sleep(time.Duration(duration) * time.Second)
 }
 
+// GHAttestationVerifier implements AttestationVerifier using the GitHub CLI,
+// which fetches the artifact's signed attestation and verifies it with Sigstore
+type GHAttestationVerifier struct {
+	executor CommandExecutor
+	repo     string
+}
+
+func NewGHAttestationVerifier(executor CommandExecutor, repo string) *GHAttestationVerifier {
+	return &GHAttestationVerifier{
+		executor: executor,
+		repo:     repo,
+	}
+}
+
+func (v *GHAttestationVerifier) Verify(ctx context.Context, artifactPath string) error {
+	// #nosec G204 - artifactPath and repo come from the bootstrap configuration, not user input
+	cmd := v.executor.CommandContext(ctx, "gh", "attestation", "verify", artifactPath, "--repo", v.repo)
+	cmd.SetStdout(os.Stdout)
+	cmd.SetStderr(os.Stderr)
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("attestation verification failed for %s: %w", artifactPath, err)
+	}
+	return nil
+}
+
+// ELFArchDetector implements ArchDetector by reading the machine type from an ELF header
+type ELFArchDetector struct{}
+
+// elfMachineArch maps ELF machine types to GOARCH names
+var elfMachineArch = map[elf.Machine]string{
+	elf.EM_X86_64:  "amd64",
+	elf.EM_386:     "386",
+	elf.EM_AARCH64: "arm64",
+	elf.EM_ARM:     "arm",
+}
+
+func (d ELFArchDetector) DetectArch(path string) (string, error) {
+	file, err := elf.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read ELF header of %s: %w", path, err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	arch, ok := elfMachineArch[file.Machine]
+	if !ok {
+		return "", fmt.Errorf("%s is built for unsupported machine type %s", path, file.Machine)
+	}
+	return arch, nil
+}
+
+// LogLevel is the verbosity of a Logger; each level includes the messages of those below it
+type LogLevel int
+
+const (
+	LogLevelError LogLevel = iota // errors and warnings only
+	LogLevelInfo                  // progress through the bootstrap phases (default)
+	LogLevelDebug                 // also URLs, command arguments and each extracted file
+)
+
+// logLevelNames maps the log_level setting and --log-level flag values to levels
+var logLevelNames = map[string]LogLevel{
+	"error": LogLevelError,
+	"info":  LogLevelInfo,
+	"debug": LogLevelDebug,
+}
+
+// ParseLogLevel parses a log level name; an empty name is LogLevelInfo
+func ParseLogLevel(name string) (LogLevel, error) {
+	if name == "" {
+		return LogLevelInfo, nil
+	}
+	level, ok := logLevelNames[strings.ToLower(name)]
+	if !ok {
+		return LogLevelInfo, fmt.Errorf("unknown log level %q, expected error, info or debug", name)
+	}
+	return level, nil
+}
+
 // RealLogger implements Logger using the standard log package
 type RealLogger struct {
 	logger *log.Logger
+	level  LogLevel
 }
 
 func NewRealLogger(prefix string) *RealLogger {
+	return NewRealLoggerWithLevel(prefix, LogLevelInfo)
+}
+
+// NewRealLoggerWithLevel returns a RealLogger dropping messages more verbose than level
+func NewRealLoggerWithLevel(prefix string, level LogLevel) *RealLogger {
 	return &RealLogger{
 		logger: log.New(os.Stdout, prefix, log.LstdFlags),
+		level:  level,
 	}
 }
 
 func (l *RealLogger) Printf(format string, v ...interface{}) {
+	l.logf(LogLevelInfo, format, v...)
+}
+
+func (l *RealLogger) Errorf(format string, v ...interface{}) {
+	l.logf(LogLevelError, format, v...)
+}
+
+func (l *RealLogger) Debugf(format string, v ...interface{}) {
+	l.logf(LogLevelDebug, format, v...)
+}
+
+// logf logs the message if the logger's level includes level
+func (l *RealLogger) logf(level LogLevel, format string, v ...interface{}) {
+	if level > l.level {
+		return
+	}
 	l.logger.Printf(format, v...)
 }
@@ -17,6 +17,8 @@ type FileSystem interface {
 	MkdirAll(path string, perm os.FileMode) error
 	RemoveAll(path string) error
 	OpenFile(name string, flag int, perm os.FileMode) (io.WriteCloser, error)
+	Open(name string) (io.ReadCloser, error)
+	Stat(name string) (os.FileInfo, error)
 	WriteString(file io.WriteCloser, data string) (int, error)
 }
 
@@ -31,6 +33,8 @@ type Command interface {
 	SetDir(dir string)
 	SetStdout(stdout io.Writer)
 	SetStderr(stderr io.Writer)
+	// SetEnv adds KEY=VALUE variables to the environment inherited from the bootstrap service
+	SetEnv(env []string)
 }
 
 // SystemOperations interface for system-level operations
@@ -40,7 +44,20 @@ type SystemOperations interface {
 	Sleep(duration int)
 }
 
-// Logger interface for logging operations
+// AttestationVerifier interface for verifying build provenance of downloaded artifacts
+type AttestationVerifier interface {
+	Verify(ctx context.Context, artifactPath string) error
+}
+
+// ArchDetector reports the CPU architecture an executable was built for, as a GOARCH name
+type ArchDetector interface {
+	DetectArch(path string) (string, error)
+}
+
+// Logger interface for logging operations. Printf logs at LogLevelInfo; Errorf logs errors
+// and warnings, which every level shows, and Debugf the details only LogLevelDebug shows.
 type Logger interface {
 	Printf(format string, v ...interface{})
+	Errorf(format string, v ...interface{})
+	Debugf(format string, v ...interface{})
 }
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	// LogExportLogFile holds the runner's output in the runner's log export directory
+	LogExportLogFile = "runner.log"
+	// LogExportStatusFile holds the JSON CompletionResult in the runner's log export directory
+	LogExportStatusFile = "status.json"
+	// LogExportMaxBytes bounds the runner output kept for export; older output is dropped first
+	LogExportMaxBytes = 8 << 20
+)
+
+// logTail keeps the last limit bytes written to it. Writes may come from the runner's stdout
+// and stderr at once.
+type logTail struct {
+	mu    sync.Mutex
+	buf   []byte
+	limit int
+}
+
+// newLogTail returns a logTail keeping up to limit bytes
+func newLogTail(limit int) *logTail {
+	return &logTail{limit: limit}
+}
+
+// Write appends p, dropping the oldest output beyond the limit
+func (t *logTail) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.buf = append(t.buf, p...)
+	if overflow := len(t.buf) - t.limit; overflow > 0 {
+		t.buf = append(t.buf[:0], t.buf[overflow:]...)
+	}
+	return len(p), nil
+}
+
+// String returns the output kept so far
+func (t *logTail) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return string(t.buf)
+}
+
+// runnerOutput returns where the runner's stdout and stderr go: the console, and the log
+// kept for export when log_export_path is configured
+func (gb *GitHubBootstrap) runnerOutput(console io.Writer) io.Writer {
+	if gb.config.LogExportPath == "" {
+		return console
+	}
+	if gb.runnerLog == nil {
+		gb.runnerLog = newLogTail(LogExportMaxBytes)
+	}
+	return io.MultiWriter(console, gb.runnerLog)
+}
+
+// logExportDir returns the runner's directory under log_export_path, so runners sharing a
+// volume do not overwrite each other's logs
+func (gb *GitHubBootstrap) logExportDir() string {
+	return filepath.Join(gb.config.LogExportPath, gb.config.RunnerName)
+}
+
+// exportLogs copies the runner's output and the bootstrap status to log_export_path before the
+// VM shuts down. It is best-effort: failures are logged and never hold up the shutdown.
+func (gb *GitHubBootstrap) exportLogs(result CompletionResult) {
+	if gb.config.LogExportPath == "" {
+		return
+	}
+
+	status, err := json.Marshal(result)
+	if err != nil {
+		gb.logger.Errorf("Warning: failed to encode exported status: %v", err)
+		return
+	}
+
+	dir := gb.logExportDir()
+	if err := gb.fileSystem.MkdirAll(dir, DirPermissions); err != nil {
+		gb.logger.Errorf("Warning: failed to create log export directory %s: %v", dir, err)
+		return
+	}
+
+	var runnerLog string
+	if gb.runnerLog != nil {
+		runnerLog = gb.runnerLog.String()
+	}
+	files := []struct {
+		name string
+		data string
+	}{
+		{name: LogExportLogFile, data: runnerLog},
+		{name: LogExportStatusFile, data: string(status)},
+	}
+	for _, file := range files {
+		path := filepath.Join(dir, file.name)
+		if err := gb.writeExportFile(path, file.data); err != nil {
+			gb.logger.Errorf("Warning: failed to export %s: %v", path, err)
+			return
+		}
+	}
+	gb.logger.Printf("Exported runner logs and status to %s", dir)
+}
+
+// writeExportFile replaces the file at path with data
+func (gb *GitHubBootstrap) writeExportFile(path, data string) error {
+	file, err := gb.fileSystem.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, FilePermissions)
+	if err != nil {
+		return err
+	}
+	if _, err := gb.fileSystem.WriteString(file, data); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close: %w", err)
+	}
+	return nil
+}
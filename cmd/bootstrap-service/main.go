@@ -2,17 +2,29 @@ package main
 
 import (
 	"archive/tar"
+	"bytes"
 	"compress/gzip"
 	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"log"
+	"maps"
 	"net/http"
+	"net/url"
 	"os"
+	"os/exec"
+	"path"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"slices"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -20,52 +32,305 @@ import (
 
 // Default configuration values
 const (
-	DefaultDownloadURL  = "https://github.com/actions/runner/releases/download/v2.311.0/actions-runner-linux-x64-2.311.0.tar.gz"
+	DefaultDownloadURL  = "https://github.com/actions/runner/releases/download/v2.311.0/actions-runner-linux-x64-2.311.0.tar.gz" // Reported by the controller as DefaultRunnerDownloadURL
 	DefaultInstallPath  = "/tmp/hyperfleet"
 	DefaultWorkDir      = "/tmp/hyperfleet-work"
+	BootWorkDirPrefix   = "boot-" // Prefix of randomized per-boot work directories
 	DefaultConfigPath   = "/etc/hyperfleet/runner-config.json"
 	DefaultConfigScript = "config.sh"
 	DefaultRunScript    = "run.sh"
 
+	// DefaultRunnerVersion is the runner release downloaded when runner.version is unset
+	DefaultRunnerVersion = "2.311.0"
+
+	// HookShell runs the pre-start hook commands
+	HookShell = "/bin/sh"
+
 	// File permissions
-	DirPermissions = 0755
+	DirPermissions  = 0755
+	FilePermissions = 0600
+	ExecPermissions = 0111 // any execute bit
+
+	// Extraction limits guarding against decompression bombs; the runner unpacks to a few hundred MiB
+	DefaultMaxExtractedBytes     = 2 << 30   // 2 GiB across all files
+	DefaultMaxExtractedFileBytes = 512 << 20 // 512 MiB for any single file
+
+	// RunnerCacheMarker is the file in a pre-staged runner cache holding the cached runner version
+	RunnerCacheMarker = ".hyperfleet-runner-version"
+	// maxRunnerCacheMarkerBytes bounds how much of the marker file is read
+	maxRunnerCacheMarkerBytes = 256
+
+	// PartialDownloadSuffix is appended to the install path for the in-progress runner archive
+	PartialDownloadSuffix = ".tar.gz.part"
 
 	// Timing constants
 	CleanupDelaySeconds = 2
-	HTTPTimeoutSeconds  = 300 // 5 minutes for download
+	// DeregisterGraceSeconds gives GitHub time to finalize a runner removal before the VM shuts
+	// down, so the runner is not left behind as an offline ghost
+	DeregisterGraceSeconds = 5
+	HTTPTimeoutSeconds     = 300 // 5 minutes for download
+
+	// Download retry settings
+	DownloadMaxAttempts       = 3
+	DownloadRetryDelaySeconds = 5
+
+	// Runner registration retry settings
+	ConfigureMaxAttempts          = 3
+	ConfigureRetryDelaySeconds    = 5
+	ConfigureMaxRetryDelaySeconds = 60
+	ConfigureBackoffFactor        = 2
+
+	// Runner deregistration retry settings; delays double after each failed attempt
+	DeregisterMaxAttempts          = 3
+	DeregisterRetryDelaySeconds    = 2
+	DeregisterMaxRetryDelaySeconds = 30
+
+	// WebhookTimeoutSeconds bounds the best-effort completion webhook request
+	WebhookTimeoutSeconds = 10
+
+	// TokenFetchTimeoutSeconds bounds the request fetching the registration token from runner_token_url
+	// or the SPIFFE token_url
+	TokenFetchTimeoutSeconds = 30
+	// maxTokenResponseBytes bounds how much of the token endpoint's response is read
+	maxTokenResponseBytes = 64 << 10
+
+	// RunnerArchProbe is the native executable in a runner installation whose architecture is
+	// checked; run.sh and config.sh are shell scripts that only fail once they exec it
+	RunnerArchProbe = "bin/Runner.Listener"
+
+	// RunnerAttestationRepo is the repository whose build attestations sign the runner releases
+	RunnerAttestationRepo = "actions/runner"
 
 	// Method constants
 	runnerTokenMethod = "runner-token"
 	joinTokenMethod   = "join-token"
 )
 
+// The runner release layout extracted when restrict_extraction is set
+var (
+	// RunnerLayoutDirs are the top-level directories of a runner release, extracted with their contents
+	RunnerLayoutDirs = []string{"bin", "externals"}
+	// RunnerLayoutFiles are the top-level files of a runner release
+	RunnerLayoutFiles = []string{
+		DefaultConfigScript, DefaultRunScript, "env.sh", "safe_sleep.sh",
+		"run-helper.sh.template", "run-helper.cmd.template",
+	}
+)
+
+// Shutdown modes: how the VM is stopped once the runner completes
+const (
+	// ShutdownModeSelf powers the VM off from inside the guest, which needs root privileges
+	ShutdownModeSelf = "self"
+	// ShutdownModeProvider leaves the VM running and signals completion, so the operator
+	// powers it off through the hypervisor API
+	ShutdownModeProvider = "provider"
+
+	// DefaultCompletionFile is where completion is signaled in ShutdownModeProvider
+	DefaultCompletionFile = "/run/hyperfleet/completion.json"
+)
+
+// Lifecycle phases reported to the completion webhook; a failure reports the phase that failed
+const (
+	PhaseDownload  = "download"
+	PhaseConfigure = "configure"
+	PhasePreStart  = "prestart"
+	PhaseRun       = "run"
+	PhaseCompleted = "completed"
+	// PhasePrepared is reported when a prepare-only run configured the runner without running it
+	PhasePrepared = "prepared"
+)
+
+// runnerArchiveVersionPattern extracts the version from a runner release archive name
+var runnerArchiveVersionPattern = regexp.MustCompile(`actions-runner-[a-z]+-[a-z0-9]+-(\d+\.\d+\.\d+)\.tar\.gz$`)
+
+// DefaultAllowedDownloadHosts are the hosts the runner may always be downloaded from
+var DefaultAllowedDownloadHosts = []string{"github.com"}
+
 // RunnerConfig represents the configuration loaded from the VM
 type RunnerConfig struct {
 	Method          string   `json:"method"`
 	Platform        string   `json:"platform,omitempty"`         // "github-actions"
 	RunnerToken     string   `json:"runner_token,omitempty"`     // Short-lived registration token
+	RemoveToken     string   `json:"remove_token,omitempty"`     // Short-lived removal token, deregisters the runner at cleanup
 	RegistrationURL string   `json:"registration_url,omitempty"` // Where runner registers to
 	RunnerName      string   `json:"runner_name,omitempty"`      // Unique runner name
 	Labels          []string `json:"labels,omitempty"`           // Runner labels
+	RunnerGroup     string   `json:"runner_group,omitempty"`     // Runner group to join (organization and enterprise runners only)
 	ExpiresAt       string   `json:"expires_at,omitempty"`       // Token expiration
 
+	// RunnerTokenURL, when set, is fetched at boot for the registration token and its expiry,
+	// in place of RunnerToken, e.g. from an instance metadata service
+	RunnerTokenURL string `json:"runner_token_url,omitempty"`
+
+	// CompletionWebhookURL receives a POST of the CompletionResult when the runner finishes or fails
+	CompletionWebhookURL string `json:"completion_webhook_url,omitempty"`
+
+	// ShutdownMode is ShutdownModeSelf (default) or ShutdownModeProvider
+	ShutdownMode string `json:"shutdown_mode,omitempty"`
+	// CompletionFile receives the CompletionResult in ShutdownModeProvider (default: DefaultCompletionFile)
+	CompletionFile string `json:"completion_file,omitempty"`
+
+	// Metrics emits the duration and outcome of each lifecycle phase; off by default
+	Metrics MetricsSettings `json:"metrics,omitempty"`
+
+	// LogExportPath is a directory, e.g. on a mounted volume, the runner's output and the
+	// bootstrap status are copied to before the VM shuts down; off when empty
+	LogExportPath string `json:"log_export_path,omitempty"`
+
+	// LogLevel is the log verbosity, error, info (default) or debug; --log-level overrides it
+	LogLevel string `json:"log_level,omitempty"`
+
 	// GitHub Actions runner configuration
-	Runner struct {
-		DownloadURL  string `json:"download_url,omitempty"`  // GitHub Actions runner download URL
-		InstallPath  string `json:"install_path,omitempty"`  // Installation path on VM
-		WorkDir      string `json:"work_dir,omitempty"`      // Working directory for jobs
-		ConfigScript string `json:"config_script,omitempty"` // Path to config script (default: config.sh)
-		RunScript    string `json:"run_script,omitempty"`    // Path to run script (default: run.sh)
-		OS           string `json:"os,omitempty"`            // Target OS (default: from GOOS or runtime)
-		Arch         string `json:"arch,omitempty"`          // Target architecture (default: from GOARCH or runtime)
-	} `json:"runner,omitempty"`
+	Runner RunnerSettings `json:"runner,omitempty"`
 
 	// SPIFFE fields (for SPIFFE attestation - independent of runner token)
-	SPIFFE struct {
-		JoinToken string `json:"join_token,omitempty"`
-		SPIFFEID  string `json:"spiffe_id,omitempty"`
-		Enabled   bool   `json:"enabled,omitempty"`
-	} `json:"spiffe,omitempty"`
+	SPIFFE SPIFFESettings `json:"spiffe,omitempty"`
+}
+
+// RunnerSettings configures how the GitHub Actions runner is installed and run
+type RunnerSettings struct {
+	DownloadURL  string `json:"download_url,omitempty"`   // GitHub Actions runner download URL
+	InstallPath  string `json:"install_path,omitempty"`   // Installation path on VM
+	WorkDir      string `json:"work_dir,omitempty"`       // Working directory for jobs
+	ConfigScript string `json:"config_script,omitempty"`  // Path to config script (default: config.sh)
+	RunScript    string `json:"run_script,omitempty"`     // Path to run script (default: run.sh)
+	OS           string `json:"os,omitempty"`             // Target OS (default: from GOOS or runtime)
+	Arch         string `json:"arch,omitempty"`           // Target architecture (default: from GOARCH or runtime)
+	Ephemeral    *bool  `json:"ephemeral,omitempty"`      // Run a single job then exit (default: true)
+	CleanWorkDir bool   `json:"clean_work_dir,omitempty"` // Clear work directory between jobs (persistent runners only)
+
+	// Replace a registered runner of the same name, e.g. left by a VM re-provisioned with the
+	// same hostname-derived name, instead of failing configuration
+	ReplaceExisting bool `json:"replace_existing,omitempty"`
+
+	// Run jobs in a randomly named directory under work_dir, fresh for each boot, so a
+	// non-ephemeral VM never hands one boot's job data to the next
+	RandomizeWorkDir bool `json:"randomize_work_dir,omitempty"`
+
+	VerifyAttestation bool `json:"verify_attestation,omitempty"` // Verify the runner's GitHub build attestation before extracting
+	VerifyArch        bool `json:"verify_arch,omitempty"`        // Check the installed runner is built for this VM's architecture
+
+	ConfigureMaxAttempts       int `json:"configure_max_attempts,omitempty"`        // Attempts for transient registration failures (default: 3)
+	ConfigureRetryDelaySeconds int `json:"configure_retry_delay_seconds,omitempty"` // Initial delay between attempts, doubled each retry (default: 5)
+
+	// Hosts besides github.com the runner may be downloaded from, e.g. internal mirrors
+	AllowedDownloadHosts []string `json:"allowed_download_hosts,omitempty"`
+
+	// TLS settings for the runner download only, e.g. for a mirror behind an internal CA
+	DownloadCAFile             string `json:"download_ca_file,omitempty"`              // PEM bundle trusted in addition to the system roots
+	DownloadInsecureSkipVerify bool   `json:"download_insecure_skip_verify,omitempty"` // Skip certificate verification (insecure)
+
+	// Permission overrides for extracted files, as octal strings (default: mode from the archive)
+	DirMode      string `json:"dir_mode,omitempty"`       // Mode for extracted directories (e.g. "0755")
+	FileMode     string `json:"file_mode,omitempty"`      // Mode for extracted regular files (e.g. "0644")
+	ExecFileMode string `json:"exec_file_mode,omitempty"` // Mode for files executable in the archive, overriding file_mode (e.g. "0755")
+
+	// Pre-staged runner installation, e.g. baked into the VM image; used in place of a download
+	// when its RunnerCacheMarker file holds the expected version. Cleanup removes it like a download.
+	CachePath string `json:"cache_path,omitempty"` // Directory of the pre-staged runner
+	Version   string `json:"version,omitempty"`    // Runner release to download and expect in the cache, e.g. "2.320.0" or "v2.320.0" (default: DefaultRunnerVersion, or parsed from download_url)
+
+	// Extraction size limits; an archive exceeding them is rejected as a possible decompression bomb
+	MaxExtractedBytes     int64 `json:"max_extracted_bytes,omitempty"`      // Total bytes extracted from the archive (default: 2 GiB)
+	MaxExtractedFileBytes int64 `json:"max_extracted_file_bytes,omitempty"` // Bytes extracted for any single file (default: 512 MiB)
+
+	// Extract only the runner release layout, RunnerLayoutDirs and RunnerLayoutFiles plus the
+	// configured scripts, skipping any other archive entry, e.g. from a tampered mirror
+	RestrictExtraction bool `json:"restrict_extraction,omitempty"`
+
+	// Check config_script and run_script exist before running them, failing with a clear error
+	// instead of the exec error left by a bad install_path or a failed extraction
+	ValidateScripts bool `json:"validate_scripts,omitempty"`
+
+	// Shell commands run in order with HookShell after configuration and before the runner starts;
+	// a failing hook fails the bootstrap
+	PreStartHooks []string `json:"pre_start_hooks,omitempty"`
+
+	DeregisterGraceSeconds int `json:"deregister_grace_seconds,omitempty"` // Delay between deregistration and shutdown (default: 5)
+	DeregisterMaxAttempts  int `json:"deregister_max_attempts,omitempty"`  // Attempts for config.sh remove before giving up (default: 3)
+
+	// Environment variables for config.sh and run.sh, e.g. proxy settings or CA bundle paths,
+	// added to the environment inherited from the bootstrap service
+	Env map[string]string `json:"env,omitempty"`
+}
+
+// RunnerTokenResponse is the JSON body served by runner_token_url, matching GitHub's
+// registration token API
+type RunnerTokenResponse struct {
+	Token     string `json:"token"`
+	ExpiresAt string `json:"expires_at,omitempty"`
+}
+
+// CompletionResult is the JSON body POSTed to the completion webhook
+type CompletionResult struct {
+	RunnerName string `json:"runner_name"`
+	Phase      string `json:"phase"`
+	Error      string `json:"error,omitempty"`
+}
+
+// extractModes holds the permission overrides applied during extraction; zero keeps the archive's mode
+type extractModes struct {
+	dir      os.FileMode
+	file     os.FileMode
+	execFile os.FileMode
+}
+
+// parseExtractModes parses the extraction permission overrides from the runner settings
+func parseExtractModes(settings RunnerSettings) (extractModes, error) {
+	var modes extractModes
+	var err error
+	if modes.dir, err = parseFileMode("dir_mode", settings.DirMode); err != nil {
+		return modes, err
+	}
+	if modes.file, err = parseFileMode("file_mode", settings.FileMode); err != nil {
+		return modes, err
+	}
+	if modes.execFile, err = parseFileMode("exec_file_mode", settings.ExecFileMode); err != nil {
+		return modes, err
+	}
+	return modes, nil
+}
+
+// parseFileMode parses an octal permission string such as "0755"
+func parseFileMode(field, value string) (os.FileMode, error) {
+	if value == "" {
+		return 0, nil
+	}
+	mode, err := strconv.ParseUint(value, 8, 32)
+	if err != nil || mode == 0 || mode > uint64(os.ModePerm) {
+		return 0, fmt.Errorf("invalid %s %q: must be an octal permission between 0001 and 0777", field, value)
+	}
+	return os.FileMode(mode), nil
+}
+
+// dirMode returns the mode for an extracted directory
+func (m extractModes) dirMode(header *tar.Header) os.FileMode {
+	if m.dir != 0 {
+		return m.dir
+	}
+	// #nosec G115 - header.Mode is from tar header, safe conversion
+	return os.FileMode(header.Mode)
+}
+
+// fileMode returns the mode for an extracted regular file
+func (m extractModes) fileMode(header *tar.Header) os.FileMode {
+	if m.execFile != 0 && header.Mode&ExecPermissions != 0 {
+		return m.execFile
+	}
+	if m.file != 0 {
+		return m.file
+	}
+	// #nosec G115 - header.Mode is from tar header, safe conversion
+	return os.FileMode(header.Mode)
+}
+
+// permanentRegistrationErrors are config.sh output fragments for failures that retrying cannot fix
+var permanentRegistrationErrors = []string{
+	"Http response code: NotFound", // invalid or expired registration token
+	"Http response code: Unauthorized",
+	"Http response code: Forbidden",
+	"Cannot configure the runner because it is already configured",
+	"A runner exists with the same name",
 }
 
 // GitHubBootstrap handles the GitHub Actions runner bootstrap process
@@ -76,6 +341,61 @@ type GitHubBootstrap struct {
 	fileSystem FileSystem
 	executor   CommandExecutor
 	system     SystemOperations
+	verifier   AttestationVerifier
+
+	// prepareOnly stops after the runner is downloaded and configured, leaving the VM running
+	prepareOnly bool
+
+	// downloadClient downloads the runner when download TLS settings are configured; nil uses httpClient
+	downloadClient HTTPClient
+	// archDetector checks the installed runner's architecture; nil uses ELFArchDetector
+	archDetector ArchDetector
+
+	// cachedInstallPath is the pre-staged runner installation in use, empty when the runner was downloaded
+	cachedInstallPath string
+	// bootWorkDir is this boot's randomized work directory, generated on first use
+	bootWorkDir string
+
+	// now returns the current time for timing lifecycle phases
+	now func() time.Time
+	// phases records the lifecycle phases run so far, emitted as metrics
+	phases []phaseResult
+	// runnerLog keeps the runner's output for export, nil until the runner runs with log_export_path set
+	runnerLog *logTail
+}
+
+// downloader returns the HTTP client the runner is downloaded with
+func (gb *GitHubBootstrap) downloader() HTTPClient {
+	if gb.downloadClient != nil {
+		return gb.downloadClient
+	}
+	return gb.httpClient
+}
+
+// installPath returns the directory holding the runner installation
+func (gb *GitHubBootstrap) installPath() string {
+	if gb.cachedInstallPath != "" {
+		return gb.cachedInstallPath
+	}
+	if gb.config.Runner.InstallPath != "" {
+		return gb.config.Runner.InstallPath
+	}
+	return DefaultInstallPath
+}
+
+// workDir returns the directory the runner runs jobs in
+func (gb *GitHubBootstrap) workDir() string {
+	base := gb.config.Runner.WorkDir
+	if base == "" {
+		base = DefaultWorkDir
+	}
+	if !gb.config.Runner.RandomizeWorkDir {
+		return base
+	}
+	if gb.bootWorkDir == "" {
+		gb.bootWorkDir = filepath.Join(base, BootWorkDirPrefix+strings.ToLower(rand.Text()))
+	}
+	return gb.bootWorkDir
 }
 
 // NewGitHubBootstrap creates a new GitHubBootstrap with the given dependencies
@@ -88,11 +408,16 @@ func NewGitHubBootstrap(config *RunnerConfig, logger Logger, httpClient HTTPClie
 		fileSystem: fileSystem,
 		executor:   executor,
 		system:     system,
+		verifier:   NewGHAttestationVerifier(executor, RunnerAttestationRepo),
+		now:        time.Now,
 	}
 }
 
 func main() {
 	configPath := flag.String("config", DefaultConfigPath, "Path to runner configuration")
+	logLevel := flag.String("log-level", "", "Log verbosity: error, info or debug (default: log_level from the config, or info)")
+	prepareOnly := flag.Bool("prepare-only", false,
+		"Download and configure the runner, then exit without running it or shutting the VM down")
 	flag.Parse()
 
 	// Load configuration
@@ -101,21 +426,40 @@ func main() {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
+	// The flag overrides the configured level, e.g. to debug a VM image by hand
+	if *logLevel == "" {
+		*logLevel = config.LogLevel
+	}
+	level, err := ParseLogLevel(*logLevel)
+	if err != nil {
+		log.Fatalf("Invalid log level: %v", err)
+	}
+
 	// Initialize bootstrap service based on method
 	switch config.Method {
 	case runnerTokenMethod:
 		bootstrap := NewGitHubBootstrap(
 			config,
-			NewRealLogger("[github-bootstrap] "),
+			NewRealLoggerWithLevel("[github-bootstrap] ", level),
 			NewRealHTTPClient(HTTPTimeoutSeconds*time.Second),
 			NewRealFileSystem(),
 			NewRealCommandExecutor(),
 			NewRealSystemOperations(),
 		)
 
+		bootstrap.prepareOnly = *prepareOnly
+
+		downloadTLS, err := downloadTLSConfig(config.Runner)
+		if err != nil {
+			log.Fatalf("Invalid download TLS settings: %v", err)
+		}
+		if downloadTLS != nil {
+			bootstrap.downloadClient = NewRealHTTPClientWithTLS(HTTPTimeoutSeconds*time.Second, downloadTLS)
+		}
+
 		// Handle SPIFFE attestation if enabled (independent of runner token)
 		if config.SPIFFE.Enabled {
-			if err := bootstrap.performSPIFFEAttestation(); err != nil {
+			if err := bootstrap.performSPIFFEAttestation(context.Background()); err != nil {
 				log.Fatalf("SPIFFE attestation failed: %v", err)
 			}
 		}
@@ -133,6 +477,36 @@ func main() {
 	}
 }
 
+// downloadTLSConfig builds the TLS configuration for the runner download from the runner
+// settings, or nil when the default configuration applies
+func downloadTLSConfig(settings RunnerSettings) (*tls.Config, error) {
+	if settings.DownloadCAFile == "" && !settings.DownloadInsecureSkipVerify {
+		return nil, nil
+	}
+
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// #nosec G402 - explicitly requested for mirrors whose certificates cannot be verified
+		InsecureSkipVerify: settings.DownloadInsecureSkipVerify,
+	}
+	if settings.DownloadCAFile != "" {
+		// #nosec G304 - the CA bundle path comes from the VM's runner configuration
+		pem, err := os.ReadFile(settings.DownloadCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read download CA file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("download CA file %s contains no PEM certificates", settings.DownloadCAFile)
+		}
+		config.RootCAs = pool
+	}
+	return config, nil
+}
+
 // loadRunnerConfig loads the runner configuration from the specified file
 func loadRunnerConfig(configPath string) (*RunnerConfig, error) {
 	// #nosec G304 - configPath is provided via command line flag, not user input
@@ -153,72 +527,177 @@ func loadRunnerConfig(configPath string) (*RunnerConfig, error) {
 func (gb *GitHubBootstrap) Run(ctx context.Context) error {
 	gb.logger.Printf("Starting GitHub runner bootstrap for %s", gb.config.RunnerName)
 
+	if err := validateShutdownMode(gb.config.ShutdownMode); err != nil {
+		return err
+	}
+
+	phase, err := gb.runLifecycle(ctx)
+
+	// Report before cleanup, which shuts the VM down
+	gb.emitMetrics(ctx)
+	gb.reportCompletion(ctx, phase, err)
+	if err != nil {
+		return err
+	}
+
+	if gb.prepareOnly {
+		// The runner never ran, so only its registration is undone
+		gb.deregisterRunner(context.WithoutCancel(ctx))
+		gb.logger.Printf("Runner %s prepared, exiting without running it", gb.config.RunnerName)
+		return nil
+	}
+
+	// 5. Cleanup and self-terminate
+	return gb.cleanup(ctx)
+}
+
+// runLifecycle downloads, configures and runs the runner, returning the phase that failed,
+// PhasePrepared when prepare-only stopped after configuring, or PhaseCompleted
+func (gb *GitHubBootstrap) runLifecycle(ctx context.Context) (string, error) {
 	// 1. Download GitHub Actions runner
-	if err := gb.downloadGitHubRunner(ctx); err != nil {
-		return fmt.Errorf("failed to download runner: %w", err)
+	if err := gb.timePhase(PhaseDownload, func() error { return gb.downloadGitHubRunner(ctx) }); err != nil {
+		return PhaseDownload, fmt.Errorf("failed to download runner: %w", err)
 	}
 
 	// 2. Configure runner with registration token
-	if err := gb.configureRunner(ctx); err != nil {
-		return fmt.Errorf("failed to configure runner: %w", err)
+	if err := gb.timePhase(PhaseConfigure, func() error { return gb.configureRunner(ctx) }); err != nil {
+		return PhaseConfigure, fmt.Errorf("failed to configure runner: %w", err)
+	}
+	if gb.prepareOnly {
+		return PhasePrepared, nil
 	}
 
-	// 3. Start runner and monitor
-	if err := gb.runAndMonitor(ctx); err != nil {
-		return fmt.Errorf("failed to run runner: %w", err)
+	// 3. Run the pre-start hooks, e.g. mounting caches or configuring Docker
+	if len(gb.config.Runner.PreStartHooks) > 0 {
+		if err := gb.timePhase(PhasePreStart, func() error { return gb.runPreStartHooks(ctx) }); err != nil {
+			return PhasePreStart, fmt.Errorf("failed to run pre-start hooks: %w", err)
+		}
 	}
 
-	// 4. Cleanup and self-terminate
-	return gb.cleanup(ctx)
+	// 4. Start runner and monitor
+	if err := gb.timePhase(PhaseRun, func() error { return gb.runAndMonitor(ctx) }); err != nil {
+		return PhaseRun, fmt.Errorf("failed to run runner: %w", err)
+	}
+
+	return PhaseCompleted, nil
+}
+
+// reportCompletion POSTs the lifecycle result to the completion webhook, if configured.
+// It is best-effort: failures are logged and never fail the bootstrap.
+func (gb *GitHubBootstrap) reportCompletion(ctx context.Context, phase string, runErr error) {
+	webhookURL := gb.config.CompletionWebhookURL
+	if webhookURL == "" {
+		return
+	}
+
+	result := CompletionResult{
+		RunnerName: gb.config.RunnerName,
+		Phase:      phase,
+	}
+	if runErr != nil {
+		result.Error = runErr.Error()
+	}
+
+	body, err := json.Marshal(result)
+	if err != nil {
+		gb.logger.Errorf("Warning: failed to encode completion webhook body: %v", err)
+		return
+	}
+
+	// Report even when the run was canceled, but never hold up the shutdown for long
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), WebhookTimeoutSeconds*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		gb.logger.Errorf("Warning: failed to create completion webhook request: %v", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := gb.httpClient.Do(req)
+	if err != nil {
+		gb.logger.Errorf("Warning: completion webhook failed: %v", err)
+		return
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		gb.logger.Errorf("Warning: completion webhook returned HTTP %d", resp.StatusCode)
+		return
+	}
+	gb.logger.Printf("Reported %s phase to completion webhook", phase)
 }
 
 // downloadGitHubRunner downloads and extracts the GitHub Actions runner using HTTP client
 func (gb *GitHubBootstrap) downloadGitHubRunner(ctx context.Context) error {
-	installPath := gb.config.Runner.InstallPath
-	if installPath == "" {
-		installPath = DefaultInstallPath
+	installPath := gb.installPath()
+
+	modes, err := parseExtractModes(gb.config.Runner)
+	if err != nil {
+		return err
 	}
 
 	downloadURL := gb.buildDownloadURL()
-	gb.logger.Printf("Downloading GitHub Actions runner from %s to %s", downloadURL, installPath)
+	if cachePath, ok := gb.runnerCache(downloadURL); ok {
+		gb.logger.Printf("Using pre-staged GitHub Actions runner at %s", cachePath)
+		gb.cachedInstallPath = cachePath
+		return gb.verifyRunnerArch()
+	}
+	if err := gb.checkDownloadHost(downloadURL); err != nil {
+		return err
+	}
+	gb.logger.Printf("Downloading GitHub Actions runner to %s", installPath)
+	gb.logger.Debugf("Runner download URL: %s", downloadURL)
 
 	// Create runner directory
 	if err := gb.fileSystem.MkdirAll(installPath, DirPermissions); err != nil {
 		return fmt.Errorf("failed to create install directory: %w", err)
 	}
 
-	// Download the file
-	req, err := http.NewRequestWithContext(ctx, "GET", downloadURL, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+	// Download the archive to a partial file so interrupted transfers can be resumed
+	archivePath := installPath + PartialDownloadSuffix
+	if err := gb.downloadArchive(ctx, downloadURL, archivePath); err != nil {
+		return err
+	}
+	defer func() {
+		if err := gb.fileSystem.RemoveAll(archivePath); err != nil {
+			gb.logger.Errorf("Warning: failed to remove downloaded archive %s: %v", archivePath, err)
+		}
+	}()
+
+	// Fail closed: an unverifiable archive is never extracted
+	if gb.config.Runner.VerifyAttestation {
+		if err := gb.verifyAttestation(ctx, archivePath); err != nil {
+			return err
+		}
 	}
 
-	resp, err := gb.httpClient.Do(req)
+	archive, err := gb.fileSystem.Open(archivePath)
 	if err != nil {
-		return fmt.Errorf("failed to download runner: %w", err)
+		return fmt.Errorf("failed to open downloaded archive: %w", err)
 	}
 	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			gb.logger.Printf("Warning: failed to close response body: %v", err)
+		if err := archive.Close(); err != nil {
+			gb.logger.Errorf("Warning: failed to close downloaded archive: %v", err)
 		}
 	}()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to download runner: HTTP %d", resp.StatusCode)
-	}
-
-	// Extract tar.gz directly from response body
-	gzipReader, err := gzip.NewReader(resp.Body)
+	// Extract tar.gz from the downloaded archive
+	gzipReader, err := gzip.NewReader(archive)
 	if err != nil {
 		return fmt.Errorf("failed to create gzip reader: %w", err)
 	}
 	defer func() {
 		if err := gzipReader.Close(); err != nil {
-			gb.logger.Printf("Warning: failed to close gzip reader: %v", err)
+			gb.logger.Errorf("Warning: failed to close gzip reader: %v", err)
 		}
 	}()
 
 	tarReader := tar.NewReader(gzipReader)
+	limiter := newExtractLimiter(tarReader, gb.config.Runner)
 
 	// Extract files
 	for {
@@ -238,11 +717,14 @@ func (gb *GitHubBootstrap) downloadGitHubRunner(ctx context.Context) error {
 		if header.Name != "./" && !strings.HasPrefix(targetPath, filepath.Clean(installPath)+string(os.PathSeparator)) {
 			return fmt.Errorf("invalid file path in archive: %s", header.Name)
 		}
+		if gb.config.Runner.RestrictExtraction && !inRunnerLayout(header.Name, gb.config.Runner) {
+			gb.logger.Errorf("Warning: skipping archive entry outside the runner layout: %s", header.Name)
+			continue
+		}
 
 		switch header.Typeflag {
 		case tar.TypeDir:
-			// #nosec G115 - header.Mode is from tar header, safe conversion
-			if err := gb.fileSystem.MkdirAll(targetPath, os.FileMode(header.Mode)); err != nil {
+			if err := gb.fileSystem.MkdirAll(targetPath, modes.dirMode(header)); err != nil {
 				return fmt.Errorf("failed to create directory %s: %w", targetPath, err)
 			}
 		case tar.TypeReg:
@@ -252,49 +734,312 @@ func (gb *GitHubBootstrap) downloadGitHubRunner(ctx context.Context) error {
 			}
 
 			// #nosec G304 - targetPath is validated above for path traversal
-			// #nosec G115 - header.Mode is from tar header, safe conversion
-			file, err := gb.fileSystem.OpenFile(targetPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode))
+			file, err := gb.fileSystem.OpenFile(targetPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, modes.fileMode(header))
 			if err != nil {
 				return fmt.Errorf("failed to create file %s: %w", targetPath, err)
 			}
 
-			// #nosec G110 - This is extracting a trusted GitHub Actions runner archive
-			if _, err := io.Copy(file, tarReader); err != nil {
+			// #nosec G110 - Extracted sizes are bounded by the limiter
+			limiter.startFile(header.Name)
+			if _, err := io.Copy(file, limiter); err != nil {
 				if closeErr := file.Close(); closeErr != nil {
-					gb.logger.Printf("Warning: failed to close file during error: %v", closeErr)
+					gb.logger.Errorf("Warning: failed to close file during error: %v", closeErr)
 				}
 				return fmt.Errorf("failed to write file %s: %w", targetPath, err)
 			}
 			if err := file.Close(); err != nil {
 				return fmt.Errorf("failed to close file %s: %w", targetPath, err)
 			}
+			gb.logger.Debugf("Extracted %s", header.Name)
 		}
 	}
 
 	gb.logger.Printf("Successfully downloaded and extracted GitHub Actions runner")
+	return gb.verifyRunnerArch()
+}
+
+// inRunnerLayout reports whether the archive entry name belongs to the runner release layout:
+// the archive root, a RunnerLayoutDirs directory or anything under it, a RunnerLayoutFiles file,
+// or the configured config and run scripts
+func inRunnerLayout(name string, settings RunnerSettings) bool {
+	name = path.Clean(name)
+	if name == "." {
+		return true
+	}
+	top, _, _ := strings.Cut(name, "/")
+	if slices.Contains(RunnerLayoutDirs, top) {
+		return true
+	}
+	return slices.Contains(RunnerLayoutFiles, name) ||
+		(settings.ConfigScript != "" && name == path.Clean(settings.ConfigScript)) ||
+		(settings.RunScript != "" && name == path.Clean(settings.RunScript))
+}
+
+// verifyRunnerArch fails early when verify_arch is set and the installed runner is built for
+// another architecture than this VM's, e.g. after a wrong arch or download_url, instead of
+// letting run.sh fail cryptically
+func (gb *GitHubBootstrap) verifyRunnerArch() error {
+	if !gb.config.Runner.VerifyArch {
+		return nil
+	}
+	detector := gb.archDetector
+	if detector == nil {
+		detector = ELFArchDetector{}
+	}
+
+	probe := filepath.Join(gb.installPath(), RunnerArchProbe)
+	arch, err := detector.DetectArch(probe)
+	if err != nil {
+		return fmt.Errorf("failed to detect runner architecture: %w", err)
+	}
+	if arch != runtime.GOARCH {
+		return fmt.Errorf("runner at %s is built for %s but this VM is %s; check runner.arch and download_url",
+			gb.installPath(), arch, runtime.GOARCH)
+	}
+	gb.logger.Printf("Runner architecture %s matches this VM", arch)
+	return nil
+}
+
+// runnerCache returns the configured runner cache path when it holds the expected runner version.
+// A missing, unreadable or mismatched cache is not an error: the runner is downloaded instead.
+func (gb *GitHubBootstrap) runnerCache(downloadURL string) (string, bool) {
+	cachePath := gb.config.Runner.CachePath
+	if cachePath == "" {
+		return "", false
+	}
+
+	expected := gb.config.Runner.Version
+	if expected == "" {
+		if match := runnerArchiveVersionPattern.FindStringSubmatch(downloadURL); match != nil {
+			expected = match[1]
+		}
+	}
+	if expected == "" {
+		gb.logger.Printf("Ignoring runner cache %s: runner version unknown, set runner.version", cachePath)
+		return "", false
+	}
+
+	marker, err := gb.fileSystem.Open(filepath.Join(cachePath, RunnerCacheMarker))
+	if err != nil {
+		gb.logger.Printf("Runner cache %s not found, downloading: %v", cachePath, err)
+		return "", false
+	}
+	defer func() {
+		_ = marker.Close()
+	}()
+	data, err := io.ReadAll(io.LimitReader(marker, maxRunnerCacheMarkerBytes))
+	if err != nil {
+		gb.logger.Printf("Failed to read runner cache marker, downloading: %v", err)
+		return "", false
+	}
+
+	cached := strings.TrimPrefix(strings.TrimSpace(string(data)), "v")
+	if cached != strings.TrimPrefix(expected, "v") {
+		gb.logger.Printf("Runner cache %s holds version %s, want %s, downloading", cachePath, cached, expected)
+		return "", false
+	}
+	return cachePath, true
+}
+
+// extractLimiter counts the bytes read from an archive and fails the read once the total
+// or the current file exceeds its limit, so a decompression bomb never fills the disk
+type extractLimiter struct {
+	reader io.Reader
+
+	maxTotal int64
+	maxFile  int64
+
+	total int64  // bytes extracted from the whole archive
+	file  int64  // bytes extracted from the current file
+	name  string // name of the current file
+}
+
+// newExtractLimiter wraps an archive reader with the configured extraction limits
+func newExtractLimiter(reader io.Reader, settings RunnerSettings) *extractLimiter {
+	limiter := &extractLimiter{
+		reader:   reader,
+		maxTotal: settings.MaxExtractedBytes,
+		maxFile:  settings.MaxExtractedFileBytes,
+	}
+	if limiter.maxTotal <= 0 {
+		limiter.maxTotal = DefaultMaxExtractedBytes
+	}
+	if limiter.maxFile <= 0 {
+		limiter.maxFile = DefaultMaxExtractedFileBytes
+	}
+	return limiter
+}
+
+// startFile resets the per-file count for the next archive entry
+func (l *extractLimiter) startFile(name string) {
+	l.name = name
+	l.file = 0
+}
+
+func (l *extractLimiter) Read(p []byte) (int, error) {
+	n, err := l.reader.Read(p)
+	l.total += int64(n)
+	l.file += int64(n)
+
+	if l.file > l.maxFile {
+		return n, fmt.Errorf("archive file %s exceeds the maximum extracted file size of %d bytes", l.name, l.maxFile)
+	}
+	if l.total > l.maxTotal {
+		return n, fmt.Errorf("archive exceeds the maximum extracted size of %d bytes", l.maxTotal)
+	}
+	return n, err
+}
+
+// verifyAttestation verifies the signed build attestation of the downloaded runner archive
+func (gb *GitHubBootstrap) verifyAttestation(ctx context.Context, archivePath string) error {
+	if gb.verifier == nil {
+		return fmt.Errorf("attestation verification enabled but no verifier is configured")
+	}
+
+	gb.logger.Printf("Verifying runner attestation for %s", archivePath)
+	if err := gb.verifier.Verify(ctx, archivePath); err != nil {
+		return fmt.Errorf("failed to verify runner attestation: %w", err)
+	}
+
+	gb.logger.Printf("Runner attestation verified")
 	return nil
 }
 
+// downloadStatusError reports an HTTP status that retrying will not fix
+type downloadStatusError struct {
+	statusCode int
+}
+
+func (e *downloadStatusError) Error() string {
+	return fmt.Sprintf("failed to download runner: HTTP %d", e.statusCode)
+}
+
+// checkDownloadHost rejects download URLs whose host is not on the allowlist, so a
+// tampered config cannot make the VM fetch and execute code from an arbitrary host
+func (gb *GitHubBootstrap) checkDownloadHost(downloadURL string) error {
+	parsed, err := url.Parse(downloadURL)
+	if err != nil || parsed.Hostname() == "" {
+		return fmt.Errorf("invalid download URL %q", downloadURL)
+	}
+
+	host := parsed.Hostname()
+	allowed := append(slices.Clone(DefaultAllowedDownloadHosts), gb.config.Runner.AllowedDownloadHosts...)
+	for _, allowedHost := range allowed {
+		if strings.EqualFold(host, strings.TrimSpace(allowedHost)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("download host %q is not allowed; add it to runner.allowed_download_hosts", host)
+}
+
+// downloadArchive downloads url to path, retrying failed transfers. Retries resume from the
+// end of the partial file with a Range request when the server advertises byte-range support.
+func (gb *GitHubBootstrap) downloadArchive(ctx context.Context, url, path string) error {
+	acceptsRanges := false
+	var lastErr error
+
+	for attempt := 1; attempt <= DownloadMaxAttempts; attempt++ {
+		if attempt > 1 {
+			if ctx.Err() != nil {
+				return fmt.Errorf("failed to download runner: %w", ctx.Err())
+			}
+			gb.logger.Printf("Retrying download (attempt %d/%d) after error: %v", attempt, DownloadMaxAttempts, lastErr)
+			gb.system.Sleep(DownloadRetryDelaySeconds)
+		}
+
+		var err error
+		acceptsRanges, err = gb.downloadAttempt(ctx, url, path, acceptsRanges)
+		if err == nil {
+			return nil
+		}
+
+		var statusErr *downloadStatusError
+		if errors.As(err, &statusErr) {
+			return err
+		}
+		lastErr = err
+	}
+
+	return lastErr
+}
+
+// downloadAttempt performs a single download request and reports whether the server accepts
+// byte ranges. When resume is set, the transfer continues from the end of the existing file.
+func (gb *GitHubBootstrap) downloadAttempt(ctx context.Context, url, path string, resume bool) (bool, error) {
+	var offset int64
+	if resume {
+		if info, err := gb.fileSystem.Stat(path); err == nil {
+			offset = info.Size()
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := gb.downloader().Do(req)
+	if err != nil {
+		return resume, fmt.Errorf("failed to download runner: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			gb.logger.Errorf("Warning: failed to close response body: %v", err)
+		}
+	}()
+
+	acceptsRanges := resp.Header.Get("Accept-Ranges") == "bytes"
+
+	flag := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		gb.logger.Printf("Resuming download at byte %d", offset)
+		flag = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	case resp.StatusCode == http.StatusOK:
+		if offset > 0 {
+			gb.logger.Printf("Server ignored range request, restarting download")
+		}
+	default:
+		return acceptsRanges, &downloadStatusError{statusCode: resp.StatusCode}
+	}
+
+	file, err := gb.fileSystem.OpenFile(path, flag, FilePermissions)
+	if err != nil {
+		return acceptsRanges, fmt.Errorf("failed to create download file %s: %w", path, err)
+	}
+
+	_, copyErr := io.Copy(file, resp.Body)
+	if closeErr := file.Close(); closeErr != nil && copyErr == nil {
+		copyErr = closeErr
+	}
+	if copyErr != nil {
+		return acceptsRanges, fmt.Errorf("failed to write download file %s: %w", path, copyErr)
+	}
+
+	return acceptsRanges, nil
+}
+
 // configureRunner configures the GitHub Actions runner with the registration token
 func (gb *GitHubBootstrap) configureRunner(ctx context.Context) error {
 	gb.logger.Printf("Configuring runner %s", gb.config.RunnerName)
 
-	installPath := gb.config.Runner.InstallPath
-	if installPath == "" {
-		installPath = DefaultInstallPath
+	if gb.config.RunnerTokenURL != "" {
+		if err := gb.fetchRunnerToken(ctx, gb.httpClient, gb.config.RunnerTokenURL); err != nil {
+			return err
+		}
 	}
 
-	workDir := gb.config.Runner.WorkDir
-	if workDir == "" {
-		workDir = DefaultWorkDir
-	}
+	installPath := gb.installPath()
 
-	configScript := gb.config.Runner.ConfigScript
-	if configScript == "" {
-		configScript = DefaultConfigScript
-	}
+	workDir := gb.workDir()
 
-	configScriptPath := filepath.Join(installPath, configScript)
+	configScriptPath := gb.configScriptPath()
+	if err := gb.checkScript("config", configScriptPath); err != nil {
+		return err
+	}
 
 	args := []string{
 		"--url", gb.config.RegistrationURL,
@@ -303,26 +1048,178 @@ func (gb *GitHubBootstrap) configureRunner(ctx context.Context) error {
 		"--labels", strings.Join(gb.config.Labels, ","),
 		"--work", workDir,
 		"--unattended",
-		"--ephemeral", // Auto-cleanup after job
+	}
+	if gb.isEphemeral() {
+		args = append(args, "--ephemeral") // Auto-cleanup after job
+	}
+	if gb.config.Runner.ReplaceExisting {
+		args = append(args, "--replace")
+	}
+	if gb.config.RunnerGroup != "" {
+		args = append(args, "--runnergroup", gb.config.RunnerGroup)
+	}
+
+	env, err := gb.runnerEnv()
+	if err != nil {
+		return err
 	}
 
+	maxAttempts := gb.config.Runner.ConfigureMaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = ConfigureMaxAttempts
+	}
+	delay := gb.config.Runner.ConfigureRetryDelaySeconds
+	if delay <= 0 {
+		delay = ConfigureRetryDelaySeconds
+	}
+
+	for attempt := 1; ; attempt++ {
+		output, err := gb.runConfigScript(ctx, configScriptPath, installPath, args, env)
+		if err == nil {
+			return nil
+		}
+		if !isRetryableRegistrationError(err, output) {
+			return fmt.Errorf("runner registration failed permanently: %w", err)
+		}
+		if attempt >= maxAttempts {
+			return fmt.Errorf("runner registration failed after %d attempts: %w", attempt, err)
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("runner registration cancelled: %w", ctx.Err())
+		}
+
+		gb.logger.Printf("Runner registration failed (attempt %d/%d), retrying in %ds: %v", attempt, maxAttempts, delay, err)
+		gb.system.Sleep(delay)
+		delay = min(delay*ConfigureBackoffFactor, ConfigureMaxRetryDelaySeconds)
+	}
+}
+
+// configScriptPath returns the path of the runner's config.sh
+func (gb *GitHubBootstrap) configScriptPath() string {
+	configScript := gb.config.Runner.ConfigScript
+	if configScript == "" {
+		configScript = DefaultConfigScript
+	}
+	return filepath.Join(gb.installPath(), configScript)
+}
+
+// checkScript returns a clear error when validate_scripts is set and the runner script at path
+// is missing, rather than leaving it to fail at exec
+func (gb *GitHubBootstrap) checkScript(kind, path string) error {
+	if !gb.config.Runner.ValidateScripts {
+		return nil
+	}
+	info, err := gb.fileSystem.Stat(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return fmt.Errorf("%s script not found at %s", kind, path)
+	case err != nil:
+		return fmt.Errorf("failed to check %s script at %s: %w", kind, path, err)
+	case info.IsDir():
+		return fmt.Errorf("%s script at %s is a directory", kind, path)
+	}
+	return nil
+}
+
+// fetchRunnerToken replaces the configured registration token and expiry with those served by
+// tokenURL, i.e. runner_token_url or the SPIFFE token_url
+func (gb *GitHubBootstrap) fetchRunnerToken(ctx context.Context, client HTTPClient, tokenURL string) error {
+	ctx, cancel := context.WithTimeout(ctx, TokenFetchTimeoutSeconds*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create registration token request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch registration token: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch registration token: HTTP %d", resp.StatusCode)
+	}
+
+	var token RunnerTokenResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxTokenResponseBytes)).Decode(&token); err != nil {
+		return fmt.Errorf("failed to parse registration token response: %w", err)
+	}
+	if token.Token == "" {
+		return fmt.Errorf("registration token response has no token")
+	}
+
+	gb.config.RunnerToken = token.Token
+	gb.config.ExpiresAt = token.ExpiresAt
+	gb.logger.Printf("Fetched registration token for %s", gb.config.RunnerName)
+	return nil
+}
+
+// runConfigScript runs config.sh once, streaming its output to the console and returning a copy for classification
+func (gb *GitHubBootstrap) runConfigScript(ctx context.Context, configScriptPath, installPath string, args, env []string) (string, error) {
+	var output bytes.Buffer
+
 	// #nosec G204 - configScriptPath is constructed from validated config, not user input
 	cmd := gb.executor.CommandContext(ctx, configScriptPath, args...)
 	cmd.SetDir(installPath)
-	cmd.SetStdout(os.Stdout)
-	cmd.SetStderr(os.Stderr)
+	cmd.SetEnv(env)
+	cmd.SetStdout(io.MultiWriter(os.Stdout, &output))
+	cmd.SetStderr(io.MultiWriter(os.Stderr, &output))
 
-	return cmd.Run()
+	err := cmd.Run()
+	return output.String(), err
+}
+
+// isRetryableRegistrationError reports whether a config.sh failure may succeed on retry.
+// A missing script, a cancelled context or a rejected token will not.
+func isRetryableRegistrationError(err error, output string) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, exec.ErrNotFound) || errors.Is(err, os.ErrNotExist) {
+		return false
+	}
+	for _, marker := range permanentRegistrationErrors {
+		if strings.Contains(output, marker) {
+			return false
+		}
+	}
+	return true
+}
+
+// runPreStartHooks runs each pre-start hook in order, stopping at the first that fails
+func (gb *GitHubBootstrap) runPreStartHooks(ctx context.Context) error {
+	env, err := gb.runnerEnv()
+	if err != nil {
+		return err
+	}
+
+	hooks := gb.config.Runner.PreStartHooks
+	for i, hook := range hooks {
+		gb.logger.Printf("Running pre-start hook %d/%d", i+1, len(hooks))
+
+		// #nosec G204 - hooks come from the runner configuration written by the operator
+		cmd := gb.executor.CommandContext(ctx, HookShell, "-c", hook)
+		cmd.SetDir(gb.installPath())
+		cmd.SetEnv(env)
+		cmd.SetStdout(os.Stdout)
+		cmd.SetStderr(os.Stderr)
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("pre-start hook %d failed: %w", i+1, err)
+		}
+	}
+	return nil
 }
 
 // runAndMonitor starts the GitHub Actions runner and monitors its execution
 func (gb *GitHubBootstrap) runAndMonitor(ctx context.Context) error {
 	gb.logger.Printf("Starting GitHub Actions runner")
 
-	installPath := gb.config.Runner.InstallPath
-	if installPath == "" {
-		installPath = DefaultInstallPath
-	}
+	installPath := gb.installPath()
 
 	runScript := gb.config.Runner.RunScript
 	if runScript == "" {
@@ -330,49 +1227,135 @@ func (gb *GitHubBootstrap) runAndMonitor(ctx context.Context) error {
 	}
 
 	runScriptPath := filepath.Join(installPath, runScript)
+	if err := gb.checkScript("run", runScriptPath); err != nil {
+		return err
+	}
+
+	env, err := gb.runnerEnv()
+	if err != nil {
+		return err
+	}
+
+	if gb.isEphemeral() {
+		// Runner will exit after job completion (ephemeral mode)
+		return gb.runJob(ctx, installPath, runScriptPath, env)
+	}
+
+	// Persistent runners take one job per run so the work directory can be reset in between
+	for job := 1; ; job++ {
+		gb.logger.Printf("Waiting for job %d", job)
+		if err := gb.runJob(ctx, installPath, runScriptPath, env, "--once"); err != nil {
+			if ctx.Err() != nil {
+				gb.logger.Printf("Runner stopped: %v", ctx.Err())
+				return nil
+			}
+			return err
+		}
+
+		if gb.config.Runner.CleanWorkDir {
+			if err := gb.cleanWorkDir(); err != nil {
+				return err
+			}
+		}
+
+		if ctx.Err() != nil {
+			gb.logger.Printf("Runner stopped: %v", ctx.Err())
+			return nil
+		}
+	}
+}
 
+// runJob runs the runner script until it exits
+func (gb *GitHubBootstrap) runJob(ctx context.Context, installPath, runScriptPath string, env []string, args ...string) error {
 	// #nosec G204 - runScriptPath is constructed from validated config, not user input
-	cmd := gb.executor.CommandContext(ctx, runScriptPath)
+	cmd := gb.executor.CommandContext(ctx, runScriptPath, args...)
 	cmd.SetDir(installPath)
-	cmd.SetStdout(os.Stdout)
-	cmd.SetStderr(os.Stderr)
+	cmd.SetEnv(env)
+	cmd.SetStdout(gb.runnerOutput(os.Stdout))
+	cmd.SetStderr(gb.runnerOutput(os.Stderr))
 
-	// Runner will exit after job completion (ephemeral mode)
 	return cmd.Run()
 }
 
-// cleanup performs cleanup operations and shuts down the VM
-func (gb *GitHubBootstrap) cleanup(_ context.Context) error {
-	gb.logger.Printf("Runner completed, initiating VM shutdown")
+// runnerEnv returns the configured runner environment as sorted KEY=VALUE entries
+func (gb *GitHubBootstrap) runnerEnv() ([]string, error) {
+	env := make([]string, 0, len(gb.config.Runner.Env))
+	for _, name := range slices.Sorted(maps.Keys(gb.config.Runner.Env)) {
+		if name == "" || strings.ContainsAny(name, "=\x00") {
+			return nil, fmt.Errorf("invalid runner environment variable name %q", name)
+		}
+		env = append(env, name+"="+gb.config.Runner.Env[name])
+	}
+	return env, nil
+}
 
-	// Clean up runner installation and work directory
-	installPath := gb.config.Runner.InstallPath
-	if installPath == "" {
-		installPath = DefaultInstallPath
+// cleanWorkDir removes everything in the work directory left behind by the previous job
+func (gb *GitHubBootstrap) cleanWorkDir() error {
+	workDir := gb.workDir()
+
+	gb.logger.Printf("Cleaning work directory %s", workDir)
+
+	if err := gb.fileSystem.RemoveAll(workDir); err != nil {
+		return fmt.Errorf("failed to clean work dir %s: %w", workDir, err)
+	}
+	if err := gb.fileSystem.MkdirAll(workDir, DirPermissions); err != nil {
+		return fmt.Errorf("failed to recreate work dir %s: %w", workDir, err)
 	}
 
-	workDir := gb.config.Runner.WorkDir
-	if workDir == "" {
-		workDir = DefaultWorkDir
+	return nil
+}
+
+// isEphemeral reports whether the runner exits after a single job
+func (gb *GitHubBootstrap) isEphemeral() bool {
+	return gb.config.Runner.Ephemeral == nil || *gb.config.Runner.Ephemeral
+}
+
+// validateShutdownMode checks the configured shutdown mode; empty selects ShutdownModeSelf
+func validateShutdownMode(mode string) error {
+	switch mode {
+	case "", ShutdownModeSelf, ShutdownModeProvider:
+		return nil
+	default:
+		return fmt.Errorf("invalid shutdown_mode %q: must be %q or %q", mode, ShutdownModeSelf, ShutdownModeProvider)
 	}
+}
+
+// cleanup performs cleanup operations and shuts down the VM, or in ShutdownModeProvider
+// signals completion so the operator can power the VM off
+func (gb *GitHubBootstrap) cleanup(ctx context.Context) error {
+	gb.logger.Printf("Runner completed, initiating VM shutdown")
+
+	// Deregister while config.sh is still installed
+	gb.deregisterRunner(context.WithoutCancel(ctx))
+
+	// Clean up runner installation and work directory
+	installPath := gb.installPath()
+
+	workDir := gb.workDir()
 
 	// Remove directories (non-fatal if they fail)
 	if err := gb.fileSystem.RemoveAll(installPath); err != nil {
-		gb.logger.Printf("Warning: failed to remove install path %s: %v", installPath, err)
+		gb.logger.Errorf("Warning: failed to remove install path %s: %v", installPath, err)
 	}
 
 	if err := gb.fileSystem.RemoveAll(workDir); err != nil {
-		gb.logger.Printf("Warning: failed to remove work dir %s: %v", workDir, err)
+		gb.logger.Errorf("Warning: failed to remove work dir %s: %v", workDir, err)
 	}
 
 	// Give a moment for cleanup to complete
 	gb.system.Sleep(CleanupDelaySeconds)
 
+	gb.exportLogs(CompletionResult{RunnerName: gb.config.RunnerName, Phase: PhaseCompleted})
+
+	if gb.config.ShutdownMode == ShutdownModeProvider {
+		return gb.signalCompletion()
+	}
+
 	// Shutdown the VM using multiple methods
 	gb.logger.Printf("Shutting down VM")
 
 	if err := gb.shutdownVM(); err != nil {
-		gb.logger.Printf("VM shutdown failed: %v", err)
+		gb.logger.Errorf("VM shutdown failed: %v", err)
 		gb.logger.Printf("VM cleanup completed, but shutdown failed - operator will handle VM cleanup")
 		// Return nil so the bootstrap service exits cleanly
 		// The operator's VM monitoring will detect the stopped process and clean up the VM
@@ -383,6 +1366,95 @@ func (gb *GitHubBootstrap) cleanup(_ context.Context) error {
 	return nil
 }
 
+// deregisterRunner removes the runner's registration with config.sh when a removal token is
+// configured, retrying failures with backoff, then waits for GitHub to finalize the removal.
+// Failures are logged, never fatal: shutdown proceeds once the attempts are exhausted.
+func (gb *GitHubBootstrap) deregisterRunner(ctx context.Context) {
+	if gb.config.RemoveToken == "" {
+		return
+	}
+	gb.logger.Printf("Deregistering runner %s", gb.config.RunnerName)
+
+	env, err := gb.runnerEnv()
+	if err != nil {
+		gb.logger.Errorf("Warning: failed to deregister runner %s: %v", gb.config.RunnerName, err)
+		return
+	}
+
+	maxAttempts := gb.config.Runner.DeregisterMaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DeregisterMaxAttempts
+	}
+	delay := DeregisterRetryDelaySeconds
+	for attempt := 1; ; attempt++ {
+		err := gb.removeRunner(ctx, env)
+		if err == nil {
+			break
+		}
+		if attempt >= maxAttempts {
+			gb.logger.Errorf("Warning: failed to deregister runner %s after %d attempts, it may be left registered: %v",
+				gb.config.RunnerName, attempt, err)
+			return
+		}
+
+		gb.logger.Printf("Runner deregistration failed (attempt %d/%d), retrying in %ds: %v", attempt, maxAttempts, delay, err)
+		gb.system.Sleep(delay)
+		delay = min(delay*ConfigureBackoffFactor, DeregisterMaxRetryDelaySeconds)
+	}
+
+	grace := gb.config.Runner.DeregisterGraceSeconds
+	if grace <= 0 {
+		grace = DeregisterGraceSeconds
+	}
+	gb.logger.Printf("Runner deregistered, waiting %ds for the removal to finalize", grace)
+	gb.system.Sleep(grace)
+}
+
+// removeRunner runs config.sh remove once
+func (gb *GitHubBootstrap) removeRunner(ctx context.Context, env []string) error {
+	// #nosec G204 - the config script path is constructed from validated config, not user input
+	cmd := gb.executor.CommandContext(ctx, gb.configScriptPath(), "remove", "--token", gb.config.RemoveToken)
+	cmd.SetDir(gb.installPath())
+	cmd.SetEnv(env)
+	cmd.SetStdout(os.Stdout)
+	cmd.SetStderr(os.Stderr)
+	return cmd.Run()
+}
+
+// signalCompletion writes the completed CompletionResult to the completion file, where the
+// operator picks it up and powers the VM off through the hypervisor
+func (gb *GitHubBootstrap) signalCompletion() error {
+	path := gb.config.CompletionFile
+	if path == "" {
+		path = DefaultCompletionFile
+	}
+
+	body, err := json.Marshal(CompletionResult{RunnerName: gb.config.RunnerName, Phase: PhaseCompleted})
+	if err != nil {
+		return fmt.Errorf("failed to encode completion result: %w", err)
+	}
+
+	if err := gb.fileSystem.MkdirAll(filepath.Dir(path), DirPermissions); err != nil {
+		return fmt.Errorf("failed to create completion file directory: %w", err)
+	}
+	file, err := gb.fileSystem.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, FilePermissions)
+	if err != nil {
+		return fmt.Errorf("failed to open completion file %s: %w", path, err)
+	}
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil {
+			gb.logger.Errorf("Warning: failed to close completion file: %v", closeErr)
+		}
+	}()
+
+	if _, err := gb.fileSystem.WriteString(file, string(body)); err != nil {
+		return fmt.Errorf("failed to write completion file %s: %w", path, err)
+	}
+
+	gb.logger.Printf("Signaled completion in %s, leaving the VM for the operator to power off", path)
+	return nil
+}
+
 // shutdownVM attempts to shutdown the VM using various methods
 func (gb *GitHubBootstrap) shutdownVM() error {
 	// Method 1: Try syscall approach (most reliable)
@@ -439,7 +1511,7 @@ func (gb *GitHubBootstrap) shutdownViaSysRq() error {
 	}
 	defer func() {
 		if closeErr := file.Close(); closeErr != nil {
-			gb.logger.Printf("Warning: failed to close sysrq-trigger: %v", closeErr)
+			gb.logger.Errorf("Warning: failed to close sysrq-trigger: %v", closeErr)
 		}
 	}()
 
@@ -465,14 +1537,14 @@ func (gb *GitHubBootstrap) shutdownViaPowerState() error {
 	}
 	defer func() {
 		if closeErr := file.Close(); closeErr != nil {
-			gb.logger.Printf("Warning: failed to close power state file: %v", closeErr)
+			gb.logger.Errorf("Warning: failed to close power state file: %v", closeErr)
 		}
 	}()
 
 	// Write "mem" to suspend to RAM, but we want to power off
 	// Actually, let's try writing to /sys/power/disk first
 	if err := file.Close(); err != nil {
-		gb.logger.Printf("Warning: failed to close power state file: %v", err)
+		gb.logger.Errorf("Warning: failed to close power state file: %v", err)
 	}
 
 	// Try the poweroff approach via /sys/power/disk
@@ -484,7 +1556,7 @@ func (gb *GitHubBootstrap) shutdownViaPowerState() error {
 	}
 	defer func() {
 		if closeErr := disk.Close(); closeErr != nil {
-			gb.logger.Printf("Warning: failed to close power disk file: %v", closeErr)
+			gb.logger.Errorf("Warning: failed to close power disk file: %v", closeErr)
 		}
 	}()
 
@@ -513,7 +1585,7 @@ func (gb *GitHubBootstrap) shutdownViaCommand() error {
 
 	var lastErr error
 	for _, cmdArgs := range shutdownCommands {
-		gb.logger.Printf("Attempting shutdown with: %v", cmdArgs)
+		gb.logger.Debugf("Attempting shutdown with: %v", cmdArgs)
 
 		// #nosec G204 - cmd is from predefined list, not user input
 		cmd := gb.executor.CommandContext(context.Background(), cmdArgs[0], cmdArgs[1:]...)
@@ -530,26 +1602,6 @@ func (gb *GitHubBootstrap) shutdownViaCommand() error {
 	return fmt.Errorf("all shutdown methods failed, last error: %w", lastErr)
 }
 
-// performSPIFFEAttestation handles SPIFFE attestation independently
-func (gb *GitHubBootstrap) performSPIFFEAttestation() error {
-	gb.logger.Printf("Performing SPIFFE attestation")
-
-	// TODO: Implement SPIFFE attestation logic
-	// This would involve:
-	// 1. Connecting to SPIRE agent
-	// 2. Obtaining SPIFFE identity
-	// 3. Validating identity against expected SPIFFE ID
-	// 4. Storing credentials securely
-
-	// For now, just validate that SPIFFE configuration is present
-	if gb.config.SPIFFE.JoinToken == "" && gb.config.SPIFFE.SPIFFEID == "" {
-		return fmt.Errorf("SPIFFE attestation enabled but no join token or SPIFFE ID provided")
-	}
-
-	gb.logger.Printf("SPIFFE attestation completed successfully")
-	return nil
-}
-
 // getOSArch returns the target OS and architecture from config or environment
 func (gb *GitHubBootstrap) getOSArch() (string, string) {
 	targetOS := gb.config.Runner.OS
@@ -578,7 +1630,7 @@ func (gb *GitHubBootstrap) buildDownloadURL() string {
 	}
 
 	targetOS, targetArch := gb.getOSArch()
-	gb.logger.Printf("Detected OS: %s, Arch: %s", targetOS, targetArch)
+	gb.logger.Debugf("Detected OS: %s, Arch: %s", targetOS, targetArch)
 
 	// Map Go arch names to GitHub runner arch names
 	archMap := map[string]string{
@@ -604,12 +1656,16 @@ func (gb *GitHubBootstrap) buildDownloadURL() string {
 		runnerOS = targetOS // fallback to original
 	}
 
-	// Construct URL based on GitHub Actions runner naming convention
-	version := "v2.311.0"      // TODO: Make this configurable
-	versionNumber := "2.311.0" // Version without 'v' prefix for filename
+	// Construct URL based on GitHub Actions runner naming convention: the release tag carries a
+	// 'v' prefix, the filename does not
+	versionNumber := strings.TrimPrefix(gb.config.Runner.Version, "v")
+	if versionNumber == "" {
+		versionNumber = DefaultRunnerVersion
+	}
+	version := "v" + versionNumber
 	filename := fmt.Sprintf("actions-runner-%s-%s-%s.tar.gz", runnerOS, runnerArch, versionNumber)
 	url := fmt.Sprintf("https://github.com/actions/runner/releases/download/%s/%s", version, filename)
 
-	gb.logger.Printf("Constructed download URL: %s", url)
+	gb.logger.Debugf("Constructed download URL: %s", url)
 	return url
 }
@@ -2,18 +2,127 @@ package main
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
-	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"syscall"
 	"testing"
 	"time"
 )
 
+func TestDownloadTLSConfig(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "mirror-ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, caPEM, 0600); err != nil {
+		t.Fatalf("Failed to write CA file: %v", err)
+	}
+	notPEM := filepath.Join(t.TempDir(), "not-a-ca.pem")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0600); err != nil {
+		t.Fatalf("Failed to write CA file: %v", err)
+	}
+
+	// get downloads from the mirror with the client used for the settings
+	get := func(config *tls.Config) error {
+		client := NewRealHTTPClient(5 * time.Second)
+		if config != nil {
+			client = NewRealHTTPClientWithTLS(5*time.Second, config)
+			transport, ok := client.client.Transport.(*http.Transport)
+			if !ok || transport.TLSClientConfig != config {
+				t.Errorf("Expected the transport to use the download TLS config, got %v", client.client.Transport)
+			}
+		}
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+
+	t.Run("default", func(t *testing.T) {
+		config, err := downloadTLSConfig(RunnerSettings{})
+		if err != nil || config != nil {
+			t.Fatalf("Expected no download TLS config, got %v (%v)", config, err)
+		}
+		if err := get(config); err == nil {
+			t.Errorf("Expected the mirror's certificate to be rejected by the system roots")
+		}
+	})
+
+	t.Run("CA bundle", func(t *testing.T) {
+		config, err := downloadTLSConfig(RunnerSettings{DownloadCAFile: caFile})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if config.RootCAs == nil || config.InsecureSkipVerify {
+			t.Errorf("Expected the CA bundle to be trusted with verification on, got %+v", config)
+		}
+		if err := get(config); err != nil {
+			t.Errorf("Expected the mirror to be trusted, got: %v", err)
+		}
+	})
+
+	t.Run("insecure", func(t *testing.T) {
+		config, err := downloadTLSConfig(RunnerSettings{DownloadInsecureSkipVerify: true})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if !config.InsecureSkipVerify || config.RootCAs != nil {
+			t.Errorf("Expected verification to be skipped without extra roots, got %+v", config)
+		}
+		if err := get(config); err != nil {
+			t.Errorf("Expected the mirror to be reachable, got: %v", err)
+		}
+	})
+
+	t.Run("invalid CA bundle", func(t *testing.T) {
+		if _, err := downloadTLSConfig(RunnerSettings{DownloadCAFile: notPEM}); err == nil || !strings.Contains(err.Error(), "contains no PEM certificates") {
+			t.Errorf("Expected a PEM error, got %v", err)
+		}
+		if _, err := downloadTLSConfig(RunnerSettings{DownloadCAFile: filepath.Join(t.TempDir(), "missing.pem")}); err == nil {
+			t.Errorf("Expected an error for a missing CA file")
+		}
+	})
+}
+
+func TestDownloaderUsesDownloadClient(t *testing.T) {
+	httpClient := &MockHTTPClient{}
+	bootstrap := NewGitHubBootstrap(&RunnerConfig{}, NewMockLogger(), httpClient, NewMockFileSystem(), NewMockCommandExecutor(), NewMockSystemOperations())
+	if bootstrap.downloader() != httpClient {
+		t.Errorf("Expected downloads to use the shared HTTP client by default")
+	}
+
+	downloadClient := &MockHTTPClient{}
+	bootstrap.downloadClient = downloadClient
+	if bootstrap.downloader() != downloadClient {
+		t.Errorf("Expected downloads to use the download client")
+	}
+}
+
 func TestLoadRunnerConfig(t *testing.T) {
 	// Create a temporary config file
 	tempDir := t.TempDir()
@@ -175,7 +284,7 @@ func TestGetOSArch(t *testing.T) {
 	config := &RunnerConfig{}
 	bootstrap := &GitHubBootstrap{
 		config: config,
-		logger: log.New(os.Stdout, "[test] ", log.LstdFlags),
+		logger: NewRealLogger("[test] "),
 	}
 
 	// Test with empty config (should use runtime defaults)
@@ -229,15 +338,7 @@ func TestBuildDownloadURL(t *testing.T) {
 		{
 			name: "custom download URL",
 			config: &RunnerConfig{
-				Runner: struct {
-					DownloadURL  string `json:"download_url,omitempty"`
-					InstallPath  string `json:"install_path,omitempty"`
-					WorkDir      string `json:"work_dir,omitempty"`
-					ConfigScript string `json:"config_script,omitempty"`
-					RunScript    string `json:"run_script,omitempty"`
-					OS           string `json:"os,omitempty"`
-					Arch         string `json:"arch,omitempty"`
-				}{
+				Runner: RunnerSettings{
 					DownloadURL: "https://custom.example.com/runner.tar.gz",
 				},
 			},
@@ -246,15 +347,7 @@ func TestBuildDownloadURL(t *testing.T) {
 		{
 			name: "linux x64 default",
 			config: &RunnerConfig{
-				Runner: struct {
-					DownloadURL  string `json:"download_url,omitempty"`
-					InstallPath  string `json:"install_path,omitempty"`
-					WorkDir      string `json:"work_dir,omitempty"`
-					ConfigScript string `json:"config_script,omitempty"`
-					RunScript    string `json:"run_script,omitempty"`
-					OS           string `json:"os,omitempty"`
-					Arch         string `json:"arch,omitempty"`
-				}{
+				Runner: RunnerSettings{
 					OS:   "linux",
 					Arch: "amd64",
 				},
@@ -264,15 +357,7 @@ func TestBuildDownloadURL(t *testing.T) {
 		{
 			name: "darwin arm64",
 			config: &RunnerConfig{
-				Runner: struct {
-					DownloadURL  string `json:"download_url,omitempty"`
-					InstallPath  string `json:"install_path,omitempty"`
-					WorkDir      string `json:"work_dir,omitempty"`
-					ConfigScript string `json:"config_script,omitempty"`
-					RunScript    string `json:"run_script,omitempty"`
-					OS           string `json:"os,omitempty"`
-					Arch         string `json:"arch,omitempty"`
-				}{
+				Runner: RunnerSettings{
 					OS:   "darwin",
 					Arch: "arm64",
 				},
@@ -282,15 +367,7 @@ func TestBuildDownloadURL(t *testing.T) {
 		{
 			name: "windows x86",
 			config: &RunnerConfig{
-				Runner: struct {
-					DownloadURL  string `json:"download_url,omitempty"`
-					InstallPath  string `json:"install_path,omitempty"`
-					WorkDir      string `json:"work_dir,omitempty"`
-					ConfigScript string `json:"config_script,omitempty"`
-					RunScript    string `json:"run_script,omitempty"`
-					OS           string `json:"os,omitempty"`
-					Arch         string `json:"arch,omitempty"`
-				}{
+				Runner: RunnerSettings{
 					OS:   "windows",
 					Arch: "386",
 				},
@@ -303,7 +380,7 @@ func TestBuildDownloadURL(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			bootstrap := &GitHubBootstrap{
 				config: tc.config,
-				logger: log.New(os.Stdout, "[test] ", log.LstdFlags),
+				logger: NewRealLogger("[test] "),
 			}
 
 			actualURL := bootstrap.buildDownloadURL()
@@ -323,11 +400,7 @@ func TestPerformSPIFFEAttestation(t *testing.T) {
 		{
 			name: "valid SPIFFE config with join token",
 			config: &RunnerConfig{
-				SPIFFE: struct {
-					JoinToken string `json:"join_token,omitempty"`
-					SPIFFEID  string `json:"spiffe_id,omitempty"`
-					Enabled   bool   `json:"enabled,omitempty"`
-				}{
+				SPIFFE: SPIFFESettings{
 					JoinToken: "test-join-token",
 					SPIFFEID:  "spiffe://example.com/test",
 					Enabled:   true,
@@ -338,11 +411,7 @@ func TestPerformSPIFFEAttestation(t *testing.T) {
 		{
 			name: "valid SPIFFE config with SPIFFE ID only",
 			config: &RunnerConfig{
-				SPIFFE: struct {
-					JoinToken string `json:"join_token,omitempty"`
-					SPIFFEID  string `json:"spiffe_id,omitempty"`
-					Enabled   bool   `json:"enabled,omitempty"`
-				}{
+				SPIFFE: SPIFFESettings{
 					SPIFFEID: "spiffe://example.com/test",
 					Enabled:  true,
 				},
@@ -352,11 +421,7 @@ func TestPerformSPIFFEAttestation(t *testing.T) {
 		{
 			name: "invalid SPIFFE config - no credentials",
 			config: &RunnerConfig{
-				SPIFFE: struct {
-					JoinToken string `json:"join_token,omitempty"`
-					SPIFFEID  string `json:"spiffe_id,omitempty"`
-					Enabled   bool   `json:"enabled,omitempty"`
-				}{
+				SPIFFE: SPIFFESettings{
 					Enabled: true,
 				},
 			},
@@ -368,10 +433,10 @@ func TestPerformSPIFFEAttestation(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			bootstrap := &GitHubBootstrap{
 				config: tc.config,
-				logger: log.New(os.Stdout, "[test] ", log.LstdFlags),
+				logger: NewRealLogger("[test] "),
 			}
 
-			err := bootstrap.performSPIFFEAttestation()
+			err := bootstrap.performSPIFFEAttestation(context.Background())
 			if tc.expectError && err == nil {
 				t.Error("Expected error but got nil")
 			}
@@ -382,6 +447,175 @@ func TestPerformSPIFFEAttestation(t *testing.T) {
 	}
 }
 
+// testCA issues certificates for the SPIFFE tests
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// newTestCA returns a self-signed CA
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate CA key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create CA certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Failed to parse CA certificate: %v", err)
+	}
+	return &testCA{cert: cert, key: key}
+}
+
+// issue returns a leaf certificate signed by the CA for template
+func (ca *testCA) issue(t *testing.T, template *x509.Certificate) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template.SerialNumber = big.NewInt(2)
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// writePEM writes der as a PEM block of blockType to a new file under dir
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestPerformSPIFFEAttestationFetchesToken(t *testing.T) {
+	const runnerID = "spiffe://example.org/hyperfleet/runner"
+
+	ca := newTestCA(t)
+	serverCert := ca.issue(t, &x509.Certificate{
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca.cert)
+
+	var fetchedBy []string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetchedBy = append(fetchedBy, r.TLS.PeerCertificates[0].URIs[0].String())
+		_, _ = w.Write([]byte(`{"token": "attested-token", "expires_at": "2025-03-14T10:26:53Z"}`))
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+		MinVersion:   tls.VersionTLS12,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	// writeSVID writes an SVID for spiffeID signed by issuer and returns the SPIFFE settings using it
+	writeSVID := func(t *testing.T, issuer *testCA, spiffeID string) SPIFFESettings {
+		id, err := url.Parse(spiffeID)
+		if err != nil {
+			t.Fatalf("Invalid SPIFFE ID: %v", err)
+		}
+		svid := issuer.issue(t, &x509.Certificate{
+			URIs:        []*url.URL{id},
+			ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+		})
+		keyDER, err := x509.MarshalPKCS8PrivateKey(svid.PrivateKey)
+		if err != nil {
+			t.Fatalf("Failed to encode SVID key: %v", err)
+		}
+		dir := t.TempDir()
+		return SPIFFESettings{
+			Enabled:     true,
+			SPIFFEID:    runnerID,
+			SVIDFile:    writePEM(t, dir, "svid.pem", "CERTIFICATE", svid.Certificate[0]),
+			SVIDKeyFile: writePEM(t, dir, "svid_key.pem", "PRIVATE KEY", keyDER),
+			BundleFile:  writePEM(t, dir, "bundle.pem", "CERTIFICATE", ca.cert.Raw),
+			TokenURL:    server.URL + "/runner-token",
+		}
+	}
+
+	tests := []struct {
+		name        string
+		settings    func(t *testing.T) SPIFFESettings
+		expectError string
+	}{
+		{
+			name:     "attested SVID fetches the token",
+			settings: func(t *testing.T) SPIFFESettings { return writeSVID(t, ca, runnerID) },
+		},
+		{
+			name:        "unexpected SPIFFE ID",
+			settings:    func(t *testing.T) SPIFFESettings { return writeSVID(t, ca, "spiffe://example.org/other") },
+			expectError: "SVID identifies spiffe://example.org/other, expected " + runnerID,
+		},
+		{
+			name:        "SVID from another trust domain",
+			settings:    func(t *testing.T) SPIFFESettings { return writeSVID(t, newTestCA(t), runnerID) },
+			expectError: "SVID is not trusted by the bundle",
+		},
+		{
+			name: "token URL without an SVID",
+			settings: func(t *testing.T) SPIFFESettings {
+				return SPIFFESettings{Enabled: true, SPIFFEID: runnerID, TokenURL: server.URL}
+			},
+			expectError: "no svid_file is configured",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetchedBy = nil
+			config := &RunnerConfig{RunnerToken: "unattested-token", SPIFFE: tt.settings(t)}
+			bootstrap := &GitHubBootstrap{config: config, logger: NewMockLogger()}
+
+			err := bootstrap.performSPIFFEAttestation(context.Background())
+
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Fatalf("Expected error containing %q, got %v", tt.expectError, err)
+				}
+				if len(fetchedBy) != 0 || config.RunnerToken != "unattested-token" {
+					t.Errorf("Expected no token fetch, got fetches by %v and token %q", fetchedBy, config.RunnerToken)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			if !slices.Equal(fetchedBy, []string{runnerID}) {
+				t.Errorf("Expected one fetch authenticated as %s, got %v", runnerID, fetchedBy)
+			}
+			if config.RunnerToken != "attested-token" || config.ExpiresAt != "2025-03-14T10:26:53Z" {
+				t.Errorf("Expected the attested token and expiry, got %q and %q", config.RunnerToken, config.ExpiresAt)
+			}
+		})
+	}
+}
+
 func TestRunMethodValidation(t *testing.T) {
 	testCases := []struct {
 		name           string
@@ -405,11 +639,7 @@ func TestRunMethodValidation(t *testing.T) {
 			name: "join-token method",
 			config: &RunnerConfig{
 				Method: "join-token",
-				SPIFFE: struct {
-					JoinToken string `json:"join_token,omitempty"`
-					SPIFFEID  string `json:"spiffe_id,omitempty"`
-					Enabled   bool   `json:"enabled,omitempty"`
-				}{
+				SPIFFE: SPIFFESettings{
 					JoinToken: "test-join-token",
 					Enabled:   true,
 				},
@@ -456,15 +686,7 @@ func TestCleanupDirectoryHandling(t *testing.T) {
 	// Create a test bootstrap instance
 	config := &RunnerConfig{
 		Method: "runner-token",
-		Runner: struct {
-			DownloadURL  string `json:"download_url,omitempty"`
-			InstallPath  string `json:"install_path,omitempty"`
-			WorkDir      string `json:"work_dir,omitempty"`
-			ConfigScript string `json:"config_script,omitempty"`
-			RunScript    string `json:"run_script,omitempty"`
-			OS           string `json:"os,omitempty"`
-			Arch         string `json:"arch,omitempty"`
-		}{
+		Runner: RunnerSettings{
 			InstallPath: testInstallPathAlt,
 			WorkDir:     "/tmp/test-work",
 		},
@@ -472,7 +694,7 @@ func TestCleanupDirectoryHandling(t *testing.T) {
 
 	bootstrap := &GitHubBootstrap{
 		config: config,
-		logger: log.New(os.Stdout, "[test] ", log.LstdFlags),
+		logger: NewRealLogger("[test] "),
 	}
 
 	// Test that cleanup handles missing directories gracefully
@@ -521,15 +743,7 @@ func TestArchitectureMapping(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.goArch, func(t *testing.T) {
 			config := &RunnerConfig{
-				Runner: struct {
-					DownloadURL  string `json:"download_url,omitempty"`
-					InstallPath  string `json:"install_path,omitempty"`
-					WorkDir      string `json:"work_dir,omitempty"`
-					ConfigScript string `json:"config_script,omitempty"`
-					RunScript    string `json:"run_script,omitempty"`
-					OS           string `json:"os,omitempty"`
-					Arch         string `json:"arch,omitempty"`
-				}{
+				Runner: RunnerSettings{
 					OS:   "linux",
 					Arch: tc.goArch,
 				},
@@ -537,7 +751,7 @@ func TestArchitectureMapping(t *testing.T) {
 
 			bootstrap := &GitHubBootstrap{
 				config: config,
-				logger: log.New(os.Stdout, "[test] ", log.LstdFlags),
+				logger: NewRealLogger("[test] "),
 			}
 
 			url := bootstrap.buildDownloadURL()
@@ -563,15 +777,7 @@ func TestOSMapping(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.goOS, func(t *testing.T) {
 			config := &RunnerConfig{
-				Runner: struct {
-					DownloadURL  string `json:"download_url,omitempty"`
-					InstallPath  string `json:"install_path,omitempty"`
-					WorkDir      string `json:"work_dir,omitempty"`
-					ConfigScript string `json:"config_script,omitempty"`
-					RunScript    string `json:"run_script,omitempty"`
-					OS           string `json:"os,omitempty"`
-					Arch         string `json:"arch,omitempty"`
-				}{
+				Runner: RunnerSettings{
 					OS:   tc.goOS,
 					Arch: "amd64",
 				},
@@ -579,7 +785,7 @@ func TestOSMapping(t *testing.T) {
 
 			bootstrap := &GitHubBootstrap{
 				config: config,
-				logger: log.New(os.Stdout, "[test] ", log.LstdFlags),
+				logger: NewRealLogger("[test] "),
 			}
 
 			url := bootstrap.buildDownloadURL()
@@ -628,7 +834,7 @@ func TestRuntimeDefaults(t *testing.T) {
 	config := &RunnerConfig{}
 	bootstrap := &GitHubBootstrap{
 		config: config,
-		logger: log.New(os.Stdout, "[test] ", log.LstdFlags),
+		logger: NewRealLogger("[test] "),
 	}
 
 	// Test that runtime defaults are used when config is empty
@@ -660,7 +866,7 @@ func TestConfigureRunnerArgs(t *testing.T) {
 
 	bootstrap := &GitHubBootstrap{
 		config: config,
-		logger: log.New(os.Stdout, "[test] ", log.LstdFlags),
+		logger: NewRealLogger("[test] "),
 	}
 
 	// Test that the configuration arguments are built correctly
@@ -710,7 +916,7 @@ func TestRunAndMonitorPaths(t *testing.T) {
 
 	bootstrap := &GitHubBootstrap{
 		config: config,
-		logger: log.New(os.Stdout, "[test] ", log.LstdFlags),
+		logger: NewRealLogger("[test] "),
 	}
 
 	// Test path construction for run script
@@ -741,7 +947,7 @@ func TestCleanupPaths(t *testing.T) {
 
 	bootstrap := &GitHubBootstrap{
 		config: config,
-		logger: log.New(os.Stdout, "[test] ", log.LstdFlags),
+		logger: NewRealLogger("[test] "),
 	}
 
 	// Test cleanup path logic
@@ -811,15 +1017,7 @@ func TestMainMethodSwitch(t *testing.T) {
 func TestDownloadURLConstruction(t *testing.T) {
 	// Test URL construction with different version scenarios
 	config := &RunnerConfig{
-		Runner: struct {
-			DownloadURL  string `json:"download_url,omitempty"`
-			InstallPath  string `json:"install_path,omitempty"`
-			WorkDir      string `json:"work_dir,omitempty"`
-			ConfigScript string `json:"config_script,omitempty"`
-			RunScript    string `json:"run_script,omitempty"`
-			OS           string `json:"os,omitempty"`
-			Arch         string `json:"arch,omitempty"`
-		}{
+		Runner: RunnerSettings{
 			OS:   "linux",
 			Arch: "amd64",
 		},
@@ -827,7 +1025,7 @@ func TestDownloadURLConstruction(t *testing.T) {
 
 	bootstrap := &GitHubBootstrap{
 		config: config,
-		logger: log.New(os.Stdout, "[test] ", log.LstdFlags),
+		logger: NewRealLogger("[test] "),
 	}
 
 	url := bootstrap.buildDownloadURL()
@@ -857,11 +1055,7 @@ func TestErrorHandlingInSPIFFE(t *testing.T) {
 		{
 			name: "SPIFFE enabled but no credentials",
 			config: &RunnerConfig{
-				SPIFFE: struct {
-					JoinToken string `json:"join_token,omitempty"`
-					SPIFFEID  string `json:"spiffe_id,omitempty"`
-					Enabled   bool   `json:"enabled,omitempty"`
-				}{
+				SPIFFE: SPIFFESettings{
 					Enabled: true,
 				},
 			},
@@ -871,11 +1065,7 @@ func TestErrorHandlingInSPIFFE(t *testing.T) {
 		{
 			name: "SPIFFE with empty strings",
 			config: &RunnerConfig{
-				SPIFFE: struct {
-					JoinToken string `json:"join_token,omitempty"`
-					SPIFFEID  string `json:"spiffe_id,omitempty"`
-					Enabled   bool   `json:"enabled,omitempty"`
-				}{
+				SPIFFE: SPIFFESettings{
 					JoinToken: "",
 					SPIFFEID:  "",
 					Enabled:   true,
@@ -890,10 +1080,10 @@ func TestErrorHandlingInSPIFFE(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			bootstrap := &GitHubBootstrap{
 				config: tc.config,
-				logger: log.New(os.Stdout, "[test] ", log.LstdFlags),
+				logger: NewRealLogger("[test] "),
 			}
 
-			err := bootstrap.performSPIFFEAttestation()
+			err := bootstrap.performSPIFFEAttestation(context.Background())
 
 			if tc.expectError {
 				if err == nil {
@@ -920,11 +1110,7 @@ func TestSPIFFEEnabledCheck(t *testing.T) {
 		{
 			name: "SPIFFE enabled",
 			config: &RunnerConfig{
-				SPIFFE: struct {
-					JoinToken string `json:"join_token,omitempty"`
-					SPIFFEID  string `json:"spiffe_id,omitempty"`
-					Enabled   bool   `json:"enabled,omitempty"`
-				}{
+				SPIFFE: SPIFFESettings{
 					Enabled: true,
 				},
 			},
@@ -933,11 +1119,7 @@ func TestSPIFFEEnabledCheck(t *testing.T) {
 		{
 			name: "SPIFFE disabled",
 			config: &RunnerConfig{
-				SPIFFE: struct {
-					JoinToken string `json:"join_token,omitempty"`
-					SPIFFEID  string `json:"spiffe_id,omitempty"`
-					Enabled   bool   `json:"enabled,omitempty"`
-				}{
+				SPIFFE: SPIFFESettings{
 					Enabled: false,
 				},
 			},
@@ -969,7 +1151,7 @@ func TestRunWorkflow(t *testing.T) {
 
 	bootstrap := &GitHubBootstrap{
 		config: config,
-		logger: log.New(os.Stdout, "[test] ", log.LstdFlags),
+		logger: NewRealLogger("[test] "),
 	}
 
 	// Test that the workflow steps are properly structured
@@ -1008,7 +1190,7 @@ func TestDownloadGitHubRunnerPathSetup(t *testing.T) {
 
 	bootstrap := &GitHubBootstrap{
 		config: config,
-		logger: log.New(os.Stdout, "[test] ", log.LstdFlags),
+		logger: NewRealLogger("[test] "),
 	}
 
 	// Test install path logic
@@ -1032,7 +1214,7 @@ func TestDownloadGitHubRunnerPathSetup(t *testing.T) {
 	emptyConfig := &RunnerConfig{}
 	emptyBootstrap := &GitHubBootstrap{
 		config: emptyConfig,
-		logger: log.New(os.Stdout, "[test] ", log.LstdFlags),
+		logger: NewRealLogger("[test] "),
 	}
 
 	defaultInstallPath := emptyBootstrap.config.Runner.InstallPath
@@ -1060,7 +1242,7 @@ func TestConfigureRunnerPathConstruction(t *testing.T) {
 
 	bootstrap := &GitHubBootstrap{
 		config: config,
-		logger: log.New(os.Stdout, "[test] ", log.LstdFlags),
+		logger: NewRealLogger("[test] "),
 	}
 
 	// Test path construction that would be used in configureRunner
@@ -1119,7 +1301,7 @@ func TestRunAndMonitorPathConstruction(t *testing.T) {
 
 	bootstrap := &GitHubBootstrap{
 		config: config,
-		logger: log.New(os.Stdout, "[test] ", log.LstdFlags),
+		logger: NewRealLogger("[test] "),
 	}
 
 	// Test path construction that would be used in runAndMonitor
@@ -1144,7 +1326,7 @@ func TestRunAndMonitorPathConstruction(t *testing.T) {
 	defaultConfig := &RunnerConfig{}
 	defaultBootstrap := &GitHubBootstrap{
 		config: defaultConfig,
-		logger: log.New(os.Stdout, "[test] ", log.LstdFlags),
+		logger: NewRealLogger("[test] "),
 	}
 
 	defaultInstallPath := defaultBootstrap.config.Runner.InstallPath
@@ -1173,7 +1355,7 @@ func TestCleanupLogic(t *testing.T) {
 
 	bootstrap := &GitHubBootstrap{
 		config: config,
-		logger: log.New(os.Stdout, "[test] ", log.LstdFlags),
+		logger: NewRealLogger("[test] "),
 	}
 
 	// Test cleanup path logic that would be used in cleanup function
@@ -1200,7 +1382,7 @@ func TestCleanupLogic(t *testing.T) {
 	defaultConfig := &RunnerConfig{}
 	defaultBootstrap := &GitHubBootstrap{
 		config: defaultConfig,
-		logger: log.New(os.Stdout, "[test] ", log.LstdFlags),
+		logger: NewRealLogger("[test] "),
 	}
 
 	defaultInstallPath := defaultBootstrap.config.Runner.InstallPath
@@ -1227,7 +1409,7 @@ func TestShutdownMethodSelection(t *testing.T) {
 	config := &RunnerConfig{}
 	bootstrap := &GitHubBootstrap{
 		config: config,
-		logger: log.New(os.Stdout, "[test] ", log.LstdFlags),
+		logger: NewRealLogger("[test] "),
 	}
 
 	// Test that we have multiple shutdown methods available
@@ -1282,7 +1464,7 @@ func TestRunMethodExecution(t *testing.T) {
 
 	bootstrap := &GitHubBootstrap{
 		config: config,
-		logger: log.New(os.Stdout, "[test] ", log.LstdFlags),
+		logger: NewRealLogger("[test] "),
 	}
 
 	// Test that the bootstrap is properly configured for Run
@@ -1313,7 +1495,7 @@ func TestDownloadGitHubRunnerSetup(t *testing.T) {
 
 	bootstrap := &GitHubBootstrap{
 		config: config,
-		logger: log.New(os.Stdout, "[test] ", log.LstdFlags),
+		logger: NewRealLogger("[test] "),
 	}
 
 	// Test the path setup that happens at the start of downloadGitHubRunner
@@ -1355,7 +1537,7 @@ func TestConfigureRunnerSetup(t *testing.T) {
 
 	bootstrap := &GitHubBootstrap{
 		config: config,
-		logger: log.New(os.Stdout, "[test] ", log.LstdFlags),
+		logger: NewRealLogger("[test] "),
 	}
 
 	// Test the path and argument setup that happens in configureRunner
@@ -1400,7 +1582,7 @@ func TestRunAndMonitorSetup(t *testing.T) {
 
 	bootstrap := &GitHubBootstrap{
 		config: config,
-		logger: log.New(os.Stdout, "[test] ", log.LstdFlags),
+		logger: NewRealLogger("[test] "),
 	}
 
 	// Test the path setup that happens in runAndMonitor
@@ -1430,7 +1612,7 @@ func TestCleanupSetup(t *testing.T) {
 
 	bootstrap := &GitHubBootstrap{
 		config: config,
-		logger: log.New(os.Stdout, "[test] ", log.LstdFlags),
+		logger: NewRealLogger("[test] "),
 	}
 
 	// Test the path setup that happens in cleanup
@@ -1465,7 +1647,7 @@ func TestShutdownVMSetup(t *testing.T) {
 	config := &RunnerConfig{}
 	bootstrap := &GitHubBootstrap{
 		config: config,
-		logger: log.New(os.Stdout, "[test] ", log.LstdFlags),
+		logger: NewRealLogger("[test] "),
 	}
 
 	// Test that we have the expected shutdown methods
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// phaseDurationMetric is the Prometheus metric recording how long each bootstrap phase took
+	phaseDurationMetric = "hyperfleet_bootstrap_phase_duration_seconds"
+	// metricsJob is the pushgateway job the bootstrap metrics are grouped under
+	metricsJob = "hyperfleet_bootstrap"
+	// MetricsPushTimeoutSeconds bounds the best-effort pushgateway request
+	MetricsPushTimeoutSeconds = 10
+
+	// Phase outcomes recorded in the outcome label
+	outcomeSuccess = "success"
+	outcomeFailure = "failure"
+)
+
+// MetricsSettings configures where phase metrics are emitted; metrics are off when both are empty
+type MetricsSettings struct {
+	PushgatewayURL string `json:"pushgateway_url,omitempty"` // Prometheus pushgateway the metrics are PUT to
+	TextfilePath   string `json:"textfile_path,omitempty"`   // File for the node_exporter textfile collector (*.prom)
+}
+
+// phaseResult records the duration and outcome of one lifecycle phase
+type phaseResult struct {
+	Phase    string
+	Duration time.Duration
+	Outcome  string
+}
+
+// timePhase runs a lifecycle phase and records its duration and outcome
+func (gb *GitHubBootstrap) timePhase(phase string, run func() error) error {
+	start := gb.now()
+	err := run()
+
+	outcome := outcomeSuccess
+	if err != nil {
+		outcome = outcomeFailure
+	}
+	gb.phases = append(gb.phases, phaseResult{Phase: phase, Duration: gb.now().Sub(start), Outcome: outcome})
+	return err
+}
+
+// formatMetrics renders the recorded phases in the Prometheus text exposition format
+func (gb *GitHubBootstrap) formatMetrics() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# HELP %s Duration of each runner bootstrap phase.\n", phaseDurationMetric)
+	fmt.Fprintf(&b, "# TYPE %s gauge\n", phaseDurationMetric)
+	for _, result := range gb.phases {
+		fmt.Fprintf(&b, "%s{runner=%s,phase=%s,outcome=%s} %s\n", phaseDurationMetric,
+			quoteLabelValue(gb.config.RunnerName), quoteLabelValue(result.Phase), quoteLabelValue(result.Outcome),
+			strconv.FormatFloat(result.Duration.Seconds(), 'f', -1, 64))
+	}
+	return b.String()
+}
+
+// quoteLabelValue quotes a Prometheus label value, escaping backslashes, quotes and newlines
+func quoteLabelValue(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value) + `"`
+}
+
+// emitMetrics writes the phase metrics to the configured textfile and pushgateway.
+// It is best-effort: failures are logged and never fail the bootstrap.
+func (gb *GitHubBootstrap) emitMetrics(ctx context.Context) {
+	settings := gb.config.Metrics
+	if settings.TextfilePath == "" && settings.PushgatewayURL == "" {
+		return
+	}
+
+	metrics := gb.formatMetrics()
+	if settings.TextfilePath != "" {
+		if err := gb.writeMetricsTextfile(settings.TextfilePath, metrics); err != nil {
+			gb.logger.Errorf("Warning: failed to write metrics to %s: %v", settings.TextfilePath, err)
+		}
+	}
+	if settings.PushgatewayURL != "" {
+		if err := gb.pushMetrics(ctx, settings.PushgatewayURL, metrics); err != nil {
+			gb.logger.Errorf("Warning: failed to push metrics: %v", err)
+		}
+	}
+}
+
+// writeMetricsTextfile replaces the textfile with the current metrics
+func (gb *GitHubBootstrap) writeMetricsTextfile(path, metrics string) error {
+	file, err := gb.fileSystem.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, FilePermissions)
+	if err != nil {
+		return err
+	}
+	if _, err := gb.fileSystem.WriteString(file, metrics); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// pushMetrics PUTs the metrics to the pushgateway, grouped by job and runner instance
+func (gb *GitHubBootstrap) pushMetrics(ctx context.Context, pushgatewayURL, metrics string) error {
+	pushURL := fmt.Sprintf("%s/metrics/job/%s/instance/%s", strings.TrimSuffix(pushgatewayURL, "/"),
+		metricsJob, url.PathEscape(gb.config.RunnerName))
+
+	// Push even when the run was canceled, but never hold up the shutdown for long
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), MetricsPushTimeoutSeconds*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, pushURL, strings.NewReader(metrics))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+
+	resp, err := gb.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("pushgateway returned HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
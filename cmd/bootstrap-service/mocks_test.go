@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"time"
 )
 
 // MockHTTPClient implements HTTPClient for testing
@@ -27,6 +28,8 @@ type MockFileSystem struct {
 	MkdirAllFunc    func(path string, perm os.FileMode) error
 	RemoveAllFunc   func(path string) error
 	OpenFileFunc    func(name string, flag int, perm os.FileMode) (io.WriteCloser, error)
+	OpenFunc        func(name string) (io.ReadCloser, error)
+	StatFunc        func(name string) (os.FileInfo, error)
 	WriteStringFunc func(file io.WriteCloser, data string) (int, error)
 
 	CreatedDirs  []string
@@ -65,7 +68,33 @@ func (m *MockFileSystem) OpenFile(name string, flag int, perm os.FileMode) (io.W
 	if m.OpenFileFunc != nil {
 		return m.OpenFileFunc(name, flag, perm)
 	}
-	return &MockWriteCloser{name: name, fs: m}, nil
+	file := &MockWriteCloser{name: name, fs: m}
+	if flag&os.O_APPEND != 0 {
+		file.buf.WriteString(m.WrittenData[name])
+	}
+	return file, nil
+}
+
+func (m *MockFileSystem) Open(name string) (io.ReadCloser, error) {
+	if m.OpenFunc != nil {
+		return m.OpenFunc(name)
+	}
+	data, exists := m.WrittenData[name]
+	if !exists {
+		return nil, os.ErrNotExist
+	}
+	return io.NopCloser(strings.NewReader(data)), nil
+}
+
+func (m *MockFileSystem) Stat(name string) (os.FileInfo, error) {
+	if m.StatFunc != nil {
+		return m.StatFunc(name)
+	}
+	data, exists := m.WrittenData[name]
+	if !exists {
+		return nil, os.ErrNotExist
+	}
+	return &MockFileInfo{name: name, size: int64(len(data))}, nil
 }
 
 func (m *MockFileSystem) WriteString(file io.WriteCloser, data string) (int, error) {
@@ -101,6 +130,19 @@ func (m *MockWriteCloser) Close() error {
 	return nil
 }
 
+// MockFileInfo implements os.FileInfo for testing
+type MockFileInfo struct {
+	name string
+	size int64
+}
+
+func (m *MockFileInfo) Name() string       { return m.name }
+func (m *MockFileInfo) Size() int64        { return m.size }
+func (m *MockFileInfo) Mode() os.FileMode  { return 0600 }
+func (m *MockFileInfo) ModTime() time.Time { return time.Time{} }
+func (m *MockFileInfo) IsDir() bool        { return false }
+func (m *MockFileInfo) Sys() interface{}   { return nil }
+
 // MockCommandExecutor implements CommandExecutor for testing
 type MockCommandExecutor struct {
 	CommandContextFunc func(ctx context.Context, name string, args ...string) Command
@@ -111,6 +153,7 @@ type MockExecutedCommand struct {
 	Name string
 	Args []string
 	Dir  string
+	Env  []string
 }
 
 func NewMockCommandExecutor() *MockCommandExecutor {
@@ -135,8 +178,14 @@ type MockCommand struct {
 	name     string
 	args     []string
 	dir      string
+	env      []string
+	stdout   io.Writer
+	stderr   io.Writer
 	executor *MockCommandExecutor
 	RunFunc  func() error
+
+	// Output is written to the command's stdout when it runs
+	Output string
 }
 
 func (m *MockCommand) Run() error {
@@ -145,8 +194,12 @@ func (m *MockCommand) Run() error {
 			Name: m.name,
 			Args: m.args,
 			Dir:  m.dir,
+			Env:  m.env,
 		})
 	}
+	if m.Output != "" && m.stdout != nil {
+		_, _ = io.WriteString(m.stdout, m.Output)
+	}
 	if m.RunFunc != nil {
 		return m.RunFunc()
 	}
@@ -158,11 +211,15 @@ func (m *MockCommand) SetDir(dir string) {
 }
 
 func (m *MockCommand) SetStdout(stdout io.Writer) {
-	// Mock implementation - could store for verification if needed
+	m.stdout = stdout
 }
 
 func (m *MockCommand) SetStderr(stderr io.Writer) {
-	// Mock implementation - could store for verification if needed
+	m.stderr = stderr
+}
+
+func (m *MockCommand) SetEnv(env []string) {
+	m.env = env
 }
 
 // MockSystemOperations implements SystemOperations for testing
@@ -206,6 +263,32 @@ func (m *MockSystemOperations) Sleep(duration int) {
 	}
 }
 
+// MockAttestationVerifier implements AttestationVerifier for testing
+type MockAttestationVerifier struct {
+	VerifyFunc    func(ctx context.Context, artifactPath string) error
+	VerifiedPaths []string
+}
+
+func (m *MockAttestationVerifier) Verify(ctx context.Context, artifactPath string) error {
+	m.VerifiedPaths = append(m.VerifiedPaths, artifactPath)
+	if m.VerifyFunc != nil {
+		return m.VerifyFunc(ctx, artifactPath)
+	}
+	return nil
+}
+
+// MockArchDetector implements ArchDetector for testing
+type MockArchDetector struct {
+	Arch          string
+	Err           error
+	DetectedPaths []string
+}
+
+func (m *MockArchDetector) DetectArch(path string) (string, error) {
+	m.DetectedPaths = append(m.DetectedPaths, path)
+	return m.Arch, m.Err
+}
+
 // MockLogger implements Logger for testing
 type MockLogger struct {
 	PrintfFunc func(format string, v ...interface{})
@@ -225,3 +308,13 @@ func (m *MockLogger) Printf(format string, v ...interface{}) {
 		m.PrintfFunc(format, v...)
 	}
 }
+
+// Errorf records the message like Printf
+func (m *MockLogger) Errorf(format string, v ...interface{}) {
+	m.Printf(format, v...)
+}
+
+// Debugf records the message like Printf
+func (m *MockLogger) Debugf(format string, v ...interface{}) {
+	m.Printf(format, v...)
+}
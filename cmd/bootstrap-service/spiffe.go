@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"time"
+)
+
+// SPIFFEScheme is the URI scheme of SPIFFE IDs
+const SPIFFEScheme = "spiffe"
+
+// SPIFFESettings configures SPIFFE attestation, independent of the runner token
+type SPIFFESettings struct {
+	JoinToken string `json:"join_token,omitempty"`
+	SPIFFEID  string `json:"spiffe_id,omitempty"`
+	Enabled   bool   `json:"enabled,omitempty"`
+
+	// X.509 SVID issued to the VM by the SPIRE agent, e.g. written to disk by spiffe-helper
+	SVIDFile    string `json:"svid_file,omitempty"`     // PEM certificate chain, leaf first
+	SVIDKeyFile string `json:"svid_key_file,omitempty"` // PEM private key of the SVID
+	BundleFile  string `json:"bundle_file,omitempty"`   // PEM trust bundle of the SVID's trust domain
+
+	// TokenURL, when set, is fetched after attestation for the registration token, with the SVID
+	// as the client certificate, so the token is only released to an attested VM
+	TokenURL string `json:"token_url,omitempty"`
+}
+
+// performSPIFFEAttestation handles SPIFFE attestation independently. When the SPIRE agent's SVID
+// is configured, the SVID must chain to the trust bundle and carry the expected SPIFFE ID; with
+// token_url set, the registration token is then fetched with it, before the runner is configured.
+func (gb *GitHubBootstrap) performSPIFFEAttestation(ctx context.Context) error {
+	gb.logger.Printf("Performing SPIFFE attestation")
+
+	settings := gb.config.SPIFFE
+	if settings.JoinToken == "" && settings.SPIFFEID == "" {
+		return fmt.Errorf("SPIFFE attestation enabled but no join token or SPIFFE ID provided")
+	}
+	if settings.SVIDFile == "" {
+		if settings.TokenURL != "" {
+			return fmt.Errorf("SPIFFE token_url needs an SVID, but no svid_file is configured")
+		}
+		gb.logger.Printf("SPIFFE attestation completed successfully")
+		return nil
+	}
+
+	tlsConfig, spiffeID, err := loadSVID(settings)
+	if err != nil {
+		return fmt.Errorf("SVID rejected: %w", err)
+	}
+	gb.logger.Printf("SPIFFE attestation completed successfully as %s", spiffeID)
+
+	if settings.TokenURL == "" {
+		return nil
+	}
+	client := NewRealHTTPClientWithTLS(TokenFetchTimeoutSeconds*time.Second, tlsConfig)
+	return gb.fetchRunnerToken(ctx, client, settings.TokenURL)
+}
+
+// loadSVID loads the configured X.509 SVID and verifies it against the trust bundle, returning
+// the TLS configuration presenting it as a client certificate and its SPIFFE ID. Servers are
+// trusted through the bundle as well.
+func loadSVID(settings SPIFFESettings) (*tls.Config, string, error) {
+	if settings.SVIDKeyFile == "" || settings.BundleFile == "" {
+		return nil, "", fmt.Errorf("svid_file needs svid_key_file and bundle_file")
+	}
+
+	// #nosec G304 - the SVID paths come from the VM's runner configuration
+	svid, err := tls.LoadX509KeyPair(settings.SVIDFile, settings.SVIDKeyFile)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load SVID: %w", err)
+	}
+	// #nosec G304 - the bundle path comes from the VM's runner configuration
+	bundlePEM, err := os.ReadFile(settings.BundleFile)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read trust bundle: %w", err)
+	}
+	bundle := x509.NewCertPool()
+	if !bundle.AppendCertsFromPEM(bundlePEM) {
+		return nil, "", fmt.Errorf("trust bundle %s contains no PEM certificates", settings.BundleFile)
+	}
+
+	leaf, err := x509.ParseCertificate(svid.Certificate[0])
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse SVID: %w", err)
+	}
+	intermediates := x509.NewCertPool()
+	for _, raw := range svid.Certificate[1:] {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to parse SVID chain: %w", err)
+		}
+		intermediates.AddCert(cert)
+	}
+	if _, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         bundle,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}); err != nil {
+		return nil, "", fmt.Errorf("SVID is not trusted by the bundle: %w", err)
+	}
+
+	// An X.509 SVID carries exactly one URI SAN, its SPIFFE ID
+	if len(leaf.URIs) != 1 || leaf.URIs[0].Scheme != SPIFFEScheme {
+		return nil, "", fmt.Errorf("SVID has no single SPIFFE ID, got URIs %v", leaf.URIs)
+	}
+	spiffeID := leaf.URIs[0].String()
+	if settings.SPIFFEID != "" && spiffeID != settings.SPIFFEID {
+		return nil, "", fmt.Errorf("SVID identifies %s, expected %s", spiffeID, settings.SPIFFEID)
+	}
+
+	return &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{svid},
+		RootCAs:      bundle,
+	}, spiffeID, nil
+}
@@ -21,6 +21,8 @@ import (
 	"flag"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -39,6 +41,7 @@ import (
 
 	hypervisorv1alpha1 "github.com/codihuston/hyperfleet-operator/api/v1alpha1"
 	"github.com/codihuston/hyperfleet-operator/internal/controller"
+	"github.com/codihuston/hyperfleet-operator/internal/provider"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -63,6 +66,13 @@ func main() {
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var clusterRequeue controller.RequeueIntervals
+	var statusUpdateRetries int
+	var minHypervisorVersion string
+	var instanceID string
+	var defaultRunnerLabels string
+	var allowCrossNamespaceClusterRefs bool
+	var templateValidationFreshness time.Duration
 	var tlsOpts []func(*tls.Config)
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
@@ -81,6 +91,33 @@ func main() {
 	flag.StringVar(&metricsCertKey, "metrics-cert-key", "tls.key", "The name of the metrics server key file.")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.DurationVar(&clusterRequeue.Success, "cluster-success-requeue-interval", controller.DefaultSuccessRequeueInterval,
+		"How often a HypervisorCluster's connection is re-checked while it is healthy.")
+	flag.DurationVar(&clusterRequeue.Failure, "cluster-failure-requeue-interval", controller.DefaultFailureRequeueInterval,
+		"How often a HypervisorCluster's connection is re-checked while it is failing.")
+	flag.DurationVar(&clusterRequeue.MaxBackoff, "cluster-max-backoff-interval", 0,
+		"Longest delay between re-checks of a HypervisorCluster whose connection keeps failing. "+
+			"Defaults to the success requeue interval.")
+	flag.DurationVar(&clusterRequeue.Unrecoverable, "cluster-unrecoverable-requeue-interval", 0,
+		"How often a HypervisorCluster is re-checked after a failure only a spec change can fix, "+
+			"such as an unsupported provider. Defaults to the success requeue interval.")
+	flag.StringVar(&minHypervisorVersion, "min-hypervisor-version", "",
+		"Oldest hypervisor version, e.g. 8.1, a HypervisorCluster may run. An older hypervisor is not Ready "+
+			"and its default storage and network are not validated. Empty accepts any version.")
+	flag.IntVar(&statusUpdateRetries, "status-update-retries", controller.DefaultStatusUpdateRetries,
+		"How many times a status update that conflicts with a newer version of the resource is re-fetched and retried.")
+	flag.StringVar(&instanceID, "instance-id", "",
+		"Identifies this operator instance when several share a hypervisor. The VMs it creates are tagged with it, "+
+			"and it only reconciles or deletes VMs carrying that tag. Empty disables the ownership check.")
+	flag.StringVar(&defaultRunnerLabels, "default-runner-labels", "",
+		"Comma-separated labels added to every runner, after its template's and its cluster's labels.")
+	flag.BoolVar(&allowCrossNamespaceClusterRefs, "allow-cross-namespace-cluster-refs", false,
+		"If set, HypervisorMachineTemplates may reference a HypervisorCluster in another namespace, "+
+			"and MachineClaims a HypervisorMachineTemplate in another namespace. Otherwise such references are denied.")
+	flag.DurationVar(&templateValidationFreshness, "template-validation-freshness", controller.DefaultValidationFreshness,
+		"How long a valid HypervisorMachineTemplate is trusted without re-checking it against the hypervisor, "+
+			"while its HypervisorCluster keeps syncing. Keep it at least the cluster success requeue interval. "+
+			"0 re-checks on every reconcile.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -89,6 +126,16 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	var minimumVersion *provider.Version
+	if minHypervisorVersion != "" {
+		version, err := provider.ParseVersion(minHypervisorVersion)
+		if err != nil {
+			setupLog.Error(err, "invalid --min-hypervisor-version")
+			os.Exit(1)
+		}
+		minimumVersion = &version
+	}
+
 	// if the enable-http2 flag is false (the default), http/2 should be disabled
 	// due to its vulnerabilities. More specifically, disabling http/2 will
 	// prevent from being vulnerable to the HTTP/2 Stream Cancellation and
@@ -203,12 +250,38 @@ func main() {
 	}
 
 	if err := (&controller.HypervisorClusterReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:              mgr.GetClient(),
+		Scheme:              mgr.GetScheme(),
+		RequeueIntervals:    clusterRequeue,
+		StatusUpdateRetries: statusUpdateRetries,
+		MinimumVersion:      minimumVersion,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "HypervisorCluster")
 		os.Exit(1)
 	}
+	if err := (&controller.HypervisorMachineTemplateReconciler{
+		Client:                         mgr.GetClient(),
+		Scheme:                         mgr.GetScheme(),
+		StatusUpdateRetries:            statusUpdateRetries,
+		AllowCrossNamespaceClusterRefs: allowCrossNamespaceClusterRefs,
+		ValidationFreshness:            templateValidationFreshness,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "HypervisorMachineTemplate")
+		os.Exit(1)
+	}
+	if err := (&controller.MachineClaimReconciler{
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		TokenProvider: controller.NewGitHubTokenProvider(mgr.GetClient()),
+		CloneLimiter:  controller.NewCloneLimiter(),
+		InstanceID:    instanceID,
+		// Empty labels are dropped when the runner's labels are merged
+		DefaultRunnerLabels:            strings.Split(defaultRunnerLabels, ","),
+		AllowCrossNamespaceClusterRefs: allowCrossNamespaceClusterRefs,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "MachineClaim")
+		os.Exit(1)
+	}
 	// +kubebuilder:scaffold:builder
 
 	if metricsCertWatcher != nil {
@@ -0,0 +1,240 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha1" // #nosec G505 - name-based UUIDs are defined over SHA-1; nothing secret is hashed
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hypervisorv1alpha1 "github.com/codihuston/hyperfleet-operator/api/v1alpha1"
+	"github.com/codihuston/hyperfleet-operator/internal/provider"
+)
+
+// DefaultVGAType is the display type of cloned VMs whose template does not set one
+const DefaultVGAType = "serial0"
+
+// cloneVGA resolves the display type for a cloned VM
+func cloneVGA(proxmox *hypervisorv1alpha1.ProxmoxTemplateSpec) string {
+	if proxmox.VGA != "" {
+		return proxmox.VGA
+	}
+	return DefaultVGAType
+}
+
+// cloneGuestAgent resolves whether the QEMU guest agent is enabled on a cloned VM; it is unless disabled
+func cloneGuestAgent(proxmox *hypervisorv1alpha1.ProxmoxTemplateSpec) *bool {
+	enabled := proxmox.GuestAgent == nil || *proxmox.GuestAgent
+	return &enabled
+}
+
+// cloneInterfaces resolves the inherited network interfaces to reconfigure on a cloned VM: those
+// the template lists, and those whose bridge is set, so the primary interface is attached to the
+// cluster's default network even when the template's VM is on another bridge
+func cloneInterfaces(template *hypervisorv1alpha1.HypervisorMachineTemplate, cluster *hypervisorv1alpha1.HypervisorCluster) []provider.InterfaceConfig {
+	bridges := networkBridges(template, cluster)
+	specs := template.Spec.Network.Interfaces
+	if len(specs) == 0 && len(bridges) == 0 {
+		return nil
+	}
+
+	interfaces := make([]provider.InterfaceConfig, 0, len(specs)+len(bridges))
+	listed := make(map[int]bool, len(specs))
+	for _, spec := range specs {
+		firewall := spec.Firewall
+		nic := provider.InterfaceConfig{Name: spec.Name, Firewall: &firewall}
+		if index, ok := interfaceIndex(spec.Name); ok {
+			listed[index] = true
+			nic.Bridge = bridges[index]
+		}
+		interfaces = append(interfaces, nic)
+	}
+	for _, index := range slices.Sorted(maps.Keys(bridges)) {
+		if !listed[index] {
+			interfaces = append(interfaces, provider.InterfaceConfig{Name: interfaceName(index), Bridge: bridges[index]})
+		}
+	}
+	return interfaces
+}
+
+// cloneOnBoot resolves whether a cloned VM starts when its host boots; it does not unless enabled
+func cloneOnBoot(proxmox *hypervisorv1alpha1.ProxmoxTemplateSpec) *bool {
+	enabled := proxmox.OnBoot != nil && *proxmox.OnBoot
+	return &enabled
+}
+
+// cloneHotplug resolves the hot-plug settings of a cloned VM; nil keeps the template's own
+func cloneHotplug(proxmox *hypervisorv1alpha1.ProxmoxTemplateSpec) *provider.HotplugConfig {
+	if !proxmox.HotplugCPU && !proxmox.HotplugMemory {
+		return nil
+	}
+	return &provider.HotplugConfig{CPU: proxmox.HotplugCPU, Memory: proxmox.HotplugMemory}
+}
+
+// clonePool resolves the resource pool for a cloned VM.
+// The template's pool takes precedence over the cluster default; an empty result means no pool.
+func clonePool(template *hypervisorv1alpha1.HypervisorMachineTemplate, cluster *hypervisorv1alpha1.HypervisorCluster) string {
+	if proxmox := template.Spec.Template.Proxmox; proxmox != nil && proxmox.Pool != "" {
+		return proxmox.Pool
+	}
+	return cluster.Spec.DefaultPool
+}
+
+// newCloneRequest builds the clone request for a VM created from a machine template
+func newCloneRequest(template *hypervisorv1alpha1.HypervisorMachineTemplate, cluster *hypervisorv1alpha1.HypervisorCluster,
+	node, name string, id int) (*provider.CloneRequest, error) {
+	proxmox := template.Spec.Template.Proxmox
+	if proxmox == nil {
+		return nil, fmt.Errorf("template %s has no Proxmox configuration", template.Name)
+	}
+
+	disks, err := cloneDisks(template, cluster)
+	if err != nil {
+		return nil, err
+	}
+	resources, err := desiredVMResources(template)
+	if err != nil {
+		return nil, err
+	}
+	memoryMin, err := templateMemoryMinMiB(template)
+	if err != nil {
+		return nil, err
+	}
+	bootDiskGB, err := templateBootDiskGB(template)
+	if err != nil {
+		return nil, err
+	}
+
+	return &provider.CloneRequest{
+		SourceNode:           node,
+		SourceID:             proxmox.TemplateID,
+		NewID:                id,
+		Name:                 name,
+		Pool:                 clonePool(template, cluster),
+		Storage:              cluster.Spec.DefaultStorage,
+		FullClone:            !proxmox.LinkedClone,
+		BootDiskGB:           bootDiskGB,
+		Disks:                disks,
+		Network:              cloudInitNetwork(template, cluster),
+		VGA:                  cloneVGA(proxmox),
+		GuestAgent:           cloneGuestAgent(proxmox),
+		OnBoot:               cloneOnBoot(proxmox),
+		Hotplug:              cloneHotplug(proxmox),
+		NestedVirtualization: proxmox.NestedVirtualization,
+		CPUs:                 resources.CPUs,
+		MemoryMiB:            resources.MemoryMiB,
+		MinMemoryMiB:         memoryMin,
+		CPULimit:             templateCPULimit(template),
+		Interfaces:           cloneInterfaces(template, cluster),
+		// Reconciles retry clones, so a VM left by an earlier attempt is not an error
+		AdoptExisting: true,
+	}, nil
+}
+
+// newClaimCloneRequest builds the clone request for a claim's VM. The VM is named after the
+// claim's runner and its description records the claim, so the VM can be traced back to it.
+// It carries the claim's tags from the start, including the managed-by and instance tags.
+// Its SMBIOS UUID is derived from the claim's UID, so it stays the same across retried clones.
+// The template's cloud-init credentials are read through c, as the password is kept in a Secret.
+func newClaimCloneRequest(ctx context.Context, c client.Reader, claim *hypervisorv1alpha1.MachineClaim,
+	template *hypervisorv1alpha1.HypervisorMachineTemplate, cluster *hypervisorv1alpha1.HypervisorCluster,
+	node string, id int, instanceID string) (*provider.CloneRequest, error) {
+	req, err := newCloneRequest(template, cluster, node, runnerName(claim), id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Credentials, err = cloudInitCredentials(ctx, c, template, cluster); err != nil {
+		return nil, err
+	}
+	req.Description = vmDescription(claim)
+	req.Tags = desiredVMTags(cluster, claim, instanceID)
+	req.SMBIOSUUID = claimSMBIOSUUID(claim)
+	return req, nil
+}
+
+// smbiosUUIDNamespace is the namespace of the name-based UUIDs derived for VM SMBIOS UUIDs
+var smbiosUUIDNamespace = [16]byte{
+	0x6b, 0x3c, 0x1f, 0x52, 0x9a, 0x0e, 0x4d, 0x71, 0xb8, 0x25, 0xe4, 0x90, 0x37, 0xd6, 0xa1, 0x4f,
+}
+
+const (
+	// uuidVersion5 marks a UUID as name-based using SHA-1 (RFC 9562)
+	uuidVersion5    = 0x50
+	uuidVersionMask = 0x0f
+	// uuidVariantRFC marks the UUID layout described by RFC 9562
+	uuidVariantRFC  = 0x80
+	uuidVariantMask = 0x3f
+)
+
+// claimSMBIOSUUID derives the SMBIOS UUID of a claim's VM as a version 5 UUID of the claim's UID
+func claimSMBIOSUUID(claim *hypervisorv1alpha1.MachineClaim) string {
+	hash := sha1.New() // #nosec G401 - see the import
+	hash.Write(smbiosUUIDNamespace[:])
+	hash.Write([]byte(claim.UID))
+	sum := hash.Sum(nil)
+
+	sum[6] = sum[6]&uuidVersionMask | uuidVersion5
+	sum[8] = sum[8]&uuidVariantMask | uuidVariantRFC
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// templateBootDiskGB returns the template's Disk in GiB, zero when the clone keeps the
+// source template's boot disk size
+func templateBootDiskGB(template *hypervisorv1alpha1.HypervisorMachineTemplate) (int, error) {
+	if template.Spec.Resources.Disk == "" {
+		return 0, nil
+	}
+	size, err := strconv.Atoi(strings.TrimSuffix(template.Spec.Resources.Disk, "G"))
+	if err != nil || size <= 0 {
+		return 0, fmt.Errorf("invalid disk size %q", template.Spec.Resources.Disk)
+	}
+	return size, nil
+}
+
+// cloneDisks resolves the template's data disks, defaulting storage to the cluster's DefaultStorage
+func cloneDisks(template *hypervisorv1alpha1.HypervisorMachineTemplate, cluster *hypervisorv1alpha1.HypervisorCluster) ([]provider.DiskConfig, error) {
+	specs := template.Spec.Resources.Disks
+	if len(specs) == 0 {
+		return nil, nil
+	}
+
+	disks := make([]provider.DiskConfig, 0, len(specs))
+	for i, spec := range specs {
+		size, err := strconv.Atoi(strings.TrimSuffix(spec.Size, "G"))
+		if err != nil || size <= 0 {
+			return nil, fmt.Errorf("invalid size %q for disk %d", spec.Size, i)
+		}
+
+		storage := spec.Storage
+		if storage == "" {
+			storage = cluster.Spec.DefaultStorage
+		}
+
+		disks = append(disks, provider.DiskConfig{
+			SizeGB:  size,
+			Storage: storage,
+			Format:  spec.Format,
+		})
+	}
+	return disks, nil
+}
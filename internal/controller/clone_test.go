@@ -0,0 +1,446 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hypervisorv1alpha1 "github.com/codihuston/hyperfleet-operator/api/v1alpha1"
+	"github.com/codihuston/hyperfleet-operator/internal/provider"
+)
+
+func TestClonePool(t *testing.T) {
+	tests := []struct {
+		name         string
+		templatePool string
+		clusterPool  string
+		expected     string
+	}{
+		{
+			name:         "template pool takes precedence",
+			templatePool: "team-a",
+			clusterPool:  "runners",
+			expected:     "team-a",
+		},
+		{
+			name:        "cluster default pool",
+			clusterPool: "runners",
+			expected:    "runners",
+		},
+		{
+			name:     "no pool",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template := &hypervisorv1alpha1.HypervisorMachineTemplate{
+				Spec: hypervisorv1alpha1.HypervisorMachineTemplateSpec{
+					Template: hypervisorv1alpha1.TemplateSpec{
+						Proxmox: &hypervisorv1alpha1.ProxmoxTemplateSpec{
+							TemplateID: 9000,
+							Pool:       tt.templatePool,
+						},
+					},
+					Resources: hypervisorv1alpha1.ResourceRequirements{CPU: 2, Memory: "4Gi"},
+				},
+			}
+			cluster := &hypervisorv1alpha1.HypervisorCluster{
+				Spec: hypervisorv1alpha1.HypervisorClusterSpec{
+					DefaultPool: tt.clusterPool,
+				},
+			}
+
+			if got := clonePool(template, cluster); got != tt.expected {
+				t.Errorf("Expected pool %q, got %q", tt.expected, got)
+			}
+
+			req, err := newCloneRequest(template, cluster, "pve1", "runner-1", 101)
+			if err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+			if req.Pool != tt.expected {
+				t.Errorf("Expected clone request pool %q, got %q", tt.expected, req.Pool)
+			}
+		})
+	}
+}
+
+func TestNewCloneRequest(t *testing.T) {
+	template := &hypervisorv1alpha1.HypervisorMachineTemplate{
+		Spec: hypervisorv1alpha1.HypervisorMachineTemplateSpec{
+			Template: hypervisorv1alpha1.TemplateSpec{
+				Proxmox: &hypervisorv1alpha1.ProxmoxTemplateSpec{
+					TemplateID:  9000,
+					LinkedClone: true,
+				},
+			},
+			Resources: hypervisorv1alpha1.ResourceRequirements{Memory: "4Gi"},
+		},
+	}
+	cluster := &hypervisorv1alpha1.HypervisorCluster{
+		Spec: hypervisorv1alpha1.HypervisorClusterSpec{
+			DefaultStorage: "local-lvm",
+		},
+	}
+
+	req, err := newCloneRequest(template, cluster, "pve1", "runner-1", 101)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if req.SourceNode != "pve1" || req.SourceID != 9000 || req.NewID != 101 || req.Name != "runner-1" {
+		t.Errorf("Unexpected clone request: %+v", req)
+	}
+	if req.FullClone {
+		t.Errorf("Expected linked clone")
+	}
+	if req.Storage != "local-lvm" {
+		t.Errorf("Expected storage local-lvm, got %s", req.Storage)
+	}
+	if !req.AdoptExisting {
+		t.Errorf("Expected clone to adopt an existing VM")
+	}
+	if req.VGA != DefaultVGAType {
+		t.Errorf("Expected default VGA type %s, got %s", DefaultVGAType, req.VGA)
+	}
+
+	if req.GuestAgent == nil || !*req.GuestAgent {
+		t.Errorf("Expected the guest agent to be enabled by default")
+	}
+
+	if req.OnBoot == nil || *req.OnBoot {
+		t.Errorf("Expected onboot to be disabled by default")
+	}
+	enabled := true
+	template.Spec.Template.Proxmox.OnBoot = &enabled
+	if req, err := newCloneRequest(template, cluster, "pve1", "runner-1", 101); err != nil || req.OnBoot == nil || !*req.OnBoot {
+		t.Errorf("Expected onboot to be enabled, got %+v (%v)", req, err)
+	}
+
+	if req.MinMemoryMiB != 0 || req.CPULimit != 0 {
+		t.Errorf("Expected no ballooning or CPU limit by default, got %d MiB and %v CPUs", req.MinMemoryMiB, req.CPULimit)
+	}
+	template.Spec.Resources.MemoryMin = "2Gi"
+	limit := resource.MustParse("1500m")
+	template.Spec.Resources.CPULimit = &limit
+	template.Spec.Resources.CPU = 2
+	if req, err := newCloneRequest(template, cluster, "pve1", "runner-1", 101); err != nil || req.MinMemoryMiB != 2048 || req.CPULimit != 1.5 || req.CPUs != 2 {
+		t.Errorf("Expected 2 CPUs, a 2048MiB balloon floor and a 1.5 CPU limit, got %+v (%v)", req, err)
+	}
+	if req, err := newCloneRequest(template, cluster, "pve1", "runner-1", 101); err != nil || req.MemoryMiB != 4096 {
+		t.Errorf("Expected the template's 4096MiB of memory, got %+v (%v)", req, err)
+	}
+
+	if req.BootDiskGB != 0 {
+		t.Errorf("Expected the template's boot disk size to be kept by default, got %dG", req.BootDiskGB)
+	}
+	template.Spec.Resources.Disk = "50G"
+	if req, err := newCloneRequest(template, cluster, "pve1", "runner-1", 101); err != nil || req.BootDiskGB != 50 {
+		t.Errorf("Expected the boot disk to grow to 50G, got %+v (%v)", req, err)
+	}
+
+	if req.NestedVirtualization {
+		t.Errorf("Expected nested virtualization to be off by default")
+	}
+	template.Spec.Template.Proxmox.NestedVirtualization = true
+	if req, err := newCloneRequest(template, cluster, "pve1", "runner-1", 101); err != nil || !req.NestedVirtualization {
+		t.Errorf("Expected nested virtualization to be requested, got %+v (%v)", req, err)
+	}
+
+	if req.Hotplug != nil {
+		t.Errorf("Expected no hot-plug settings by default, got %+v", req.Hotplug)
+	}
+	template.Spec.Template.Proxmox.HotplugCPU = true
+	if req, err := newCloneRequest(template, cluster, "pve1", "runner-1", 101); err != nil ||
+		req.Hotplug == nil || !req.Hotplug.CPU || req.Hotplug.Memory {
+		t.Errorf("Expected CPU hot-plug only, got %+v (%v)", req, err)
+	}
+
+	disabled := false
+	template.Spec.Template.Proxmox.GuestAgent = &disabled
+	if req, err := newCloneRequest(template, cluster, "pve1", "runner-1", 101); err != nil || req.GuestAgent == nil || *req.GuestAgent {
+		t.Errorf("Expected the guest agent to be disabled, got %+v (%v)", req, err)
+	}
+
+	if req.Interfaces != nil {
+		t.Errorf("Expected inherited interfaces to be left alone, got %+v", req.Interfaces)
+	}
+	template.Spec.Network.Interfaces = []hypervisorv1alpha1.NetworkInterfaceSpec{{Name: "net0", Firewall: true}, {Name: "net1"}}
+	expectedInterfaces := []provider.InterfaceConfig{{Name: "net0", Firewall: &enabled}, {Name: "net1", Firewall: &disabled}}
+	if req, err := newCloneRequest(template, cluster, "pve1", "runner-1", 101); err != nil || !reflect.DeepEqual(req.Interfaces, expectedInterfaces) {
+		t.Errorf("Expected interfaces %+v, got %+v (%v)", expectedInterfaces, req, err)
+	}
+
+	template.Spec.Template.Proxmox.VGA = "std"
+	if req, err := newCloneRequest(template, cluster, "pve1", "runner-1", 101); err != nil || req.VGA != "std" {
+		t.Errorf("Expected the template's VGA type std, got %+v (%v)", req, err)
+	}
+
+	// Templates without Proxmox configuration cannot be cloned
+	if _, err := newCloneRequest(&hypervisorv1alpha1.HypervisorMachineTemplate{}, cluster, "pve1", "runner-1", 101); err == nil {
+		t.Errorf("Expected error for template without Proxmox configuration")
+	}
+}
+
+func TestNewCloneRequestNetworkBridges(t *testing.T) {
+	template := &hypervisorv1alpha1.HypervisorMachineTemplate{
+		Spec: hypervisorv1alpha1.HypervisorMachineTemplateSpec{
+			Template: hypervisorv1alpha1.TemplateSpec{
+				Proxmox: &hypervisorv1alpha1.ProxmoxTemplateSpec{TemplateID: 9000},
+			},
+			Resources: hypervisorv1alpha1.ResourceRequirements{CPU: 2, Memory: "4Gi"},
+		},
+	}
+	cluster := &hypervisorv1alpha1.HypervisorCluster{
+		Spec: hypervisorv1alpha1.HypervisorClusterSpec{DefaultNetwork: "vmbr0"},
+	}
+	enabled := true
+
+	tests := []struct {
+		name       string
+		interfaces []hypervisorv1alpha1.NetworkInterfaceSpec
+		expected   []provider.InterfaceConfig
+	}{
+		{
+			name:     "primary interface on the cluster's default network",
+			expected: []provider.InterfaceConfig{{Name: "net0", Bridge: "vmbr0"}},
+		},
+		{
+			name:       "listed primary interface",
+			interfaces: []hypervisorv1alpha1.NetworkInterfaceSpec{{Name: "net0", Firewall: true}},
+			expected:   []provider.InterfaceConfig{{Name: "net0", Firewall: &enabled, Bridge: "vmbr0"}},
+		},
+		{
+			name: "template bridges",
+			interfaces: []hypervisorv1alpha1.NetworkInterfaceSpec{
+				{Name: "net1", Firewall: true, Bridge: "vmbr2"},
+				{Name: "net0", Firewall: true, Bridge: "vmbr1"},
+			},
+			expected: []provider.InterfaceConfig{
+				{Name: "net1", Firewall: &enabled, Bridge: "vmbr2"},
+				{Name: "net0", Firewall: &enabled, Bridge: "vmbr1"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template.Spec.Network.Interfaces = tt.interfaces
+			req, err := newCloneRequest(template, cluster, "pve1", "runner-1", 101)
+			if err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+			if !reflect.DeepEqual(req.Interfaces, tt.expected) {
+				t.Errorf("Expected interfaces %+v, got %+v", tt.expected, req.Interfaces)
+			}
+		})
+	}
+}
+
+func TestNewCloneRequestDisks(t *testing.T) {
+	template := &hypervisorv1alpha1.HypervisorMachineTemplate{
+		Spec: hypervisorv1alpha1.HypervisorMachineTemplateSpec{
+			Template: hypervisorv1alpha1.TemplateSpec{
+				Proxmox: &hypervisorv1alpha1.ProxmoxTemplateSpec{TemplateID: 9000},
+			},
+			Resources: hypervisorv1alpha1.ResourceRequirements{
+				CPU:    2,
+				Memory: "4Gi",
+				Disk:   "50G",
+				Disks: []hypervisorv1alpha1.DiskSpec{
+					{Size: "100G"},
+					{Size: "20G", Storage: "ceph-fast", Format: "raw"},
+				},
+			},
+		},
+	}
+	cluster := &hypervisorv1alpha1.HypervisorCluster{
+		Spec: hypervisorv1alpha1.HypervisorClusterSpec{
+			DefaultStorage: "local-lvm",
+		},
+	}
+
+	req, err := newCloneRequest(template, cluster, "pve1", "runner-1", 101)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	expected := []provider.DiskConfig{
+		{SizeGB: 100, Storage: "local-lvm"},
+		{SizeGB: 20, Storage: "ceph-fast", Format: "raw"},
+	}
+	if len(req.Disks) != len(expected) {
+		t.Fatalf("Expected %d disks, got %d", len(expected), len(req.Disks))
+	}
+	for i := range expected {
+		if req.Disks[i] != expected[i] {
+			t.Errorf("Disk %d: expected %+v, got %+v", i, expected[i], req.Disks[i])
+		}
+	}
+
+	// Sizes must be whole gigabytes
+	template.Spec.Resources.Disks = []hypervisorv1alpha1.DiskSpec{{Size: "1T"}}
+	if _, err := newCloneRequest(template, cluster, "pve1", "runner-1", 101); err == nil {
+		t.Errorf("Expected error for invalid disk size")
+	}
+}
+
+func TestTemplateBootDiskGB(t *testing.T) {
+	tests := []struct {
+		disk        string
+		expected    int
+		expectError bool
+	}{
+		{disk: "50G", expected: 50},
+		{disk: ""},
+		{disk: "lots", expectError: true},
+		{disk: "0G", expectError: true},
+		{disk: "1T", expectError: true},
+	}
+
+	for _, tt := range tests {
+		template := &hypervisorv1alpha1.HypervisorMachineTemplate{
+			Spec: hypervisorv1alpha1.HypervisorMachineTemplateSpec{
+				Resources: hypervisorv1alpha1.ResourceRequirements{Disk: tt.disk},
+			},
+		}
+		size, err := templateBootDiskGB(template)
+		if (err != nil) != tt.expectError {
+			t.Errorf("templateBootDiskGB(%q) error = %v, expectError %v", tt.disk, err, tt.expectError)
+		}
+		if size != tt.expected {
+			t.Errorf("templateBootDiskGB(%q) = %d, expected %d", tt.disk, size, tt.expected)
+		}
+	}
+}
+
+func TestNewClaimCloneRequest(t *testing.T) {
+	template := &hypervisorv1alpha1.HypervisorMachineTemplate{
+		Spec: hypervisorv1alpha1.HypervisorMachineTemplateSpec{
+			Template: hypervisorv1alpha1.TemplateSpec{
+				Proxmox: &hypervisorv1alpha1.ProxmoxTemplateSpec{TemplateID: 9000},
+			},
+			Resources: hypervisorv1alpha1.ResourceRequirements{CPU: 2, Memory: "4Gi"},
+		},
+	}
+	claim := newTestClaim()
+	claim.Spec.RunnerName = "runner-1"
+	claim.CreationTimestamp = metav1.NewTime(time.Date(2025, 3, 14, 9, 26, 53, 0, time.UTC))
+
+	c := fake.NewClientBuilder().Build()
+	req, err := newClaimCloneRequest(context.Background(), c, claim, template, &hypervisorv1alpha1.HypervisorCluster{}, "pve1", 101, "")
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if req.Name != "runner-1" || req.NewID != 101 {
+		t.Errorf("Unexpected clone request: %+v", req)
+	}
+
+	// The description correlates the VM with its claim by namespace/name and UID
+	expected := "Managed by HyperFleet\nMachineClaim: default/runner-abc123\nUID: claim-uid\nCreated: 2025-03-14T09:26:53Z"
+	if req.Description != expected {
+		t.Errorf("Expected description %q, got %q", expected, req.Description)
+	}
+	if !slices.Equal(req.Tags, []string{provider.ManagedByTag("")}) {
+		t.Errorf("Expected the VM to be tagged as managed, got %v", req.Tags)
+	}
+	if req.SMBIOSUUID != claimSMBIOSUUID(claim) {
+		t.Errorf("Expected the SMBIOS UUID derived from the claim, got %q", req.SMBIOSUUID)
+	}
+
+	// An operator instance tags its VMs so instances sharing a hypervisor can tell them apart
+	req, err = newClaimCloneRequest(context.Background(), c, claim, template, &hypervisorv1alpha1.HypervisorCluster{}, "pve1", 101, "ci-east")
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if !slices.Contains(req.Tags, provider.InstanceTag("ci-east")) {
+		t.Errorf("Expected the VM to be tagged for its instance, got %v", req.Tags)
+	}
+	if req.Credentials != nil {
+		t.Errorf("Expected the template's cloud-init credentials to be kept, got %+v", req.Credentials)
+	}
+}
+
+func TestNewClaimCloneRequestCredentials(t *testing.T) {
+	template := &hypervisorv1alpha1.HypervisorMachineTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "runner-template", Namespace: "default"},
+		Spec: hypervisorv1alpha1.HypervisorMachineTemplateSpec{
+			Template: hypervisorv1alpha1.TemplateSpec{
+				Proxmox: &hypervisorv1alpha1.ProxmoxTemplateSpec{TemplateID: 9000},
+			},
+			Resources: hypervisorv1alpha1.ResourceRequirements{CPU: 2, Memory: "4Gi"},
+			CloudInit: &hypervisorv1alpha1.CloudInitSpec{
+				User:              "runner",
+				PasswordSecretRef: &hypervisorv1alpha1.SecretKeySelector{Name: "runner-login", Key: "password"},
+			},
+		},
+	}
+	cluster := &hypervisorv1alpha1.HypervisorCluster{
+		Spec: hypervisorv1alpha1.HypervisorClusterSpec{DefaultSSHAuthorizedKeys: []string{testSSHKey}},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "runner-login", Namespace: "default"},
+		Data:       map[string][]byte{"password": []byte("s3cret")},
+	}
+
+	c := fake.NewClientBuilder().WithObjects(secret).Build()
+	req, err := newClaimCloneRequest(context.Background(), c, newTestClaim(), template, cluster, "pve1", 101, "")
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if creds := req.Credentials; creds == nil || creds.User != "runner" || creds.Password != "s3cret" ||
+		!slices.Equal(creds.SSHKeys, []string{testSSHKey}) {
+		t.Errorf("Expected the template's user and password with the cluster's keys, got %+v", creds)
+	}
+
+	// A password that cannot be read fails the request rather than cloning without it
+	template.Spec.CloudInit.PasswordSecretRef.Name = "missing"
+	if _, err := newClaimCloneRequest(context.Background(), c, newTestClaim(), template, cluster, "pve1", 101, ""); err == nil ||
+		!strings.Contains(err.Error(), "failed to load cloud-init password") {
+		t.Errorf("Expected a password error, got %v", err)
+	}
+}
+
+func TestClaimSMBIOSUUID(t *testing.T) {
+	claim := newTestClaim()
+
+	// A version 5 UUID of the claim UID in the SMBIOS UUID namespace
+	const expected = "c19e4985-baab-5fe3-946c-6dc05c015db2"
+	if got := claimSMBIOSUUID(claim); got != expected {
+		t.Errorf("Expected SMBIOS UUID %s, got %s", expected, got)
+	}
+	if got := claimSMBIOSUUID(claim); got != expected {
+		t.Errorf("Expected the SMBIOS UUID to be stable, got %s", got)
+	}
+
+	other := newTestClaim()
+	other.UID = "other-uid"
+	if got := claimSMBIOSUUID(other); got != "7d4075a2-4f99-5cdc-be2d-4ecc0cce8a5e" {
+		t.Errorf("Expected claims to get distinct SMBIOS UUIDs, got %s", got)
+	}
+}
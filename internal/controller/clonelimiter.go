@@ -0,0 +1,138 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hypervisorv1alpha1 "github.com/codihuston/hyperfleet-operator/api/v1alpha1"
+	"github.com/codihuston/hyperfleet-operator/internal/provider"
+)
+
+// DefaultMaxConcurrentClones bounds concurrent clones on a cluster that does not set MaxConcurrentClones
+const DefaultMaxConcurrentClones = 4
+
+// CloneLimiter bounds the number of concurrent clone operations per hypervisor cluster.
+// A single limiter must be shared by everything that clones VMs for the limit to hold.
+type CloneLimiter struct {
+	mu       sync.Mutex
+	clusters map[client.ObjectKey]*cloneSlots
+}
+
+// cloneSlots counts the clones in flight on one cluster against its limit
+type cloneSlots struct {
+	limit int
+	inUse int
+	// freed is closed, and replaced, whenever a slot may have become free
+	freed chan struct{}
+}
+
+// wake lets every clone waiting on the cluster re-check for a free slot
+func (s *cloneSlots) wake() {
+	close(s.freed)
+	s.freed = make(chan struct{})
+}
+
+// NewCloneLimiter returns a CloneLimiter with no clones in flight
+func NewCloneLimiter() *CloneLimiter {
+	return &CloneLimiter{
+		clusters: make(map[client.ObjectKey]*cloneSlots),
+	}
+}
+
+// maxConcurrentClones returns the clone limit configured for a cluster
+func maxConcurrentClones(cluster *hypervisorv1alpha1.HypervisorCluster) int {
+	if cluster.Spec.MaxConcurrentClones > 0 {
+		return int(cluster.Spec.MaxConcurrentClones)
+	}
+	return DefaultMaxConcurrentClones
+}
+
+// slots returns the cluster's clone slots, updating their limit to the cluster's current one.
+// Clones in flight keep their slots when the limit drops, so new clones wait until the count
+// is below the new limit. The caller must hold l.mu.
+func (l *CloneLimiter) slots(cluster *hypervisorv1alpha1.HypervisorCluster) *cloneSlots {
+	key := client.ObjectKeyFromObject(cluster)
+	limit := maxConcurrentClones(cluster)
+	slots, ok := l.clusters[key]
+	if !ok {
+		slots = &cloneSlots{limit: limit, freed: make(chan struct{})}
+		l.clusters[key] = slots
+	}
+	if limit > slots.limit {
+		slots.wake()
+	}
+	slots.limit = limit
+	return slots
+}
+
+// Acquire blocks until a clone slot on the cluster is free or the context is done.
+// The returned release func must be called once the clone completes.
+// Waiting and running clones and the time spent waiting are exported as per-cluster metrics.
+func (l *CloneLimiter) Acquire(ctx context.Context, cluster *hypervisorv1alpha1.HypervisorCluster) (func(), error) {
+	waiting := clonesWaiting.WithLabelValues(cluster.Namespace, cluster.Name)
+	inFlight := clonesInFlight.WithLabelValues(cluster.Namespace, cluster.Name)
+
+	start := time.Now()
+	waiting.Inc()
+	defer waiting.Dec()
+
+	l.mu.Lock()
+	slots := l.slots(cluster)
+	for slots.inUse >= slots.limit {
+		freed := slots.freed
+		l.mu.Unlock()
+		select {
+		case <-freed:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("waiting for a clone slot on cluster %s: %w", cluster.Name, ctx.Err())
+		}
+		l.mu.Lock()
+	}
+	slots.inUse++
+	l.mu.Unlock()
+
+	cloneQueueWait.WithLabelValues(cluster.Namespace, cluster.Name).Observe(time.Since(start).Seconds())
+	inFlight.Inc()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			inFlight.Dec()
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			slots.inUse--
+			slots.wake()
+		})
+	}, nil
+}
+
+// CloneVM clones a VM on the cluster while holding one of its clone slots
+func (l *CloneLimiter) CloneVM(ctx context.Context, hypervisorClient provider.HypervisorClient,
+	cluster *hypervisorv1alpha1.HypervisorCluster, req *provider.CloneRequest) (*provider.VMRef, error) {
+	release, err := l.Acquire(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return hypervisorClient.CloneVM(ctx, req)
+}
@@ -0,0 +1,260 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hypervisorv1alpha1 "github.com/codihuston/hyperfleet-operator/api/v1alpha1"
+	"github.com/codihuston/hyperfleet-operator/internal/provider"
+)
+
+// cloneWaitTimeout bounds how long the tests wait for a clone that should start
+const cloneWaitTimeout = 5 * time.Second
+
+// cloneBlockedWait is how long the tests wait to be confident a clone is blocked
+const cloneBlockedWait = 100 * time.Millisecond
+
+func newLimitedCluster(name string, limit int32) *hypervisorv1alpha1.HypervisorCluster {
+	return &hypervisorv1alpha1.HypervisorCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       hypervisorv1alpha1.HypervisorClusterSpec{MaxConcurrentClones: limit},
+	}
+}
+
+// blockingCloneClient returns a client whose clones report on started and block until finish is signalled
+func blockingCloneClient(started chan<- int, finish <-chan struct{}) *provider.MockHypervisorClient {
+	return &provider.MockHypervisorClient{
+		CloneVMFunc: func(ctx context.Context, req *provider.CloneRequest) (*provider.VMRef, error) {
+			started <- req.NewID
+			<-finish
+			return &provider.VMRef{Node: req.SourceNode, ID: req.NewID}, nil
+		},
+	}
+}
+
+func TestCloneLimiterBlocksBeyondLimit(t *testing.T) {
+	const limit = 2
+	limiter := NewCloneLimiter()
+	cluster := newLimitedCluster("test-cluster", limit)
+
+	started := make(chan int)
+	finish := make(chan struct{})
+	hypervisorClient := blockingCloneClient(started, finish)
+
+	done := make(chan error, limit+1)
+	for id := 101; id <= 101+limit; id++ {
+		go func(id int) {
+			_, err := limiter.CloneVM(context.Background(), hypervisorClient, cluster,
+				&provider.CloneRequest{SourceNode: "pve1", NewID: id})
+			done <- err
+		}(id)
+	}
+
+	// Exactly limit clones start
+	for range limit {
+		select {
+		case <-started:
+		case <-time.After(cloneWaitTimeout):
+			t.Fatal("Expected clones up to the limit to start")
+		}
+	}
+	select {
+	case id := <-started:
+		t.Fatalf("Expected clone %d to wait for a free slot", id)
+	case <-time.After(cloneBlockedWait):
+	}
+
+	// Completing one clone lets the waiting clone start
+	finish <- struct{}{}
+	select {
+	case <-started:
+	case <-time.After(cloneWaitTimeout):
+		t.Fatal("Expected the waiting clone to start once a slot was released")
+	}
+
+	close(finish)
+	for range limit + 1 {
+		if err := <-done; err != nil {
+			t.Errorf("Expected no error but got: %v", err)
+		}
+	}
+}
+
+func TestCloneLimiterPerCluster(t *testing.T) {
+	limiter := NewCloneLimiter()
+
+	release, err := limiter.Acquire(context.Background(), newLimitedCluster("cluster-a", 1))
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	defer release()
+
+	// A full cluster does not block clones on another cluster
+	ctx, cancel := context.WithTimeout(context.Background(), cloneWaitTimeout)
+	defer cancel()
+	otherRelease, err := limiter.Acquire(ctx, newLimitedCluster("cluster-b", 1))
+	if err != nil {
+		t.Fatalf("Expected a slot on another cluster but got: %v", err)
+	}
+	otherRelease()
+}
+
+func TestCloneLimiterAcquireCanceled(t *testing.T) {
+	limiter := NewCloneLimiter()
+	cluster := newLimitedCluster("test-cluster", 1)
+
+	release, err := limiter.Acquire(context.Background(), cluster)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), cloneBlockedWait)
+	defer cancel()
+	if _, err := limiter.Acquire(ctx, cluster); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a deadline error while the cluster is full, got %v", err)
+	}
+}
+
+func TestCloneLimiterLimitChange(t *testing.T) {
+	limiter := NewCloneLimiter()
+	acquire := func(limit int32, wait time.Duration) (func(), error) {
+		ctx, cancel := context.WithTimeout(context.Background(), wait)
+		defer cancel()
+		return limiter.Acquire(ctx, newLimitedCluster("test-cluster", limit))
+	}
+
+	first, err := acquire(2, cloneWaitTimeout)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	second, err := acquire(2, cloneWaitTimeout)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	// Lowering the limit keeps counting the clones in flight, so one release is not enough
+	first()
+	if _, err := acquire(1, cloneBlockedWait); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected a deadline error while a clone is in flight at limit 1, got %v", err)
+	}
+	second()
+	third, err := acquire(1, cloneWaitTimeout)
+	if err != nil {
+		t.Fatalf("Expected a slot once the clones in flight completed, got %v", err)
+	}
+	defer third()
+
+	// Raising the limit frees a slot at once
+	fourth, err := acquire(2, cloneWaitTimeout)
+	if err != nil {
+		t.Fatalf("Expected a slot after raising the limit, got %v", err)
+	}
+	defer fourth()
+	if _, err := acquire(2, cloneBlockedWait); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a deadline error at the raised limit, got %v", err)
+	}
+}
+
+// metricValue reads a gauge's value or a histogram's sample count and sum
+func metricValue(t *testing.T, metric prometheus.Metric) *dto.Metric {
+	t.Helper()
+	out := &dto.Metric{}
+	if err := metric.Write(out); err != nil {
+		t.Fatalf("Failed to read metric: %v", err)
+	}
+	return out
+}
+
+func TestCloneLimiterMetrics(t *testing.T) {
+	limiter := NewCloneLimiter()
+	cluster := newLimitedCluster("metrics-cluster", 1)
+	inFlight := clonesInFlight.WithLabelValues("default", "metrics-cluster")
+	waiting := clonesWaiting.WithLabelValues("default", "metrics-cluster")
+	queueWait := cloneQueueWait.WithLabelValues("default", "metrics-cluster").(prometheus.Metric)
+
+	release, err := limiter.Acquire(context.Background(), cluster)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if got := metricValue(t, inFlight).GetGauge().GetValue(); got != 1 {
+		t.Errorf("Expected 1 clone in flight, got %v", got)
+	}
+
+	// A second clone queues behind the first
+	acquired := make(chan func())
+	go func() {
+		next, err := limiter.Acquire(context.Background(), cluster)
+		if err != nil {
+			t.Errorf("Expected no error but got: %v", err)
+		}
+		acquired <- next
+	}()
+	deadline := time.Now().Add(cloneWaitTimeout)
+	for metricValue(t, waiting).GetGauge().GetValue() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the second clone to be reported as waiting")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(cloneBlockedWait)
+
+	release()
+	var next func()
+	select {
+	case next = <-acquired:
+	case <-time.After(cloneWaitTimeout):
+		t.Fatal("Expected the waiting clone to acquire the released slot")
+	}
+	if got := metricValue(t, waiting).GetGauge().GetValue(); got != 0 {
+		t.Errorf("Expected no waiting clones, got %v", got)
+	}
+	if got := metricValue(t, inFlight).GetGauge().GetValue(); got != 1 {
+		t.Errorf("Expected 1 clone in flight, got %v", got)
+	}
+
+	// Both acquisitions are recorded, including the time the second spent blocked
+	histogram := metricValue(t, queueWait).GetHistogram()
+	if histogram.GetSampleCount() != 2 {
+		t.Errorf("Expected 2 queue wait samples, got %d", histogram.GetSampleCount())
+	}
+	if histogram.GetSampleSum() < cloneBlockedWait.Seconds() {
+		t.Errorf("Expected at least %v of queue wait, got %vs", cloneBlockedWait, histogram.GetSampleSum())
+	}
+
+	next()
+	if got := metricValue(t, inFlight).GetGauge().GetValue(); got != 0 {
+		t.Errorf("Expected no clones in flight, got %v", got)
+	}
+}
+
+func TestMaxConcurrentClones(t *testing.T) {
+	if got := maxConcurrentClones(newLimitedCluster("test-cluster", 0)); got != DefaultMaxConcurrentClones {
+		t.Errorf("Expected default limit %d, got %d", DefaultMaxConcurrentClones, got)
+	}
+	if got := maxConcurrentClones(newLimitedCluster("test-cluster", 7)); got != 7 {
+		t.Errorf("Expected configured limit 7, got %d", got)
+	}
+}
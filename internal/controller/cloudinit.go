@@ -0,0 +1,174 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/crypto/ssh"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	hypervisorv1alpha1 "github.com/codihuston/hyperfleet-operator/api/v1alpha1"
+	"github.com/codihuston/hyperfleet-operator/internal/provider"
+)
+
+const (
+	// UserDataSecretKey is the bootstrap Secret key holding the rendered cloud-init user-data
+	UserDataSecretKey = "user-data"
+
+	// CloudInitDebugUser is the VM user the SSH authorized keys are installed for
+	CloudInitDebugUser = "hyperfleet"
+
+	// cloudConfigHeader marks user-data as cloud-config YAML
+	cloudConfigHeader = "#cloud-config\n"
+
+	// networkModeDHCP configures the VM's primary interface with DHCP
+	networkModeDHCP = "dhcp"
+)
+
+// cloudInitNetwork renders the cloud-init network configuration for a VM, nil when there is
+// nothing to configure. A static address takes precedence over DHCP, and the static
+// configuration's DNS servers over the cluster's. The cluster's DNS servers override the
+// ones offered by DHCP, and its domain becomes the resolver search domain.
+func cloudInitNetwork(template *hypervisorv1alpha1.HypervisorMachineTemplate, cluster *hypervisorv1alpha1.HypervisorCluster) *provider.CloudInitNetwork {
+	network := &provider.CloudInitNetwork{}
+	if static := template.Spec.Network.StaticConfig; static != nil {
+		network.IP = static.IP
+		network.Gateway = static.Gateway
+		network.Nameservers = static.DNS
+	} else if template.Spec.Network.Mode == networkModeDHCP {
+		network.DHCP = true
+	}
+
+	if dns := cluster.Spec.DNS; dns != nil {
+		if dns.Domain != "" {
+			network.SearchDomains = []string{dns.Domain}
+		}
+		if len(network.Nameservers) == 0 {
+			network.Nameservers = dns.Servers
+		}
+	}
+
+	if !network.DHCP && network.IP == "" && len(network.Nameservers) == 0 && len(network.SearchDomains) == 0 {
+		return nil
+	}
+	return network
+}
+
+// cloudInitCredentials returns the cloud-init user credentials for a template's VMs, nil when the
+// template sets neither a user nor a password. The password is read from the referenced Secret,
+// and the SSH authorized keys are authorized for the user as well.
+func cloudInitCredentials(ctx context.Context, c client.Reader, template *hypervisorv1alpha1.HypervisorMachineTemplate,
+	cluster *hypervisorv1alpha1.HypervisorCluster) (*provider.CloudInitCredentials, error) {
+	spec := template.Spec.CloudInit
+	if spec == nil || (spec.User == "" && spec.PasswordSecretRef == nil) {
+		return nil, nil
+	}
+
+	credentials := &provider.CloudInitCredentials{User: spec.User}
+	if spec.PasswordSecretRef != nil {
+		password, err := getSecretKeyValue(ctx, c, template.Namespace, spec.PasswordSecretRef)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load cloud-init password: %w", err)
+		}
+		credentials.Password = password
+	}
+
+	keys := sshAuthorizedKeys(template, cluster)
+	if err := validateSSHAuthorizedKeys(keys); err != nil {
+		return nil, err
+	}
+	credentials.SSHKeys = keys
+	return credentials, nil
+}
+
+// sshAuthorizedKeys returns the template's SSH authorized keys, falling back to the cluster defaults
+func sshAuthorizedKeys(template *hypervisorv1alpha1.HypervisorMachineTemplate, cluster *hypervisorv1alpha1.HypervisorCluster) []string {
+	if template.Spec.CloudInit != nil && len(template.Spec.CloudInit.SSHAuthorizedKeys) > 0 {
+		return template.Spec.CloudInit.SSHAuthorizedKeys
+	}
+	if cluster != nil {
+		return cluster.Spec.DefaultSSHAuthorizedKeys
+	}
+	return nil
+}
+
+// validateSSHAuthorizedKeys checks every key is a single public key in authorized_keys format
+func validateSSHAuthorizedKeys(keys []string) error {
+	for i, key := range keys {
+		_, _, _, rest, err := ssh.ParseAuthorizedKey([]byte(key))
+		if err != nil {
+			return fmt.Errorf("invalid SSH authorized key %d: %w", i, err)
+		}
+		if len(rest) > 0 {
+			return fmt.Errorf("invalid SSH authorized key %d: expected a single key", i)
+		}
+	}
+	return nil
+}
+
+// renderCloudInitUserData renders the cloud-init user-data for a VM. The template's custom
+// user-data is passed through unchanged unless SSH authorized keys are configured, in which
+// case it must be cloud-config and the debug user holding the keys is added to its users.
+func renderCloudInitUserData(template *hypervisorv1alpha1.HypervisorMachineTemplate, cluster *hypervisorv1alpha1.HypervisorCluster) ([]byte, error) {
+	var userData string
+	if template.Spec.CloudInit != nil {
+		userData = template.Spec.CloudInit.UserData
+	}
+
+	keys := sshAuthorizedKeys(template, cluster)
+	if len(keys) == 0 {
+		if userData == "" {
+			return nil, nil
+		}
+		return []byte(userData), nil
+	}
+	if err := validateSSHAuthorizedKeys(keys); err != nil {
+		return nil, err
+	}
+
+	config := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(userData), &config); err != nil {
+		return nil, fmt.Errorf("failed to parse cloud-init user data as cloud-config: %w", err)
+	}
+	if config == nil {
+		config = map[string]interface{}{}
+	}
+
+	// Keep the image's default user unless the custom user-data already lists its users
+	users := []interface{}{"default"}
+	if existing, ok := config["users"]; ok {
+		if users, ok = existing.([]interface{}); !ok {
+			return nil, fmt.Errorf("cloud-init user data field users must be a list")
+		}
+	}
+	config["users"] = append(users, map[string]interface{}{
+		"name":                CloudInitDebugUser,
+		"lock_passwd":         true,
+		"sudo":                "ALL=(ALL) NOPASSWD:ALL",
+		"shell":               "/bin/bash",
+		"ssh_authorized_keys": keys,
+	})
+
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal cloud-init user data: %w", err)
+	}
+	return append([]byte(cloudConfigHeader), data...), nil
+}
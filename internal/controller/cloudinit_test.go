@@ -0,0 +1,363 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
+
+	hypervisorv1alpha1 "github.com/codihuston/hyperfleet-operator/api/v1alpha1"
+	"github.com/codihuston/hyperfleet-operator/internal/provider"
+)
+
+const (
+	testSSHKey        = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAILVjSBfL7wPfPoFU5D5L+JR5A1N2Tq170g2ug4FWxpee ops@example.com"
+	testClusterSSHKey = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAILVjSBfL7wPfPoFU5D5L+JR5A1N2Tq170g2ug4FWxpee cluster@example.com"
+)
+
+// renderedUsers parses rendered user-data and returns its users list
+func renderedUsers(t *testing.T, data []byte) []interface{} {
+	t.Helper()
+	if !strings.HasPrefix(string(data), "#cloud-config\n") {
+		t.Fatalf("Expected cloud-config user-data, got %q", data)
+	}
+	var config map[string]interface{}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		t.Fatalf("Failed to parse rendered user-data: %v", err)
+	}
+	users, ok := config["users"].([]interface{})
+	if !ok {
+		t.Fatalf("Expected users list in user-data, got %v", config["users"])
+	}
+	return users
+}
+
+// debugUserKeys returns the SSH authorized keys of the debug user in a users list
+func debugUserKeys(t *testing.T, users []interface{}) []string {
+	t.Helper()
+	for _, entry := range users {
+		user, ok := entry.(map[string]interface{})
+		if !ok || user["name"] != CloudInitDebugUser {
+			continue
+		}
+		var keys []string
+		for _, key := range user["ssh_authorized_keys"].([]interface{}) {
+			keys = append(keys, key.(string))
+		}
+		return keys
+	}
+	t.Fatalf("Expected %s user in %v", CloudInitDebugUser, users)
+	return nil
+}
+
+func TestRenderCloudInitUserData(t *testing.T) {
+	cluster := &hypervisorv1alpha1.HypervisorCluster{
+		Spec: hypervisorv1alpha1.HypervisorClusterSpec{DefaultSSHAuthorizedKeys: []string{testClusterSSHKey}},
+	}
+
+	tests := []struct {
+		name         string
+		cloudInit    *hypervisorv1alpha1.CloudInitSpec
+		expectedKeys []string
+		expectedLen  int
+	}{
+		{
+			name:         "template keys",
+			cloudInit:    &hypervisorv1alpha1.CloudInitSpec{SSHAuthorizedKeys: []string{testSSHKey}},
+			expectedKeys: []string{testSSHKey},
+			expectedLen:  2,
+		},
+		{
+			name:         "cluster default keys",
+			expectedKeys: []string{testClusterSSHKey},
+			expectedLen:  2,
+		},
+		{
+			name: "merged into custom user-data",
+			cloudInit: &hypervisorv1alpha1.CloudInitSpec{
+				UserData:          "#cloud-config\npackages:\n  - jq\nusers:\n  - name: ops\n",
+				SSHAuthorizedKeys: []string{testSSHKey},
+			},
+			expectedKeys: []string{testSSHKey},
+			expectedLen:  2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template := &hypervisorv1alpha1.HypervisorMachineTemplate{
+				Spec: hypervisorv1alpha1.HypervisorMachineTemplateSpec{CloudInit: tt.cloudInit},
+			}
+
+			data, err := renderCloudInitUserData(template, cluster)
+			if err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+
+			users := renderedUsers(t, data)
+			if len(users) != tt.expectedLen {
+				t.Errorf("Expected %d users, got %v", tt.expectedLen, users)
+			}
+			keys := debugUserKeys(t, users)
+			if strings.Join(keys, ",") != strings.Join(tt.expectedKeys, ",") {
+				t.Errorf("Expected keys %v, got %v", tt.expectedKeys, keys)
+			}
+		})
+	}
+}
+
+func TestRenderCloudInitUserDataWithoutKeys(t *testing.T) {
+	userData := "#!/bin/sh\necho hello\n"
+	template := &hypervisorv1alpha1.HypervisorMachineTemplate{
+		Spec: hypervisorv1alpha1.HypervisorMachineTemplateSpec{
+			CloudInit: &hypervisorv1alpha1.CloudInitSpec{UserData: userData},
+		},
+	}
+
+	data, err := renderCloudInitUserData(template, nil)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if string(data) != userData {
+		t.Errorf("Expected custom user-data to pass through unchanged, got %q", data)
+	}
+
+	data, err = renderCloudInitUserData(&hypervisorv1alpha1.HypervisorMachineTemplate{}, nil)
+	if err != nil || data != nil {
+		t.Errorf("Expected no user-data, got %q (err %v)", data, err)
+	}
+}
+
+func TestRenderCloudInitUserDataRejectsMalformedKey(t *testing.T) {
+	tests := map[string]string{
+		"not a key":      "not-a-key",
+		"bad base64":     "ssh-ed25519 !!!not-base64!!! ops@example.com",
+		"truncated blob": "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5 ops@example.com",
+		"two keys":       testSSHKey + "\n" + testClusterSSHKey,
+	}
+
+	for name, key := range tests {
+		t.Run(name, func(t *testing.T) {
+			template := &hypervisorv1alpha1.HypervisorMachineTemplate{
+				Spec: hypervisorv1alpha1.HypervisorMachineTemplateSpec{
+					CloudInit: &hypervisorv1alpha1.CloudInitSpec{SSHAuthorizedKeys: []string{testSSHKey, key}},
+				},
+			}
+
+			_, err := renderCloudInitUserData(template, nil)
+			if err == nil {
+				t.Fatal("Expected malformed key to be rejected")
+			}
+			if !strings.Contains(err.Error(), "invalid SSH authorized key 1") {
+				t.Errorf("Expected error to identify the malformed key, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestRenderCloudInitUserDataRejectsNonCloudConfig(t *testing.T) {
+	template := &hypervisorv1alpha1.HypervisorMachineTemplate{
+		Spec: hypervisorv1alpha1.HypervisorMachineTemplateSpec{
+			CloudInit: &hypervisorv1alpha1.CloudInitSpec{
+				UserData:          "#cloud-config\nusers: ops\n",
+				SSHAuthorizedKeys: []string{testSSHKey},
+			},
+		},
+	}
+
+	if _, err := renderCloudInitUserData(template, nil); err == nil {
+		t.Fatal("Expected an error when users is not a list")
+	}
+}
+
+func TestCloudInitNetwork(t *testing.T) {
+	clusterDNS := &hypervisorv1alpha1.DNSConfig{Domain: "runners.example.com", Servers: []string{"10.0.0.53", "10.0.1.53"}}
+
+	tests := []struct {
+		name     string
+		network  hypervisorv1alpha1.NetworkSpec
+		dns      *hypervisorv1alpha1.DNSConfig
+		expected *provider.CloudInitNetwork
+	}{
+		{
+			name: "static with its own DNS",
+			network: hypervisorv1alpha1.NetworkSpec{
+				Mode: "static",
+				StaticConfig: &hypervisorv1alpha1.StaticNetworkConfig{
+					IP: "10.0.0.5/24", Gateway: "10.0.0.1", DNS: []string{"1.1.1.1"},
+				},
+			},
+			dns: clusterDNS,
+			expected: &provider.CloudInitNetwork{
+				IP: "10.0.0.5/24", Gateway: "10.0.0.1",
+				Nameservers: []string{"1.1.1.1"}, SearchDomains: []string{"runners.example.com"},
+			},
+		},
+		{
+			name: "static falls back to cluster DNS",
+			network: hypervisorv1alpha1.NetworkSpec{
+				StaticConfig: &hypervisorv1alpha1.StaticNetworkConfig{IP: "10.0.0.5/24", Gateway: "10.0.0.1"},
+			},
+			dns: clusterDNS,
+			expected: &provider.CloudInitNetwork{
+				IP: "10.0.0.5/24", Gateway: "10.0.0.1",
+				Nameservers: []string{"10.0.0.53", "10.0.1.53"}, SearchDomains: []string{"runners.example.com"},
+			},
+		},
+		{
+			name:    "DHCP with cluster DNS override",
+			network: hypervisorv1alpha1.NetworkSpec{Mode: "dhcp"},
+			dns:     clusterDNS,
+			expected: &provider.CloudInitNetwork{
+				DHCP:        true,
+				Nameservers: []string{"10.0.0.53", "10.0.1.53"}, SearchDomains: []string{"runners.example.com"},
+			},
+		},
+		{
+			name:     "DHCP without DNS",
+			network:  hypervisorv1alpha1.NetworkSpec{Mode: "dhcp"},
+			expected: &provider.CloudInitNetwork{DHCP: true},
+		},
+		{
+			name:     "static takes precedence over DHCP",
+			network:  hypervisorv1alpha1.NetworkSpec{Mode: "dhcp", StaticConfig: &hypervisorv1alpha1.StaticNetworkConfig{IP: "10.0.0.5/24"}},
+			expected: &provider.CloudInitNetwork{IP: "10.0.0.5/24"},
+		},
+		{
+			name:    "nothing to configure",
+			network: hypervisorv1alpha1.NetworkSpec{Mode: "cloud-init"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template := newRunnerTemplate()
+			template.Spec.Network = tt.network
+			cluster := newTestCluster()
+			cluster.Spec.DNS = tt.dns
+
+			network := cloudInitNetwork(template, cluster)
+			if !reflect.DeepEqual(network, tt.expected) {
+				t.Errorf("Expected %+v, got %+v", tt.expected, network)
+			}
+		})
+	}
+}
+
+func TestCloudInitCredentials(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "runner-login", Namespace: "default"},
+		Data:       map[string][]byte{"password": []byte("s3cret")},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+	cluster := &hypervisorv1alpha1.HypervisorCluster{
+		Spec: hypervisorv1alpha1.HypervisorClusterSpec{DefaultSSHAuthorizedKeys: []string{testClusterSSHKey}},
+	}
+
+	tests := []struct {
+		name        string
+		cloudInit   *hypervisorv1alpha1.CloudInitSpec
+		expected    *provider.CloudInitCredentials
+		expectError string
+	}{
+		{
+			name: "no cloud-init configuration",
+		},
+		{
+			name:      "keys alone keep the template's credentials",
+			cloudInit: &hypervisorv1alpha1.CloudInitSpec{SSHAuthorizedKeys: []string{testSSHKey}},
+		},
+		{
+			name: "password from the referenced secret",
+			cloudInit: &hypervisorv1alpha1.CloudInitSpec{
+				User:              "runner",
+				PasswordSecretRef: &hypervisorv1alpha1.SecretKeySelector{Name: "runner-login", Key: "password"},
+				SSHAuthorizedKeys: []string{testSSHKey},
+			},
+			expected: &provider.CloudInitCredentials{User: "runner", Password: "s3cret", SSHKeys: []string{testSSHKey}},
+		},
+		{
+			name:      "user with the cluster's keys",
+			cloudInit: &hypervisorv1alpha1.CloudInitSpec{User: "runner"},
+			expected:  &provider.CloudInitCredentials{User: "runner", SSHKeys: []string{testClusterSSHKey}},
+		},
+		{
+			name: "missing password secret",
+			cloudInit: &hypervisorv1alpha1.CloudInitSpec{
+				User:              "runner",
+				PasswordSecretRef: &hypervisorv1alpha1.SecretKeySelector{Name: "missing", Key: "password"},
+			},
+			expectError: "failed to load cloud-init password: failed to get secret default/missing",
+		},
+		{
+			name: "missing password key",
+			cloudInit: &hypervisorv1alpha1.CloudInitSpec{
+				PasswordSecretRef: &hypervisorv1alpha1.SecretKeySelector{Name: "runner-login", Key: "pass"},
+			},
+			expectError: "key pass not found in secret default/runner-login",
+		},
+		{
+			name: "invalid key",
+			cloudInit: &hypervisorv1alpha1.CloudInitSpec{
+				User:              "runner",
+				SSHAuthorizedKeys: []string{"not-a-key"},
+			},
+			expectError: "invalid SSH authorized key 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template := &hypervisorv1alpha1.HypervisorMachineTemplate{
+				ObjectMeta: metav1.ObjectMeta{Name: "runner-template", Namespace: "default"},
+				Spec:       hypervisorv1alpha1.HypervisorMachineTemplateSpec{CloudInit: tt.cloudInit},
+			}
+
+			credentials, err := cloudInitCredentials(context.Background(), c, template, cluster)
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Fatalf("Expected error containing %q, got %v", tt.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+			if tt.expected == nil {
+				if credentials != nil {
+					t.Errorf("Expected the template's credentials to be kept, got %+v", credentials)
+				}
+				return
+			}
+			if credentials == nil || credentials.User != tt.expected.User || credentials.Password != tt.expected.Password ||
+				!slices.Equal(credentials.SSHKeys, tt.expected.SSHKeys) {
+				t.Errorf("Expected credentials %+v, got %+v", tt.expected, credentials)
+			}
+		})
+	}
+}
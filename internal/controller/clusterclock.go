@@ -0,0 +1,76 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ConditionClockSkew reports whether the hypervisor's clock has drifted from the controller's.
+	// It is informational only: skew never affects the Ready condition or the cluster phase.
+	ConditionClockSkew = "ClockSkew"
+
+	// MaxClockSkew is the largest clock difference tolerated before ClockSkew is set; registration
+	// token expiry and attestation checks start failing spuriously well before minutes of skew
+	MaxClockSkew = 30 * time.Second
+)
+
+// measureClockSkew returns how far the hypervisor's time, read between before and after, is ahead
+// of the controller's clock. The controller time is taken halfway through the request to offset its latency.
+func measureClockSkew(before, serverTime, after time.Time) time.Duration {
+	local := before.Add(after.Sub(before) / 2)
+	return serverTime.Sub(local)
+}
+
+// clockSkewCondition builds the ClockSkew condition from a connection test result
+func clockSkewCondition(result *ConnectionResult, generation int64) metav1.Condition {
+	condition := metav1.Condition{
+		Type:               ConditionClockSkew,
+		Status:             metav1.ConditionUnknown,
+		Reason:             "ClockSkewUnknown",
+		LastTransitionTime: result.TestedAt,
+		ObservedGeneration: generation,
+	}
+
+	switch {
+	case !result.Success:
+		condition.Message = "Clock not checked: hypervisor is not connected"
+		return condition
+	case result.ClockSkew == nil:
+		condition.Message = fmt.Sprintf("Clock check failed: %s", result.ClockSkewMessage)
+		return condition
+	}
+
+	skew := *result.ClockSkew
+	direction := "ahead of"
+	if skew < 0 {
+		skew, direction = -skew, "behind"
+	}
+	condition.Message = fmt.Sprintf("Hypervisor clock is %v %s the controller's (max %v)", skew.Round(time.Second), direction, MaxClockSkew)
+	if skew > MaxClockSkew {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "ClockSkewExceeded"
+	} else {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "ClockInSync"
+	}
+	return condition
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/codihuston/hyperfleet-operator/internal/provider"
+)
+
+// validateNodeStorage checks the storage is available on each online node, returning a message
+// for each node where it is not. An empty storage is not checked.
+func validateNodeStorage(ctx context.Context, hypervisorClient provider.HypervisorClient, nodes []provider.NodeInfo, storage string) []string {
+	if storage == "" {
+		return nil
+	}
+	var failures []string
+	for _, node := range nodes {
+		if !node.Online {
+			continue
+		}
+		if _, err := hypervisorClient.GetStorageStatus(ctx, node.Name, storage); err != nil {
+			failures = append(failures, fmt.Sprintf("storage %s is not available on node %s: %v", storage, node.Name, err))
+		}
+	}
+	return failures
+}
+
+// validateNodeNetwork checks the bridge exists on each online node, returning a message for each
+// node where it does not. An empty bridge is not checked.
+func validateNodeNetwork(ctx context.Context, hypervisorClient provider.HypervisorClient, nodes []provider.NodeInfo, bridge string) []string {
+	if bridge == "" {
+		return nil
+	}
+	var failures []string
+	for _, node := range nodes {
+		if !node.Online {
+			continue
+		}
+		networks, err := hypervisorClient.GetNodeNetworks(ctx, node.Name)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("networks of node %s could not be listed: %v", node.Name, err))
+			continue
+		}
+		if !slices.ContainsFunc(networks, func(network provider.NetworkInfo) bool { return network.Name == bridge }) {
+			failures = append(failures, fmt.Sprintf("bridge %s does not exist on node %s", bridge, node.Name))
+		}
+	}
+	return failures
+}
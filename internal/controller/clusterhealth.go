@@ -0,0 +1,193 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	hypervisorv1alpha1 "github.com/codihuston/hyperfleet-operator/api/v1alpha1"
+	"github.com/codihuston/hyperfleet-operator/internal/provider"
+)
+
+const (
+	// ConditionDegraded represents the degraded condition type
+	ConditionDegraded = "Degraded"
+
+	// HealthCheckConnection verifies the hypervisor API is reachable with the configured credentials
+	HealthCheckConnection = "Connection"
+
+	// HealthCheckVersion verifies the hypervisor is not older than the minimum supported version
+	HealthCheckVersion = "Version"
+
+	// HealthCheckNodes verifies at least one hypervisor node is online to host VMs
+	HealthCheckNodes = "Nodes"
+
+	// HealthCheckStorage verifies the cluster's DefaultStorage is available on every online node
+	HealthCheckStorage = "Storage"
+
+	// HealthCheckNetwork verifies the cluster's DefaultNetwork bridge exists on every online node
+	HealthCheckNetwork = "Network"
+)
+
+// healthCheck is the outcome of a single cluster sub-check
+type healthCheck struct {
+	Name    string
+	Passed  bool
+	Message string
+	// Required checks make the cluster unusable when they fail; other failures only degrade it
+	Required bool
+}
+
+// ClusterHealth is the aggregate result of all cluster health checks
+type ClusterHealth struct {
+	Phase   hypervisorv1alpha1.ClusterPhase
+	Message string
+	Checks  []hypervisorv1alpha1.HealthCheckStatus
+
+	// failedRequired is the first required check that failed, if any
+	failedRequired *healthCheck
+	// requiredFailures describes every failed required check, as "Name: message"
+	requiredFailures []string
+}
+
+// aggregateHealth combines individual health checks into a single cluster phase.
+// Any failed required check makes the cluster NotReady; any other failure makes it Degraded.
+func aggregateHealth(checks []healthCheck) *ClusterHealth {
+	health := &ClusterHealth{
+		Phase:  hypervisorv1alpha1.ClusterPhaseReady,
+		Checks: make([]hypervisorv1alpha1.HealthCheckStatus, 0, len(checks)),
+	}
+
+	if len(checks) == 0 {
+		health.Phase = hypervisorv1alpha1.ClusterPhaseNotReady
+		health.Message = "No health checks were run"
+		return health
+	}
+
+	var failures []string
+	for i := range checks {
+		check := checks[i]
+		health.Checks = append(health.Checks, hypervisorv1alpha1.HealthCheckStatus{
+			Name:    check.Name,
+			Passed:  check.Passed,
+			Message: check.Message,
+		})
+
+		if check.Passed {
+			continue
+		}
+
+		failure := fmt.Sprintf("%s: %s", check.Name, check.Message)
+		failures = append(failures, failure)
+		if check.Required {
+			if health.failedRequired == nil {
+				health.failedRequired = &checks[i]
+			}
+			health.requiredFailures = append(health.requiredFailures, failure)
+			health.Phase = hypervisorv1alpha1.ClusterPhaseNotReady
+		} else if health.Phase == hypervisorv1alpha1.ClusterPhaseReady {
+			health.Phase = hypervisorv1alpha1.ClusterPhaseDegraded
+		}
+	}
+
+	if len(failures) == 0 {
+		health.Message = fmt.Sprintf("All %d health checks passed", len(checks))
+	} else {
+		health.Message = fmt.Sprintf("%d of %d health checks failed: %s",
+			len(failures), len(checks), strings.Join(failures, "; "))
+	}
+
+	return health
+}
+
+// connectionCheck converts a connection test result into a required health check
+func connectionCheck(result *ConnectionResult) healthCheck {
+	return healthCheck{
+		Name:     HealthCheckConnection,
+		Passed:   result.Success,
+		Message:  result.Message,
+		Required: true,
+	}
+}
+
+// versionCheck converts the comparison of the hypervisor version with the minimum supported
+// version into a required health check. A version that cannot be compared is not held against the cluster.
+func versionCheck(result *ConnectionResult) healthCheck {
+	below, message, _ := compareMinimumVersion(result)
+	return healthCheck{Name: HealthCheckVersion, Passed: !below, Message: message, Required: true}
+}
+
+// nodesCheck converts the hypervisor's node list, narrowed to the usable nodes, into a required health
+// check. An API that answers while every usable node is offline cannot host VMs, so no online nodes fails the check.
+func nodesCheck(result *ConnectionResult, nodes []provider.NodeInfo) healthCheck {
+	check := healthCheck{Name: HealthCheckNodes, Required: true}
+	if result.Nodes == nil {
+		check.Message = fmt.Sprintf("Failed to list nodes: %s", result.NodesMessage)
+		return check
+	}
+
+	online := onlineNodes(nodes)
+	check.Passed = online > 0
+	check.Message = fmt.Sprintf("%d of %d nodes online", online, len(nodes))
+	return check
+}
+
+// storageCheck converts the validation of the cluster's default storage on the usable nodes into
+// a required health check
+func storageCheck(result *ConnectionResult, nodes []provider.NodeInfo, storage string) healthCheck {
+	return nodeDefaultCheck(HealthCheckStorage, "storage", storage, result, nodes, result.StorageFailures)
+}
+
+// networkCheck converts the validation of the cluster's default network bridge on the usable
+// nodes into a required health check
+func networkCheck(result *ConnectionResult, nodes []provider.NodeInfo, bridge string) healthCheck {
+	return nodeDefaultCheck(HealthCheckNetwork, "network", bridge, result, nodes, result.NetworkFailures)
+}
+
+// nodeDefaultCheck builds the required health check of a cluster default, e.g. its storage, that
+// must be usable on every online node. A cluster without the default has nothing to validate.
+func nodeDefaultCheck(name, kind, value string, result *ConnectionResult, nodes []provider.NodeInfo, failures []string) healthCheck {
+	check := healthCheck{Name: name, Required: true}
+	online := onlineNodes(nodes)
+	switch {
+	case value == "":
+		check.Passed = true
+		check.Message = fmt.Sprintf("No default %s configured", kind)
+	case result.Nodes == nil:
+		check.Message = fmt.Sprintf("Default %s %s not checked: nodes could not be listed", kind, value)
+	case online == 0:
+		check.Message = fmt.Sprintf("Default %s %s not checked: no online nodes", kind, value)
+	case len(failures) > 0:
+		check.Message = strings.Join(failures, "; ")
+	default:
+		check.Passed = true
+		check.Message = fmt.Sprintf("Default %s %s available on %d online nodes", kind, value, online)
+	}
+	return check
+}
+
+// onlineNodes counts the nodes that are online
+func onlineNodes(nodes []provider.NodeInfo) int {
+	online := 0
+	for _, node := range nodes {
+		if node.Online {
+			online++
+		}
+	}
+	return online
+}
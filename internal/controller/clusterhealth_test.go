@@ -0,0 +1,112 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	hypervisorv1alpha1 "github.com/codihuston/hyperfleet-operator/api/v1alpha1"
+)
+
+func TestAggregateHealth(t *testing.T) {
+	connectionOK := healthCheck{Name: "Connection", Passed: true, Message: "connected", Required: true}
+	connectionFailed := healthCheck{Name: "Connection", Passed: false, Message: "connection refused", Required: true}
+	storageOK := healthCheck{Name: "Storage", Passed: true, Message: "storage available"}
+	storageFailed := healthCheck{Name: "Storage", Passed: false, Message: "storage local-lvm not found"}
+	networkFailed := healthCheck{Name: "Network", Passed: false, Message: "bridge vmbr1 not found"}
+
+	tests := []struct {
+		name             string
+		checks           []healthCheck
+		expectedPhase    hypervisorv1alpha1.ClusterPhase
+		expectedRequired string
+		messageContains  []string
+	}{
+		{
+			name:          "no checks",
+			checks:        nil,
+			expectedPhase: hypervisorv1alpha1.ClusterPhaseNotReady,
+		},
+		{
+			name:            "all checks pass",
+			checks:          []healthCheck{connectionOK, storageOK},
+			expectedPhase:   hypervisorv1alpha1.ClusterPhaseReady,
+			messageContains: []string{"All 2 health checks passed"},
+		},
+		{
+			name:            "reachable but storage missing",
+			checks:          []healthCheck{connectionOK, storageFailed},
+			expectedPhase:   hypervisorv1alpha1.ClusterPhaseDegraded,
+			messageContains: []string{"1 of 2", "Storage: storage local-lvm not found"},
+		},
+		{
+			name:            "multiple optional checks fail",
+			checks:          []healthCheck{connectionOK, storageFailed, networkFailed},
+			expectedPhase:   hypervisorv1alpha1.ClusterPhaseDegraded,
+			messageContains: []string{"2 of 3", "Storage:", "Network:"},
+		},
+		{
+			name:             "required check fails",
+			checks:           []healthCheck{connectionFailed, storageOK},
+			expectedPhase:    hypervisorv1alpha1.ClusterPhaseNotReady,
+			expectedRequired: "Connection",
+			messageContains:  []string{"Connection: connection refused"},
+		},
+		{
+			name:             "required and optional checks fail",
+			checks:           []healthCheck{storageFailed, connectionFailed},
+			expectedPhase:    hypervisorv1alpha1.ClusterPhaseNotReady,
+			expectedRequired: "Connection",
+			messageContains:  []string{"2 of 2", "Storage:", "Connection:"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			health := aggregateHealth(tt.checks)
+
+			if health.Phase != tt.expectedPhase {
+				t.Errorf("Expected phase %s, got %s", tt.expectedPhase, health.Phase)
+			}
+
+			if len(health.Checks) != len(tt.checks) {
+				t.Fatalf("Expected %d check results, got %d", len(tt.checks), len(health.Checks))
+			}
+			for i, check := range tt.checks {
+				got := health.Checks[i]
+				if got.Name != check.Name || got.Passed != check.Passed || got.Message != check.Message {
+					t.Errorf("Expected check %+v, got %+v", check, got)
+				}
+			}
+
+			if tt.expectedRequired == "" {
+				if health.failedRequired != nil {
+					t.Errorf("Expected no failed required check, got %s", health.failedRequired.Name)
+				}
+			} else if health.failedRequired == nil || health.failedRequired.Name != tt.expectedRequired {
+				t.Errorf("Expected failed required check %s, got %v", tt.expectedRequired, health.failedRequired)
+			}
+
+			for _, fragment := range tt.messageContains {
+				if !strings.Contains(health.Message, fragment) {
+					t.Errorf("Expected message to contain %q, got %q", fragment, health.Message)
+				}
+			}
+		})
+	}
+}
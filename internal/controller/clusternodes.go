@@ -0,0 +1,107 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/codihuston/hyperfleet-operator/internal/provider"
+)
+
+// ConditionNodesInSync reports whether the nodes listed in the cluster spec match the hypervisor's nodes.
+// Listed nodes the hypervisor does not know make it False; unlisted hypervisor nodes are only noted in the message.
+const ConditionNodesInSync = "NodesInSync"
+
+// diffNodes compares the nodes listed in the spec with the hypervisor's nodes. missing are listed
+// nodes the hypervisor does not have; unlisted are hypervisor nodes the spec does not mention.
+func diffNodes(listed []string, nodes []provider.NodeInfo) (missing, unlisted []string) {
+	known := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		known[node.Name] = true
+		if !slices.Contains(listed, node.Name) {
+			unlisted = append(unlisted, node.Name)
+		}
+	}
+	for _, name := range listed {
+		if !known[name] {
+			missing = append(missing, name)
+		}
+	}
+	return missing, unlisted
+}
+
+// listedNodes returns the hypervisor nodes named in the spec, or all of them when the spec lists none.
+// A nil node list stays nil so callers can still tell that listing failed.
+func listedNodes(listed []string, nodes []provider.NodeInfo) []provider.NodeInfo {
+	if nodes == nil || len(listed) == 0 {
+		return nodes
+	}
+	filtered := []provider.NodeInfo{}
+	for _, node := range nodes {
+		if slices.Contains(listed, node.Name) {
+			filtered = append(filtered, node)
+		}
+	}
+	return filtered
+}
+
+// nodesInSyncCondition builds the NodesInSync condition from the spec's node list and a connection test result
+func nodesInSyncCondition(listed []string, result *ConnectionResult, generation int64) metav1.Condition {
+	condition := metav1.Condition{
+		Type:               ConditionNodesInSync,
+		Status:             metav1.ConditionUnknown,
+		Reason:             "NodesUnknown",
+		LastTransitionTime: result.TestedAt,
+		ObservedGeneration: generation,
+	}
+	if result.Nodes == nil {
+		condition.Message = "Node list not checked: hypervisor nodes could not be listed"
+		return condition
+	}
+
+	missing, unlisted := diffNodes(listed, result.Nodes)
+	var messages []string
+	if len(missing) > 0 {
+		messages = append(messages, fmt.Sprintf("Listed nodes not found on the hypervisor: %s", strings.Join(missing, ", ")))
+	}
+	if len(listed) > 0 && len(unlisted) > 0 {
+		messages = append(messages, fmt.Sprintf("Hypervisor nodes not listed in spec: %s", strings.Join(unlisted, ", ")))
+	}
+
+	switch {
+	case len(missing) > 0:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "NodesMissing"
+	case len(messages) > 0:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "UnlistedNodes"
+	case len(listed) == 0:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "NodesMatch"
+		messages = append(messages, "No nodes listed in spec, all hypervisor nodes are used")
+	default:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "NodesMatch"
+		messages = append(messages, fmt.Sprintf("All %d listed nodes found on the hypervisor", len(listed)))
+	}
+	condition.Message = strings.Join(messages, "; ")
+	return condition
+}
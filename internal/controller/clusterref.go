@@ -0,0 +1,69 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hypervisorv1alpha1 "github.com/codihuston/hyperfleet-operator/api/v1alpha1"
+)
+
+// ReasonCrossNamespaceClusterRef is the TemplateValid reason for a template referencing a
+// HypervisorCluster in another namespace while cross-namespace references are not allowed
+const ReasonCrossNamespaceClusterRef = "CrossNamespaceClusterRefDenied"
+
+// ReasonCrossNamespaceTemplateRef is the BootstrapReady reason for a claim referencing a
+// HypervisorMachineTemplate in another namespace while cross-namespace references are not allowed
+const ReasonCrossNamespaceTemplateRef = "CrossNamespaceTemplateRefDenied"
+
+// templateClusterKey returns the key of the HypervisorCluster a template references; the
+// reference defaults to the template's namespace
+func templateClusterKey(template *hypervisorv1alpha1.HypervisorMachineTemplate) client.ObjectKey {
+	key := client.ObjectKey{
+		Name:      template.Spec.HypervisorClusterRef.Name,
+		Namespace: template.Spec.HypervisorClusterRef.Namespace,
+	}
+	if key.Namespace == "" {
+		key.Namespace = template.Namespace
+	}
+	return key
+}
+
+// checkClusterRef rejects a template's reference to a HypervisorCluster in another namespace
+// unless allowCrossNamespace is set. The operator can read clusters in every namespace, so
+// without this check anyone able to create a template could use any namespace's cluster.
+func checkClusterRef(template *hypervisorv1alpha1.HypervisorMachineTemplate, key client.ObjectKey, allowCrossNamespace bool) error {
+	if allowCrossNamespace || key.Namespace == template.Namespace {
+		return nil
+	}
+	return fmt.Errorf("HypervisorCluster %s is in another namespace than template %s/%s and cross-namespace cluster references are not allowed",
+		key, template.Namespace, template.Name)
+}
+
+// checkTemplateRef rejects a claim's reference to a HypervisorMachineTemplate in another
+// namespace unless allowCrossNamespace is set. A claim's VM is bootstrapped with credentials
+// from its template's namespace and the result is stored in the claim's namespace, so without
+// this check anyone able to create a claim could read another namespace's credentials.
+func checkTemplateRef(claim *hypervisorv1alpha1.MachineClaim, key client.ObjectKey, allowCrossNamespace bool) error {
+	if allowCrossNamespace || key.Namespace == claim.Namespace {
+		return nil
+	}
+	return fmt.Errorf("HypervisorMachineTemplate %s is in another namespace than claim %s/%s and cross-namespace references are not allowed",
+		key, claim.Namespace, claim.Name)
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/codihuston/hyperfleet-operator/internal/provider"
+)
+
+// ConditionSubscriptionActive reports the hypervisor's support subscription. It is informational
+// only: an expired or missing subscription never affects the Ready condition or the cluster phase.
+const ConditionSubscriptionActive = "SubscriptionActive"
+
+// subscriptionCondition builds the SubscriptionActive condition from a connection test result
+func subscriptionCondition(result *ConnectionResult, generation int64) metav1.Condition {
+	condition := metav1.Condition{
+		Type:               ConditionSubscriptionActive,
+		Status:             metav1.ConditionUnknown,
+		Reason:             "SubscriptionUnknown",
+		LastTransitionTime: result.TestedAt,
+		ObservedGeneration: generation,
+	}
+
+	switch {
+	case !result.Success:
+		condition.Message = "Subscription not checked: hypervisor is not connected"
+		return condition
+	case result.Subscription == nil:
+		condition.Message = fmt.Sprintf("Subscription check failed: %s", result.SubscriptionMessage)
+		return condition
+	}
+
+	condition.Message = result.Subscription.Message
+	switch result.Subscription.State {
+	case provider.SubscriptionActive:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "SubscriptionActive"
+	case provider.SubscriptionExpired:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "SubscriptionExpired"
+	default:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "NoSubscription"
+	}
+	return condition
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hypervisorv1alpha1 "github.com/codihuston/hyperfleet-operator/api/v1alpha1"
+	"github.com/codihuston/hyperfleet-operator/internal/provider"
+)
+
+// ConditionNodeVersionSkew reports whether the cluster's nodes run different hypervisor releases.
+// It is informational only: mixed versions never affect the Ready condition or the cluster phase.
+const ConditionNodeVersionSkew = "NodeVersionSkew"
+
+// ConditionUnsupportedVersion reports whether the hypervisor is older than the minimum supported
+// version. It is only set when a minimum version is configured.
+const ConditionUnsupportedVersion = "UnsupportedVersion"
+
+// nodeVersionReleaseParts is how many leading version components make up a release, e.g. "8.2"
+// of "8.2.4"; nodes differing only in later components run compatible patch levels
+const nodeVersionReleaseParts = 2
+
+// readNodeVersions returns the hypervisor version of each online node, and a message for each
+// node whose version could not be read
+func readNodeVersions(ctx context.Context, hypervisorClient provider.HypervisorClient, nodes []provider.NodeInfo) (map[string]string, []string) {
+	versions := make(map[string]string, len(nodes))
+	var failures []string
+	for _, node := range nodes {
+		if !node.Online {
+			continue
+		}
+		version, err := hypervisorClient.GetNodeVersion(ctx, node.Name)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", node.Name, err))
+			continue
+		}
+		versions[node.Name] = version
+	}
+	return versions, failures
+}
+
+// nodeVersionRelease returns the release of a version, its major and minor components
+func nodeVersionRelease(version string) string {
+	parts := strings.SplitN(version, ".", nodeVersionReleaseParts+1)
+	return strings.Join(parts[:min(len(parts), nodeVersionReleaseParts)], ".")
+}
+
+// nodeVersionStatus lists node versions for the cluster status, sorted by node name
+func nodeVersionStatus(versions map[string]string) []hypervisorv1alpha1.NodeVersionStatus {
+	statuses := make([]hypervisorv1alpha1.NodeVersionStatus, 0, len(versions))
+	for _, name := range slices.Sorted(maps.Keys(versions)) {
+		statuses = append(statuses, hypervisorv1alpha1.NodeVersionStatus{Name: name, Version: versions[name]})
+	}
+	return statuses
+}
+
+// nodeVersionSkewCondition builds the NodeVersionSkew condition from a connection test result
+func nodeVersionSkewCondition(result *ConnectionResult, generation int64) metav1.Condition {
+	condition := metav1.Condition{
+		Type:               ConditionNodeVersionSkew,
+		Status:             metav1.ConditionUnknown,
+		Reason:             "NodeVersionsUnknown",
+		LastTransitionTime: result.TestedAt,
+		ObservedGeneration: generation,
+	}
+
+	var failed string
+	if len(result.NodeVersionFailures) > 0 {
+		failed = fmt.Sprintf("; versions not read: %s", strings.Join(result.NodeVersionFailures, "; "))
+	}
+	switch {
+	case !result.Success:
+		condition.Message = "Node versions not checked: hypervisor is not connected"
+		return condition
+	case len(result.NodeVersions) == 0:
+		condition.Message = "No online node reported its version" + failed
+		return condition
+	}
+
+	releases := make(map[string]bool)
+	var release string
+	var listed []string
+	for _, status := range nodeVersionStatus(result.NodeVersions) {
+		release = nodeVersionRelease(status.Version)
+		releases[release] = true
+		listed = append(listed, fmt.Sprintf("%s %s", status.Name, status.Version))
+	}
+	if len(releases) > 1 {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "NodeVersionsDiverge"
+		condition.Message = fmt.Sprintf("Nodes run different hypervisor releases: %s", strings.Join(listed, ", ")) + failed
+	} else {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "NodeVersionsMatch"
+		condition.Message = fmt.Sprintf("All %d online nodes run hypervisor release %s", len(result.NodeVersions), release) + failed
+	}
+	return condition
+}
+
+// compareMinimumVersion reports whether the hypervisor version of a connection test result is
+// below its minimum version, with a message describing the comparison. A version that cannot be
+// parsed is returned as an error and never reported as below the minimum.
+func compareMinimumVersion(result *ConnectionResult) (bool, string, error) {
+	if result.MinimumVersion == nil {
+		return false, "No minimum hypervisor version configured", nil
+	}
+	version, err := provider.ParseVersion(result.Version)
+	if err != nil {
+		return false, fmt.Sprintf("Hypervisor version not compared with minimum %s: %v", result.MinimumVersion, err), err
+	}
+	if version.Compare(*result.MinimumVersion) < 0 {
+		return true, fmt.Sprintf("Hypervisor version %s is below the minimum supported version %s", version, result.MinimumVersion), nil
+	}
+	return false, fmt.Sprintf("Hypervisor version %s meets the minimum supported version %s", version, result.MinimumVersion), nil
+}
+
+// unsupportedVersionCondition builds the UnsupportedVersion condition from a connection test result
+func unsupportedVersionCondition(result *ConnectionResult, generation int64) metav1.Condition {
+	condition := metav1.Condition{
+		Type:               ConditionUnsupportedVersion,
+		Status:             metav1.ConditionUnknown,
+		Reason:             "VersionUnknown",
+		LastTransitionTime: result.TestedAt,
+		ObservedGeneration: generation,
+	}
+	if !result.Success {
+		condition.Message = "Hypervisor version not checked: hypervisor is not connected"
+		return condition
+	}
+
+	below, message, err := compareMinimumVersion(result)
+	condition.Message = message
+	switch {
+	case err != nil:
+	case below:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "VersionBelowMinimum"
+	default:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "VersionSupported"
+	}
+	return condition
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hypervisorv1alpha1 "github.com/codihuston/hyperfleet-operator/api/v1alpha1"
+	"github.com/codihuston/hyperfleet-operator/internal/provider"
+)
+
+// loadCredentials loads authentication credentials from Kubernetes secrets
+func loadCredentials(ctx context.Context, c client.Reader, cluster *hypervisorv1alpha1.HypervisorCluster) (*provider.AuthConfig, error) {
+	creds := cluster.Spec.Credentials
+
+	// Check token-based authentication (preferred)
+	if creds.TokenID != nil && creds.TokenSecret != nil {
+		tokenID, err := getSecretValue(ctx, c, cluster.Namespace, creds.TokenID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get tokenId: %w", err)
+		}
+
+		tokenSecret, err := getSecretValue(ctx, c, cluster.Namespace, creds.TokenSecret)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get tokenSecret: %w", err)
+		}
+
+		return &provider.AuthConfig{
+			Type:        "token",
+			TokenID:     tokenID,
+			TokenSecret: tokenSecret,
+		}, nil
+	}
+
+	// Check username/password authentication
+	if creds.Username != nil && creds.Password != nil {
+		username, err := getSecretValue(ctx, c, cluster.Namespace, creds.Username)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get username: %w", err)
+		}
+
+		password, err := getSecretValue(ctx, c, cluster.Namespace, creds.Password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get password: %w", err)
+		}
+
+		return &provider.AuthConfig{
+			Type:     "password",
+			Username: username,
+			Password: password,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("no valid credential configuration found")
+}
+
+// gitHubAppCredentials are the credentials a GitHub App authenticates with
+type gitHubAppCredentials struct {
+	AppID          int64
+	InstallationID int64
+	PrivateKey     []byte
+}
+
+// loadGitHubAppCredentials loads a GitHub App's ID, installation ID and private key from their
+// secrets, reporting every missing secret or key together
+func loadGitHubAppCredentials(ctx context.Context, c client.Reader, namespace string, app *hypervisorv1alpha1.GitHubAppConfig) (*gitHubAppCredentials, error) {
+	if app == nil {
+		return nil, fmt.Errorf("GitHub App configuration is required")
+	}
+
+	values, err := getSecretKeyValues(ctx, c, namespace, []namedSecretKey{
+		{Field: "appId", Selector: app.AppID},
+		{Field: "installationId", Selector: app.InstallationID},
+		{Field: "privateKey", Selector: app.PrivateKey},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load GitHub App credentials: %w", err)
+	}
+
+	appID, err := strconv.ParseInt(strings.TrimSpace(values["appId"]), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GitHub App ID: %w", err)
+	}
+	installationID, err := strconv.ParseInt(strings.TrimSpace(values["installationId"]), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GitHub App installation ID: %w", err)
+	}
+	return &gitHubAppCredentials{
+		AppID:          appID,
+		InstallationID: installationID,
+		PrivateKey:     []byte(values["privateKey"]),
+	}, nil
+}
+
+// newProviderClient creates an authenticated hypervisor client for a cluster
+func newProviderClient(ctx context.Context, c client.Reader, factory provider.ClientFactory, cluster *hypervisorv1alpha1.HypervisorCluster) (provider.HypervisorClient, error) {
+	auth, err := loadCredentials(ctx, c, cluster)
+	if err != nil {
+		return nil, fmt.Errorf("credential loading failed: %w", err)
+	}
+
+	if factory == nil {
+		factory = provider.NewClientFactory()
+	}
+
+	hypervisorClient, err := factory.CreateClient(cluster.Spec.Provider, buildClientConfig(cluster), auth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create hypervisor client: %w", err)
+	}
+	return hypervisorClient, nil
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	hypervisorv1alpha1 "github.com/codihuston/hyperfleet-operator/api/v1alpha1"
+	"github.com/codihuston/hyperfleet-operator/internal/provider"
+)
+
+const (
+	// ConditionResourcesInSync reports whether a claim's VM CPU and memory match its template
+	ConditionResourcesInSync = "ResourcesInSync"
+	// ReasonRebootRequired marks a claim whose VM was reconfigured but only takes the change at its next boot
+	ReasonRebootRequired = "RebootRequired"
+
+	// bytesPerMiB converts template memory quantities to the MiB used by providers
+	bytesPerMiB = 1024 * 1024
+)
+
+// DriftRebootTimeout bounds how long a VM rebooted under DriftPolicyReconcileWithReboot gets to
+// shut down and come back up
+var DriftRebootTimeout = 5 * time.Minute
+
+// desiredVMResources returns the CPU and memory allocation a template asks for
+func desiredVMResources(template *hypervisorv1alpha1.HypervisorMachineTemplate) (provider.VMResources, error) {
+	memory, err := resource.ParseQuantity(template.Spec.Resources.Memory)
+	if err != nil {
+		return provider.VMResources{}, fmt.Errorf("invalid memory %q in template %s: %w", template.Spec.Resources.Memory, template.Name, err)
+	}
+	return provider.VMResources{
+		CPUs:      template.Spec.Resources.CPU,
+		MemoryMiB: memory.Value() / bytesPerMiB,
+	}, nil
+}
+
+// describeDrift lists the differences between the actual and desired resources, empty when they match
+func describeDrift(actual, desired provider.VMResources) string {
+	var drift []string
+	if actual.CPUs != desired.CPUs {
+		drift = append(drift, fmt.Sprintf("CPUs %d, template %d", actual.CPUs, desired.CPUs))
+	}
+	if actual.MemoryMiB != desired.MemoryMiB {
+		drift = append(drift, fmt.Sprintf("memory %dMiB, template %dMiB", actual.MemoryMiB, desired.MemoryMiB))
+	}
+	return strings.Join(drift, "; ")
+}
+
+// reconcileVMResources compares the VM's CPU and memory to the template and handles drift
+// according to the template's DriftPolicy
+func reconcileVMResources(ctx context.Context, hypervisorClient provider.HypervisorClient, claim *hypervisorv1alpha1.MachineClaim,
+	template *hypervisorv1alpha1.HypervisorMachineTemplate) error {
+	policy := template.Spec.DriftPolicy
+	if policy == "" {
+		policy = hypervisorv1alpha1.DriftPolicyWarn
+	}
+	if policy == hypervisorv1alpha1.DriftPolicyIgnore {
+		meta.RemoveStatusCondition(&claim.Status.Conditions, ConditionResourcesInSync)
+		return nil
+	}
+
+	desired, err := desiredVMResources(template)
+	if err != nil {
+		return err
+	}
+	ref := provider.VMRef{Node: claim.Status.VMRef.Node, ID: claim.Status.VMRef.ID}
+	info, err := hypervisorClient.GetVM(ctx, ref)
+	if err != nil {
+		return err
+	}
+
+	condition := metav1.Condition{
+		Type:               ConditionResourcesInSync,
+		Status:             metav1.ConditionTrue,
+		Reason:             "ResourcesMatch",
+		Message:            "VM CPU and memory match the template",
+		ObservedGeneration: claim.Generation,
+	}
+
+	drift := describeDrift(info.Resources, desired)
+	switch {
+	case drift == "":
+	case policy == hypervisorv1alpha1.DriftPolicyReconcile || policy == hypervisorv1alpha1.DriftPolicyReconcileWithReboot:
+		err := hypervisorClient.ReconfigureVM(ctx, ref, desired)
+		switch {
+		case provider.IsRebootRequired(err) && policy == hypervisorv1alpha1.DriftPolicyReconcileWithReboot:
+			if err := hypervisorClient.RebootVM(ctx, ref, DriftRebootTimeout); err != nil {
+				return fmt.Errorf("failed to reboot VM %d to apply its new resources: %w", ref.ID, err)
+			}
+			logf.FromContext(ctx).Info("Rebooted VM to apply corrected resources", "vm", ref.ID, "drift", drift)
+			condition.Reason = "DriftCorrected"
+			condition.Message = "Reconfigured and rebooted VM to match the template: " + drift
+		case provider.IsRebootRequired(err):
+			logf.FromContext(ctx).Info("Reconfigured VM pending a reboot", "vm", ref.ID, "drift", drift)
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = ReasonRebootRequired
+			condition.Message = "Reconfigured VM to match the template, the change applies after a reboot: " + drift
+		case err != nil:
+			return err
+		default:
+			logf.FromContext(ctx).Info("Corrected VM resource drift", "vm", ref.ID, "drift", drift)
+			condition.Reason = "DriftCorrected"
+			condition.Message = "Reconfigured VM to match the template: " + drift
+		}
+	default:
+		logf.FromContext(ctx).Info("VM resources drifted from template", "vm", ref.ID, "drift", drift)
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "ResourceDrift"
+		condition.Message = drift
+	}
+
+	meta.SetStatusCondition(&claim.Status.Conditions, condition)
+	return nil
+}
@@ -0,0 +1,205 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hypervisorv1alpha1 "github.com/codihuston/hyperfleet-operator/api/v1alpha1"
+	"github.com/codihuston/hyperfleet-operator/internal/provider"
+)
+
+func TestDesiredVMResources(t *testing.T) {
+	tests := map[string]int64{
+		"4Gi":    4096,
+		"8192Mi": 8192,
+		"1Ti":    1024 * 1024,
+	}
+	for memory, expected := range tests {
+		template := newRunnerTemplate()
+		template.Spec.Resources.Memory = memory
+
+		resources, err := desiredVMResources(template)
+		if err != nil {
+			t.Fatalf("desiredVMResources(%q) error = %v", memory, err)
+		}
+		if resources.CPUs != 2 || resources.MemoryMiB != expected {
+			t.Errorf("desiredVMResources(%q) = %+v, want 2 CPUs and %dMiB", memory, resources, expected)
+		}
+	}
+
+	template := newRunnerTemplate()
+	template.Spec.Resources.Memory = "lots"
+	if _, err := desiredVMResources(template); err == nil {
+		t.Error("Expected an error for invalid memory")
+	}
+}
+
+func TestReconcileVMResources(t *testing.T) {
+	matching := provider.VMResources{CPUs: 2, MemoryMiB: 4096}
+	drifted := provider.VMResources{CPUs: 4, MemoryMiB: 4096}
+
+	tests := []struct {
+		name              string
+		policy            hypervisorv1alpha1.DriftPolicy
+		actual            provider.VMResources
+		expectedStatus    metav1.ConditionStatus
+		expectedReason    string
+		reconfigureErr    error
+		expectReconfigure bool
+		expectReboot      bool
+	}{
+		{name: "ignore with drift", policy: hypervisorv1alpha1.DriftPolicyIgnore, actual: drifted},
+		{name: "ignore without drift", policy: hypervisorv1alpha1.DriftPolicyIgnore, actual: matching},
+		{
+			name:           "warn with drift",
+			policy:         hypervisorv1alpha1.DriftPolicyWarn,
+			actual:         drifted,
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "ResourceDrift",
+		},
+		{
+			name:           "warn without drift",
+			policy:         hypervisorv1alpha1.DriftPolicyWarn,
+			actual:         matching,
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: "ResourcesMatch",
+		},
+		{
+			name:           "default policy warns",
+			actual:         drifted,
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "ResourceDrift",
+		},
+		{
+			name:              "reconcile with drift",
+			policy:            hypervisorv1alpha1.DriftPolicyReconcile,
+			actual:            drifted,
+			expectedStatus:    metav1.ConditionTrue,
+			expectedReason:    "DriftCorrected",
+			expectReconfigure: true,
+		},
+		{
+			name:              "reconcile with drift the VM cannot hot-plug",
+			policy:            hypervisorv1alpha1.DriftPolicyReconcile,
+			actual:            drifted,
+			reconfigureErr:    fmt.Errorf("%w: VM 200 is running", provider.ErrRebootRequired),
+			expectedStatus:    metav1.ConditionFalse,
+			expectedReason:    ReasonRebootRequired,
+			expectReconfigure: true,
+		},
+		{
+			name:              "reconcile with reboot, hot-plugged",
+			policy:            hypervisorv1alpha1.DriftPolicyReconcileWithReboot,
+			actual:            drifted,
+			expectedStatus:    metav1.ConditionTrue,
+			expectedReason:    "DriftCorrected",
+			expectReconfigure: true,
+		},
+		{
+			name:              "reconcile with reboot, change pending a reboot",
+			policy:            hypervisorv1alpha1.DriftPolicyReconcileWithReboot,
+			actual:            drifted,
+			reconfigureErr:    fmt.Errorf("%w: VM 200 is running", provider.ErrRebootRequired),
+			expectedStatus:    metav1.ConditionTrue,
+			expectedReason:    "DriftCorrected",
+			expectReconfigure: true,
+			expectReboot:      true,
+		},
+		{
+			name:           "reconcile without drift",
+			policy:         hypervisorv1alpha1.DriftPolicyReconcile,
+			actual:         matching,
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: "ResourcesMatch",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claim := newTestClaim()
+			claim.Status.VMRef = &hypervisorv1alpha1.VMReference{Node: "pve1", ID: 200}
+			// A stale condition from an earlier policy must not linger
+			meta.SetStatusCondition(&claim.Status.Conditions, metav1.Condition{
+				Type: ConditionResourcesInSync, Status: metav1.ConditionUnknown, Reason: "Stale",
+			})
+
+			template := newRunnerTemplate()
+			template.Spec.DriftPolicy = tt.policy
+
+			getCalls, rebooted := 0, 0
+			var reconfigured []provider.VMResources
+			mockClient := &provider.MockHypervisorClient{
+				GetVMFunc: func(_ context.Context, ref provider.VMRef) (*provider.VMInfo, error) {
+					getCalls++
+					return &provider.VMInfo{Ref: ref, Resources: tt.actual}, nil
+				},
+				ReconfigureVMFunc: func(_ context.Context, ref provider.VMRef, resources provider.VMResources) error {
+					if ref.Node != "pve1" || ref.ID != 200 {
+						t.Errorf("unexpected VM ref %+v", ref)
+					}
+					reconfigured = append(reconfigured, resources)
+					return tt.reconfigureErr
+				},
+				RebootVMFunc: func(_ context.Context, ref provider.VMRef, timeout time.Duration) error {
+					rebooted++
+					return nil
+				},
+			}
+
+			if err := reconcileVMResources(context.Background(), mockClient, claim, template); err != nil {
+				t.Fatalf("reconcileVMResources() error = %v", err)
+			}
+
+			condition := meta.FindStatusCondition(claim.Status.Conditions, ConditionResourcesInSync)
+			if tt.policy == hypervisorv1alpha1.DriftPolicyIgnore {
+				if getCalls != 0 {
+					t.Errorf("Expected the VM not to be inspected, got %d calls", getCalls)
+				}
+				if condition != nil {
+					t.Errorf("Expected no ResourcesInSync condition, got %+v", condition)
+				}
+			} else {
+				if condition == nil {
+					t.Fatal("Expected ResourcesInSync condition to be set")
+				}
+				if condition.Status != tt.expectedStatus || condition.Reason != tt.expectedReason {
+					t.Errorf("Expected ResourcesInSync %s/%s, got %s/%s", tt.expectedStatus, tt.expectedReason, condition.Status, condition.Reason)
+				}
+			}
+
+			if tt.expectReconfigure {
+				if len(reconfigured) != 1 || reconfigured[0] != matching {
+					t.Errorf("Expected VM to be reconfigured to %+v, got %v", matching, reconfigured)
+				}
+			} else if len(reconfigured) != 0 {
+				t.Errorf("Expected no reconfiguration, got %v", reconfigured)
+			}
+			if tt.expectReboot && rebooted != 1 {
+				t.Errorf("Expected the VM to be rebooted once, got %d reboots", rebooted)
+			} else if !tt.expectReboot && rebooted != 0 {
+				t.Errorf("Expected no reboot, got %d", rebooted)
+			}
+		})
+	}
+}
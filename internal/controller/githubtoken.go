@@ -0,0 +1,263 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hypervisorv1alpha1 "github.com/codihuston/hyperfleet-operator/api/v1alpha1"
+)
+
+const (
+	// gitHubAPIURL is the REST API of github.com; GitHub Enterprise Server serves it under /api/v3
+	gitHubAPIURL          = "https://api.github.com"
+	gitHubServerAPIPath   = "/api/v3"
+	gitHubDotComHost      = "github.com"
+	gitHubAPIVersion      = "2022-11-28"
+	gitHubRequestTimeout  = 30 * time.Second
+	gitHubMaxResponseSize = 1 << 20
+
+	// gitHubAppJWTLifetime is how long the JWT a GitHub App signs stays valid; GitHub accepts
+	// at most ten minutes. It is backdated by gitHubAppJWTClockSkew against clock drift.
+	gitHubAppJWTLifetime  = 9 * time.Minute
+	gitHubAppJWTClockSkew = time.Minute
+)
+
+// GitHubTokenProvider mints runner registration tokens through the GitHub REST API. It
+// authenticates with the template's GitHub App, or with its personal access token when no
+// App is configured, reading the credentials from their Secrets in the template's namespace.
+type GitHubTokenProvider struct {
+	Client     client.Reader
+	HTTPClient *http.Client
+}
+
+// NewGitHubTokenProvider returns a GitHubTokenProvider reading credentials through c
+func NewGitHubTokenProvider(c client.Reader) *GitHubTokenProvider {
+	return &GitHubTokenProvider{
+		Client:     c,
+		HTTPClient: &http.Client{Timeout: gitHubRequestTimeout},
+	}
+}
+
+// gitHubToken is a token returned by the GitHub API with its expiry
+type gitHubToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// RegistrationToken implements RegistrationTokenProvider
+func (p *GitHubTokenProvider) RegistrationToken(ctx context.Context, namespace string, github *hypervisorv1alpha1.GitHubConfig) (*RegistrationToken, error) {
+	if github == nil {
+		return nil, fmt.Errorf("GitHub configuration is required")
+	}
+	apiURL, err := gitHubAPIBaseURL(github.URL)
+	if err != nil {
+		return nil, err
+	}
+	tokenPath, err := registrationTokenPath(github.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	var credential string
+	switch {
+	case github.App != nil:
+		app, err := loadGitHubAppCredentials(ctx, p.Client, namespace, github.App)
+		if err != nil {
+			return nil, err
+		}
+		credential, err = p.installationToken(ctx, apiURL, app)
+		if err != nil {
+			return nil, err
+		}
+	case github.PAT != nil:
+		credential, err = getSecretKeyValue(ctx, p.Client, namespace, github.PAT)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load GitHub PAT: %w", err)
+		}
+		credential = strings.TrimSpace(credential)
+	default:
+		return nil, fmt.Errorf("GitHub configuration has neither App nor PAT credentials")
+	}
+
+	token, err := p.postToken(ctx, apiURL+tokenPath, credential)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create runner registration token: %w", err)
+	}
+	return &RegistrationToken{Token: token.Token, ExpiresAt: token.ExpiresAt}, nil
+}
+
+// installationToken exchanges a JWT signed with the App's private key for an access token of
+// its installation
+func (p *GitHubTokenProvider) installationToken(ctx context.Context, apiURL string, app *gitHubAppCredentials) (string, error) {
+	jwt, err := gitHubAppJWT(app, time.Now())
+	if err != nil {
+		return "", err
+	}
+	token, err := p.postToken(ctx, fmt.Sprintf("%s/app/installations/%d/access_tokens", apiURL, app.InstallationID), jwt)
+	if err != nil {
+		return "", fmt.Errorf("failed to create GitHub App installation token: %w", err)
+	}
+	return token.Token, nil
+}
+
+// postToken POSTs to a GitHub endpoint returning a token, authenticated with the bearer credential
+func (p *GitHubTokenProvider) postToken(ctx context.Context, endpoint, credential string) (*gitHubToken, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+credential)
+	req.Header.Set("X-GitHub-Api-Version", gitHubAPIVersion)
+
+	httpClient := p.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, gitHubMaxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GitHub response: %w", err)
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		var failure struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(body, &failure) == nil && failure.Message != "" {
+			return nil, fmt.Errorf("GitHub returned %s: %s", resp.Status, failure.Message)
+		}
+		return nil, fmt.Errorf("GitHub returned %s", resp.Status)
+	}
+
+	token := &gitHubToken{}
+	if err := json.Unmarshal(body, token); err != nil {
+		return nil, fmt.Errorf("unexpected GitHub response: %w", err)
+	}
+	if token.Token == "" {
+		return nil, fmt.Errorf("GitHub response has no token")
+	}
+	return token, nil
+}
+
+// gitHubAPIBaseURL returns the REST API base URL for a registration URL: api.github.com for
+// github.com, and the server's /api/v3 for GitHub Enterprise Server
+func gitHubAPIBaseURL(registrationURL string) (string, error) {
+	parsed, err := url.Parse(registrationURL)
+	if err != nil || parsed.Host == "" {
+		return "", fmt.Errorf("invalid GitHub URL %q", registrationURL)
+	}
+	if strings.EqualFold(parsed.Host, gitHubDotComHost) {
+		return gitHubAPIURL, nil
+	}
+	return parsed.Scheme + "://" + parsed.Host + gitHubServerAPIPath, nil
+}
+
+// registrationTokenPath returns the API path minting registration tokens for the runner scope
+// of a registration URL
+func registrationTokenPath(registrationURL string) (string, error) {
+	parsed, err := url.Parse(registrationURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid GitHub URL %q", registrationURL)
+	}
+	path := strings.Trim(parsed.Path, "/")
+	switch gitHubRunnerScope(registrationURL) {
+	case runnerScopeRepository:
+		return "/repos/" + path + "/actions/runners/registration-token", nil
+	case runnerScopeOrganization:
+		return "/orgs/" + path + "/actions/runners/registration-token", nil
+	case runnerScopeEnterprise:
+		// The path already starts with the enterprises segment
+		return "/" + path + "/actions/runners/registration-token", nil
+	}
+	return "", fmt.Errorf("GitHub URL %q is not a repository, organization or enterprise URL", registrationURL)
+}
+
+// gitHubAppJWT signs the JWT a GitHub App authenticates with, using RS256 as GitHub requires
+func gitHubAppJWT(app *gitHubAppCredentials, now time.Time) (string, error) {
+	key, err := parseRSAPrivateKey(app.PrivateKey)
+	if err != nil {
+		return "", err
+	}
+
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-gitHubAppJWTClockSkew).Unix(),
+		"exp": now.Add(gitHubAppJWTLifetime).Unix(),
+		"iss": fmt.Sprint(app.AppID),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	encoding := base64.RawURLEncoding
+	signed := encoding.EncodeToString(header) + "." + encoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign GitHub App JWT: %w", err)
+	}
+	return signed + "." + encoding.EncodeToString(signature), nil
+}
+
+// parseRSAPrivateKey parses a PEM-encoded RSA private key in PKCS#1 form, as GitHub issues
+// them, or in PKCS#8 form
+func parseRSAPrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(bytes.TrimSpace(data))
+	if block == nil {
+		return nil, fmt.Errorf("GitHub App private key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GitHub App private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("GitHub App private key is not an RSA key")
+	}
+	return key, nil
+}
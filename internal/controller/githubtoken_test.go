@@ -0,0 +1,256 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hypervisorv1alpha1 "github.com/codihuston/hyperfleet-operator/api/v1alpha1"
+)
+
+// fakeGitHubAPI serves the token endpoints of the GitHub API, recording the bearer
+// credential of each request by path
+type fakeGitHubAPI struct {
+	credentials map[string]string
+	status      int
+}
+
+func (f *fakeGitHubAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	f.credentials[r.URL.Path] = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if f.status != 0 {
+		w.WriteHeader(f.status)
+		_, _ = w.Write([]byte(`{"message":"Bad credentials"}`))
+		return
+	}
+	token := "registration-token"
+	if strings.HasPrefix(r.URL.Path, "/api/v3/app/installations/") {
+		token = "installation-token"
+	}
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write([]byte(`{"token":"` + token + `","expires_at":"2030-01-02T03:04:05Z"}`))
+}
+
+func TestGitHubTokenProvider_RegistrationToken(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	privateKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "github", Namespace: "default"},
+		Data: map[string][]byte{
+			"pat":             []byte("ghp_example\n"),
+			"app-id":          []byte("12345"),
+			"installation-id": []byte("678"),
+			"private-key":     privateKey,
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+
+	pat := &hypervisorv1alpha1.SecretKeySelector{Name: "github", Key: "pat"}
+	app := &hypervisorv1alpha1.GitHubAppConfig{
+		AppID:          hypervisorv1alpha1.SecretKeySelector{Name: "github", Key: "app-id"},
+		InstallationID: hypervisorv1alpha1.SecretKeySelector{Name: "github", Key: "installation-id"},
+		PrivateKey:     hypervisorv1alpha1.SecretKeySelector{Name: "github", Key: "private-key"},
+	}
+
+	tests := []struct {
+		name        string
+		path        string
+		pat         *hypervisorv1alpha1.SecretKeySelector
+		app         *hypervisorv1alpha1.GitHubAppConfig
+		status      int
+		expectPath  string
+		expectError string
+	}{
+		{
+			name:       "repository runner with a PAT",
+			path:       "/test/repo",
+			pat:        pat,
+			expectPath: "/api/v3/repos/test/repo/actions/runners/registration-token",
+		},
+		{
+			name:       "organization runner with a PAT",
+			path:       "/test",
+			pat:        pat,
+			expectPath: "/api/v3/orgs/test/actions/runners/registration-token",
+		},
+		{
+			name:       "enterprise runner with a PAT",
+			path:       "/enterprises/test",
+			pat:        pat,
+			expectPath: "/api/v3/enterprises/test/actions/runners/registration-token",
+		},
+		{
+			name:       "GitHub App is preferred over a PAT",
+			path:       "/test/repo",
+			pat:        pat,
+			app:        app,
+			expectPath: "/api/v3/repos/test/repo/actions/runners/registration-token",
+		},
+		{
+			name:        "API error",
+			path:        "/test/repo",
+			pat:         pat,
+			status:      http.StatusUnauthorized,
+			expectError: "401 Unauthorized: Bad credentials",
+		},
+		{
+			name:        "no credentials",
+			path:        "/test/repo",
+			expectError: "neither App nor PAT credentials",
+		},
+		{
+			name:        "unscoped URL",
+			path:        "/test/repo/extra",
+			pat:         pat,
+			expectError: "is not a repository, organization or enterprise URL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeGitHubAPI{credentials: map[string]string{}, status: tt.status}
+			server := httptest.NewServer(api)
+			defer server.Close()
+
+			provider := NewGitHubTokenProvider(c)
+			github := &hypervisorv1alpha1.GitHubConfig{URL: server.URL + tt.path, PAT: tt.pat, App: tt.app}
+			token, err := provider.RegistrationToken(context.Background(), "default", github)
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if token.Token != "registration-token" {
+				t.Errorf("expected registration-token, got %q", token.Token)
+			}
+			if expected := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC); !token.ExpiresAt.Equal(expected) {
+				t.Errorf("expected expiry %v, got %v", expected, token.ExpiresAt)
+			}
+
+			credential, ok := api.credentials[tt.expectPath]
+			if !ok {
+				t.Fatalf("expected a request to %s, got %v", tt.expectPath, api.credentials)
+			}
+			if tt.app == nil {
+				if credential != "ghp_example" {
+					t.Errorf("expected the PAT as credential, got %q", credential)
+				}
+				return
+			}
+			if credential != "installation-token" {
+				t.Errorf("expected the installation token as credential, got %q", credential)
+			}
+			jwt, ok := api.credentials["/api/v3/app/installations/678/access_tokens"]
+			if !ok {
+				t.Fatalf("expected an installation token request, got %v", api.credentials)
+			}
+			verifyGitHubAppJWT(t, jwt, &key.PublicKey, "12345")
+		})
+	}
+}
+
+// verifyGitHubAppJWT checks a JWT is signed by the App's key and issued by the App
+func verifyGitHubAppJWT(t *testing.T, jwt string, key *rsa.PublicKey, appID string) {
+	t.Helper()
+
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		t.Fatalf("expected a JWT with 3 parts, got %q", jwt)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		t.Fatalf("invalid JWT signature encoding: %v", err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		t.Fatalf("JWT signature does not verify: %v", err)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		t.Fatalf("invalid JWT payload encoding: %v", err)
+	}
+	var claims struct {
+		IssuedAt  int64  `json:"iat"`
+		ExpiresAt int64  `json:"exp"`
+		Issuer    string `json:"iss"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		t.Fatalf("invalid JWT payload: %v", err)
+	}
+	if claims.Issuer != appID {
+		t.Errorf("expected issuer %q, got %q", appID, claims.Issuer)
+	}
+	if lifetime := time.Duration(claims.ExpiresAt-claims.IssuedAt) * time.Second; lifetime > 10*time.Minute {
+		t.Errorf("JWT lifetime %v exceeds GitHub's 10 minute limit", lifetime)
+	}
+}
+
+func TestGitHubAPIBaseURL(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{url: "https://github.com/test/repo", expected: "https://api.github.com"},
+		{url: "https://GitHub.com/test", expected: "https://api.github.com"},
+		{url: "https://ghes.example.com/test/repo", expected: "https://ghes.example.com/api/v3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			got, err := gitHubAPIBaseURL(tt.url)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
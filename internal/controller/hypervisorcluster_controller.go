@@ -20,13 +20,13 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"strings"
 	"time"
 
-	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -36,16 +36,23 @@ import (
 )
 
 const (
-	// RequeueInterval defines how often to requeue reconciliation for periodic connection checks
-	RequeueInterval = 5 * time.Minute
 	// DefaultTimeout defines the default timeout for hypervisor client operations
 	DefaultTimeout = 300 // 5 minutes in seconds
+	// DefaultConnectTimeout bounds establishing a hypervisor API connection when the cluster does not set one
+	DefaultConnectTimeout = 10 // seconds
+	// DefaultRequestTimeout bounds each hypervisor API request when the cluster does not set one
+	DefaultRequestTimeout = 60 // seconds
 	// DefaultInsecureSkipVerify defines the default TLS verification behavior
 	// Set to false by default for security - users must explicitly configure insecure connections
 	DefaultInsecureSkipVerify = false
 
 	// ConditionReady represents the ready condition type
 	ConditionReady = "Ready"
+
+	// ReasonUnsupportedProvider marks a cluster whose provider has no client, which retrying does not fix
+	ReasonUnsupportedProvider = "UnsupportedProvider"
+	// ReasonUnsupportedVersion marks a cluster whose hypervisor is older than the minimum supported version
+	ReasonUnsupportedVersion = "UnsupportedVersion"
 )
 
 // HypervisorClusterReconciler reconciles a HypervisorCluster object
@@ -53,6 +60,17 @@ type HypervisorClusterReconciler struct {
 	client.Client
 	Scheme        *runtime.Scheme
 	ClientFactory provider.ClientFactory
+
+	// RequeueIntervals controls how often the connection is re-checked after it succeeds or fails
+	RequeueIntervals RequeueIntervals
+
+	// StatusUpdateRetries bounds how often a conflicting status update is re-fetched and retried;
+	// zero uses DefaultStatusUpdateRetries
+	StatusUpdateRetries int
+
+	// MinimumVersion is the oldest hypervisor version the cluster may run; an older one is not
+	// Ready and skips feature-dependent validation. Nil accepts any version.
+	MinimumVersion *provider.Version
 }
 
 // +kubebuilder:rbac:groups=hypervisor.hyperfleet.io,resources=hypervisorclusters,verbs=get;list;watch;create;update;patch;delete
@@ -88,8 +106,26 @@ func (r *HypervisorClusterReconciler) Reconcile(ctx context.Context, req ctrl.Re
 		return ctrl.Result{}, err
 	}
 
-	// Requeue after defined interval to periodically check connection
-	return ctrl.Result{RequeueAfter: RequeueInterval}, nil
+	// Requeue to periodically check the cluster, sooner while it is not Ready but backing off
+	// the longer that persists. Ready covers every required check, so a cluster that connects
+	// but has no online node is retried as a failure. An unsupported provider only changes with
+	// the spec, which is reconciled at once, so it is not retried any sooner than a healthy cluster.
+	if connectionResult.UnsupportedProvider {
+		return ctrl.Result{RequeueAfter: r.RequeueIntervals.AfterUnrecoverable()}, nil
+	}
+	if meta.IsStatusConditionFalse(hypervisorCluster.Status.Conditions, ConditionReady) {
+		return ctrl.Result{RequeueAfter: r.RequeueIntervals.Backoff(notReadyFor(&hypervisorCluster, time.Now()))}, nil
+	}
+	return ctrl.Result{RequeueAfter: r.RequeueIntervals.After(true)}, nil
+}
+
+// notReadyFor returns how long the cluster's Ready condition has been false, zero when it is not false
+func notReadyFor(cluster *hypervisorv1alpha1.HypervisorCluster, now time.Time) time.Duration {
+	ready := meta.FindStatusCondition(cluster.Status.Conditions, ConditionReady)
+	if ready == nil || ready.Status != metav1.ConditionFalse {
+		return 0
+	}
+	return max(now.Sub(ready.LastTransitionTime.Time), 0)
 }
 
 // testConnection tests the connection to the hypervisor using the provider adapter
@@ -97,38 +133,21 @@ func (r *HypervisorClusterReconciler) testConnection(ctx context.Context, cluste
 	logger := log.FromContext(ctx)
 
 	result := &ConnectionResult{
-		Success:  false,
-		Message:  "",
-		TestedAt: metav1.Now(),
+		Success:        false,
+		Message:        "",
+		TestedAt:       metav1.Now(),
+		MinimumVersion: r.MinimumVersion,
 	}
 
 	// Load credentials from secrets
-	auth, err := r.loadCredentials(ctx, cluster)
+	auth, err := loadCredentials(ctx, r.Client, cluster)
 	if err != nil {
 		result.Message = fmt.Sprintf("Credential loading failed: %v", err)
 		logger.Error(err, "Credential loading failed")
 		return result
 	}
 
-	// Create client configuration with secure TLS defaults
-	// #nosec G402 -- User-configurable TLS with secure defaults (defaults to false)
-	tlsConfig := &tls.Config{
-		InsecureSkipVerify: DefaultInsecureSkipVerify, // Secure by default
-	}
-
-	// Apply user-specified TLS configuration if provided
-	if cluster.Spec.TLS != nil {
-		tlsConfig.InsecureSkipVerify = cluster.Spec.TLS.InsecureSkipVerify
-
-		// TODO: Implement CA certificate loading from cluster.Spec.TLS.CACertificate
-		// This will be added in a future iteration to support custom CA certificates
-	}
-
-	clientConfig := &provider.ClientConfig{
-		Endpoint:  cluster.Spec.Endpoint,
-		TLSConfig: tlsConfig,
-		Timeout:   DefaultTimeout,
-	}
+	clientConfig := buildClientConfig(cluster)
 
 	// Create hypervisor client using the factory
 	if r.ClientFactory == nil {
@@ -138,6 +157,7 @@ func (r *HypervisorClusterReconciler) testConnection(ctx context.Context, cluste
 	hypervisorClient, err := r.ClientFactory.CreateClient(cluster.Spec.Provider, clientConfig, auth)
 	if err != nil {
 		result.Message = fmt.Sprintf("Failed to create hypervisor client: %v", err)
+		result.UnsupportedProvider = provider.IsUnsupportedProvider(err)
 		logger.Error(err, "Failed to create hypervisor client", "provider", cluster.Spec.Provider)
 		return result
 	}
@@ -163,110 +183,188 @@ func (r *HypervisorClusterReconciler) testConnection(ctx context.Context, cluste
 		"version", connInfo.Version,
 		"endpoint", cluster.Spec.Endpoint)
 
-	return result
-}
-
-// loadCredentials loads authentication credentials from Kubernetes secrets
-func (r *HypervisorClusterReconciler) loadCredentials(ctx context.Context, cluster *hypervisorv1alpha1.HypervisorCluster) (*provider.AuthConfig, error) {
-	creds := cluster.Spec.Credentials
-
-	// Check token-based authentication (preferred)
-	if creds.TokenID != nil && creds.TokenSecret != nil {
-		tokenID, err := r.getSecretValue(ctx, cluster.Namespace, creds.TokenID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get tokenId: %w", err)
-		}
-
-		tokenSecret, err := r.getSecretValue(ctx, cluster.Namespace, creds.TokenSecret)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get tokenSecret: %w", err)
-		}
-
-		return &provider.AuthConfig{
-			Type:        "token",
-			TokenID:     tokenID,
-			TokenSecret: tokenSecret,
-		}, nil
+	result.Version = connInfo.Version
+	if below, _, err := compareMinimumVersion(result); err != nil {
+		logger.Error(err, "Hypervisor version not checked against the minimum", "version", connInfo.Version)
+	} else if below {
+		result.UnsupportedVersion = true
+		logger.Info("Hypervisor version is below the minimum supported version, skipping feature-dependent validation",
+			"version", connInfo.Version, "minimum", r.MinimumVersion.String())
 	}
 
-	// Check username/password authentication
-	if creds.Username != nil && creds.Password != nil {
-		username, err := r.getSecretValue(ctx, cluster.Namespace, creds.Username)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get username: %w", err)
+	// Node availability is its own health check, so a failed listing never fails the connection test
+	nodes, err := hypervisorClient.ListNodes(ctx)
+	if err != nil {
+		result.NodesMessage = err.Error()
+		logger.Error(err, "Hypervisor node listing failed", "endpoint", cluster.Spec.Endpoint)
+	} else {
+		result.Nodes = nodes
+		usable := listedNodes(cluster.Spec.Nodes, nodes)
+		result.NodeVersions, result.NodeVersionFailures = readNodeVersions(ctx, hypervisorClient, usable)
+		// Storage and network validation rely on the API of supported versions
+		if !result.UnsupportedVersion {
+			result.StorageFailures = validateNodeStorage(ctx, hypervisorClient, usable, cluster.Spec.DefaultStorage)
+			result.NetworkFailures = validateNodeNetwork(ctx, hypervisorClient, usable, cluster.Spec.DefaultNetwork)
 		}
+	}
 
-		password, err := r.getSecretValue(ctx, cluster.Namespace, creds.Password)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get password: %w", err)
-		}
+	// Clock skew is informational, so a failed check never fails the connection test
+	before := time.Now()
+	serverTime, err := hypervisorClient.ServerTime(ctx)
+	if err != nil {
+		result.ClockSkewMessage = err.Error()
+		logger.Error(err, "Hypervisor time check failed", "endpoint", cluster.Spec.Endpoint)
+	} else {
+		skew := measureClockSkew(before, serverTime, time.Now())
+		result.ClockSkew = &skew
+	}
 
-		return &provider.AuthConfig{
-			Type:     "password",
-			Username: username,
-			Password: password,
-		}, nil
+	// The subscription is informational, so a failed check never fails the connection test
+	subscription, err := hypervisorClient.SubscriptionStatus(ctx)
+	if err != nil {
+		result.SubscriptionMessage = err.Error()
+		logger.Error(err, "Hypervisor subscription check failed", "endpoint", cluster.Spec.Endpoint)
+		return result
 	}
+	result.Subscription = subscription
 
-	return nil, fmt.Errorf("no valid credential configuration found")
+	return result
 }
 
-// getSecretValue retrieves a value from a Kubernetes secret
-func (r *HypervisorClusterReconciler) getSecretValue(ctx context.Context, namespace string, selector *corev1.SecretKeySelector) (string, error) {
-	secret := &corev1.Secret{}
-	secretName := types.NamespacedName{
-		Name:      selector.Name,
-		Namespace: namespace,
+// buildClientConfig builds the hypervisor client configuration for a cluster.
+// TLS settings are only built for https endpoints; plaintext endpoints get a nil TLS config.
+func buildClientConfig(cluster *hypervisorv1alpha1.HypervisorCluster) *provider.ClientConfig {
+	clientConfig := &provider.ClientConfig{
+		Endpoint:       cluster.Spec.Endpoint,
+		Timeout:        DefaultTimeout,
+		ConnectTimeout: timeoutSeconds(cluster.Spec.ConnectionTimeout, DefaultConnectTimeout),
+		RequestTimeout: timeoutSeconds(cluster.Spec.RequestTimeout, DefaultRequestTimeout),
 	}
 
-	if err := r.Get(ctx, secretName, secret); err != nil {
-		return "", fmt.Errorf("failed to get secret %s: %w", secretName, err)
+	if provider.IsPlaintextEndpoint(cluster.Spec.Endpoint) {
+		return clientConfig
 	}
 
-	value, exists := secret.Data[selector.Key]
-	if !exists {
-		return "", fmt.Errorf("key %s not found in secret %s", selector.Key, secretName)
+	// Create client configuration with secure TLS defaults
+	// #nosec G402 -- User-configurable TLS with secure defaults (defaults to false)
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: DefaultInsecureSkipVerify, // Secure by default
 	}
 
-	return string(value), nil
+	// Apply user-specified TLS configuration if provided
+	if cluster.Spec.TLS != nil {
+		tlsConfig.InsecureSkipVerify = cluster.Spec.TLS.InsecureSkipVerify
+
+		// TODO: Implement CA certificate loading from cluster.Spec.TLS.CACertificate
+		// This will be added in a future iteration to support custom CA certificates
+	}
+
+	clientConfig.TLSConfig = tlsConfig
+	return clientConfig
+}
+
+// timeoutSeconds converts a cluster timeout to whole seconds, rounding up so short
+// timeouts are not disabled, and falls back to the default when unset
+func timeoutSeconds(timeout *metav1.Duration, defaultSeconds int) int {
+	if timeout == nil || timeout.Duration <= 0 {
+		return defaultSeconds
+	}
+	return int((timeout.Duration + time.Second - 1) / time.Second)
 }
 
 // updateStatus updates the HypervisorCluster status based on connection test results
 func (r *HypervisorClusterReconciler) updateStatus(ctx context.Context, cluster *hypervisorv1alpha1.HypervisorCluster, result *ConnectionResult) error {
+	applyConnectionResult(cluster, result)
+	return updateStatusWithRetry(ctx, r.Client, cluster, r.StatusUpdateRetries, func() {
+		applyConnectionResult(cluster, result)
+	})
+}
+
+// applyConnectionResult sets the HypervisorCluster status from connection test results
+func applyConnectionResult(cluster *hypervisorv1alpha1.HypervisorCluster, result *ConnectionResult) {
 	// Update last sync time
 	cluster.Status.LastSyncTime = &result.TestedAt
 
-	// Prepare condition
-	condition := metav1.Condition{
+	// Only the nodes listed in the spec host VMs, so unlisted nodes do not count as connected
+	nodes := listedNodes(cluster.Spec.Nodes, result.Nodes)
+	checks := []healthCheck{connectionCheck(result)}
+	if result.Success && result.MinimumVersion != nil {
+		checks = append(checks, versionCheck(result))
+	}
+	if result.Nodes != nil || result.NodesMessage != "" {
+		checks = append(checks, nodesCheck(result, nodes))
+		// Below the minimum version the default storage and network were not validated
+		if !result.UnsupportedVersion {
+			checks = append(checks,
+				storageCheck(result, nodes, cluster.Spec.DefaultStorage),
+				networkCheck(result, nodes, cluster.Spec.DefaultNetwork))
+		}
+	}
+	switch {
+	case nodes != nil:
+		// #nosec G115 - node counts are far below the int32 range
+		cluster.Status.ConnectedNodes = int32(onlineNodes(nodes))
+	case !result.Success:
+		cluster.Status.ConnectedNodes = 0
+	}
+
+	health := aggregateHealth(checks)
+	cluster.Status.Phase = health.Phase
+	cluster.Status.Checks = health.Checks
+	if result.NodeVersions != nil {
+		cluster.Status.NodeVersions = nodeVersionStatus(result.NodeVersions)
+	}
+	cluster.Status.EffectiveTags = clusterVMTags(cluster)
+
+	// Ready needs every required check to pass: the connection, an online node and the default
+	// storage and network; it stays true while the cluster is only degraded
+	readyCondition := metav1.Condition{
 		Type:               ConditionReady,
+		Status:             metav1.ConditionTrue,
+		Reason:             "ConnectionSuccessful",
 		LastTransitionTime: result.TestedAt,
 		ObservedGeneration: cluster.Generation,
 		Message:            result.Message,
 	}
-
-	if result.Success {
-		condition.Status = metav1.ConditionTrue
-		condition.Reason = "ConnectionSuccessful"
-	} else {
-		condition.Status = metav1.ConditionFalse
-		condition.Reason = "ConnectionFailed"
+	if health.failedRequired != nil {
+		readyCondition.Status = metav1.ConditionFalse
+		readyCondition.Reason = health.failedRequired.Name + "Failed"
+		readyCondition.Message = strings.Join(health.requiredFailures, "; ")
+		switch {
+		case result.UnsupportedProvider:
+			readyCondition.Reason = ReasonUnsupportedProvider
+		case result.UnsupportedVersion:
+			readyCondition.Reason = ReasonUnsupportedVersion
+		}
 	}
 
-	// Update or add the condition
-	updated := false
-	for i, existingCondition := range cluster.Status.Conditions {
-		if existingCondition.Type == ConditionReady {
-			cluster.Status.Conditions[i] = condition
-			updated = true
-			break
-		}
+	degradedCondition := metav1.Condition{
+		Type:               ConditionDegraded,
+		Status:             metav1.ConditionFalse,
+		Reason:             "HealthChecksPassed",
+		LastTransitionTime: result.TestedAt,
+		ObservedGeneration: cluster.Generation,
+		Message:            health.Message,
 	}
-	if !updated {
-		cluster.Status.Conditions = append(cluster.Status.Conditions, condition)
+	switch health.Phase {
+	case hypervisorv1alpha1.ClusterPhaseDegraded:
+		degradedCondition.Status = metav1.ConditionTrue
+		degradedCondition.Reason = "HealthChecksFailed"
+	case hypervisorv1alpha1.ClusterPhaseNotReady:
+		degradedCondition.Reason = "ClusterNotReady"
 	}
 
-	// Update the status
-	return r.Status().Update(ctx, cluster)
+	meta.SetStatusCondition(&cluster.Status.Conditions, readyCondition)
+	meta.SetStatusCondition(&cluster.Status.Conditions, degradedCondition)
+	meta.SetStatusCondition(&cluster.Status.Conditions, subscriptionCondition(result, cluster.Generation))
+	meta.SetStatusCondition(&cluster.Status.Conditions, clockSkewCondition(result, cluster.Generation))
+	meta.SetStatusCondition(&cluster.Status.Conditions, nodesInSyncCondition(cluster.Spec.Nodes, result, cluster.Generation))
+	meta.SetStatusCondition(&cluster.Status.Conditions, nodeVersionSkewCondition(result, cluster.Generation))
+	if result.MinimumVersion != nil {
+		meta.SetStatusCondition(&cluster.Status.Conditions, unsupportedVersionCondition(result, cluster.Generation))
+	} else {
+		meta.RemoveStatusCondition(&cluster.Status.Conditions, ConditionUnsupportedVersion)
+	}
 }
 
 // ConnectionResult holds the result of a connection test
@@ -274,6 +372,40 @@ type ConnectionResult struct {
 	Success  bool
 	Message  string
 	TestedAt metav1.Time
+
+	// UnsupportedProvider reports that no client exists for the cluster's provider
+	UnsupportedProvider bool
+
+	// Version is the hypervisor version reported by the connection test, checked against
+	// MinimumVersion when set; UnsupportedVersion reports it is older
+	Version            string
+	MinimumVersion     *provider.Version
+	UnsupportedVersion bool
+
+	// Subscription is the hypervisor's support subscription, nil when it could not be read
+	Subscription *provider.SubscriptionInfo
+	// SubscriptionMessage explains why the subscription could not be read
+	SubscriptionMessage string
+
+	// ClockSkew is how far the hypervisor's clock is ahead of the controller's, nil when it could not be read
+	ClockSkew *time.Duration
+	// ClockSkewMessage explains why the hypervisor's clock could not be read
+	ClockSkewMessage string
+
+	// Nodes are the hypervisor's nodes, nil when they could not be listed
+	Nodes []provider.NodeInfo
+	// NodesMessage explains why the nodes could not be listed
+	NodesMessage string
+
+	// StorageFailures and NetworkFailures explain, per online node, why the cluster's default
+	// storage or network bridge is unusable there
+	StorageFailures []string
+	NetworkFailures []string
+
+	// NodeVersions maps each online node to its hypervisor version, nil when nodes could not be listed
+	NodeVersions map[string]string
+	// NodeVersionFailures explain, per node, why a version could not be read
+	NodeVersionFailures []string
 }
 
 // SetupWithManager sets up the controller with the Manager.
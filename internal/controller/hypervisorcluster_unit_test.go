@@ -0,0 +1,1065 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hypervisorv1alpha1 "github.com/codihuston/hyperfleet-operator/api/v1alpha1"
+	"github.com/codihuston/hyperfleet-operator/internal/provider"
+)
+
+func TestBuildClientConfig(t *testing.T) {
+	tests := []struct {
+		name               string
+		endpoint           string
+		tls                *hypervisorv1alpha1.TLSConfig
+		expectTLS          bool
+		insecureSkipVerify bool
+	}{
+		{
+			name:      "http endpoint builds no TLS config",
+			endpoint:  "http://pve.example.com:8006/api2/json",
+			expectTLS: false,
+		},
+		{
+			name:      "http endpoint ignores TLS settings",
+			endpoint:  "http://pve.example.com:8006/api2/json",
+			tls:       &hypervisorv1alpha1.TLSConfig{InsecureSkipVerify: true},
+			expectTLS: false,
+		},
+		{
+			name:               "https endpoint uses secure defaults",
+			endpoint:           "https://pve.example.com:8006/api2/json",
+			expectTLS:          true,
+			insecureSkipVerify: false,
+		},
+		{
+			name:               "https endpoint applies TLS settings",
+			endpoint:           "https://pve.example.com:8006/api2/json",
+			tls:                &hypervisorv1alpha1.TLSConfig{InsecureSkipVerify: true},
+			expectTLS:          true,
+			insecureSkipVerify: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := &hypervisorv1alpha1.HypervisorCluster{
+				Spec: hypervisorv1alpha1.HypervisorClusterSpec{
+					Endpoint: tt.endpoint,
+					TLS:      tt.tls,
+				},
+			}
+
+			config := buildClientConfig(cluster)
+
+			if config.Endpoint != tt.endpoint {
+				t.Errorf("Expected endpoint %s, got %s", tt.endpoint, config.Endpoint)
+			}
+			if config.Timeout != DefaultTimeout {
+				t.Errorf("Expected timeout %d, got %d", DefaultTimeout, config.Timeout)
+			}
+			if !tt.expectTLS {
+				if config.TLSConfig != nil {
+					t.Errorf("Expected no TLS config, got %v", config.TLSConfig)
+				}
+				return
+			}
+			if config.TLSConfig == nil {
+				t.Fatalf("Expected TLS config but got nil")
+			}
+			if config.TLSConfig.InsecureSkipVerify != tt.insecureSkipVerify {
+				t.Errorf("Expected InsecureSkipVerify %v, got %v", tt.insecureSkipVerify, config.TLSConfig.InsecureSkipVerify)
+			}
+		})
+	}
+}
+
+func TestBuildClientConfigTimeouts(t *testing.T) {
+	tests := []struct {
+		name              string
+		connectionTimeout *metav1.Duration
+		requestTimeout    *metav1.Duration
+		expectConnect     int
+		expectRequest     int
+	}{
+		{
+			name:          "defaults when unset",
+			expectConnect: DefaultConnectTimeout,
+			expectRequest: DefaultRequestTimeout,
+		},
+		{
+			name:              "cluster overrides",
+			connectionTimeout: &metav1.Duration{Duration: 5 * time.Second},
+			requestTimeout:    &metav1.Duration{Duration: 2 * time.Minute},
+			expectConnect:     5,
+			expectRequest:     120,
+		},
+		{
+			name:              "sub-second timeouts round up",
+			connectionTimeout: &metav1.Duration{Duration: 500 * time.Millisecond},
+			requestTimeout:    &metav1.Duration{Duration: 1500 * time.Millisecond},
+			expectConnect:     1,
+			expectRequest:     2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := &hypervisorv1alpha1.HypervisorCluster{
+				Spec: hypervisorv1alpha1.HypervisorClusterSpec{
+					Endpoint:          "https://pve.example.com:8006/api2/json",
+					ConnectionTimeout: tt.connectionTimeout,
+					RequestTimeout:    tt.requestTimeout,
+				},
+			}
+
+			config := buildClientConfig(cluster)
+
+			if config.ConnectTimeout != tt.expectConnect {
+				t.Errorf("Expected connect timeout %d, got %d", tt.expectConnect, config.ConnectTimeout)
+			}
+			if config.RequestTimeout != tt.expectRequest {
+				t.Errorf("Expected request timeout %d, got %d", tt.expectRequest, config.RequestTimeout)
+			}
+			// Task waits keep their own, longer timeout
+			if config.Timeout != DefaultTimeout {
+				t.Errorf("Expected task timeout %d, got %d", DefaultTimeout, config.Timeout)
+			}
+		})
+	}
+}
+
+func TestHypervisorClusterReconciler_updateStatus(t *testing.T) {
+	tests := []struct {
+		name           string
+		result         *ConnectionResult
+		expectedPhase  hypervisorv1alpha1.ClusterPhase
+		expectedReady  metav1.ConditionStatus
+		expectedReason string
+		expectedMsg    string
+	}{
+		{
+			name:           "connection succeeds",
+			result:         &ConnectionResult{Success: true, Message: "Successfully connected to proxmox cluster", TestedAt: metav1.Now()},
+			expectedPhase:  hypervisorv1alpha1.ClusterPhaseReady,
+			expectedReady:  metav1.ConditionTrue,
+			expectedReason: "ConnectionSuccessful",
+			expectedMsg:    "Successfully connected to proxmox cluster",
+		},
+		{
+			name:           "connection fails",
+			result:         &ConnectionResult{Success: false, Message: "Hypervisor connection failed: timeout", TestedAt: metav1.Now()},
+			expectedPhase:  hypervisorv1alpha1.ClusterPhaseNotReady,
+			expectedReady:  metav1.ConditionFalse,
+			expectedReason: "ConnectionFailed",
+			expectedMsg:    "Connection: Hypervisor connection failed: timeout",
+		},
+		{
+			name: "unsupported provider",
+			result: &ConnectionResult{
+				Message:             "Failed to create hypervisor client: unsupported hypervisor provider: vsphere",
+				TestedAt:            metav1.Now(),
+				UnsupportedProvider: true,
+			},
+			expectedPhase:  hypervisorv1alpha1.ClusterPhaseNotReady,
+			expectedReady:  metav1.ConditionFalse,
+			expectedReason: ReasonUnsupportedProvider,
+			expectedMsg:    "Connection: Failed to create hypervisor client: unsupported hypervisor provider: vsphere",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			_ = hypervisorv1alpha1.AddToScheme(scheme)
+
+			cluster := &hypervisorv1alpha1.HypervisorCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-cluster",
+					Namespace: "default",
+				},
+			}
+
+			client := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(cluster).WithObjects(cluster).Build()
+			r := &HypervisorClusterReconciler{
+				Client: client,
+				Scheme: scheme,
+			}
+
+			if err := r.updateStatus(context.Background(), cluster, tt.result); err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+
+			if cluster.Status.Phase != tt.expectedPhase {
+				t.Errorf("Expected phase %s, got %s", tt.expectedPhase, cluster.Status.Phase)
+			}
+			if len(cluster.Status.Checks) != 1 || cluster.Status.Checks[0].Name != HealthCheckConnection {
+				t.Errorf("Expected a single Connection check, got %v", cluster.Status.Checks)
+			}
+
+			ready := meta.FindStatusCondition(cluster.Status.Conditions, ConditionReady)
+			if ready == nil {
+				t.Fatalf("Expected Ready condition to be set")
+			}
+			if ready.Status != tt.expectedReady || ready.Reason != tt.expectedReason {
+				t.Errorf("Expected Ready %s/%s, got %s/%s", tt.expectedReady, tt.expectedReason, ready.Status, ready.Reason)
+			}
+			if ready.Message != tt.expectedMsg {
+				t.Errorf("Expected Ready message %q, got %q", tt.expectedMsg, ready.Message)
+			}
+
+			if !meta.IsStatusConditionFalse(cluster.Status.Conditions, ConditionDegraded) {
+				t.Errorf("Expected Degraded condition to be false")
+			}
+		})
+	}
+}
+
+func TestHypervisorClusterReconciler_updateStatusSubscription(t *testing.T) {
+	tests := []struct {
+		name           string
+		result         *ConnectionResult
+		expectedStatus metav1.ConditionStatus
+		expectedReason string
+	}{
+		{
+			name: "active subscription",
+			result: &ConnectionResult{Success: true, TestedAt: metav1.Now(),
+				Subscription: &provider.SubscriptionInfo{State: provider.SubscriptionActive, Message: "node pve1: subscription active"}},
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: "SubscriptionActive",
+		},
+		{
+			name: "expired subscription",
+			result: &ConnectionResult{Success: true, TestedAt: metav1.Now(),
+				Subscription: &provider.SubscriptionInfo{State: provider.SubscriptionExpired, Message: "node pve1: subscription expired"}},
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "SubscriptionExpired",
+		},
+		{
+			name: "no subscription",
+			result: &ConnectionResult{Success: true, TestedAt: metav1.Now(),
+				Subscription: &provider.SubscriptionInfo{State: provider.SubscriptionNone, Message: "node pve1: no subscription"}},
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "NoSubscription",
+		},
+		{
+			name:           "subscription check fails",
+			result:         &ConnectionResult{Success: true, TestedAt: metav1.Now(), SubscriptionMessage: "permission denied"},
+			expectedStatus: metav1.ConditionUnknown,
+			expectedReason: "SubscriptionUnknown",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			_ = hypervisorv1alpha1.AddToScheme(scheme)
+
+			cluster := &hypervisorv1alpha1.HypervisorCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-cluster",
+					Namespace: "default",
+				},
+			}
+
+			client := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(cluster).WithObjects(cluster).Build()
+			r := &HypervisorClusterReconciler{
+				Client: client,
+				Scheme: scheme,
+			}
+
+			if err := r.updateStatus(context.Background(), cluster, tt.result); err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+
+			condition := meta.FindStatusCondition(cluster.Status.Conditions, ConditionSubscriptionActive)
+			if condition == nil {
+				t.Fatalf("Expected SubscriptionActive condition to be set")
+			}
+			if condition.Status != tt.expectedStatus || condition.Reason != tt.expectedReason {
+				t.Errorf("Expected SubscriptionActive %s/%s, got %s/%s", tt.expectedStatus, tt.expectedReason, condition.Status, condition.Reason)
+			}
+
+			// The subscription is informational and never affects readiness
+			if cluster.Status.Phase != hypervisorv1alpha1.ClusterPhaseReady {
+				t.Errorf("Expected phase %s, got %s", hypervisorv1alpha1.ClusterPhaseReady, cluster.Status.Phase)
+			}
+			if !meta.IsStatusConditionTrue(cluster.Status.Conditions, ConditionReady) {
+				t.Errorf("Expected Ready condition to be true")
+			}
+		})
+	}
+}
+
+func TestHypervisorClusterReconciler_ReconcileRequeueInterval(t *testing.T) {
+	intervals := RequeueIntervals{Success: 15 * time.Minute, Failure: time.Minute, MaxBackoff: 10 * time.Minute}
+
+	tests := []struct {
+		name          string
+		connectionErr error
+		factoryErr    error
+		nodes         []provider.NodeInfo // nodes reported by the hypervisor, nil for the mock's default
+		notReadyFor   time.Duration       // how long the Ready condition has already been false
+		expected      time.Duration
+	}{
+		{name: "healthy cluster", expected: intervals.Success},
+		{name: "failing cluster", connectionErr: fmt.Errorf("connection refused"), expected: intervals.Failure},
+		{
+			name:     "connected cluster without online nodes",
+			nodes:    []provider.NodeInfo{{Name: "pve1", Online: false}},
+			expected: intervals.Failure,
+		},
+		{
+			name:        "persistently offline nodes",
+			nodes:       []provider.NodeInfo{{Name: "pve1", Online: false}},
+			notReadyFor: 4 * time.Hour,
+			expected:    intervals.MaxBackoff,
+		},
+		{
+			name:          "persistently failing cluster",
+			connectionErr: fmt.Errorf("connection refused"),
+			notReadyFor:   4 * time.Hour,
+			expected:      intervals.MaxBackoff,
+		},
+		{
+			name:       "unsupported provider",
+			factoryErr: fmt.Errorf("%w: vsphere", provider.ErrUnsupportedProvider),
+			expected:   intervals.Success,
+		},
+		{
+			name:       "client creation fails otherwise",
+			factoryErr: fmt.Errorf("endpoint is required"),
+			expected:   intervals.Failure,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			_ = hypervisorv1alpha1.AddToScheme(scheme)
+			_ = corev1.AddToScheme(scheme)
+
+			cluster := newTestCluster()
+			if tt.notReadyFor > 0 {
+				cluster.Status.Conditions = []metav1.Condition{{
+					Type:               ConditionReady,
+					Status:             metav1.ConditionFalse,
+					Reason:             "ConnectionFailed",
+					LastTransitionTime: metav1.NewTime(time.Now().Add(-tt.notReadyFor)),
+				}}
+			}
+			mockClient := &provider.MockHypervisorClient{
+				TestConnectionFunc: func(ctx context.Context) (*provider.ConnectionInfo, error) {
+					if tt.connectionErr != nil {
+						return nil, tt.connectionErr
+					}
+					return &provider.ConnectionInfo{Version: "8.1.4"}, nil
+				},
+			}
+			if tt.nodes != nil {
+				mockClient.ListNodesFunc = func(ctx context.Context) ([]provider.NodeInfo, error) {
+					return tt.nodes, nil
+				}
+			}
+
+			client := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(cluster).
+				WithObjects(cluster, newTestCredentialsSecret()).Build()
+			factory := provider.NewMockClientFactoryWithClient(mockClient)
+			if tt.factoryErr != nil {
+				factory = provider.NewFailingMockClientFactory(tt.factoryErr)
+			}
+			r := &HypervisorClusterReconciler{
+				Client:           client,
+				Scheme:           scheme,
+				ClientFactory:    factory,
+				RequeueIntervals: intervals,
+			}
+
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: cluster.Name, Namespace: cluster.Namespace}}
+			result, err := r.Reconcile(context.Background(), req)
+			if err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+			if result.RequeueAfter != tt.expected {
+				t.Errorf("Expected requeue after %v, got %v", tt.expected, result.RequeueAfter)
+			}
+
+			if tt.factoryErr != nil {
+				expectedReason := "ConnectionFailed"
+				if provider.IsUnsupportedProvider(tt.factoryErr) {
+					expectedReason = ReasonUnsupportedProvider
+				}
+				updated := &hypervisorv1alpha1.HypervisorCluster{}
+				if err := client.Get(context.Background(), req.NamespacedName, updated); err != nil {
+					t.Fatalf("Failed to get cluster: %v", err)
+				}
+				if ready := meta.FindStatusCondition(updated.Status.Conditions, ConditionReady); ready == nil || ready.Reason != expectedReason {
+					t.Errorf("Expected Ready reason %s, got %v", expectedReason, ready)
+				}
+			}
+		})
+	}
+}
+
+func TestHypervisorClusterReconciler_ReconcileNodes(t *testing.T) {
+	tests := []struct {
+		name              string
+		nodes             []provider.NodeInfo
+		listErr           error
+		expectedPhase     hypervisorv1alpha1.ClusterPhase
+		expectedConnected int32
+	}{
+		{
+			name:              "all nodes offline",
+			nodes:             []provider.NodeInfo{{Name: "pve1"}, {Name: "pve2"}},
+			expectedPhase:     hypervisorv1alpha1.ClusterPhaseNotReady,
+			expectedConnected: 0,
+		},
+		{
+			name:              "some nodes online",
+			nodes:             []provider.NodeInfo{{Name: "pve1", Online: true}, {Name: "pve2"}, {Name: "pve3", Online: true}},
+			expectedPhase:     hypervisorv1alpha1.ClusterPhaseReady,
+			expectedConnected: 2,
+		},
+		{
+			name:              "node listing fails",
+			listErr:           fmt.Errorf("permission denied"),
+			expectedPhase:     hypervisorv1alpha1.ClusterPhaseNotReady,
+			expectedConnected: 3, // the last known count is kept
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			_ = hypervisorv1alpha1.AddToScheme(scheme)
+			_ = corev1.AddToScheme(scheme)
+
+			cluster := newTestCluster()
+			cluster.Status.ConnectedNodes = 3
+			mockClient := &provider.MockHypervisorClient{
+				ListNodesFunc: func(ctx context.Context) ([]provider.NodeInfo, error) {
+					return tt.nodes, tt.listErr
+				},
+			}
+
+			client := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(cluster).
+				WithObjects(cluster, newTestCredentialsSecret()).Build()
+			r := &HypervisorClusterReconciler{
+				Client:        client,
+				Scheme:        scheme,
+				ClientFactory: provider.NewMockClientFactoryWithClient(mockClient),
+			}
+
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: cluster.Name, Namespace: cluster.Namespace}}
+			if _, err := r.Reconcile(context.Background(), req); err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+
+			updated := &hypervisorv1alpha1.HypervisorCluster{}
+			if err := client.Get(context.Background(), req.NamespacedName, updated); err != nil {
+				t.Fatalf("Failed to get cluster: %v", err)
+			}
+			if updated.Status.Phase != tt.expectedPhase {
+				t.Errorf("Expected phase %s, got %s", tt.expectedPhase, updated.Status.Phase)
+			}
+			if updated.Status.ConnectedNodes != tt.expectedConnected {
+				t.Errorf("Expected %d connected nodes, got %d", tt.expectedConnected, updated.Status.ConnectedNodes)
+			}
+			degraded := tt.expectedPhase == hypervisorv1alpha1.ClusterPhaseDegraded
+			if meta.IsStatusConditionTrue(updated.Status.Conditions, ConditionDegraded) != degraded {
+				t.Errorf("Expected Degraded condition %v, got %v", degraded, updated.Status.Conditions)
+			}
+		})
+	}
+}
+
+func TestHypervisorClusterReconciler_ReconcileReadiness(t *testing.T) {
+	tests := []struct {
+		name             string
+		connectionErr    error
+		nodes            []provider.NodeInfo
+		storageErr       error
+		bridges          []provider.NetworkInfo
+		expectedReady    metav1.ConditionStatus
+		expectedFailures []string
+	}{
+		{
+			name:          "all checks pass",
+			nodes:         []provider.NodeInfo{{Name: "pve1", Online: true}},
+			bridges:       []provider.NetworkInfo{{Name: "vmbr0"}},
+			expectedReady: metav1.ConditionTrue,
+		},
+		{
+			name:             "connection fails",
+			connectionErr:    fmt.Errorf("timeout"),
+			expectedReady:    metav1.ConditionFalse,
+			expectedFailures: []string{HealthCheckConnection},
+		},
+		{
+			name:             "no online nodes",
+			nodes:            []provider.NodeInfo{{Name: "pve1"}},
+			bridges:          []provider.NetworkInfo{{Name: "vmbr0"}},
+			expectedReady:    metav1.ConditionFalse,
+			expectedFailures: []string{HealthCheckNodes, HealthCheckStorage, HealthCheckNetwork},
+		},
+		{
+			name:             "storage missing",
+			nodes:            []provider.NodeInfo{{Name: "pve1", Online: true}},
+			storageErr:       fmt.Errorf("storage 'local-lvm' does not exist"),
+			bridges:          []provider.NetworkInfo{{Name: "vmbr0"}},
+			expectedReady:    metav1.ConditionFalse,
+			expectedFailures: []string{HealthCheckStorage},
+		},
+		{
+			name:             "bridge missing",
+			nodes:            []provider.NodeInfo{{Name: "pve1", Online: true}},
+			bridges:          []provider.NetworkInfo{{Name: "vmbr1"}},
+			expectedReady:    metav1.ConditionFalse,
+			expectedFailures: []string{HealthCheckNetwork},
+		},
+		{
+			name:             "storage and bridge missing",
+			nodes:            []provider.NodeInfo{{Name: "pve1", Online: true}, {Name: "pve2"}},
+			storageErr:       fmt.Errorf("storage 'local-lvm' does not exist"),
+			expectedReady:    metav1.ConditionFalse,
+			expectedFailures: []string{HealthCheckStorage, HealthCheckNetwork},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			_ = hypervisorv1alpha1.AddToScheme(scheme)
+			_ = corev1.AddToScheme(scheme)
+
+			cluster := newTestCluster()
+			cluster.Spec.DefaultStorage = "local-lvm"
+			cluster.Spec.DefaultNetwork = "vmbr0"
+			mockClient := &provider.MockHypervisorClient{
+				TestConnectionFunc: func(ctx context.Context) (*provider.ConnectionInfo, error) {
+					if tt.connectionErr != nil {
+						return nil, tt.connectionErr
+					}
+					return &provider.ConnectionInfo{Version: "8.0.4"}, nil
+				},
+				ListNodesFunc: func(ctx context.Context) ([]provider.NodeInfo, error) {
+					return tt.nodes, nil
+				},
+				GetStorageStatusFunc: func(ctx context.Context, node, storage string) (*provider.StorageStatus, error) {
+					return &provider.StorageStatus{}, tt.storageErr
+				},
+				GetNodeNetworksFunc: func(ctx context.Context, node string) ([]provider.NetworkInfo, error) {
+					return tt.bridges, nil
+				},
+			}
+
+			client := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(cluster).
+				WithObjects(cluster, newTestCredentialsSecret()).Build()
+			r := &HypervisorClusterReconciler{
+				Client:        client,
+				Scheme:        scheme,
+				ClientFactory: provider.NewMockClientFactoryWithClient(mockClient),
+			}
+
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: cluster.Name, Namespace: cluster.Namespace}}
+			if _, err := r.Reconcile(context.Background(), req); err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+
+			updated := &hypervisorv1alpha1.HypervisorCluster{}
+			if err := client.Get(context.Background(), req.NamespacedName, updated); err != nil {
+				t.Fatalf("Failed to get cluster: %v", err)
+			}
+			ready := meta.FindStatusCondition(updated.Status.Conditions, ConditionReady)
+			if ready == nil || ready.Status != tt.expectedReady {
+				t.Fatalf("Expected Ready %s, got %v", tt.expectedReady, ready)
+			}
+			for _, check := range []string{HealthCheckConnection, HealthCheckNodes, HealthCheckStorage, HealthCheckNetwork} {
+				listed := strings.Contains(ready.Message, check+": ")
+				if expected := slices.Contains(tt.expectedFailures, check); listed != expected {
+					t.Errorf("Expected %s listed in Ready message %v, got %q", check, expected, ready.Message)
+				}
+			}
+			if len(tt.expectedFailures) > 0 {
+				if ready.Reason != tt.expectedFailures[0]+"Failed" {
+					t.Errorf("Expected Ready reason %sFailed, got %s", tt.expectedFailures[0], ready.Reason)
+				}
+				if updated.Status.Phase != hypervisorv1alpha1.ClusterPhaseNotReady {
+					t.Errorf("Expected phase NotReady, got %s", updated.Status.Phase)
+				}
+			}
+		})
+	}
+}
+
+func TestHypervisorClusterReconciler_ReconcileMinimumVersion(t *testing.T) {
+	tests := []struct {
+		name              string
+		version           string
+		expectedReady     metav1.ConditionStatus
+		expectedReason    string
+		expectedCondition metav1.ConditionStatus
+		expectValidation  bool
+	}{
+		{
+			name:              "below minimum",
+			version:           "7.4.17",
+			expectedReady:     metav1.ConditionFalse,
+			expectedReason:    ReasonUnsupportedVersion,
+			expectedCondition: metav1.ConditionTrue,
+		},
+		{
+			name:              "at minimum",
+			version:           "8.1.0",
+			expectedReady:     metav1.ConditionTrue,
+			expectedReason:    "ConnectionSuccessful",
+			expectedCondition: metav1.ConditionFalse,
+			expectValidation:  true,
+		},
+		{
+			name:              "above minimum",
+			version:           "8.2.4",
+			expectedReady:     metav1.ConditionTrue,
+			expectedReason:    "ConnectionSuccessful",
+			expectedCondition: metav1.ConditionFalse,
+			expectValidation:  true,
+		},
+		{
+			name:              "unparseable version proceeds",
+			version:           "mock-1.0.0",
+			expectedReady:     metav1.ConditionTrue,
+			expectedReason:    "ConnectionSuccessful",
+			expectedCondition: metav1.ConditionUnknown,
+			expectValidation:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			_ = hypervisorv1alpha1.AddToScheme(scheme)
+			_ = corev1.AddToScheme(scheme)
+
+			cluster := newTestCluster()
+			cluster.Spec.DefaultStorage = "local-lvm"
+			validated := false
+			mockClient := &provider.MockHypervisorClient{
+				TestConnectionFunc: func(ctx context.Context) (*provider.ConnectionInfo, error) {
+					return &provider.ConnectionInfo{Version: tt.version}, nil
+				},
+				ListNodesFunc: func(ctx context.Context) ([]provider.NodeInfo, error) {
+					return []provider.NodeInfo{{Name: "pve1", Online: true}}, nil
+				},
+				GetStorageStatusFunc: func(ctx context.Context, node, storage string) (*provider.StorageStatus, error) {
+					validated = true
+					return &provider.StorageStatus{}, nil
+				},
+			}
+
+			client := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(cluster).
+				WithObjects(cluster, newTestCredentialsSecret()).Build()
+			r := &HypervisorClusterReconciler{
+				Client:         client,
+				Scheme:         scheme,
+				ClientFactory:  provider.NewMockClientFactoryWithClient(mockClient),
+				MinimumVersion: &provider.Version{Major: 8, Minor: 1},
+			}
+
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: cluster.Name, Namespace: cluster.Namespace}}
+			if _, err := r.Reconcile(context.Background(), req); err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+
+			updated := &hypervisorv1alpha1.HypervisorCluster{}
+			if err := client.Get(context.Background(), req.NamespacedName, updated); err != nil {
+				t.Fatalf("Failed to get cluster: %v", err)
+			}
+			ready := meta.FindStatusCondition(updated.Status.Conditions, ConditionReady)
+			if ready == nil || ready.Status != tt.expectedReady || ready.Reason != tt.expectedReason {
+				t.Errorf("Expected Ready %s/%s, got %v", tt.expectedReady, tt.expectedReason, ready)
+			}
+			unsupported := meta.FindStatusCondition(updated.Status.Conditions, ConditionUnsupportedVersion)
+			if unsupported == nil || unsupported.Status != tt.expectedCondition {
+				t.Errorf("Expected UnsupportedVersion %s, got %v", tt.expectedCondition, unsupported)
+			}
+			if validated != tt.expectValidation {
+				t.Errorf("Expected default storage validated %v, got %v", tt.expectValidation, validated)
+			}
+			checked := slices.ContainsFunc(updated.Status.Checks, func(check hypervisorv1alpha1.HealthCheckStatus) bool {
+				return check.Name == HealthCheckStorage
+			})
+			if checked != tt.expectValidation {
+				t.Errorf("Expected Storage check %v, got %v", tt.expectValidation, updated.Status.Checks)
+			}
+		})
+	}
+
+	t.Run("no minimum leaves the condition unset", func(t *testing.T) {
+		cluster := newTestCluster()
+		applyConnectionResult(cluster, &ConnectionResult{Success: true, Version: "1.0.0", TestedAt: metav1.Now()})
+		if condition := meta.FindStatusCondition(cluster.Status.Conditions, ConditionUnsupportedVersion); condition != nil {
+			t.Errorf("Expected no UnsupportedVersion condition, got %v", condition)
+		}
+	})
+}
+
+func TestHypervisorClusterReconciler_ReconcileNodeList(t *testing.T) {
+	tests := []struct {
+		name              string
+		specNodes         []string
+		nodes             []provider.NodeInfo
+		expectedStatus    metav1.ConditionStatus
+		expectedReason    string
+		messageContains   string
+		expectedConnected int32
+	}{
+		{
+			name:              "spec matches hypervisor",
+			specNodes:         []string{"pve1", "pve2"},
+			nodes:             []provider.NodeInfo{{Name: "pve1", Online: true}, {Name: "pve2", Online: true}},
+			expectedStatus:    metav1.ConditionTrue,
+			expectedReason:    "NodesMatch",
+			expectedConnected: 2,
+		},
+		{
+			name:              "spec has extra node",
+			specNodes:         []string{"pve1", "pve2", "pve3"},
+			nodes:             []provider.NodeInfo{{Name: "pve1", Online: true}, {Name: "pve2", Online: true}},
+			expectedStatus:    metav1.ConditionFalse,
+			expectedReason:    "NodesMissing",
+			messageContains:   "not found on the hypervisor: pve3",
+			expectedConnected: 2,
+		},
+		{
+			name:              "hypervisor has node not in spec",
+			specNodes:         []string{"pve1"},
+			nodes:             []provider.NodeInfo{{Name: "pve1", Online: true}, {Name: "pve2", Online: true}},
+			expectedStatus:    metav1.ConditionTrue,
+			expectedReason:    "UnlistedNodes",
+			messageContains:   "not listed in spec: pve2",
+			expectedConnected: 1, // unlisted nodes never host VMs
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			_ = hypervisorv1alpha1.AddToScheme(scheme)
+			_ = corev1.AddToScheme(scheme)
+
+			cluster := newTestCluster()
+			cluster.Spec.Nodes = tt.specNodes
+			mockClient := &provider.MockHypervisorClient{
+				ListNodesFunc: func(ctx context.Context) ([]provider.NodeInfo, error) {
+					return tt.nodes, nil
+				},
+			}
+
+			client := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(cluster).
+				WithObjects(cluster, newTestCredentialsSecret()).Build()
+			r := &HypervisorClusterReconciler{
+				Client:        client,
+				Scheme:        scheme,
+				ClientFactory: provider.NewMockClientFactoryWithClient(mockClient),
+			}
+
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: cluster.Name, Namespace: cluster.Namespace}}
+			if _, err := r.Reconcile(context.Background(), req); err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+
+			updated := &hypervisorv1alpha1.HypervisorCluster{}
+			if err := client.Get(context.Background(), req.NamespacedName, updated); err != nil {
+				t.Fatalf("Failed to get cluster: %v", err)
+			}
+			condition := meta.FindStatusCondition(updated.Status.Conditions, ConditionNodesInSync)
+			if condition == nil {
+				t.Fatalf("Expected %s condition, got %v", ConditionNodesInSync, updated.Status.Conditions)
+			}
+			if condition.Status != tt.expectedStatus || condition.Reason != tt.expectedReason {
+				t.Errorf("Expected %s/%s, got %s/%s", tt.expectedStatus, tt.expectedReason, condition.Status, condition.Reason)
+			}
+			if !strings.Contains(condition.Message, tt.messageContains) {
+				t.Errorf("Expected message containing %q, got %q", tt.messageContains, condition.Message)
+			}
+			if updated.Status.ConnectedNodes != tt.expectedConnected {
+				t.Errorf("Expected %d connected nodes, got %d", tt.expectedConnected, updated.Status.ConnectedNodes)
+			}
+			// A node list mismatch is reported but never takes the cluster out of service
+			if updated.Status.Phase != hypervisorv1alpha1.ClusterPhaseReady {
+				t.Errorf("Expected phase Ready, got %s", updated.Status.Phase)
+			}
+		})
+	}
+}
+
+func TestHypervisorClusterReconciler_ReconcileClockSkew(t *testing.T) {
+	tests := []struct {
+		name           string
+		offset         time.Duration
+		timeErr        error
+		expectedStatus metav1.ConditionStatus
+		expectedReason string
+	}{
+		{name: "within threshold", offset: 5 * time.Second, expectedStatus: metav1.ConditionFalse, expectedReason: "ClockInSync"},
+		{name: "hypervisor clock ahead", offset: 10 * time.Minute, expectedStatus: metav1.ConditionTrue, expectedReason: "ClockSkewExceeded"},
+		{name: "hypervisor clock behind", offset: -2 * time.Minute, expectedStatus: metav1.ConditionTrue, expectedReason: "ClockSkewExceeded"},
+		{name: "time check fails", timeErr: fmt.Errorf("permission denied"), expectedStatus: metav1.ConditionUnknown, expectedReason: "ClockSkewUnknown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			_ = hypervisorv1alpha1.AddToScheme(scheme)
+			_ = corev1.AddToScheme(scheme)
+
+			cluster := newTestCluster()
+			mockClient := &provider.MockHypervisorClient{
+				ServerTimeFunc: func(ctx context.Context) (time.Time, error) {
+					return time.Now().Add(tt.offset), tt.timeErr
+				},
+			}
+
+			client := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(cluster).
+				WithObjects(cluster, newTestCredentialsSecret()).Build()
+			r := &HypervisorClusterReconciler{
+				Client:        client,
+				Scheme:        scheme,
+				ClientFactory: provider.NewMockClientFactoryWithClient(mockClient),
+			}
+
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: cluster.Name, Namespace: cluster.Namespace}}
+			if _, err := r.Reconcile(context.Background(), req); err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+
+			updated := &hypervisorv1alpha1.HypervisorCluster{}
+			if err := client.Get(context.Background(), req.NamespacedName, updated); err != nil {
+				t.Fatalf("Failed to get cluster: %v", err)
+			}
+			condition := meta.FindStatusCondition(updated.Status.Conditions, ConditionClockSkew)
+			if condition == nil {
+				t.Fatal("Expected ClockSkew condition to be set")
+			}
+			if condition.Status != tt.expectedStatus || condition.Reason != tt.expectedReason {
+				t.Errorf("Expected ClockSkew %s/%s, got %s/%s: %s",
+					tt.expectedStatus, tt.expectedReason, condition.Status, condition.Reason, condition.Message)
+			}
+			// Clock skew is informational and never affects readiness
+			if !meta.IsStatusConditionTrue(updated.Status.Conditions, ConditionReady) {
+				t.Errorf("Expected Ready condition to be true")
+			}
+		})
+	}
+}
+
+func TestHypervisorClusterReconciler_ReconcileEffectiveTags(t *testing.T) {
+	tests := []struct {
+		name      string
+		tags      map[string]string
+		managedBy string
+		expected  []string
+	}{
+		{
+			name:     "no tags carries the default managed-by tag",
+			expected: []string{"managed-by_hyperfleet"},
+		},
+		{
+			name:      "spec tags are formatted and sorted",
+			tags:      map[string]string{"Team": "CI", "env": "prod"},
+			managedBy: "ci-operator",
+			expected:  []string{"env_prod", "managed-by_ci-operator", "team_ci"},
+		},
+		{
+			name:     "reserved keys cannot be overridden",
+			tags:     map[string]string{"managed-by": "someone-else", "hyperfleet-instance": "east", "env": "prod"},
+			expected: []string{"env_prod", "managed-by_hyperfleet"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			_ = hypervisorv1alpha1.AddToScheme(scheme)
+			_ = corev1.AddToScheme(scheme)
+
+			cluster := newTestCluster()
+			cluster.Spec.Tags = tt.tags
+			cluster.Spec.ManagedBy = tt.managedBy
+
+			client := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(cluster).
+				WithObjects(cluster, newTestCredentialsSecret()).Build()
+			r := &HypervisorClusterReconciler{
+				Client:        client,
+				Scheme:        scheme,
+				ClientFactory: provider.NewMockClientFactory(),
+			}
+
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: cluster.Name, Namespace: cluster.Namespace}}
+			if _, err := r.Reconcile(context.Background(), req); err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+
+			updated := &hypervisorv1alpha1.HypervisorCluster{}
+			if err := client.Get(context.Background(), req.NamespacedName, updated); err != nil {
+				t.Fatalf("Failed to get cluster: %v", err)
+			}
+			if !slices.Equal(updated.Status.EffectiveTags, tt.expected) {
+				t.Errorf("Expected effective tags %v, got %v", tt.expected, updated.Status.EffectiveTags)
+			}
+		})
+	}
+}
+
+func TestHypervisorClusterReconciler_ReconcileNodeVersions(t *testing.T) {
+	tests := []struct {
+		name             string
+		versions         map[string]string
+		expectedStatus   metav1.ConditionStatus
+		expectedReason   string
+		expectedVersions []hypervisorv1alpha1.NodeVersionStatus
+	}{
+		{
+			name:           "uniform release",
+			versions:       map[string]string{"pve1": "8.2.4", "pve2": "8.2.7"},
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "NodeVersionsMatch",
+			expectedVersions: []hypervisorv1alpha1.NodeVersionStatus{
+				{Name: "pve1", Version: "8.2.4"}, {Name: "pve2", Version: "8.2.7"},
+			},
+		},
+		{
+			name:           "divergent releases",
+			versions:       map[string]string{"pve1": "8.2.4", "pve2": "7.4.3"},
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: "NodeVersionsDiverge",
+			expectedVersions: []hypervisorv1alpha1.NodeVersionStatus{
+				{Name: "pve1", Version: "8.2.4"}, {Name: "pve2", Version: "7.4.3"},
+			},
+		},
+		{
+			name:           "versions not readable",
+			versions:       map[string]string{},
+			expectedStatus: metav1.ConditionUnknown,
+			expectedReason: "NodeVersionsUnknown",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			_ = hypervisorv1alpha1.AddToScheme(scheme)
+			_ = corev1.AddToScheme(scheme)
+
+			cluster := newTestCluster()
+			mockClient := &provider.MockHypervisorClient{
+				ListNodesFunc: func(ctx context.Context) ([]provider.NodeInfo, error) {
+					return []provider.NodeInfo{
+						{Name: "pve1", Online: true}, {Name: "pve2", Online: true}, {Name: "pve3", Online: false},
+					}, nil
+				},
+				GetNodeVersionFunc: func(ctx context.Context, node string) (string, error) {
+					if node == "pve3" {
+						t.Errorf("Expected offline node %s not to be asked for its version", node)
+					}
+					version, ok := tt.versions[node]
+					if !ok {
+						return "", fmt.Errorf("permission denied")
+					}
+					return version, nil
+				},
+			}
+
+			client := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(cluster).
+				WithObjects(cluster, newTestCredentialsSecret()).Build()
+			r := &HypervisorClusterReconciler{
+				Client:        client,
+				Scheme:        scheme,
+				ClientFactory: provider.NewMockClientFactoryWithClient(mockClient),
+			}
+
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: cluster.Name, Namespace: cluster.Namespace}}
+			if _, err := r.Reconcile(context.Background(), req); err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+
+			updated := &hypervisorv1alpha1.HypervisorCluster{}
+			if err := client.Get(context.Background(), req.NamespacedName, updated); err != nil {
+				t.Fatalf("Failed to get cluster: %v", err)
+			}
+			if !slices.Equal(updated.Status.NodeVersions, tt.expectedVersions) {
+				t.Errorf("Expected node versions %v, got %v", tt.expectedVersions, updated.Status.NodeVersions)
+			}
+			condition := meta.FindStatusCondition(updated.Status.Conditions, ConditionNodeVersionSkew)
+			if condition == nil {
+				t.Fatal("Expected NodeVersionSkew condition to be set")
+			}
+			if condition.Status != tt.expectedStatus || condition.Reason != tt.expectedReason {
+				t.Errorf("Expected NodeVersionSkew %s/%s, got %s/%s: %s",
+					tt.expectedStatus, tt.expectedReason, condition.Status, condition.Reason, condition.Message)
+			}
+			// Version skew is informational and never affects readiness
+			if !meta.IsStatusConditionTrue(updated.Status.Conditions, ConditionReady) {
+				t.Errorf("Expected Ready condition to be true")
+			}
+		})
+	}
+}
+
+func TestNodeVersionRelease(t *testing.T) {
+	tests := map[string]string{
+		"8.2.4":      "8.2",
+		"8.2":        "8.2",
+		"8":          "8",
+		"mock-1.0.0": "mock-1.0",
+	}
+	for version, expected := range tests {
+		if release := nodeVersionRelease(version); release != expected {
+			t.Errorf("nodeVersionRelease(%q) = %q, expected %q", version, release, expected)
+		}
+	}
+}
+
+func TestMeasureClockSkew(t *testing.T) {
+	before := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	after := before.Add(2 * time.Second)
+
+	// The server time is compared to the midpoint of the request
+	if skew := measureClockSkew(before, before.Add(time.Minute), after); skew != 59*time.Second {
+		t.Errorf("Expected skew of 59s, got %v", skew)
+	}
+	if skew := measureClockSkew(before, before, after); skew != -time.Second {
+		t.Errorf("Expected skew of -1s, got %v", skew)
+	}
+}
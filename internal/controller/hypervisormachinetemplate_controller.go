@@ -22,12 +22,17 @@ import (
 	"time"
 
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	hypervisorv1alpha1 "github.com/codihuston/hyperfleet-operator/api/v1alpha1"
 	"github.com/codihuston/hyperfleet-operator/internal/provider"
@@ -38,26 +43,67 @@ type HypervisorMachineTemplateReconciler struct {
 	client.Client
 	Scheme          *runtime.Scheme
 	ProviderFactory provider.ClientFactory
+
+	// RequeueIntervals controls how often the template is re-validated after it passes or fails
+	RequeueIntervals RequeueIntervals
+
+	// Cleaner releases resources held for a template before it is deleted; nil when there are none
+	Cleaner TemplateCleaner
+
+	// ValidationFreshness skips the provider round-trip for a template that already passed
+	// validation at its current generation while its cluster synced within this window.
+	// Zero validates against the provider on every reconcile.
+	ValidationFreshness time.Duration
+
+	// StatusUpdateRetries bounds how often a conflicting status update is re-fetched and retried;
+	// zero uses DefaultStatusUpdateRetries
+	StatusUpdateRetries int
+
+	// AllowCrossNamespaceClusterRefs lets templates use a HypervisorCluster in another namespace;
+	// otherwise such templates are marked invalid
+	AllowCrossNamespaceClusterRefs bool
+}
+
+// TemplateCleaner releases the resources held for a template before its finalizer is removed
+type TemplateCleaner interface {
+	// CleanupTemplate releases the template's resources; an error keeps the template from being deleted
+	CleanupTemplate(ctx context.Context, template *hypervisorv1alpha1.HypervisorMachineTemplate) error
 }
 
 const (
 	// FinalizerName is the finalizer used by this controller
 	FinalizerName = "hypervisormachinetemplate.hyperfleet.io/finalizer"
 
-	// TemplateRequeueInterval for periodic validation checks
-	TemplateRequeueInterval = 5 * time.Minute
+	// DefaultValidationFreshness is the default ValidationFreshness. A healthy cluster is
+	// re-synced every DefaultSuccessRequeueInterval, so its templates stay fresh between syncs.
+	DefaultValidationFreshness = DefaultSuccessRequeueInterval
 
-	// DefaultProviderTimeout for hypervisor client operations
-	DefaultProviderTimeout = 300 // 5 minutes in seconds
+	// TemplateRequeueInterval is how long dependents wait for a template to become valid
+	TemplateRequeueInterval = 5 * time.Minute
 
 	// ConditionTemplateValid represents the template validation condition
 	ConditionTemplateValid = "TemplateValid"
+
+	// ConditionCleanupFailed records why cleanup is blocking the template's deletion
+	ConditionCleanupFailed = "CleanupFailed"
+
+	// ReasonNotATemplate is the TemplateValid reason for a template ID that references a regular VM,
+	// which would be cloned with its running state rather than as a fresh machine
+	ReasonNotATemplate = "NotATemplate"
+
+	// MaxCleanupRequeueInterval caps the backoff between retries of a failing template cleanup
+	MaxCleanupRequeueInterval = 30 * time.Minute
+
+	// validationVMID is the VM ID of the clone request a template is validated with. The request
+	// adopts an existing VM, so the ID is not checked against the cluster's guests.
+	validationVMID = claimVMIDRangeStart
 )
 
 // +kubebuilder:rbac:groups=hypervisor.hyperfleet.io,resources=hypervisormachinetemplates,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=hypervisor.hyperfleet.io,resources=hypervisormachinetemplates/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=hypervisor.hyperfleet.io,resources=hypervisormachinetemplates/finalizers,verbs=update
 // +kubebuilder:rbac:groups=hypervisor.hyperfleet.io,resources=hypervisorclusters,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -117,17 +163,48 @@ func (r *HypervisorMachineTemplateReconciler) Reconcile(ctx context.Context, req
 func (r *HypervisorMachineTemplateReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&hypervisorv1alpha1.HypervisorMachineTemplate{}).
+		// Re-validate templates when their cluster's spec changes, e.g. its DefaultStorage; status
+		// updates do not change the generation
+		Watches(&hypervisorv1alpha1.HypervisorCluster{},
+			handler.EnqueueRequestsFromMapFunc(r.templatesForCluster),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
 		Named("hypervisormachinetemplate").
 		Complete(r)
 }
 
+// templatesForCluster returns a reconcile request for each template referencing the cluster
+func (r *HypervisorMachineTemplateReconciler) templatesForCluster(ctx context.Context, cluster client.Object) []reconcile.Request {
+	// Templates may reference clusters in other namespaces, so all of them are listed
+	templates := &hypervisorv1alpha1.HypervisorMachineTemplateList{}
+	if err := r.List(ctx, templates); err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to list HypervisorMachineTemplates for cluster", "cluster", cluster.GetName())
+		return nil
+	}
+
+	clusterKey := client.ObjectKeyFromObject(cluster)
+	var requests []reconcile.Request
+	for i := range templates.Items {
+		template := &templates.Items[i]
+		if templateClusterKey(template) == clusterKey {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(template)})
+		}
+	}
+	return requests
+}
+
 // handleDeletion handles the deletion of HypervisorMachineTemplate resources
 func (r *HypervisorMachineTemplateReconciler) handleDeletion(ctx context.Context, template *hypervisorv1alpha1.HypervisorMachineTemplate) (ctrl.Result, error) {
 	log := logf.FromContext(ctx)
 
-	// Perform cleanup logic here if needed
 	log.Info("Cleaning up HypervisorMachineTemplate", "name", template.Name)
 
+	if r.Cleaner != nil {
+		if err := r.Cleaner.CleanupTemplate(ctx, template); err != nil {
+			log.Error(err, "Failed to clean up HypervisorMachineTemplate")
+			return r.backOffCleanup(ctx, template, err)
+		}
+	}
+
 	// Remove finalizer
 	controllerutil.RemoveFinalizer(template, FinalizerName)
 	if err := r.Update(ctx, template); err != nil {
@@ -138,25 +215,51 @@ func (r *HypervisorMachineTemplateReconciler) handleDeletion(ctx context.Context
 	return ctrl.Result{}, nil
 }
 
+// backOffCleanup records a failed cleanup in the CleanupFailed condition and retries the deletion
+// after a delay that grows with how long cleanup has been failing, so a stuck deletion doesn't hot-loop
+func (r *HypervisorMachineTemplateReconciler) backOffCleanup(ctx context.Context, template *hypervisorv1alpha1.HypervisorMachineTemplate, cleanupErr error) (ctrl.Result, error) {
+	// The transition time is kept while the condition stays true, marking when cleanup started failing
+	meta.SetStatusCondition(&template.Status.Conditions, metav1.Condition{
+		Type:               ConditionCleanupFailed,
+		Status:             metav1.ConditionTrue,
+		Reason:             "CleanupError",
+		Message:            cleanupErr.Error(),
+		ObservedGeneration: template.Generation,
+	})
+	if err := r.Status().Update(ctx, template); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	condition := meta.FindStatusCondition(template.Status.Conditions, ConditionCleanupFailed)
+	failingFor := time.Since(condition.LastTransitionTime.Time)
+	return ctrl.Result{RequeueAfter: cleanupBackoff(failingFor, r.RequeueIntervals.After(false))}, nil
+}
+
+// cleanupBackoff waits as long as cleanup has already been failing, roughly doubling the delay
+// with every retry, bounded by the failure requeue interval and MaxCleanupRequeueInterval
+func cleanupBackoff(failingFor, base time.Duration) time.Duration {
+	return min(max(failingFor, base), MaxCleanupRequeueInterval)
+}
+
 // validateTemplate validates the template against the hypervisor
 func (r *HypervisorMachineTemplateReconciler) validateTemplate(ctx context.Context, template *hypervisorv1alpha1.HypervisorMachineTemplate) (ctrl.Result, error) {
 	log := logf.FromContext(ctx)
 
 	// Get the referenced HypervisorCluster
 	cluster := &hypervisorv1alpha1.HypervisorCluster{}
-	clusterKey := client.ObjectKey{
-		Name:      template.Spec.HypervisorClusterRef.Name,
-		Namespace: template.Spec.HypervisorClusterRef.Namespace,
-	}
-	if clusterKey.Namespace == "" {
-		clusterKey.Namespace = template.Namespace
+	clusterKey := templateClusterKey(template)
+	if err := checkClusterRef(template, clusterKey, r.AllowCrossNamespaceClusterRefs); err != nil {
+		log.Info("Rejected cross-namespace HypervisorCluster reference", "cluster", clusterKey)
+		r.setTemplateValidCondition(template, metav1.ConditionFalse, ReasonCrossNamespaceClusterRef, err.Error())
+		// Only a change to the template or the operator's configuration can fix the reference
+		return ctrl.Result{}, nil
 	}
 
 	if err := r.Get(ctx, clusterKey, cluster); err != nil {
 		if errors.IsNotFound(err) {
 			log.Info("Referenced HypervisorCluster not found", "cluster", clusterKey)
 			r.setTemplateValidCondition(template, metav1.ConditionFalse, "ClusterNotFound", "Referenced HypervisorCluster not found")
-			return ctrl.Result{RequeueAfter: TemplateRequeueInterval}, nil
+			return ctrl.Result{RequeueAfter: r.RequeueIntervals.After(false)}, nil
 		}
 		return ctrl.Result{}, err
 	}
@@ -165,64 +268,123 @@ func (r *HypervisorMachineTemplateReconciler) validateTemplate(ctx context.Conte
 	if !r.isClusterReady(cluster) {
 		log.Info("Referenced HypervisorCluster not ready", "cluster", clusterKey)
 		r.setTemplateValidCondition(template, metav1.ConditionFalse, "ClusterNotReady", "Referenced HypervisorCluster is not ready")
-		return ctrl.Result{RequeueAfter: TemplateRequeueInterval}, nil
+		return ctrl.Result{RequeueAfter: r.RequeueIntervals.After(false)}, nil
+	}
+
+	// Trust the last validation while neither the template nor the cluster's health changed
+	if r.validationFresh(template, cluster, time.Now()) {
+		log.V(1).Info("Skipping provider validation, cluster was validated recently", "cluster", clusterKey)
+		return ctrl.Result{RequeueAfter: r.RequeueIntervals.After(true)}, nil
 	}
 
 	// Create provider client and validate template
-	if err := r.validateWithProvider(ctx, template, cluster); err != nil {
+	err := r.validateWithProvider(ctx, template, cluster)
+	now := metav1.Now()
+	template.Status.LastValidated = &now
+	if err != nil {
 		log.Error(err, "Template validation failed")
-		r.setTemplateValidCondition(template, metav1.ConditionFalse, "ValidationFailed", err.Error())
-		return ctrl.Result{RequeueAfter: TemplateRequeueInterval}, nil
+		reason := "ValidationFailed"
+		if provider.IsNotATemplate(err) {
+			reason = ReasonNotATemplate
+		}
+		r.setTemplateValidCondition(template, metav1.ConditionFalse, reason, err.Error())
+		return ctrl.Result{RequeueAfter: r.RequeueIntervals.After(false)}, nil
 	}
 
-	// Template is valid
-	r.setTemplateValidCondition(template, metav1.ConditionTrue, "ValidationSucceeded", "Template validation succeeded")
+	// Template is valid; a runner group a repository runner cannot join is only a warning
+	message := "Template validation succeeded"
+	if warning := runnerGroupWarning(template.Spec.Bootstrap.Config.GitHub); warning != "" {
+		message += "; warning: " + warning
+	}
+	r.setTemplateValidCondition(template, metav1.ConditionTrue, "ValidationSucceeded", message)
 	template.Status.TemplateAvailable = true
 	template.Status.ValidationStatus = "Valid"
+	template.Status.ObservedClusterGeneration = cluster.Generation
 
-	return ctrl.Result{RequeueAfter: TemplateRequeueInterval}, nil
+	return ctrl.Result{RequeueAfter: r.RequeueIntervals.After(true)}, nil
 }
 
-// validateWithProvider validates the template using the hypervisor provider
-func (r *HypervisorMachineTemplateReconciler) validateWithProvider(_ context.Context, template *hypervisorv1alpha1.HypervisorMachineTemplate, cluster *hypervisorv1alpha1.HypervisorCluster) error {
-	// Create provider client configuration
-	clientConfig := &provider.ClientConfig{
-		Endpoint: cluster.Spec.Endpoint,
-		Timeout:  DefaultProviderTimeout,
+// validateWithProvider validates the template using the hypervisor provider and records the
+// resolved source template in the template's status
+func (r *HypervisorMachineTemplateReconciler) validateWithProvider(ctx context.Context, template *hypervisorv1alpha1.HypervisorMachineTemplate, cluster *hypervisorv1alpha1.HypervisorCluster) error {
+	template.Status.TemplateName = ""
+	template.Status.TemplateNode = ""
+
+	// For Proxmox, validate that the template configuration is valid
+	if template.Spec.Template.Proxmox != nil {
+		if template.Spec.Template.Proxmox.TemplateID <= 0 {
+			return fmt.Errorf("invalid Proxmox template ID: %d", template.Spec.Template.Proxmox.TemplateID)
+		}
 	}
 
-	// Create auth config (simplified for now - would need to read from secrets in real implementation)
-	authConfig := &provider.AuthConfig{
-		Type: "token", // Default to token auth for Proxmox
+	// Validate resource requirements
+	if template.Spec.Resources.CPU <= 0 {
+		return fmt.Errorf("invalid CPU specification: %d", template.Spec.Resources.CPU)
+	}
+	if err := validateResourceLimits(template); err != nil {
+		return err
 	}
 
-	// Create provider client
-	providerClient, err := r.ProviderFactory.CreateClient(cluster.Spec.Provider, clientConfig, authConfig)
+	// Validate the SSH keys, including cluster defaults, so a bad key fails here rather than at VM bootstrap
+	if err := validateSSHAuthorizedKeys(sshAuthorizedKeys(template, cluster)); err != nil {
+		return err
+	}
+
+	// The remaining checks read from the hypervisor
+	providerClient, err := newProviderClient(ctx, r.Client, r.ProviderFactory, cluster)
 	if err != nil {
-		return fmt.Errorf("failed to create provider client: %w", err)
+		return err
 	}
 	defer func() {
 		_ = providerClient.Close() // Ignore close errors in validation
 	}()
 
-	// For Proxmox, validate that the template configuration is valid
+	var source *provider.TemplateInfo
 	if template.Spec.Template.Proxmox != nil {
-		if template.Spec.Template.Proxmox.TemplateID <= 0 {
-			return fmt.Errorf("invalid Proxmox template ID: %d", template.Spec.Template.Proxmox.TemplateID)
+		source, err = providerClient.GetTemplate(ctx, template.Spec.Template.Proxmox.TemplateID)
+		if err != nil {
+			return err
+		}
+		// Dry-run a clone on the source template's node, where clones are created
+		req, err := newCloneRequest(template, cluster, source.Node, template.Name, validationVMID)
+		if err != nil {
+			return err
+		}
+		if err := providerClient.ValidateCreateRequest(ctx, req); err != nil {
+			return err
 		}
-
-		// TODO: Add actual template existence check via provider client
-		// This would call something like: providerClient.ValidateTemplate(templateID)
 	}
 
-	// Validate resource requirements
-	if template.Spec.Resources.CPU <= 0 {
-		return fmt.Errorf("invalid CPU specification: %d", template.Spec.Resources.CPU)
+	// A static address outside the bridge's subnet would leave the VM unreachable
+	if err := validateStaticNetwork(ctx, providerClient, template, cluster); err != nil {
+		return err
 	}
 
+	if source != nil {
+		template.Status.TemplateName = source.Name
+		template.Status.TemplateNode = source.Node
+	}
 	return nil
 }
 
+// validationFresh reports whether the template's last successful validation still holds: it
+// covered the current generations of the template and the cluster, and the cluster passed its
+// own checks within ValidationFreshness of now
+func (r *HypervisorMachineTemplateReconciler) validationFresh(template *hypervisorv1alpha1.HypervisorMachineTemplate, cluster *hypervisorv1alpha1.HypervisorCluster, now time.Time) bool {
+	if r.ValidationFreshness <= 0 || cluster.Status.LastSyncTime == nil {
+		return false
+	}
+	valid := meta.FindStatusCondition(template.Status.Conditions, ConditionTemplateValid)
+	if valid == nil || valid.Status != metav1.ConditionTrue || valid.ObservedGeneration != template.Generation {
+		return false
+	}
+	// A cluster spec change, e.g. a new DefaultStorage, may invalidate the template
+	if template.Status.ObservedClusterGeneration != cluster.Generation {
+		return false
+	}
+	return now.Sub(cluster.Status.LastSyncTime.Time) < r.ValidationFreshness
+}
+
 // isClusterReady checks if the HypervisorCluster is ready
 func (r *HypervisorMachineTemplateReconciler) isClusterReady(cluster *hypervisorv1alpha1.HypervisorCluster) bool {
 	for _, condition := range cluster.Status.Conditions {
@@ -241,6 +403,7 @@ func (r *HypervisorMachineTemplateReconciler) setTemplateValidCondition(template
 		Reason:             reason,
 		Message:            message,
 		LastTransitionTime: metav1.Now(),
+		ObservedGeneration: template.Generation,
 	}
 
 	// Find existing condition and update or append
@@ -255,8 +418,9 @@ func (r *HypervisorMachineTemplateReconciler) setTemplateValidCondition(template
 
 // updateStatus updates the template status
 func (r *HypervisorMachineTemplateReconciler) updateStatus(ctx context.Context, template *hypervisorv1alpha1.HypervisorMachineTemplate) error {
-	now := metav1.Now()
-	template.Status.LastValidated = &now
-
-	return r.Status().Update(ctx, template)
+	// The conditions were set during this reconcile, so a re-fetched template gets the whole status back
+	status := template.Status.DeepCopy()
+	return updateStatusWithRetry(ctx, r.Client, template, r.StatusUpdateRetries, func() {
+		template.Status = *status
+	})
 }
@@ -18,11 +18,21 @@ package controller
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	hypervisorv1alpha1 "github.com/codihuston/hyperfleet-operator/api/v1alpha1"
 	"github.com/codihuston/hyperfleet-operator/internal/provider"
@@ -130,6 +140,7 @@ func TestHypervisorMachineTemplateReconciler_setTemplateValidCondition(t *testin
 func TestHypervisorMachineTemplateReconciler_validateWithProvider(t *testing.T) {
 	scheme := runtime.NewScheme()
 	_ = hypervisorv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
 
 	tests := []struct {
 		name        string
@@ -147,7 +158,8 @@ func TestHypervisorMachineTemplateReconciler_validateWithProvider(t *testing.T)
 						},
 					},
 					Resources: hypervisorv1alpha1.ResourceRequirements{
-						CPU: 2,
+						CPU:    2,
+						Memory: "4Gi",
 					},
 				},
 			},
@@ -169,7 +181,8 @@ func TestHypervisorMachineTemplateReconciler_validateWithProvider(t *testing.T)
 						},
 					},
 					Resources: hypervisorv1alpha1.ResourceRequirements{
-						CPU: 2,
+						CPU:    2,
+						Memory: "4Gi",
 					},
 				},
 			},
@@ -181,6 +194,30 @@ func TestHypervisorMachineTemplateReconciler_validateWithProvider(t *testing.T)
 			},
 			expectError: true,
 		},
+		{
+			name: "malformed cluster SSH key",
+			template: &hypervisorv1alpha1.HypervisorMachineTemplate{
+				Spec: hypervisorv1alpha1.HypervisorMachineTemplateSpec{
+					Template: hypervisorv1alpha1.TemplateSpec{
+						Proxmox: &hypervisorv1alpha1.ProxmoxTemplateSpec{
+							TemplateID: 9000,
+						},
+					},
+					Resources: hypervisorv1alpha1.ResourceRequirements{
+						CPU:    2,
+						Memory: "4Gi",
+					},
+				},
+			},
+			cluster: &hypervisorv1alpha1.HypervisorCluster{
+				Spec: hypervisorv1alpha1.HypervisorClusterSpec{
+					Provider:                 "proxmox",
+					Endpoint:                 "https://test.example.com:8006",
+					DefaultSSHAuthorizedKeys: []string{"ssh-rsa not-a-key"},
+				},
+			},
+			expectError: true,
+		},
 		{
 			name: "invalid CPU specification",
 			template: &hypervisorv1alpha1.HypervisorMachineTemplate{
@@ -207,7 +244,11 @@ func TestHypervisorMachineTemplateReconciler_validateWithProvider(t *testing.T)
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			client := fake.NewClientBuilder().WithScheme(scheme).Build()
+			// The provider checks authenticate with the cluster's credentials
+			tt.cluster.Namespace = "default"
+			tt.cluster.Spec.Credentials = newTestCluster().Spec.Credentials
+
+			client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(newTestCredentialsSecret()).Build()
 			r := &HypervisorMachineTemplateReconciler{
 				Client:          client,
 				Scheme:          scheme,
@@ -227,6 +268,464 @@ func TestHypervisorMachineTemplateReconciler_validateWithProvider(t *testing.T)
 	}
 }
 
+func TestHypervisorMachineTemplateReconciler_validateWithProviderResolvesTemplate(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	tests := []struct {
+		name         string
+		cpu          int
+		templateErr  error
+		expectError  bool
+		expectedName string
+		expectedNode string
+	}{
+		{name: "valid template", cpu: 2, expectedName: "ubuntu-2404", expectedNode: "pve2"},
+		{name: "template lookup fails", cpu: 2, templateErr: errors.New("template 9000 does not exist"), expectError: true},
+		{name: "invalid template", cpu: 0, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hypervisorClient := &provider.MockHypervisorClient{
+				GetTemplateFunc: func(ctx context.Context, id int) (*provider.TemplateInfo, error) {
+					if tt.templateErr != nil {
+						return nil, tt.templateErr
+					}
+					return &provider.TemplateInfo{ID: id, Name: "ubuntu-2404", Node: "pve2"}, nil
+				},
+			}
+			template := newRunnerTemplate()
+			template.Spec.Template.Proxmox = &hypervisorv1alpha1.ProxmoxTemplateSpec{TemplateID: 9000}
+			template.Spec.Resources.CPU = tt.cpu
+			// A previous validation's result is cleared when validation fails
+			template.Status.TemplateName = "stale-template"
+			template.Status.TemplateNode = "pve1"
+
+			r := &HypervisorMachineTemplateReconciler{
+				Client:          fake.NewClientBuilder().WithScheme(scheme).WithObjects(newTestCredentialsSecret()).Build(),
+				ProviderFactory: provider.NewMockClientFactoryWithClient(hypervisorClient),
+			}
+
+			err := r.validateWithProvider(context.Background(), template, newTestCluster())
+			if tt.expectError != (err != nil) {
+				t.Errorf("Expected error %v, got: %v", tt.expectError, err)
+			}
+			if template.Status.TemplateName != tt.expectedName || template.Status.TemplateNode != tt.expectedNode {
+				t.Errorf("Expected template %q on node %q, got %q on node %q",
+					tt.expectedName, tt.expectedNode, template.Status.TemplateName, template.Status.TemplateNode)
+			}
+		})
+	}
+}
+
+func TestHypervisorMachineTemplateReconciler_validateTemplateRejectsRegularVM(t *testing.T) {
+	tests := []struct {
+		name           string
+		templateErr    error
+		expectedStatus metav1.ConditionStatus
+		expectedReason string
+	}{
+		{name: "template", expectedStatus: metav1.ConditionTrue, expectedReason: "ValidationSucceeded"},
+		{
+			name:           "regular VM",
+			templateErr:    fmt.Errorf("VM 9000 is %w", provider.ErrNotATemplate),
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: ReasonNotATemplate,
+		},
+		{
+			name:           "not found",
+			templateErr:    errors.New("template 9000 does not exist"),
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "ValidationFailed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			_ = hypervisorv1alpha1.AddToScheme(scheme)
+			_ = corev1.AddToScheme(scheme)
+
+			cluster := newTestCluster()
+			cluster.Status.Conditions = []metav1.Condition{{Type: ConditionReady, Status: metav1.ConditionTrue}}
+			template := newRunnerTemplate()
+			template.Spec.HypervisorClusterRef = hypervisorv1alpha1.ObjectReference{Name: cluster.Name}
+			template.Spec.Template.Proxmox = &hypervisorv1alpha1.ProxmoxTemplateSpec{TemplateID: 9000}
+
+			hypervisorClient := &provider.MockHypervisorClient{
+				GetTemplateFunc: func(ctx context.Context, id int) (*provider.TemplateInfo, error) {
+					if tt.templateErr != nil {
+						return nil, tt.templateErr
+					}
+					return &provider.TemplateInfo{ID: id, Name: "ubuntu-2404", Node: "pve1"}, nil
+				},
+			}
+			r := &HypervisorMachineTemplateReconciler{
+				Client:          fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster, newTestCredentialsSecret()).Build(),
+				Scheme:          scheme,
+				ProviderFactory: provider.NewMockClientFactoryWithClient(hypervisorClient),
+			}
+
+			if _, err := r.validateTemplate(context.Background(), template); err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+			condition := meta.FindStatusCondition(template.Status.Conditions, ConditionTemplateValid)
+			if condition == nil {
+				t.Fatal("Expected TemplateValid condition to be set")
+			}
+			if condition.Status != tt.expectedStatus || condition.Reason != tt.expectedReason {
+				t.Errorf("Expected TemplateValid=%s (%s), got %s (%s): %s",
+					tt.expectedStatus, tt.expectedReason, condition.Status, condition.Reason, condition.Message)
+			}
+		})
+	}
+}
+
+func TestHypervisorMachineTemplateReconciler_validateTemplateClusterNamespace(t *testing.T) {
+	tests := []struct {
+		name              string
+		templateNamespace string
+		refNamespace      string
+		allowCrossNS      bool
+		expectedStatus    metav1.ConditionStatus
+		expectedReason    string
+	}{
+		{
+			name:              "same namespace by default",
+			templateNamespace: "default",
+			expectedStatus:    metav1.ConditionTrue,
+			expectedReason:    "ValidationSucceeded",
+		},
+		{
+			name:              "same namespace spelled out",
+			templateNamespace: "default",
+			refNamespace:      "default",
+			expectedStatus:    metav1.ConditionTrue,
+			expectedReason:    "ValidationSucceeded",
+		},
+		{
+			name:              "cross-namespace allowed",
+			templateNamespace: "team-a",
+			refNamespace:      "default",
+			allowCrossNS:      true,
+			expectedStatus:    metav1.ConditionTrue,
+			expectedReason:    "ValidationSucceeded",
+		},
+		{
+			name:              "cross-namespace denied",
+			templateNamespace: "team-a",
+			refNamespace:      "default",
+			expectedStatus:    metav1.ConditionFalse,
+			expectedReason:    ReasonCrossNamespaceClusterRef,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			_ = hypervisorv1alpha1.AddToScheme(scheme)
+			_ = corev1.AddToScheme(scheme)
+
+			cluster := newTestCluster()
+			cluster.Status.Conditions = []metav1.Condition{{Type: ConditionReady, Status: metav1.ConditionTrue}}
+			template := newRunnerTemplate()
+			template.Namespace = tt.templateNamespace
+			template.Spec.HypervisorClusterRef = hypervisorv1alpha1.ObjectReference{Name: cluster.Name, Namespace: tt.refNamespace}
+			template.Spec.Template.Proxmox = &hypervisorv1alpha1.ProxmoxTemplateSpec{TemplateID: 9000}
+
+			validated := false
+			hypervisorClient := &provider.MockHypervisorClient{
+				GetTemplateFunc: func(ctx context.Context, id int) (*provider.TemplateInfo, error) {
+					validated = true
+					return &provider.TemplateInfo{ID: id, Name: "ubuntu-2404", Node: "pve1"}, nil
+				},
+			}
+			r := &HypervisorMachineTemplateReconciler{
+				Client:                         fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster, newTestCredentialsSecret()).Build(),
+				Scheme:                         scheme,
+				ProviderFactory:                provider.NewMockClientFactoryWithClient(hypervisorClient),
+				AllowCrossNamespaceClusterRefs: tt.allowCrossNS,
+			}
+
+			result, err := r.validateTemplate(context.Background(), template)
+			if err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+			condition := meta.FindStatusCondition(template.Status.Conditions, ConditionTemplateValid)
+			if condition == nil {
+				t.Fatal("Expected TemplateValid condition to be set")
+			}
+			if condition.Status != tt.expectedStatus || condition.Reason != tt.expectedReason {
+				t.Errorf("Expected TemplateValid=%s (%s), got %s (%s): %s",
+					tt.expectedStatus, tt.expectedReason, condition.Status, condition.Reason, condition.Message)
+			}
+
+			if tt.expectedReason == ReasonCrossNamespaceClusterRef {
+				if validated {
+					t.Error("Expected the cluster not to be used")
+				}
+				if !strings.Contains(condition.Message, "cross-namespace cluster references are not allowed") {
+					t.Errorf("Expected the message to explain the rejection, got %q", condition.Message)
+				}
+				if result.RequeueAfter != 0 {
+					t.Errorf("Expected no requeue, got %v", result.RequeueAfter)
+				}
+			}
+		})
+	}
+}
+
+func TestHypervisorMachineTemplateReconciler_validateTemplateRequeueInterval(t *testing.T) {
+	intervals := RequeueIntervals{Success: 15 * time.Minute, Failure: time.Minute}
+
+	tests := []struct {
+		name     string
+		cpu      int
+		ready    metav1.ConditionStatus
+		expected time.Duration
+	}{
+		{name: "valid template", cpu: 2, ready: metav1.ConditionTrue, expected: intervals.Success},
+		{name: "cluster not ready", cpu: 2, ready: metav1.ConditionFalse, expected: intervals.Failure},
+		{name: "invalid template", cpu: 0, ready: metav1.ConditionTrue, expected: intervals.Failure},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			_ = hypervisorv1alpha1.AddToScheme(scheme)
+			_ = corev1.AddToScheme(scheme)
+
+			cluster := newTestCluster()
+			cluster.Status.Conditions = []metav1.Condition{{Type: ConditionReady, Status: tt.ready}}
+			template := &hypervisorv1alpha1.HypervisorMachineTemplate{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-template", Namespace: "default"},
+				Spec: hypervisorv1alpha1.HypervisorMachineTemplateSpec{
+					HypervisorClusterRef: hypervisorv1alpha1.ObjectReference{Name: "test-cluster"},
+					Template: hypervisorv1alpha1.TemplateSpec{
+						Proxmox: &hypervisorv1alpha1.ProxmoxTemplateSpec{TemplateID: 9000},
+					},
+					Resources: hypervisorv1alpha1.ResourceRequirements{CPU: tt.cpu, Memory: "4Gi"},
+				},
+			}
+
+			client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster, template, newTestCredentialsSecret()).Build()
+			r := &HypervisorMachineTemplateReconciler{
+				Client:           client,
+				Scheme:           scheme,
+				ProviderFactory:  provider.NewMockClientFactory(),
+				RequeueIntervals: intervals,
+			}
+
+			result, err := r.validateTemplate(context.Background(), template)
+			if err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+			if result.RequeueAfter != tt.expected {
+				t.Errorf("Expected requeue after %v, got %v", tt.expected, result.RequeueAfter)
+			}
+		})
+	}
+}
+
+func TestHypervisorMachineTemplateReconciler_validateTemplateFreshness(t *testing.T) {
+	const freshness = time.Minute
+
+	tests := []struct {
+		name              string
+		lastSync          time.Duration // how long ago the cluster last synced
+		generation        int64         // generation the TemplateValid condition observed
+		clusterGeneration int64         // cluster generation it observed; the cluster is at 3
+		expectedCalls     int
+	}{
+		{name: "skips within freshness window", lastSync: 10 * time.Second, generation: 2, clusterGeneration: 3, expectedCalls: 0},
+		{name: "validates when cluster sync is stale", lastSync: 5 * time.Minute, generation: 2, clusterGeneration: 3, expectedCalls: 1},
+		{name: "validates when template changed", lastSync: 10 * time.Second, generation: 1, clusterGeneration: 3, expectedCalls: 1},
+		{name: "validates when cluster spec changed", lastSync: 10 * time.Second, generation: 2, clusterGeneration: 2, expectedCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			_ = hypervisorv1alpha1.AddToScheme(scheme)
+			_ = corev1.AddToScheme(scheme)
+
+			cluster := newTestCluster()
+			cluster.Generation = 3
+			cluster.Status.Conditions = []metav1.Condition{{Type: ConditionReady, Status: metav1.ConditionTrue}}
+			lastSync := metav1.NewTime(time.Now().Add(-tt.lastSync))
+			cluster.Status.LastSyncTime = &lastSync
+
+			template := newRunnerTemplate()
+			template.Generation = 2
+			template.Spec.HypervisorClusterRef = hypervisorv1alpha1.ObjectReference{Name: cluster.Name}
+			template.Spec.Template.Proxmox = &hypervisorv1alpha1.ProxmoxTemplateSpec{TemplateID: 9000}
+			template.Status.Conditions = []metav1.Condition{{
+				Type:               ConditionTemplateValid,
+				Status:             metav1.ConditionTrue,
+				Reason:             "ValidationSucceeded",
+				ObservedGeneration: tt.generation,
+			}}
+			template.Status.ObservedClusterGeneration = tt.clusterGeneration
+			lastValidated := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+			template.Status.LastValidated = &lastValidated
+
+			calls := 0
+			hypervisorClient := &provider.MockHypervisorClient{
+				GetTemplateFunc: func(ctx context.Context, id int) (*provider.TemplateInfo, error) {
+					calls++
+					return &provider.TemplateInfo{ID: id, Name: "ubuntu-2404", Node: "pve1"}, nil
+				},
+			}
+			r := &HypervisorMachineTemplateReconciler{
+				Client:              fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster, newTestCredentialsSecret()).Build(),
+				Scheme:              scheme,
+				ProviderFactory:     provider.NewMockClientFactoryWithClient(hypervisorClient),
+				ValidationFreshness: freshness,
+			}
+
+			result, err := r.validateTemplate(context.Background(), template)
+			if err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+			if calls != tt.expectedCalls {
+				t.Errorf("Expected %d provider lookups, got %d", tt.expectedCalls, calls)
+			}
+			// A skipped validation keeps the time the template was last validated
+			if validated := !template.Status.LastValidated.Equal(&lastValidated); validated != (tt.expectedCalls > 0) {
+				t.Errorf("Expected LastValidated to change only when validated, got %v", template.Status.LastValidated)
+			}
+			if result.RequeueAfter != DefaultSuccessRequeueInterval {
+				t.Errorf("Expected requeue after %v, got %v", DefaultSuccessRequeueInterval, result.RequeueAfter)
+			}
+			condition := meta.FindStatusCondition(template.Status.Conditions, ConditionTemplateValid)
+			if condition == nil || condition.Status != metav1.ConditionTrue || condition.ObservedGeneration != template.Generation {
+				t.Errorf("Expected TemplateValid=True at generation %d, got %+v", template.Generation, condition)
+			}
+		})
+	}
+}
+
+func TestHypervisorMachineTemplateReconciler_templatesForCluster(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = hypervisorv1alpha1.AddToScheme(scheme)
+
+	cluster := newTestCluster()
+	referencing := func(name, namespace string, ref hypervisorv1alpha1.ObjectReference) *hypervisorv1alpha1.HypervisorMachineTemplate {
+		template := newRunnerTemplate()
+		template.Name, template.Namespace = name, namespace
+		template.Spec.HypervisorClusterRef = ref
+		return template
+	}
+	r := &HypervisorMachineTemplateReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			referencing("same-namespace", "default", hypervisorv1alpha1.ObjectReference{Name: cluster.Name}),
+			referencing("other-namespace", "ci", hypervisorv1alpha1.ObjectReference{Name: cluster.Name, Namespace: "default"}),
+			referencing("other-cluster", "default", hypervisorv1alpha1.ObjectReference{Name: "other-cluster"}),
+			referencing("same-name-elsewhere", "ci", hypervisorv1alpha1.ObjectReference{Name: cluster.Name}),
+		).Build(),
+	}
+
+	var enqueued []string
+	for _, request := range r.templatesForCluster(context.Background(), cluster) {
+		enqueued = append(enqueued, request.String())
+	}
+	slices.Sort(enqueued)
+	if expected := []string{"ci/other-namespace", "default/same-namespace"}; !slices.Equal(enqueued, expected) {
+		t.Errorf("Expected %v to be enqueued, got %v", expected, enqueued)
+	}
+
+	// Only spec changes, which bump the generation, pass the watch's predicate
+	edited := cluster.DeepCopy()
+	edited.Spec.DefaultStorage = "ceph-pool"
+	edited.Generation = cluster.Generation + 1
+	statusOnly := cluster.DeepCopy()
+	statusOnly.Status.Conditions = []metav1.Condition{{Type: ConditionReady, Status: metav1.ConditionTrue}}
+	changed := predicate.GenerationChangedPredicate{}
+	if !changed.Update(event.UpdateEvent{ObjectOld: cluster, ObjectNew: edited}) {
+		t.Errorf("Expected a DefaultStorage edit to enqueue the cluster's templates")
+	}
+	if changed.Update(event.UpdateEvent{ObjectOld: cluster, ObjectNew: statusOnly}) {
+		t.Errorf("Expected a status update not to enqueue the cluster's templates")
+	}
+}
+
+func TestHypervisorMachineTemplateReconciler_validateWithProviderDryRunsClone(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	tests := []struct {
+		name        string
+		dryRunErr   error
+		expectError string
+	}{
+		{name: "clone would succeed"},
+		{
+			name:        "clone would fail",
+			dryRunErr:   errors.New(`storage "fast-ssd" does not exist`),
+			expectError: `storage "fast-ssd" does not exist`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dryRun *provider.CloneRequest
+			hypervisorClient := &provider.MockHypervisorClient{
+				GetTemplateFunc: func(ctx context.Context, id int) (*provider.TemplateInfo, error) {
+					return &provider.TemplateInfo{ID: id, Name: "ubuntu-2404", Node: "pve2"}, nil
+				},
+				ValidateCreateRequestFunc: func(ctx context.Context, req *provider.CloneRequest) error {
+					dryRun = req
+					return tt.dryRunErr
+				},
+			}
+			template := newRunnerTemplate()
+			template.Spec.Template.Proxmox = &hypervisorv1alpha1.ProxmoxTemplateSpec{TemplateID: 9000}
+			template.Spec.Resources.Disk = "20G"
+			template.Spec.Resources.Disks = []hypervisorv1alpha1.DiskSpec{
+				{Size: "20G"},
+				{Size: "50G", Storage: "fast-ssd"},
+			}
+			cluster := newTestCluster()
+			cluster.Spec.DefaultStorage = "ceph-pool"
+
+			r := &HypervisorMachineTemplateReconciler{
+				Client:          fake.NewClientBuilder().WithScheme(scheme).WithObjects(newTestCredentialsSecret()).Build(),
+				ProviderFactory: provider.NewMockClientFactoryWithClient(hypervisorClient),
+			}
+
+			err := r.validateWithProvider(context.Background(), template, cluster)
+			if dryRun == nil {
+				t.Fatal("Expected a dry run of the clone")
+			}
+			// Clones are made on the source template's node
+			if dryRun.SourceNode != "pve2" || dryRun.TargetNode != "" || dryRun.SourceID != 9000 {
+				t.Errorf("Expected a clone of template 9000 on pve2, got %+v", dryRun)
+			}
+			if dryRun.Storage != "ceph-pool" || dryRun.BootDiskGB != 20 || len(dryRun.Disks) != 2 ||
+				dryRun.Disks[0].Storage != "ceph-pool" || dryRun.Disks[1].Storage != "fast-ssd" {
+				t.Errorf("Expected the clone's storages and disk sizes, got %+v", dryRun)
+			}
+
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Errorf("Expected error containing %q, got %v", tt.expectError, err)
+				}
+				if template.Status.TemplateNode != "" {
+					t.Errorf("Expected no template node for an invalid template, got %s", template.Status.TemplateNode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			if template.Status.TemplateNode != "pve2" {
+				t.Errorf("Expected template node pve2, got %s", template.Status.TemplateNode)
+			}
+		})
+	}
+}
+
 func TestHypervisorMachineTemplateReconciler_updateStatus(t *testing.T) {
 	scheme := runtime.NewScheme()
 	_ = hypervisorv1alpha1.AddToScheme(scheme)
@@ -252,8 +751,9 @@ func TestHypervisorMachineTemplateReconciler_updateStatus(t *testing.T) {
 		t.Errorf("Expected no error but got: %v", err)
 	}
 
-	if template.Status.LastValidated == nil {
-		t.Errorf("Expected LastValidated to be set")
+	// Only validation against the hypervisor records when it last happened
+	if template.Status.LastValidated != nil {
+		t.Errorf("Expected LastValidated to be left unset, got %v", template.Status.LastValidated)
 	}
 }
 
@@ -292,6 +792,161 @@ func TestHypervisorMachineTemplateReconciler_handleDeletion(t *testing.T) {
 	}
 }
 
+type fakeTemplateCleaner struct {
+	err   error
+	calls int
+}
+
+func (c *fakeTemplateCleaner) CleanupTemplate(ctx context.Context, template *hypervisorv1alpha1.HypervisorMachineTemplate) error {
+	c.calls++
+	return c.err
+}
+
+func TestHypervisorMachineTemplateReconciler_handleDeletionCleanupFailure(t *testing.T) {
+	tests := []struct {
+		name         string
+		failingSince time.Duration // how long ago cleanup started failing; 0 for the first failure
+		wantMin      time.Duration
+		wantMax      time.Duration
+	}{
+		{
+			name:    "first failure waits the failure interval",
+			wantMin: DefaultFailureRequeueInterval,
+			wantMax: DefaultFailureRequeueInterval,
+		},
+		{
+			name:         "repeated failures back off",
+			failingSince: 10 * time.Minute,
+			wantMin:      10 * time.Minute,
+			wantMax:      11 * time.Minute,
+		},
+		{
+			name:         "backoff is capped",
+			failingSince: 2 * time.Hour,
+			wantMin:      MaxCleanupRequeueInterval,
+			wantMax:      MaxCleanupRequeueInterval,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			_ = hypervisorv1alpha1.AddToScheme(scheme)
+
+			now := metav1.Now()
+			template := &hypervisorv1alpha1.HypervisorMachineTemplate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "test-template",
+					Namespace:         "default",
+					Finalizers:        []string{FinalizerName},
+					DeletionTimestamp: &now,
+				},
+			}
+			if tt.failingSince > 0 {
+				template.Status.Conditions = []metav1.Condition{{
+					Type:               ConditionCleanupFailed,
+					Status:             metav1.ConditionTrue,
+					Reason:             "CleanupError",
+					Message:            "earlier failure",
+					LastTransitionTime: metav1.NewTime(time.Now().Add(-tt.failingSince)),
+				}}
+			}
+
+			client := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(template).
+				WithStatusSubresource(template).
+				Build()
+			cleaner := &fakeTemplateCleaner{err: errors.New("hypervisor unreachable")}
+			r := &HypervisorMachineTemplateReconciler{
+				Client:  client,
+				Scheme:  scheme,
+				Cleaner: cleaner,
+			}
+
+			result, err := r.handleDeletion(context.Background(), template)
+			if err != nil {
+				t.Fatalf("Expected the failure to be recorded rather than returned, got: %v", err)
+			}
+			if cleaner.calls != 1 {
+				t.Errorf("Expected cleanup to be attempted once, got %d", cleaner.calls)
+			}
+			if result.RequeueAfter < tt.wantMin || result.RequeueAfter > tt.wantMax {
+				t.Errorf("Expected requeue after between %v and %v, got %v", tt.wantMin, tt.wantMax, result.RequeueAfter)
+			}
+
+			updated := &hypervisorv1alpha1.HypervisorMachineTemplate{}
+			if err := client.Get(context.Background(), types.NamespacedName{Name: "test-template", Namespace: "default"}, updated); err != nil {
+				t.Fatalf("Failed to get template: %v", err)
+			}
+			if len(updated.Finalizers) != 1 {
+				t.Errorf("Expected finalizer to be kept while cleanup fails, got %v", updated.Finalizers)
+			}
+			condition := meta.FindStatusCondition(updated.Status.Conditions, ConditionCleanupFailed)
+			if condition == nil {
+				t.Fatal("Expected CleanupFailed condition to be set")
+			}
+			if condition.Status != metav1.ConditionTrue || condition.Message != "hypervisor unreachable" {
+				t.Errorf("Expected CleanupFailed=True with the cleanup error, got %s: %q", condition.Status, condition.Message)
+			}
+		})
+	}
+}
+
+func TestHypervisorMachineTemplateReconciler_handleDeletionCleanupSuccess(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = hypervisorv1alpha1.AddToScheme(scheme)
+
+	template := &hypervisorv1alpha1.HypervisorMachineTemplate{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "test-template",
+			Namespace:  "default",
+			Finalizers: []string{FinalizerName},
+		},
+	}
+
+	client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(template).Build()
+	cleaner := &fakeTemplateCleaner{}
+	r := &HypervisorMachineTemplateReconciler{
+		Client:  client,
+		Scheme:  scheme,
+		Cleaner: cleaner,
+	}
+
+	result, err := r.handleDeletion(context.Background(), template)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if result.RequeueAfter > 0 {
+		t.Errorf("Expected no requeue, got %v", result.RequeueAfter)
+	}
+	if cleaner.calls != 1 {
+		t.Errorf("Expected cleanup to be attempted once, got %d", cleaner.calls)
+	}
+	if len(template.Finalizers) != 0 {
+		t.Errorf("Expected finalizer to be removed, but got %v", template.Finalizers)
+	}
+}
+
+func TestCleanupBackoff(t *testing.T) {
+	base := time.Minute
+	tests := []struct {
+		failingFor time.Duration
+		want       time.Duration
+	}{
+		{0, base},
+		{30 * time.Second, base},
+		{5 * time.Minute, 5 * time.Minute},
+		{time.Hour, MaxCleanupRequeueInterval},
+	}
+
+	for _, tt := range tests {
+		if got := cleanupBackoff(tt.failingFor, base); got != tt.want {
+			t.Errorf("cleanupBackoff(%v, %v) = %v, want %v", tt.failingFor, base, got, tt.want)
+		}
+	}
+}
+
 func TestHypervisorMachineTemplateReconciler_validateTemplate(t *testing.T) {
 	scheme := runtime.NewScheme()
 	_ = hypervisorv1alpha1.AddToScheme(scheme)
@@ -323,8 +978,8 @@ func TestHypervisorMachineTemplateReconciler_validateTemplate(t *testing.T) {
 		t.Errorf("Expected no error but got: %v", err)
 	}
 
-	if result.RequeueAfter != TemplateRequeueInterval {
-		t.Errorf("Expected requeue after %v, got %v", TemplateRequeueInterval, result.RequeueAfter)
+	if result.RequeueAfter != DefaultFailureRequeueInterval {
+		t.Errorf("Expected requeue after %v, got %v", DefaultFailureRequeueInterval, result.RequeueAfter)
 	}
 
 	// Verify condition was set
@@ -18,46 +18,735 @@ package controller
 
 import (
 	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	hypervisorv1alpha1 "github.com/codihuston/hyperfleet-operator/api/v1alpha1"
+	"github.com/codihuston/hyperfleet-operator/internal/provider"
+)
+
+const (
+	// ConditionBootstrapReady represents the bootstrap config condition on a MachineClaim
+	ConditionBootstrapReady = "BootstrapReady"
+
+	// vmDescriptionTimeFormat formats the creation time written to the VM description
+	vmDescriptionTimeFormat = time.RFC3339
+
+	// AnnotationMigrateTo requests migration of the claim's VM to the named node
+	AnnotationMigrateTo = "hypervisor.hyperfleet.io/migrate-to"
+	// AnnotationLiveMigrate set to "true" keeps the VM running during a requested migration
+	AnnotationLiveMigrate = "hypervisor.hyperfleet.io/live-migrate"
+	// AnnotationTokenExpiresAt records on the bootstrap Secret when its registration token expires
+	AnnotationTokenExpiresAt = "hypervisor.hyperfleet.io/token-expires-at"
+
+	// RegistrationTokenRenewBefore is how long before its registration token expires the bootstrap
+	// config of a claim whose VM is not cloned yet is re-rendered, leaving the VM time to boot and register
+	RegistrationTokenRenewBefore = 15 * time.Minute
+
+	// MachineClaimFinalizer keeps a claim until its VM has been deleted
+	MachineClaimFinalizer = "machineclaim.hyperfleet.io/finalizer"
+	// VMDeleteTaskTimeout is how long one reconcile waits for a VM delete task to finish
+	VMDeleteTaskTimeout = 10 * time.Second
+	// VMDeleteRequeueInterval is how soon a claim is re-checked while its VM is still being deleted
+	VMDeleteRequeueInterval = 10 * time.Second
+
+	// bootstrapSecretSuffix is appended to the claim name to form the bootstrap Secret name
+	bootstrapSecretSuffix = "-runner-config"
 )
 
 // MachineClaimReconciler reconciles a MachineClaim object
 type MachineClaimReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	Scheme          *runtime.Scheme
+	TokenProvider   RegistrationTokenProvider
+	ProviderFactory provider.ClientFactory
+
+	// CloneLimiter bounds the clones running at once on each cluster. It must be shared with
+	// everything else cloning VMs; SetupWithManager creates one when it is nil.
+	CloneLimiter *CloneLimiter
+
+	// DefaultRunnerLabels are added to every runner, after the template's and the cluster's labels,
+	// e.g. to identify the fleet's environment
+	DefaultRunnerLabels []string
+
+	// InstanceID identifies this operator instance when several share a hypervisor. When set,
+	// every VM it creates is tagged with provider.InstanceTag(InstanceID) and it only
+	// reconciles or deletes VMs carrying that tag.
+	InstanceID string
+
+	// AllowCrossNamespaceClusterRefs lets claims use templates in another namespace, and templates
+	// whose HypervisorCluster is in another namespace; it should match the
+	// HypervisorMachineTemplateReconciler's setting
+	AllowCrossNamespaceClusterRefs bool
 }
 
 // +kubebuilder:rbac:groups=hypervisor.hyperfleet.io,resources=machineclaims,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=hypervisor.hyperfleet.io,resources=machineclaims/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=hypervisor.hyperfleet.io,resources=machineclaims/finalizers,verbs=update
+// +kubebuilder:rbac:groups=hypervisor.hyperfleet.io,resources=hypervisormachinetemplates,verbs=get;list;watch
+// +kubebuilder:rbac:groups=hypervisor.hyperfleet.io,resources=hypervisorclusters,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
-// TODO(user): Modify the Reconcile function to compare the state specified by
-// the MachineClaim object against the actual cluster state, and then
-// perform operations to make the cluster state reflect the state specified by
-// the user.
 //
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.21.0/pkg/reconcile
 func (r *MachineClaimReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	_ = logf.FromContext(ctx)
+	log := logf.FromContext(ctx)
+
+	// Fetch the MachineClaim instance
+	claim := &hypervisorv1alpha1.MachineClaim{}
+	if err := r.Get(ctx, req.NamespacedName, claim); err != nil {
+		if errors.IsNotFound(err) {
+			log.Info("MachineClaim resource not found, ignoring since object must be deleted")
+			return ctrl.Result{}, nil
+		}
+		log.Error(err, "Failed to get MachineClaim")
+		return ctrl.Result{}, err
+	}
 
-	// TODO(user): your logic here
+	// Handle deletion
+	if !claim.DeletionTimestamp.IsZero() {
+		return r.handleDeletion(ctx, claim)
+	}
+
+	// Add finalizer if not present
+	if !controllerutil.ContainsFinalizer(claim, MachineClaimFinalizer) {
+		controllerutil.AddFinalizer(claim, MachineClaimFinalizer)
+		if err := r.Update(ctx, claim); err != nil {
+			log.Error(err, "Failed to add finalizer")
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	// Get the referenced HypervisorMachineTemplate
+	template := &hypervisorv1alpha1.HypervisorMachineTemplate{}
+	templateKey := claimTemplateKey(claim)
+	if err := checkTemplateRef(claim, templateKey, r.AllowCrossNamespaceClusterRefs); err != nil {
+		log.Info("Referenced HypervisorMachineTemplate is in another namespace", "template", templateKey)
+		r.setCondition(claim, ConditionBootstrapReady, metav1.ConditionFalse, ReasonCrossNamespaceTemplateRef, err.Error())
+		if err := r.Status().Update(ctx, claim); err != nil {
+			log.Error(err, "Failed to update status")
+			return ctrl.Result{}, err
+		}
+		// Only a spec change or a restart with the policy relaxed can fix the reference
+		return ctrl.Result{}, nil
+	}
+	if err := r.Get(ctx, templateKey, template); err != nil {
+		if !errors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		log.Info("Referenced HypervisorMachineTemplate not found", "template", templateKey)
+		r.setCondition(claim, ConditionBootstrapReady, metav1.ConditionFalse, "TemplateNotFound", "Referenced HypervisorMachineTemplate not found")
+		if err := r.Status().Update(ctx, claim); err != nil {
+			log.Error(err, "Failed to update status")
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: TemplateRequeueInterval}, nil
+	}
+
+	// Render the runner bootstrap config for the VM
+	if err := r.reconcileBootstrapSecret(ctx, claim, template); err != nil {
+		log.Error(err, "Failed to reconcile bootstrap secret")
+		r.setCondition(claim, ConditionBootstrapReady, metav1.ConditionFalse, "BootstrapConfigFailed", err.Error())
+	}
+
+	// Clone the claim's VM, then reconcile it against the claim
+	result := ctrl.Result{}
+	if claim.Status.VMRef == nil {
+		var err error
+		if result, err = r.provisionVM(ctx, claim, template); err != nil {
+			log.Error(err, "Failed to provision VM")
+			r.setCondition(claim, ConditionVMProvisioned, metav1.ConditionFalse, "CloneFailed", err.Error())
+			result = ctrl.Result{RequeueAfter: VMProvisionRequeueInterval}
+		}
+	}
+	if claim.Status.VMRef != nil {
+		if err := r.reconcileVM(ctx, claim, template); err != nil {
+			log.Error(err, "Failed to reconcile VM", "vm", claim.Status.VMRef.ID)
+		}
+	}
+
+	if err := r.Status().Update(ctx, claim); err != nil {
+		log.Error(err, "Failed to update status")
+		return ctrl.Result{}, err
+	}
+
+	return result, nil
+}
+
+// handleDeletion deletes the claim's VM and removes the finalizer only once the delete task
+// has finished, so the claim does not disappear while its VM is still being torn down. A VM
+// whose cluster is gone cannot be deleted, so it is abandoned rather than blocking the claim.
+func (r *MachineClaimReconciler) handleDeletion(ctx context.Context, claim *hypervisorv1alpha1.MachineClaim) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	if !controllerutil.ContainsFinalizer(claim, MachineClaimFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	if claim.Status.VMRef == nil && claim.Status.PendingVMRef != nil {
+		err := r.resolvePendingVM(ctx, claim)
+		switch {
+		case errors.IsNotFound(err):
+			if err := r.abandonVM(ctx, claim, claim.Status.PendingVMRef, err); err != nil {
+				return ctrl.Result{}, err
+			}
+		case err != nil:
+			log.Error(err, "Failed to check VM being cloned", "vm", claim.Status.PendingVMRef.ID)
+			return ctrl.Result{}, err
+		}
+	}
+	if claim.Status.VMRef != nil {
+		deleted, err := r.deleteVM(ctx, claim)
+		switch {
+		case errors.IsNotFound(err):
+			if err := r.abandonVM(ctx, claim, claim.Status.VMRef, err); err != nil {
+				return ctrl.Result{}, err
+			}
+		case err != nil:
+			log.Error(err, "Failed to delete VM", "vm", claim.Status.VMRef.ID)
+			return ctrl.Result{}, err
+		case !deleted:
+			log.Info("Waiting for VM deletion", "vm", claim.Status.VMRef.ID, "task", claim.Status.DeleteTaskID)
+			return ctrl.Result{RequeueAfter: VMDeleteRequeueInterval}, nil
+		default:
+			log.Info("Deleted VM", "vm", claim.Status.VMRef.ID, "node", claim.Status.VMRef.Node)
+		}
+	}
+
+	controllerutil.RemoveFinalizer(claim, MachineClaimFinalizer)
+	if err := r.Update(ctx, claim); err != nil {
+		log.Error(err, "Failed to remove finalizer")
+		return ctrl.Result{}, err
+	}
 
 	return ctrl.Result{}, nil
 }
 
+// deletionClient returns a client for the cluster hosting the claim's VM: the one recorded
+// in its status, or for claims cloned before it was recorded, its template's. It skips the
+// cross-namespace check, so tightening it never strands a VM.
+func (r *MachineClaimReconciler) deletionClient(ctx context.Context, claim *hypervisorv1alpha1.MachineClaim) (provider.HypervisorClient, error) {
+	var clusterKey client.ObjectKey
+	if ref := claim.Status.ClusterRef; ref != nil {
+		clusterKey = client.ObjectKey{Name: ref.Name, Namespace: ref.Namespace}
+	} else {
+		template := &hypervisorv1alpha1.HypervisorMachineTemplate{}
+		templateKey := claimTemplateKey(claim)
+		if err := r.Get(ctx, templateKey, template); err != nil {
+			return nil, fmt.Errorf("failed to get HypervisorMachineTemplate %s: %w", templateKey, err)
+		}
+		clusterKey = templateClusterKey(template)
+	}
+	cluster := &hypervisorv1alpha1.HypervisorCluster{}
+	if err := r.Get(ctx, clusterKey, cluster); err != nil {
+		return nil, fmt.Errorf("failed to get HypervisorCluster %s: %w", clusterKey, err)
+	}
+	return newProviderClient(ctx, r.Client, r.ProviderFactory, cluster)
+}
+
+// abandonVM records that the claim's VM cannot be deleted because the cluster hosting it, or
+// the template naming that cluster, is gone. Retrying could never succeed, so the finalizer is
+// released and the VM, if it still exists, is left for an administrator to remove.
+func (r *MachineClaimReconciler) abandonVM(ctx context.Context, claim *hypervisorv1alpha1.MachineClaim, vm *hypervisorv1alpha1.VMReference, cause error) error {
+	logf.FromContext(ctx).Info("Leaving VM behind, its cluster cannot be resolved", "vm", vm.ID, "node", vm.Node, "reason", cause.Error())
+	r.setCondition(claim, ConditionVMProvisioned, metav1.ConditionFalse, "VMAbandoned",
+		fmt.Sprintf("VM %d on node %s was not deleted: %v", vm.ID, vm.Node, cause))
+	return r.Status().Update(ctx, claim)
+}
+
+// resolvePendingVM settles a clone whose VM was never recorded in VMRef: the VM it may have
+// created is recorded for deletion when its description names the claim, and otherwise, as the
+// ID may since have been taken by another guest, it is left alone
+func (r *MachineClaimReconciler) resolvePendingVM(ctx context.Context, claim *hypervisorv1alpha1.MachineClaim) error {
+	hypervisorClient, err := r.deletionClient(ctx, claim)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = hypervisorClient.Close()
+	}()
+
+	pending := claim.Status.PendingVMRef
+	exists, err := hypervisorClient.VMExists(ctx, pending.ID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		description, err := hypervisorClient.GetVMDescription(ctx, provider.VMRef{Node: pending.Node, ID: pending.ID})
+		if err != nil {
+			return err
+		}
+		if description == vmDescription(claim) {
+			claim.Status.VMRef = pending
+		}
+	}
+	claim.Status.PendingVMRef = nil
+	return nil
+}
+
+// deleteVM starts deleting the claim's VM unless a delete task is already recorded, then waits
+// briefly for the task. It reports whether the VM is gone; a task still running is not an error.
+func (r *MachineClaimReconciler) deleteVM(ctx context.Context, claim *hypervisorv1alpha1.MachineClaim) (bool, error) {
+	hypervisorClient, err := r.deletionClient(ctx, claim)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = hypervisorClient.Close()
+	}()
+
+	ref := provider.VMRef{Node: claim.Status.VMRef.Node, ID: claim.Status.VMRef.ID}
+	if claim.Status.DeleteTaskID == "" {
+		owned, err := ownedByInstance(ctx, hypervisorClient, ref, r.InstanceID)
+		if err != nil {
+			return false, err
+		}
+		if !owned {
+			// The VM is already gone or belongs to another instance; either way it is not ours to delete
+			logf.FromContext(ctx).Info("Skipping deletion of VM not owned by this instance", "vm", ref.ID, "instance", r.InstanceID)
+			return true, nil
+		}
+		taskID, err := hypervisorClient.DeleteVM(ctx, ref)
+		if err != nil {
+			return false, err
+		}
+		if taskID == "" {
+			// The VM no longer exists
+			return true, nil
+		}
+		claim.Status.DeleteTaskID = taskID
+		if err := r.Status().Update(ctx, claim); err != nil {
+			return false, err
+		}
+	}
+
+	err = hypervisorClient.WaitForTask(ctx, ref.Node, claim.Status.DeleteTaskID, VMDeleteTaskTimeout)
+	if provider.IsTaskTimeout(err) {
+		return false, nil
+	}
+	if err != nil {
+		// Forget the failed task so the next attempt deletes the VM again
+		claim.Status.DeleteTaskID = ""
+		if updateErr := r.Status().Update(ctx, claim); updateErr != nil {
+			return false, updateErr
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// reconcileBootstrapSecret ensures the Secret holding the rendered runner config exists. Registration
+// tokens expire, so the config is re-rendered with a new token when the claim's VM has not been
+// cloned yet and the token is about to expire.
+func (r *MachineClaimReconciler) reconcileBootstrapSecret(ctx context.Context, claim *hypervisorv1alpha1.MachineClaim, template *hypervisorv1alpha1.HypervisorMachineTemplate) error {
+	log := logf.FromContext(ctx)
+
+	secretKey := client.ObjectKey{Name: bootstrapSecretName(claim), Namespace: claim.Namespace}
+
+	existing := &corev1.Secret{}
+	err := r.Get(ctx, secretKey, existing)
+	switch {
+	case err == nil && !bootstrapTokenExpiring(claim, existing, time.Now()):
+		claim.Status.BootstrapSecretName = secretKey.Name
+		r.setCondition(claim, ConditionBootstrapReady, metav1.ConditionTrue, "BootstrapConfigRendered", "Runner bootstrap config is available")
+		return nil
+	case err == nil:
+		log.Info("Registration token is about to expire, re-rendering bootstrap config", "secret", secretKey,
+			"expiresAt", existing.Annotations[AnnotationTokenExpiresAt])
+	case errors.IsNotFound(err):
+		existing = nil
+	default:
+		return fmt.Errorf("failed to get bootstrap secret %s: %w", secretKey, err)
+	}
+
+	if r.TokenProvider == nil {
+		log.Info("No registration token provider configured, skipping bootstrap config")
+		r.setCondition(claim, ConditionBootstrapReady, metav1.ConditionFalse, "TokenProviderMissing", "No registration token provider configured")
+		return nil
+	}
+
+	// Resolve the cluster first, so a failed lookup does not waste a token
+	cluster, err := r.getCluster(ctx, template)
+	if err != nil {
+		return err
+	}
+	token, err := r.TokenProvider.RegistrationToken(ctx, template.Namespace, template.Spec.Bootstrap.Config.GitHub)
+	if err != nil {
+		return fmt.Errorf("failed to mint registration token: %w", err)
+	}
+
+	data, err := renderRunnerConfig(template, cluster, runnerName(claim), token, r.DefaultRunnerLabels)
+	if err != nil {
+		return err
+	}
+	if warning := runnerGroupWarning(template.Spec.Bootstrap.Config.GitHub); warning != "" {
+		log.Info("Rendering runner config without its runner group", "template", template.Name, "reason", warning)
+	}
+	userData, err := renderCloudInitUserData(template, cluster)
+	if err != nil {
+		return err
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretKey.Name,
+			Namespace: secretKey.Namespace,
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			RunnerConfigSecretKey: data,
+		},
+	}
+	if len(userData) > 0 {
+		secret.Data[UserDataSecretKey] = userData
+	}
+	if !token.ExpiresAt.IsZero() {
+		secret.Annotations = map[string]string{AnnotationTokenExpiresAt: token.ExpiresAt.UTC().Format(time.RFC3339)}
+	}
+
+	if existing != nil {
+		existing.Data = secret.Data
+		if existing.Annotations == nil {
+			existing.Annotations = map[string]string{}
+		}
+		delete(existing.Annotations, AnnotationTokenExpiresAt)
+		maps.Copy(existing.Annotations, secret.Annotations)
+		if err := r.Update(ctx, existing); err != nil {
+			return fmt.Errorf("failed to update bootstrap secret %s: %w", secretKey, err)
+		}
+	} else {
+		if err := controllerutil.SetControllerReference(claim, secret, r.Scheme); err != nil {
+			return fmt.Errorf("failed to set owner reference on bootstrap secret: %w", err)
+		}
+		if err := r.Create(ctx, secret); err != nil {
+			return fmt.Errorf("failed to create bootstrap secret %s: %w", secretKey, err)
+		}
+	}
+
+	downloadURL, version := resolvedRunnerDownload(template.Spec.Bootstrap.Config.GitHub)
+	log.Info("Rendered runner bootstrap secret", "secret", secretKey, "runnerDownloadURL", downloadURL, "runnerVersion", version)
+	claim.Status.BootstrapSecretName = secretKey.Name
+	claim.Status.RunnerDownloadURL = downloadURL
+	claim.Status.RunnerVersion = version
+	r.setCondition(claim, ConditionBootstrapReady, metav1.ConditionTrue, "BootstrapConfigRendered", "Runner bootstrap config is available")
+	return nil
+}
+
+// bootstrapTokenExpiring reports whether the bootstrap Secret's registration token expires within
+// RegistrationTokenRenewBefore of now while the claim's VM, which boots with it, is not cloned yet.
+// A token without a recorded expiry never needs renewing.
+func bootstrapTokenExpiring(claim *hypervisorv1alpha1.MachineClaim, secret *corev1.Secret, now time.Time) bool {
+	if claim.Status.VMRef != nil {
+		return false
+	}
+	expiresAt, err := time.Parse(time.RFC3339, secret.Annotations[AnnotationTokenExpiresAt])
+	if err != nil {
+		return false
+	}
+	return !now.Add(RegistrationTokenRenewBefore).Before(expiresAt)
+}
+
+// reconcileVM applies requested migrations, handles resource and network bridge drift and keeps the VM notes and tags in sync with the claim
+func (r *MachineClaimReconciler) reconcileVM(ctx context.Context, claim *hypervisorv1alpha1.MachineClaim, template *hypervisorv1alpha1.HypervisorMachineTemplate) error {
+	cluster, err := r.getCluster(ctx, template)
+	if err != nil {
+		return err
+	}
+
+	hypervisorClient, err := newProviderClient(ctx, r.Client, r.ProviderFactory, cluster)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = hypervisorClient.Close()
+	}()
+
+	ref := provider.VMRef{Node: claim.Status.VMRef.Node, ID: claim.Status.VMRef.ID}
+	owned, err := ownedByInstance(ctx, hypervisorClient, ref, r.InstanceID)
+	if err != nil {
+		return err
+	}
+	if !owned {
+		return fmt.Errorf("VM %d is not tagged %s, refusing to manage it", ref.ID, provider.InstanceTag(r.InstanceID))
+	}
+
+	// Migrate first so later steps address the VM on its new node
+	if err := reconcileVMMigration(ctx, hypervisorClient, claim); err != nil {
+		return err
+	}
+	if err := reconcileVMResources(ctx, hypervisorClient, claim, template); err != nil {
+		return err
+	}
+	if err := reconcileVMNetworkBridges(ctx, hypervisorClient, claim, template, cluster); err != nil {
+		return err
+	}
+	if err := reconcileVMDescription(ctx, hypervisorClient, claim); err != nil {
+		return err
+	}
+	if err := reconcileVMMACAddress(ctx, hypervisorClient, claim, template); err != nil {
+		return err
+	}
+	return reconcileVMTags(ctx, hypervisorClient, claim, cluster, r.InstanceID)
+}
+
+// ownedByInstance reports whether the VM is one of those listed under the instance's tag.
+// Without an instance ID every VM is considered owned.
+func ownedByInstance(ctx context.Context, hypervisorClient provider.HypervisorClient, ref provider.VMRef, instanceID string) (bool, error) {
+	if instanceID == "" {
+		return true, nil
+	}
+	vms, err := hypervisorClient.ListVMs(ctx, provider.InstanceTag(instanceID))
+	if err != nil {
+		return false, err
+	}
+	return slices.ContainsFunc(vms, func(vm provider.VMInfo) bool { return vm.Ref.ID == ref.ID }), nil
+}
+
+// claimTemplateKey returns the key of the HypervisorMachineTemplate a claim references
+func claimTemplateKey(claim *hypervisorv1alpha1.MachineClaim) client.ObjectKey {
+	key := client.ObjectKey{
+		Name:      claim.Spec.TemplateRef.Name,
+		Namespace: claim.Spec.TemplateRef.Namespace,
+	}
+	if key.Namespace == "" {
+		key.Namespace = claim.Namespace
+	}
+	return key
+}
+
+// getCluster fetches the HypervisorCluster a template targets, subject to the cross-namespace policy
+func (r *MachineClaimReconciler) getCluster(ctx context.Context, template *hypervisorv1alpha1.HypervisorMachineTemplate) (*hypervisorv1alpha1.HypervisorCluster, error) {
+	cluster := &hypervisorv1alpha1.HypervisorCluster{}
+	clusterKey := templateClusterKey(template)
+	if err := checkClusterRef(template, clusterKey, r.AllowCrossNamespaceClusterRefs); err != nil {
+		return nil, err
+	}
+	if err := r.Get(ctx, clusterKey, cluster); err != nil {
+		return nil, fmt.Errorf("failed to get HypervisorCluster %s: %w", clusterKey, err)
+	}
+	return cluster, nil
+}
+
+// reconcileVMMigration moves the VM to the node requested by the migrate-to annotation
+func reconcileVMMigration(ctx context.Context, hypervisorClient provider.HypervisorClient, claim *hypervisorv1alpha1.MachineClaim) error {
+	target := claim.Annotations[AnnotationMigrateTo]
+	if target == "" || target == claim.Status.VMRef.Node {
+		return nil
+	}
+	live := claim.Annotations[AnnotationLiveMigrate] == "true"
+
+	ref := provider.VMRef{Node: claim.Status.VMRef.Node, ID: claim.Status.VMRef.ID}
+	if err := hypervisorClient.MigrateVM(ctx, ref, target, live); err != nil {
+		return err
+	}
+
+	logf.FromContext(ctx).Info("Migrated VM", "vm", ref.ID, "from", ref.Node, "to", target, "live", live)
+	claim.Status.VMRef.Node = target
+	return nil
+}
+
+// reconcileVMDescription writes the owning claim into the VM description, only updating it when it changed
+func reconcileVMDescription(ctx context.Context, hypervisorClient provider.HypervisorClient, claim *hypervisorv1alpha1.MachineClaim) error {
+	ref := provider.VMRef{Node: claim.Status.VMRef.Node, ID: claim.Status.VMRef.ID}
+	desired := vmDescription(claim)
+
+	current, err := hypervisorClient.GetVMDescription(ctx, ref)
+	if err != nil {
+		return err
+	}
+	if current == desired {
+		return nil
+	}
+
+	if err := hypervisorClient.SetVMDescription(ctx, ref, desired); err != nil {
+		return err
+	}
+	logf.FromContext(ctx).Info("Updated VM description", "vm", ref.ID, "node", ref.Node)
+	return nil
+}
+
+// primaryInterfaceIndex is the index of the interface DHCP reservations are made for (net0)
+const primaryInterfaceIndex = 0
+
+// reconcileVMMACAddress records the primary interface's MAC address in the claim status when
+// the template requests a static DHCP lease, so a reservation can be made for the address
+func reconcileVMMACAddress(ctx context.Context, hypervisorClient provider.HypervisorClient, claim *hypervisorv1alpha1.MachineClaim, template *hypervisorv1alpha1.HypervisorMachineTemplate) error {
+	dhcp := template.Spec.Network.DHCP
+	if dhcp == nil || !dhcp.RequestStaticLease {
+		claim.Status.MACAddress = ""
+		return nil
+	}
+
+	ref := provider.VMRef{Node: claim.Status.VMRef.Node, ID: claim.Status.VMRef.ID}
+	macs, err := hypervisorClient.GetVMMACAddresses(ctx, ref)
+	if err != nil {
+		return err
+	}
+	mac, ok := macs[primaryInterfaceIndex]
+	if !ok {
+		return fmt.Errorf("VM %d has no primary network interface for a static DHCP lease", ref.ID)
+	}
+	claim.Status.MACAddress = mac
+	return nil
+}
+
+// reconcileVMTags converges the VM's tags on the cluster and claim tags. Only tags the
+// operator applied previously are removed, so tags added on the hypervisor by hand survive.
+func reconcileVMTags(ctx context.Context, hypervisorClient provider.HypervisorClient, claim *hypervisorv1alpha1.MachineClaim,
+	cluster *hypervisorv1alpha1.HypervisorCluster, instanceID string) error {
+	ref := provider.VMRef{Node: claim.Status.VMRef.Node, ID: claim.Status.VMRef.ID}
+	desired := desiredVMTags(cluster, claim, instanceID)
+
+	current, err := hypervisorClient.GetVMTags(ctx, ref)
+	if err != nil {
+		return err
+	}
+	current = sortedTags(current)
+
+	next := make([]string, 0, len(current)+len(desired))
+	for _, tag := range current {
+		stale := slices.Contains(claim.Status.AppliedTags, tag) && !slices.Contains(desired, tag)
+		if !stale {
+			next = append(next, tag)
+		}
+	}
+	next = sortedTags(append(next, desired...))
+
+	if !slices.Equal(current, next) {
+		if err := hypervisorClient.SetVMTags(ctx, ref, next); err != nil {
+			return err
+		}
+		logf.FromContext(ctx).Info("Updated VM tags", "vm", ref.ID, "node", ref.Node, "tags", next)
+	}
+
+	claim.Status.AppliedTags = desired
+	return nil
+}
+
+// desiredVMTags returns the formatted tags for a claim's VM, with claim tags overriding cluster tags.
+// The cluster's managed-by tag and the operator's instance tag, if any, are always included and
+// cannot be overridden.
+func desiredVMTags(cluster *hypervisorv1alpha1.HypervisorCluster, claim *hypervisorv1alpha1.MachineClaim, instanceID string) []string {
+	merged := make(map[string]string, len(cluster.Spec.Tags)+len(claim.Spec.Tags))
+	maps.Copy(merged, cluster.Spec.Tags)
+	maps.Copy(merged, claim.Spec.Tags)
+
+	tags := formatVMTags(merged, cluster.Spec.ManagedBy)
+	if instanceID != "" {
+		tags = append(tags, provider.InstanceTag(instanceID))
+	}
+	return sortedTags(tags)
+}
+
+// clusterVMTags returns the formatted tags every VM on the cluster carries, whichever claim
+// it belongs to: the cluster's tags and its managed-by tag
+func clusterVMTags(cluster *hypervisorv1alpha1.HypervisorCluster) []string {
+	return sortedTags(formatVMTags(cluster.Spec.Tags, cluster.Spec.ManagedBy))
+}
+
+// formatVMTags formats tags, skipping the reserved managed-by and instance keys, and adds the
+// managed-by tag for managedBy
+func formatVMTags(tags map[string]string, managedBy string) []string {
+	formatted := make([]string, 0, len(tags)+2)
+	for key, value := range tags {
+		if key == provider.ManagedByTagKey || key == provider.InstanceTagKey {
+			continue
+		}
+		formatted = append(formatted, provider.FormatTag(key, value))
+	}
+	return append(formatted, provider.ManagedByTag(managedBy))
+}
+
+// sortedTags sorts tags and drops duplicates so tag sets can be compared
+func sortedTags(tags []string) []string {
+	slices.Sort(tags)
+	return slices.Compact(tags)
+}
+
+// vmDescription renders the VM notes identifying the claim that owns the VM. The UID tells
+// the claim apart from a later one reusing its name.
+func vmDescription(claim *hypervisorv1alpha1.MachineClaim) string {
+	return fmt.Sprintf("Managed by HyperFleet\nMachineClaim: %s/%s\nUID: %s\nCreated: %s",
+		claim.Namespace, claim.Name, claim.UID, claim.CreationTimestamp.UTC().Format(vmDescriptionTimeFormat))
+}
+
+// setCondition sets a condition on the claim status
+func (r *MachineClaimReconciler) setCondition(claim *hypervisorv1alpha1.MachineClaim, conditionType string, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&claim.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: claim.Generation,
+	})
+}
+
+// bootstrapSecretName returns the name of the bootstrap Secret for a claim
+func bootstrapSecretName(claim *hypervisorv1alpha1.MachineClaim) string {
+	return claim.Name + bootstrapSecretSuffix
+}
+
+// runnerName returns the runner name for a claim, defaulting to the claim name
+func runnerName(claim *hypervisorv1alpha1.MachineClaim) string {
+	if claim.Spec.RunnerName != "" {
+		return claim.Spec.RunnerName
+	}
+	return claim.Name
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *MachineClaimReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.CloneLimiter == nil {
+		r.CloneLimiter = NewCloneLimiter()
+	}
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&hypervisorv1alpha1.MachineClaim{}).
+		Owns(&corev1.Secret{}).
+		// Re-check VM resources against a template when its spec changes, e.g. its CPU or memory,
+		// so the DriftPolicy applies to existing VMs; status updates do not change the generation
+		Watches(&hypervisorv1alpha1.HypervisorMachineTemplate{},
+			handler.EnqueueRequestsFromMapFunc(r.claimsForTemplate),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
 		Named("machineclaim").
 		Complete(r)
 }
+
+// claimsForTemplate returns a reconcile request for each claim referencing the template
+func (r *MachineClaimReconciler) claimsForTemplate(ctx context.Context, template client.Object) []reconcile.Request {
+	// Claims may reference templates in other namespaces, so all of them are listed
+	claims := &hypervisorv1alpha1.MachineClaimList{}
+	if err := r.List(ctx, claims); err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to list MachineClaims for template", "template", template.GetName())
+		return nil
+	}
+
+	templateKey := client.ObjectKeyFromObject(template)
+	var requests []reconcile.Request
+	for i := range claims.Items {
+		claim := &claims.Items[i]
+		if claimTemplateKey(claim) == templateKey {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(claim)})
+		}
+	}
+	return requests
+}
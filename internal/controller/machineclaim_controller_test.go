@@ -51,7 +51,11 @@ var _ = Describe("MachineClaim Controller", func() {
 						Name:      resourceName,
 						Namespace: "default",
 					},
-					// TODO(user): Specify other spec details if needed.
+					Spec: hypervisorv1alpha1.MachineClaimSpec{
+						TemplateRef: hypervisorv1alpha1.ObjectReference{
+							Name: "test-template",
+						},
+					},
 				}
 				Expect(k8sClient.Create(ctx, resource)).To(Succeed())
 			}
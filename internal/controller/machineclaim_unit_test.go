@@ -0,0 +1,1118 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	hypervisorv1alpha1 "github.com/codihuston/hyperfleet-operator/api/v1alpha1"
+	"github.com/codihuston/hyperfleet-operator/internal/provider"
+)
+
+// fakeTokenProvider returns a fixed registration token and counts calls
+type fakeTokenProvider struct {
+	token *RegistrationToken
+	err   error
+	calls int
+}
+
+func (f *fakeTokenProvider) RegistrationToken(_ context.Context, _ string, _ *hypervisorv1alpha1.GitHubConfig) (*RegistrationToken, error) {
+	f.calls++
+	return f.token, f.err
+}
+
+func newTestClaim() *hypervisorv1alpha1.MachineClaim {
+	return &hypervisorv1alpha1.MachineClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "runner-abc123",
+			Namespace: "default",
+			UID:       "claim-uid",
+		},
+		Spec: hypervisorv1alpha1.MachineClaimSpec{
+			TemplateRef: hypervisorv1alpha1.ObjectReference{
+				Name: "runner-template",
+			},
+		},
+	}
+}
+
+// newTestCluster returns a Proxmox cluster using token credentials from newTestCredentialsSecret
+func newTestCluster() *hypervisorv1alpha1.HypervisorCluster {
+	return &hypervisorv1alpha1.HypervisorCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"},
+		Spec: hypervisorv1alpha1.HypervisorClusterSpec{
+			Provider: "proxmox",
+			Endpoint: "https://pve.example.com:8006",
+			Credentials: hypervisorv1alpha1.HypervisorCredentials{
+				TokenID: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "proxmox-creds"},
+					Key:                  "token-id",
+				},
+				TokenSecret: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "proxmox-creds"},
+					Key:                  "token-secret",
+				},
+			},
+		},
+	}
+}
+
+// newTestCredentialsSecret returns the Secret holding newTestCluster's API token
+func newTestCredentialsSecret() *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "proxmox-creds", Namespace: "default"},
+		Data: map[string][]byte{
+			"token-id":     []byte("root@pam!hyperfleet"),
+			"token-secret": []byte("secret"),
+		},
+	}
+}
+
+func TestMachineClaimReconciler_reconcileBootstrapSecret(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = hypervisorv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+
+	claim := newTestClaim()
+	template := newRunnerTemplate()
+	template.Spec.HypervisorClusterRef = hypervisorv1alpha1.ObjectReference{Name: "test-cluster"}
+	template.Spec.CloudInit = &hypervisorv1alpha1.CloudInitSpec{SSHAuthorizedKeys: []string{testSSHKey}}
+	tokens := &fakeTokenProvider{token: &RegistrationToken{Token: "minted-token"}}
+
+	client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(claim, template, newTestCluster()).Build()
+	r := &MachineClaimReconciler{
+		Client:        client,
+		Scheme:        scheme,
+		TokenProvider: tokens,
+	}
+
+	ctx := context.Background()
+	if err := r.reconcileBootstrapSecret(ctx, claim, template); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	secret := &corev1.Secret{}
+	key := types.NamespacedName{Name: "runner-abc123-runner-config", Namespace: "default"}
+	if err := client.Get(ctx, key, secret); err != nil {
+		t.Fatalf("Expected bootstrap secret to be created: %v", err)
+	}
+
+	var rendered runnerConfig
+	if err := json.Unmarshal(secret.Data[RunnerConfigSecretKey], &rendered); err != nil {
+		t.Fatalf("Failed to parse rendered config: %v", err)
+	}
+	if rendered.Method != "runner-token" {
+		t.Errorf("Expected method runner-token, got %s", rendered.Method)
+	}
+	if rendered.RegistrationURL != "https://github.com/test/repo" {
+		t.Errorf("Expected registration URL https://github.com/test/repo, got %s", rendered.RegistrationURL)
+	}
+	if rendered.RunnerName != "runner-abc123" {
+		t.Errorf("Expected runner name to default to claim name, got %s", rendered.RunnerName)
+	}
+	if rendered.RunnerToken != "minted-token" {
+		t.Errorf("Expected minted token, got %s", rendered.RunnerToken)
+	}
+	if rendered.Runner.InstallPath != "/opt/actions-runner" {
+		t.Errorf("Expected install path /opt/actions-runner, got %s", rendered.Runner.InstallPath)
+	}
+	if keys := debugUserKeys(t, renderedUsers(t, secret.Data[UserDataSecretKey])); !slices.Equal(keys, []string{testSSHKey}) {
+		t.Errorf("Expected user-data to authorize the template SSH key, got %v", keys)
+	}
+
+	if len(secret.OwnerReferences) != 1 || secret.OwnerReferences[0].Name != claim.Name {
+		t.Errorf("Expected secret to be owned by the claim, got %v", secret.OwnerReferences)
+	}
+	if claim.Status.BootstrapSecretName != key.Name {
+		t.Errorf("Expected status to reference %s, got %s", key.Name, claim.Status.BootstrapSecretName)
+	}
+	if claim.Status.RunnerDownloadURL != rendered.Runner.DownloadURL {
+		t.Errorf("Expected status runner download URL %s, got %s", rendered.Runner.DownloadURL, claim.Status.RunnerDownloadURL)
+	}
+	if claim.Status.RunnerVersion != "" {
+		t.Errorf("Expected no runner version for a non-release URL, got %s", claim.Status.RunnerVersion)
+	}
+	if !meta.IsStatusConditionTrue(claim.Status.Conditions, ConditionBootstrapReady) {
+		t.Errorf("Expected BootstrapReady condition to be true")
+	}
+
+	// A second reconcile must not mint a new token for the existing secret
+	if err := r.reconcileBootstrapSecret(ctx, claim, template); err != nil {
+		t.Fatalf("Expected no error on second reconcile but got: %v", err)
+	}
+	if tokens.calls != 1 {
+		t.Errorf("Expected a single token to be minted, got %d", tokens.calls)
+	}
+}
+
+func TestMachineClaimReconciler_reconcileBootstrapSecretTokenExpiry(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = hypervisorv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+
+	now := time.Now()
+	tests := []struct {
+		name           string
+		expiresAt      string
+		vmRef          *hypervisorv1alpha1.VMReference
+		expectRerender bool
+	}{
+		{name: "token valid for long enough", expiresAt: now.Add(time.Hour).UTC().Format(time.RFC3339)},
+		{name: "token about to expire", expiresAt: now.Add(time.Minute).UTC().Format(time.RFC3339), expectRerender: true},
+		{name: "token expired", expiresAt: now.Add(-time.Hour).UTC().Format(time.RFC3339), expectRerender: true},
+		{
+			name:      "token consumed by the cloned VM",
+			expiresAt: now.Add(-time.Hour).UTC().Format(time.RFC3339),
+			vmRef:     &hypervisorv1alpha1.VMReference{Node: "pve1", ID: 105},
+		},
+		{name: "token without expiry"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claim := newTestClaim()
+			claim.Status.VMRef = tt.vmRef
+			template := newRunnerTemplate()
+			template.Spec.HypervisorClusterRef = hypervisorv1alpha1.ObjectReference{Name: "test-cluster"}
+
+			existing := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: bootstrapSecretName(claim), Namespace: claim.Namespace},
+				Data:       map[string][]byte{RunnerConfigSecretKey: []byte(`{"runner_token":"old-token"}`)},
+			}
+			if tt.expiresAt != "" {
+				existing.Annotations = map[string]string{AnnotationTokenExpiresAt: tt.expiresAt}
+			}
+			renewed := now.Add(time.Hour).Truncate(time.Second).UTC()
+			tokens := &fakeTokenProvider{token: &RegistrationToken{Token: "minted-token", ExpiresAt: renewed}}
+			r := &MachineClaimReconciler{
+				Client:        fake.NewClientBuilder().WithScheme(scheme).WithObjects(claim, template, newTestCluster(), existing).Build(),
+				Scheme:        scheme,
+				TokenProvider: tokens,
+			}
+
+			if err := r.reconcileBootstrapSecret(context.Background(), claim, template); err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+			if !meta.IsStatusConditionTrue(claim.Status.Conditions, ConditionBootstrapReady) {
+				t.Errorf("Expected BootstrapReady to be true, got %v", claim.Status.Conditions)
+			}
+
+			secret := &corev1.Secret{}
+			if err := r.Get(context.Background(), client.ObjectKeyFromObject(existing), secret); err != nil {
+				t.Fatalf("Failed to get bootstrap secret: %v", err)
+			}
+			var rendered runnerConfig
+			if err := json.Unmarshal(secret.Data[RunnerConfigSecretKey], &rendered); err != nil {
+				t.Fatalf("Failed to parse rendered config: %v", err)
+			}
+			if !tt.expectRerender {
+				if tokens.calls != 0 || rendered.RunnerToken != "old-token" {
+					t.Errorf("Expected the secret to be kept, got token %q after %d mints", rendered.RunnerToken, tokens.calls)
+				}
+				return
+			}
+			if tokens.calls != 1 || rendered.RunnerToken != "minted-token" {
+				t.Errorf("Expected the secret to be re-rendered with a new token, got %q after %d mints", rendered.RunnerToken, tokens.calls)
+			}
+			if got := secret.Annotations[AnnotationTokenExpiresAt]; got != renewed.Format(time.RFC3339) {
+				t.Errorf("Expected the new token's expiry %s, got %q", renewed.Format(time.RFC3339), got)
+			}
+		})
+	}
+}
+
+func TestMachineClaimReconciler_reconcileBootstrapSecretMissingCluster(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = hypervisorv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+
+	claim := newTestClaim()
+	template := newRunnerTemplate()
+	template.Spec.HypervisorClusterRef = hypervisorv1alpha1.ObjectReference{Name: "missing-cluster"}
+	tokens := &fakeTokenProvider{token: &RegistrationToken{Token: "minted-token"}}
+	r := &MachineClaimReconciler{
+		Client:        fake.NewClientBuilder().WithScheme(scheme).WithObjects(claim, template).Build(),
+		Scheme:        scheme,
+		TokenProvider: tokens,
+	}
+
+	if err := r.reconcileBootstrapSecret(context.Background(), claim, template); err == nil {
+		t.Fatal("Expected an error for the missing cluster")
+	}
+	if tokens.calls != 0 {
+		t.Errorf("Expected no token minted before the cluster is resolved, got %d", tokens.calls)
+	}
+}
+
+func TestMachineClaimReconciler_reconcileBootstrapSecretNoTokenProvider(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = hypervisorv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+
+	claim := newTestClaim()
+	template := newRunnerTemplate()
+
+	client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(claim, template).Build()
+	r := &MachineClaimReconciler{
+		Client: client,
+		Scheme: scheme,
+	}
+
+	if err := r.reconcileBootstrapSecret(context.Background(), claim, template); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	condition := meta.FindStatusCondition(claim.Status.Conditions, ConditionBootstrapReady)
+	if condition == nil || condition.Reason != "TokenProviderMissing" {
+		t.Errorf("Expected TokenProviderMissing condition, got %v", condition)
+	}
+	if claim.Status.BootstrapSecretName != "" {
+		t.Errorf("Expected no bootstrap secret, got %s", claim.Status.BootstrapSecretName)
+	}
+}
+
+func TestVMDescription(t *testing.T) {
+	claim := newTestClaim()
+	claim.CreationTimestamp = metav1.NewTime(time.Date(2025, 3, 14, 9, 26, 53, 0, time.UTC))
+
+	expected := "Managed by HyperFleet\nMachineClaim: default/runner-abc123\nUID: claim-uid\nCreated: 2025-03-14T09:26:53Z"
+	if got := vmDescription(claim); got != expected {
+		t.Errorf("vmDescription() = %q, want %q", got, expected)
+	}
+}
+
+func TestMachineClaimReconciler_reconcileVM(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = hypervisorv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+
+	claim := newTestClaim()
+	claim.CreationTimestamp = metav1.NewTime(time.Date(2025, 3, 14, 9, 26, 53, 0, time.UTC))
+	claim.Status.VMRef = &hypervisorv1alpha1.VMReference{Node: "pve1", ID: 200}
+
+	template := newRunnerTemplate()
+	template.Spec.HypervisorClusterRef = hypervisorv1alpha1.ObjectReference{Name: "test-cluster"}
+
+	cluster := newTestCluster()
+	secret := newTestCredentialsSecret()
+
+	desired := vmDescription(claim)
+
+	tests := []struct {
+		name           string
+		current        string
+		expectedWrites []string
+	}{
+		{
+			name:           "writes description when missing",
+			current:        "",
+			expectedWrites: []string{desired},
+		},
+		{
+			name:           "overwrites stale description",
+			current:        "something else",
+			expectedWrites: []string{desired},
+		},
+		{
+			name:    "skips write when unchanged",
+			current: desired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var writes []string
+			var refs []provider.VMRef
+			mockClient := &provider.MockHypervisorClient{
+				GetVMDescriptionFunc: func(_ context.Context, ref provider.VMRef) (string, error) {
+					refs = append(refs, ref)
+					return tt.current, nil
+				},
+				SetVMDescriptionFunc: func(_ context.Context, ref provider.VMRef, text string) error {
+					refs = append(refs, ref)
+					writes = append(writes, text)
+					return nil
+				},
+			}
+
+			r := &MachineClaimReconciler{
+				Client:          fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster, secret).Build(),
+				Scheme:          scheme,
+				ProviderFactory: provider.NewMockClientFactoryWithClient(mockClient),
+			}
+
+			if err := r.reconcileVM(context.Background(), claim, template); err != nil {
+				t.Fatalf("reconcileVM() error = %v", err)
+			}
+
+			if len(writes) != len(tt.expectedWrites) {
+				t.Fatalf("expected %d writes, got %d: %v", len(tt.expectedWrites), len(writes), writes)
+			}
+			for i := range writes {
+				if writes[i] != tt.expectedWrites[i] {
+					t.Errorf("write %d = %q, want %q", i, writes[i], tt.expectedWrites[i])
+				}
+			}
+			for _, ref := range refs {
+				if ref.Node != "pve1" || ref.ID != 200 {
+					t.Errorf("unexpected VM ref %+v", ref)
+				}
+			}
+		})
+	}
+}
+
+func TestMachineClaimReconciler_templateResourceChange(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = hypervisorv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+
+	template := newRunnerTemplate()
+	template.Generation = 1
+	template.Spec.HypervisorClusterRef = hypervisorv1alpha1.ObjectReference{Name: "test-cluster"}
+	template.Spec.DriftPolicy = hypervisorv1alpha1.DriftPolicyReconcile
+
+	referencing := func(name, namespace string, ref hypervisorv1alpha1.ObjectReference) *hypervisorv1alpha1.MachineClaim {
+		claim := newTestClaim()
+		claim.Name, claim.Namespace = name, namespace
+		claim.Spec.TemplateRef = ref
+		claim.Status.VMRef = &hypervisorv1alpha1.VMReference{Node: "pve1", ID: 200}
+		return claim
+	}
+	claim := referencing("same-namespace", "default", hypervisorv1alpha1.ObjectReference{Name: template.Name})
+
+	var reconfigured []provider.VMResources
+	mockClient := &provider.MockHypervisorClient{
+		GetVMFunc: func(_ context.Context, ref provider.VMRef) (*provider.VMInfo, error) {
+			return &provider.VMInfo{Ref: ref, Resources: provider.VMResources{CPUs: 2, MemoryMiB: 4096}}, nil
+		},
+		ReconfigureVMFunc: func(_ context.Context, ref provider.VMRef, resources provider.VMResources) error {
+			reconfigured = append(reconfigured, resources)
+			return nil
+		},
+	}
+	r := &MachineClaimReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			newTestCluster(), newTestCredentialsSecret(), claim,
+			referencing("other-namespace", "ci", hypervisorv1alpha1.ObjectReference{Name: template.Name, Namespace: "default"}),
+			referencing("other-template", "default", hypervisorv1alpha1.ObjectReference{Name: "other-template"}),
+			referencing("same-name-elsewhere", "ci", hypervisorv1alpha1.ObjectReference{Name: template.Name}),
+		).Build(),
+		Scheme:          scheme,
+		ProviderFactory: provider.NewMockClientFactoryWithClient(mockClient),
+	}
+
+	// Raising the template's CPUs bumps its generation, which passes the watch's predicate
+	updated := template.DeepCopy()
+	updated.Generation = template.Generation + 1
+	updated.Spec.Resources.CPU = 4
+	if !(predicate.GenerationChangedPredicate{}).Update(event.UpdateEvent{ObjectOld: template, ObjectNew: updated}) {
+		t.Fatal("Expected a template resource change to pass the watch predicate")
+	}
+
+	var enqueued []string
+	for _, request := range r.claimsForTemplate(context.Background(), updated) {
+		enqueued = append(enqueued, request.String())
+	}
+	slices.Sort(enqueued)
+	if expected := []string{"ci/other-namespace", "default/same-namespace"}; !slices.Equal(enqueued, expected) {
+		t.Errorf("Expected %v to be enqueued, got %v", expected, enqueued)
+	}
+
+	// Reconciling an enqueued claim brings its VM up to the template's new CPUs
+	if err := r.reconcileVM(context.Background(), claim, updated); err != nil {
+		t.Fatalf("reconcileVM() error = %v", err)
+	}
+	if expected := (provider.VMResources{CPUs: 4, MemoryMiB: 4096}); len(reconfigured) != 1 || reconfigured[0] != expected {
+		t.Errorf("Expected the VM to be reconfigured to %+v, got %v", expected, reconfigured)
+	}
+	if !meta.IsStatusConditionTrue(claim.Status.Conditions, ConditionResourcesInSync) {
+		t.Errorf("Expected ResourcesInSync to be true, got %+v", meta.FindStatusCondition(claim.Status.Conditions, ConditionResourcesInSync))
+	}
+}
+
+func TestMachineClaimReconciler_reconcileVMClusterMissing(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = hypervisorv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+
+	claim := newTestClaim()
+	claim.Status.VMRef = &hypervisorv1alpha1.VMReference{Node: "pve1", ID: 200}
+	template := newRunnerTemplate()
+	template.Spec.HypervisorClusterRef = hypervisorv1alpha1.ObjectReference{Name: "missing-cluster"}
+
+	r := &MachineClaimReconciler{
+		Client:          fake.NewClientBuilder().WithScheme(scheme).Build(),
+		Scheme:          scheme,
+		ProviderFactory: provider.NewMockClientFactory(),
+	}
+
+	if err := r.reconcileVM(context.Background(), claim, template); err == nil {
+		t.Fatal("expected error for missing HypervisorCluster")
+	}
+}
+
+func TestReconcileVMMigration(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		migrateErr  error
+		expectCall  bool
+		expectLive  bool
+		expectNode  string
+		expectError bool
+	}{
+		{
+			name:       "no migration requested",
+			expectNode: "pve1",
+		},
+		{
+			name:        "already on requested node",
+			annotations: map[string]string{AnnotationMigrateTo: "pve1"},
+			expectNode:  "pve1",
+		},
+		{
+			name:        "offline migration",
+			annotations: map[string]string{AnnotationMigrateTo: "pve2"},
+			expectCall:  true,
+			expectNode:  "pve2",
+		},
+		{
+			name:        "live migration",
+			annotations: map[string]string{AnnotationMigrateTo: "pve2", AnnotationLiveMigrate: "true"},
+			expectCall:  true,
+			expectLive:  true,
+			expectNode:  "pve2",
+		},
+		{
+			name:        "migration failure keeps current node",
+			annotations: map[string]string{AnnotationMigrateTo: "pve2"},
+			migrateErr:  errors.New("live migration is not supported"),
+			expectCall:  true,
+			expectNode:  "pve1",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claim := newTestClaim()
+			claim.Annotations = tt.annotations
+			claim.Status.VMRef = &hypervisorv1alpha1.VMReference{Node: "pve1", ID: 200}
+
+			called := false
+			mockClient := &provider.MockHypervisorClient{
+				MigrateVMFunc: func(_ context.Context, ref provider.VMRef, targetNode string, live bool) error {
+					called = true
+					if ref.Node != "pve1" || ref.ID != 200 || targetNode != "pve2" {
+						t.Errorf("unexpected migration of %+v to %s", ref, targetNode)
+					}
+					if live != tt.expectLive {
+						t.Errorf("expected live %v, got %v", tt.expectLive, live)
+					}
+					return tt.migrateErr
+				},
+			}
+
+			err := reconcileVMMigration(context.Background(), mockClient, claim)
+
+			if (err != nil) != tt.expectError {
+				t.Errorf("reconcileVMMigration() error = %v, expectError %v", err, tt.expectError)
+			}
+			if called != tt.expectCall {
+				t.Errorf("expected MigrateVM called %v, got %v", tt.expectCall, called)
+			}
+			if claim.Status.VMRef.Node != tt.expectNode {
+				t.Errorf("expected VM node %s, got %s", tt.expectNode, claim.Status.VMRef.Node)
+			}
+		})
+	}
+}
+
+func TestReconcileVMMACAddress(t *testing.T) {
+	tests := []struct {
+		name        string
+		dhcp        *hypervisorv1alpha1.DHCPConfig
+		macs        map[int]string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "static lease records the primary interface",
+			dhcp:     &hypervisorv1alpha1.DHCPConfig{RequestStaticLease: true},
+			macs:     map[int]string{0: "BC:24:11:00:00:01", 1: "BC:24:11:00:00:02"},
+			expected: "BC:24:11:00:00:01",
+		},
+		{
+			name:        "static lease without a primary interface",
+			dhcp:        &hypervisorv1alpha1.DHCPConfig{RequestStaticLease: true},
+			macs:        map[int]string{1: "BC:24:11:00:00:02"},
+			expectError: true,
+		},
+		{
+			name: "no static lease requested",
+			dhcp: &hypervisorv1alpha1.DHCPConfig{SendHostname: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template := newRunnerTemplate()
+			template.Spec.Network.DHCP = tt.dhcp
+			claim := newTestClaim()
+			claim.Status.VMRef = &hypervisorv1alpha1.VMReference{Node: "pve1", ID: 200}
+			claim.Status.MACAddress = "BC:24:11:FF:FF:FF"
+
+			mockClient := &provider.MockHypervisorClient{
+				GetVMMACAddressesFunc: func(_ context.Context, _ provider.VMRef) (map[int]string, error) {
+					return tt.macs, nil
+				},
+			}
+
+			err := reconcileVMMACAddress(context.Background(), mockClient, claim, template)
+			if tt.expectError {
+				if err == nil {
+					t.Fatalf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("reconcileVMMACAddress() error = %v", err)
+			}
+			if claim.Status.MACAddress != tt.expected {
+				t.Errorf("expected MAC address %q, got %q", tt.expected, claim.Status.MACAddress)
+			}
+		})
+	}
+}
+
+func TestReconcileVMTags(t *testing.T) {
+	const managed = "managed-by_hyperfleet"
+
+	tests := []struct {
+		name          string
+		managedBy     string
+		instanceID    string
+		clusterTags   map[string]string
+		claimTags     map[string]string
+		appliedTags   []string
+		currentTags   []string
+		expectSet     []string
+		expectApplied []string
+	}{
+		{
+			name:          "cluster tag added",
+			clusterTags:   map[string]string{"env": "prod", "team": "ci"},
+			appliedTags:   []string{"env_prod", managed},
+			currentTags:   []string{"env_prod", managed},
+			expectSet:     []string{"env_prod", managed, "team_ci"},
+			expectApplied: []string{"env_prod", managed, "team_ci"},
+		},
+		{
+			name:          "cluster tag removed",
+			clusterTags:   map[string]string{"env": "prod"},
+			appliedTags:   []string{"env_prod", managed, "team_ci"},
+			currentTags:   []string{"env_prod", managed, "team_ci"},
+			expectSet:     []string{"env_prod", managed},
+			expectApplied: []string{"env_prod", managed},
+		},
+		{
+			name:          "tags in sync",
+			clusterTags:   map[string]string{"env": "prod"},
+			appliedTags:   []string{"env_prod", managed},
+			currentTags:   []string{"env_prod", managed},
+			expectApplied: []string{"env_prod", managed},
+		},
+		{
+			name:          "manually added tags are kept",
+			clusterTags:   map[string]string{"env": "staging"},
+			appliedTags:   []string{"env_prod", managed},
+			currentTags:   []string{"env_prod", "debug", managed},
+			expectSet:     []string{"debug", "env_staging", managed},
+			expectApplied: []string{"env_staging", managed},
+		},
+		{
+			name:          "claim tags override cluster tags",
+			clusterTags:   map[string]string{"env": "prod"},
+			claimTags:     map[string]string{"env": "canary"},
+			expectSet:     []string{"env_canary", managed},
+			expectApplied: []string{"env_canary", managed},
+		},
+		{
+			name:          "managed-by tag added to an untagged VM",
+			expectSet:     []string{managed},
+			expectApplied: []string{managed},
+		},
+		{
+			name:          "cluster managed-by value",
+			managedBy:     "tenant-a",
+			claimTags:     map[string]string{"managed-by": "someone-else"},
+			appliedTags:   []string{managed},
+			currentTags:   []string{managed},
+			expectSet:     []string{"managed-by_tenant-a"},
+			expectApplied: []string{"managed-by_tenant-a"},
+		},
+		{
+			name:          "instance tag cannot be overridden",
+			instanceID:    "ci-east",
+			claimTags:     map[string]string{"hyperfleet-instance": "ci-west"},
+			appliedTags:   []string{managed},
+			currentTags:   []string{managed},
+			expectSet:     []string{"hyperfleet-instance_ci-east", managed},
+			expectApplied: []string{"hyperfleet-instance_ci-east", managed},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := newTestCluster()
+			cluster.Spec.Tags = tt.clusterTags
+			cluster.Spec.ManagedBy = tt.managedBy
+			claim := newTestClaim()
+			claim.Spec.Tags = tt.claimTags
+			claim.Status.VMRef = &hypervisorv1alpha1.VMReference{Node: "pve1", ID: 200}
+			claim.Status.AppliedTags = tt.appliedTags
+
+			var set []string
+			setCalled := false
+			mockClient := &provider.MockHypervisorClient{
+				GetVMTagsFunc: func(_ context.Context, _ provider.VMRef) ([]string, error) {
+					return append([]string(nil), tt.currentTags...), nil
+				},
+				SetVMTagsFunc: func(_ context.Context, _ provider.VMRef, tags []string) error {
+					setCalled = true
+					set = tags
+					return nil
+				},
+			}
+
+			if err := reconcileVMTags(context.Background(), mockClient, claim, cluster, tt.instanceID); err != nil {
+				t.Fatalf("reconcileVMTags() error = %v", err)
+			}
+
+			if setCalled != (tt.expectSet != nil) {
+				t.Fatalf("expected SetVMTags called %v, got %v (%v)", tt.expectSet != nil, setCalled, set)
+			}
+			if setCalled && !slices.Equal(set, tt.expectSet) {
+				t.Errorf("expected tags %v, got %v", tt.expectSet, set)
+			}
+			if !slices.Equal(claim.Status.AppliedTags, tt.expectApplied) {
+				t.Errorf("expected applied tags %v, got %v", tt.expectApplied, claim.Status.AppliedTags)
+			}
+		})
+	}
+}
+
+func TestMachineClaimReconcilerInstanceIsolation(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = hypervisorv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+
+	const instanceTag = "hyperfleet-instance_ci-east"
+
+	tests := []struct {
+		name       string
+		instanceID string
+		listed     []provider.VMInfo
+		expectList bool
+		expectOwn  bool
+	}{
+		{
+			name:       "VM tagged for this instance",
+			instanceID: "ci-east",
+			listed:     []provider.VMInfo{{Ref: provider.VMRef{Node: "pve1", ID: 200}}},
+			expectList: true,
+			expectOwn:  true,
+		},
+		{
+			name:       "VM tagged for another instance",
+			instanceID: "ci-east",
+			listed:     []provider.VMInfo{{Ref: provider.VMRef{Node: "pve1", ID: 201}}},
+			expectList: true,
+		},
+		{
+			name:      "no instance ID manages every VM",
+			expectOwn: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claim := newTestClaim()
+			claim.Finalizers = []string{MachineClaimFinalizer}
+			claim.Status.VMRef = &hypervisorv1alpha1.VMReference{Node: "pve1", ID: 200}
+
+			template := newRunnerTemplate()
+			template.Spec.HypervisorClusterRef = hypervisorv1alpha1.ObjectReference{Name: "test-cluster"}
+
+			var listedTags []string
+			deletes, descriptionReads := 0, 0
+			mockClient := &provider.MockHypervisorClient{
+				ListVMsFunc: func(_ context.Context, tag string) ([]provider.VMInfo, error) {
+					listedTags = append(listedTags, tag)
+					return tt.listed, nil
+				},
+				GetVMDescriptionFunc: func(_ context.Context, _ provider.VMRef) (string, error) {
+					descriptionReads++
+					return vmDescription(claim), nil
+				},
+				DeleteVMFunc: func(_ context.Context, _ provider.VMRef) (string, error) {
+					deletes++
+					return "", nil
+				},
+			}
+
+			r := &MachineClaimReconciler{
+				Client: fake.NewClientBuilder().WithScheme(scheme).
+					WithObjects(claim, template, newTestCluster(), newTestCredentialsSecret()).
+					WithStatusSubresource(claim).Build(),
+				Scheme:          scheme,
+				ProviderFactory: provider.NewMockClientFactoryWithClient(mockClient),
+				InstanceID:      tt.instanceID,
+			}
+
+			err := r.reconcileVM(context.Background(), claim, template)
+			if tt.expectOwn != (err == nil) {
+				t.Errorf("reconcileVM() error = %v, expected owned %v", err, tt.expectOwn)
+			}
+			if tt.expectOwn != (descriptionReads > 0) {
+				t.Errorf("expected the VM to be reconciled %v, got %d description reads", tt.expectOwn, descriptionReads)
+			}
+
+			deleted, err := r.deleteVM(context.Background(), claim)
+			if err != nil || !deleted {
+				t.Fatalf("deleteVM() = %v, %v", deleted, err)
+			}
+			if expected := map[bool]int{true: 1, false: 0}[tt.expectOwn]; deletes != expected {
+				t.Errorf("expected %d deletes, got %d", expected, deletes)
+			}
+
+			if tt.expectList != (len(listedTags) > 0) {
+				t.Fatalf("expected VMs listed %v, got %v", tt.expectList, listedTags)
+			}
+			for _, tag := range listedTags {
+				if tag != instanceTag {
+					t.Errorf("expected VMs listed by %s, got %s", instanceTag, tag)
+				}
+			}
+		})
+	}
+}
+
+func TestMachineClaimReconcilerClusterNamespace(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = hypervisorv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+
+	tests := []struct {
+		name         string
+		refNamespace string
+		allowCrossNS bool
+		expectError  string
+	}{
+		{name: "same namespace"},
+		{name: "cross-namespace allowed", refNamespace: "default", allowCrossNS: true},
+		{name: "cross-namespace denied", refNamespace: "default", expectError: "cross-namespace cluster references are not allowed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claim := newTestClaim()
+			claim.Namespace = "team-a"
+			claim.Status.VMRef = &hypervisorv1alpha1.VMReference{Node: "pve1", ID: 200}
+
+			template := newRunnerTemplate()
+			template.Namespace = "team-a"
+			template.Spec.HypervisorClusterRef = hypervisorv1alpha1.ObjectReference{Name: "test-cluster", Namespace: tt.refNamespace}
+
+			// Without a namespace the reference resolves to a cluster in the template's namespace
+			local := newTestCluster()
+			local.Namespace = "team-a"
+			localSecret := newTestCredentialsSecret()
+			localSecret.Namespace = "team-a"
+
+			deletes := 0
+			mockClient := &provider.MockHypervisorClient{
+				GetVMDescriptionFunc: func(_ context.Context, _ provider.VMRef) (string, error) {
+					return vmDescription(claim), nil
+				},
+				DeleteVMFunc: func(_ context.Context, _ provider.VMRef) (string, error) {
+					deletes++
+					return "", nil
+				},
+			}
+			r := &MachineClaimReconciler{
+				Client: fake.NewClientBuilder().WithScheme(scheme).
+					WithObjects(claim, template, newTestCluster(), newTestCredentialsSecret(), local, localSecret).
+					WithStatusSubresource(claim).Build(),
+				Scheme:                         scheme,
+				ProviderFactory:                provider.NewMockClientFactoryWithClient(mockClient),
+				AllowCrossNamespaceClusterRefs: tt.allowCrossNS,
+			}
+
+			err := r.reconcileVM(context.Background(), claim, template)
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Fatalf("Expected error containing %q, got %v", tt.expectError, err)
+				}
+			} else if err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+
+			// A VM is still deleted whatever the policy
+			deleted, err := r.deleteVM(context.Background(), claim)
+			if err != nil || !deleted || deletes != 1 {
+				t.Errorf("Expected the VM to be deleted, got %v, %v after %d deletes", deleted, err, deletes)
+			}
+		})
+	}
+}
+
+func TestMachineClaimReconcilerTemplateNamespace(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = hypervisorv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+
+	tests := []struct {
+		name         string
+		refNamespace string
+		allowCrossNS bool
+		expectDenied bool
+	}{
+		{name: "same namespace"},
+		{name: "cross-namespace allowed", refNamespace: "default", allowCrossNS: true},
+		{name: "cross-namespace denied", refNamespace: "default", expectDenied: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claim := newTestClaim()
+			claim.Namespace = "team-a"
+			claim.Finalizers = []string{MachineClaimFinalizer}
+			claim.Spec.TemplateRef.Namespace = tt.refNamespace
+
+			// The template and its cluster exist in both namespaces, so only the policy decides
+			var objects []client.Object
+			for _, namespace := range []string{"default", "team-a"} {
+				template := newRunnerTemplate()
+				template.Namespace = namespace
+				template.Spec.HypervisorClusterRef = hypervisorv1alpha1.ObjectReference{Name: "test-cluster"}
+				cluster := newTestCluster()
+				cluster.Namespace = namespace
+				objects = append(objects, template, cluster)
+			}
+			tokens := &fakeTokenProvider{token: &RegistrationToken{Token: "minted-token"}}
+			r := &MachineClaimReconciler{
+				Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(append(objects, claim)...).
+					WithStatusSubresource(claim).Build(),
+				Scheme:                         scheme,
+				TokenProvider:                  tokens,
+				AllowCrossNamespaceClusterRefs: tt.allowCrossNS,
+			}
+
+			_, updated := reconcileClaim(t, r, client.ObjectKeyFromObject(claim))
+			ready := meta.FindStatusCondition(updated.Status.Conditions, ConditionBootstrapReady)
+			if ready == nil {
+				t.Fatalf("Expected a BootstrapReady condition, got %v", updated.Status.Conditions)
+			}
+			if !tt.expectDenied {
+				if ready.Status != metav1.ConditionTrue || tokens.calls != 1 {
+					t.Errorf("Expected the bootstrap config to be rendered, got %v after %d tokens", ready, tokens.calls)
+				}
+				return
+			}
+			if ready.Status != metav1.ConditionFalse || ready.Reason != ReasonCrossNamespaceTemplateRef {
+				t.Errorf("Expected BootstrapReady false with reason %s, got %v", ReasonCrossNamespaceTemplateRef, ready)
+			}
+			if tokens.calls != 0 {
+				t.Errorf("Expected no token minted with another namespace's credentials, got %d", tokens.calls)
+			}
+			secret := &corev1.Secret{}
+			err := r.Get(context.Background(), types.NamespacedName{Name: bootstrapSecretName(claim), Namespace: "team-a"}, secret)
+			if !apierrors.IsNotFound(err) {
+				t.Errorf("Expected no bootstrap secret, got %v", err)
+			}
+		})
+	}
+}
+
+func TestMachineClaimReconciler_handleDeletion(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = hypervisorv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+
+	const deleteTask = "UPID:pve1:0000A1B2:00C3D4E5:67890ABC:qmdestroy:200:root@pam:"
+
+	tests := []struct {
+		name            string
+		recordedTask    string
+		deleteTaskID    string
+		waitErr         error
+		expectDeletes   int
+		expectRequeue   bool
+		expectError     bool
+		expectFinalizer bool
+		expectTask      string
+		// clusterRef is recorded in the claim's status
+		clusterRef      *hypervisorv1alpha1.ObjectReference
+		withoutTemplate bool
+		withoutCluster  bool
+	}{
+		{
+			name:          "delete completes",
+			deleteTaskID:  deleteTask,
+			expectDeletes: 1,
+		},
+		{
+			name:            "delete in progress",
+			deleteTaskID:    deleteTask,
+			waitErr:         provider.ErrTaskTimeout,
+			expectDeletes:   1,
+			expectRequeue:   true,
+			expectFinalizer: true,
+			expectTask:      deleteTask,
+		},
+		{
+			name:         "recorded task completes without deleting again",
+			recordedTask: deleteTask,
+		},
+		{
+			name:          "VM already gone",
+			expectDeletes: 1,
+		},
+		{
+			name:            "delete task fails",
+			deleteTaskID:    deleteTask,
+			waitErr:         errors.New("can't lock file"),
+			expectDeletes:   1,
+			expectError:     true,
+			expectFinalizer: true,
+		},
+		{
+			name:            "template gone, VM deleted on the recorded cluster",
+			deleteTaskID:    deleteTask,
+			expectDeletes:   1,
+			clusterRef:      &hypervisorv1alpha1.ObjectReference{Name: "test-cluster", Namespace: "default"},
+			withoutTemplate: true,
+		},
+		{
+			name:            "template gone without a recorded cluster",
+			withoutTemplate: true,
+		},
+		{
+			name:           "cluster gone",
+			clusterRef:     &hypervisorv1alpha1.ObjectReference{Name: "test-cluster", Namespace: "default"},
+			withoutCluster: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claim := newTestClaim()
+			claim.Finalizers = []string{MachineClaimFinalizer}
+			claim.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+			claim.Status.VMRef = &hypervisorv1alpha1.VMReference{Node: "pve1", ID: 200}
+			claim.Status.DeleteTaskID = tt.recordedTask
+			claim.Status.ClusterRef = tt.clusterRef
+
+			template := newRunnerTemplate()
+			template.Spec.HypervisorClusterRef = hypervisorv1alpha1.ObjectReference{Name: "test-cluster"}
+			objects := []client.Object{claim, newTestCredentialsSecret()}
+			if !tt.withoutTemplate {
+				objects = append(objects, template)
+			}
+			if !tt.withoutCluster {
+				objects = append(objects, newTestCluster())
+			}
+
+			deletes := 0
+			var waitedTask string
+			mockClient := &provider.MockHypervisorClient{
+				DeleteVMFunc: func(_ context.Context, ref provider.VMRef) (string, error) {
+					deletes++
+					if ref.Node != "pve1" || ref.ID != 200 {
+						t.Errorf("unexpected VM ref %+v", ref)
+					}
+					return tt.deleteTaskID, nil
+				},
+				WaitForTaskFunc: func(_ context.Context, _, taskID string, _ time.Duration) error {
+					waitedTask = taskID
+					return tt.waitErr
+				},
+			}
+
+			k8sClient := fake.NewClientBuilder().WithScheme(scheme).
+				WithObjects(objects...).
+				WithStatusSubresource(claim).Build()
+			r := &MachineClaimReconciler{
+				Client:          k8sClient,
+				Scheme:          scheme,
+				ProviderFactory: provider.NewMockClientFactoryWithClient(mockClient),
+			}
+
+			result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: claim.Name, Namespace: claim.Namespace}})
+			if (err != nil) != tt.expectError {
+				t.Fatalf("Reconcile() error = %v, expectError %v", err, tt.expectError)
+			}
+			if deletes != tt.expectDeletes {
+				t.Errorf("expected %d DeleteVM calls, got %d", tt.expectDeletes, deletes)
+			}
+			if tt.recordedTask != "" && waitedTask != tt.recordedTask {
+				t.Errorf("expected to wait for task %q, got %q", tt.recordedTask, waitedTask)
+			}
+			if (result.RequeueAfter == VMDeleteRequeueInterval) != tt.expectRequeue {
+				t.Errorf("unexpected requeue %v", result.RequeueAfter)
+			}
+
+			updated := &hypervisorv1alpha1.MachineClaim{}
+			err = k8sClient.Get(context.Background(), types.NamespacedName{Name: claim.Name, Namespace: claim.Namespace}, updated)
+			if !tt.expectFinalizer {
+				if !apierrors.IsNotFound(err) {
+					t.Errorf("expected claim to be gone after finalizer removal, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to get claim: %v", err)
+			}
+			if !slices.Contains(updated.Finalizers, MachineClaimFinalizer) {
+				t.Error("expected finalizer to be kept")
+			}
+			if updated.Status.DeleteTaskID != tt.expectTask {
+				t.Errorf("expected delete task %q, got %q", tt.expectTask, updated.Status.DeleteTaskID)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// clonesInFlight is the number of clones holding one of a cluster's clone slots
+	clonesInFlight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "hyperfleet_cluster_clones_in_flight",
+		Help: "Clone operations currently running on a hypervisor cluster.",
+	}, []string{"namespace", "cluster"})
+
+	// clonesWaiting is the number of clones queued for a cluster's clone slot
+	clonesWaiting = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "hyperfleet_cluster_clones_waiting",
+		Help: "Clone operations waiting for a free clone slot on a hypervisor cluster.",
+	}, []string{"namespace", "cluster"})
+
+	// cloneQueueWait is how long clones waited for a slot before starting
+	cloneQueueWait = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "hyperfleet_cluster_clone_queue_wait_seconds",
+		Help:    "Time clone operations waited for a free clone slot on a hypervisor cluster.",
+		Buckets: []float64{0.01, 0.1, 1, 5, 15, 30, 60, 120, 300, 600},
+	}, []string{"namespace", "cluster"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(clonesInFlight, clonesWaiting, cloneQueueWait)
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	hypervisorv1alpha1 "github.com/codihuston/hyperfleet-operator/api/v1alpha1"
+	"github.com/codihuston/hyperfleet-operator/internal/provider"
+)
+
+// interfaceNamePrefix prefixes the index of a VM network interface in its device name, e.g. "net0"
+const interfaceNamePrefix = "net"
+
+// interfaceIndex returns the index of a network interface device name
+func interfaceIndex(name string) (int, bool) {
+	suffix, ok := strings.CutPrefix(name, interfaceNamePrefix)
+	if !ok {
+		return 0, false
+	}
+	index, err := strconv.Atoi(suffix)
+	return index, err == nil && index >= 0
+}
+
+// interfaceName returns the device name of the network interface with the given index
+func interfaceName(index int) string {
+	return fmt.Sprintf("%s%d", interfaceNamePrefix, index)
+}
+
+// networkBridges resolves the bridge each of a VM's network interfaces belongs on, keyed by
+// interface index. The primary interface defaults to the cluster's DefaultNetwork; interfaces
+// without a bridge from either the template or the cluster keep the one they were cloned with.
+func networkBridges(template *hypervisorv1alpha1.HypervisorMachineTemplate, cluster *hypervisorv1alpha1.HypervisorCluster) map[int]string {
+	bridges := make(map[int]string)
+	if cluster.Spec.DefaultNetwork != "" {
+		bridges[primaryInterfaceIndex] = cluster.Spec.DefaultNetwork
+	}
+	for _, spec := range template.Spec.Network.Interfaces {
+		if index, ok := interfaceIndex(spec.Name); ok && spec.Bridge != "" {
+			bridges[index] = spec.Bridge
+		}
+	}
+	return bridges
+}
+
+// reconcileVMNetworkBridges moves network interfaces that were attached to another bridge, e.g.
+// by hand or by a clone predating the bridge setting, back to the bridge they belong on
+func reconcileVMNetworkBridges(ctx context.Context, hypervisorClient provider.HypervisorClient, claim *hypervisorv1alpha1.MachineClaim,
+	template *hypervisorv1alpha1.HypervisorMachineTemplate, cluster *hypervisorv1alpha1.HypervisorCluster) error {
+	desired := networkBridges(template, cluster)
+	if len(desired) == 0 {
+		return nil
+	}
+
+	ref := provider.VMRef{Node: claim.Status.VMRef.Node, ID: claim.Status.VMRef.ID}
+	current, err := hypervisorClient.GetVMNetworkBridges(ctx, ref)
+	if err != nil {
+		return err
+	}
+
+	// Interfaces the VM lacks are left alone; the clone already fails for those the template lists
+	drifted := make(map[int]string)
+	for index, bridge := range desired {
+		if attached, ok := current[index]; ok && attached != bridge {
+			drifted[index] = bridge
+		}
+	}
+	if len(drifted) == 0 {
+		return nil
+	}
+
+	if err := hypervisorClient.SetVMNetworkBridges(ctx, ref, drifted); err != nil {
+		return err
+	}
+	logf.FromContext(ctx).Info("Corrected VM network bridges", "vm", ref.ID, "node", ref.Node, "bridges", drifted)
+	return nil
+}
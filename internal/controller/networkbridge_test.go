@@ -0,0 +1,96 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"maps"
+	"testing"
+
+	hypervisorv1alpha1 "github.com/codihuston/hyperfleet-operator/api/v1alpha1"
+	"github.com/codihuston/hyperfleet-operator/internal/provider"
+)
+
+func TestReconcileVMNetworkBridges(t *testing.T) {
+	tests := []struct {
+		name           string
+		defaultNetwork string
+		interfaces     []hypervisorv1alpha1.NetworkInterfaceSpec
+		current        map[int]string
+		expectSet      map[int]string
+	}{
+		{
+			name:           "bridges match",
+			defaultNetwork: "vmbr0",
+			current:        map[int]string{0: "vmbr0", 1: "vmbr9"},
+		},
+		{
+			name:           "primary interface moved off the default network",
+			defaultNetwork: "vmbr0",
+			current:        map[int]string{0: "vmbr1", 1: "vmbr9"},
+			expectSet:      map[int]string{0: "vmbr0"},
+		},
+		{
+			name:           "template bridge takes precedence",
+			defaultNetwork: "vmbr0",
+			interfaces:     []hypervisorv1alpha1.NetworkInterfaceSpec{{Name: "net0", Bridge: "vmbr2"}, {Name: "net1", Bridge: "vmbr3"}},
+			current:        map[int]string{0: "vmbr0", 1: "vmbr3"},
+			expectSet:      map[int]string{0: "vmbr2"},
+		},
+		{
+			name:       "interfaces the VM lacks are left alone",
+			interfaces: []hypervisorv1alpha1.NetworkInterfaceSpec{{Name: "net1", Bridge: "vmbr3"}},
+			current:    map[int]string{0: "vmbr0"},
+		},
+		{
+			name:    "no bridges configured",
+			current: map[int]string{0: "vmbr1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claim := newTestClaim()
+			claim.Status.VMRef = &hypervisorv1alpha1.VMReference{Node: "pve1", ID: 200}
+			template := newRunnerTemplate()
+			template.Spec.Network.Interfaces = tt.interfaces
+			cluster := newTestCluster()
+			cluster.Spec.DefaultNetwork = tt.defaultNetwork
+
+			var set map[int]string
+			mockClient := &provider.MockHypervisorClient{
+				GetVMNetworkBridgesFunc: func(_ context.Context, ref provider.VMRef) (map[int]string, error) {
+					return tt.current, nil
+				},
+				SetVMNetworkBridgesFunc: func(_ context.Context, ref provider.VMRef, bridges map[int]string) error {
+					if ref.Node != "pve1" || ref.ID != 200 {
+						t.Errorf("unexpected VM ref %+v", ref)
+					}
+					set = bridges
+					return nil
+				},
+			}
+
+			if err := reconcileVMNetworkBridges(context.Background(), mockClient, claim, template, cluster); err != nil {
+				t.Fatalf("reconcileVMNetworkBridges() error = %v", err)
+			}
+			if !maps.Equal(set, tt.expectSet) {
+				t.Errorf("expected bridges %v to be set, got %v", tt.expectSet, set)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	hypervisorv1alpha1 "github.com/codihuston/hyperfleet-operator/api/v1alpha1"
+	"github.com/codihuston/hyperfleet-operator/internal/provider"
+)
+
+// templateMemoryMinMiB returns the template's MemoryMin in MiB, zero when it does not balloon
+func templateMemoryMinMiB(template *hypervisorv1alpha1.HypervisorMachineTemplate) (int64, error) {
+	if template.Spec.Resources.MemoryMin == "" {
+		return 0, nil
+	}
+	memory, err := resource.ParseQuantity(template.Spec.Resources.MemoryMin)
+	if err != nil {
+		return 0, fmt.Errorf("invalid memoryMin %q in template %s: %w", template.Spec.Resources.MemoryMin, template.Name, err)
+	}
+	return memory.Value() / bytesPerMiB, nil
+}
+
+// templateCPULimit returns the template's CPULimit in CPUs, zero when unlimited
+func templateCPULimit(template *hypervisorv1alpha1.HypervisorMachineTemplate) float64 {
+	if template.Spec.Resources.CPULimit == nil {
+		return 0
+	}
+	return template.Spec.Resources.CPULimit.AsApproximateFloat64()
+}
+
+// validateResourceLimits checks the template's minimums and limits fit within its allocation
+func validateResourceLimits(template *hypervisorv1alpha1.HypervisorMachineTemplate) error {
+	resources := template.Spec.Resources
+	if resources.MemoryMin != "" {
+		memoryMin, err := templateMemoryMinMiB(template)
+		if err != nil {
+			return err
+		}
+		desired, err := desiredVMResources(template)
+		if err != nil {
+			return err
+		}
+		if memoryMin > desired.MemoryMiB {
+			return fmt.Errorf("memoryMin %s exceeds memory %s", resources.MemoryMin, resources.Memory)
+		}
+	}
+	if limit := resources.CPULimit; limit != nil {
+		if limit.Sign() <= 0 || templateCPULimit(template) > float64(resources.CPU) {
+			return fmt.Errorf("cpuLimit %s must be above zero and at most the %d CPUs", limit, resources.CPU)
+		}
+	}
+	return nil
+}
+
+// placementMemoryMiB returns the memory a VM of the template reserves on its node: MemoryMin
+// when its memory balloons, since the hypervisor can reclaim the rest, and Memory otherwise
+func placementMemoryMiB(template *hypervisorv1alpha1.HypervisorMachineTemplate) (int64, error) {
+	memoryMin, err := templateMemoryMinMiB(template)
+	if err != nil || memoryMin > 0 {
+		return memoryMin, err
+	}
+	desired, err := desiredVMResources(template)
+	if err != nil {
+		return 0, err
+	}
+	return desired.MemoryMiB, nil
+}
+
+// selectNode returns the online node with the most free memory among those with memoryMiB
+// free; the first listed wins a tie. A node whose memory is unknown is not ruled out, but any
+// node known to fit is preferred.
+func selectNode(nodes []provider.NodeInfo, memoryMiB int64) (string, error) {
+	var best *provider.NodeInfo
+	for i := range nodes {
+		node := &nodes[i]
+		if !node.Online || (node.MemoryMiB > 0 && node.FreeMemoryMiB < memoryMiB) {
+			continue
+		}
+		if best == nil || node.FreeMemoryMiB > best.FreeMemoryMiB {
+			best = node
+		}
+	}
+	if best == nil {
+		return "", fmt.Errorf("no online node has %dMiB of memory free", memoryMiB)
+	}
+	return best.Name, nil
+}
+
+// placeVM selects the node a VM of the template is cloned onto: of the cluster's nodes the
+// template can be cloned onto, the one with the most free memory that fits the VM's
+// placementMemoryMiB
+func placeVM(ctx context.Context, hypervisorClient provider.HypervisorClient, template *hypervisorv1alpha1.HypervisorMachineTemplate,
+	cluster *hypervisorv1alpha1.HypervisorCluster) (string, error) {
+	memoryMiB, err := placementMemoryMiB(template)
+	if err != nil {
+		return "", err
+	}
+	targets, err := hypervisorClient.GetTemplateCloneNodes(ctx, template.Spec.Template.Proxmox.TemplateID)
+	if err != nil {
+		return "", err
+	}
+	if len(targets) == 0 {
+		targets = []string{template.Status.TemplateNode}
+	}
+	nodes, err := hypervisorClient.ListNodes(ctx)
+	if err != nil {
+		return "", err
+	}
+	candidates := slices.DeleteFunc(listedNodes(cluster.Spec.Nodes, nodes), func(node provider.NodeInfo) bool {
+		return !slices.Contains(targets, node.Name)
+	})
+	return selectNode(candidates, memoryMiB)
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/codihuston/hyperfleet-operator/internal/provider"
+)
+
+func TestSelectNodeUsesMinimumMemory(t *testing.T) {
+	nodes := []provider.NodeInfo{
+		{Name: "pve1", Online: true, MemoryMiB: 65536, FreeMemoryMiB: 3072},
+		{Name: "pve2", Online: false, MemoryMiB: 65536, FreeMemoryMiB: 65536},
+		{Name: "pve3", Online: true, MemoryMiB: 65536, FreeMemoryMiB: 2048},
+	}
+
+	template := newRunnerTemplate() // 4Gi of memory
+	need, err := placementMemoryMiB(template)
+	if err != nil || need != 4096 {
+		t.Fatalf("Expected placement to reserve the full 4096MiB, got %d (%v)", need, err)
+	}
+	if node, err := selectNode(nodes, need); err == nil {
+		t.Errorf("Expected no online node to fit 4096MiB, got %s", node)
+	}
+
+	// Ballooning VMs only need their minimum free on the node
+	template.Spec.Resources.MemoryMin = "2Gi"
+	need, err = placementMemoryMiB(template)
+	if err != nil || need != 2048 {
+		t.Fatalf("Expected placement to reserve the 2048MiB minimum, got %d (%v)", need, err)
+	}
+	node, err := selectNode(nodes, need)
+	if err != nil || node != "pve1" {
+		t.Errorf("Expected the online node with the most free memory, pve1, got %q (%v)", node, err)
+	}
+
+	// Ties go to the first listed node
+	nodes[2].FreeMemoryMiB = 3072
+	if node, err := selectNode(nodes, need); err != nil || node != "pve1" {
+		t.Errorf("Expected pve1 to win the tie, got %q (%v)", node, err)
+	}
+}
+
+func TestValidateResourceLimits(t *testing.T) {
+	tests := []struct {
+		name        string
+		memoryMin   string
+		cpuLimit    string
+		expectError string
+	}{
+		{name: "no limits"},
+		{name: "within the allocation", memoryMin: "2Gi", cpuLimit: "1500m"},
+		{name: "equal to the allocation", memoryMin: "4Gi", cpuLimit: "2"},
+		{name: "minimum above memory", memoryMin: "8Gi", expectError: "memoryMin 8Gi exceeds memory 4Gi"},
+		{name: "limit above CPUs", cpuLimit: "3", expectError: "at most the 2 CPUs"},
+		{name: "zero limit", cpuLimit: "0", expectError: "must be above zero"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template := newRunnerTemplate() // 2 CPUs and 4Gi of memory
+			template.Spec.Resources.MemoryMin = tt.memoryMin
+			if tt.cpuLimit != "" {
+				limit := resource.MustParse(tt.cpuLimit)
+				template.Spec.Resources.CPULimit = &limit
+			}
+
+			err := validateResourceLimits(template)
+			if tt.expectError == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+				t.Errorf("Expected error containing %q, got %v", tt.expectError, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hypervisorv1alpha1 "github.com/codihuston/hyperfleet-operator/api/v1alpha1"
+	"github.com/codihuston/hyperfleet-operator/internal/provider"
+)
+
+// ConditionPoolQuotaExceeded reports whether a claim's VM would exceed its resource pool's quota
+const ConditionPoolQuotaExceeded = "PoolQuotaExceeded"
+
+// poolQuota returns the cluster's quota for a resource pool, nil when the pool is unlimited
+func poolQuota(cluster *hypervisorv1alpha1.HypervisorCluster, pool string) *hypervisorv1alpha1.PoolQuota {
+	for i := range cluster.Spec.PoolQuotas {
+		if cluster.Spec.PoolQuotas[i].Pool == pool {
+			return &cluster.Spec.PoolQuotas[i]
+		}
+	}
+	return nil
+}
+
+// describeQuotaExcess lists the quota limits the pool would exceed once a VM with the desired
+// resources is added to its current usage, empty when the VM fits
+func describeQuotaExcess(quota *hypervisorv1alpha1.PoolQuota, usage *provider.PoolUsage, desired provider.VMResources) string {
+	var excess []string
+	if quota.MaxVMs > 0 && usage.VMs+1 > int(quota.MaxVMs) {
+		excess = append(excess, fmt.Sprintf("VMs %d/%d", usage.VMs, quota.MaxVMs))
+	}
+	if quota.MaxCPUs > 0 && usage.CPUs+desired.CPUs > int(quota.MaxCPUs) {
+		excess = append(excess, fmt.Sprintf("CPUs %d+%d/%d", usage.CPUs, desired.CPUs, quota.MaxCPUs))
+	}
+	if quota.MaxMemory != nil {
+		maxMemoryMiB := quota.MaxMemory.Value() / bytesPerMiB
+		if usage.MemoryMiB+desired.MemoryMiB > maxMemoryMiB {
+			excess = append(excess, fmt.Sprintf("memory %d+%dMiB/%dMiB", usage.MemoryMiB, desired.MemoryMiB, maxMemoryMiB))
+		}
+	}
+	return strings.Join(excess, "; ")
+}
+
+// checkPoolQuota reports whether the claim's VM fits in its resource pool's quota and records
+// the result in the PoolQuotaExceeded condition. A clone must not be attempted when it returns false.
+func checkPoolQuota(ctx context.Context, hypervisorClient provider.HypervisorClient, claim *hypervisorv1alpha1.MachineClaim,
+	template *hypervisorv1alpha1.HypervisorMachineTemplate, cluster *hypervisorv1alpha1.HypervisorCluster) (bool, error) {
+	pool := clonePool(template, cluster)
+	quota := poolQuota(cluster, pool)
+	if pool == "" || quota == nil {
+		meta.RemoveStatusCondition(&claim.Status.Conditions, ConditionPoolQuotaExceeded)
+		return true, nil
+	}
+
+	desired, err := desiredVMResources(template)
+	if err != nil {
+		return false, err
+	}
+	usage, err := hypervisorClient.GetPoolUsage(ctx, pool)
+	if err != nil {
+		return false, err
+	}
+
+	condition := metav1.Condition{
+		Type:               ConditionPoolQuotaExceeded,
+		Status:             metav1.ConditionFalse,
+		Reason:             "WithinQuota",
+		Message:            fmt.Sprintf("VM fits in the quota of pool %s", pool),
+		ObservedGeneration: claim.Generation,
+	}
+	excess := describeQuotaExcess(quota, usage, desired)
+	if excess != "" {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "QuotaExceeded"
+		condition.Message = fmt.Sprintf("pool %s is full: %s", pool, excess)
+	}
+
+	meta.SetStatusCondition(&claim.Status.Conditions, condition)
+	return excess == "", nil
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hypervisorv1alpha1 "github.com/codihuston/hyperfleet-operator/api/v1alpha1"
+	"github.com/codihuston/hyperfleet-operator/internal/provider"
+)
+
+// newQuotaCluster returns a cluster whose default pool allows 4 VMs, 8 CPUs and 16Gi of memory
+func newQuotaCluster() *hypervisorv1alpha1.HypervisorCluster {
+	maxMemory := resource.MustParse("16Gi")
+	cluster := newTestCluster()
+	cluster.Spec.DefaultPool = "ci"
+	cluster.Spec.PoolQuotas = []hypervisorv1alpha1.PoolQuota{
+		{Pool: "ci", MaxVMs: 4, MaxCPUs: 8, MaxMemory: &maxMemory},
+	}
+	return cluster
+}
+
+func TestCheckPoolQuota(t *testing.T) {
+	// The runner template asks for 2 CPUs and 4096MiB of memory
+	tests := []struct {
+		name      string
+		usage     provider.PoolUsage
+		allowed   bool
+		condition metav1.ConditionStatus
+		reason    string
+	}{
+		{"below quota", provider.PoolUsage{VMs: 1, CPUs: 2, MemoryMiB: 4096}, true, metav1.ConditionFalse, "WithinQuota"},
+		{"clone fills quota", provider.PoolUsage{VMs: 3, CPUs: 6, MemoryMiB: 12288}, true, metav1.ConditionFalse, "WithinQuota"},
+		{"at quota", provider.PoolUsage{VMs: 4, CPUs: 8, MemoryMiB: 16384}, false, metav1.ConditionTrue, "QuotaExceeded"},
+		{"above quota", provider.PoolUsage{VMs: 5, CPUs: 10, MemoryMiB: 20480}, false, metav1.ConditionTrue, "QuotaExceeded"},
+		{"only memory exhausted", provider.PoolUsage{VMs: 1, CPUs: 2, MemoryMiB: 14336}, false, metav1.ConditionTrue, "QuotaExceeded"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requestedPool string
+			hypervisorClient := &provider.MockHypervisorClient{
+				GetPoolUsageFunc: func(ctx context.Context, pool string) (*provider.PoolUsage, error) {
+					requestedPool = pool
+					usage := tt.usage
+					return &usage, nil
+				},
+			}
+			claim := newTestClaim()
+
+			allowed, err := checkPoolQuota(context.Background(), hypervisorClient, claim, newRunnerTemplate(), newQuotaCluster())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if allowed != tt.allowed {
+				t.Errorf("expected allowed=%v, got %v", tt.allowed, allowed)
+			}
+			if requestedPool != "ci" {
+				t.Errorf("expected usage of pool ci, got %q", requestedPool)
+			}
+			condition := meta.FindStatusCondition(claim.Status.Conditions, ConditionPoolQuotaExceeded)
+			if condition == nil || condition.Status != tt.condition || condition.Reason != tt.reason {
+				t.Errorf("unexpected condition: %+v", condition)
+			}
+		})
+	}
+}
+
+func TestCheckPoolQuota_Unlimited(t *testing.T) {
+	hypervisorClient := &provider.MockHypervisorClient{
+		GetPoolUsageFunc: func(ctx context.Context, pool string) (*provider.PoolUsage, error) {
+			t.Errorf("pool usage should not be read for pool %q without a quota", pool)
+			return &provider.PoolUsage{}, nil
+		},
+	}
+
+	// A quota on another pool does not apply, and a stale condition is removed
+	cluster := newQuotaCluster()
+	cluster.Spec.DefaultPool = "builds"
+	claim := newTestClaim()
+	meta.SetStatusCondition(&claim.Status.Conditions, metav1.Condition{
+		Type: ConditionPoolQuotaExceeded, Status: metav1.ConditionTrue, Reason: "QuotaExceeded",
+	})
+
+	allowed, err := checkPoolQuota(context.Background(), hypervisorClient, claim, newRunnerTemplate(), cluster)
+	if err != nil || !allowed {
+		t.Fatalf("expected clone to be allowed, got %v, %v", allowed, err)
+	}
+	if meta.FindStatusCondition(claim.Status.Conditions, ConditionPoolQuotaExceeded) != nil {
+		t.Errorf("expected condition to be removed")
+	}
+
+	// No pool at all is unlimited
+	cluster.Spec.DefaultPool = ""
+	if allowed, err := checkPoolQuota(context.Background(), hypervisorClient, claim, newRunnerTemplate(), cluster); err != nil || !allowed {
+		t.Errorf("expected clone without a pool to be allowed, got %v, %v", allowed, err)
+	}
+}
+
+func TestCheckPoolQuota_UsageError(t *testing.T) {
+	hypervisorClient := &provider.MockHypervisorClient{
+		GetPoolUsageFunc: func(ctx context.Context, pool string) (*provider.PoolUsage, error) {
+			return nil, errors.New("pool does not exist")
+		},
+	}
+
+	allowed, err := checkPoolQuota(context.Background(), hypervisorClient, newTestClaim(), newRunnerTemplate(), newQuotaCluster())
+	if err == nil || allowed {
+		t.Errorf("expected the usage error to block the clone, got %v, %v", allowed, err)
+	}
+}
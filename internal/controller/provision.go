@@ -0,0 +1,129 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	hypervisorv1alpha1 "github.com/codihuston/hyperfleet-operator/api/v1alpha1"
+	"github.com/codihuston/hyperfleet-operator/internal/provider"
+)
+
+const (
+	// ConditionVMProvisioned reports whether a claim's VM has been cloned
+	ConditionVMProvisioned = "VMProvisioned"
+
+	// VMProvisionRequeueInterval is how soon a claim whose VM could not be cloned yet is retried
+	VMProvisionRequeueInterval = 30 * time.Second
+
+	// claimVMIDRangeStart and claimVMIDRangeEnd bound the VM IDs picked for claims' VMs;
+	// Proxmox reserves the IDs below 100
+	claimVMIDRangeStart = 100
+	claimVMIDRangeEnd   = 999999999
+)
+
+// provisionVM clones the claim's VM once its bootstrap config is rendered and its template
+// has been validated, and records it in the claim's VMRef. The VM is placed by placeVM, and its
+// node and ID are recorded in PendingVMRef before cloning, so a clone interrupted or not recorded is retried, and adopted,
+// under the same ID rather than leaving a VM behind.
+func (r *MachineClaimReconciler) provisionVM(ctx context.Context, claim *hypervisorv1alpha1.MachineClaim,
+	template *hypervisorv1alpha1.HypervisorMachineTemplate) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	// The VM must not boot before its runner config exists
+	if !meta.IsStatusConditionTrue(claim.Status.Conditions, ConditionBootstrapReady) {
+		return ctrl.Result{}, nil
+	}
+	// Clones are made from the source template on its node, which validation resolves
+	if !meta.IsStatusConditionTrue(template.Status.Conditions, ConditionTemplateValid) || template.Status.TemplateNode == "" {
+		r.setCondition(claim, ConditionVMProvisioned, metav1.ConditionFalse, "TemplateNotValid",
+			fmt.Sprintf("Waiting for HypervisorMachineTemplate %s to be validated", template.Name))
+		return ctrl.Result{RequeueAfter: VMProvisionRequeueInterval}, nil
+	}
+
+	cluster, err := r.getCluster(ctx, template)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	hypervisorClient, err := newProviderClient(ctx, r.Client, r.ProviderFactory, cluster)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	defer func() {
+		_ = hypervisorClient.Close()
+	}()
+
+	if claim.Status.PendingVMRef == nil {
+		// A pending clone is not re-checked, as the VM it may have created already counts
+		// against the quota
+		fits, err := checkPoolQuota(ctx, hypervisorClient, claim, template, cluster)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if !fits {
+			r.setCondition(claim, ConditionVMProvisioned, metav1.ConditionFalse, "PoolQuotaExceeded",
+				"Waiting for room in the resource pool's quota")
+			return ctrl.Result{RequeueAfter: VMProvisionRequeueInterval}, nil
+		}
+
+		node, err := placeVM(ctx, hypervisorClient, template, cluster)
+		if err != nil {
+			r.setCondition(claim, ConditionVMProvisioned, metav1.ConditionFalse, "NoNodeAvailable", err.Error())
+			return ctrl.Result{RequeueAfter: VMProvisionRequeueInterval}, nil
+		}
+
+		id, err := hypervisorClient.NextAvailableVMIDInPool(ctx, clonePool(template, cluster), claimVMIDRangeStart, claimVMIDRangeEnd)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		claim.Status.PendingVMRef = &hypervisorv1alpha1.VMReference{Node: node, ID: id}
+		claim.Status.ClusterRef = &hypervisorv1alpha1.ObjectReference{Name: cluster.Name, Namespace: cluster.Namespace}
+		if err := r.Status().Update(ctx, claim); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to record VM ID %d: %w", id, err)
+		}
+	}
+	pending := claim.Status.PendingVMRef
+
+	req, err := newClaimCloneRequest(ctx, r.Client, claim, template, cluster, template.Status.TemplateNode, pending.ID, r.InstanceID)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	req.TargetNode = pending.Node
+	ref, err := r.CloneLimiter.CloneVM(ctx, hypervisorClient, cluster, req)
+	if provider.IsVMConflict(err) {
+		// Another guest took the ID, so the next attempt picks a new one
+		claim.Status.PendingVMRef = nil
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	log.Info("Cloned VM", "vm", ref.ID, "node", ref.Node, "template", template.Name)
+	claim.Status.VMRef = &hypervisorv1alpha1.VMReference{Node: ref.Node, ID: ref.ID}
+	claim.Status.PendingVMRef = nil
+	claim.Status.AppliedTags = req.Tags
+	r.setCondition(claim, ConditionVMProvisioned, metav1.ConditionTrue, "VMCloned",
+		fmt.Sprintf("VM %d was cloned on node %s", ref.ID, ref.Node))
+	return ctrl.Result{}, nil
+}
@@ -0,0 +1,616 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hypervisorv1alpha1 "github.com/codihuston/hyperfleet-operator/api/v1alpha1"
+	"github.com/codihuston/hyperfleet-operator/internal/provider"
+)
+
+// newProvisioningClaim returns a claim whose bootstrap config is rendered, and a validated
+// Proxmox template on newTestCluster, so reconciling the claim clones its VM
+func newProvisioningClaim() (*hypervisorv1alpha1.MachineClaim, *hypervisorv1alpha1.HypervisorMachineTemplate, *corev1.Secret) {
+	claim := newTestClaim()
+	claim.Finalizers = []string{MachineClaimFinalizer}
+	claim.CreationTimestamp = metav1.NewTime(time.Date(2025, 3, 14, 9, 26, 53, 0, time.UTC))
+
+	template := newRunnerTemplate()
+	template.Spec.HypervisorClusterRef = hypervisorv1alpha1.ObjectReference{Name: "test-cluster"}
+	template.Spec.Template.Proxmox = &hypervisorv1alpha1.ProxmoxTemplateSpec{TemplateID: 9000}
+	template.Status.TemplateNode = "pve1"
+	template.Status.Conditions = []metav1.Condition{{
+		Type:   ConditionTemplateValid,
+		Status: metav1.ConditionTrue,
+		Reason: "ValidationSucceeded",
+	}}
+
+	// An existing bootstrap Secret is reused, so no token provider is needed
+	bootstrap := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: bootstrapSecretName(claim), Namespace: claim.Namespace},
+	}
+	return claim, template, bootstrap
+}
+
+// listProvisioningNodes lists newProvisioningClaim's template node, pve1, with 16GiB of memory free
+func listProvisioningNodes(context.Context) ([]provider.NodeInfo, error) {
+	return []provider.NodeInfo{{Name: "pve1", Online: true, MemoryMiB: 65536, FreeMemoryMiB: 16384}}, nil
+}
+
+// reconcileClaim reconciles the claim and returns it as stored afterwards
+func reconcileClaim(t *testing.T, r *MachineClaimReconciler, key types.NamespacedName) (ctrl.Result, *hypervisorv1alpha1.MachineClaim) {
+	t.Helper()
+	result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key})
+	if err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	updated := &hypervisorv1alpha1.MachineClaim{}
+	if err := r.Get(context.Background(), key, updated); err != nil {
+		t.Fatalf("Failed to get claim: %v", err)
+	}
+	return result, updated
+}
+
+func TestMachineClaimReconciler_provisionVM(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = hypervisorv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+
+	newReconciler := func(mockClient *provider.MockHypervisorClient, objects ...client.Object) *MachineClaimReconciler {
+		objects = append(objects, newTestCluster(), newTestCredentialsSecret())
+		return &MachineClaimReconciler{
+			Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).
+				WithStatusSubresource(&hypervisorv1alpha1.MachineClaim{}).Build(),
+			Scheme:          scheme,
+			ProviderFactory: provider.NewMockClientFactoryWithClient(mockClient),
+			CloneLimiter:    NewCloneLimiter(),
+			InstanceID:      "ci-east",
+		}
+	}
+
+	t.Run("clones the VM and records it", func(t *testing.T) {
+		claim, template, bootstrap := newProvisioningClaim()
+		var clones []*provider.CloneRequest
+		mockClient := &provider.MockHypervisorClient{
+			ListNodesFunc: listProvisioningNodes,
+			NextAvailableVMIDInPoolFunc: func(_ context.Context, _ string, rangeStart, rangeEnd int) (int, error) {
+				if rangeStart != claimVMIDRangeStart || rangeEnd != claimVMIDRangeEnd {
+					t.Errorf("unexpected VM ID range %d-%d", rangeStart, rangeEnd)
+				}
+				return 105, nil
+			},
+			CloneVMFunc: func(_ context.Context, req *provider.CloneRequest) (*provider.VMRef, error) {
+				clones = append(clones, req)
+				return &provider.VMRef{Node: req.SourceNode, ID: req.NewID}, nil
+			},
+			ListVMsFunc: func(_ context.Context, _ string) ([]provider.VMInfo, error) {
+				return []provider.VMInfo{{Ref: provider.VMRef{Node: "pve1", ID: 105}}}, nil
+			},
+		}
+		r := newReconciler(mockClient, claim, template, bootstrap)
+
+		result, updated := reconcileClaim(t, r, client.ObjectKeyFromObject(claim))
+		if len(clones) != 1 {
+			t.Fatalf("expected one clone, got %d", len(clones))
+		}
+		req := clones[0]
+		if req.SourceNode != "pve1" || req.SourceID != 9000 || req.NewID != 105 || req.Name != runnerName(claim) {
+			t.Errorf("unexpected clone request %+v", req)
+		}
+		if req.Description != vmDescription(claim) || req.SMBIOSUUID != claimSMBIOSUUID(claim) {
+			t.Errorf("expected the clone to identify the claim, got %+v", req)
+		}
+		if !slices.Contains(req.Tags, provider.InstanceTag("ci-east")) {
+			t.Errorf("expected the clone to carry the instance tag, got %v", req.Tags)
+		}
+
+		if ref := updated.Status.VMRef; ref == nil || ref.Node != "pve1" || ref.ID != 105 {
+			t.Errorf("expected VMRef pve1/105, got %+v", ref)
+		}
+		if updated.Status.PendingVMRef != nil {
+			t.Errorf("expected no pending VM, got %+v", updated.Status.PendingVMRef)
+		}
+		if ref := updated.Status.ClusterRef; ref == nil || ref.Name != "test-cluster" || ref.Namespace != "default" {
+			t.Errorf("expected the VM's cluster default/test-cluster to be recorded, got %+v", ref)
+		}
+		if !meta.IsStatusConditionTrue(updated.Status.Conditions, ConditionVMProvisioned) {
+			t.Errorf("expected VMProvisioned true, got %v", updated.Status.Conditions)
+		}
+		if result.RequeueAfter != 0 {
+			t.Errorf("expected no requeue, got %v", result.RequeueAfter)
+		}
+
+		// A provisioned claim is not cloned again
+		reconcileClaim(t, r, client.ObjectKeyFromObject(claim))
+		if len(clones) != 1 {
+			t.Errorf("expected no further clones, got %d", len(clones))
+		}
+	})
+
+	t.Run("a new clone has no resource drift", func(t *testing.T) {
+		claim, template, bootstrap := newProvisioningClaim()
+		template.Spec.Resources.Disk = "50G"
+		var clone *provider.CloneRequest
+		mockClient := &provider.MockHypervisorClient{
+			ListNodesFunc: listProvisioningNodes,
+			CloneVMFunc: func(_ context.Context, req *provider.CloneRequest) (*provider.VMRef, error) {
+				clone = req
+				return &provider.VMRef{Node: req.SourceNode, ID: req.NewID}, nil
+			},
+			// The VM reports whatever the clone configured
+			GetVMFunc: func(_ context.Context, ref provider.VMRef) (*provider.VMInfo, error) {
+				return &provider.VMInfo{Ref: ref, PowerState: provider.PowerStateRunning,
+					Resources: provider.VMResources{CPUs: clone.CPUs, MemoryMiB: clone.MemoryMiB}}, nil
+			},
+			ListVMsFunc: func(_ context.Context, _ string) ([]provider.VMInfo, error) {
+				return []provider.VMInfo{{Ref: provider.VMRef{Node: "pve1", ID: clone.NewID}}}, nil
+			},
+		}
+		r := newReconciler(mockClient, claim, template, bootstrap)
+
+		reconcileClaim(t, r, client.ObjectKeyFromObject(claim))
+		if clone == nil {
+			t.Fatal("expected the VM to be cloned")
+		}
+		if clone.BootDiskGB != 50 {
+			t.Errorf("expected the boot disk to grow to 50G, got %dG", clone.BootDiskGB)
+		}
+
+		_, updated := reconcileClaim(t, r, client.ObjectKeyFromObject(claim))
+		if !meta.IsStatusConditionTrue(updated.Status.Conditions, ConditionResourcesInSync) {
+			t.Errorf("expected ResourcesInSync true, got %v", updated.Status.Conditions)
+		}
+	})
+
+	t.Run("waits for the template to be validated", func(t *testing.T) {
+		claim, template, bootstrap := newProvisioningClaim()
+		template.Status = hypervisorv1alpha1.HypervisorMachineTemplateStatus{}
+		clones := 0
+		mockClient := &provider.MockHypervisorClient{
+			ListNodesFunc: listProvisioningNodes,
+			CloneVMFunc: func(_ context.Context, _ *provider.CloneRequest) (*provider.VMRef, error) {
+				clones++
+				return nil, errors.New("unexpected clone")
+			},
+		}
+		r := newReconciler(mockClient, claim, template, bootstrap)
+
+		result, updated := reconcileClaim(t, r, client.ObjectKeyFromObject(claim))
+		if clones != 0 || updated.Status.VMRef != nil {
+			t.Errorf("expected no clone, got %d clones and VMRef %+v", clones, updated.Status.VMRef)
+		}
+		provisioned := meta.FindStatusCondition(updated.Status.Conditions, ConditionVMProvisioned)
+		if provisioned == nil || provisioned.Reason != "TemplateNotValid" {
+			t.Errorf("expected VMProvisioned reason TemplateNotValid, got %v", provisioned)
+		}
+		if result.RequeueAfter != VMProvisionRequeueInterval {
+			t.Errorf("expected requeue after %v, got %v", VMProvisionRequeueInterval, result.RequeueAfter)
+		}
+	})
+
+	t.Run("waits for the bootstrap config", func(t *testing.T) {
+		claim, template, _ := newProvisioningClaim()
+		clones := 0
+		mockClient := &provider.MockHypervisorClient{
+			ListNodesFunc: listProvisioningNodes,
+			CloneVMFunc: func(_ context.Context, _ *provider.CloneRequest) (*provider.VMRef, error) {
+				clones++
+				return nil, errors.New("unexpected clone")
+			},
+		}
+		r := newReconciler(mockClient, claim, template)
+
+		_, updated := reconcileClaim(t, r, client.ObjectKeyFromObject(claim))
+		if clones != 0 || updated.Status.VMRef != nil {
+			t.Errorf("expected no clone without a runner config, got %d clones and VMRef %+v", clones, updated.Status.VMRef)
+		}
+	})
+
+	t.Run("retries a failed clone with the same VM ID", func(t *testing.T) {
+		claim, template, bootstrap := newProvisioningClaim()
+		idRequests := 0
+		var cloneIDs []int
+		cloneErr := fmt.Errorf("clone task failed")
+		mockClient := &provider.MockHypervisorClient{
+			ListNodesFunc: listProvisioningNodes,
+			NextAvailableVMIDInPoolFunc: func(_ context.Context, _ string, rangeStart, _ int) (int, error) {
+				idRequests++
+				return rangeStart + idRequests, nil
+			},
+			CloneVMFunc: func(_ context.Context, req *provider.CloneRequest) (*provider.VMRef, error) {
+				cloneIDs = append(cloneIDs, req.NewID)
+				if cloneErr != nil {
+					return nil, cloneErr
+				}
+				return &provider.VMRef{Node: req.SourceNode, ID: req.NewID}, nil
+			},
+		}
+		r := newReconciler(mockClient, claim, template, bootstrap)
+
+		result, updated := reconcileClaim(t, r, client.ObjectKeyFromObject(claim))
+		if pending := updated.Status.PendingVMRef; pending == nil || pending.ID != 101 || updated.Status.VMRef != nil {
+			t.Fatalf("expected VM 101 pending and no VMRef, got %+v and %+v", pending, updated.Status.VMRef)
+		}
+		provisioned := meta.FindStatusCondition(updated.Status.Conditions, ConditionVMProvisioned)
+		if provisioned == nil || provisioned.Reason != "CloneFailed" {
+			t.Errorf("expected VMProvisioned reason CloneFailed, got %v", provisioned)
+		}
+		if result.RequeueAfter != VMProvisionRequeueInterval {
+			t.Errorf("expected requeue after %v, got %v", VMProvisionRequeueInterval, result.RequeueAfter)
+		}
+
+		cloneErr = nil
+		_, updated = reconcileClaim(t, r, client.ObjectKeyFromObject(claim))
+		if !slices.Equal(cloneIDs, []int{101, 101}) || idRequests != 1 {
+			t.Errorf("expected both clones to use VM 101 from one ID request, got %v after %d requests", cloneIDs, idRequests)
+		}
+		if ref := updated.Status.VMRef; ref == nil || ref.ID != 101 {
+			t.Errorf("expected VMRef 101, got %+v", ref)
+		}
+	})
+
+	t.Run("places the VM by its minimum memory", func(t *testing.T) {
+		claim, template, bootstrap := newProvisioningClaim()
+		template.Spec.Resources.MemoryMin = "2Gi"
+		var clones []*provider.CloneRequest
+		mockClient := &provider.MockHypervisorClient{
+			// The template is on shared storage, so it can be cloned onto any node
+			GetTemplateCloneNodesFunc: func(_ context.Context, _ int) ([]string, error) {
+				return []string{"pve1", "pve2", "pve3"}, nil
+			},
+			ListNodesFunc: func(context.Context) ([]provider.NodeInfo, error) {
+				return []provider.NodeInfo{
+					{Name: "pve1", Online: true, MemoryMiB: 65536, FreeMemoryMiB: 1024},
+					{Name: "pve2", Online: true, MemoryMiB: 65536, FreeMemoryMiB: 3072},
+					{Name: "pve3", Online: false},
+				}, nil
+			},
+			CloneVMFunc: func(_ context.Context, req *provider.CloneRequest) (*provider.VMRef, error) {
+				clones = append(clones, req)
+				return &provider.VMRef{Node: req.TargetNode, ID: req.NewID}, nil
+			},
+		}
+		r := newReconciler(mockClient, claim, template, bootstrap)
+
+		_, updated := reconcileClaim(t, r, client.ObjectKeyFromObject(claim))
+		if len(clones) != 1 {
+			t.Fatalf("expected one clone, got %d", len(clones))
+		}
+		// pve2 fits the 2Gi minimum though not the 4Gi of memory
+		if clones[0].SourceNode != "pve1" || clones[0].TargetNode != "pve2" {
+			t.Errorf("expected a clone from pve1 onto pve2, got %s onto %s", clones[0].SourceNode, clones[0].TargetNode)
+		}
+		if ref := updated.Status.VMRef; ref == nil || ref.Node != "pve2" {
+			t.Errorf("expected VMRef on pve2, got %+v", ref)
+		}
+	})
+
+	t.Run("waits for a node with enough memory", func(t *testing.T) {
+		claim, template, bootstrap := newProvisioningClaim()
+		clones := 0
+		mockClient := &provider.MockHypervisorClient{
+			// The template is on local storage, so only its own node can host the clone
+			GetTemplateCloneNodesFunc: func(_ context.Context, _ int) ([]string, error) {
+				return []string{"pve1"}, nil
+			},
+			ListNodesFunc: func(context.Context) ([]provider.NodeInfo, error) {
+				return []provider.NodeInfo{
+					{Name: "pve1", Online: true, MemoryMiB: 65536, FreeMemoryMiB: 1024},
+					{Name: "pve2", Online: true, MemoryMiB: 65536, FreeMemoryMiB: 32768},
+				}, nil
+			},
+			CloneVMFunc: func(_ context.Context, req *provider.CloneRequest) (*provider.VMRef, error) {
+				clones++
+				return &provider.VMRef{Node: req.TargetNode, ID: req.NewID}, nil
+			},
+		}
+		r := newReconciler(mockClient, claim, template, bootstrap)
+
+		result, updated := reconcileClaim(t, r, client.ObjectKeyFromObject(claim))
+		if clones != 0 || updated.Status.PendingVMRef != nil {
+			t.Errorf("expected no clone or VM ID without a node, got %d clones and %+v", clones, updated.Status.PendingVMRef)
+		}
+		condition := meta.FindStatusCondition(updated.Status.Conditions, ConditionVMProvisioned)
+		if condition == nil || condition.Reason != "NoNodeAvailable" {
+			t.Errorf("expected VMProvisioned reason NoNodeAvailable, got %v", condition)
+		}
+		if result.RequeueAfter != VMProvisionRequeueInterval {
+			t.Errorf("expected a requeue after %v, got %v", VMProvisionRequeueInterval, result.RequeueAfter)
+		}
+	})
+
+	t.Run("waits for a clone slot", func(t *testing.T) {
+		claim, template, bootstrap := newProvisioningClaim()
+		clones := 0
+		mockClient := &provider.MockHypervisorClient{
+			ListNodesFunc: listProvisioningNodes,
+			CloneVMFunc: func(_ context.Context, req *provider.CloneRequest) (*provider.VMRef, error) {
+				clones++
+				return &provider.VMRef{Node: req.SourceNode, ID: req.NewID}, nil
+			},
+		}
+		r := newReconciler(mockClient, claim, template, bootstrap)
+
+		// Every clone slot on the cluster is taken by clones for other claims
+		cluster := newTestCluster()
+		for range maxConcurrentClones(cluster) {
+			release, err := r.CloneLimiter.Acquire(context.Background(), cluster)
+			if err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+			defer release()
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), cloneBlockedWait)
+		defer cancel()
+		result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(claim)})
+		if err != nil {
+			t.Fatalf("Reconcile() error = %v", err)
+		}
+		updated := &hypervisorv1alpha1.MachineClaim{}
+		if err := r.Get(context.Background(), client.ObjectKeyFromObject(claim), updated); err != nil {
+			t.Fatalf("Failed to get claim: %v", err)
+		}
+		if clones != 0 || updated.Status.VMRef != nil {
+			t.Errorf("expected no clone while the cluster is at its limit, got %d clones and VMRef %+v", clones, updated.Status.VMRef)
+		}
+		if updated.Status.PendingVMRef == nil || result.RequeueAfter != VMProvisionRequeueInterval {
+			t.Errorf("expected the VM ID kept for a retry after %v, got %+v and %v",
+				VMProvisionRequeueInterval, updated.Status.PendingVMRef, result.RequeueAfter)
+		}
+	})
+
+	t.Run("waits for room in the pool quota", func(t *testing.T) {
+		claim, template, bootstrap := newProvisioningClaim()
+		template.Spec.Template.Proxmox.Pool = "ci"
+		cluster := newTestCluster()
+		cluster.Spec.PoolQuotas = []hypervisorv1alpha1.PoolQuota{{Pool: "ci", MaxVMs: 2}}
+
+		vms, clones := 2, 0
+		mockClient := &provider.MockHypervisorClient{
+			ListNodesFunc: listProvisioningNodes,
+			GetPoolUsageFunc: func(_ context.Context, _ string) (*provider.PoolUsage, error) {
+				return &provider.PoolUsage{VMs: vms}, nil
+			},
+			CloneVMFunc: func(_ context.Context, req *provider.CloneRequest) (*provider.VMRef, error) {
+				clones++
+				return &provider.VMRef{Node: req.SourceNode, ID: req.NewID}, nil
+			},
+		}
+		r := &MachineClaimReconciler{
+			Client: fake.NewClientBuilder().WithScheme(scheme).
+				WithObjects(claim, template, bootstrap, cluster, newTestCredentialsSecret()).
+				WithStatusSubresource(claim).Build(),
+			Scheme:          scheme,
+			ProviderFactory: provider.NewMockClientFactoryWithClient(mockClient),
+			CloneLimiter:    NewCloneLimiter(),
+		}
+
+		result, updated := reconcileClaim(t, r, client.ObjectKeyFromObject(claim))
+		if clones != 0 || updated.Status.PendingVMRef != nil {
+			t.Errorf("expected no clone and no VM ID while the pool is full, got %d clones and %+v", clones, updated.Status.PendingVMRef)
+		}
+		if !meta.IsStatusConditionTrue(updated.Status.Conditions, ConditionPoolQuotaExceeded) {
+			t.Errorf("expected PoolQuotaExceeded true, got %v", updated.Status.Conditions)
+		}
+		provisioned := meta.FindStatusCondition(updated.Status.Conditions, ConditionVMProvisioned)
+		if provisioned == nil || provisioned.Reason != "PoolQuotaExceeded" || result.RequeueAfter != VMProvisionRequeueInterval {
+			t.Errorf("expected VMProvisioned reason PoolQuotaExceeded and a requeue, got %v and %v", provisioned, result.RequeueAfter)
+		}
+
+		// Once a VM leaves the pool the claim's VM is cloned
+		vms = 1
+		_, updated = reconcileClaim(t, r, client.ObjectKeyFromObject(claim))
+		if clones != 1 || updated.Status.VMRef == nil {
+			t.Errorf("expected the VM to be cloned, got %d clones and VMRef %+v", clones, updated.Status.VMRef)
+		}
+		if meta.IsStatusConditionTrue(updated.Status.Conditions, ConditionPoolQuotaExceeded) {
+			t.Errorf("expected PoolQuotaExceeded false, got %v", updated.Status.Conditions)
+		}
+	})
+
+	t.Run("picks a new VM ID after a conflict", func(t *testing.T) {
+		claim, template, bootstrap := newProvisioningClaim()
+		claim.Status.PendingVMRef = &hypervisorv1alpha1.VMReference{Node: "pve1", ID: 101}
+		mockClient := &provider.MockHypervisorClient{
+			ListNodesFunc: listProvisioningNodes,
+			CloneVMFunc: func(_ context.Context, req *provider.CloneRequest) (*provider.VMRef, error) {
+				return nil, fmt.Errorf("%w: VM %d is named other-vm", provider.ErrVMConflict, req.NewID)
+			},
+		}
+		r := newReconciler(mockClient, claim, template, bootstrap)
+
+		_, updated := reconcileClaim(t, r, client.ObjectKeyFromObject(claim))
+		if updated.Status.PendingVMRef != nil || updated.Status.VMRef != nil {
+			t.Errorf("expected the conflicting VM ID to be dropped, got %+v and %+v", updated.Status.PendingVMRef, updated.Status.VMRef)
+		}
+	})
+}
+
+func TestMachineClaimReconciler_provisionVMCloneRequest(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = hypervisorv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+
+	disabled, enabled := false, true
+	tests := []struct {
+		name     string
+		proxmox  hypervisorv1alpha1.ProxmoxTemplateSpec
+		validate func(t *testing.T, req *provider.CloneRequest)
+	}{
+		{
+			name:    "cluster defaults",
+			proxmox: hypervisorv1alpha1.ProxmoxTemplateSpec{TemplateID: 9000},
+			validate: func(t *testing.T, req *provider.CloneRequest) {
+				if req.Pool != "ci" || req.Storage != "local-lvm" || !req.FullClone {
+					t.Errorf("expected a full clone to local-lvm in pool ci, got %+v", req)
+				}
+				if req.VGA != DefaultVGAType || req.GuestAgent == nil || !*req.GuestAgent || req.OnBoot == nil || *req.OnBoot {
+					t.Errorf("expected the default VGA, guest agent and onboot settings, got %+v", req)
+				}
+				if req.Hotplug != nil {
+					t.Errorf("expected the template's hot-plug settings to be kept, got %+v", req.Hotplug)
+				}
+			},
+		},
+		{
+			name: "template settings",
+			proxmox: hypervisorv1alpha1.ProxmoxTemplateSpec{
+				TemplateID:  9000,
+				LinkedClone: true,
+				Pool:        "team-a",
+				VGA:         "std",
+				GuestAgent:  &disabled,
+				OnBoot:      &enabled,
+				HotplugCPU:  true,
+			},
+			validate: func(t *testing.T, req *provider.CloneRequest) {
+				if req.Pool != "team-a" || req.FullClone {
+					t.Errorf("expected a linked clone in pool team-a, got %+v", req)
+				}
+				if req.VGA != "std" || req.GuestAgent == nil || *req.GuestAgent || req.OnBoot == nil || !*req.OnBoot {
+					t.Errorf("expected the template's VGA, guest agent and onboot settings, got %+v", req)
+				}
+				if req.Hotplug == nil || !req.Hotplug.CPU || req.Hotplug.Memory {
+					t.Errorf("expected CPU hot-plug only, got %+v", req.Hotplug)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claim, template, bootstrap := newProvisioningClaim()
+			template.Spec.Template.Proxmox = &tt.proxmox
+			cluster := newTestCluster()
+			cluster.Spec.DefaultPool = "ci"
+			cluster.Spec.DefaultStorage = "local-lvm"
+
+			var clones []*provider.CloneRequest
+			var idPools []string
+			mockClient := &provider.MockHypervisorClient{
+				ListNodesFunc: listProvisioningNodes,
+				NextAvailableVMIDInPoolFunc: func(_ context.Context, pool string, rangeStart, _ int) (int, error) {
+					idPools = append(idPools, pool)
+					return rangeStart, nil
+				},
+				CloneVMFunc: func(_ context.Context, req *provider.CloneRequest) (*provider.VMRef, error) {
+					clones = append(clones, req)
+					return &provider.VMRef{Node: req.SourceNode, ID: req.NewID}, nil
+				},
+			}
+			r := &MachineClaimReconciler{
+				Client: fake.NewClientBuilder().WithScheme(scheme).
+					WithObjects(claim, template, bootstrap, cluster, newTestCredentialsSecret()).
+					WithStatusSubresource(claim).Build(),
+				Scheme:          scheme,
+				ProviderFactory: provider.NewMockClientFactoryWithClient(mockClient),
+				CloneLimiter:    NewCloneLimiter(),
+			}
+
+			reconcileClaim(t, r, client.ObjectKeyFromObject(claim))
+			if len(clones) != 1 {
+				t.Fatalf("expected one clone, got %d", len(clones))
+			}
+			// The VM ID is picked from the range of the pool the VM joins
+			if len(idPools) != 1 || idPools[0] != clones[0].Pool {
+				t.Errorf("expected a VM ID for pool %q, got requests for %v", clones[0].Pool, idPools)
+			}
+			tt.validate(t, clones[0])
+		})
+	}
+}
+
+func TestMachineClaimReconciler_handleDeletionPendingVM(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = hypervisorv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+
+	tests := []struct {
+		name          string
+		exists        bool
+		description   func(claim *hypervisorv1alpha1.MachineClaim) string
+		expectDeletes int
+	}{
+		{name: "clone never created the VM"},
+		{
+			name:          "clone created the VM",
+			exists:        true,
+			description:   vmDescription,
+			expectDeletes: 1,
+		},
+		{
+			name:        "ID taken by another guest",
+			exists:      true,
+			description: func(*hypervisorv1alpha1.MachineClaim) string { return "someone else's VM" },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claim, template, _ := newProvisioningClaim()
+			claim.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+			claim.Status.PendingVMRef = &hypervisorv1alpha1.VMReference{Node: "pve1", ID: 101}
+
+			deletes := 0
+			mockClient := &provider.MockHypervisorClient{
+				VMExistsFunc: func(_ context.Context, id int) (bool, error) {
+					return tt.exists && id == 101, nil
+				},
+				GetVMDescriptionFunc: func(_ context.Context, _ provider.VMRef) (string, error) {
+					return tt.description(claim), nil
+				},
+				DeleteVMFunc: func(_ context.Context, ref provider.VMRef) (string, error) {
+					deletes++
+					if ref.Node != "pve1" || ref.ID != 101 {
+						t.Errorf("unexpected VM ref %+v", ref)
+					}
+					return "", nil
+				},
+			}
+			r := &MachineClaimReconciler{
+				Client: fake.NewClientBuilder().WithScheme(scheme).
+					WithObjects(claim, template, newTestCluster(), newTestCredentialsSecret()).
+					WithStatusSubresource(claim).Build(),
+				Scheme:          scheme,
+				ProviderFactory: provider.NewMockClientFactoryWithClient(mockClient),
+			}
+
+			if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(claim)}); err != nil {
+				t.Fatalf("Reconcile() error = %v", err)
+			}
+			if deletes != tt.expectDeletes {
+				t.Errorf("expected %d DeleteVM calls, got %d", tt.expectDeletes, deletes)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import "time"
+
+const (
+	// DefaultSuccessRequeueInterval is how long a reconciler waits before re-checking a healthy resource
+	DefaultSuccessRequeueInterval = 15 * time.Minute
+	// DefaultFailureRequeueInterval is how long a reconciler waits before re-checking a failing resource
+	DefaultFailureRequeueInterval = 1 * time.Minute
+)
+
+// RequeueIntervals controls how soon a reconciler re-checks a resource, so healthy
+// resources are polled less aggressively than failing ones. Zero values use the defaults.
+type RequeueIntervals struct {
+	// Success is used after the last check succeeded
+	Success time.Duration
+	// Failure is used after the last check failed
+	Failure time.Duration
+	// MaxBackoff caps the failure interval as failures persist; zero caps it at the Success interval,
+	// so a failing resource is never checked less often than a healthy one
+	MaxBackoff time.Duration
+	// Unrecoverable is used after a failure only a spec change can fix, such as an unsupported
+	// provider; zero uses the Success interval, as the spec change is reconciled at once anyway
+	Unrecoverable time.Duration
+}
+
+// After returns the requeue interval for the result of the last check
+func (i RequeueIntervals) After(success bool) time.Duration {
+	if success {
+		if i.Success > 0 {
+			return i.Success
+		}
+		return DefaultSuccessRequeueInterval
+	}
+	if i.Failure > 0 {
+		return i.Failure
+	}
+	return DefaultFailureRequeueInterval
+}
+
+// AfterUnrecoverable returns the requeue interval for a check that will keep failing until the spec changes
+func (i RequeueIntervals) AfterUnrecoverable() time.Duration {
+	if i.Unrecoverable > 0 {
+		return i.Unrecoverable
+	}
+	return i.After(true)
+}
+
+// Backoff returns the requeue interval for a check that has been failing for failingFor. Waiting as
+// long as the check has already been failing roughly doubles the delay with every retry; the delay
+// is at least the Failure interval and never more than MaxBackoff.
+func (i RequeueIntervals) Backoff(failingFor time.Duration) time.Duration {
+	maxBackoff := i.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = i.After(true)
+	}
+	return min(max(failingFor, i.After(false)), maxBackoff)
+}
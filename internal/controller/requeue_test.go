@@ -0,0 +1,129 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRequeueIntervalsAfter(t *testing.T) {
+	tests := []struct {
+		name      string
+		intervals RequeueIntervals
+		success   bool
+		expected  time.Duration
+	}{
+		{name: "default success", success: true, expected: DefaultSuccessRequeueInterval},
+		{name: "default failure", success: false, expected: DefaultFailureRequeueInterval},
+		{
+			name:      "configured success",
+			intervals: RequeueIntervals{Success: 30 * time.Minute, Failure: 30 * time.Second},
+			success:   true,
+			expected:  30 * time.Minute,
+		},
+		{
+			name:      "configured failure",
+			intervals: RequeueIntervals{Success: 30 * time.Minute, Failure: 30 * time.Second},
+			success:   false,
+			expected:  30 * time.Second,
+		},
+		{
+			name:      "only success configured",
+			intervals: RequeueIntervals{Success: 30 * time.Minute},
+			success:   false,
+			expected:  DefaultFailureRequeueInterval,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.intervals.After(tt.success); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRequeueIntervalsBackoff(t *testing.T) {
+	tests := []struct {
+		name       string
+		intervals  RequeueIntervals
+		failingFor time.Duration
+		expected   time.Duration
+	}{
+		{name: "first failure", failingFor: 0, expected: DefaultFailureRequeueInterval},
+		{name: "grows with the failure", failingFor: 4 * time.Minute, expected: 4 * time.Minute},
+		{name: "capped at the success interval by default", failingFor: time.Hour, expected: DefaultSuccessRequeueInterval},
+		{
+			name:       "configured cap",
+			intervals:  RequeueIntervals{Failure: 30 * time.Second, MaxBackoff: 5 * time.Minute},
+			failingFor: time.Hour,
+			expected:   5 * time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.intervals.Backoff(tt.failingFor); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRequeueIntervalsAfterUnrecoverable(t *testing.T) {
+	if got := (RequeueIntervals{}).AfterUnrecoverable(); got != DefaultSuccessRequeueInterval {
+		t.Errorf("Expected the success interval by default, got %v", got)
+	}
+	if got := (RequeueIntervals{Success: 30 * time.Minute}).AfterUnrecoverable(); got != 30*time.Minute {
+		t.Errorf("Expected the configured success interval, got %v", got)
+	}
+	if got := (RequeueIntervals{Success: 30 * time.Minute, Unrecoverable: 2 * time.Hour}).AfterUnrecoverable(); got != 2*time.Hour {
+		t.Errorf("Expected the configured unrecoverable interval, got %v", got)
+	}
+}
+
+func TestRequeueIntervalsBackoffNeverExceedsCap(t *testing.T) {
+	for _, intervals := range []RequeueIntervals{
+		{},
+		{Success: 10 * time.Minute, Failure: 10 * time.Second},
+		{Failure: 10 * time.Second, MaxBackoff: 3 * time.Minute},
+	} {
+		limit := intervals.MaxBackoff
+		if limit == 0 {
+			limit = intervals.After(true)
+		}
+
+		// Each retry happens after the previous delay, so the failure keeps getting older
+		var failingFor, delay time.Duration
+		for failures := 1; failures <= 1000; failures++ {
+			next := intervals.Backoff(failingFor)
+			if next > limit {
+				t.Fatalf("%+v: delay %v after %d failures exceeds the cap %v", intervals, next, failures, limit)
+			}
+			if next < delay {
+				t.Fatalf("%+v: delay shrank from %v to %v after %d failures", intervals, delay, next, failures)
+			}
+			delay = next
+			failingFor += delay
+		}
+		if delay != limit {
+			t.Errorf("%+v: expected the delay to reach the cap %v, got %v", intervals, limit, delay)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	hypervisorv1alpha1 "github.com/codihuston/hyperfleet-operator/api/v1alpha1"
+)
+
+const (
+	// RunnerConfigSecretKey is the Secret key holding the rendered runner config.
+	// The VM writes it to /etc/hyperfleet/runner-config.json, the bootstrap service's default config path.
+	RunnerConfigSecretKey = "runner-config.json"
+
+	// runnerConfigPlatformGitHub identifies GitHub Actions runners in the rendered config
+	runnerConfigPlatformGitHub = "github-actions"
+)
+
+// DefaultRunnerDownloadURL is the runner the bootstrap service downloads on a linux x64 VM when
+// the template sets no download URL. It must match the bootstrap service's DefaultDownloadURL.
+const DefaultRunnerDownloadURL = "https://github.com/actions/runner/releases/download/v2.311.0/actions-runner-linux-x64-2.311.0.tar.gz"
+
+// runnerArchiveVersionPattern extracts the version from a runner release archive name
+var runnerArchiveVersionPattern = regexp.MustCompile(`actions-runner-[a-z]+-[a-z0-9]+-(\d+\.\d+\.\d+)\.tar\.gz$`)
+
+// runnerScope is the level a runner registers at, told apart by the shape of the registration URL
+type runnerScope string
+
+const (
+	runnerScopeRepository   runnerScope = "repository"   // https://github.com/owner/repo
+	runnerScopeOrganization runnerScope = "organization" // https://github.com/org
+	runnerScopeEnterprise   runnerScope = "enterprise"   // https://github.com/enterprises/name
+
+	// enterprisePathPrefix is the first path segment of enterprise URLs
+	enterprisePathPrefix = "enterprises"
+)
+
+// RegistrationToken is a short-lived runner registration token
+type RegistrationToken struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+// RegistrationTokenProvider mints runner registration tokens
+type RegistrationTokenProvider interface {
+	// RegistrationToken returns a new registration token for the given GitHub configuration
+	RegistrationToken(ctx context.Context, namespace string, github *hypervisorv1alpha1.GitHubConfig) (*RegistrationToken, error)
+}
+
+// runnerConfig mirrors the RunnerConfig JSON consumed by the bootstrap service
+type runnerConfig struct {
+	Method          string   `json:"method"`
+	Platform        string   `json:"platform,omitempty"`
+	RunnerToken     string   `json:"runner_token,omitempty"`
+	RegistrationURL string   `json:"registration_url,omitempty"`
+	RunnerName      string   `json:"runner_name,omitempty"`
+	Labels          []string `json:"labels,omitempty"`
+	RunnerGroup     string   `json:"runner_group,omitempty"`
+	ExpiresAt       string   `json:"expires_at,omitempty"`
+
+	Runner runnerSettings `json:"runner,omitempty"`
+}
+
+// runnerSettings mirrors the runner section of the bootstrap service config
+type runnerSettings struct {
+	DownloadURL string `json:"download_url,omitempty"`
+	InstallPath string `json:"install_path,omitempty"`
+	WorkDir     string `json:"work_dir,omitempty"`
+}
+
+// buildRunnerConfig builds the bootstrap config for a runner from its template. The runner's
+// labels merge the template's, the cluster's and the controller's default labels, in that order
+// of precedence; cluster may be nil. A runner group is left out for repository runners, which
+// cannot join one.
+func buildRunnerConfig(template *hypervisorv1alpha1.HypervisorMachineTemplate, cluster *hypervisorv1alpha1.HypervisorCluster,
+	runnerName string, token *RegistrationToken, defaultLabels []string) (*runnerConfig, error) {
+	github := template.Spec.Bootstrap.Config.GitHub
+	if github == nil {
+		return nil, fmt.Errorf("template %s has no GitHub bootstrap configuration", template.Name)
+	}
+	if token == nil || token.Token == "" {
+		return nil, fmt.Errorf("registration token is required")
+	}
+
+	config := &runnerConfig{
+		Method:          template.Spec.Bootstrap.Method,
+		Platform:        runnerConfigPlatformGitHub,
+		RunnerToken:     token.Token,
+		RegistrationURL: github.URL,
+		RunnerName:      runnerName,
+		Labels:          mergeRunnerLabels(github.Runner.Labels, clusterRunnerLabels(cluster), defaultLabels),
+		Runner: runnerSettings{
+			DownloadURL: github.Runner.DownloadURL,
+			InstallPath: github.Runner.InstallPath,
+			WorkDir:     github.Runner.WorkDir,
+		},
+	}
+	if runnerGroupWarning(github) == "" {
+		config.RunnerGroup = github.Runner.Group
+	}
+	if !token.ExpiresAt.IsZero() {
+		config.ExpiresAt = token.ExpiresAt.Format(time.RFC3339)
+	}
+
+	return config, nil
+}
+
+// renderRunnerConfig renders the bootstrap config JSON for a runner
+func renderRunnerConfig(template *hypervisorv1alpha1.HypervisorMachineTemplate, cluster *hypervisorv1alpha1.HypervisorCluster,
+	runnerName string, token *RegistrationToken, defaultLabels []string) ([]byte, error) {
+	config, err := buildRunnerConfig(template, cluster, runnerName, token, defaultLabels)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal runner config: %w", err)
+	}
+	return data, nil
+}
+
+// gitHubRunnerScope tells repository, organization and enterprise registration URLs apart by
+// their path: /owner/repo, /org or /enterprises/name. It is empty for any other URL.
+func gitHubRunnerScope(registrationURL string) runnerScope {
+	parsed, err := url.Parse(registrationURL)
+	if err != nil || parsed.Host == "" {
+		return ""
+	}
+	segments := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+	switch {
+	case slices.Contains(segments, ""):
+		return ""
+	case len(segments) == 1:
+		return runnerScopeOrganization
+	case len(segments) == 2 && segments[0] == enterprisePathPrefix:
+		return runnerScopeEnterprise
+	case len(segments) == 2:
+		return runnerScopeRepository
+	}
+	return ""
+}
+
+// runnerGroupWarning explains why the template's runner group is ignored, empty when it is
+// applied or unset. Runner groups only exist for organization and enterprise runners.
+func runnerGroupWarning(github *hypervisorv1alpha1.GitHubConfig) string {
+	if github == nil || github.Runner.Group == "" || gitHubRunnerScope(github.URL) != runnerScopeRepository {
+		return ""
+	}
+	return fmt.Sprintf("runner group %q is ignored: %s is a repository URL, and only organization and enterprise runners join runner groups",
+		github.Runner.Group, github.URL)
+}
+
+// resolvedRunnerDownload returns the runner download URL a VM is bootstrapped with and its
+// version. The version is empty when the URL is not a runner release archive.
+func resolvedRunnerDownload(github *hypervisorv1alpha1.GitHubConfig) (downloadURL, version string) {
+	downloadURL = DefaultRunnerDownloadURL
+	if github != nil && github.Runner.DownloadURL != "" {
+		downloadURL = github.Runner.DownloadURL
+	}
+	if match := runnerArchiveVersionPattern.FindStringSubmatch(downloadURL); match != nil {
+		version = match[1]
+	}
+	return downloadURL, version
+}
+
+// clusterRunnerLabels returns the labels a cluster adds to its runners
+func clusterRunnerLabels(cluster *hypervisorv1alpha1.HypervisorCluster) []string {
+	if cluster == nil {
+		return nil
+	}
+	return cluster.Spec.RunnerLabels
+}
+
+// mergeRunnerLabels concatenates label sources, dropping empty labels and duplicates. GitHub
+// compares labels case-insensitively, so of labels differing only in case the one from the
+// earliest source is kept.
+func mergeRunnerLabels(sources ...[]string) []string {
+	var labels []string
+	seen := map[string]bool{}
+	for _, source := range sources {
+		for _, label := range source {
+			label = strings.TrimSpace(label)
+			key := strings.ToLower(label)
+			if label == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			labels = append(labels, label)
+		}
+	}
+	return labels
+}
@@ -0,0 +1,281 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hypervisorv1alpha1 "github.com/codihuston/hyperfleet-operator/api/v1alpha1"
+)
+
+// newRunnerTemplate returns a template with a GitHub runner bootstrap configuration
+func newRunnerTemplate() *hypervisorv1alpha1.HypervisorMachineTemplate {
+	return &hypervisorv1alpha1.HypervisorMachineTemplate{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "runner-template",
+			Namespace: "default",
+		},
+		Spec: hypervisorv1alpha1.HypervisorMachineTemplateSpec{
+			Resources: hypervisorv1alpha1.ResourceRequirements{
+				CPU:    2,
+				Memory: "4Gi",
+			},
+			Bootstrap: hypervisorv1alpha1.BootstrapSpec{
+				Method: "runner-token",
+				Config: hypervisorv1alpha1.BootstrapConfig{
+					GitHub: &hypervisorv1alpha1.GitHubConfig{
+						URL: "https://github.com/test/repo",
+						Runner: hypervisorv1alpha1.GitHubRunnerConfig{
+							DownloadURL: "https://example.com/runner.tar.gz",
+							InstallPath: "/opt/actions-runner",
+							WorkDir:     "/tmp/runner-work",
+							Labels:      []string{"self-hosted", "hyperfleet"},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestRenderRunnerConfig(t *testing.T) {
+	template := newRunnerTemplate()
+	expiresAt := time.Date(2025, 12, 25, 6, 0, 0, 0, time.UTC)
+	token := &RegistrationToken{Token: "test-token", ExpiresAt: expiresAt}
+
+	data, err := renderRunnerConfig(template, nil, "runner-1", token, nil)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	// Decode into a generic map to assert the JSON keys the bootstrap service reads
+	var rendered map[string]interface{}
+	if err := json.Unmarshal(data, &rendered); err != nil {
+		t.Fatalf("Failed to parse rendered config: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"method":           "runner-token",
+		"platform":         "github-actions",
+		"runner_token":     "test-token",
+		"registration_url": "https://github.com/test/repo",
+		"runner_name":      "runner-1",
+		"expires_at":       "2025-12-25T06:00:00Z",
+	}
+	for key, want := range expected {
+		if rendered[key] != want {
+			t.Errorf("Expected %s to be %v, got %v", key, want, rendered[key])
+		}
+	}
+
+	labels, ok := rendered["labels"].([]interface{})
+	if !ok || !reflect.DeepEqual(labels, []interface{}{"self-hosted", "hyperfleet"}) {
+		t.Errorf("Expected labels [self-hosted hyperfleet], got %v", rendered["labels"])
+	}
+
+	runner, ok := rendered["runner"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected runner section, got %v", rendered["runner"])
+	}
+	if runner["install_path"] != "/opt/actions-runner" {
+		t.Errorf("Expected install_path /opt/actions-runner, got %v", runner["install_path"])
+	}
+	if runner["work_dir"] != "/tmp/runner-work" {
+		t.Errorf("Expected work_dir /tmp/runner-work, got %v", runner["work_dir"])
+	}
+	if runner["download_url"] != "https://example.com/runner.tar.gz" {
+		t.Errorf("Expected download_url https://example.com/runner.tar.gz, got %v", runner["download_url"])
+	}
+}
+
+func TestResolvedRunnerDownload(t *testing.T) {
+	tests := []struct {
+		name        string
+		downloadURL string
+		expectURL   string
+		expectVer   string
+	}{
+		{
+			name:        "template release archive",
+			downloadURL: "https://github.com/actions/runner/releases/download/v2.319.1/actions-runner-linux-arm64-2.319.1.tar.gz",
+			expectURL:   "https://github.com/actions/runner/releases/download/v2.319.1/actions-runner-linux-arm64-2.319.1.tar.gz",
+			expectVer:   "2.319.1",
+		},
+		{
+			name:        "template mirror without a version",
+			downloadURL: "https://example.com/runner.tar.gz",
+			expectURL:   "https://example.com/runner.tar.gz",
+		},
+		{
+			name:      "bootstrap service default",
+			expectURL: DefaultRunnerDownloadURL,
+			expectVer: "2.311.0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template := newRunnerTemplate()
+			template.Spec.Bootstrap.Config.GitHub.Runner.DownloadURL = tt.downloadURL
+
+			downloadURL, version := resolvedRunnerDownload(template.Spec.Bootstrap.Config.GitHub)
+			if downloadURL != tt.expectURL {
+				t.Errorf("Expected download URL %s, got %s", tt.expectURL, downloadURL)
+			}
+			if version != tt.expectVer {
+				t.Errorf("Expected version %q, got %q", tt.expectVer, version)
+			}
+		})
+	}
+}
+
+func TestRenderRunnerConfigErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		template *hypervisorv1alpha1.HypervisorMachineTemplate
+		token    *RegistrationToken
+	}{
+		{
+			name:     "missing github config",
+			template: &hypervisorv1alpha1.HypervisorMachineTemplate{},
+			token:    &RegistrationToken{Token: "test-token"},
+		},
+		{
+			name:     "missing token",
+			template: newRunnerTemplate(),
+			token:    nil,
+		},
+		{
+			name:     "empty token",
+			template: newRunnerTemplate(),
+			token:    &RegistrationToken{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := renderRunnerConfig(tt.template, nil, "runner-1", tt.token, nil); err == nil {
+				t.Errorf("Expected error but got none")
+			}
+		})
+	}
+}
+
+func TestRenderRunnerConfigOmitsZeroExpiry(t *testing.T) {
+	data, err := renderRunnerConfig(newRunnerTemplate(), nil, "runner-1", &RegistrationToken{Token: "test-token"}, nil)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	var rendered map[string]interface{}
+	if err := json.Unmarshal(data, &rendered); err != nil {
+		t.Fatalf("Failed to parse rendered config: %v", err)
+	}
+	if _, exists := rendered["expires_at"]; exists {
+		t.Errorf("Expected expires_at to be omitted, got %v", rendered["expires_at"])
+	}
+}
+
+func TestRenderRunnerConfigMergesLabels(t *testing.T) {
+	template := newRunnerTemplate()
+	template.Spec.Bootstrap.Config.GitHub.Runner.Labels = []string{"self-hosted", "gpu"}
+	cluster := newTestCluster()
+	cluster.Spec.RunnerLabels = []string{"region-eu", "GPU", "hyperfleet"}
+	defaults := []string{"env-prod", "Region-EU", "self-hosted", " ", "fleet"}
+
+	config, err := buildRunnerConfig(template, cluster, "runner-1", &RegistrationToken{Token: "test-token"}, defaults)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	// Template labels come first, then the cluster's, then the controller defaults; a label
+	// repeated in any case keeps the spelling of the first source that has it
+	expected := []string{"self-hosted", "gpu", "region-eu", "hyperfleet", "env-prod", "fleet"}
+	if !reflect.DeepEqual(config.Labels, expected) {
+		t.Errorf("Expected labels %v, got %v", expected, config.Labels)
+	}
+}
+
+func TestMergeRunnerLabels(t *testing.T) {
+	if labels := mergeRunnerLabels(nil, nil); labels != nil {
+		t.Errorf("Expected no labels, got %v", labels)
+	}
+	if labels := mergeRunnerLabels(nil, []string{"a"}, []string{"b", "A"}); !reflect.DeepEqual(labels, []string{"a", "b"}) {
+		t.Errorf("Expected [a b], got %v", labels)
+	}
+}
+
+func TestGitHubRunnerScope(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected runnerScope
+	}{
+		{url: "https://github.com/test/repo", expected: runnerScopeRepository},
+		{url: "https://github.com/test/repo/", expected: runnerScopeRepository},
+		{url: "https://github.com/test", expected: runnerScopeOrganization},
+		{url: "https://ghe.example.com/platform", expected: runnerScopeOrganization},
+		{url: "https://github.com/enterprises/acme", expected: runnerScopeEnterprise},
+		{url: "https://github.com", expected: ""},
+		{url: "https://github.com/test/repo/actions", expected: ""},
+		{url: "github.com/test/repo", expected: ""},
+	}
+
+	for _, tt := range tests {
+		if scope := gitHubRunnerScope(tt.url); scope != tt.expected {
+			t.Errorf("gitHubRunnerScope(%q) = %q, expected %q", tt.url, scope, tt.expected)
+		}
+	}
+}
+
+func TestBuildRunnerConfigRunnerGroup(t *testing.T) {
+	tests := []struct {
+		name          string
+		url           string
+		group         string
+		expectGroup   string
+		expectWarning bool
+	}{
+		{name: "organization URL", url: "https://github.com/test", group: "linux-fleet", expectGroup: "linux-fleet"},
+		{name: "enterprise URL", url: "https://github.com/enterprises/acme", group: "linux-fleet", expectGroup: "linux-fleet"},
+		{name: "repository URL without group", url: "https://github.com/test/repo"},
+		{name: "group on repository URL", url: "https://github.com/test/repo", group: "linux-fleet", expectWarning: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template := newRunnerTemplate()
+			template.Spec.Bootstrap.Config.GitHub.URL = tt.url
+			template.Spec.Bootstrap.Config.GitHub.Runner.Group = tt.group
+
+			config, err := buildRunnerConfig(template, nil, "runner-1", &RegistrationToken{Token: "test-token"}, nil)
+			if err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+			if config.RunnerGroup != tt.expectGroup {
+				t.Errorf("Expected runner group %q, got %q", tt.expectGroup, config.RunnerGroup)
+			}
+			if warning := runnerGroupWarning(template.Spec.Bootstrap.Config.GitHub); (warning != "") != tt.expectWarning {
+				t.Errorf("Expected warning %v, got %q", tt.expectWarning, warning)
+			}
+		})
+	}
+}
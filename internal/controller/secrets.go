@@ -0,0 +1,134 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hypervisorv1alpha1 "github.com/codihuston/hyperfleet-operator/api/v1alpha1"
+)
+
+// secretKeyRef identifies one key of a Secret; an empty Namespace is resolved to the
+// namespace of the object holding the reference
+type secretKeyRef struct {
+	Name      string
+	Key       string
+	Namespace string
+}
+
+// loadSecretKey reads one key of a Secret, looking the Secret up in defaultNamespace when
+// the reference does not name a namespace
+func loadSecretKey(ctx context.Context, c client.Reader, defaultNamespace string, ref secretKeyRef) ([]byte, error) {
+	if ref.Name == "" || ref.Key == "" {
+		return nil, fmt.Errorf("secret reference requires a name and key")
+	}
+
+	secretName := types.NamespacedName{Name: ref.Name, Namespace: ref.Namespace}
+	if secretName.Namespace == "" {
+		secretName.Namespace = defaultNamespace
+	}
+
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, secretName, secret); err != nil {
+		return nil, fmt.Errorf("failed to get secret %s: %w", secretName, err)
+	}
+
+	value, exists := secret.Data[ref.Key]
+	if !exists {
+		return nil, fmt.Errorf("key %s not found in secret %s", ref.Key, secretName)
+	}
+	return value, nil
+}
+
+// getSecretValue retrieves a value selected by a core SecretKeySelector, which always refers
+// to a Secret in the referring object's namespace
+func getSecretValue(ctx context.Context, c client.Reader, namespace string, selector *corev1.SecretKeySelector) (string, error) {
+	if selector == nil {
+		return "", fmt.Errorf("secret reference is required")
+	}
+
+	value, err := loadSecretKey(ctx, c, namespace, secretKeyRef{Name: selector.Name, Key: selector.Key})
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
+
+// getSecretKeyValue retrieves a value selected by a SecretKeySelector, which may name a Secret
+// in another namespace and otherwise refers to the referring object's namespace
+func getSecretKeyValue(ctx context.Context, c client.Reader, namespace string, selector *hypervisorv1alpha1.SecretKeySelector) (string, error) {
+	if selector == nil {
+		return "", fmt.Errorf("secret reference is required")
+	}
+
+	value, err := loadSecretKey(ctx, c, namespace, secretKeyRef(*selector))
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
+
+// namedSecretKey is one entry of a set of secret values loaded together; Field names the value
+// in errors, e.g. "appId"
+type namedSecretKey struct {
+	Field    string
+	Selector hypervisorv1alpha1.SecretKeySelector
+}
+
+// secretKeysError reports every value of a set that could not be loaded
+type secretKeysError struct {
+	total    int
+	failures []error
+}
+
+func (e *secretKeysError) Error() string {
+	messages := make([]string, 0, len(e.failures))
+	for _, err := range e.failures {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Sprintf("failed to load %d of %d secret values: %s", len(e.failures), e.total, strings.Join(messages, "; "))
+}
+
+// Unwrap exposes the individual failures to errors.Is and errors.As, e.g. to detect a missing Secret
+func (e *secretKeysError) Unwrap() []error {
+	return e.failures
+}
+
+// getSecretKeyValues loads a set of secret values, keyed by field. Every value is attempted, so
+// the error names all missing secrets and keys at once rather than only the first.
+func getSecretKeyValues(ctx context.Context, c client.Reader, namespace string, keys []namedSecretKey) (map[string]string, error) {
+	values := make(map[string]string, len(keys))
+	var failures []error
+	for _, key := range keys {
+		value, err := loadSecretKey(ctx, c, namespace, secretKeyRef(key.Selector))
+		if err != nil {
+			failures = append(failures, fmt.Errorf("%s: %w", key.Field, err))
+			continue
+		}
+		values[key.Field] = string(value)
+	}
+	if len(failures) > 0 {
+		return nil, &secretKeysError{total: len(keys), failures: failures}
+	}
+	return values, nil
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+
+	hypervisorv1alpha1 "github.com/codihuston/hyperfleet-operator/api/v1alpha1"
+	"github.com/codihuston/hyperfleet-operator/internal/provider"
+)
+
+// validateStaticNetwork checks that a template's static IP and gateway fall within the subnet of
+// the cluster's network bridge on every node a VM may be placed on. Bridges without an address
+// on the node, such as VM-only bridges, carry no subnet to check against and are accepted.
+func validateStaticNetwork(ctx context.Context, hypervisorClient provider.HypervisorClient,
+	template *hypervisorv1alpha1.HypervisorMachineTemplate, cluster *hypervisorv1alpha1.HypervisorCluster) error {
+	static := template.Spec.Network.StaticConfig
+	if static == nil {
+		return nil
+	}
+
+	ip, err := netip.ParsePrefix(static.IP)
+	if err != nil {
+		return fmt.Errorf("invalid static IP %q, expected CIDR notation: %w", static.IP, err)
+	}
+	gateway, err := netip.ParseAddr(static.Gateway)
+	if err != nil {
+		return fmt.Errorf("invalid static gateway %q: %w", static.Gateway, err)
+	}
+
+	bridge := cluster.Spec.DefaultNetwork
+	for _, node := range cluster.Spec.Nodes {
+		networks, err := hypervisorClient.GetNodeNetworks(ctx, node)
+		if err != nil {
+			return err
+		}
+		subnet, err := bridgeSubnet(networks, bridge, node)
+		if err != nil {
+			return err
+		}
+		if !subnet.IsValid() {
+			continue
+		}
+
+		if !subnet.Contains(ip.Addr()) {
+			return fmt.Errorf("static IP %s is outside subnet %s of bridge %s on node %s", ip.Addr(), subnet, bridge, node)
+		}
+		if !subnet.Contains(gateway) {
+			return fmt.Errorf("static gateway %s is outside subnet %s of bridge %s on node %s", gateway, subnet, bridge, node)
+		}
+	}
+	return nil
+}
+
+// bridgeSubnet returns the subnet of the named bridge, the zero prefix when the bridge has no address
+func bridgeSubnet(networks []provider.NetworkInfo, bridge, node string) (netip.Prefix, error) {
+	for _, network := range networks {
+		if network.Name != bridge {
+			continue
+		}
+		if network.CIDR == "" {
+			return netip.Prefix{}, nil
+		}
+		prefix, err := netip.ParsePrefix(network.CIDR)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid address %q on bridge %s of node %s: %w", network.CIDR, bridge, node, err)
+		}
+		return prefix.Masked(), nil
+	}
+	return netip.Prefix{}, fmt.Errorf("bridge %s does not exist on node %s", bridge, node)
+}
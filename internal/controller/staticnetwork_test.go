@@ -0,0 +1,151 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hypervisorv1alpha1 "github.com/codihuston/hyperfleet-operator/api/v1alpha1"
+	"github.com/codihuston/hyperfleet-operator/internal/provider"
+)
+
+// newStaticNetworkClient returns a client whose nodes have vmbr0 on 192.168.1.0/24 and an unaddressed vmbr1
+func newStaticNetworkClient() *provider.MockHypervisorClient {
+	return &provider.MockHypervisorClient{
+		GetNodeNetworksFunc: func(ctx context.Context, node string) ([]provider.NetworkInfo, error) {
+			return []provider.NetworkInfo{
+				{Name: "vmbr0", CIDR: "192.168.1.10/24", Gateway: "192.168.1.1"},
+				{Name: "vmbr1"},
+			}, nil
+		},
+	}
+}
+
+func TestValidateStaticNetwork(t *testing.T) {
+	tests := []struct {
+		name        string
+		bridge      string
+		static      *hypervisorv1alpha1.StaticNetworkConfig
+		expectError string
+	}{
+		{
+			name:   "static IP within subnet",
+			bridge: "vmbr0",
+			static: &hypervisorv1alpha1.StaticNetworkConfig{IP: "192.168.1.50/24", Gateway: "192.168.1.1"},
+		},
+		{
+			name:        "static IP outside subnet",
+			bridge:      "vmbr0",
+			static:      &hypervisorv1alpha1.StaticNetworkConfig{IP: "10.0.0.50/24", Gateway: "192.168.1.1"},
+			expectError: "static IP 10.0.0.50 is outside subnet 192.168.1.0/24 of bridge vmbr0 on node pve1",
+		},
+		{
+			name:        "gateway outside subnet",
+			bridge:      "vmbr0",
+			static:      &hypervisorv1alpha1.StaticNetworkConfig{IP: "192.168.1.50/24", Gateway: "10.0.0.1"},
+			expectError: "static gateway 10.0.0.1 is outside subnet",
+		},
+		{
+			name:        "IP without prefix length",
+			bridge:      "vmbr0",
+			static:      &hypervisorv1alpha1.StaticNetworkConfig{IP: "192.168.1.50", Gateway: "192.168.1.1"},
+			expectError: "expected CIDR notation",
+		},
+		{
+			name:   "unaddressed bridge is not checked",
+			bridge: "vmbr1",
+			static: &hypervisorv1alpha1.StaticNetworkConfig{IP: "10.0.0.50/24", Gateway: "10.0.0.1"},
+		},
+		{
+			name:        "missing bridge",
+			bridge:      "vmbr9",
+			static:      &hypervisorv1alpha1.StaticNetworkConfig{IP: "192.168.1.50/24", Gateway: "192.168.1.1"},
+			expectError: "bridge vmbr9 does not exist on node pve1",
+		},
+		{
+			name:   "no static configuration",
+			bridge: "vmbr9",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template := newRunnerTemplate()
+			template.Spec.Network.StaticConfig = tt.static
+			cluster := newTestCluster()
+			cluster.Spec.Nodes = []string{"pve1", "pve2"}
+			cluster.Spec.DefaultNetwork = tt.bridge
+
+			err := validateStaticNetwork(context.Background(), newStaticNetworkClient(), template, cluster)
+			if tt.expectError == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+				t.Errorf("expected error containing %q, got %v", tt.expectError, err)
+			}
+		})
+	}
+}
+
+func TestValidateStaticNetwork_ProviderError(t *testing.T) {
+	hypervisorClient := &provider.MockHypervisorClient{
+		GetNodeNetworksFunc: func(ctx context.Context, node string) ([]provider.NetworkInfo, error) {
+			return nil, errors.New("node is offline")
+		},
+	}
+	template := newRunnerTemplate()
+	template.Spec.Network.StaticConfig = &hypervisorv1alpha1.StaticNetworkConfig{IP: "192.168.1.50/24", Gateway: "192.168.1.1"}
+	cluster := newTestCluster()
+	cluster.Spec.Nodes = []string{"pve1"}
+	cluster.Spec.DefaultNetwork = "vmbr0"
+
+	if err := validateStaticNetwork(context.Background(), hypervisorClient, template, cluster); err == nil {
+		t.Errorf("expected the provider error to fail validation")
+	}
+}
+
+func TestHypervisorMachineTemplateReconciler_validateWithProviderStaticNetwork(t *testing.T) {
+	template := newRunnerTemplate()
+	template.Spec.Template.Proxmox = &hypervisorv1alpha1.ProxmoxTemplateSpec{TemplateID: 9000}
+	template.Spec.Network.StaticConfig = &hypervisorv1alpha1.StaticNetworkConfig{IP: "10.0.0.50/24", Gateway: "10.0.0.1"}
+	cluster := newTestCluster()
+	cluster.Spec.Nodes = []string{"pve1"}
+	cluster.Spec.DefaultNetwork = "vmbr0"
+
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	r := &HypervisorMachineTemplateReconciler{
+		Client:          fake.NewClientBuilder().WithScheme(scheme).WithObjects(newTestCredentialsSecret()).Build(),
+		ProviderFactory: provider.NewMockClientFactoryWithClient(newStaticNetworkClient()),
+	}
+
+	err := r.validateWithProvider(context.Background(), template, cluster)
+	if err == nil || !strings.Contains(err.Error(), "outside subnet") {
+		t.Errorf("expected static IP outside the bridge subnet to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DefaultStatusUpdateRetries is how many times a status update is retried after a conflict
+const DefaultStatusUpdateRetries = 3
+
+// updateStatusWithRetry writes obj's status. When the write conflicts because obj is stale,
+// obj is re-fetched, applyStatus re-applies the computed status to it and the write is
+// retried, at most retries times (DefaultStatusUpdateRetries when retries is not positive).
+func updateStatusWithRetry(ctx context.Context, c client.Client, obj client.Object, retries int, applyStatus func()) error {
+	if retries <= 0 {
+		retries = DefaultStatusUpdateRetries
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := c.Status().Update(ctx, obj)
+		if err == nil || !errors.IsConflict(err) || attempt >= retries {
+			return err
+		}
+		if err := c.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+			return fmt.Errorf("failed to re-fetch %s after a status conflict: %w", client.ObjectKeyFromObject(obj), err)
+		}
+		applyStatus()
+	}
+}
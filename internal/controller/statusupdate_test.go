@@ -0,0 +1,107 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	hypervisorv1alpha1 "github.com/codihuston/hyperfleet-operator/api/v1alpha1"
+)
+
+func TestHypervisorClusterReconciler_updateStatusConflict(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = hypervisorv1alpha1.AddToScheme(scheme)
+
+	cluster := newTestCluster()
+	statusUpdates := 0
+	c := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(cluster).WithObjects(cluster).
+		WithInterceptorFuncs(interceptor.Funcs{
+			SubResourceUpdate: func(ctx context.Context, c client.Client, subResource string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+				statusUpdates++
+				return c.SubResource(subResource).Update(ctx, obj, opts...)
+			},
+		}).Build()
+
+	// Hold a copy of the cluster, then change it on the server so the copy goes stale
+	stale := &hypervisorv1alpha1.HypervisorCluster{}
+	if err := c.Get(context.Background(), client.ObjectKeyFromObject(cluster), stale); err != nil {
+		t.Fatalf("Failed to get cluster: %v", err)
+	}
+	current := stale.DeepCopy()
+	current.Labels = map[string]string{"team": "runners"}
+	if err := c.Update(context.Background(), current); err != nil {
+		t.Fatalf("Failed to update cluster: %v", err)
+	}
+
+	r := &HypervisorClusterReconciler{Client: c, Scheme: scheme}
+	result := &ConnectionResult{Success: true, Message: "Successfully connected to proxmox cluster", TestedAt: metav1.Now()}
+	if err := r.updateStatus(context.Background(), stale, result); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	if statusUpdates != 2 {
+		t.Errorf("Expected the conflicting update to be retried once, got %d updates", statusUpdates)
+	}
+	if stale.ResourceVersion == current.ResourceVersion || stale.Labels["team"] != "runners" {
+		t.Errorf("Expected the cluster to be re-fetched, got resourceVersion %s and labels %v", stale.ResourceVersion, stale.Labels)
+	}
+
+	stored := &hypervisorv1alpha1.HypervisorCluster{}
+	if err := c.Get(context.Background(), client.ObjectKeyFromObject(cluster), stored); err != nil {
+		t.Fatalf("Failed to get cluster: %v", err)
+	}
+	if stored.Status.Phase != hypervisorv1alpha1.ClusterPhaseReady {
+		t.Errorf("Expected the stored phase %s, got %s", hypervisorv1alpha1.ClusterPhaseReady, stored.Status.Phase)
+	}
+	if !meta.IsStatusConditionTrue(stored.Status.Conditions, ConditionReady) {
+		t.Errorf("Expected the stored Ready condition to be true, got %v", stored.Status.Conditions)
+	}
+}
+
+func TestUpdateStatusWithRetryGivesUp(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = hypervisorv1alpha1.AddToScheme(scheme)
+
+	cluster := newTestCluster()
+	statusUpdates := 0
+	c := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(cluster).WithObjects(cluster).
+		WithInterceptorFuncs(interceptor.Funcs{
+			SubResourceUpdate: func(_ context.Context, _ client.Client, _ string, obj client.Object, _ ...client.SubResourceUpdateOption) error {
+				statusUpdates++
+				return apierrors.NewConflict(schema.GroupResource{Resource: "hypervisorclusters"}, obj.GetName(), nil)
+			},
+		}).Build()
+
+	applied := 0
+	err := updateStatusWithRetry(context.Background(), c, cluster, 2, func() { applied++ })
+	if !apierrors.IsConflict(err) {
+		t.Fatalf("Expected a conflict error, got %v", err)
+	}
+	if statusUpdates != 3 || applied != 2 {
+		t.Errorf("Expected 3 updates and 2 re-applied statuses, got %d and %d", statusUpdates, applied)
+	}
+}
@@ -19,6 +19,6 @@ func (f *DefaultClientFactory) CreateClient(provider string, config *ClientConfi
 	case "proxmox":
 		return NewProxmoxClient(config, auth)
 	default:
-		return nil, fmt.Errorf("unsupported hypervisor provider: %s", provider)
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedProvider, provider)
 	}
 }
@@ -57,8 +57,8 @@ func TestDefaultClientFactory_CreateClient(t *testing.T) {
 			client, err := factory.CreateClient(tt.provider, config, auth)
 
 			if tt.expectError {
-				if err == nil {
-					t.Errorf("expected error but got none")
+				if !IsUnsupportedProvider(err) {
+					t.Errorf("expected ErrUnsupportedProvider but got %v", err)
 				}
 				if client != nil {
 					t.Errorf("expected nil client but got %v", client)
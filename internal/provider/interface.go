@@ -3,13 +3,212 @@ package provider
 import (
 	"context"
 	"crypto/tls"
+	"errors"
+	"time"
 )
 
+// ErrVMConflict reports that a VM already exists with a configuration other than the one requested
+var ErrVMConflict = errors.New("VM already exists with a conflicting configuration")
+
+// IsVMConflict reports whether err was caused by an existing VM other than the one requested
+func IsVMConflict(err error) bool {
+	return errors.Is(err, ErrVMConflict)
+}
+
+// ErrUnsupportedProvider reports a hypervisor provider no client exists for
+var ErrUnsupportedProvider = errors.New("unsupported hypervisor provider")
+
+// IsUnsupportedProvider reports whether err was caused by an unknown hypervisor provider
+func IsUnsupportedProvider(err error) bool {
+	return errors.Is(err, ErrUnsupportedProvider)
+}
+
+// ErrNotATemplate reports that a guest referenced as a template is a regular VM or container
+var ErrNotATemplate = errors.New("not a template")
+
+// IsNotATemplate reports whether err was caused by a guest that is not a template
+func IsNotATemplate(err error) bool {
+	return errors.Is(err, ErrNotATemplate)
+}
+
+// ErrRebootRequired reports that a VM configuration change was stored but only takes effect
+// once the VM is restarted
+var ErrRebootRequired = errors.New("reboot required")
+
+// IsRebootRequired reports whether err was caused by a change that waits for a VM restart
+func IsRebootRequired(err error) bool {
+	return errors.Is(err, ErrRebootRequired)
+}
+
+// ErrNestedVirtualizationUnsupported reports that a node's CPU lacks the virtualization
+// extension nested guests need
+var ErrNestedVirtualizationUnsupported = errors.New("nested virtualization not supported")
+
+// IsNestedVirtualizationUnsupported reports whether err was caused by a node that cannot run
+// nested guests
+func IsNestedVirtualizationUnsupported(err error) bool {
+	return errors.Is(err, ErrNestedVirtualizationUnsupported)
+}
+
+// ErrNoFreeVMID reports that every VM ID in the requested range is taken
+var ErrNoFreeVMID = errors.New("no free VM ID")
+
+// IsNoFreeVMID reports whether err was caused by a VM ID range with no free ID
+func IsNoFreeVMID(err error) bool {
+	return errors.Is(err, ErrNoFreeVMID)
+}
+
 // HypervisorClient defines the interface for hypervisor client adapters
 type HypervisorClient interface {
 	// TestConnection validates the connection to the hypervisor
 	TestConnection(ctx context.Context) (*ConnectionInfo, error)
 
+	// VMExists reports whether a VM with the given ID exists. A lookup failure
+	// is returned as an error rather than being reported as a missing VM.
+	VMExists(ctx context.Context, id int) (bool, error)
+
+	// NextAvailableVMID returns the lowest VM ID between rangeStart and rangeEnd, inclusive,
+	// that no guest or template in the cluster uses, or ErrNoFreeVMID when all are taken
+	NextAvailableVMID(ctx context.Context, rangeStart, rangeEnd int) (int, error)
+
+	// NextAvailableVMIDInPool is NextAvailableVMID for a guest of a resource pool: free IDs
+	// outside the ID ranges spanned by other pools' members are preferred, keeping tenants apart
+	NextAvailableVMIDInPool(ctx context.Context, pool string, rangeStart, rangeEnd int) (int, error)
+
+	// ValidateCreateRequest is a dry run of CloneVM: it checks the request's nodes, storage, pool
+	// and resources against the hypervisor, reporting every failure in one error, and creates nothing
+	ValidateCreateRequest(ctx context.Context, req *CloneRequest) error
+
+	// CloneVM clones a template or VM and returns a reference to the new VM
+	CloneVM(ctx context.Context, req *CloneRequest) (*VMRef, error)
+
+	// GetTemplate returns the template with the given ID; an ID that is not a template is an error
+	GetTemplate(ctx context.Context, id int) (*TemplateInfo, error)
+
+	// GetTemplateDiskSizes returns the size in GiB, rounded up, of each of a template's disks keyed
+	// by drive name (e.g. "scsi0"); CD-ROM and cloud-init drives are left out
+	GetTemplateDiskSizes(ctx context.Context, templateID int) (map[string]int, error)
+
+	// GetTemplateCloneNodes returns the nodes a template can be cloned onto. Cloning onto another
+	// node than the template's needs all of its disks on shared storage, so otherwise only the
+	// template's own node is returned.
+	GetTemplateCloneNodes(ctx context.Context, templateID int) ([]string, error)
+
+	// ListNodes returns the hypervisor's nodes and whether each is online
+	ListNodes(ctx context.Context) ([]NodeInfo, error)
+
+	// GetNodeVersion returns the hypervisor software version the node runs, e.g. "8.2.4",
+	// for spotting mixed-version clusters
+	GetNodeVersion(ctx context.Context, node string) (string, error)
+
+	// ServerTime returns the hypervisor's current time, for detecting clock skew
+	ServerTime(ctx context.Context) (time.Time, error)
+
+	// SubscriptionStatus reports the hypervisor's support subscription
+	SubscriptionStatus(ctx context.Context) (*SubscriptionInfo, error)
+
+	// GetCapabilities reports which optional operations the hypervisor supports
+	GetCapabilities(ctx context.Context) (*Capabilities, error)
+
+	// MigrateVM moves a VM to another node; live keeps the VM running during the move
+	MigrateVM(ctx context.Context, ref VMRef, targetNode string, live bool) error
+
+	// GetVM returns the current state of a VM
+	GetVM(ctx context.Context, ref VMRef) (*VMInfo, error)
+
+	// ListVMs returns the VMs carrying tag, usually a ManagedByTag, so only the operator's own
+	// VMs are listed. Templates are never listed; an empty tag lists every VM.
+	ListVMs(ctx context.Context, tag string) ([]VMInfo, error)
+
+	// WaitForPowerState polls the VM until it reaches the target power state, failing with
+	// ErrPowerStateTimeout once the timeout elapses
+	WaitForPowerState(ctx context.Context, ref VMRef, target PowerState, timeout time.Duration) error
+
+	// DeleteVM stops the VM when it runs, starts deleting it and its disks and returns the ID
+	// of the deletion task without waiting for it. A VM that no longer exists returns an empty task ID.
+	DeleteVM(ctx context.Context, ref VMRef) (string, error)
+
+	// WaitForTask polls a task on the given node until it finishes, returning the task's
+	// failure if it did not succeed and ErrTaskTimeout once the timeout elapses
+	WaitForTask(ctx context.Context, node, taskID string, timeout time.Duration) error
+
+	// ReconfigureVM sets the VM's CPU and memory allocation. A running VM takes the change at
+	// once where its hot-plug settings allow; otherwise the change is stored for its next start
+	// and ErrRebootRequired is returned.
+	ReconfigureVM(ctx context.Context, ref VMRef, resources VMResources) error
+
+	// GetPoolUsage returns the resources allocated to the VMs in a resource pool
+	GetPoolUsage(ctx context.Context, pool string) (*PoolUsage, error)
+
+	// GetNodeNetworks returns the network bridges configured on a node
+	GetNodeNetworks(ctx context.Context, node string) ([]NetworkInfo, error)
+
+	// GetStorageStatus returns the capacity and free space of a storage as seen from a node
+	GetStorageStatus(ctx context.Context, node, storage string) (*StorageStatus, error)
+
+	// GetVMDescription returns the VM's description (notes)
+	GetVMDescription(ctx context.Context, ref VMRef) (string, error)
+
+	// SetVMDescription replaces the VM's description (notes)
+	SetVMDescription(ctx context.Context, ref VMRef, text string) error
+
+	// GetVMTags returns the VM's tags
+	GetVMTags(ctx context.Context, ref VMRef) ([]string, error)
+
+	// SetVMTags replaces the VM's tags; tags should be built with FormatTag
+	SetVMTags(ctx context.Context, ref VMRef, tags []string) error
+
+	// GetBootOrder returns the VM's boot devices in boot order
+	GetBootOrder(ctx context.Context, ref VMRef) ([]string, error)
+
+	// SetBootOrder replaces the VM's boot order; order lists device names such as "scsi0"
+	SetBootOrder(ctx context.Context, ref VMRef, order []string) error
+
+	// GetVMMACAddresses returns the MAC address of each of the VM's network interfaces,
+	// keyed by interface index (0 for net0)
+	GetVMMACAddresses(ctx context.Context, ref VMRef) (map[int]string, error)
+
+	// GetVMNetworkBridges returns the bridge each of the VM's network interfaces is attached to,
+	// keyed by interface index (0 for net0)
+	GetVMNetworkBridges(ctx context.Context, ref VMRef) (map[int]string, error)
+
+	// SetVMNetworkBridges attaches the VM's network interfaces, keyed by interface index, to the
+	// given bridges; other interfaces and each interface's other settings are kept
+	SetVMNetworkBridges(ctx context.Context, ref VMRef, bridges map[int]string) error
+
+	// ListSnapshots returns the VM's snapshots, oldest first
+	ListSnapshots(ctx context.Context, ref VMRef) ([]SnapshotInfo, error)
+
+	// CreateSnapshot snapshots the VM's current state under name. A snapshot that already
+	// has the name is kept as is, so retried creates do not fail or duplicate it.
+	CreateSnapshot(ctx context.Context, ref VMRef, name, description string) error
+
+	// ConvertToTemplate turns a VM into a template, e.g. a golden image the controller built.
+	// A running VM is stopped first, failing with ErrPowerStateTimeout if it does not stop
+	// within TemplateStopTimeout; a VM that already is a template is left as is.
+	ConvertToTemplate(ctx context.Context, ref VMRef) error
+
+	// SetCloudInitCredentials sets the user, password and SSH public keys the hypervisor renders
+	// into the VM's cloud-init data; empty values keep the VM's current ones
+	SetCloudInitCredentials(ctx context.Context, ref VMRef, user, password string, sshKeys []string) error
+
+	// StopVM stops a VM. A graceful stop asks the guest to shut down, through the guest agent
+	// or ACPI, and escalates to a hard stop if the VM is still running after timeout; otherwise
+	// the VM is powered off at once. A VM that is already stopped is left as is.
+	StopVM(ctx context.Context, ref VMRef, graceful bool, timeout time.Duration) error
+
+	// RebootVM restarts a running VM through a guest shutdown, which applies configuration
+	// changes left pending while it ran, e.g. by ReconfigureVM, and waits up to timeout for it
+	RebootVM(ctx context.Context, ref VMRef, timeout time.Duration) error
+
+	// GetHAStatus reports whether the VM is managed by the hypervisor's high-availability
+	// manager; a cluster without one reports every VM as unmanaged
+	GetHAStatus(ctx context.Context, ref VMRef) (*HAInfo, error)
+
+	// NestedVirtualizationFlag returns the CPU flag of the node's virtualization extension, vmx
+	// or svm, which VMs need to run nested guests, or ErrNestedVirtualizationUnsupported
+	NestedVirtualizationFlag(ctx context.Context, node string) (string, error)
+
 	// Close cleans up any resources used by the client
 	Close() error
 }
@@ -20,11 +219,228 @@ type ConnectionInfo struct {
 	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
+// NodeInfo describes a hypervisor node
+type NodeInfo struct {
+	Name   string `json:"name"`
+	Online bool   `json:"online"`
+	CPUs   int    `json:"cpus,omitempty"` // logical CPUs, zero when unknown (e.g. the node is offline)
+
+	// Memory of the node and how much of it is not in use, zero when unknown
+	MemoryMiB     int64 `json:"memoryMiB,omitempty"`
+	FreeMemoryMiB int64 `json:"freeMemoryMiB,omitempty"`
+}
+
+// SubscriptionState is the provider-neutral state of a support subscription
+type SubscriptionState string
+
+const (
+	SubscriptionActive  SubscriptionState = "Active"
+	SubscriptionExpired SubscriptionState = "Expired"
+	SubscriptionNone    SubscriptionState = "None"
+)
+
+// SubscriptionInfo describes the hypervisor's support subscription
+type SubscriptionInfo struct {
+	State       SubscriptionState `json:"state"`
+	Level       string            `json:"level,omitempty"`
+	NextDueDate string            `json:"nextDueDate,omitempty"`
+	Message     string            `json:"message,omitempty"`
+}
+
+// Capabilities describes optional hypervisor features
+type Capabilities struct {
+	LiveMigration bool `json:"liveMigration"`
+	// HA reports an active high-availability manager, which VMs may be placed under
+	HA bool `json:"ha"`
+}
+
+// HAInfo describes a VM's high-availability management
+type HAInfo struct {
+	Managed bool   `json:"managed"`
+	State   string `json:"state,omitempty"` // state requested of the HA manager, e.g. "started" or "stopped"
+}
+
+// VMRef identifies a VM on a hypervisor node
+type VMRef struct {
+	Node string `json:"node"`
+	ID   int    `json:"id"`
+}
+
+// TemplateInfo describes a VM template on the hypervisor
+type TemplateInfo struct {
+	ID   int    `json:"id"`
+	Name string `json:"name,omitempty"`
+	Node string `json:"node"`
+}
+
+// PowerState is the provider-neutral power state of a VM
+type PowerState string
+
+const (
+	PowerStateRunning   PowerState = "Running"
+	PowerStateStopped   PowerState = "Stopped"
+	PowerStatePaused    PowerState = "Paused"
+	PowerStateSuspended PowerState = "Suspended"
+	// PowerStateUnknown is reported when the provider state cannot be mapped
+	PowerStateUnknown PowerState = "Unknown"
+)
+
+// VMInfo describes the current state of a VM
+type VMInfo struct {
+	Ref        VMRef      `json:"ref"`
+	Name       string     `json:"name,omitempty"`
+	PowerState PowerState `json:"powerState"`
+
+	// Resources is the VM's configured CPU and memory allocation
+	Resources VMResources `json:"resources"`
+}
+
+// VMResources is a VM's CPU and memory allocation
+type VMResources struct {
+	CPUs      int   `json:"cpus"`
+	MemoryMiB int64 `json:"memoryMiB"`
+}
+
+// SnapshotInfo describes a VM snapshot
+type SnapshotInfo struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Parent      string    `json:"parent,omitempty"` // snapshot this one was taken on top of, empty for the first
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// PoolUsage is the resources allocated to the VMs in a resource pool
+type PoolUsage struct {
+	VMs       int   `json:"vms"`
+	CPUs      int   `json:"cpus"`
+	MemoryMiB int64 `json:"memoryMiB"`
+}
+
+// NetworkInfo describes a network bridge on a hypervisor node
+type NetworkInfo struct {
+	Name    string `json:"name"`              // bridge name, e.g. "vmbr0"
+	CIDR    string `json:"cidr,omitempty"`    // the node's address on the bridge in CIDR notation, empty when unaddressed
+	Gateway string `json:"gateway,omitempty"` // default gateway reached through the bridge, optional
+}
+
+// StorageStatus is the capacity of a storage, in bytes
+type StorageStatus struct {
+	Total     int64 `json:"total"`
+	Used      int64 `json:"used"`
+	Available int64 `json:"available"`
+
+	// Shared storage is reachable from every node, so guests on it can be cloned onto any node
+	Shared bool `json:"shared,omitempty"`
+}
+
+// CloneRequest describes a VM clone operation
+type CloneRequest struct {
+	SourceNode string // node hosting the source template
+	SourceID   int    // ID of the template or VM to clone
+	TargetNode string // node for the new VM, defaults to SourceNode
+	NewID      int    // ID for the new VM
+	Name       string // name for the new VM
+	Pool       string // resource pool for the new VM, optional
+	Storage    string // target storage for a full clone, optional
+	FullClone  bool   // full clone instead of a linked clone
+	VGA        string // display type for the new VM (e.g. "serial0", "std", "none"), optional; empty keeps the template's
+	GuestAgent *bool  // enable or disable the QEMU guest agent, optional; nil keeps the template's
+	OnBoot     *bool  // start the VM when its host boots, optional; nil keeps the template's
+
+	// Tags are applied to the new VM, e.g. its ManagedByTag; tags should be built with FormatTag
+	Tags []string
+
+	// SMBIOSUUID is the new VM's SMBIOS system UUID, e.g. for attestation; empty keeps the random
+	// one the hypervisor assigns
+	SMBIOSUUID string
+
+	// Description is the new VM's notes, e.g. identifying the object that owns it; empty keeps the template's
+	Description string
+
+	// AdoptExisting makes the clone idempotent: a VM that already has NewID and matches the
+	// request is returned as the result, while a mismatched one fails with ErrVMConflict.
+	// Without it any existing VM with NewID is an error.
+	AdoptExisting bool
+
+	// BootDiskGB grows the boot disk, the first device of DefaultBootOrder, to this size in GiB,
+	// optional; zero keeps the template's size. Disks can only grow, so a smaller size is rejected.
+	BootDiskGB int
+
+	Disks      []DiskConfig      // data disks attached after the boot disk, optional
+	BootOrder  []string          // boot devices in order, defaults to DefaultBootOrder
+	Network    *CloudInitNetwork // cloud-init network configuration, optional; nil keeps the template's
+	Interfaces []InterfaceConfig // network interfaces inherited from the template to reconfigure, optional
+
+	// Credentials are the cloud-init user credentials of the new VM, optional; nil keeps the template's
+	Credentials *CloudInitCredentials
+
+	// Hotplug enables CPU and memory hot-plug on the new VM, optional; nil keeps the template's
+	Hotplug *HotplugConfig
+	// MemoryMiB is the new VM's memory, optional; zero keeps the template's
+	MemoryMiB int64
+	// MinMemoryMiB enables memory ballooning, letting the hypervisor reclaim the VM's memory down
+	// to this floor, optional; zero keeps the template's setting. It must not exceed the VM's
+	// memory, MemoryMiB or else the template's.
+	MinMemoryMiB int64
+	// CPUs configures the VM with this many cores of one socket, optional; zero keeps the
+	// template's topology. The clone is rejected if the target node has fewer CPUs.
+	CPUs int
+	// CPULimit caps the VM's CPU time, in CPUs, optional; zero keeps the template's setting
+	CPULimit float64
+	// NestedVirtualization passes the node's virtualization extension through to the new VM, so
+	// it can run KVM guests itself. The clone is rejected if the node lacks the extension.
+	NestedVirtualization bool
+}
+
+// HotplugConfig selects the resources that can be added to a running VM without a reboot
+type HotplugConfig struct {
+	CPU    bool // change the VM's active CPUs within its CPU topology
+	Memory bool // add memory; this needs NUMA, which is enabled along with it
+}
+
+// CloudInitCredentials are the login credentials the hypervisor renders into a VM's cloud-init data
+type CloudInitCredentials struct {
+	User     string   // default user to create; empty keeps the template's
+	Password string   // password of the user; empty keeps the template's
+	SSHKeys  []string // public keys authorized for the user in authorized_keys format; empty keeps the template's
+}
+
+// InterfaceConfig reconfigures a network interface a VM inherits from its template
+type InterfaceConfig struct {
+	Name     string // device name, e.g. "net0"
+	Firewall *bool  // enable or disable the hypervisor firewall on the interface, optional; nil keeps the template's
+	Bridge   string // network bridge to attach the interface to, optional; empty keeps the template's
+}
+
+// CloudInitNetwork is the network configuration the hypervisor renders into a VM's cloud-init data
+type CloudInitNetwork struct {
+	DHCP    bool   // configure the primary interface with DHCP
+	IP      string // static address in CIDR notation, used when DHCP is false; empty keeps the template's
+	Gateway string // default gateway for the static address, optional
+
+	Nameservers   []string // resolver addresses, overriding DHCP-provided ones; empty keeps the template's
+	SearchDomains []string // resolver search domains; empty keeps the template's
+}
+
+// DefaultBootOrder boots from the primary disk a clone inherits from its template, so a
+// detached cloud-init drive or empty CD-ROM left first in the template's order is skipped
+var DefaultBootOrder = []string{"scsi0"}
+
+// DiskConfig describes a data disk attached to a VM
+type DiskConfig struct {
+	SizeGB  int    // disk size in GiB
+	Storage string // storage pool holding the disk
+	Format  string // image format (raw, qcow2, vmdk), optional
+}
+
 // ClientConfig contains common configuration for hypervisor clients
 type ClientConfig struct {
 	Endpoint  string
 	TLSConfig *tls.Config
-	Timeout   int // timeout in seconds
+	Timeout   int // seconds to wait for long-running hypervisor tasks (e.g. clones)
+
+	ConnectTimeout int // seconds to establish a connection, including the TLS handshake; 0 for no limit
+	RequestTimeout int // seconds allowed for each API request; 0 for no limit
 }
 
 // AuthConfig contains authentication information
@@ -2,13 +2,54 @@ package provider
 
 import (
 	"context"
+	"time"
 )
 
 // MockHypervisorClient implements HypervisorClient for testing
 type MockHypervisorClient struct {
-	TestConnectionFunc func(ctx context.Context) (*ConnectionInfo, error)
-	CloseFunc          func() error
-	Closed             bool
+	TestConnectionFunc           func(ctx context.Context) (*ConnectionInfo, error)
+	VMExistsFunc                 func(ctx context.Context, id int) (bool, error)
+	NextAvailableVMIDFunc        func(ctx context.Context, rangeStart, rangeEnd int) (int, error)
+	NextAvailableVMIDInPoolFunc  func(ctx context.Context, pool string, rangeStart, rangeEnd int) (int, error)
+	ValidateCreateRequestFunc    func(ctx context.Context, req *CloneRequest) error
+	CloneVMFunc                  func(ctx context.Context, req *CloneRequest) (*VMRef, error)
+	GetTemplateFunc              func(ctx context.Context, id int) (*TemplateInfo, error)
+	GetTemplateDiskSizesFunc     func(ctx context.Context, templateID int) (map[string]int, error)
+	GetTemplateCloneNodesFunc    func(ctx context.Context, templateID int) ([]string, error)
+	ListNodesFunc                func(ctx context.Context) ([]NodeInfo, error)
+	GetNodeVersionFunc           func(ctx context.Context, node string) (string, error)
+	ServerTimeFunc               func(ctx context.Context) (time.Time, error)
+	SubscriptionFunc             func(ctx context.Context) (*SubscriptionInfo, error)
+	GetCapabilitiesFunc          func(ctx context.Context) (*Capabilities, error)
+	MigrateVMFunc                func(ctx context.Context, ref VMRef, targetNode string, live bool) error
+	GetVMFunc                    func(ctx context.Context, ref VMRef) (*VMInfo, error)
+	ListVMsFunc                  func(ctx context.Context, tag string) ([]VMInfo, error)
+	WaitForPowerStateFunc        func(ctx context.Context, ref VMRef, target PowerState, timeout time.Duration) error
+	DeleteVMFunc                 func(ctx context.Context, ref VMRef) (string, error)
+	WaitForTaskFunc              func(ctx context.Context, node, taskID string, timeout time.Duration) error
+	ReconfigureVMFunc            func(ctx context.Context, ref VMRef, resources VMResources) error
+	GetPoolUsageFunc             func(ctx context.Context, pool string) (*PoolUsage, error)
+	GetNodeNetworksFunc          func(ctx context.Context, node string) ([]NetworkInfo, error)
+	GetStorageStatusFunc         func(ctx context.Context, node, storage string) (*StorageStatus, error)
+	GetVMDescriptionFunc         func(ctx context.Context, ref VMRef) (string, error)
+	SetVMDescriptionFunc         func(ctx context.Context, ref VMRef, text string) error
+	GetVMTagsFunc                func(ctx context.Context, ref VMRef) ([]string, error)
+	SetVMTagsFunc                func(ctx context.Context, ref VMRef, tags []string) error
+	GetBootOrderFunc             func(ctx context.Context, ref VMRef) ([]string, error)
+	SetBootOrderFunc             func(ctx context.Context, ref VMRef, order []string) error
+	GetVMMACAddressesFunc        func(ctx context.Context, ref VMRef) (map[int]string, error)
+	ListSnapshotsFunc            func(ctx context.Context, ref VMRef) ([]SnapshotInfo, error)
+	CreateSnapshotFunc           func(ctx context.Context, ref VMRef, name, description string) error
+	ConvertToTemplateFunc        func(ctx context.Context, ref VMRef) error
+	SetCloudInitCredentialsFunc  func(ctx context.Context, ref VMRef, user, password string, sshKeys []string) error
+	StopVMFunc                   func(ctx context.Context, ref VMRef, graceful bool, timeout time.Duration) error
+	RebootVMFunc                 func(ctx context.Context, ref VMRef, timeout time.Duration) error
+	GetHAStatusFunc              func(ctx context.Context, ref VMRef) (*HAInfo, error)
+	GetVMNetworkBridgesFunc      func(ctx context.Context, ref VMRef) (map[int]string, error)
+	SetVMNetworkBridgesFunc      func(ctx context.Context, ref VMRef, bridges map[int]string) error
+	NestedVirtualizationFlagFunc func(ctx context.Context, node string) (string, error)
+	CloseFunc                    func() error
+	Closed                       bool
 }
 
 // TestConnection implements HypervisorClient
@@ -25,6 +66,330 @@ func (m *MockHypervisorClient) TestConnection(ctx context.Context) (*ConnectionI
 	}, nil
 }
 
+// VMExists implements HypervisorClient
+func (m *MockHypervisorClient) VMExists(ctx context.Context, id int) (bool, error) {
+	if m.VMExistsFunc != nil {
+		return m.VMExistsFunc(ctx, id)
+	}
+	return false, nil
+}
+
+// NextAvailableVMID implements HypervisorClient
+func (m *MockHypervisorClient) NextAvailableVMID(ctx context.Context, rangeStart, rangeEnd int) (int, error) {
+	if m.NextAvailableVMIDFunc != nil {
+		return m.NextAvailableVMIDFunc(ctx, rangeStart, rangeEnd)
+	}
+	return rangeStart, nil
+}
+
+// NextAvailableVMIDInPool implements HypervisorClient
+func (m *MockHypervisorClient) NextAvailableVMIDInPool(ctx context.Context, pool string, rangeStart, rangeEnd int) (int, error) {
+	if m.NextAvailableVMIDInPoolFunc != nil {
+		return m.NextAvailableVMIDInPoolFunc(ctx, pool, rangeStart, rangeEnd)
+	}
+	return rangeStart, nil
+}
+
+// ValidateCreateRequest implements HypervisorClient
+func (m *MockHypervisorClient) ValidateCreateRequest(ctx context.Context, req *CloneRequest) error {
+	if m.ValidateCreateRequestFunc != nil {
+		return m.ValidateCreateRequestFunc(ctx, req)
+	}
+	return nil
+}
+
+// CloneVM implements HypervisorClient
+func (m *MockHypervisorClient) CloneVM(ctx context.Context, req *CloneRequest) (*VMRef, error) {
+	if m.CloneVMFunc != nil {
+		return m.CloneVMFunc(ctx, req)
+	}
+	node := req.TargetNode
+	if node == "" {
+		node = req.SourceNode
+	}
+	return &VMRef{Node: node, ID: req.NewID}, nil
+}
+
+// GetTemplate implements HypervisorClient
+func (m *MockHypervisorClient) GetTemplate(ctx context.Context, id int) (*TemplateInfo, error) {
+	if m.GetTemplateFunc != nil {
+		return m.GetTemplateFunc(ctx, id)
+	}
+	return &TemplateInfo{ID: id, Name: "mock-template", Node: "mock-node"}, nil
+}
+
+// GetTemplateDiskSizes implements HypervisorClient
+func (m *MockHypervisorClient) GetTemplateDiskSizes(ctx context.Context, templateID int) (map[string]int, error) {
+	if m.GetTemplateDiskSizesFunc != nil {
+		return m.GetTemplateDiskSizesFunc(ctx, templateID)
+	}
+	return nil, nil
+}
+
+// GetTemplateCloneNodes implements HypervisorClient
+func (m *MockHypervisorClient) GetTemplateCloneNodes(ctx context.Context, templateID int) ([]string, error) {
+	if m.GetTemplateCloneNodesFunc != nil {
+		return m.GetTemplateCloneNodesFunc(ctx, templateID)
+	}
+	return nil, nil
+}
+
+// ListNodes implements HypervisorClient
+func (m *MockHypervisorClient) ListNodes(ctx context.Context) ([]NodeInfo, error) {
+	if m.ListNodesFunc != nil {
+		return m.ListNodesFunc(ctx)
+	}
+	return []NodeInfo{{Name: "mock-node", Online: true}}, nil
+}
+
+// GetNodeVersion implements HypervisorClient
+func (m *MockHypervisorClient) GetNodeVersion(ctx context.Context, node string) (string, error) {
+	if m.GetNodeVersionFunc != nil {
+		return m.GetNodeVersionFunc(ctx, node)
+	}
+	return "mock-1.0.0", nil
+}
+
+// ServerTime implements HypervisorClient
+func (m *MockHypervisorClient) ServerTime(ctx context.Context) (time.Time, error) {
+	if m.ServerTimeFunc != nil {
+		return m.ServerTimeFunc(ctx)
+	}
+	return time.Now(), nil
+}
+
+// SubscriptionStatus implements HypervisorClient
+func (m *MockHypervisorClient) SubscriptionStatus(ctx context.Context) (*SubscriptionInfo, error) {
+	if m.SubscriptionFunc != nil {
+		return m.SubscriptionFunc(ctx)
+	}
+	return &SubscriptionInfo{State: SubscriptionActive}, nil
+}
+
+// GetCapabilities implements HypervisorClient
+func (m *MockHypervisorClient) GetCapabilities(ctx context.Context) (*Capabilities, error) {
+	if m.GetCapabilitiesFunc != nil {
+		return m.GetCapabilitiesFunc(ctx)
+	}
+	return &Capabilities{LiveMigration: true}, nil
+}
+
+// MigrateVM implements HypervisorClient
+func (m *MockHypervisorClient) MigrateVM(ctx context.Context, ref VMRef, targetNode string, live bool) error {
+	if m.MigrateVMFunc != nil {
+		return m.MigrateVMFunc(ctx, ref, targetNode, live)
+	}
+	return nil
+}
+
+// GetVM implements HypervisorClient
+func (m *MockHypervisorClient) GetVM(ctx context.Context, ref VMRef) (*VMInfo, error) {
+	if m.GetVMFunc != nil {
+		return m.GetVMFunc(ctx, ref)
+	}
+	return &VMInfo{Ref: ref, PowerState: PowerStateRunning}, nil
+}
+
+// ListVMs implements HypervisorClient
+func (m *MockHypervisorClient) ListVMs(ctx context.Context, tag string) ([]VMInfo, error) {
+	if m.ListVMsFunc != nil {
+		return m.ListVMsFunc(ctx, tag)
+	}
+	return nil, nil
+}
+
+// WaitForPowerState implements HypervisorClient, polling GetVM unless WaitForPowerStateFunc is set
+func (m *MockHypervisorClient) WaitForPowerState(ctx context.Context, ref VMRef, target PowerState, timeout time.Duration) error {
+	if m.WaitForPowerStateFunc != nil {
+		return m.WaitForPowerStateFunc(ctx, ref, target, timeout)
+	}
+	return waitForPowerState(ctx, m.GetVM, ref, target, timeout)
+}
+
+// DeleteVM implements HypervisorClient
+func (m *MockHypervisorClient) DeleteVM(ctx context.Context, ref VMRef) (string, error) {
+	if m.DeleteVMFunc != nil {
+		return m.DeleteVMFunc(ctx, ref)
+	}
+	return "mock-task", nil
+}
+
+// WaitForTask implements HypervisorClient
+func (m *MockHypervisorClient) WaitForTask(ctx context.Context, node, taskID string, timeout time.Duration) error {
+	if m.WaitForTaskFunc != nil {
+		return m.WaitForTaskFunc(ctx, node, taskID, timeout)
+	}
+	return nil
+}
+
+// ReconfigureVM implements HypervisorClient
+func (m *MockHypervisorClient) ReconfigureVM(ctx context.Context, ref VMRef, resources VMResources) error {
+	if m.ReconfigureVMFunc != nil {
+		return m.ReconfigureVMFunc(ctx, ref, resources)
+	}
+	return nil
+}
+
+// GetPoolUsage implements HypervisorClient
+func (m *MockHypervisorClient) GetPoolUsage(ctx context.Context, pool string) (*PoolUsage, error) {
+	if m.GetPoolUsageFunc != nil {
+		return m.GetPoolUsageFunc(ctx, pool)
+	}
+	return &PoolUsage{}, nil
+}
+
+// GetNodeNetworks implements HypervisorClient
+func (m *MockHypervisorClient) GetNodeNetworks(ctx context.Context, node string) ([]NetworkInfo, error) {
+	if m.GetNodeNetworksFunc != nil {
+		return m.GetNodeNetworksFunc(ctx, node)
+	}
+	return nil, nil
+}
+
+// GetStorageStatus implements HypervisorClient
+func (m *MockHypervisorClient) GetStorageStatus(ctx context.Context, node, storage string) (*StorageStatus, error) {
+	if m.GetStorageStatusFunc != nil {
+		return m.GetStorageStatusFunc(ctx, node, storage)
+	}
+	return &StorageStatus{}, nil
+}
+
+// GetVMDescription implements HypervisorClient
+func (m *MockHypervisorClient) GetVMDescription(ctx context.Context, ref VMRef) (string, error) {
+	if m.GetVMDescriptionFunc != nil {
+		return m.GetVMDescriptionFunc(ctx, ref)
+	}
+	return "", nil
+}
+
+// SetVMDescription implements HypervisorClient
+func (m *MockHypervisorClient) SetVMDescription(ctx context.Context, ref VMRef, text string) error {
+	if m.SetVMDescriptionFunc != nil {
+		return m.SetVMDescriptionFunc(ctx, ref, text)
+	}
+	return nil
+}
+
+// GetVMTags implements HypervisorClient
+func (m *MockHypervisorClient) GetVMTags(ctx context.Context, ref VMRef) ([]string, error) {
+	if m.GetVMTagsFunc != nil {
+		return m.GetVMTagsFunc(ctx, ref)
+	}
+	return nil, nil
+}
+
+// SetVMTags implements HypervisorClient
+func (m *MockHypervisorClient) SetVMTags(ctx context.Context, ref VMRef, tags []string) error {
+	if m.SetVMTagsFunc != nil {
+		return m.SetVMTagsFunc(ctx, ref, tags)
+	}
+	return nil
+}
+
+// GetBootOrder implements HypervisorClient
+func (m *MockHypervisorClient) GetBootOrder(ctx context.Context, ref VMRef) ([]string, error) {
+	if m.GetBootOrderFunc != nil {
+		return m.GetBootOrderFunc(ctx, ref)
+	}
+	return []string{"scsi0"}, nil
+}
+
+// SetBootOrder implements HypervisorClient
+func (m *MockHypervisorClient) SetBootOrder(ctx context.Context, ref VMRef, order []string) error {
+	if m.SetBootOrderFunc != nil {
+		return m.SetBootOrderFunc(ctx, ref, order)
+	}
+	return nil
+}
+
+// GetVMMACAddresses implements HypervisorClient
+func (m *MockHypervisorClient) GetVMMACAddresses(ctx context.Context, ref VMRef) (map[int]string, error) {
+	if m.GetVMMACAddressesFunc != nil {
+		return m.GetVMMACAddressesFunc(ctx, ref)
+	}
+	return map[int]string{0: "BC:24:11:00:00:01"}, nil
+}
+
+// ListSnapshots implements HypervisorClient
+func (m *MockHypervisorClient) ListSnapshots(ctx context.Context, ref VMRef) ([]SnapshotInfo, error) {
+	if m.ListSnapshotsFunc != nil {
+		return m.ListSnapshotsFunc(ctx, ref)
+	}
+	return nil, nil
+}
+
+// CreateSnapshot implements HypervisorClient
+func (m *MockHypervisorClient) CreateSnapshot(ctx context.Context, ref VMRef, name, description string) error {
+	if m.CreateSnapshotFunc != nil {
+		return m.CreateSnapshotFunc(ctx, ref, name, description)
+	}
+	return nil
+}
+
+// ConvertToTemplate implements HypervisorClient
+func (m *MockHypervisorClient) ConvertToTemplate(ctx context.Context, ref VMRef) error {
+	if m.ConvertToTemplateFunc != nil {
+		return m.ConvertToTemplateFunc(ctx, ref)
+	}
+	return nil
+}
+
+// SetCloudInitCredentials implements HypervisorClient
+func (m *MockHypervisorClient) SetCloudInitCredentials(ctx context.Context, ref VMRef, user, password string, sshKeys []string) error {
+	if m.SetCloudInitCredentialsFunc != nil {
+		return m.SetCloudInitCredentialsFunc(ctx, ref, user, password, sshKeys)
+	}
+	return nil
+}
+
+// StopVM implements HypervisorClient
+func (m *MockHypervisorClient) StopVM(ctx context.Context, ref VMRef, graceful bool, timeout time.Duration) error {
+	if m.StopVMFunc != nil {
+		return m.StopVMFunc(ctx, ref, graceful, timeout)
+	}
+	return nil
+}
+
+// GetVMNetworkBridges implements HypervisorClient
+func (m *MockHypervisorClient) GetVMNetworkBridges(ctx context.Context, ref VMRef) (map[int]string, error) {
+	if m.GetVMNetworkBridgesFunc != nil {
+		return m.GetVMNetworkBridgesFunc(ctx, ref)
+	}
+	return map[int]string{}, nil
+}
+
+// SetVMNetworkBridges implements HypervisorClient
+func (m *MockHypervisorClient) SetVMNetworkBridges(ctx context.Context, ref VMRef, bridges map[int]string) error {
+	if m.SetVMNetworkBridgesFunc != nil {
+		return m.SetVMNetworkBridgesFunc(ctx, ref, bridges)
+	}
+	return nil
+}
+
+// RebootVM implements HypervisorClient
+func (m *MockHypervisorClient) RebootVM(ctx context.Context, ref VMRef, timeout time.Duration) error {
+	if m.RebootVMFunc != nil {
+		return m.RebootVMFunc(ctx, ref, timeout)
+	}
+	return nil
+}
+
+// GetHAStatus implements HypervisorClient
+func (m *MockHypervisorClient) GetHAStatus(ctx context.Context, ref VMRef) (*HAInfo, error) {
+	if m.GetHAStatusFunc != nil {
+		return m.GetHAStatusFunc(ctx, ref)
+	}
+	return &HAInfo{}, nil
+}
+
+// NestedVirtualizationFlag implements HypervisorClient
+func (m *MockHypervisorClient) NestedVirtualizationFlag(ctx context.Context, node string) (string, error) {
+	if m.NestedVirtualizationFlagFunc != nil {
+		return m.NestedVirtualizationFlagFunc(ctx, node)
+	}
+	return nestedVirtualizationFlags[0], nil
+}
+
 // Close implements HypervisorClient
 func (m *MockHypervisorClient) Close() error {
 	m.Closed = true
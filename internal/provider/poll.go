@@ -0,0 +1,26 @@
+package provider
+
+import "time"
+
+// pollBackoffFactor is how much the delay between polls grows after each poll
+const pollBackoffFactor = 2
+
+// pollBackoff spaces out the polls of a long-running operation: the delay starts at an
+// initial interval, so quick operations are seen promptly, and doubles after each poll up
+// to a cap, so long ones do not load the API
+type pollBackoff struct {
+	delay time.Duration
+	limit time.Duration
+}
+
+// newPollBackoff returns a pollBackoff starting at initial and growing to limit
+func newPollBackoff(initial, limit time.Duration) *pollBackoff {
+	return &pollBackoff{delay: initial, limit: max(initial, limit)}
+}
+
+// next returns the delay before the next poll and grows the one after it
+func (b *pollBackoff) next() time.Duration {
+	delay := b.delay
+	b.delay = min(b.delay*pollBackoffFactor, b.limit)
+	return delay
+}
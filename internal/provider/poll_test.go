@@ -0,0 +1,98 @@
+package provider
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPollBackoff(t *testing.T) {
+	backoff := newPollBackoff(500*time.Millisecond, 5*time.Second)
+
+	var delays []time.Duration
+	for range 6 {
+		delays = append(delays, backoff.next())
+	}
+	expected := []time.Duration{
+		500 * time.Millisecond, time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second,
+	}
+	if !slices.Equal(delays, expected) {
+		t.Errorf("expected delays %v, got %v", expected, delays)
+	}
+
+	// A cap below the initial interval keeps polling at the initial interval
+	backoff = newPollBackoff(time.Second, time.Millisecond)
+	if first, second := backoff.next(), backoff.next(); first != time.Second || second != time.Second {
+		t.Errorf("expected a fixed 1s delay, got %v then %v", first, second)
+	}
+}
+
+func TestWaitForTaskBackoff(t *testing.T) {
+	defer func(interval, maxInterval time.Duration) {
+		TaskPollInterval, TaskMaxPollInterval = interval, maxInterval
+	}(TaskPollInterval, TaskMaxPollInterval)
+	TaskPollInterval, TaskMaxPollInterval = time.Millisecond, time.Hour
+
+	t.Run("polls less often as the task runs on", func(t *testing.T) {
+		var polls []time.Time
+		getTask := func(ctx context.Context, node, taskID string) (*TaskStatus, error) {
+			polls = append(polls, time.Now())
+			return &TaskStatus{Running: true}, nil
+		}
+
+		err := waitForTask(context.Background(), getTask, "pve1", "UPID:clone", 200*time.Millisecond)
+		if !IsTaskTimeout(err) {
+			t.Fatalf("expected ErrTaskTimeout, got %v", err)
+		}
+		// Doubling from 1ms fits 8 polls in 200ms, where a fixed 1ms interval would take ~200
+		if len(polls) < 3 || len(polls) > 10 {
+			t.Fatalf("expected a handful of polls, got %d", len(polls))
+		}
+		first, last := polls[1].Sub(polls[0]), polls[len(polls)-1].Sub(polls[len(polls)-2])
+		if last <= first {
+			t.Errorf("expected the interval to grow, first %v, last %v", first, last)
+		}
+	})
+
+	t.Run("quick completion is seen promptly", func(t *testing.T) {
+		polls := 0
+		getTask := func(ctx context.Context, node, taskID string) (*TaskStatus, error) {
+			polls++
+			return &TaskStatus{Running: polls < 3}, nil
+		}
+
+		start := time.Now()
+		if err := waitForTask(context.Background(), getTask, "pve1", "UPID:clone", time.Minute); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("expected the completed task to be seen within 1s, took %v", elapsed)
+		}
+		if polls != 3 {
+			t.Errorf("expected 3 polls, got %d", polls)
+		}
+	})
+}
+
+func TestWaitForPowerStateBackoff(t *testing.T) {
+	defer func(interval, maxInterval time.Duration) {
+		PowerStatePollInterval, PowerStateMaxPollInterval = interval, maxInterval
+	}(PowerStatePollInterval, PowerStateMaxPollInterval)
+	PowerStatePollInterval, PowerStateMaxPollInterval = time.Millisecond, 4*time.Millisecond
+
+	ref := VMRef{Node: "pve1", ID: 101}
+	polls := 0
+	getVM := func(ctx context.Context, ref VMRef) (*VMInfo, error) {
+		polls++
+		return &VMInfo{Ref: ref, PowerState: PowerStateRunning}, nil
+	}
+
+	// Capped at 4ms, 100ms of polling takes at least as many polls as the cap allows
+	if err := waitForPowerState(context.Background(), getVM, ref, PowerStateStopped, 100*time.Millisecond); err == nil {
+		t.Fatal("expected a timeout")
+	}
+	if polls < 10 {
+		t.Errorf("expected the interval to stop growing at the cap, got %d polls", polls)
+	}
+}
@@ -0,0 +1,58 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// PowerStatePollInterval is how long WaitForPowerState waits before first checking a VM's
+// power state again; the wait doubles after each check up to PowerStateMaxPollInterval
+var PowerStatePollInterval = 500 * time.Millisecond
+
+// PowerStateMaxPollInterval caps the wait between checks of a VM's power state
+var PowerStateMaxPollInterval = 5 * time.Second
+
+// TemplateStopTimeout bounds how long ConvertToTemplate waits for a running VM to stop
+var TemplateStopTimeout = 2 * time.Minute
+
+// HardStopTimeout bounds how long StopVM waits for a VM to stop after a hard stop
+var HardStopTimeout = 2 * time.Minute
+
+// ErrPowerStateTimeout reports that a VM did not reach the requested power state in time
+var ErrPowerStateTimeout = errors.New("timed out waiting for power state")
+
+// waitForPowerState polls getVM with a backoff from PowerStatePollInterval to
+// PowerStateMaxPollInterval until the VM reports the target power state. It gives up when the timeout elapses, ctx is done or a lookup fails.
+func waitForPowerState(ctx context.Context, getVM func(context.Context, VMRef) (*VMInfo, error),
+	ref VMRef, target PowerState, timeout time.Duration) error {
+	if timeout <= 0 {
+		return fmt.Errorf("invalid power state timeout: %v", timeout)
+	}
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	backoff := newPollBackoff(PowerStatePollInterval, PowerStateMaxPollInterval)
+	poll := time.NewTimer(backoff.next())
+	defer poll.Stop()
+
+	for {
+		info, err := getVM(ctx, ref)
+		if err != nil {
+			return fmt.Errorf("failed to get power state of VM %d: %w", ref.ID, err)
+		}
+		if info.PowerState == target {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("stopped waiting for VM %d to be %s: %w", ref.ID, target, ctx.Err())
+		case <-deadline.C:
+			return fmt.Errorf("%w %s: VM %d is still %s after %v", ErrPowerStateTimeout, target, ref.ID, info.PowerState, timeout)
+		case <-poll.C:
+			poll.Reset(backoff.next())
+		}
+	}
+}
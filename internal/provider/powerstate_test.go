@@ -0,0 +1,75 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWaitForPowerState(t *testing.T) {
+	defer func(interval time.Duration) { PowerStatePollInterval = interval }(PowerStatePollInterval)
+	PowerStatePollInterval = time.Millisecond
+
+	ref := VMRef{Node: "pve1", ID: 101}
+
+	t.Run("reaches target", func(t *testing.T) {
+		polls := 0
+		client := &MockHypervisorClient{
+			GetVMFunc: func(ctx context.Context, ref VMRef) (*VMInfo, error) {
+				polls++
+				if polls < 3 {
+					return &VMInfo{Ref: ref, PowerState: PowerStateStopped}, nil
+				}
+				return &VMInfo{Ref: ref, PowerState: PowerStateRunning}, nil
+			},
+		}
+
+		if err := client.WaitForPowerState(context.Background(), ref, PowerStateRunning, time.Second); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if polls != 3 {
+			t.Errorf("expected 3 polls, got %d", polls)
+		}
+	})
+
+	t.Run("times out", func(t *testing.T) {
+		client := &MockHypervisorClient{
+			GetVMFunc: func(ctx context.Context, ref VMRef) (*VMInfo, error) {
+				return &VMInfo{Ref: ref, PowerState: PowerStateStopped}, nil
+			},
+		}
+
+		err := client.WaitForPowerState(context.Background(), ref, PowerStateRunning, 20*time.Millisecond)
+		if !errors.Is(err, ErrPowerStateTimeout) {
+			t.Errorf("expected ErrPowerStateTimeout, got %v", err)
+		}
+	})
+
+	t.Run("context cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		client := &MockHypervisorClient{
+			GetVMFunc: func(ctx context.Context, ref VMRef) (*VMInfo, error) {
+				cancel()
+				return &VMInfo{Ref: ref, PowerState: PowerStateStopped}, nil
+			},
+		}
+
+		err := client.WaitForPowerState(ctx, ref, PowerStateRunning, time.Minute)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	})
+
+	t.Run("lookup fails", func(t *testing.T) {
+		client := &MockHypervisorClient{
+			GetVMFunc: func(ctx context.Context, ref VMRef) (*VMInfo, error) {
+				return nil, errors.New("VM 101 does not exist")
+			},
+		}
+
+		if err := client.WaitForPowerState(context.Background(), ref, PowerStateRunning, time.Minute); err == nil {
+			t.Error("expected lookup error")
+		}
+	})
+}
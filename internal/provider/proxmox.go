@@ -2,14 +2,58 @@ package provider
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"maps"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Telmate/proxmox-api-go/proxmox"
 )
 
+const (
+	// proxmoxVMResourcesPath lists all guests in the Proxmox cluster
+	proxmoxVMResourcesPath = "/cluster/resources?type=vm"
+	// proxmoxPoolsPath lists all resource pools
+	proxmoxPoolsPath = "/pools"
+	// proxmoxClusterStatusPath lists the cluster and its member nodes
+	proxmoxClusterStatusPath = "/cluster/status"
+	// proxmoxHAStatusPath lists the HA manager's state and the guests it manages
+	proxmoxHAStatusPath = "/cluster/ha/status/current"
+	// proxmoxNodesPath lists the nodes and their online status
+	proxmoxNodesPath = "/nodes"
+	// bytesPerMiB converts Proxmox memory sizes reported in bytes
+	bytesPerMiB = 1024 * 1024
+	// bytesPerGiB converts disk sizes given in GiB
+	bytesPerGiB = 1024 * bytesPerMiB
+	// haStateStopped is the HA resource state keeping a VM shut down
+	haStateStopped = "stopped"
+	// maxDataDisks is the number of SCSI slots left after the boot disk on scsi0
+	maxDataDisks = 30
+)
+
+// proxmoxAPI is the subset of the Proxmox API client used by ProxmoxClient
+type proxmoxAPI interface {
+	SetAPIToken(userID, token string)
+	Login(ctx context.Context, username string, password string, otp string) error
+	GetVersion(ctx context.Context) (proxmox.Version, error)
+	GetItemList(ctx context.Context, url string) (map[string]interface{}, error)
+	PostWithTask(ctx context.Context, params map[string]interface{}, url string) (string, error)
+	Put(ctx context.Context, params map[string]interface{}, url string) error
+	Delete(ctx context.Context, url string) error
+}
+
 // ProxmoxClient implements HypervisorClient for Proxmox VE
 type ProxmoxClient struct {
-	client *proxmox.Client
+	client proxmoxAPI
 	auth   *AuthConfig
 }
 
@@ -22,8 +66,13 @@ func NewProxmoxClient(config *ClientConfig, auth *AuthConfig) (*ProxmoxClient, e
 		return nil, fmt.Errorf("auth config is required")
 	}
 
-	// Create Proxmox client with TLS configuration
-	client, err := proxmox.NewClient(config.Endpoint, nil, "", config.TLSConfig, "", config.Timeout)
+	// Create Proxmox client with TLS configuration; plaintext endpoints never use TLS
+	tlsConfig := config.TLSConfig
+	if IsPlaintextEndpoint(config.Endpoint) {
+		tlsConfig = nil
+	}
+
+	client, err := proxmox.NewClient(config.Endpoint, newHTTPClient(config, tlsConfig), "", tlsConfig, "", config.Timeout)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Proxmox client: %w", err)
 	}
@@ -34,9 +83,34 @@ func NewProxmoxClient(config *ClientConfig, auth *AuthConfig) (*ProxmoxClient, e
 	}, nil
 }
 
-// TestConnection validates the connection to Proxmox VE
-func (p *ProxmoxClient) TestConnection(ctx context.Context) (*ConnectionInfo, error) {
-	// Authenticate based on type
+// newHTTPClient builds the HTTP client for the Proxmox API, applying the connection
+// timeout to dialing and the TLS handshake and the request timeout to each API call
+func newHTTPClient(config *ClientConfig, tlsConfig *tls.Config) *http.Client {
+	connectTimeout := time.Duration(config.ConnectTimeout) * time.Second
+	dialer := &net.Dialer{Timeout: connectTimeout}
+
+	return &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig:     tlsConfig,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: connectTimeout,
+			DisableCompression:  true,
+		},
+		Timeout: time.Duration(config.RequestTimeout) * time.Second,
+	}
+}
+
+// IsPlaintextEndpoint reports whether the endpoint uses the plain http scheme
+func IsPlaintextEndpoint(endpoint string) bool {
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(parsed.Scheme, "http")
+}
+
+// authenticate configures the underlying client with the configured credentials
+func (p *ProxmoxClient) authenticate(ctx context.Context) error {
 	switch p.auth.Type {
 	case "token":
 		// For API tokens, use SetAPIToken method
@@ -45,10 +119,18 @@ func (p *ProxmoxClient) TestConnection(ctx context.Context) (*ConnectionInfo, er
 		// For username/password, use Login method
 		err := p.client.Login(ctx, p.auth.Username, p.auth.Password, "")
 		if err != nil {
-			return nil, fmt.Errorf("failed to login to Proxmox: %w", err)
+			return fmt.Errorf("failed to login to Proxmox: %w", err)
 		}
 	default:
-		return nil, fmt.Errorf("unsupported authentication type: %s", p.auth.Type)
+		return fmt.Errorf("unsupported authentication type: %s", p.auth.Type)
+	}
+	return nil
+}
+
+// TestConnection validates the connection to Proxmox VE
+func (p *ProxmoxClient) TestConnection(ctx context.Context) (*ConnectionInfo, error) {
+	if err := p.authenticate(ctx); err != nil {
+		return nil, err
 	}
 
 	// Test connection by getting version info
@@ -66,6 +148,2256 @@ func (p *ProxmoxClient) TestConnection(ctx context.Context) (*ConnectionInfo, er
 	}, nil
 }
 
+// VMExists reports whether a guest with the given VM ID exists anywhere in the cluster.
+// API failures are returned as errors and never reported as an absent VM.
+func (p *ProxmoxClient) VMExists(ctx context.Context, id int) (bool, error) {
+	if id <= 0 {
+		return false, fmt.Errorf("invalid VM ID: %d", id)
+	}
+	if err := p.authenticate(ctx); err != nil {
+		return false, err
+	}
+
+	guest, err := p.findGuest(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	return guest != nil, nil
+}
+
+// NextAvailableVMID returns the lowest VM ID in [rangeStart, rangeEnd] not used by any guest in
+// the cluster. VMs, containers and templates share one ID space, so all of them count as taken.
+func (p *ProxmoxClient) NextAvailableVMID(ctx context.Context, rangeStart, rangeEnd int) (int, error) {
+	return p.NextAvailableVMIDInPool(ctx, "", rangeStart, rangeEnd)
+}
+
+// NextAvailableVMIDInPool returns a free VM ID in [rangeStart, rangeEnd] for a guest of the given
+// resource pool. The IDs from the lowest to the highest member of every other pool are that
+// pool's tenant range, so the lowest free ID outside all of them is preferred; only when there is
+// none is the lowest free ID of the range returned. An empty pool behaves like NextAvailableVMID.
+func (p *ProxmoxClient) NextAvailableVMIDInPool(ctx context.Context, pool string, rangeStart, rangeEnd int) (int, error) {
+	if rangeStart <= 0 || rangeEnd < rangeStart {
+		return 0, fmt.Errorf("invalid VM ID range: %d-%d", rangeStart, rangeEnd)
+	}
+	if err := p.authenticate(ctx); err != nil {
+		return 0, err
+	}
+
+	resources, err := p.client.GetItemList(ctx, proxmoxVMResourcesPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list Proxmox VMs: %w", err)
+	}
+	guests, ok := resources["data"].([]interface{})
+	if !ok {
+		return 0, fmt.Errorf("unexpected Proxmox VM list response: %v", resources)
+	}
+
+	taken := make(map[int]bool, len(guests))
+	spans := make(map[string]vmidSpan)
+	for _, guest := range guests {
+		attrs, ok := guest.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		vmid, ok := attrs["vmid"].(float64)
+		if !ok {
+			continue
+		}
+		taken[int(vmid)] = true
+		if member, _ := attrs["pool"].(string); member != "" && member != pool {
+			spans[member] = spans[member].extend(int(vmid))
+		}
+	}
+
+	if pool != "" {
+		for id := rangeStart; id <= rangeEnd; id++ {
+			if !taken[id] && !inAnySpan(spans, id) {
+				return id, nil
+			}
+		}
+	}
+	for id := rangeStart; id <= rangeEnd; id++ {
+		if !taken[id] {
+			return id, nil
+		}
+	}
+	return 0, fmt.Errorf("%w in range %d-%d", ErrNoFreeVMID, rangeStart, rangeEnd)
+}
+
+// vmidSpan is the VM IDs from the lowest to the highest member of a resource pool
+type vmidSpan struct {
+	low, high int
+}
+
+// extend returns the span grown to include id; the zero span holds no IDs
+func (s vmidSpan) extend(id int) vmidSpan {
+	if s.low == 0 {
+		return vmidSpan{low: id, high: id}
+	}
+	return vmidSpan{low: min(s.low, id), high: max(s.high, id)}
+}
+
+// inAnySpan reports whether id lies within any of the spans
+func inAnySpan(spans map[string]vmidSpan, id int) bool {
+	for _, span := range spans {
+		if id >= span.low && id <= span.high {
+			return true
+		}
+	}
+	return false
+}
+
+// findGuest returns the cluster resource entry of the guest with the given VM ID, nil when absent
+func (p *ProxmoxClient) findGuest(ctx context.Context, id int) (map[string]interface{}, error) {
+	resources, err := p.client.GetItemList(ctx, proxmoxVMResourcesPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Proxmox VMs: %w", err)
+	}
+
+	guests, ok := resources["data"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected Proxmox VM list response: %v", resources)
+	}
+
+	for _, guest := range guests {
+		attrs, ok := guest.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if vmid, ok := attrs["vmid"].(float64); ok && int(vmid) == id {
+			return attrs, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// GetTemplate looks up a Proxmox VM template by ID anywhere in the cluster
+func (p *ProxmoxClient) GetTemplate(ctx context.Context, id int) (*TemplateInfo, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("invalid template ID: %d", id)
+	}
+	if err := p.authenticate(ctx); err != nil {
+		return nil, err
+	}
+
+	guest, err := p.findGuest(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if guest == nil {
+		return nil, fmt.Errorf("template %d does not exist", id)
+	}
+	if template, _ := guest["template"].(float64); guest["type"] != "qemu" || template != 1 {
+		return nil, fmt.Errorf("VM %d is %w", id, ErrNotATemplate)
+	}
+
+	name, _ := guest["name"].(string)
+	node, _ := guest["node"].(string)
+	return &TemplateInfo{ID: id, Name: name, Node: node}, nil
+}
+
+// GetTemplateDiskSizes returns the size in GiB of each disk of a Proxmox template
+func (p *ProxmoxClient) GetTemplateDiskSizes(ctx context.Context, templateID int) (map[string]int, error) {
+	template, err := p.GetTemplate(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	disks, err := p.templateDisks(ctx, VMRef{Node: template.Node, ID: templateID})
+	if err != nil {
+		return nil, err
+	}
+	sizes := make(map[string]int, len(disks))
+	for key, size := range disks {
+		sizes[key] = int(ceilDiv(size, bytesPerGiB))
+	}
+	return sizes, nil
+}
+
+// GetTemplateCloneNodes returns the nodes a Proxmox template can be cloned onto: every node of
+// the cluster when all its disks are on shared storage, and otherwise only its own node, as
+// Proxmox refuses to clone a guest on local storage onto another node
+func (p *ProxmoxClient) GetTemplateCloneNodes(ctx context.Context, templateID int) ([]string, error) {
+	template, err := p.GetTemplate(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	storages, err := p.templateStorages(ctx, VMRef{Node: template.Node, ID: templateID})
+	if err != nil {
+		return nil, err
+	}
+	for _, storage := range storages {
+		status, err := p.GetStorageStatus(ctx, template.Node, storage)
+		if err != nil {
+			return nil, err
+		}
+		if !status.Shared {
+			return []string{template.Node}, nil
+		}
+	}
+
+	nodes, err := p.ListNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		names = append(names, node.Name)
+	}
+	return names, nil
+}
+
+// ValidateCreateRequest checks a clone request against the cluster without creating anything:
+// its fields, the source and target nodes, the VM ID, the pool, the boot disk size, the storage
+// formats and free space, and the target node's CPUs, memory and virtualization extension. Every failed check is
+// reported, joined into one error.
+func (p *ProxmoxClient) ValidateCreateRequest(ctx context.Context, req *CloneRequest) error {
+	if req == nil {
+		return fmt.Errorf("clone request is required")
+	}
+	if err := p.authenticate(ctx); err != nil {
+		return err
+	}
+
+	var errs []error
+	if err := validateCloneRequest(req); err != nil {
+		errs = append(errs, err)
+	}
+
+	targetNode := cloneTargetNode(req)
+	nodes, err := p.ListNodes(ctx)
+	if err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+	var target *NodeInfo
+	for i, node := range nodes {
+		if node.Name == targetNode {
+			target = &nodes[i]
+		}
+	}
+	if req.SourceNode != targetNode && !slices.ContainsFunc(nodes, func(node NodeInfo) bool { return node.Name == req.SourceNode }) {
+		errs = append(errs, fmt.Errorf("source node %q does not exist", req.SourceNode))
+	}
+	switch {
+	case target == nil:
+		errs = append(errs, fmt.Errorf("target node %q does not exist", targetNode))
+	case !target.Online:
+		errs = append(errs, fmt.Errorf("target node %q is offline", targetNode))
+	default:
+		if target.CPUs > 0 && req.CPULimit > float64(target.CPUs) {
+			errs = append(errs, fmt.Errorf("CPU limit %g exceeds the %d CPUs of node %s", req.CPULimit, target.CPUs, targetNode))
+		}
+		if target.MemoryMiB > 0 && req.MinMemoryMiB > target.MemoryMiB {
+			errs = append(errs, fmt.Errorf("minimum memory %d MiB exceeds the %d MiB of node %s", req.MinMemoryMiB, target.MemoryMiB, targetNode))
+		}
+		if req.CPUs > 0 {
+			if err := p.validateNodeCPUs(ctx, VMRef{Node: targetNode, ID: req.NewID}, req.CPUs, vmSockets); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		// Storage space is per node, so it is only checked on a usable target node
+		if err := p.validateStorageSpace(ctx, req); err != nil {
+			errs = append(errs, err)
+		}
+		if req.NestedVirtualization {
+			if _, err := p.NestedVirtualizationFlag(ctx, targetNode); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	if req.NewID > 0 && !req.AdoptExisting {
+		exists, err := p.VMExists(ctx, req.NewID)
+		switch {
+		case err != nil:
+			errs = append(errs, fmt.Errorf("failed to check VM ID %d: %w", req.NewID, err))
+		case exists:
+			errs = append(errs, fmt.Errorf("VM ID %d is already in use", req.NewID))
+		}
+	}
+	if req.Pool != "" {
+		found, err := p.poolExists(ctx, req.Pool)
+		switch {
+		case err != nil:
+			errs = append(errs, err)
+		case !found:
+			errs = append(errs, fmt.Errorf("pool %q does not exist", req.Pool))
+		}
+	}
+	if _, err := p.bootDiskGrowth(ctx, req); err != nil {
+		errs = append(errs, err)
+	}
+	if err := p.validateDiskFormats(ctx, req.Disks); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// CloneVM clones a Proxmox template or VM and waits for the clone task to finish
+func (p *ProxmoxClient) CloneVM(ctx context.Context, req *CloneRequest) (*VMRef, error) {
+	if err := validateCloneRequest(req); err != nil {
+		return nil, err
+	}
+
+	if err := p.authenticate(ctx); err != nil {
+		return nil, err
+	}
+	if req.AdoptExisting {
+		existing, err := p.findGuest(ctx, req.NewID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check VM ID %d: %w", req.NewID, err)
+		}
+		// A previous attempt already created the VM; reuse it if it is the one requested
+		if existing != nil {
+			if conflict := cloneConflict(req, existing); conflict != "" {
+				return nil, fmt.Errorf("%w: VM %d %s", ErrVMConflict, req.NewID, conflict)
+			}
+			return &VMRef{Node: cloneTargetNode(req), ID: req.NewID}, nil
+		}
+	} else {
+		exists, err := p.VMExists(ctx, req.NewID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check VM ID %d: %w", req.NewID, err)
+		}
+		if exists {
+			return nil, fmt.Errorf("VM ID %d is already in use", req.NewID)
+		}
+	}
+
+	if req.Pool != "" {
+		found, err := p.poolExists(ctx, req.Pool)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			return nil, fmt.Errorf("pool %q does not exist", req.Pool)
+		}
+	}
+	if err := p.validateDiskFormats(ctx, req.Disks); err != nil {
+		return nil, err
+	}
+	if err := p.validateStorageSpace(ctx, req); err != nil {
+		return nil, err
+	}
+	if req.CPUs > 0 {
+		if err := p.validateNodeCPUs(ctx, VMRef{Node: cloneTargetNode(req), ID: req.NewID}, req.CPUs, vmSockets); err != nil {
+			return nil, err
+		}
+	}
+	growBootDisk, err := p.bootDiskGrowth(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	var nestedFlag string
+	if req.NestedVirtualization {
+		flag, err := p.NestedVirtualizationFlag(ctx, cloneTargetNode(req))
+		if err != nil {
+			return nil, err
+		}
+		nestedFlag = flag
+	}
+
+	url := fmt.Sprintf("/nodes/%s/qemu/%d/clone", req.SourceNode, req.SourceID)
+	if _, err := p.client.PostWithTask(ctx, cloneParams(req), url); err != nil {
+		return nil, fmt.Errorf("failed to clone VM %d to %d: %w", req.SourceID, req.NewID, err)
+	}
+
+	ref := &VMRef{Node: cloneTargetNode(req), ID: req.NewID}
+
+	// Clones inherit the template's boot order, which may not start with the boot disk
+	params := scsiDiskParams(req.Disks)
+	params["boot"] = bootOrderParam(cloneBootOrder(req))
+	maps.Copy(params, cloudInitNetworkParams(req.Network))
+	maps.Copy(params, hotplugParams(req.Hotplug))
+	if creds := req.Credentials; creds != nil {
+		maps.Copy(params, cloudInitCredentialParams(creds.User, creds.Password, creds.SSHKeys))
+	}
+	if req.VGA != "" {
+		params["vga"] = req.VGA
+	}
+	if req.GuestAgent != nil {
+		params["agent"] = boolParam(*req.GuestAgent)
+	}
+	if req.OnBoot != nil {
+		params["onboot"] = boolParam(*req.OnBoot)
+	}
+	if nestedFlag != "" {
+		params["cpu"] = nestedVirtualizationCPU(nestedFlag)
+	}
+	// Memory and balloon are set together, as Proxmox rejects a balloon above the memory
+	if req.MemoryMiB > 0 {
+		params["memory"] = req.MemoryMiB
+	}
+	if req.MinMemoryMiB > 0 {
+		params["balloon"] = req.MinMemoryMiB
+	}
+	if req.CPUs > 0 {
+		params["sockets"] = vmSockets
+		params["cores"] = req.CPUs
+	}
+	if req.CPULimit > 0 {
+		params["cpulimit"] = strconv.FormatFloat(req.CPULimit, 'f', -1, 64)
+	}
+	if len(req.Tags) > 0 {
+		params["tags"] = strings.Join(req.Tags, ";")
+	}
+	if req.SMBIOSUUID != "" {
+		smbios, err := p.smbiosParams(ctx, *ref, req.SMBIOSUUID)
+		if err != nil {
+			return nil, err
+		}
+		maps.Copy(params, smbios)
+	}
+	if len(req.Interfaces) > 0 {
+		interfaces, err := p.interfaceParams(ctx, *ref, req.Interfaces)
+		if err != nil {
+			return nil, err
+		}
+		maps.Copy(params, interfaces)
+	}
+	if err := p.client.Put(ctx, params, vmConfigPath(*ref)); err != nil {
+		return nil, fmt.Errorf("failed to configure disks and boot order of VM %d: %w", req.NewID, err)
+	}
+	if growBootDisk {
+		resize := map[string]interface{}{"disk": DefaultBootOrder[0], "size": fmt.Sprintf("%dG", req.BootDiskGB)}
+		if err := p.client.Put(ctx, resize, fmt.Sprintf("/nodes/%s/qemu/%d/resize", ref.Node, ref.ID)); err != nil {
+			return nil, fmt.Errorf("failed to grow boot disk of VM %d to %dG: %w", req.NewID, req.BootDiskGB, err)
+		}
+	}
+
+	return ref, nil
+}
+
+// bootDiskGrowth reports whether the clone's boot disk must grow to the request's BootDiskGB,
+// rejecting a size below the source's boot disk, which cannot shrink
+func (p *ProxmoxClient) bootDiskGrowth(ctx context.Context, req *CloneRequest) (bool, error) {
+	if req.BootDiskGB <= 0 {
+		return false, nil
+	}
+	disks, err := p.templateDisks(ctx, VMRef{Node: req.SourceNode, ID: req.SourceID})
+	if err != nil {
+		return false, err
+	}
+	bootDisk := DefaultBootOrder[0]
+	current, ok := disks[bootDisk]
+	if !ok {
+		return false, fmt.Errorf("VM %d has no boot disk %s to grow", req.SourceID, bootDisk)
+	}
+	requested := int64(req.BootDiskGB) * bytesPerGiB
+	if requested < current {
+		return false, fmt.Errorf("boot disk size %dG is smaller than the %d GiB boot disk %s of VM %d; disks can only grow",
+			req.BootDiskGB, ceilDiv(current, bytesPerGiB), bootDisk, req.SourceID)
+	}
+	return requested > current, nil
+}
+
+// cloneTargetNode returns the node a clone is created on
+func cloneTargetNode(req *CloneRequest) string {
+	if req.TargetNode != "" {
+		return req.TargetNode
+	}
+	return req.SourceNode
+}
+
+// cloneConflict describes how an existing guest differs from the VM a clone request would
+// create, empty when the guest matches. Only identity is compared: the guest must be a VM,
+// not a template, with the requested name, node and pool, and must not belong to another
+// operator instance.
+func cloneConflict(req *CloneRequest, guest map[string]interface{}) string {
+	kind, _ := guest["type"].(string)
+	tags, _ := guest["tags"].(string)
+	name, _ := guest["name"].(string)
+	node, _ := guest["node"].(string)
+	pool, _ := guest["pool"].(string)
+	template, _ := guest["template"].(float64)
+
+	var conflicts []string
+	if kind != "qemu" {
+		conflicts = append(conflicts, fmt.Sprintf("is a %s guest", kind))
+	}
+	if template == 1 {
+		conflicts = append(conflicts, "is a template")
+	}
+	if name != req.Name {
+		conflicts = append(conflicts, fmt.Sprintf("is named %q, want %q", name, req.Name))
+	}
+	if target := cloneTargetNode(req); node != target {
+		conflicts = append(conflicts, fmt.Sprintf("is on node %s, want %s", node, target))
+	}
+	if pool != req.Pool {
+		conflicts = append(conflicts, fmt.Sprintf("is in pool %q, want %q", pool, req.Pool))
+	}
+	for _, tag := range parseTags(tags) {
+		if isInstanceTag(tag) && !slices.Contains(req.Tags, tag) {
+			conflicts = append(conflicts, fmt.Sprintf("is tagged %s for another instance", tag))
+		}
+	}
+	return strings.Join(conflicts, ", ")
+}
+
+// ListNodes returns the Proxmox cluster's nodes and their online status
+func (p *ProxmoxClient) ListNodes(ctx context.Context) ([]NodeInfo, error) {
+	if err := p.authenticate(ctx); err != nil {
+		return nil, err
+	}
+
+	nodes, err := p.client.GetItemList(ctx, proxmoxNodesPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Proxmox nodes: %w", err)
+	}
+	entries, ok := nodes["data"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected Proxmox nodes response: %v", nodes)
+	}
+
+	infos := make([]NodeInfo, 0, len(entries))
+	for _, entry := range entries {
+		node, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := node["node"].(string)
+		maxCPU, _ := node["maxcpu"].(float64)
+		// mem and maxmem are in bytes
+		usedMem, _ := node["mem"].(float64)
+		maxMem, _ := node["maxmem"].(float64)
+		infos = append(infos, NodeInfo{
+			Name:          name,
+			Online:        node["status"] == "online",
+			CPUs:          int(maxCPU),
+			MemoryMiB:     int64(maxMem) / bytesPerMiB,
+			FreeMemoryMiB: int64(maxMem-usedMem) / bytesPerMiB,
+		})
+	}
+	return infos, nil
+}
+
+// GetNodeVersion returns the Proxmox VE version the node runs
+func (p *ProxmoxClient) GetNodeVersion(ctx context.Context, node string) (string, error) {
+	if err := p.authenticate(ctx); err != nil {
+		return "", err
+	}
+
+	response, err := p.client.GetItemList(ctx, fmt.Sprintf("/nodes/%s/version", node))
+	if err != nil {
+		return "", fmt.Errorf("failed to get version of node %s: %w", node, err)
+	}
+	data, ok := response["data"].(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("unexpected Proxmox version response: %v", response)
+	}
+	version, _ := data["version"].(string)
+	if version == "" {
+		return "", fmt.Errorf("unexpected Proxmox version response: %v", response)
+	}
+	return version, nil
+}
+
+// ServerTime returns the current time of the first online Proxmox node
+func (p *ProxmoxClient) ServerTime(ctx context.Context) (time.Time, error) {
+	nodes, err := p.ListNodes(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	index := slices.IndexFunc(nodes, func(node NodeInfo) bool { return node.Online })
+	if index < 0 {
+		return time.Time{}, fmt.Errorf("no online Proxmox nodes to read the time from")
+	}
+	node := nodes[index].Name
+
+	response, err := p.client.GetItemList(ctx, fmt.Sprintf("/nodes/%s/time", node))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get time of node %s: %w", node, err)
+	}
+	data, ok := response["data"].(map[string]interface{})
+	if !ok {
+		return time.Time{}, fmt.Errorf("unexpected Proxmox time response: %v", response)
+	}
+	// "time" is the node's clock in seconds since the Unix epoch
+	seconds, ok := data["time"].(float64)
+	if !ok {
+		return time.Time{}, fmt.Errorf("unexpected Proxmox time response: %v", response)
+	}
+	return time.Unix(int64(seconds), 0), nil
+}
+
+// SubscriptionStatus reports the subscription of the Proxmox cluster. Subscriptions are
+// per node, so the least healthy subscription among the online nodes is reported.
+func (p *ProxmoxClient) SubscriptionStatus(ctx context.Context) (*SubscriptionInfo, error) {
+	if err := p.authenticate(ctx); err != nil {
+		return nil, err
+	}
+
+	nodes, err := p.client.GetItemList(ctx, proxmoxNodesPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Proxmox nodes: %w", err)
+	}
+	entries, ok := nodes["data"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected Proxmox nodes response: %v", nodes)
+	}
+
+	var worst *SubscriptionInfo
+	for _, entry := range entries {
+		node, ok := entry.(map[string]interface{})
+		if !ok || node["status"] != "online" {
+			continue
+		}
+		name, _ := node["node"].(string)
+
+		info, err := p.nodeSubscription(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if worst == nil || subscriptionRank(info.State) < subscriptionRank(worst.State) {
+			worst = info
+		}
+	}
+
+	if worst == nil {
+		return nil, fmt.Errorf("no online Proxmox nodes to read the subscription from")
+	}
+	return worst, nil
+}
+
+// nodeSubscription reads the subscription of a single Proxmox node
+func (p *ProxmoxClient) nodeSubscription(ctx context.Context, node string) (*SubscriptionInfo, error) {
+	subscription, err := p.client.GetItemList(ctx, fmt.Sprintf("/nodes/%s/subscription", node))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscription for node %s: %w", node, err)
+	}
+	data, ok := subscription["data"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected Proxmox subscription response: %v", subscription)
+	}
+
+	status, _ := data["status"].(string)
+	level, _ := data["level"].(string)
+	nextDueDate, _ := data["nextduedate"].(string)
+
+	state := proxmoxSubscriptionState(status)
+	message := fmt.Sprintf("node %s: subscription %s", node, strings.ToLower(string(state)))
+	if state == SubscriptionNone {
+		message = fmt.Sprintf("node %s: no subscription", node)
+	}
+
+	return &SubscriptionInfo{
+		State:       state,
+		Level:       level,
+		NextDueDate: nextDueDate,
+		Message:     message,
+	}, nil
+}
+
+// proxmoxSubscriptionState maps a Proxmox subscription status to a SubscriptionState.
+// Statuses other than active and lapsed ones (notfound, new, invalid) mean no usable subscription.
+func proxmoxSubscriptionState(status string) SubscriptionState {
+	switch strings.ToLower(status) {
+	case "active":
+		return SubscriptionActive
+	case "expired", "suspended":
+		return SubscriptionExpired
+	default:
+		return SubscriptionNone
+	}
+}
+
+// subscriptionRanking orders subscription states from least to most healthy
+var subscriptionRanking = []SubscriptionState{SubscriptionNone, SubscriptionExpired, SubscriptionActive}
+
+// subscriptionRank returns the position of a subscription state in subscriptionRanking
+func subscriptionRank(state SubscriptionState) int {
+	return slices.Index(subscriptionRanking, state)
+}
+
+// GetCapabilities reports which optional operations the Proxmox cluster supports.
+// Live migration needs at least two online cluster members.
+func (p *ProxmoxClient) GetCapabilities(ctx context.Context) (*Capabilities, error) {
+	if err := p.authenticate(ctx); err != nil {
+		return nil, err
+	}
+
+	status, err := p.client.GetItemList(ctx, proxmoxClusterStatusPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Proxmox cluster status: %w", err)
+	}
+
+	entries, ok := status["data"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected Proxmox cluster status response: %v", status)
+	}
+
+	onlineNodes := 0
+	for _, entry := range entries {
+		member, ok := entry.(map[string]interface{})
+		if !ok || member["type"] != "node" {
+			continue
+		}
+		if online, _ := member["online"].(float64); online == 1 {
+			onlineNodes++
+		}
+	}
+
+	entries, err = p.haStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Capabilities{LiveMigration: onlineNodes > 1, HA: haManagerActive(entries)}, nil
+}
+
+// haStatus returns the entries of the HA manager's status: the quorum, the manager itself,
+// each node's local resource manager and each managed guest
+func (p *ProxmoxClient) haStatus(ctx context.Context) ([]interface{}, error) {
+	status, err := p.client.GetItemList(ctx, proxmoxHAStatusPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Proxmox HA status: %w", err)
+	}
+	entries, ok := status["data"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected Proxmox HA status response: %v", status)
+	}
+	return entries, nil
+}
+
+// haManagerActive reports whether the HA status has a manager, which is only elected once HA
+// is in use in the cluster
+func haManagerActive(entries []interface{}) bool {
+	return slices.ContainsFunc(entries, func(entry interface{}) bool {
+		status, _ := entry.(map[string]interface{})
+		return status["type"] == "master"
+	})
+}
+
+// haResourceID returns the HA resource ID of a VM
+func haResourceID(ref VMRef) string {
+	return fmt.Sprintf("vm:%d", ref.ID)
+}
+
+// GetHAStatus reports whether the HA manager manages the VM, and the state requested of it.
+// Without an active HA manager, as in clusters not using HA, no VM is managed.
+func (p *ProxmoxClient) GetHAStatus(ctx context.Context, ref VMRef) (*HAInfo, error) {
+	if err := p.authenticate(ctx); err != nil {
+		return nil, err
+	}
+
+	entries, err := p.haStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !haManagerActive(entries) {
+		return &HAInfo{}, nil
+	}
+
+	sid := haResourceID(ref)
+	for _, entry := range entries {
+		service, ok := entry.(map[string]interface{})
+		if !ok || service["type"] != "service" || service["sid"] != sid {
+			continue
+		}
+		// request_state is the state last asked of the manager; older releases only report state
+		state, _ := service["request_state"].(string)
+		if state == "" {
+			state, _ = service["state"].(string)
+		}
+		return &HAInfo{Managed: true, State: state}, nil
+	}
+	return &HAInfo{}, nil
+}
+
+// removeFromHA stops the HA manager managing the VM, so it neither recovers nor restarts it
+func (p *ProxmoxClient) removeFromHA(ctx context.Context, ref VMRef) error {
+	if err := p.client.Delete(ctx, "/cluster/ha/resources/"+haResourceID(ref)); err != nil {
+		return fmt.Errorf("failed to remove VM %d from HA: %w", ref.ID, err)
+	}
+	return nil
+}
+
+// MigrateVM moves a VM to another node in the Proxmox cluster and waits for the migration task
+func (p *ProxmoxClient) MigrateVM(ctx context.Context, ref VMRef, targetNode string, live bool) error {
+	if targetNode == "" {
+		return fmt.Errorf("target node is required")
+	}
+	if targetNode == ref.Node {
+		return fmt.Errorf("VM %d is already on node %s", ref.ID, targetNode)
+	}
+
+	// GetCapabilities authenticates the client for the live migration request below
+	if live {
+		capabilities, err := p.GetCapabilities(ctx)
+		if err != nil {
+			return err
+		}
+		if !capabilities.LiveMigration {
+			return fmt.Errorf("live migration of VM %d is not supported by this cluster", ref.ID)
+		}
+	} else if err := p.authenticate(ctx); err != nil {
+		return err
+	}
+
+	params := map[string]interface{}{
+		"target": targetNode,
+		"online": 0,
+	}
+	if live {
+		params["online"] = 1
+	}
+
+	url := fmt.Sprintf("/nodes/%s/qemu/%d/migrate", ref.Node, ref.ID)
+	if _, err := p.client.PostWithTask(ctx, params, url); err != nil {
+		return fmt.Errorf("failed to migrate VM %d from %s to %s: %w", ref.ID, ref.Node, targetNode, err)
+	}
+	return nil
+}
+
+// GetVM returns the current state of a VM
+func (p *ProxmoxClient) GetVM(ctx context.Context, ref VMRef) (*VMInfo, error) {
+	if err := p.authenticate(ctx); err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("/nodes/%s/qemu/%d/status/current", ref.Node, ref.ID)
+	status, err := p.client.GetItemList(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status for VM %d: %w", ref.ID, err)
+	}
+
+	data, ok := status["data"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected Proxmox VM status response: %v", status)
+	}
+
+	name, _ := data["name"].(string)
+	rawStatus, _ := data["status"].(string)
+	qmpStatus, _ := data["qmpstatus"].(string)
+	// JSON numbers decode as float64; cpus counts every vCPU and maxmem is in bytes
+	cpus, _ := data["cpus"].(float64)
+	maxMem, _ := data["maxmem"].(float64)
+
+	return &VMInfo{
+		Ref:        ref,
+		Name:       name,
+		PowerState: proxmoxPowerState(rawStatus, qmpStatus),
+		Resources: VMResources{
+			CPUs:      int(cpus),
+			MemoryMiB: int64(maxMem) / bytesPerMiB,
+		},
+	}, nil
+}
+
+// ListVMs returns the VMs in the Proxmox cluster carrying tag
+func (p *ProxmoxClient) ListVMs(ctx context.Context, tag string) ([]VMInfo, error) {
+	if err := p.authenticate(ctx); err != nil {
+		return nil, err
+	}
+
+	resources, err := p.client.GetItemList(ctx, proxmoxVMResourcesPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Proxmox VMs: %w", err)
+	}
+	guests, ok := resources["data"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected Proxmox VM list response: %v", resources)
+	}
+
+	var vms []VMInfo
+	for _, guest := range guests {
+		attrs, ok := guest.(map[string]interface{})
+		if !ok || attrs["type"] != "qemu" {
+			continue
+		}
+		if template, _ := attrs["template"].(float64); template != 0 {
+			continue
+		}
+		tags, _ := attrs["tags"].(string)
+		if tag != "" && !slices.Contains(parseTags(tags), tag) {
+			continue
+		}
+
+		vmid, _ := attrs["vmid"].(float64)
+		node, _ := attrs["node"].(string)
+		name, _ := attrs["name"].(string)
+		status, _ := attrs["status"].(string)
+		maxCPU, _ := attrs["maxcpu"].(float64)
+		maxMem, _ := attrs["maxmem"].(float64)
+		vms = append(vms, VMInfo{
+			Ref:        VMRef{Node: node, ID: int(vmid)},
+			Name:       name,
+			PowerState: proxmoxPowerState(status, ""),
+			Resources: VMResources{
+				CPUs:      int(maxCPU),
+				MemoryMiB: int64(maxMem) / bytesPerMiB,
+			},
+		})
+	}
+	return vms, nil
+}
+
+// WaitForPowerState polls the VM's status until it reaches the target power state
+func (p *ProxmoxClient) WaitForPowerState(ctx context.Context, ref VMRef, target PowerState, timeout time.Duration) error {
+	return waitForPowerState(ctx, p.GetVM, ref, target, timeout)
+}
+
+// DeleteVM starts destroying the VM, removing it from HA, stopping it when it runs, purging it
+// from backup jobs and removing its disks, and returns the UPID of the destroy task. The client's Delete discards the response
+// carrying the UPID, so the task is looked up as the VM's newest destroy task on its node.
+func (p *ProxmoxClient) DeleteVM(ctx context.Context, ref VMRef) (string, error) {
+	if err := p.authenticate(ctx); err != nil {
+		return "", err
+	}
+
+	guest, err := p.findGuest(ctx, ref.ID)
+	if err != nil {
+		return "", err
+	}
+	if guest == nil {
+		return "", nil
+	}
+	// Address the node the cluster reports, in case the VM moved since ref was recorded
+	node := ref.Node
+	if guestNode, ok := guest["node"].(string); ok && guestNode != "" {
+		node = guestNode
+	}
+
+	// Take the VM out of HA first, so the manager does not recover it while it is destroyed
+	ha, err := p.GetHAStatus(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	if ha.Managed {
+		if err := p.removeFromHA(ctx, ref); err != nil {
+			return "", err
+		}
+	}
+
+	// Proxmox refuses to destroy a running VM, and reports paused guests as running too
+	if status, _ := guest["status"].(string); status == "running" {
+		if err := p.StopVM(ctx, VMRef{Node: node, ID: ref.ID}, false, HardStopTimeout); err != nil {
+			return "", err
+		}
+	}
+
+	url := fmt.Sprintf("/nodes/%s/qemu/%d?purge=1&destroy-unreferenced-disks=1", node, ref.ID)
+	if err := p.client.Delete(ctx, url); err != nil {
+		return "", fmt.Errorf("failed to delete VM %d: %w", ref.ID, err)
+	}
+
+	tasks, err := p.client.GetItemList(ctx, fmt.Sprintf("/nodes/%s/tasks?vmid=%d&typefilter=qmdestroy&source=all&limit=1", node, ref.ID))
+	if err != nil {
+		return "", fmt.Errorf("failed to list delete tasks for VM %d: %w", ref.ID, err)
+	}
+	data, ok := tasks["data"].([]interface{})
+	if !ok || len(data) == 0 {
+		return "", fmt.Errorf("no delete task found for VM %d", ref.ID)
+	}
+	task, _ := data[0].(map[string]interface{})
+	upid, _ := task["upid"].(string)
+	if upid == "" {
+		return "", fmt.Errorf("unexpected Proxmox task list response: %v", tasks)
+	}
+	return upid, nil
+}
+
+// WaitForTask polls a Proxmox task's status until the task stops
+func (p *ProxmoxClient) WaitForTask(ctx context.Context, node, taskID string, timeout time.Duration) error {
+	if err := p.authenticate(ctx); err != nil {
+		return err
+	}
+	return waitForTask(ctx, p.taskStatus, node, taskID, timeout)
+}
+
+// taskStatus reads a Proxmox task's status; a stopped task succeeded when its exit status is OK or WARNINGS
+func (p *ProxmoxClient) taskStatus(ctx context.Context, node, taskID string) (*TaskStatus, error) {
+	// A UPID names the node running the task (UPID:<node>:...), which is authoritative
+	if fields := strings.Split(taskID, ":"); len(fields) > 1 && fields[0] == "UPID" && fields[1] != "" {
+		node = fields[1]
+	}
+
+	status, err := p.client.GetItemList(ctx, fmt.Sprintf("/nodes/%s/tasks/%s/status", node, url.PathEscape(taskID)))
+	if err != nil {
+		return nil, err
+	}
+
+	data, ok := status["data"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected Proxmox task status response: %v", status)
+	}
+
+	if state, _ := data["status"].(string); state == "running" {
+		return &TaskStatus{Running: true}, nil
+	}
+	exitStatus, _ := data["exitstatus"].(string)
+	if exitStatus == "OK" || strings.HasPrefix(exitStatus, "WARNINGS") {
+		return &TaskStatus{}, nil
+	}
+	return &TaskStatus{Err: fmt.Errorf("%s", exitStatus)}, nil
+}
+
+// ReconfigureVM sets the VM's CPU and memory allocation. The vCPUs are configured as
+// cores of a single socket. A running VM takes the changes its hot-plug settings allow
+// at once; the others apply at its next boot and ErrRebootRequired is returned.
+func (p *ProxmoxClient) ReconfigureVM(ctx context.Context, ref VMRef, resources VMResources) error {
+	if resources.CPUs <= 0 {
+		return fmt.Errorf("invalid CPU count: %d", resources.CPUs)
+	}
+	if resources.MemoryMiB <= 0 {
+		return fmt.Errorf("invalid memory size: %dMiB", resources.MemoryMiB)
+	}
+	if err := p.authenticate(ctx); err != nil {
+		return err
+	}
+	if err := p.validateNodeCPUs(ctx, ref, resources.CPUs, vmSockets); err != nil {
+		return err
+	}
+
+	response, err := p.client.GetItemList(ctx, vmConfigPath(ref))
+	if err != nil {
+		return fmt.Errorf("failed to get config for VM %d: %w", ref.ID, err)
+	}
+	config, ok := response["data"].(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("unexpected Proxmox VM config response: %v", response)
+	}
+	info, err := p.GetVM(ctx, ref)
+	if err != nil {
+		return err
+	}
+
+	params, pending := reconfigureParams(config, info, resources)
+	if len(params) == 0 {
+		return nil
+	}
+	if err := p.client.Put(ctx, params, vmConfigPath(ref)); err != nil {
+		return fmt.Errorf("failed to reconfigure VM %d: %w", ref.ID, err)
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: VM %d is running and its %s change applies after a restart",
+			ErrRebootRequired, ref.ID, strings.Join(pending, " and "))
+	}
+	return nil
+}
+
+// reconfigureParams builds the VM config update for a reconfiguration and lists the resources
+// whose change waits for a restart. A stopped VM gets its full allocation. A running VM with
+// CPU hot-plug changes its active CPUs through vcpus while they fit its CPU topology, and one
+// with memory hot-plug takes memory increases; any other change is left pending by Proxmox.
+func reconfigureParams(config map[string]interface{}, info *VMInfo, resources VMResources) (map[string]interface{}, []string) {
+	cpuParams := map[string]interface{}{
+		"sockets": vmSockets,
+		"cores":   resources.CPUs,
+	}
+	// The active CPU count would otherwise stay limited to the old one
+	if _, ok := config["vcpus"]; ok {
+		cpuParams["delete"] = "vcpus"
+	}
+
+	if info.PowerState != PowerStateRunning {
+		params := map[string]interface{}{"memory": resources.MemoryMiB}
+		maps.Copy(params, cpuParams)
+		return params, nil
+	}
+
+	features := hotplugFeatures(config["hotplug"])
+	params := map[string]interface{}{}
+	var pending []string
+	if current := info.Resources.CPUs; resources.CPUs != current {
+		topology := configInt(config, "cores", 1) * configInt(config, "sockets", 1)
+		if slices.Contains(features, hotplugCPU) && resources.CPUs <= topology {
+			params["vcpus"] = resources.CPUs
+		} else {
+			maps.Copy(params, cpuParams)
+			pending = append(pending, "CPU")
+		}
+	}
+	if current := info.Resources.MemoryMiB; resources.MemoryMiB != current {
+		params["memory"] = resources.MemoryMiB
+		if !slices.Contains(features, hotplugMemory) || resources.MemoryMiB < current {
+			pending = append(pending, "memory")
+		}
+	}
+	return params, pending
+}
+
+// Proxmox hot-plug features; defaultHotplugFeatures apply when a VM does not set "hotplug"
+const (
+	hotplugCPU    = "cpu"
+	hotplugMemory = "memory"
+)
+
+var defaultHotplugFeatures = []string{"network", "disk", "usb"}
+
+// hotplugFeatures parses a VM's "hotplug" option, which lists features or is 1 for the defaults
+// and 0 for none
+func hotplugFeatures(value interface{}) []string {
+	raw, ok := value.(string)
+	switch {
+	case !ok || raw == "1":
+		return defaultHotplugFeatures
+	case raw == "0":
+		return nil
+	default:
+		return strings.Split(raw, ",")
+	}
+}
+
+// hotplugParams builds the VM config parameters enabling the hot-plug of CPUs and memory
+// along with the default features
+func hotplugParams(hotplug *HotplugConfig) map[string]interface{} {
+	if hotplug == nil {
+		return map[string]interface{}{}
+	}
+	features := slices.Clone(defaultHotplugFeatures)
+	if hotplug.CPU {
+		features = append(features, hotplugCPU)
+	}
+	params := map[string]interface{}{}
+	if hotplug.Memory {
+		features = append(features, hotplugMemory)
+		// Proxmox only hot-plugs memory into VMs with NUMA enabled
+		params["numa"] = 1
+	}
+	params["hotplug"] = strings.Join(features, ",")
+	return params
+}
+
+// configInt returns a numeric VM config option, or fallback when the VM does not set it
+func configInt(config map[string]interface{}, key string, fallback int) int {
+	// JSON numbers decode as float64
+	value, ok := config[key].(float64)
+	if !ok {
+		return fallback
+	}
+	return int(value)
+}
+
+// vmSockets is the socket count VMs are configured with; their CPUs are all cores of one socket
+const vmSockets = 1
+
+// validateNodeCPUs rejects a CPU topology needing more logical CPUs than the VM's node has,
+// since Proxmox refuses to start such a VM. Nodes whose CPU count is unknown are not checked.
+func (p *ProxmoxClient) validateNodeCPUs(ctx context.Context, ref VMRef, cores, sockets int) error {
+	nodes, err := p.ListNodes(ctx)
+	if err != nil {
+		return err
+	}
+	idx := slices.IndexFunc(nodes, func(node NodeInfo) bool { return node.Name == ref.Node })
+	if idx < 0 {
+		return fmt.Errorf("node %s not found", ref.Node)
+	}
+
+	available := nodes[idx].CPUs
+	if required := cores * sockets; available > 0 && required > available {
+		return fmt.Errorf("VM %d needs %d CPUs (%d cores x %d sockets) but node %s has only %d",
+			ref.ID, required, cores, sockets, ref.Node, available)
+	}
+	return nil
+}
+
+// GetPoolUsage sums the CPU and memory allocated to the VMs in a resource pool.
+// Templates are pool members too but are not counted, since they never run.
+func (p *ProxmoxClient) GetPoolUsage(ctx context.Context, pool string) (*PoolUsage, error) {
+	if pool == "" {
+		return nil, fmt.Errorf("pool name is required")
+	}
+	if err := p.authenticate(ctx); err != nil {
+		return nil, err
+	}
+
+	response, err := p.client.GetItemList(ctx, "/pools/"+url.PathEscape(pool))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pool %s: %w", pool, err)
+	}
+	data, ok := response["data"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected Proxmox pool response: %v", response)
+	}
+	members, _ := data["members"].([]interface{})
+
+	usage := &PoolUsage{}
+	for _, entry := range members {
+		member, ok := entry.(map[string]interface{})
+		if !ok || member["type"] != "qemu" {
+			continue
+		}
+		if template, _ := member["template"].(float64); template == 1 {
+			continue
+		}
+		// JSON numbers decode as float64; maxmem is in bytes
+		maxCPU, _ := member["maxcpu"].(float64)
+		maxMem, _ := member["maxmem"].(float64)
+
+		usage.VMs++
+		usage.CPUs += int(maxCPU)
+		usage.MemoryMiB += int64(maxMem) / bytesPerMiB
+	}
+	return usage, nil
+}
+
+// GetNodeNetworks returns the Linux and Open vSwitch bridges configured on a Proxmox node
+func (p *ProxmoxClient) GetNodeNetworks(ctx context.Context, node string) ([]NetworkInfo, error) {
+	if node == "" {
+		return nil, fmt.Errorf("node name is required")
+	}
+	if err := p.authenticate(ctx); err != nil {
+		return nil, err
+	}
+
+	response, err := p.client.GetItemList(ctx, fmt.Sprintf("/nodes/%s/network?type=any_bridge", node))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list networks on node %s: %w", node, err)
+	}
+	entries, ok := response["data"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected Proxmox network response: %v", response)
+	}
+
+	networks := make([]NetworkInfo, 0, len(entries))
+	for _, entry := range entries {
+		attrs, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := attrs["iface"].(string)
+		cidr, _ := attrs["cidr"].(string)
+		gateway, _ := attrs["gateway"].(string)
+		networks = append(networks, NetworkInfo{Name: name, CIDR: cidr, Gateway: gateway})
+	}
+	return networks, nil
+}
+
+// GetStorageStatus returns the capacity and free space of a Proxmox storage on a node
+func (p *ProxmoxClient) GetStorageStatus(ctx context.Context, node, storage string) (*StorageStatus, error) {
+	if node == "" || storage == "" {
+		return nil, fmt.Errorf("node and storage names are required")
+	}
+	if err := p.authenticate(ctx); err != nil {
+		return nil, err
+	}
+
+	response, err := p.client.GetItemList(ctx, fmt.Sprintf("/nodes/%s/storage/%s/status", node, url.PathEscape(storage)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status of storage %q on node %s: %w", storage, node, err)
+	}
+	data, ok := response["data"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected Proxmox storage status response: %v", response)
+	}
+
+	total, _ := data["total"].(float64)
+	used, _ := data["used"].(float64)
+	available, _ := data["avail"].(float64)
+	shared, _ := data["shared"].(float64)
+	return &StorageStatus{Total: int64(total), Used: int64(used), Available: int64(available), Shared: shared == 1}, nil
+}
+
+// proxmoxPowerState maps a Proxmox guest status to a PowerState.
+// Proxmox reports paused and suspended guests as "running"; qmpstatus tells them apart.
+func proxmoxPowerState(status, qmpStatus string) PowerState {
+	switch status {
+	case "stopped":
+		return PowerStateStopped
+	case "running":
+		switch qmpStatus {
+		case "paused":
+			return PowerStatePaused
+		case "suspended":
+			return PowerStateSuspended
+		}
+		return PowerStateRunning
+	default:
+		return PowerStateUnknown
+	}
+}
+
+// GetVMDescription returns the notes shown for the VM in the Proxmox UI
+func (p *ProxmoxClient) GetVMDescription(ctx context.Context, ref VMRef) (string, error) {
+	if err := p.authenticate(ctx); err != nil {
+		return "", err
+	}
+
+	config, err := p.client.GetItemList(ctx, vmConfigPath(ref))
+	if err != nil {
+		return "", fmt.Errorf("failed to get config for VM %d: %w", ref.ID, err)
+	}
+
+	data, ok := config["data"].(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("unexpected Proxmox VM config response: %v", config)
+	}
+
+	// VMs without notes have no description key
+	description, _ := data["description"].(string)
+	return description, nil
+}
+
+// SetVMDescription replaces the notes shown for the VM in the Proxmox UI
+func (p *ProxmoxClient) SetVMDescription(ctx context.Context, ref VMRef, text string) error {
+	if err := p.authenticate(ctx); err != nil {
+		return err
+	}
+
+	params := map[string]interface{}{
+		"description": text,
+	}
+	if err := p.client.Put(ctx, params, vmConfigPath(ref)); err != nil {
+		return fmt.Errorf("failed to set description for VM %d: %w", ref.ID, err)
+	}
+	return nil
+}
+
+// GetVMTags returns the tags shown for the VM in the Proxmox UI
+func (p *ProxmoxClient) GetVMTags(ctx context.Context, ref VMRef) ([]string, error) {
+	if err := p.authenticate(ctx); err != nil {
+		return nil, err
+	}
+
+	config, err := p.client.GetItemList(ctx, vmConfigPath(ref))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config for VM %d: %w", ref.ID, err)
+	}
+
+	data, ok := config["data"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected Proxmox VM config response: %v", config)
+	}
+
+	raw, _ := data["tags"].(string)
+	return parseTags(raw), nil
+}
+
+// parseTags splits a Proxmox tags option. Proxmox stores tags as a single ";"-separated
+// string and omits the key when there are none.
+func parseTags(raw string) []string {
+	return strings.FieldsFunc(raw, func(r rune) bool {
+		return r == ';' || r == ',' || r == ' '
+	})
+}
+
+// SetVMTags replaces the tags shown for the VM in the Proxmox UI
+func (p *ProxmoxClient) SetVMTags(ctx context.Context, ref VMRef, tags []string) error {
+	if err := p.authenticate(ctx); err != nil {
+		return err
+	}
+
+	params := map[string]interface{}{
+		"tags": strings.Join(tags, ";"),
+	}
+	if len(tags) == 0 {
+		// An empty tags value is rejected; the option has to be deleted instead
+		params = map[string]interface{}{
+			"delete": "tags",
+		}
+	}
+	if err := p.client.Put(ctx, params, vmConfigPath(ref)); err != nil {
+		return fmt.Errorf("failed to set tags for VM %d: %w", ref.ID, err)
+	}
+	return nil
+}
+
+// GetBootOrder returns the VM's boot devices in boot order
+func (p *ProxmoxClient) GetBootOrder(ctx context.Context, ref VMRef) ([]string, error) {
+	if err := p.authenticate(ctx); err != nil {
+		return nil, err
+	}
+
+	config, err := p.client.GetItemList(ctx, vmConfigPath(ref))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config for VM %d: %w", ref.ID, err)
+	}
+
+	data, ok := config["data"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected Proxmox VM config response: %v", config)
+	}
+
+	boot, _ := data["boot"].(string)
+	return parseBootOrder(boot), nil
+}
+
+// nestedVirtualizationFlags are the CPU flags of the Intel (VT-x) and AMD (AMD-V) virtualization extensions
+var nestedVirtualizationFlags = []string{"vmx", "svm"}
+
+// NestedVirtualizationFlag returns the virtualization extension flag the node's CPU reports in its status
+func (p *ProxmoxClient) NestedVirtualizationFlag(ctx context.Context, node string) (string, error) {
+	if err := p.authenticate(ctx); err != nil {
+		return "", err
+	}
+
+	status, err := p.client.GetItemList(ctx, fmt.Sprintf("/nodes/%s/status", node))
+	if err != nil {
+		return "", fmt.Errorf("failed to get status of node %s: %w", node, err)
+	}
+	data, ok := status["data"].(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("unexpected Proxmox node status response: %v", status)
+	}
+	cpuInfo, _ := data["cpuinfo"].(map[string]interface{})
+	flags, _ := cpuInfo["flags"].(string)
+
+	cpuFlags := strings.Fields(flags)
+	for _, flag := range nestedVirtualizationFlags {
+		if slices.Contains(cpuFlags, flag) {
+			return flag, nil
+		}
+	}
+	return "", fmt.Errorf("%w: node %s does not report the vmx or svm CPU flag", ErrNestedVirtualizationUnsupported, node)
+}
+
+// nestedVirtualizationCPU returns the VM cpu option exposing the host CPU along with its
+// virtualization extension flag
+func nestedVirtualizationCPU(flag string) string {
+	return "host,flags=+" + flag
+}
+
+// SetCloudInitCredentials sets the ciuser, cipassword and sshkeys options of the VM's config
+func (p *ProxmoxClient) SetCloudInitCredentials(ctx context.Context, ref VMRef, user, password string, sshKeys []string) error {
+	params := cloudInitCredentialParams(user, password, sshKeys)
+	if len(params) == 0 {
+		return nil
+	}
+	if err := p.authenticate(ctx); err != nil {
+		return err
+	}
+
+	if err := p.client.Put(ctx, params, vmConfigPath(ref)); err != nil {
+		// The parameters hold the password, so only the VM is named
+		return fmt.Errorf("failed to set cloud-init credentials of VM %d: %w", ref.ID, err)
+	}
+	return nil
+}
+
+// cloudInitCredentialParams builds the VM config parameters Proxmox renders into the cloud-init
+// user configuration
+func cloudInitCredentialParams(user, password string, sshKeys []string) map[string]interface{} {
+	params := map[string]interface{}{}
+	if user != "" {
+		params["ciuser"] = user
+	}
+	if password != "" {
+		params["cipassword"] = password
+	}
+	if len(sshKeys) > 0 {
+		// Proxmox takes the keys newline-separated and percent-encoded, spaces as %20 rather than +
+		params["sshkeys"] = url.PathEscape(strings.Join(sshKeys, "\n"))
+	}
+	return params
+}
+
+// SetBootOrder replaces the VM's boot order
+func (p *ProxmoxClient) SetBootOrder(ctx context.Context, ref VMRef, order []string) error {
+	if err := validateBootOrder(order); err != nil {
+		return err
+	}
+	if err := p.authenticate(ctx); err != nil {
+		return err
+	}
+
+	params := map[string]interface{}{
+		"boot": bootOrderParam(order),
+	}
+	if err := p.client.Put(ctx, params, vmConfigPath(ref)); err != nil {
+		return fmt.Errorf("failed to set boot order for VM %d: %w", ref.ID, err)
+	}
+	return nil
+}
+
+// GetVMMACAddresses returns the MAC address of each network interface in the VM's config
+func (p *ProxmoxClient) GetVMMACAddresses(ctx context.Context, ref VMRef) (map[int]string, error) {
+	if err := p.authenticate(ctx); err != nil {
+		return nil, err
+	}
+
+	config, err := p.client.GetItemList(ctx, vmConfigPath(ref))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config for VM %d: %w", ref.ID, err)
+	}
+
+	data, ok := config["data"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected Proxmox VM config response: %v", config)
+	}
+
+	macs := make(map[int]string)
+	for key, value := range data {
+		suffix, ok := strings.CutPrefix(key, "net")
+		if !ok {
+			continue
+		}
+		index, err := strconv.Atoi(suffix)
+		if err != nil {
+			continue
+		}
+		device, _ := value.(string)
+		if mac := netMACAddress(device); mac != "" {
+			macs[index] = mac
+		}
+	}
+	return macs, nil
+}
+
+// GetVMNetworkBridges returns the bridge of each of the VM's network interfaces, keyed by index
+func (p *ProxmoxClient) GetVMNetworkBridges(ctx context.Context, ref VMRef) (map[int]string, error) {
+	if err := p.authenticate(ctx); err != nil {
+		return nil, err
+	}
+
+	config, err := p.client.GetItemList(ctx, vmConfigPath(ref))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config for VM %d: %w", ref.ID, err)
+	}
+
+	data, ok := config["data"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected Proxmox VM config response: %v", config)
+	}
+
+	bridges := make(map[int]string)
+	for key, value := range data {
+		suffix, ok := strings.CutPrefix(key, "net")
+		if !ok {
+			continue
+		}
+		index, err := strconv.Atoi(suffix)
+		if err != nil {
+			continue
+		}
+		device, _ := value.(string)
+		if bridge := netOption(device, "bridge"); bridge != "" {
+			bridges[index] = bridge
+		}
+	}
+	return bridges, nil
+}
+
+// SetVMNetworkBridges moves the VM's network interfaces to other bridges. Like interfaceParams
+// it rewrites each "netN" option from its current value, so the interface keeps its MAC address.
+func (p *ProxmoxClient) SetVMNetworkBridges(ctx context.Context, ref VMRef, bridges map[int]string) error {
+	if len(bridges) == 0 {
+		return nil
+	}
+	if err := p.authenticate(ctx); err != nil {
+		return err
+	}
+
+	interfaces := make([]InterfaceConfig, 0, len(bridges))
+	for _, index := range slices.Sorted(maps.Keys(bridges)) {
+		if bridges[index] == "" {
+			return fmt.Errorf("bridge is required for network interface net%d", index)
+		}
+		interfaces = append(interfaces, InterfaceConfig{Name: fmt.Sprintf("net%d", index), Bridge: bridges[index]})
+	}
+	params, err := p.interfaceParams(ctx, ref, interfaces)
+	if err != nil {
+		return err
+	}
+
+	if err := p.client.Put(ctx, params, vmConfigPath(ref)); err != nil {
+		return fmt.Errorf("failed to set network bridges of VM %d: %w", ref.ID, err)
+	}
+	return nil
+}
+
+// netOption returns the value of an option of a Proxmox network device string, empty when unset
+func netOption(device, key string) string {
+	for _, option := range strings.Split(device, ",") {
+		if name, value, ok := strings.Cut(option, "="); ok && name == key {
+			return value
+		}
+	}
+	return ""
+}
+
+// netMACAddress returns the MAC address of a Proxmox network device string. The address
+// is the value of the model option (e.g. "virtio=BC:24:11:00:00:01") or of "macaddr".
+func netMACAddress(device string) string {
+	for _, option := range strings.Split(device, ",") {
+		_, value, ok := strings.Cut(option, "=")
+		if !ok {
+			continue
+		}
+		if mac, err := net.ParseMAC(value); err == nil {
+			return strings.ToUpper(mac.String())
+		}
+	}
+	return ""
+}
+
+// proxmoxCurrentSnapshot is the pseudo-snapshot Proxmox lists for the VM's running state
+const proxmoxCurrentSnapshot = "current"
+
+// ListSnapshots returns the VM's snapshots, oldest first
+func (p *ProxmoxClient) ListSnapshots(ctx context.Context, ref VMRef) ([]SnapshotInfo, error) {
+	if err := p.authenticate(ctx); err != nil {
+		return nil, err
+	}
+
+	response, err := p.client.GetItemList(ctx, snapshotPath(ref))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots of VM %d: %w", ref.ID, err)
+	}
+
+	items, ok := response["data"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected Proxmox snapshot list response: %v", response)
+	}
+
+	snapshots := make([]SnapshotInfo, 0, len(items))
+	for _, item := range items {
+		attrs, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := attrs["name"].(string)
+		if name == "" || name == proxmoxCurrentSnapshot {
+			continue
+		}
+		description, _ := attrs["description"].(string)
+		parent, _ := attrs["parent"].(string)
+		// snaptime is in seconds since the epoch
+		snaptime, _ := attrs["snaptime"].(float64)
+
+		snapshots = append(snapshots, SnapshotInfo{
+			Name:        name,
+			Description: description,
+			Parent:      parent,
+			CreatedAt:   time.Unix(int64(snaptime), 0).UTC(),
+		})
+	}
+
+	slices.SortStableFunc(snapshots, func(a, b SnapshotInfo) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return snapshots, nil
+}
+
+// CreateSnapshot snapshots the VM and waits for the snapshot task, unless a snapshot with the name exists
+func (p *ProxmoxClient) CreateSnapshot(ctx context.Context, ref VMRef, name, description string) error {
+	if name == "" || name == proxmoxCurrentSnapshot {
+		return fmt.Errorf("invalid snapshot name %q", name)
+	}
+
+	// ListSnapshots authenticates the client for the snapshot request below
+	existing, err := p.ListSnapshots(ctx, ref)
+	if err != nil {
+		return err
+	}
+	if slices.ContainsFunc(existing, func(snapshot SnapshotInfo) bool { return snapshot.Name == name }) {
+		return nil
+	}
+
+	params := map[string]interface{}{
+		"snapname": name,
+	}
+	if description != "" {
+		params["description"] = description
+	}
+	if _, err := p.client.PostWithTask(ctx, params, snapshotPath(ref)); err != nil {
+		return fmt.Errorf("failed to snapshot VM %d as %s: %w", ref.ID, name, err)
+	}
+	return nil
+}
+
+// ConvertToTemplate stops the VM if it is not already stopped, waits until it is, then
+// converts it to a template
+func (p *ProxmoxClient) ConvertToTemplate(ctx context.Context, ref VMRef) error {
+	if err := p.authenticate(ctx); err != nil {
+		return err
+	}
+
+	guest, err := p.findGuest(ctx, ref.ID)
+	if err != nil {
+		return err
+	}
+	if guest == nil {
+		return fmt.Errorf("VM %d not found", ref.ID)
+	}
+	if template, _ := guest["template"].(float64); template == 1 {
+		return nil
+	}
+
+	info, err := p.GetVM(ctx, ref)
+	if err != nil {
+		return err
+	}
+	if info.PowerState != PowerStateStopped {
+		stopURL := fmt.Sprintf("/nodes/%s/qemu/%d/status/stop", ref.Node, ref.ID)
+		if _, err := p.client.PostWithTask(ctx, map[string]interface{}{}, stopURL); err != nil {
+			return fmt.Errorf("failed to stop VM %d: %w", ref.ID, err)
+		}
+		if err := p.WaitForPowerState(ctx, ref, PowerStateStopped, TemplateStopTimeout); err != nil {
+			return err
+		}
+	}
+
+	templateURL := fmt.Sprintf("/nodes/%s/qemu/%d/template", ref.Node, ref.ID)
+	if _, err := p.client.PostWithTask(ctx, map[string]interface{}{}, templateURL); err != nil {
+		return fmt.Errorf("failed to convert VM %d to a template: %w", ref.ID, err)
+	}
+	return nil
+}
+
+// StopVM stops the VM, gracefully with a shutdown first when requested, and waits until it is stopped
+func (p *ProxmoxClient) StopVM(ctx context.Context, ref VMRef, graceful bool, timeout time.Duration) error {
+	if graceful && timeout <= 0 {
+		return fmt.Errorf("invalid graceful stop timeout: %v", timeout)
+	}
+	if err := p.authenticate(ctx); err != nil {
+		return err
+	}
+
+	info, err := p.GetVM(ctx, ref)
+	if err != nil {
+		return err
+	}
+	if info.PowerState == PowerStateStopped {
+		return nil
+	}
+
+	// Proxmox hands stops of an HA-managed VM to the HA manager; requesting the stopped state
+	// up front keeps the manager from starting the VM again once it is down
+	ha, err := p.GetHAStatus(ctx, ref)
+	if err != nil {
+		return err
+	}
+	if ha.Managed && ha.State != haStateStopped {
+		params := map[string]interface{}{"state": haStateStopped}
+		if err := p.client.Put(ctx, params, "/cluster/ha/resources/"+haResourceID(ref)); err != nil {
+			return fmt.Errorf("failed to request HA stop of VM %d: %w", ref.ID, err)
+		}
+	}
+
+	if graceful {
+		err := p.shutdownVM(ctx, ref, timeout)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+		// The guest ignored the shutdown or took too long; escalate to a hard stop
+		if stopErr := p.hardStopVM(ctx, ref); stopErr != nil {
+			return fmt.Errorf("graceful shutdown of VM %d failed (%w), then hard stop failed: %w", ref.ID, err, stopErr)
+		}
+		return nil
+	}
+	return p.hardStopVM(ctx, ref)
+}
+
+// RebootVM reboots the VM with a guest shutdown and a fresh start, which applies its pending
+// configuration, and waits up to timeout for the reboot task to finish
+func (p *ProxmoxClient) RebootVM(ctx context.Context, ref VMRef, timeout time.Duration) error {
+	if timeout <= 0 {
+		return fmt.Errorf("invalid reboot timeout: %v", timeout)
+	}
+	if err := p.authenticate(ctx); err != nil {
+		return err
+	}
+
+	rebootURL := fmt.Sprintf("/nodes/%s/qemu/%d/status/reboot", ref.Node, ref.ID)
+	params := map[string]interface{}{
+		// Seconds the guest gets to shut down before the reboot task fails
+		"timeout": int(math.Ceil(timeout.Seconds())),
+	}
+	taskID, err := p.client.PostWithTask(ctx, params, rebootURL)
+	if err != nil {
+		return fmt.Errorf("failed to reboot VM %d: %w", ref.ID, err)
+	}
+	return p.WaitForTask(ctx, ref.Node, taskID, timeout)
+}
+
+// shutdownVM asks the guest to shut down and waits up to timeout for the VM to stop. Proxmox
+// uses the guest agent when the VM has it enabled and ACPI otherwise.
+func (p *ProxmoxClient) shutdownVM(ctx context.Context, ref VMRef, timeout time.Duration) error {
+	shutdownURL := fmt.Sprintf("/nodes/%s/qemu/%d/status/shutdown", ref.Node, ref.ID)
+	params := map[string]interface{}{
+		// The shutdown task fails rather than force the VM off once its timeout, in seconds, elapses
+		"timeout":   int(math.Ceil(timeout.Seconds())),
+		"forceStop": 0,
+	}
+	if _, err := p.client.PostWithTask(ctx, params, shutdownURL); err != nil {
+		return fmt.Errorf("failed to shut down VM %d: %w", ref.ID, err)
+	}
+	return p.WaitForPowerState(ctx, ref, PowerStateStopped, timeout)
+}
+
+// hardStopVM powers the VM off at once and waits up to HardStopTimeout for it to stop
+func (p *ProxmoxClient) hardStopVM(ctx context.Context, ref VMRef) error {
+	stopURL := fmt.Sprintf("/nodes/%s/qemu/%d/status/stop", ref.Node, ref.ID)
+	if _, err := p.client.PostWithTask(ctx, map[string]interface{}{}, stopURL); err != nil {
+		return fmt.Errorf("failed to stop VM %d: %w", ref.ID, err)
+	}
+	return p.WaitForPowerState(ctx, ref, PowerStateStopped, HardStopTimeout)
+}
+
+// snapshotPath returns the API path of a VM's snapshots
+func snapshotPath(ref VMRef) string {
+	return fmt.Sprintf("/nodes/%s/qemu/%d/snapshot", ref.Node, ref.ID)
+}
+
+// proxmoxBootDevicePattern matches the device names Proxmox accepts in a boot order
+var proxmoxBootDevicePattern = regexp.MustCompile(`^(ide|sata|scsi|virtio|net|usb|hostpci)[0-9]+$`)
+
+// proxmoxInterfacePattern matches Proxmox network device names
+var proxmoxInterfacePattern = regexp.MustCompile(`^net[0-9]+$`)
+
+// smbiosUUIDPattern matches an SMBIOS UUID in its canonical textual form
+var smbiosUUIDPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// validateBootOrder checks the boot order is non-empty and only lists Proxmox device names
+func validateBootOrder(order []string) error {
+	if len(order) == 0 {
+		return fmt.Errorf("boot order must list at least one device")
+	}
+	for _, device := range order {
+		if !proxmoxBootDevicePattern.MatchString(device) {
+			return fmt.Errorf("invalid boot device %q", device)
+		}
+	}
+	return nil
+}
+
+// cloneBootOrder returns the boot order for a cloned VM
+func cloneBootOrder(req *CloneRequest) []string {
+	if len(req.BootOrder) > 0 {
+		return req.BootOrder
+	}
+	return DefaultBootOrder
+}
+
+// boolParam formats a boolean as the 0/1 value Proxmox uses for flags
+func boolParam(value bool) int {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+// interfaceParams rewrites the clone's inherited network interfaces. Proxmox replaces a "netN"
+// option as a whole, so each interface's current value is read and only its firewall flag and
+// bridge are changed.
+func (p *ProxmoxClient) interfaceParams(ctx context.Context, ref VMRef, interfaces []InterfaceConfig) (map[string]interface{}, error) {
+	config, err := p.client.GetItemList(ctx, vmConfigPath(ref))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config for VM %d: %w", ref.ID, err)
+	}
+
+	data, ok := config["data"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected Proxmox VM config response: %v", config)
+	}
+
+	params := make(map[string]interface{}, len(interfaces))
+	for _, nic := range interfaces {
+		value, ok := data[nic.Name].(string)
+		if !ok || value == "" {
+			return nil, fmt.Errorf("VM %d has no network interface %s", ref.ID, nic.Name)
+		}
+		if nic.Firewall != nil {
+			value = setNetOption(value, "firewall", strconv.Itoa(boolParam(*nic.Firewall)))
+		}
+		if nic.Bridge != "" {
+			value = setNetOption(value, "bridge", nic.Bridge)
+		}
+		params[nic.Name] = value
+	}
+	return params, nil
+}
+
+// smbiosParams sets the clone's SMBIOS UUID. The "smbios1" option also carries the template's
+// other SMBIOS fields, so its current value is read and only the UUID is replaced.
+func (p *ProxmoxClient) smbiosParams(ctx context.Context, ref VMRef, uuid string) (map[string]interface{}, error) {
+	config, err := p.client.GetItemList(ctx, vmConfigPath(ref))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config for VM %d: %w", ref.ID, err)
+	}
+
+	data, ok := config["data"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected Proxmox VM config response: %v", config)
+	}
+
+	smbios, _ := data["smbios1"].(string)
+	if smbios == "" {
+		return map[string]interface{}{"smbios1": "uuid=" + uuid}, nil
+	}
+	return map[string]interface{}{"smbios1": setNetOption(smbios, "uuid", uuid)}, nil
+}
+
+// setNetOption sets key=value in a Proxmox option string such as the network device
+// "virtio=BC:24:11:00:00:01,bridge=vmbr0", replacing any existing value for key
+func setNetOption(device, key, value string) string {
+	options := strings.Split(device, ",")
+	for i, option := range options {
+		if name, _, _ := strings.Cut(option, "="); name == key {
+			options[i] = key + "=" + value
+			return strings.Join(options, ",")
+		}
+	}
+	return strings.Join(append(options, key+"="+value), ",")
+}
+
+// bootOrderParam formats a boot order as the Proxmox "boot" option, e.g. "order=scsi0;net0"
+func bootOrderParam(order []string) string {
+	return "order=" + strings.Join(order, ";")
+}
+
+// parseBootOrder parses the Proxmox "boot" option. Only the "order=" format is understood;
+// the legacy drive-letter format (e.g. "cdn") yields no devices.
+func parseBootOrder(boot string) []string {
+	for _, option := range strings.Split(boot, ",") {
+		if order, ok := strings.CutPrefix(option, "order="); ok {
+			return strings.FieldsFunc(order, func(r rune) bool { return r == ';' })
+		}
+	}
+	return nil
+}
+
+// vmConfigPath returns the API path of a VM's configuration
+func vmConfigPath(ref VMRef) string {
+	return fmt.Sprintf("/nodes/%s/qemu/%d/config", ref.Node, ref.ID)
+}
+
+// poolExists reports whether a resource pool with the given name exists
+func (p *ProxmoxClient) poolExists(ctx context.Context, pool string) (bool, error) {
+	resources, err := p.client.GetItemList(ctx, proxmoxPoolsPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to list Proxmox pools: %w", err)
+	}
+
+	pools, ok := resources["data"].([]interface{})
+	if !ok {
+		return false, fmt.Errorf("unexpected Proxmox pool list response: %v", resources)
+	}
+
+	for _, item := range pools {
+		attrs, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if attrs["poolid"] == pool {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// proxmoxDiskFormats are the image formats Proxmox can allocate disks in
+var proxmoxDiskFormats = []string{"raw", "qcow2", "vmdk"}
+
+// proxmoxFileStorageTypes are the storage types holding disks as image files. Block storage
+// (LVM, ZFS, Ceph RBD, iSCSI) only holds raw volumes, snapshotting them natively.
+var proxmoxFileStorageTypes = []string{"dir", "nfs", "cifs", "glusterfs"}
+
+// validateDiskFormats rejects disks whose image format their storage cannot hold. Raw disks
+// fit any storage, so only storages receiving another format are looked up.
+func (p *ProxmoxClient) validateDiskFormats(ctx context.Context, disks []DiskConfig) error {
+	storageTypes := map[string]string{}
+	for i, disk := range disks {
+		if disk.Format == "" || disk.Format == "raw" {
+			continue
+		}
+
+		storageType, ok := storageTypes[disk.Storage]
+		if !ok {
+			var err error
+			storageType, err = p.storageType(ctx, disk.Storage)
+			if err != nil {
+				return err
+			}
+			storageTypes[disk.Storage] = storageType
+		}
+		if !slices.Contains(proxmoxFileStorageTypes, storageType) {
+			return fmt.Errorf("format %s for disk %d is not supported by %s storage %q, which only holds raw disks",
+				disk.Format, i, storageType, disk.Storage)
+		}
+	}
+	return nil
+}
+
+// validateStorageSpace rejects a clone whose disks do not fit the free space of their storage on
+// the target node, so a full storage fails the clone up front instead of partway through
+func (p *ProxmoxClient) validateStorageSpace(ctx context.Context, req *CloneRequest) error {
+	required := map[string]int64{}
+	for _, disk := range req.Disks {
+		required[disk.Storage] += int64(disk.SizeGB) * bytesPerGiB
+	}
+	// A full clone copies the template's disks, while a linked clone shares them
+	if req.FullClone && req.Storage != "" {
+		size, err := p.templateDiskSize(ctx, VMRef{Node: req.SourceNode, ID: req.SourceID})
+		if err != nil {
+			return err
+		}
+		required[req.Storage] += size
+	}
+
+	node := cloneTargetNode(req)
+	for _, storage := range slices.Sorted(maps.Keys(required)) {
+		status, err := p.GetStorageStatus(ctx, node, storage)
+		if err != nil {
+			return err
+		}
+		if status.Available < required[storage] {
+			return fmt.Errorf("storage %q on node %s has %d GiB free but the clone needs %d GiB",
+				storage, node, status.Available/bytesPerGiB, ceilDiv(required[storage], bytesPerGiB))
+		}
+	}
+	return nil
+}
+
+// proxmoxDiskKey matches the config keys of a VM's disk and CD-ROM drives
+var proxmoxDiskKey = regexp.MustCompile(`^(scsi|virtio|sata|ide)[0-9]+$`)
+
+// templateDiskSize returns the total size in bytes of a template's disks
+func (p *ProxmoxClient) templateDiskSize(ctx context.Context, ref VMRef) (int64, error) {
+	disks, err := p.templateDisks(ctx, ref)
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, size := range disks {
+		total += size
+	}
+	return total, nil
+}
+
+// templateDisks returns the size in bytes of each of a template's disks keyed by drive name,
+// ignoring CD-ROMs and cloud-init drives, which a clone regenerates
+func (p *ProxmoxClient) templateDisks(ctx context.Context, ref VMRef) (map[string]int64, error) {
+	config, err := p.client.GetItemList(ctx, vmConfigPath(ref))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config for VM %d: %w", ref.ID, err)
+	}
+	data, ok := config["data"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected Proxmox VM config response: %v", config)
+	}
+
+	disks := map[string]int64{}
+	for key, value := range data {
+		drive, _ := value.(string)
+		if !proxmoxDiskKey.MatchString(key) || strings.Contains(drive, "media=cdrom") || strings.Contains(drive, "cloudinit") {
+			continue
+		}
+		size, err := driveSize(drive)
+		if err != nil {
+			return nil, fmt.Errorf("VM %d disk %s: %w", ref.ID, key, err)
+		}
+		disks[key] = size
+	}
+	return disks, nil
+}
+
+// templateStorages returns the storages holding a template's disks, sorted, ignoring CD-ROMs
+// and cloud-init drives, which a clone regenerates
+func (p *ProxmoxClient) templateStorages(ctx context.Context, ref VMRef) ([]string, error) {
+	config, err := p.client.GetItemList(ctx, vmConfigPath(ref))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config for VM %d: %w", ref.ID, err)
+	}
+	data, ok := config["data"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected Proxmox VM config response: %v", config)
+	}
+
+	var storages []string
+	for key, value := range data {
+		drive, _ := value.(string)
+		if !proxmoxDiskKey.MatchString(key) || strings.Contains(drive, "media=cdrom") || strings.Contains(drive, "cloudinit") {
+			continue
+		}
+		// A drive is written storage:volume,options
+		storage, _, ok := strings.Cut(drive, ":")
+		if !ok {
+			return nil, fmt.Errorf("VM %d disk %s has no storage: %q", ref.ID, key, drive)
+		}
+		storages = append(storages, storage)
+	}
+	slices.Sort(storages)
+	return slices.Compact(storages), nil
+}
+
+// driveSizeUnits are the multipliers of the suffixes Proxmox uses for drive sizes
+var driveSizeUnits = map[string]int64{"": 1, "K": 1024, "M": bytesPerMiB, "G": bytesPerGiB, "T": 1024 * bytesPerGiB}
+
+// driveSize parses the size option of a Proxmox drive, e.g. 32G in "local-lvm:base-9000-disk-0,size=32G"
+func driveSize(drive string) (int64, error) {
+	for _, option := range strings.Split(drive, ",") {
+		raw, ok := strings.CutPrefix(option, "size=")
+		if !ok {
+			continue
+		}
+		number := strings.TrimRight(raw, "KMGT")
+		multiplier, ok := driveSizeUnits[raw[len(number):]]
+		value, err := strconv.ParseInt(number, 10, 64)
+		if !ok || err != nil {
+			return 0, fmt.Errorf("invalid drive size %q", raw)
+		}
+		return value * multiplier, nil
+	}
+	return 0, fmt.Errorf("drive %q has no size", drive)
+}
+
+// ceilDiv divides a by b, rounding up
+func ceilDiv(a, b int64) int64 {
+	return (a + b - 1) / b
+}
+
+// storageType returns the type of a Proxmox storage, e.g. "dir" or "lvmthin"
+func (p *ProxmoxClient) storageType(ctx context.Context, storage string) (string, error) {
+	response, err := p.client.GetItemList(ctx, "/storage/"+url.PathEscape(storage))
+	if err != nil {
+		return "", fmt.Errorf("failed to get storage %q: %w", storage, err)
+	}
+
+	data, ok := response["data"].(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("unexpected Proxmox storage response: %v", response)
+	}
+	storageType, _ := data["type"].(string)
+	if storageType == "" {
+		return "", fmt.Errorf("storage %q has no type", storage)
+	}
+	return storageType, nil
+}
+
+// proxmoxVGATypes are the display types Proxmox accepts for the "vga" option
+var proxmoxVGATypes = []string{"serial0", "serial1", "serial2", "serial3", "std", "cirrus", "vmware", "qxl", "virtio", "none"}
+
+// validateCloneRequest checks the fields required for a clone
+func validateCloneRequest(req *CloneRequest) error {
+	if req == nil {
+		return fmt.Errorf("clone request is required")
+	}
+	if req.SourceNode == "" {
+		return fmt.Errorf("source node is required")
+	}
+	if req.SourceID <= 0 {
+		return fmt.Errorf("invalid source VM ID: %d", req.SourceID)
+	}
+	if req.NewID <= 0 {
+		return fmt.Errorf("invalid VM ID: %d", req.NewID)
+	}
+	if req.Name == "" {
+		return fmt.Errorf("VM name is required")
+	}
+	if req.CPUs < 0 {
+		return fmt.Errorf("invalid CPU count: %d", req.CPUs)
+	}
+	if req.BootDiskGB < 0 {
+		return fmt.Errorf("invalid boot disk size: %dG", req.BootDiskGB)
+	}
+	if req.MemoryMiB < 0 || req.MinMemoryMiB < 0 {
+		return fmt.Errorf("invalid memory: %d MiB with a minimum of %d MiB", req.MemoryMiB, req.MinMemoryMiB)
+	}
+	if req.MemoryMiB > 0 && req.MinMemoryMiB > req.MemoryMiB {
+		return fmt.Errorf("minimum memory %d MiB exceeds the memory of %d MiB", req.MinMemoryMiB, req.MemoryMiB)
+	}
+	if len(req.BootOrder) > 0 {
+		if err := validateBootOrder(req.BootOrder); err != nil {
+			return err
+		}
+	}
+	if req.VGA != "" && !slices.Contains(proxmoxVGATypes, req.VGA) {
+		return fmt.Errorf("invalid VGA type %q", req.VGA)
+	}
+	if req.SMBIOSUUID != "" && !smbiosUUIDPattern.MatchString(req.SMBIOSUUID) {
+		return fmt.Errorf("invalid SMBIOS UUID %q", req.SMBIOSUUID)
+	}
+	for _, nic := range req.Interfaces {
+		if !proxmoxInterfacePattern.MatchString(nic.Name) {
+			return fmt.Errorf("invalid network interface %q", nic.Name)
+		}
+	}
+	if len(req.Disks) > maxDataDisks {
+		return fmt.Errorf("too many data disks: %d (max %d)", len(req.Disks), maxDataDisks)
+	}
+	for i, disk := range req.Disks {
+		if disk.SizeGB <= 0 {
+			return fmt.Errorf("invalid size for disk %d: %dG", i, disk.SizeGB)
+		}
+		if disk.Storage == "" {
+			return fmt.Errorf("storage is required for disk %d", i)
+		}
+		if disk.Format != "" && !slices.Contains(proxmoxDiskFormats, disk.Format) {
+			return fmt.Errorf("invalid format %q for disk %d", disk.Format, i)
+		}
+	}
+	return nil
+}
+
+// cloneParams builds the Proxmox clone API parameters for a request
+func cloneParams(req *CloneRequest) map[string]interface{} {
+	params := map[string]interface{}{
+		"newid": req.NewID,
+		"name":  req.Name,
+	}
+	if req.FullClone {
+		params["full"] = 1
+		// Target storage is only allowed for full clones
+		if req.Storage != "" {
+			params["storage"] = req.Storage
+		}
+	} else {
+		params["full"] = 0
+	}
+	if req.TargetNode != "" {
+		params["target"] = req.TargetNode
+	}
+	if req.Pool != "" {
+		params["pool"] = req.Pool
+	}
+	if req.Description != "" {
+		params["description"] = req.Description
+	}
+	return params
+}
+
+// scsiDiskParams builds the VM config parameters allocating data disks as scsi1..N.
+// scsi0 is left to the boot disk inherited from the template.
+func scsiDiskParams(disks []DiskConfig) map[string]interface{} {
+	params := make(map[string]interface{}, len(disks))
+	for i, disk := range disks {
+		// "<storage>:<size>" allocates a new volume of <size> GiB on the storage
+		value := fmt.Sprintf("%s:%d", disk.Storage, disk.SizeGB)
+		if disk.Format != "" {
+			value += ",format=" + disk.Format
+		}
+		params[fmt.Sprintf("scsi%d", i+1)] = value
+	}
+	return params
+}
+
+// cloudInitNetworkParams builds the VM config parameters Proxmox renders into the cloud-init
+// network configuration of the primary interface
+func cloudInitNetworkParams(network *CloudInitNetwork) map[string]interface{} {
+	params := map[string]interface{}{}
+	if network == nil {
+		return params
+	}
+
+	switch {
+	case network.DHCP:
+		params["ipconfig0"] = "ip=dhcp"
+	case network.IP != "":
+		ipConfig := "ip=" + network.IP
+		if network.Gateway != "" {
+			ipConfig += ",gw=" + network.Gateway
+		}
+		params["ipconfig0"] = ipConfig
+	}
+	// Proxmox takes space-separated lists for both resolver options
+	if len(network.Nameservers) > 0 {
+		params["nameserver"] = strings.Join(network.Nameservers, " ")
+	}
+	if len(network.SearchDomains) > 0 {
+		params["searchdomain"] = strings.Join(network.SearchDomains, " ")
+	}
+	return params
+}
+
 // Close cleans up any resources used by the Proxmox client
 func (p *ProxmoxClient) Close() error {
 	// Proxmox client doesn't require explicit cleanup
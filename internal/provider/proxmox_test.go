@@ -3,9 +3,120 @@ package provider
 import (
 	"context"
 	"crypto/tls"
+	"errors"
+	"maps"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/Telmate/proxmox-api-go/proxmox"
 )
 
+// fakeProxmoxAPI implements proxmoxAPI for testing
+type fakeProxmoxAPI struct {
+	items    map[string]map[string]interface{}
+	itemsErr error
+	loginErr error
+
+	postURL    string
+	postParams map[string]interface{}
+	postErr    error
+	// postURLs records every POST in order; onPost, if set, runs on each, e.g. to change items
+	postURLs []string
+	onPost   func(url string)
+
+	putURL    string
+	putParams map[string]interface{}
+	putErr    error
+	// puts records the params of the last PUT to each URL
+	puts map[string]map[string]interface{}
+
+	deleteURL string
+	deleteErr error
+	// deleteURLs records every DELETE in order
+	deleteURLs []string
+}
+
+func (f *fakeProxmoxAPI) SetAPIToken(userID, token string) {}
+
+func (f *fakeProxmoxAPI) Login(ctx context.Context, username string, password string, otp string) error {
+	return f.loginErr
+}
+
+func (f *fakeProxmoxAPI) GetVersion(ctx context.Context) (proxmox.Version, error) {
+	return proxmox.Version{Major: 8, Minor: 1, Patch: 4}, nil
+}
+
+func (f *fakeProxmoxAPI) GetItemList(ctx context.Context, url string) (map[string]interface{}, error) {
+	if f.itemsErr != nil {
+		return nil, f.itemsErr
+	}
+	return f.items[url], nil
+}
+
+func (f *fakeProxmoxAPI) PostWithTask(ctx context.Context, params map[string]interface{}, url string) (string, error) {
+	f.postURL = url
+	f.postParams = params
+	f.postURLs = append(f.postURLs, url)
+	if f.onPost != nil {
+		f.onPost(url)
+	}
+	if f.postErr != nil {
+		return "", f.postErr
+	}
+	return "OK", nil
+}
+
+func (f *fakeProxmoxAPI) Put(ctx context.Context, params map[string]interface{}, url string) error {
+	f.putURL = url
+	f.putParams = params
+	if f.puts == nil {
+		f.puts = map[string]map[string]interface{}{}
+	}
+	f.puts[url] = params
+	return f.putErr
+}
+
+func (f *fakeProxmoxAPI) Delete(ctx context.Context, url string) error {
+	f.deleteURL = url
+	f.deleteURLs = append(f.deleteURLs, url)
+	return f.deleteErr
+}
+
+// noHAStatus is the HA status of a cluster not using HA, which has no manager
+var noHAStatus = map[string]interface{}{"data": []interface{}{
+	map[string]interface{}{"id": "quorum", "type": "quorum", "status": "OK"},
+}}
+
+// haStatusManaging returns the HA status of a cluster whose manager has VM 101 in the given
+// requested state
+func haStatusManaging(state string) map[string]interface{} {
+	return map[string]interface{}{"data": []interface{}{
+		map[string]interface{}{"id": "quorum", "type": "quorum", "status": "OK"},
+		map[string]interface{}{"id": "master", "type": "master", "node": "pve1", "status": "pve1 (active)"},
+		map[string]interface{}{
+			"id": "service:vm:101", "type": "service", "sid": "vm:101",
+			"node": "pve1", "state": "started", "request_state": state,
+		},
+	}}
+}
+
+// newFakeProxmoxClient returns a ProxmoxClient backed by the given fake API
+func newFakeProxmoxClient(api *fakeProxmoxAPI) *ProxmoxClient {
+	return &ProxmoxClient{
+		client: api,
+		auth: &AuthConfig{
+			Type:        "token",
+			TokenID:     "root@pam!test",
+			TokenSecret: "test-token-secret",
+		},
+	}
+}
+
 func TestNewProxmoxClient(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -140,3 +251,3371 @@ func TestProxmoxClient_TestConnection_InvalidAuth(t *testing.T) {
 		})
 	}
 }
+
+func TestProxmoxClient_TestConnection_Plaintext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api2/json/version" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"version":"8.1.4","release":"8.1","repoid":"abc123"}}`))
+	}))
+	defer server.Close()
+
+	// A TLS config on a plaintext endpoint must be ignored rather than applied
+	config := &ClientConfig{
+		Endpoint:  server.URL + "/api2/json",
+		TLSConfig: &tls.Config{InsecureSkipVerify: true},
+		Timeout:   300,
+	}
+	auth := &AuthConfig{
+		Type:        "token",
+		TokenID:     "root@pam!test",
+		TokenSecret: "test-token-secret",
+	}
+
+	client, err := NewProxmoxClient(config, auth)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	info, err := client.TestConnection(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.Version != "8.1.4" {
+		t.Errorf("expected version 8.1.4, got %s", info.Version)
+	}
+}
+
+func TestNewHTTPClient(t *testing.T) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: true}
+	client := newHTTPClient(&ClientConfig{ConnectTimeout: 10, RequestTimeout: 60}, tlsConfig)
+
+	if client.Timeout != 60*time.Second {
+		t.Errorf("expected request timeout 60s, got %s", client.Timeout)
+	}
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("expected *http.Transport, got %T", client.Transport)
+	}
+	if transport.TLSHandshakeTimeout != 10*time.Second {
+		t.Errorf("expected TLS handshake timeout 10s, got %s", transport.TLSHandshakeTimeout)
+	}
+	if transport.TLSClientConfig != tlsConfig {
+		t.Errorf("expected TLS config to be applied")
+	}
+}
+
+func TestNewHTTPClient_ConnectTimeout(t *testing.T) {
+	// A listener that accepts connections but never completes the TLS handshake
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer func() { _ = listener.Close() }()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				<-done
+				_ = conn.Close()
+			}()
+		}
+	}()
+
+	// No request timeout: only the connection timeout can end the stalled handshake
+	client := newHTTPClient(&ClientConfig{ConnectTimeout: 1}, &tls.Config{InsecureSkipVerify: true})
+
+	resp, err := client.Get("https://" + listener.Addr().String() + "/api2/json/version")
+	if err == nil {
+		_ = resp.Body.Close()
+	}
+	if err == nil || !strings.Contains(err.Error(), "TLS handshake timeout") {
+		t.Errorf("expected TLS handshake timeout, got %v", err)
+	}
+}
+
+func TestNewHTTPClient_RequestTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	// The connection succeeds immediately; only the slow response should trip the request timeout
+	client := newHTTPClient(&ClientConfig{ConnectTimeout: 10, RequestTimeout: 1}, nil)
+
+	resp, err := client.Get(server.URL + "/api2/json/version")
+	if err == nil {
+		_ = resp.Body.Close()
+	}
+	if err == nil || !strings.Contains(err.Error(), "Client.Timeout exceeded") {
+		t.Errorf("expected request timeout, got %v", err)
+	}
+}
+
+func TestIsPlaintextEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		expected bool
+	}{
+		{endpoint: "http://pve.example.com:8006/api2/json", expected: true},
+		{endpoint: "HTTP://pve.example.com:8006/api2/json", expected: true},
+		{endpoint: "https://pve.example.com:8006/api2/json", expected: false},
+		{endpoint: "://invalid", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.endpoint, func(t *testing.T) {
+			if got := IsPlaintextEndpoint(tt.endpoint); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestProxmoxClient_VMExists(t *testing.T) {
+	guests := map[string]map[string]interface{}{
+		proxmoxVMResourcesPath: {
+			"data": []interface{}{
+				map[string]interface{}{"vmid": float64(100), "node": "pve1", "type": "qemu"},
+				map[string]interface{}{"vmid": float64(9000), "node": "pve2", "type": "qemu"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		api         *fakeProxmoxAPI
+		id          int
+		expected    bool
+		expectError bool
+	}{
+		{
+			name:     "vm exists",
+			api:      &fakeProxmoxAPI{items: guests},
+			id:       9000,
+			expected: true,
+		},
+		{
+			name:     "vm not found",
+			api:      &fakeProxmoxAPI{items: guests},
+			id:       101,
+			expected: false,
+		},
+		{
+			name:        "api error is not reported as absent",
+			api:         &fakeProxmoxAPI{itemsErr: errors.New("connection reset by peer")},
+			id:          101,
+			expectError: true,
+		},
+		{
+			name: "malformed response is an error",
+			api: &fakeProxmoxAPI{items: map[string]map[string]interface{}{
+				proxmoxVMResourcesPath: {"errors": "permission denied"},
+			}},
+			id:          101,
+			expectError: true,
+		},
+		{
+			name:        "invalid vm id",
+			api:         &fakeProxmoxAPI{items: guests},
+			id:          0,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newFakeProxmoxClient(tt.api)
+
+			exists, err := client.VMExists(context.Background(), tt.id)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				if exists {
+					t.Errorf("expected exists to be false on error")
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if exists != tt.expected {
+				t.Errorf("expected exists %v, got %v", tt.expected, exists)
+			}
+		})
+	}
+}
+
+func TestProxmoxClient_NextAvailableVMID(t *testing.T) {
+	guests := map[string]map[string]interface{}{
+		proxmoxVMResourcesPath: {
+			"data": []interface{}{
+				map[string]interface{}{"vmid": float64(1000), "node": "pve1", "type": "qemu"},
+				map[string]interface{}{"vmid": float64(1001), "node": "pve2", "type": "qemu", "template": float64(1)},
+				map[string]interface{}{"vmid": float64(1002), "node": "pve1", "type": "lxc"},
+				map[string]interface{}{"vmid": float64(1004), "node": "pve2", "type": "qemu"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		api        *fakeProxmoxAPI
+		rangeStart int
+		rangeEnd   int
+		expected   int
+		expectFull bool
+		expectErr  bool
+	}{
+		{
+			name:       "first gap in the range is picked",
+			api:        &fakeProxmoxAPI{items: guests},
+			rangeStart: 1000,
+			rangeEnd:   1010,
+			expected:   1003,
+		},
+		{
+			name:       "range start is free",
+			api:        &fakeProxmoxAPI{items: guests},
+			rangeStart: 900,
+			rangeEnd:   999,
+			expected:   900,
+		},
+		{
+			name:       "full range",
+			api:        &fakeProxmoxAPI{items: guests},
+			rangeStart: 1000,
+			rangeEnd:   1002,
+			expectFull: true,
+			expectErr:  true,
+		},
+		{
+			name:       "api error",
+			api:        &fakeProxmoxAPI{itemsErr: errors.New("connection reset by peer")},
+			rangeStart: 1000,
+			rangeEnd:   1010,
+			expectErr:  true,
+		},
+		{
+			name:       "invalid range",
+			api:        &fakeProxmoxAPI{items: guests},
+			rangeStart: 1010,
+			rangeEnd:   1000,
+			expectErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newFakeProxmoxClient(tt.api)
+
+			id, err := client.NextAvailableVMID(context.Background(), tt.rangeStart, tt.rangeEnd)
+
+			if tt.expectErr {
+				if err == nil {
+					t.Fatalf("expected error but got ID %d", id)
+				}
+				if IsNoFreeVMID(err) != tt.expectFull {
+					t.Errorf("expected IsNoFreeVMID %v, got error %v", tt.expectFull, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if id != tt.expected {
+				t.Errorf("expected ID %d, got %d", tt.expected, id)
+			}
+		})
+	}
+}
+
+func TestProxmoxClient_NextAvailableVMIDInPool(t *testing.T) {
+	// Tenant "blue" spans 1000-1004 and tenant "green" spans 1010-1011
+	guests := map[string]map[string]interface{}{
+		proxmoxVMResourcesPath: {
+			"data": []interface{}{
+				map[string]interface{}{"vmid": float64(1000), "node": "pve1", "type": "qemu", "pool": "blue"},
+				map[string]interface{}{"vmid": float64(1004), "node": "pve1", "type": "qemu", "pool": "blue"},
+				map[string]interface{}{"vmid": float64(1005), "node": "pve2", "type": "qemu"},
+				map[string]interface{}{"vmid": float64(1010), "node": "pve2", "type": "qemu", "pool": "green"},
+				map[string]interface{}{"vmid": float64(1011), "node": "pve2", "type": "qemu", "pool": "green"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		pool       string
+		rangeStart int
+		rangeEnd   int
+		expected   int
+		expectFull bool
+	}{
+		{
+			name:       "pool fills the gaps of its own range",
+			pool:       "blue",
+			rangeStart: 1000,
+			rangeEnd:   1020,
+			expected:   1001,
+		},
+		{
+			name:       "other pools' ranges are skipped",
+			pool:       "green",
+			rangeStart: 1000,
+			rangeEnd:   1020,
+			expected:   1006,
+		},
+		{
+			name:       "new pool avoids every tenant range",
+			pool:       "red",
+			rangeStart: 1000,
+			rangeEnd:   1020,
+			expected:   1006,
+		},
+		{
+			name:       "range start outside all pools",
+			pool:       "red",
+			rangeStart: 1012,
+			rangeEnd:   1020,
+			expected:   1012,
+		},
+		{
+			name:       "falls back into another pool's range when nothing else is free",
+			pool:       "red",
+			rangeStart: 1000,
+			rangeEnd:   1005,
+			expected:   1001,
+		},
+		{
+			name:       "no pool takes the lowest free ID",
+			rangeStart: 1000,
+			rangeEnd:   1020,
+			expected:   1001,
+		},
+		{
+			name:       "full range",
+			pool:       "blue",
+			rangeStart: 1010,
+			rangeEnd:   1011,
+			expectFull: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newFakeProxmoxClient(&fakeProxmoxAPI{items: guests})
+
+			id, err := client.NextAvailableVMIDInPool(context.Background(), tt.pool, tt.rangeStart, tt.rangeEnd)
+
+			if tt.expectFull {
+				if !IsNoFreeVMID(err) {
+					t.Fatalf("expected ErrNoFreeVMID, got ID %d, error %v", id, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if id != tt.expected {
+				t.Errorf("expected ID %d, got %d", tt.expected, id)
+			}
+			if id < tt.rangeStart || id > tt.rangeEnd {
+				t.Errorf("expected ID within %d-%d, got %d", tt.rangeStart, tt.rangeEnd, id)
+			}
+		})
+	}
+}
+
+func TestProxmoxClient_CloneVM(t *testing.T) {
+	items := map[string]map[string]interface{}{
+		proxmoxVMResourcesPath: {
+			"data": []interface{}{
+				map[string]interface{}{"vmid": float64(9000), "node": "pve1", "type": "qemu"},
+			},
+		},
+		proxmoxPoolsPath: {
+			"data": []interface{}{
+				map[string]interface{}{"poolid": "runners"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		req           *CloneRequest
+		postErr       error
+		expectError   string
+		expectPool    interface{}
+		expectRef     *VMRef
+		expectNoClone bool
+	}{
+		{
+			name:       "clone into existing pool",
+			req:        &CloneRequest{SourceNode: "pve1", SourceID: 9000, NewID: 101, Name: "runner-1", Pool: "runners"},
+			expectPool: "runners",
+			expectRef:  &VMRef{Node: "pve1", ID: 101},
+		},
+		{
+			name:      "clone without pool",
+			req:       &CloneRequest{SourceNode: "pve1", SourceID: 9000, TargetNode: "pve2", NewID: 101, Name: "runner-1"},
+			expectRef: &VMRef{Node: "pve2", ID: 101},
+		},
+		{
+			name:          "missing pool",
+			req:           &CloneRequest{SourceNode: "pve1", SourceID: 9000, NewID: 101, Name: "runner-1", Pool: "missing"},
+			expectError:   `pool "missing" does not exist`,
+			expectNoClone: true,
+		},
+		{
+			name:          "vm id already in use",
+			req:           &CloneRequest{SourceNode: "pve1", SourceID: 9000, NewID: 9000, Name: "runner-1"},
+			expectError:   "VM ID 9000 is already in use",
+			expectNoClone: true,
+		},
+		{
+			name:          "missing name",
+			req:           &CloneRequest{SourceNode: "pve1", SourceID: 9000, NewID: 101},
+			expectError:   "VM name is required",
+			expectNoClone: true,
+		},
+		{
+			name:        "clone task fails",
+			req:         &CloneRequest{SourceNode: "pve1", SourceID: 9000, NewID: 101, Name: "runner-1"},
+			postErr:     errors.New("storage full"),
+			expectError: "storage full",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeProxmoxAPI{items: items, postErr: tt.postErr}
+			client := newFakeProxmoxClient(api)
+
+			ref, err := client.CloneVM(context.Background(), tt.req)
+
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Errorf("expected error containing %q, got %v", tt.expectError, err)
+				}
+				if tt.expectNoClone && api.postURL != "" {
+					t.Errorf("expected no clone request, got %s", api.postURL)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if api.postURL != "/nodes/pve1/qemu/9000/clone" {
+				t.Errorf("unexpected clone URL: %s", api.postURL)
+			}
+			if api.postParams["pool"] != tt.expectPool {
+				t.Errorf("expected pool %v, got %v", tt.expectPool, api.postParams["pool"])
+			}
+			if *ref != *tt.expectRef {
+				t.Errorf("expected ref %+v, got %+v", *tt.expectRef, *ref)
+			}
+		})
+	}
+}
+
+func TestProxmoxClient_GetTemplate(t *testing.T) {
+	client := newFakeProxmoxClient(&fakeProxmoxAPI{items: map[string]map[string]interface{}{
+		proxmoxVMResourcesPath: {
+			"data": []interface{}{
+				map[string]interface{}{"vmid": float64(9000), "node": "pve2", "type": "qemu", "name": "ubuntu-2404", "template": float64(1)},
+				map[string]interface{}{"vmid": float64(101), "node": "pve1", "type": "qemu", "name": "runner-1"},
+				map[string]interface{}{"vmid": float64(200), "node": "pve1", "type": "lxc", "name": "ct-template", "template": float64(1)},
+			},
+		},
+	}})
+
+	info, err := client.GetTemplate(context.Background(), 9000)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *info != (TemplateInfo{ID: 9000, Name: "ubuntu-2404", Node: "pve2"}) {
+		t.Errorf("unexpected template info: %+v", info)
+	}
+
+	for id, expected := range map[int]string{
+		101: "VM 101 is not a template",
+		200: "VM 200 is not a template",
+		404: "template 404 does not exist",
+		0:   "invalid template ID",
+	} {
+		_, err := client.GetTemplate(context.Background(), id)
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected error containing %q for ID %d, got %v", expected, id, err)
+		}
+		if IsNotATemplate(err) != (id == 101 || id == 200) {
+			t.Errorf("unexpected IsNotATemplate for ID %d: %v", id, err)
+		}
+	}
+}
+
+func TestProxmoxClient_GetTemplateDiskSizes(t *testing.T) {
+	client := newFakeProxmoxClient(&fakeProxmoxAPI{items: map[string]map[string]interface{}{
+		proxmoxVMResourcesPath: {
+			"data": []interface{}{
+				map[string]interface{}{"vmid": float64(9000), "node": "pve2", "type": "qemu", "name": "ubuntu-2404", "template": float64(1)},
+				map[string]interface{}{"vmid": float64(101), "node": "pve1", "type": "qemu", "name": "runner-1"},
+			},
+		},
+		vmConfigPath(VMRef{Node: "pve2", ID: 9000}): {
+			"data": map[string]interface{}{
+				"scsi0":  "local-lvm:base-9000-disk-0,size=32G",
+				"scsi1":  "local-lvm:base-9000-disk-1,size=2252M",
+				"ide0":   "local:iso/ubuntu.iso,media=cdrom",
+				"ide2":   "local-lvm:vm-9000-cloudinit,media=cdrom",
+				"memory": "4096",
+			},
+		},
+	}})
+
+	sizes, err := client.GetTemplateDiskSizes(context.Background(), 9000)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Partial gigabytes round up, so a request of the reported size never shrinks the disk
+	expected := map[string]int{"scsi0": 32, "scsi1": 3}
+	if !maps.Equal(sizes, expected) {
+		t.Errorf("expected disk sizes %v, got %v", expected, sizes)
+	}
+
+	if _, err := client.GetTemplateDiskSizes(context.Background(), 101); !IsNotATemplate(err) {
+		t.Errorf("expected ErrNotATemplate for a regular VM, got %v", err)
+	}
+}
+
+func TestProxmoxClient_GetTemplateCloneNodes(t *testing.T) {
+	sharedStatus := storageStatusItem(200)
+	sharedStatus["data"].(map[string]interface{})["shared"] = float64(1)
+
+	tests := []struct {
+		name     string
+		disks    map[string]interface{}
+		expected []string
+	}{
+		{
+			name: "disks on shared storage",
+			disks: map[string]interface{}{
+				"scsi0": "ceph:base-9000-disk-0,size=32G",
+				"ide2":  "local-lvm:vm-9000-cloudinit,media=cdrom",
+			},
+			expected: []string{"pve1", "pve2"},
+		},
+		{
+			name: "a disk on local storage",
+			disks: map[string]interface{}{
+				"scsi0": "ceph:base-9000-disk-0,size=32G",
+				"scsi1": "local-lvm:base-9000-disk-1,size=8G",
+			},
+			expected: []string{"pve2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newFakeProxmoxClient(&fakeProxmoxAPI{items: map[string]map[string]interface{}{
+				proxmoxVMResourcesPath: {"data": []interface{}{
+					map[string]interface{}{"vmid": float64(9000), "node": "pve2", "type": "qemu", "template": float64(1)},
+				}},
+				vmConfigPath(VMRef{Node: "pve2", ID: 9000}): {"data": tt.disks},
+				"/nodes/pve2/storage/ceph/status":           sharedStatus,
+				"/nodes/pve2/storage/local-lvm/status":      storageStatusItem(200),
+				proxmoxNodesPath: {"data": []interface{}{
+					map[string]interface{}{"node": "pve1", "status": "online"},
+					map[string]interface{}{"node": "pve2", "status": "online"},
+				}},
+			}})
+
+			nodes, err := client.GetTemplateCloneNodes(context.Background(), 9000)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(nodes, tt.expected) {
+				t.Errorf("expected nodes %v, got %v", tt.expected, nodes)
+			}
+		})
+	}
+}
+
+func TestProxmoxClient_CloneVMAdoptExisting(t *testing.T) {
+	items := map[string]map[string]interface{}{
+		proxmoxVMResourcesPath: {
+			"data": []interface{}{
+				map[string]interface{}{"vmid": float64(9000), "node": "pve1", "type": "qemu", "name": "ubuntu-template", "template": float64(1)},
+				map[string]interface{}{"vmid": float64(101), "node": "pve1", "type": "qemu", "name": "runner-1", "pool": "runners",
+					"tags": "hyperfleet-instance_ci-east;managed-by_hyperfleet"},
+			},
+		},
+		proxmoxPoolsPath: {
+			"data": []interface{}{
+				map[string]interface{}{"poolid": "runners"},
+			},
+		},
+	}
+	existing := CloneRequest{SourceNode: "pve1", SourceID: 9000, NewID: 101, Name: "runner-1", Pool: "runners", AdoptExisting: true,
+		Tags: []string{InstanceTag("ci-east"), ManagedByTag("")}}
+
+	t.Run("existing matching VM is reused", func(t *testing.T) {
+		api := &fakeProxmoxAPI{items: items}
+		req := existing
+
+		ref, err := newFakeProxmoxClient(api).CloneVM(context.Background(), &req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if *ref != (VMRef{Node: "pve1", ID: 101}) {
+			t.Errorf("unexpected ref: %+v", *ref)
+		}
+		if api.postURL != "" || api.putURL != "" {
+			t.Errorf("expected no changes to the existing VM, got %s %s", api.postURL, api.putURL)
+		}
+	})
+
+	mismatched := map[string]func(req *CloneRequest){
+		"name":     func(req *CloneRequest) { req.Name = "runner-2" },
+		"node":     func(req *CloneRequest) { req.TargetNode = "pve2" },
+		"pool":     func(req *CloneRequest) { req.Pool = "" },
+		"template": func(req *CloneRequest) { req.NewID, req.Name, req.SourceID = 9000, "ubuntu-template", 101 },
+		"instance": func(req *CloneRequest) { req.Tags = []string{InstanceTag("ci-west"), ManagedByTag("")} },
+	}
+	for field, mismatch := range mismatched {
+		t.Run("existing VM with different "+field, func(t *testing.T) {
+			api := &fakeProxmoxAPI{items: items}
+			req := existing
+			mismatch(&req)
+
+			_, err := newFakeProxmoxClient(api).CloneVM(context.Background(), &req)
+			if !errors.Is(err, ErrVMConflict) {
+				t.Errorf("expected ErrVMConflict, got %v", err)
+			}
+			if api.postURL != "" || api.putURL != "" {
+				t.Errorf("expected no changes to the existing VM, got %s %s", api.postURL, api.putURL)
+			}
+		})
+	}
+
+	t.Run("absent VM is created", func(t *testing.T) {
+		api := &fakeProxmoxAPI{items: items}
+		req := existing
+		req.NewID = 102
+
+		ref, err := newFakeProxmoxClient(api).CloneVM(context.Background(), &req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if *ref != (VMRef{Node: "pve1", ID: 102}) {
+			t.Errorf("unexpected ref: %+v", *ref)
+		}
+		if api.postURL != "/nodes/pve1/qemu/9000/clone" || api.putURL != "/nodes/pve1/qemu/102/config" {
+			t.Errorf("expected clone and configuration, got %s %s", api.postURL, api.putURL)
+		}
+	})
+}
+
+func TestCloneParams(t *testing.T) {
+	full := cloneParams(&CloneRequest{NewID: 101, Name: "runner-1", Storage: "local-lvm", FullClone: true})
+	if full["full"] != 1 || full["storage"] != "local-lvm" {
+		t.Errorf("expected full clone to local-lvm, got %v", full)
+	}
+
+	linked := cloneParams(&CloneRequest{NewID: 101, Name: "runner-1", Storage: "local-lvm"})
+	if linked["full"] != 0 {
+		t.Errorf("expected linked clone, got %v", linked["full"])
+	}
+	if _, exists := linked["storage"]; exists {
+		t.Errorf("expected no storage for linked clone, got %v", linked["storage"])
+	}
+	if _, exists := linked["description"]; exists {
+		t.Errorf("expected the template's description to be kept, got %v", linked["description"])
+	}
+
+	described := cloneParams(&CloneRequest{NewID: 101, Name: "runner-1", Description: "MachineClaim: default/runner-1"})
+	if described["description"] != "MachineClaim: default/runner-1" {
+		t.Errorf("expected the clone description to be set, got %v", described["description"])
+	}
+}
+
+func TestCloudInitNetworkParams(t *testing.T) {
+	tests := []struct {
+		name     string
+		network  *CloudInitNetwork
+		expected map[string]interface{}
+	}{
+		{name: "no network", expected: map[string]interface{}{}},
+		{
+			name: "static with resolver",
+			network: &CloudInitNetwork{
+				IP: "10.0.0.5/24", Gateway: "10.0.0.1",
+				Nameservers: []string{"10.0.0.53", "1.1.1.1"}, SearchDomains: []string{"runners.example.com"},
+			},
+			expected: map[string]interface{}{
+				"ipconfig0":    "ip=10.0.0.5/24,gw=10.0.0.1",
+				"nameserver":   "10.0.0.53 1.1.1.1",
+				"searchdomain": "runners.example.com",
+			},
+		},
+		{
+			name:    "dhcp with resolver override",
+			network: &CloudInitNetwork{DHCP: true, Nameservers: []string{"10.0.0.53"}},
+			expected: map[string]interface{}{
+				"ipconfig0":  "ip=dhcp",
+				"nameserver": "10.0.0.53",
+			},
+		},
+		{
+			name:     "search domain only",
+			network:  &CloudInitNetwork{SearchDomains: []string{"runners.example.com"}},
+			expected: map[string]interface{}{"searchdomain": "runners.example.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := cloudInitNetworkParams(tt.network)
+			if !maps.Equal(params, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, params)
+			}
+		})
+	}
+}
+
+func TestScsiDiskParams(t *testing.T) {
+	params := scsiDiskParams([]DiskConfig{
+		{SizeGB: 100, Storage: "local-lvm"},
+		{SizeGB: 20, Storage: "ceph-fast", Format: "qcow2"},
+	})
+
+	expected := map[string]interface{}{
+		"scsi1": "local-lvm:100",
+		"scsi2": "ceph-fast:20,format=qcow2",
+	}
+	if len(params) != len(expected) {
+		t.Fatalf("expected %d params, got %v", len(expected), params)
+	}
+	for key, value := range expected {
+		if params[key] != value {
+			t.Errorf("expected %s=%v, got %v", key, value, params[key])
+		}
+	}
+}
+
+func TestProxmoxClient_CloneVMDisks(t *testing.T) {
+	items := map[string]map[string]interface{}{
+		proxmoxVMResourcesPath:                 {"data": []interface{}{}},
+		"/nodes/pve1/storage/local-lvm/status": storageStatusItem(500),
+		"/nodes/pve1/storage/ceph-fast/status": storageStatusItem(500),
+	}
+	req := &CloneRequest{
+		SourceNode: "pve1",
+		SourceID:   9000,
+		NewID:      101,
+		Name:       "runner-1",
+		Disks: []DiskConfig{
+			{SizeGB: 100, Storage: "local-lvm"},
+			{SizeGB: 20, Storage: "ceph-fast", Format: "raw"},
+		},
+	}
+
+	t.Run("attaches disks after clone", func(t *testing.T) {
+		api := &fakeProxmoxAPI{items: items}
+		client := newFakeProxmoxClient(api)
+
+		if _, err := client.CloneVM(context.Background(), req); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if api.putURL != "/nodes/pve1/qemu/101/config" {
+			t.Errorf("unexpected config URL: %s", api.putURL)
+		}
+		if api.putParams["scsi1"] != "local-lvm:100" || api.putParams["scsi2"] != "ceph-fast:20,format=raw" {
+			t.Errorf("unexpected disk params: %v", api.putParams)
+		}
+	})
+
+	t.Run("attach failure", func(t *testing.T) {
+		api := &fakeProxmoxAPI{items: items, putErr: errors.New("storage full")}
+		client := newFakeProxmoxClient(api)
+
+		if _, err := client.CloneVM(context.Background(), req); err == nil || !strings.Contains(err.Error(), "storage full") {
+			t.Errorf("expected attach error, got %v", err)
+		}
+	})
+
+	t.Run("disk without storage", func(t *testing.T) {
+		api := &fakeProxmoxAPI{items: items}
+		client := newFakeProxmoxClient(api)
+
+		invalid := *req
+		invalid.Disks = []DiskConfig{{SizeGB: 10}}
+		if _, err := client.CloneVM(context.Background(), &invalid); err == nil {
+			t.Errorf("expected validation error")
+		}
+		if api.postURL != "" {
+			t.Errorf("expected no clone request, got %s", api.postURL)
+		}
+	})
+}
+
+// storageStatusItem is a Proxmox storage status response with availableGiB free of 1000 GiB
+func storageStatusItem(availableGiB int64) map[string]interface{} {
+	return map[string]interface{}{"data": map[string]interface{}{
+		"total": float64(1000 * bytesPerGiB),
+		"used":  float64((1000 - availableGiB) * bytesPerGiB),
+		"avail": float64(availableGiB * bytesPerGiB),
+	}}
+}
+
+func TestProxmoxClient_GetStorageStatus(t *testing.T) {
+	api := &fakeProxmoxAPI{items: map[string]map[string]interface{}{
+		"/nodes/pve1/storage/local-lvm/status": storageStatusItem(200),
+	}}
+	client := newFakeProxmoxClient(api)
+
+	status, err := client.GetStorageStatus(context.Background(), "pve1", "local-lvm")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := StorageStatus{Total: 1000 * bytesPerGiB, Used: 800 * bytesPerGiB, Available: 200 * bytesPerGiB}
+	if *status != expected {
+		t.Errorf("expected %+v, got %+v", expected, *status)
+	}
+
+	if _, err := client.GetStorageStatus(context.Background(), "pve1", "missing"); err == nil {
+		t.Errorf("expected error for unknown storage")
+	}
+
+	shared := storageStatusItem(200)
+	shared["data"].(map[string]interface{})["shared"] = float64(1)
+	api.items["/nodes/pve1/storage/ceph/status"] = shared
+	if status, err := client.GetStorageStatus(context.Background(), "pve1", "ceph"); err != nil || !status.Shared {
+		t.Errorf("expected shared storage, got %+v, %v", status, err)
+	}
+	if _, err := client.GetStorageStatus(context.Background(), "", "local-lvm"); err == nil {
+		t.Errorf("expected error without a node")
+	}
+}
+
+func TestProxmoxClient_CloneVMStorageSpace(t *testing.T) {
+	template := map[string]interface{}{"data": map[string]interface{}{
+		"scsi0": "local-lvm:base-9000-disk-0,size=32G",
+		"ide2":  "local-lvm:vm-9000-cloudinit,media=cdrom",
+		"net0":  "virtio=BC:24:11:00:00:01,bridge=vmbr0",
+	}}
+
+	tests := []struct {
+		name         string
+		availableGiB int64
+		req          CloneRequest
+		expectError  string
+	}{
+		{
+			name:         "sufficient space for data disks",
+			availableGiB: 120,
+			req:          CloneRequest{Disks: []DiskConfig{{SizeGB: 100, Storage: "local-lvm"}, {SizeGB: 20, Storage: "local-lvm"}}},
+		},
+		{
+			name:         "insufficient space for data disks",
+			availableGiB: 110,
+			req:          CloneRequest{Disks: []DiskConfig{{SizeGB: 100, Storage: "local-lvm"}, {SizeGB: 20, Storage: "local-lvm"}}},
+			expectError:  `storage "local-lvm" on node pve2 has 110 GiB free but the clone needs 120 GiB`,
+		},
+		{
+			name:         "sufficient space for full clone",
+			availableGiB: 32,
+			req:          CloneRequest{FullClone: true, Storage: "local-lvm"},
+		},
+		{
+			name:         "insufficient space for full clone",
+			availableGiB: 40,
+			req:          CloneRequest{FullClone: true, Storage: "local-lvm", Disks: []DiskConfig{{SizeGB: 10, Storage: "local-lvm"}}},
+			expectError:  "has 40 GiB free but the clone needs 42 GiB",
+		},
+		{
+			name: "linked clone shares the template's disks",
+			req:  CloneRequest{Storage: "local-lvm"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeProxmoxAPI{items: map[string]map[string]interface{}{
+				proxmoxVMResourcesPath:                 {"data": []interface{}{}},
+				"/nodes/pve1/qemu/9000/config":         template,
+				"/nodes/pve2/storage/local-lvm/status": storageStatusItem(tt.availableGiB),
+			}}
+			req := tt.req
+			req.SourceNode, req.SourceID, req.TargetNode, req.NewID, req.Name = "pve1", 9000, "pve2", 101, "runner-1"
+
+			_, err := newFakeProxmoxClient(api).CloneVM(context.Background(), &req)
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Errorf("expected error containing %q, got %v", tt.expectError, err)
+				}
+				if api.postURL != "" {
+					t.Errorf("expected no clone request, got %s", api.postURL)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestProxmoxClient_ValidateCreateRequest(t *testing.T) {
+	items := map[string]map[string]interface{}{
+		proxmoxNodesPath: {"data": []interface{}{
+			map[string]interface{}{"node": "pve1", "status": "online", "maxcpu": float64(16), "maxmem": float64(64 * bytesPerGiB), "mem": float64(8 * bytesPerGiB)},
+			map[string]interface{}{"node": "pve2", "status": "offline"},
+		}},
+		proxmoxVMResourcesPath: {"data": []interface{}{
+			map[string]interface{}{"vmid": float64(9000), "node": "pve1", "type": "qemu", "template": float64(1)},
+			map[string]interface{}{"vmid": float64(100), "node": "pve1", "type": "qemu"},
+		}},
+		proxmoxPoolsPath:                       {"data": []interface{}{map[string]interface{}{"poolid": "ci"}}},
+		"/storage/local-lvm":                   {"data": map[string]interface{}{"type": "lvmthin"}},
+		"/nodes/pve1/storage/local-lvm/status": storageStatusItem(50),
+		vmConfigPath(VMRef{Node: "pve1", ID: 9000}): {"data": map[string]interface{}{
+			"scsi0": "local-lvm:base-9000-disk-0,size=10G",
+		}},
+	}
+	valid := func() CloneRequest {
+		return CloneRequest{
+			SourceNode:   "pve1",
+			SourceID:     9000,
+			NewID:        101,
+			Name:         "runner-1",
+			Pool:         "ci",
+			BootDiskGB:   20,
+			Disks:        []DiskConfig{{SizeGB: 20, Storage: "local-lvm"}},
+			MinMemoryMiB: 2048,
+			CPUs:         4,
+			CPULimit:     2,
+		}
+	}
+
+	t.Run("valid request", func(t *testing.T) {
+		api := &fakeProxmoxAPI{items: items}
+		req := valid()
+		if err := newFakeProxmoxClient(api).ValidateCreateRequest(context.Background(), &req); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(api.postURLs) != 0 {
+			t.Errorf("expected nothing created, got %v", api.postURLs)
+		}
+	})
+
+	t.Run("every failure is reported", func(t *testing.T) {
+		api := &fakeProxmoxAPI{items: items}
+		req := valid()
+		req.NewID = 100
+		req.Pool = "missing"
+		req.Disks = []DiskConfig{{SizeGB: 80, Storage: "local-lvm", Format: "qcow2"}}
+		req.MinMemoryMiB = 128 * 1024
+		req.CPUs = 24
+		req.CPULimit = 32
+		req.VGA = "bogus"
+		req.BootDiskGB = 5
+
+		err := newFakeProxmoxClient(api).ValidateCreateRequest(context.Background(), &req)
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+		for _, expected := range []string{
+			`invalid VGA type "bogus"`,
+			"CPU limit 32 exceeds the 16 CPUs of node pve1",
+			"VM 100 needs 24 CPUs (24 cores x 1 sockets) but node pve1 has only 16",
+			"minimum memory 131072 MiB exceeds the 65536 MiB of node pve1",
+			`storage "local-lvm" on node pve1 has 50 GiB free but the clone needs 80 GiB`,
+			"VM ID 100 is already in use",
+			`pool "missing" does not exist`,
+			"boot disk size 5G is smaller than the 10 GiB boot disk scsi0 of VM 9000",
+			`format qcow2 for disk 0 is not supported by lvmthin storage "local-lvm"`,
+		} {
+			if !strings.Contains(err.Error(), expected) {
+				t.Errorf("expected error to contain %q, got %v", expected, err)
+			}
+		}
+		if len(api.postURLs) != 0 {
+			t.Errorf("expected nothing created, got %v", api.postURLs)
+		}
+	})
+
+	t.Run("offline or unknown nodes", func(t *testing.T) {
+		req := valid()
+		req.TargetNode = "pve2"
+		err := newFakeProxmoxClient(&fakeProxmoxAPI{items: items}).ValidateCreateRequest(context.Background(), &req)
+		if err == nil || !strings.Contains(err.Error(), `target node "pve2" is offline`) {
+			t.Errorf("expected an offline target node error, got %v", err)
+		}
+
+		req = valid()
+		req.SourceNode = "pve9"
+		err = newFakeProxmoxClient(&fakeProxmoxAPI{items: items}).ValidateCreateRequest(context.Background(), &req)
+		if err == nil || !strings.Contains(err.Error(), `target node "pve9" does not exist`) {
+			t.Errorf("expected an unknown target node error, got %v", err)
+		}
+	})
+}
+
+func TestDriveSize(t *testing.T) {
+	tests := map[string]int64{
+		"local-lvm:base-9000-disk-0,size=32G": 32 * bytesPerGiB,
+		"local:9000/disk.qcow2,size=512M":     512 * bytesPerMiB,
+		"ceph:disk-0,size=2T,ssd=1":           2048 * bytesPerGiB,
+		"local:disk-0,size=4096":              4096,
+	}
+	for drive, expected := range tests {
+		if got, err := driveSize(drive); err != nil || got != expected {
+			t.Errorf("driveSize(%q) = %d, %v; expected %d", drive, got, err, expected)
+		}
+	}
+
+	for _, drive := range []string{"local-lvm:disk-0", "local:disk-0,size=10X"} {
+		if _, err := driveSize(drive); err == nil {
+			t.Errorf("expected error for %q", drive)
+		}
+	}
+}
+
+func TestProxmoxClient_CloneVMDiskFormat(t *testing.T) {
+	items := map[string]map[string]interface{}{
+		proxmoxVMResourcesPath: {"data": []interface{}{}},
+		"/storage/local":       {"data": map[string]interface{}{"storage": "local", "type": "dir"}},
+		"/storage/local-lvm":   {"data": map[string]interface{}{"storage": "local-lvm", "type": "lvmthin"}},
+
+		"/nodes/pve1/storage/local/status":     storageStatusItem(500),
+		"/nodes/pve1/storage/local-lvm/status": storageStatusItem(500),
+	}
+
+	tests := []struct {
+		name         string
+		disk         DiskConfig
+		expectedDisk string
+		expectError  string
+	}{
+		{
+			name:         "raw on block storage",
+			disk:         DiskConfig{SizeGB: 20, Storage: "local-lvm", Format: "raw"},
+			expectedDisk: "local-lvm:20,format=raw",
+		},
+		{
+			name:         "qcow2 on file storage",
+			disk:         DiskConfig{SizeGB: 20, Storage: "local", Format: "qcow2"},
+			expectedDisk: "local:20,format=qcow2",
+		},
+		{
+			name:        "qcow2 on block storage",
+			disk:        DiskConfig{SizeGB: 20, Storage: "local-lvm", Format: "qcow2"},
+			expectError: `not supported by lvmthin storage "local-lvm"`,
+		},
+		{
+			name:        "unknown format",
+			disk:        DiskConfig{SizeGB: 20, Storage: "local", Format: "vdi"},
+			expectError: `invalid format "vdi"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeProxmoxAPI{items: items}
+			req := &CloneRequest{
+				SourceNode: "pve1",
+				SourceID:   9000,
+				NewID:      101,
+				Name:       "runner-1",
+				Disks:      []DiskConfig{tt.disk},
+			}
+
+			_, err := newFakeProxmoxClient(api).CloneVM(context.Background(), req)
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Errorf("expected error containing %q, got %v", tt.expectError, err)
+				}
+				if api.postURL != "" {
+					t.Errorf("expected no clone request, got %s", api.postURL)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if api.putParams["scsi1"] != tt.expectedDisk {
+				t.Errorf("expected scsi1 %q, got %v", tt.expectedDisk, api.putParams["scsi1"])
+			}
+		})
+	}
+}
+
+func TestProxmoxClient_GetCapabilities(t *testing.T) {
+	tests := []struct {
+		name       string
+		members    []interface{}
+		haStatus   map[string]interface{}
+		expectLive bool
+		expectHA   bool
+	}{
+		{
+			name: "multi-node cluster",
+			members: []interface{}{
+				map[string]interface{}{"type": "cluster", "name": "lab", "quorate": float64(1)},
+				map[string]interface{}{"type": "node", "name": "pve1", "online": float64(1)},
+				map[string]interface{}{"type": "node", "name": "pve2", "online": float64(1)},
+			},
+			haStatus:   noHAStatus,
+			expectLive: true,
+		},
+		{
+			name: "HA cluster",
+			members: []interface{}{
+				map[string]interface{}{"type": "node", "name": "pve1", "online": float64(1)},
+				map[string]interface{}{"type": "node", "name": "pve2", "online": float64(1)},
+			},
+			haStatus:   haStatusManaging("started"),
+			expectLive: true,
+			expectHA:   true,
+		},
+		{
+			name: "peer node offline",
+			members: []interface{}{
+				map[string]interface{}{"type": "node", "name": "pve1", "online": float64(1)},
+				map[string]interface{}{"type": "node", "name": "pve2", "online": float64(0)},
+			},
+			haStatus:   noHAStatus,
+			expectLive: false,
+		},
+		{
+			name: "standalone node",
+			members: []interface{}{
+				map[string]interface{}{"type": "node", "name": "pve1", "online": float64(1)},
+			},
+			haStatus:   noHAStatus,
+			expectLive: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newFakeProxmoxClient(&fakeProxmoxAPI{items: map[string]map[string]interface{}{
+				proxmoxClusterStatusPath: {"data": tt.members},
+				proxmoxHAStatusPath:      tt.haStatus,
+			}})
+
+			capabilities, err := client.GetCapabilities(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if capabilities.LiveMigration != tt.expectLive {
+				t.Errorf("expected LiveMigration %v, got %v", tt.expectLive, capabilities.LiveMigration)
+			}
+			if capabilities.HA != tt.expectHA {
+				t.Errorf("expected HA %v, got %v", tt.expectHA, capabilities.HA)
+			}
+		})
+	}
+}
+
+func TestProxmoxClient_ListNodes(t *testing.T) {
+	client := newFakeProxmoxClient(&fakeProxmoxAPI{items: map[string]map[string]interface{}{
+		proxmoxNodesPath: {"data": []interface{}{
+			map[string]interface{}{
+				"node": "pve1", "status": "online", "maxcpu": float64(16),
+				"maxmem": float64(64 * bytesPerGiB), "mem": float64(24 * bytesPerGiB),
+			},
+			map[string]interface{}{"node": "pve2", "status": "offline"},
+			map[string]interface{}{"node": "pve3", "status": "unknown"},
+		}},
+	}})
+
+	nodes, err := client.ListNodes(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []NodeInfo{
+		{Name: "pve1", Online: true, CPUs: 16, MemoryMiB: 64 * 1024, FreeMemoryMiB: 40 * 1024},
+		{Name: "pve2"},
+		{Name: "pve3"},
+	}
+	if !slices.Equal(nodes, expected) {
+		t.Errorf("expected nodes %v, got %v", expected, nodes)
+	}
+}
+
+func TestProxmoxClient_ServerTime(t *testing.T) {
+	client := newFakeProxmoxClient(&fakeProxmoxAPI{items: map[string]map[string]interface{}{
+		proxmoxNodesPath: {"data": []interface{}{
+			map[string]interface{}{"node": "pve1", "status": "offline"},
+			map[string]interface{}{"node": "pve2", "status": "online"},
+		}},
+		"/nodes/pve2/time": {"data": map[string]interface{}{
+			"time": float64(1767225600), "localtime": float64(1767229200), "timezone": "Europe/Berlin",
+		}},
+	}})
+
+	serverTime, err := client.ServerTime(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := time.Unix(1767225600, 0); !serverTime.Equal(expected) {
+		t.Errorf("expected %v, got %v", expected, serverTime)
+	}
+
+	offline := newFakeProxmoxClient(&fakeProxmoxAPI{items: map[string]map[string]interface{}{
+		proxmoxNodesPath: {"data": []interface{}{
+			map[string]interface{}{"node": "pve1", "status": "offline"},
+		}},
+	}})
+	if _, err := offline.ServerTime(context.Background()); err == nil {
+		t.Error("expected error without online nodes")
+	}
+}
+
+func TestProxmoxClient_GetNodeVersion(t *testing.T) {
+	client := newFakeProxmoxClient(&fakeProxmoxAPI{items: map[string]map[string]interface{}{
+		"/nodes/pve1/version": {"data": map[string]interface{}{
+			"version": "8.2.4", "release": "8.2", "repoid": "faa83925c9641325",
+		}},
+		"/nodes/pve2/version": {"errors": "permission denied"},
+	}})
+
+	version, err := client.GetNodeVersion(context.Background(), "pve1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if version != "8.2.4" {
+		t.Errorf("expected version 8.2.4, got %q", version)
+	}
+
+	if _, err := client.GetNodeVersion(context.Background(), "pve2"); err == nil {
+		t.Error("expected error for a malformed response")
+	}
+	failing := newFakeProxmoxClient(&fakeProxmoxAPI{itemsErr: errors.New("connection reset by peer")})
+	if _, err := failing.GetNodeVersion(context.Background(), "pve1"); err == nil {
+		t.Error("expected error when the API call fails")
+	}
+}
+
+func TestProxmoxClient_SubscriptionStatus(t *testing.T) {
+	onlineNodes := map[string]interface{}{"data": []interface{}{
+		map[string]interface{}{"node": "pve1", "status": "online"},
+		map[string]interface{}{"node": "pve2", "status": "online"},
+		map[string]interface{}{"node": "pve3", "status": "offline"},
+	}}
+	subscription := func(status string) map[string]interface{} {
+		return map[string]interface{}{"data": map[string]interface{}{
+			"status":      status,
+			"level":       "c",
+			"nextduedate": "2027-01-01",
+		}}
+	}
+
+	tests := []struct {
+		name          string
+		statuses      map[string]string
+		expectState   SubscriptionState
+		expectMessage string
+	}{
+		{
+			name:          "active",
+			statuses:      map[string]string{"pve1": "active", "pve2": "active"},
+			expectState:   SubscriptionActive,
+			expectMessage: "node pve1: subscription active",
+		},
+		{
+			name:          "expired",
+			statuses:      map[string]string{"pve1": "active", "pve2": "expired"},
+			expectState:   SubscriptionExpired,
+			expectMessage: "node pve2: subscription expired",
+		},
+		{
+			name:          "no subscription",
+			statuses:      map[string]string{"pve1": "notfound", "pve2": "expired"},
+			expectState:   SubscriptionNone,
+			expectMessage: "node pve1: no subscription",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := map[string]map[string]interface{}{
+				proxmoxNodesPath: onlineNodes,
+				// The offline node is never queried
+				"/nodes/pve3/subscription": subscription("notfound"),
+			}
+			for node, status := range tt.statuses {
+				items["/nodes/"+node+"/subscription"] = subscription(status)
+			}
+			client := newFakeProxmoxClient(&fakeProxmoxAPI{items: items})
+
+			info, err := client.SubscriptionStatus(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if info.State != tt.expectState {
+				t.Errorf("expected state %s, got %s", tt.expectState, info.State)
+			}
+			if info.Message != tt.expectMessage {
+				t.Errorf("expected message %q, got %q", tt.expectMessage, info.Message)
+			}
+		})
+	}
+}
+
+func TestProxmoxClient_SubscriptionStatus_NoOnlineNodes(t *testing.T) {
+	client := newFakeProxmoxClient(&fakeProxmoxAPI{items: map[string]map[string]interface{}{
+		proxmoxNodesPath: {"data": []interface{}{
+			map[string]interface{}{"node": "pve1", "status": "offline"},
+		}},
+	}})
+
+	if _, err := client.SubscriptionStatus(context.Background()); err == nil {
+		t.Fatal("expected an error when no node is online")
+	}
+}
+
+func TestProxmoxSubscriptionState(t *testing.T) {
+	tests := map[string]SubscriptionState{
+		"active":    SubscriptionActive,
+		"Active":    SubscriptionActive,
+		"expired":   SubscriptionExpired,
+		"suspended": SubscriptionExpired,
+		"notfound":  SubscriptionNone,
+		"new":       SubscriptionNone,
+		"invalid":   SubscriptionNone,
+		"":          SubscriptionNone,
+	}
+	for status, expected := range tests {
+		if got := proxmoxSubscriptionState(status); got != expected {
+			t.Errorf("proxmoxSubscriptionState(%q) = %s, want %s", status, got, expected)
+		}
+	}
+}
+
+func TestProxmoxClient_MigrateVM(t *testing.T) {
+	standalone := map[string]map[string]interface{}{
+		proxmoxClusterStatusPath: {"data": []interface{}{
+			map[string]interface{}{"type": "node", "name": "pve1", "online": float64(1)},
+		}},
+		proxmoxHAStatusPath: noHAStatus,
+	}
+	clustered := map[string]map[string]interface{}{
+		proxmoxClusterStatusPath: {"data": []interface{}{
+			map[string]interface{}{"type": "node", "name": "pve1", "online": float64(1)},
+			map[string]interface{}{"type": "node", "name": "pve2", "online": float64(1)},
+		}},
+		proxmoxHAStatusPath: noHAStatus,
+	}
+	ref := VMRef{Node: "pve1", ID: 101}
+
+	tests := []struct {
+		name         string
+		items        map[string]map[string]interface{}
+		target       string
+		live         bool
+		postErr      error
+		expectError  string
+		expectOnline interface{}
+		expectNoTask bool
+	}{
+		{
+			name:         "offline migration",
+			items:        standalone,
+			target:       "pve2",
+			expectOnline: 0,
+		},
+		{
+			name:         "live migration",
+			items:        clustered,
+			target:       "pve2",
+			live:         true,
+			expectOnline: 1,
+		},
+		{
+			name:         "live migration unsupported",
+			items:        standalone,
+			target:       "pve2",
+			live:         true,
+			expectError:  "live migration of VM 101 is not supported",
+			expectNoTask: true,
+		},
+		{
+			name:         "already on target",
+			items:        standalone,
+			target:       "pve1",
+			expectError:  "already on node pve1",
+			expectNoTask: true,
+		},
+		{
+			name:        "migration task fails",
+			items:       standalone,
+			target:      "pve2",
+			postErr:     errors.New("migration aborted"),
+			expectError: "migration aborted",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeProxmoxAPI{items: tt.items, postErr: tt.postErr}
+			client := newFakeProxmoxClient(api)
+
+			err := client.MigrateVM(context.Background(), ref, tt.target, tt.live)
+
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Errorf("expected error containing %q, got %v", tt.expectError, err)
+				}
+				if tt.expectNoTask && api.postURL != "" {
+					t.Errorf("expected no migration task, got %s", api.postURL)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if api.postURL != "/nodes/pve1/qemu/101/migrate" {
+				t.Errorf("unexpected migrate URL: %s", api.postURL)
+			}
+			if api.postParams["target"] != tt.target || api.postParams["online"] != tt.expectOnline {
+				t.Errorf("unexpected migrate params: %v", api.postParams)
+			}
+		})
+	}
+}
+
+func TestProxmoxClient_GetVM(t *testing.T) {
+	ref := VMRef{Node: "pve1", ID: 101}
+	client := newFakeProxmoxClient(&fakeProxmoxAPI{items: map[string]map[string]interface{}{
+		"/nodes/pve1/qemu/101/status/current": {"data": map[string]interface{}{
+			"name": "runner-1", "status": "running", "qmpstatus": "paused",
+			"cpus": float64(4), "maxmem": float64(8 * 1024 * 1024 * 1024),
+		}},
+	}})
+
+	info, err := client.GetVM(context.Background(), ref)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.Ref != ref || info.Name != "runner-1" || info.PowerState != PowerStatePaused {
+		t.Errorf("unexpected VM info: %+v", info)
+	}
+	if info.Resources != (VMResources{CPUs: 4, MemoryMiB: 8192}) {
+		t.Errorf("unexpected VM resources: %+v", info.Resources)
+	}
+
+	missing := newFakeProxmoxClient(&fakeProxmoxAPI{itemsErr: errors.New("vm does not exist")})
+	if _, err := missing.GetVM(context.Background(), ref); err == nil {
+		t.Errorf("expected error for missing VM")
+	}
+}
+
+func TestProxmoxClient_GetPoolUsage(t *testing.T) {
+	client := newFakeProxmoxClient(&fakeProxmoxAPI{items: map[string]map[string]interface{}{
+		"/pools/ci": {"data": map[string]interface{}{
+			"members": []interface{}{
+				map[string]interface{}{"type": "qemu", "vmid": float64(101), "maxcpu": float64(2), "maxmem": float64(4 * 1024 * 1024 * 1024)},
+				map[string]interface{}{"type": "qemu", "vmid": float64(102), "maxcpu": float64(4), "maxmem": float64(8 * 1024 * 1024 * 1024)},
+				// Templates and storage are pool members but do not count towards usage
+				map[string]interface{}{"type": "qemu", "vmid": float64(9000), "template": float64(1), "maxcpu": float64(2), "maxmem": float64(2 * 1024 * 1024 * 1024)},
+				map[string]interface{}{"type": "storage", "storage": "local-lvm"},
+			},
+		}},
+		"/pools/empty": {"data": map[string]interface{}{"comment": "no members"}},
+	}})
+
+	usage, err := client.GetPoolUsage(context.Background(), "ci")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *usage != (PoolUsage{VMs: 2, CPUs: 6, MemoryMiB: 12288}) {
+		t.Errorf("unexpected pool usage: %+v", usage)
+	}
+
+	usage, err = client.GetPoolUsage(context.Background(), "empty")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *usage != (PoolUsage{}) {
+		t.Errorf("expected no usage for an empty pool, got %+v", usage)
+	}
+
+	if _, err := client.GetPoolUsage(context.Background(), ""); err == nil {
+		t.Errorf("expected error for an empty pool name")
+	}
+	missing := newFakeProxmoxClient(&fakeProxmoxAPI{itemsErr: errors.New("pool does not exist")})
+	if _, err := missing.GetPoolUsage(context.Background(), "ci"); err == nil {
+		t.Errorf("expected error for a missing pool")
+	}
+}
+
+func TestProxmoxClient_GetNodeNetworks(t *testing.T) {
+	client := newFakeProxmoxClient(&fakeProxmoxAPI{items: map[string]map[string]interface{}{
+		"/nodes/pve1/network?type=any_bridge": {"data": []interface{}{
+			map[string]interface{}{"iface": "vmbr0", "type": "bridge", "cidr": "192.168.1.10/24", "gateway": "192.168.1.1"},
+			map[string]interface{}{"iface": "vmbr1", "type": "OVSBridge"},
+		}},
+	}})
+
+	networks, err := client.GetNodeNetworks(context.Background(), "pve1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []NetworkInfo{
+		{Name: "vmbr0", CIDR: "192.168.1.10/24", Gateway: "192.168.1.1"},
+		{Name: "vmbr1"},
+	}
+	if !slices.Equal(networks, expected) {
+		t.Errorf("expected %+v, got %+v", expected, networks)
+	}
+
+	if _, err := client.GetNodeNetworks(context.Background(), ""); err == nil {
+		t.Errorf("expected error for an empty node name")
+	}
+	offline := newFakeProxmoxClient(&fakeProxmoxAPI{itemsErr: errors.New("node is offline")})
+	if _, err := offline.GetNodeNetworks(context.Background(), "pve1"); err == nil {
+		t.Errorf("expected error for an offline node")
+	}
+}
+
+func TestProxmoxPowerState(t *testing.T) {
+	tests := []struct {
+		status    string
+		qmpStatus string
+		expected  PowerState
+	}{
+		{"running", "running", PowerStateRunning},
+		{"running", "", PowerStateRunning},
+		{"running", "paused", PowerStatePaused},
+		{"running", "suspended", PowerStateSuspended},
+		{"stopped", "stopped", PowerStateStopped},
+		{"stopped", "", PowerStateStopped},
+		{"migrating", "", PowerStateUnknown},
+		{"", "", PowerStateUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status+"/"+tt.qmpStatus, func(t *testing.T) {
+			if got := proxmoxPowerState(tt.status, tt.qmpStatus); got != tt.expected {
+				t.Errorf("proxmoxPowerState(%q, %q) = %s, want %s", tt.status, tt.qmpStatus, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestProxmoxClient_GetVMTags(t *testing.T) {
+	ref := VMRef{Node: "pve1", ID: 101}
+
+	tagged := newFakeProxmoxClient(&fakeProxmoxAPI{items: map[string]map[string]interface{}{
+		"/nodes/pve1/qemu/101/config": {"data": map[string]interface{}{"tags": "env_prod;team_ci"}},
+	}})
+	tags, err := tagged.GetVMTags(context.Background(), ref)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tags) != 2 || tags[0] != "env_prod" || tags[1] != "team_ci" {
+		t.Errorf("unexpected tags: %v", tags)
+	}
+
+	untagged := newFakeProxmoxClient(&fakeProxmoxAPI{items: map[string]map[string]interface{}{
+		"/nodes/pve1/qemu/101/config": {"data": map[string]interface{}{"name": "runner-1"}},
+	}})
+	tags, err = untagged.GetVMTags(context.Background(), ref)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tags) != 0 {
+		t.Errorf("expected no tags, got %v", tags)
+	}
+}
+
+func TestProxmoxClient_SetVMTags(t *testing.T) {
+	ref := VMRef{Node: "pve1", ID: 101}
+
+	api := &fakeProxmoxAPI{}
+	if err := newFakeProxmoxClient(api).SetVMTags(context.Background(), ref, []string{"env_prod", "team_ci"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if api.putURL != "/nodes/pve1/qemu/101/config" || api.putParams["tags"] != "env_prod;team_ci" {
+		t.Errorf("unexpected tag update %s %v", api.putURL, api.putParams)
+	}
+
+	// Clearing tags deletes the option rather than sending an empty value
+	api = &fakeProxmoxAPI{}
+	if err := newFakeProxmoxClient(api).SetVMTags(context.Background(), ref, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if api.putParams["delete"] != "tags" {
+		t.Errorf("expected tags option to be deleted, got %v", api.putParams)
+	}
+
+	api = &fakeProxmoxAPI{putErr: errors.New("permission denied")}
+	if err := newFakeProxmoxClient(api).SetVMTags(context.Background(), ref, []string{"env_prod"}); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+func TestProxmoxClient_GetVMDescription(t *testing.T) {
+	ref := VMRef{Node: "pve1", ID: 101}
+
+	tests := []struct {
+		name        string
+		items       map[string]map[string]interface{}
+		itemsErr    error
+		expected    string
+		expectError bool
+	}{
+		{
+			name: "description set",
+			items: map[string]map[string]interface{}{
+				"/nodes/pve1/qemu/101/config": {"data": map[string]interface{}{"name": "runner-1", "description": "Managed by HyperFleet"}},
+			},
+			expected: "Managed by HyperFleet",
+		},
+		{
+			name: "no description",
+			items: map[string]map[string]interface{}{
+				"/nodes/pve1/qemu/101/config": {"data": map[string]interface{}{"name": "runner-1"}},
+			},
+			expected: "",
+		},
+		{
+			name:        "api error",
+			itemsErr:    errors.New("vm does not exist"),
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newFakeProxmoxClient(&fakeProxmoxAPI{items: tt.items, itemsErr: tt.itemsErr})
+
+			description, err := client.GetVMDescription(context.Background(), ref)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if description != tt.expected {
+				t.Errorf("expected description %q, got %q", tt.expected, description)
+			}
+		})
+	}
+}
+
+func TestProxmoxClient_SetVMDescription(t *testing.T) {
+	api := &fakeProxmoxAPI{}
+	client := newFakeProxmoxClient(api)
+
+	if err := client.SetVMDescription(context.Background(), VMRef{Node: "pve1", ID: 101}, "Managed by HyperFleet"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if api.putURL != "/nodes/pve1/qemu/101/config" {
+		t.Errorf("unexpected config URL: %s", api.putURL)
+	}
+	if api.putParams["description"] != "Managed by HyperFleet" {
+		t.Errorf("unexpected description param: %v", api.putParams["description"])
+	}
+
+	api.putErr = errors.New("permission denied")
+	if err := client.SetVMDescription(context.Background(), VMRef{Node: "pve1", ID: 101}, "notes"); err == nil {
+		t.Errorf("expected error but got none")
+	}
+}
+
+func TestBootOrderParam(t *testing.T) {
+	tests := []struct {
+		order    []string
+		expected string
+	}{
+		{order: []string{"scsi0"}, expected: "order=scsi0"},
+		{order: []string{"scsi0", "ide2", "net0"}, expected: "order=scsi0;ide2;net0"},
+	}
+	for _, tt := range tests {
+		if got := bootOrderParam(tt.order); got != tt.expected {
+			t.Errorf("bootOrderParam(%v) = %q, want %q", tt.order, got, tt.expected)
+		}
+	}
+}
+
+func TestParseBootOrder(t *testing.T) {
+	tests := map[string][]string{
+		"order=scsi0;ide2;net0": {"scsi0", "ide2", "net0"},
+		"order=virtio0":         {"virtio0"},
+		"cdn":                   nil,
+		"":                      nil,
+	}
+	for boot, expected := range tests {
+		if got := parseBootOrder(boot); !slices.Equal(got, expected) {
+			t.Errorf("parseBootOrder(%q) = %v, want %v", boot, got, expected)
+		}
+	}
+}
+
+func TestProxmoxClient_GetBootOrder(t *testing.T) {
+	ref := VMRef{Node: "pve1", ID: 101}
+	client := newFakeProxmoxClient(&fakeProxmoxAPI{items: map[string]map[string]interface{}{
+		vmConfigPath(ref): {"data": map[string]interface{}{"boot": "order=ide2;scsi0"}},
+	}})
+
+	order, err := client.GetBootOrder(context.Background(), ref)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(order, []string{"ide2", "scsi0"}) {
+		t.Errorf("unexpected boot order: %v", order)
+	}
+}
+
+func TestProxmoxClient_GetVMMACAddresses(t *testing.T) {
+	ref := VMRef{Node: "pve1", ID: 101}
+
+	tests := []struct {
+		name     string
+		config   map[string]interface{}
+		expected map[int]string
+	}{
+		{
+			name: "model option carries the MAC",
+			config: map[string]interface{}{
+				"net0": "virtio=BC:24:11:00:00:01,bridge=vmbr0,firewall=1",
+				"net1": "e1000=bc:24:11:00:00:02,bridge=vmbr1",
+			},
+			expected: map[int]string{0: "BC:24:11:00:00:01", 1: "BC:24:11:00:00:02"},
+		},
+		{
+			name:     "macaddr option",
+			config:   map[string]interface{}{"net2": "model=virtio,macaddr=BC:24:11:00:00:03,bridge=vmbr0"},
+			expected: map[int]string{2: "BC:24:11:00:00:03"},
+		},
+		{
+			name: "non-interface keys and interfaces without a MAC are skipped",
+			config: map[string]interface{}{
+				"name":    "runner-1",
+				"netmask": "255.255.255.0",
+				"net0":    "virtio,bridge=vmbr0",
+			},
+			expected: map[int]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newFakeProxmoxClient(&fakeProxmoxAPI{items: map[string]map[string]interface{}{
+				vmConfigPath(ref): {"data": tt.config},
+			}})
+
+			macs, err := client.GetVMMACAddresses(context.Background(), ref)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !maps.Equal(macs, tt.expected) {
+				t.Errorf("expected MAC addresses %v, got %v", tt.expected, macs)
+			}
+		})
+	}
+}
+
+func TestProxmoxClient_GetVMNetworkBridges(t *testing.T) {
+	ref := VMRef{Node: "pve1", ID: 101}
+	client := newFakeProxmoxClient(&fakeProxmoxAPI{items: map[string]map[string]interface{}{
+		vmConfigPath(ref): {"data": map[string]interface{}{
+			"name":    "runner-1",
+			"net0":    "virtio=BC:24:11:00:00:01,bridge=vmbr0,firewall=1",
+			"net1":    "e1000=BC:24:11:00:00:02,bridge=vmbr1",
+			"net2":    "virtio=BC:24:11:00:00:03",
+			"netmask": "255.255.255.0",
+		}},
+	}})
+
+	bridges, err := client.GetVMNetworkBridges(context.Background(), ref)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[int]string{0: "vmbr0", 1: "vmbr1"}
+	if !maps.Equal(bridges, expected) {
+		t.Errorf("expected bridges %v, got %v", expected, bridges)
+	}
+}
+
+func TestProxmoxClient_SetVMNetworkBridges(t *testing.T) {
+	ref := VMRef{Node: "pve1", ID: 101}
+	items := map[string]map[string]interface{}{
+		vmConfigPath(ref): {"data": map[string]interface{}{
+			"net0": "virtio=BC:24:11:00:00:01,bridge=vmbr1,firewall=1",
+			"net1": "virtio=BC:24:11:00:00:02,bridge=vmbr1",
+		}},
+	}
+
+	t.Run("moves only the given interfaces", func(t *testing.T) {
+		api := &fakeProxmoxAPI{items: items}
+		if err := newFakeProxmoxClient(api).SetVMNetworkBridges(context.Background(), ref, map[int]string{0: "vmbr0"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := map[string]interface{}{"net0": "virtio=BC:24:11:00:00:01,bridge=vmbr0,firewall=1"}
+		if api.putURL != vmConfigPath(ref) || !maps.Equal(api.putParams, expected) {
+			t.Errorf("expected %v on %s, got %v on %s", expected, vmConfigPath(ref), api.putParams, api.putURL)
+		}
+	})
+
+	t.Run("missing interface", func(t *testing.T) {
+		api := &fakeProxmoxAPI{items: items}
+		err := newFakeProxmoxClient(api).SetVMNetworkBridges(context.Background(), ref, map[int]string{3: "vmbr0"})
+		if err == nil || !strings.Contains(err.Error(), "has no network interface net3") {
+			t.Errorf("expected a missing interface error, got %v", err)
+		}
+		if api.putURL != "" {
+			t.Errorf("expected no config update, got %s", api.putURL)
+		}
+	})
+}
+
+func TestProxmoxClient_SetBootOrder(t *testing.T) {
+	ref := VMRef{Node: "pve1", ID: 101}
+
+	tests := []struct {
+		name        string
+		order       []string
+		expectBoot  string
+		expectError string
+	}{
+		{name: "disk then network", order: []string{"scsi0", "net0"}, expectBoot: "order=scsi0;net0"},
+		{name: "empty order", order: nil, expectError: "at least one device"},
+		{name: "invalid device", order: []string{"scsi0", "floppy"}, expectError: `invalid boot device "floppy"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeProxmoxAPI{}
+			client := newFakeProxmoxClient(api)
+
+			err := client.SetBootOrder(context.Background(), ref, tt.order)
+
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Errorf("expected error containing %q, got %v", tt.expectError, err)
+				}
+				if api.putURL != "" {
+					t.Errorf("expected no config update, got %s", api.putURL)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if api.putURL != vmConfigPath(ref) || api.putParams["boot"] != tt.expectBoot {
+				t.Errorf("unexpected config update %s: %v", api.putURL, api.putParams)
+			}
+		})
+	}
+}
+
+func TestProxmoxClient_SetCloudInitCredentials(t *testing.T) {
+	ref := VMRef{Node: "pve1", ID: 101}
+	keys := []string{
+		"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIA+b/c= ops@example.com",
+		"ssh-rsa AAAAB3NzaC1yc2E debug",
+	}
+
+	tests := []struct {
+		name         string
+		user         string
+		password     string
+		sshKeys      []string
+		expectParams map[string]interface{}
+	}{
+		{
+			name:     "all fields",
+			user:     "runner",
+			password: "s3cret pass",
+			sshKeys:  keys,
+			expectParams: map[string]interface{}{
+				"ciuser":     "runner",
+				"cipassword": "s3cret pass",
+				"sshkeys":    "ssh-ed25519%20AAAAC3NzaC1lZDI1NTE5AAAAIA+b%2Fc=%20ops@example.com%0Assh-rsa%20AAAAB3NzaC1yc2E%20debug",
+			},
+		},
+		{
+			name:         "password only",
+			password:     "s3cret",
+			expectParams: map[string]interface{}{"cipassword": "s3cret"},
+		},
+		{
+			name: "nothing to set",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeProxmoxAPI{}
+			client := newFakeProxmoxClient(api)
+
+			if err := client.SetCloudInitCredentials(context.Background(), ref, tt.user, tt.password, tt.sshKeys); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.expectParams == nil {
+				if api.putURL != "" {
+					t.Errorf("expected no config update, got %s", api.putURL)
+				}
+				return
+			}
+			if api.putURL != vmConfigPath(ref) || !maps.Equal(api.putParams, tt.expectParams) {
+				t.Errorf("unexpected config update %s: %v", api.putURL, api.putParams)
+			}
+		})
+	}
+
+	t.Run("update error does not reveal the password", func(t *testing.T) {
+		api := &fakeProxmoxAPI{putErr: errors.New("500 Internal Server Error")}
+		client := newFakeProxmoxClient(api)
+
+		err := client.SetCloudInitCredentials(context.Background(), ref, "runner", "s3cret", nil)
+		if err == nil || !strings.Contains(err.Error(), "failed to set cloud-init credentials of VM 101") {
+			t.Fatalf("expected credentials error, got %v", err)
+		}
+		if strings.Contains(err.Error(), "s3cret") {
+			t.Errorf("expected the password to be left out of the error, got %v", err)
+		}
+	})
+}
+
+func TestProxmoxClient_CloneVMCredentials(t *testing.T) {
+	items := map[string]map[string]interface{}{
+		proxmoxVMResourcesPath: {"data": []interface{}{}},
+	}
+	api := &fakeProxmoxAPI{items: items}
+	client := newFakeProxmoxClient(api)
+
+	req := &CloneRequest{
+		SourceNode: "pve1", SourceID: 9000, NewID: 101, Name: "runner-1",
+		Credentials: &CloudInitCredentials{User: "runner", Password: "s3cret", SSHKeys: []string{"ssh-ed25519 AAAA ops"}},
+	}
+	if _, err := client.CloneVM(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if api.putParams["ciuser"] != "runner" || api.putParams["cipassword"] != "s3cret" || api.putParams["sshkeys"] != "ssh-ed25519%20AAAA%20ops" {
+		t.Errorf("expected the credentials in the clone's config update, got %v", api.putParams)
+	}
+
+	// Without credentials the template's are kept
+	api = &fakeProxmoxAPI{items: items}
+	client = newFakeProxmoxClient(api)
+	req.Credentials = nil
+	if _, err := client.CloneVM(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, key := range []string{"ciuser", "cipassword", "sshkeys"} {
+		if _, ok := api.putParams[key]; ok {
+			t.Errorf("expected %s to be left alone, got %v", key, api.putParams)
+		}
+	}
+}
+
+func TestProxmoxClient_NestedVirtualizationFlag(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      map[string]interface{}
+		expectFlag  string
+		expectError bool
+	}{
+		{
+			name:       "Intel VT-x",
+			status:     map[string]interface{}{"cpuinfo": map[string]interface{}{"flags": "fpu vme sse2 vmx ept"}},
+			expectFlag: "vmx",
+		},
+		{
+			name:       "AMD-V",
+			status:     map[string]interface{}{"cpuinfo": map[string]interface{}{"flags": "fpu sse2 svm npt"}},
+			expectFlag: "svm",
+		},
+		{
+			name:        "no virtualization extension",
+			status:      map[string]interface{}{"cpuinfo": map[string]interface{}{"flags": "fpu vme sse2 hypervisor"}},
+			expectError: true,
+		},
+		{
+			name:        "flags not reported",
+			status:      map[string]interface{}{"cpuinfo": map[string]interface{}{"cpus": float64(8)}},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeProxmoxAPI{items: map[string]map[string]interface{}{
+				"/nodes/pve1/status": {"data": tt.status},
+			}}
+
+			flag, err := newFakeProxmoxClient(api).NestedVirtualizationFlag(context.Background(), "pve1")
+			if tt.expectError {
+				if !IsNestedVirtualizationUnsupported(err) {
+					t.Errorf("expected ErrNestedVirtualizationUnsupported, got %q (%v)", flag, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if flag != tt.expectFlag {
+				t.Errorf("expected flag %q, got %q", tt.expectFlag, flag)
+			}
+		})
+	}
+}
+
+func TestProxmoxClient_CloneVMNestedVirtualization(t *testing.T) {
+	items := func(flags string) map[string]map[string]interface{} {
+		return map[string]map[string]interface{}{
+			proxmoxVMResourcesPath: {"data": []interface{}{}},
+			"/nodes/pve2/status":   {"data": map[string]interface{}{"cpuinfo": map[string]interface{}{"flags": flags}}},
+		}
+	}
+	req := &CloneRequest{SourceNode: "pve1", TargetNode: "pve2", SourceID: 9000, NewID: 101, Name: "runner-1"}
+
+	t.Run("enabled on a supporting node", func(t *testing.T) {
+		api := &fakeProxmoxAPI{items: items("fpu sse2 svm")}
+		nested := *req
+		nested.NestedVirtualization = true
+
+		if _, err := newFakeProxmoxClient(api).CloneVM(context.Background(), &nested); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if api.putParams["cpu"] != "host,flags=+svm" {
+			t.Errorf("expected the host CPU with +svm, got %v", api.putParams["cpu"])
+		}
+	})
+
+	t.Run("rejected on an unsupported node", func(t *testing.T) {
+		api := &fakeProxmoxAPI{items: items("fpu sse2 hypervisor")}
+		nested := *req
+		nested.NestedVirtualization = true
+
+		_, err := newFakeProxmoxClient(api).CloneVM(context.Background(), &nested)
+		if !IsNestedVirtualizationUnsupported(err) || !strings.Contains(err.Error(), "node pve2") {
+			t.Fatalf("expected ErrNestedVirtualizationUnsupported for pve2, got %v", err)
+		}
+		if len(api.postURLs) != 0 || api.putURL != "" {
+			t.Errorf("expected no clone, got posts %v and config update %s", api.postURLs, api.putURL)
+		}
+	})
+
+	t.Run("not requested", func(t *testing.T) {
+		api := &fakeProxmoxAPI{items: items("")}
+
+		if _, err := newFakeProxmoxClient(api).CloneVM(context.Background(), req); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, ok := api.putParams["cpu"]; ok {
+			t.Errorf("expected the template's CPU type to be kept, got %v", api.putParams["cpu"])
+		}
+	})
+}
+
+func TestProxmoxClient_CloneVMResourceLimits(t *testing.T) {
+	items := map[string]map[string]interface{}{
+		proxmoxVMResourcesPath: {"data": []interface{}{}},
+	}
+
+	api := &fakeProxmoxAPI{items: items}
+	req := &CloneRequest{SourceNode: "pve1", SourceID: 9000, NewID: 101, Name: "runner-1", MinMemoryMiB: 2048, CPULimit: 1.5}
+	if _, err := newFakeProxmoxClient(api).CloneVM(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if api.putParams["balloon"] != int64(2048) || api.putParams["cpulimit"] != "1.5" {
+		t.Errorf("expected balloon 2048 and cpulimit 1.5, got %v", api.putParams)
+	}
+
+	// The balloon fits the VM's new memory though not the template's 2048MiB, so both are set at once
+	api = &fakeProxmoxAPI{items: items}
+	grown := &CloneRequest{SourceNode: "pve1", SourceID: 9000, NewID: 101, Name: "runner-1", MemoryMiB: 8192, MinMemoryMiB: 4096}
+	if _, err := newFakeProxmoxClient(api).CloneVM(context.Background(), grown); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if api.putParams["memory"] != int64(8192) || api.putParams["balloon"] != int64(4096) {
+		t.Errorf("expected memory 8192 and balloon 4096 in one config update, got %v", api.putParams)
+	}
+
+	// A balloon above the VM's memory is rejected before cloning
+	api = &fakeProxmoxAPI{items: items}
+	grown.MinMemoryMiB = 16384
+	if _, err := newFakeProxmoxClient(api).CloneVM(context.Background(), grown); err == nil || !strings.Contains(err.Error(), "exceeds the memory") {
+		t.Errorf("expected a balloon above the memory to be rejected, got %v", err)
+	}
+	if len(api.postURLs) != 0 {
+		t.Errorf("expected no clone, got %v", api.postURLs)
+	}
+
+	// Without limits the template's are kept
+	api = &fakeProxmoxAPI{items: items}
+	req.MinMemoryMiB, req.CPULimit = 0, 0
+	if _, err := newFakeProxmoxClient(api).CloneVM(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, key := range []string{"memory", "balloon", "cpulimit"} {
+		if _, ok := api.putParams[key]; ok {
+			t.Errorf("expected %s to be left alone, got %v", key, api.putParams)
+		}
+	}
+}
+
+func TestProxmoxClient_CloneVMBootDisk(t *testing.T) {
+	items := map[string]map[string]interface{}{
+		proxmoxVMResourcesPath: {"data": []interface{}{}},
+		vmConfigPath(VMRef{Node: "pve1", ID: 9000}): {"data": map[string]interface{}{
+			"scsi0": "local-lvm:base-9000-disk-0,size=10G",
+			"ide2":  "local-lvm:vm-9000-cloudinit,media=cdrom",
+		}},
+	}
+	const resizePath = "/nodes/pve1/qemu/101/resize"
+
+	tests := []struct {
+		name         string
+		bootDiskGB   int
+		expectResize string
+		expectError  string
+	}{
+		{name: "grown", bootDiskGB: 50, expectResize: "50G"},
+		{name: "same size", bootDiskGB: 10},
+		{name: "template size kept"},
+		{name: "smaller than the template", bootDiskGB: 5, expectError: "disks can only grow"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeProxmoxAPI{items: items}
+			req := &CloneRequest{SourceNode: "pve1", SourceID: 9000, NewID: 101, Name: "runner-1", BootDiskGB: tt.bootDiskGB}
+
+			_, err := newFakeProxmoxClient(api).CloneVM(context.Background(), req)
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectError, err)
+				}
+				if len(api.postURLs) != 0 {
+					t.Errorf("expected no clone, got %v", api.postURLs)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			resize, resized := api.puts[resizePath]
+			if tt.expectResize == "" {
+				if resized {
+					t.Errorf("expected the boot disk to be left alone, got %v", resize)
+				}
+				return
+			}
+			if !resized || resize["disk"] != "scsi0" || resize["size"] != tt.expectResize {
+				t.Errorf("expected scsi0 to grow to %s, got %v", tt.expectResize, resize)
+			}
+		})
+	}
+}
+
+func TestProxmoxClient_CloneVMCPUs(t *testing.T) {
+	items := map[string]map[string]interface{}{
+		proxmoxVMResourcesPath: {"data": []interface{}{}},
+		proxmoxNodesPath: {"data": []interface{}{
+			map[string]interface{}{"node": "pve1", "status": "online", "maxcpu": float64(8)},
+		}},
+	}
+
+	api := &fakeProxmoxAPI{items: items}
+	req := &CloneRequest{SourceNode: "pve1", SourceID: 9000, NewID: 101, Name: "runner-1", CPUs: 4}
+	if _, err := newFakeProxmoxClient(api).CloneVM(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if api.putParams["cores"] != 4 || api.putParams["sockets"] != vmSockets {
+		t.Errorf("expected 4 cores of one socket, got %v", api.putParams)
+	}
+
+	// More CPUs than the node has are rejected before anything is cloned
+	api = &fakeProxmoxAPI{items: items}
+	req.CPUs = 12
+	_, err := newFakeProxmoxClient(api).CloneVM(context.Background(), req)
+	if err == nil || !strings.Contains(err.Error(), "needs 12 CPUs (12 cores x 1 sockets) but node pve1 has only 8") {
+		t.Fatalf("expected a node CPU error, got %v", err)
+	}
+	if len(api.postURLs) != 0 || api.putURL != "" {
+		t.Errorf("expected no clone, got posts %v and config update %s", api.postURLs, api.putURL)
+	}
+
+	// Without a CPU count the template's topology is kept
+	api = &fakeProxmoxAPI{items: items}
+	req.CPUs = 0
+	if _, err := newFakeProxmoxClient(api).CloneVM(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, key := range []string{"cores", "sockets"} {
+		if _, ok := api.putParams[key]; ok {
+			t.Errorf("expected %s to be left alone, got %v", key, api.putParams)
+		}
+	}
+}
+
+func TestProxmoxClient_CloneVMVGA(t *testing.T) {
+	items := map[string]map[string]interface{}{
+		proxmoxVMResourcesPath: {"data": []interface{}{}},
+	}
+
+	tests := []struct {
+		name      string
+		vga       string
+		expectVGA interface{}
+	}{
+		{name: "keeps the template's display", expectVGA: nil},
+		{name: "serial console", vga: "serial0", expectVGA: "serial0"},
+		{name: "no display", vga: "none", expectVGA: "none"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeProxmoxAPI{items: items}
+			client := newFakeProxmoxClient(api)
+
+			req := &CloneRequest{SourceNode: "pve1", SourceID: 9000, NewID: 101, Name: "runner-1", VGA: tt.vga}
+			if _, err := client.CloneVM(context.Background(), req); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if api.putParams["vga"] != tt.expectVGA {
+				t.Errorf("expected vga %v, got %v", tt.expectVGA, api.putParams["vga"])
+			}
+		})
+	}
+
+	t.Run("invalid type is rejected before cloning", func(t *testing.T) {
+		api := &fakeProxmoxAPI{items: items}
+		client := newFakeProxmoxClient(api)
+
+		req := &CloneRequest{SourceNode: "pve1", SourceID: 9000, NewID: 101, Name: "runner-1", VGA: "vga9000"}
+		if _, err := client.CloneVM(context.Background(), req); err == nil || !strings.Contains(err.Error(), `invalid VGA type "vga9000"`) {
+			t.Errorf("expected invalid VGA type error, got %v", err)
+		}
+		if api.postURL != "" {
+			t.Errorf("expected no clone request, got %s", api.postURL)
+		}
+	})
+}
+
+func TestProxmoxClient_CloneVMGuestAgent(t *testing.T) {
+	items := map[string]map[string]interface{}{
+		proxmoxVMResourcesPath: {"data": []interface{}{}},
+	}
+	enabled, disabled := true, false
+
+	tests := []struct {
+		name        string
+		guestAgent  *bool
+		expectAgent interface{}
+	}{
+		{name: "keeps the template's setting", expectAgent: nil},
+		{name: "enabled", guestAgent: &enabled, expectAgent: 1},
+		{name: "disabled", guestAgent: &disabled, expectAgent: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeProxmoxAPI{items: items}
+			client := newFakeProxmoxClient(api)
+
+			req := &CloneRequest{SourceNode: "pve1", SourceID: 9000, NewID: 101, Name: "runner-1", GuestAgent: tt.guestAgent}
+			if _, err := client.CloneVM(context.Background(), req); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if api.putParams["agent"] != tt.expectAgent {
+				t.Errorf("expected agent %v, got %v", tt.expectAgent, api.putParams["agent"])
+			}
+		})
+	}
+}
+
+func TestProxmoxClient_CloneVMOnBoot(t *testing.T) {
+	items := map[string]map[string]interface{}{
+		proxmoxVMResourcesPath: {"data": []interface{}{}},
+	}
+	enabled, disabled := true, false
+
+	tests := []struct {
+		name         string
+		onBoot       *bool
+		expectOnBoot interface{}
+	}{
+		{name: "keeps the template's setting", expectOnBoot: nil},
+		{name: "enabled", onBoot: &enabled, expectOnBoot: 1},
+		{name: "disabled", onBoot: &disabled, expectOnBoot: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeProxmoxAPI{items: items}
+			client := newFakeProxmoxClient(api)
+
+			req := &CloneRequest{SourceNode: "pve1", SourceID: 9000, NewID: 101, Name: "runner-1", OnBoot: tt.onBoot}
+			if _, err := client.CloneVM(context.Background(), req); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if api.putParams["onboot"] != tt.expectOnBoot {
+				t.Errorf("expected onboot %v, got %v", tt.expectOnBoot, api.putParams["onboot"])
+			}
+		})
+	}
+}
+
+func TestProxmoxClient_CloneVMTags(t *testing.T) {
+	items := map[string]map[string]interface{}{
+		proxmoxVMResourcesPath: {"data": []interface{}{}},
+	}
+
+	api := &fakeProxmoxAPI{items: items}
+	req := &CloneRequest{SourceNode: "pve1", SourceID: 9000, NewID: 101, Name: "runner-1", Tags: []string{"env_prod", ManagedByTag("")}}
+	if _, err := newFakeProxmoxClient(api).CloneVM(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if api.putParams["tags"] != "env_prod;managed-by_hyperfleet" {
+		t.Errorf("expected the tags to be applied, got %v", api.putParams["tags"])
+	}
+
+	// Without tags the template's tags are kept
+	api = &fakeProxmoxAPI{items: items}
+	req.Tags = nil
+	if _, err := newFakeProxmoxClient(api).CloneVM(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, exists := api.putParams["tags"]; exists {
+		t.Errorf("expected no tags, got %v", api.putParams["tags"])
+	}
+}
+
+func TestProxmoxClient_ListVMs(t *testing.T) {
+	client := newFakeProxmoxClient(&fakeProxmoxAPI{items: map[string]map[string]interface{}{
+		proxmoxVMResourcesPath: {"data": []interface{}{
+			map[string]interface{}{"type": "qemu", "vmid": float64(101), "node": "pve1", "name": "runner-1", "status": "running",
+				"maxcpu": float64(2), "maxmem": float64(4 * 1024 * 1024 * 1024), "tags": "env_prod;managed-by_hyperfleet"},
+			map[string]interface{}{"type": "qemu", "vmid": float64(102), "node": "pve2", "name": "runner-2", "status": "stopped",
+				"tags": "managed-by_hyperfleet"},
+			map[string]interface{}{"type": "qemu", "vmid": float64(103), "node": "pve1", "name": "tenant-b", "status": "running",
+				"tags": "managed-by_tenant-b"},
+			map[string]interface{}{"type": "qemu", "vmid": float64(104), "node": "pve1", "name": "db", "status": "running"},
+			map[string]interface{}{"type": "qemu", "vmid": float64(9000), "node": "pve1", "template": float64(1),
+				"tags": "managed-by_hyperfleet"},
+			map[string]interface{}{"type": "lxc", "vmid": float64(200), "node": "pve1", "tags": "managed-by_hyperfleet"},
+		}},
+	}})
+
+	ids := func(vms []VMInfo) []int {
+		var ids []int
+		for _, vm := range vms {
+			ids = append(ids, vm.Ref.ID)
+		}
+		return ids
+	}
+
+	managed, err := client.ListVMs(context.Background(), ManagedByTag(""))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(ids(managed), []int{101, 102}) {
+		t.Errorf("expected the managed VMs 101 and 102, got %v", ids(managed))
+	}
+	expected := VMInfo{
+		Ref:        VMRef{Node: "pve1", ID: 101},
+		Name:       "runner-1",
+		PowerState: PowerStateRunning,
+		Resources:  VMResources{CPUs: 2, MemoryMiB: 4096},
+	}
+	if managed[0] != expected {
+		t.Errorf("expected %+v, got %+v", expected, managed[0])
+	}
+
+	tenant, err := client.ListVMs(context.Background(), ManagedByTag("tenant-b"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(ids(tenant), []int{103}) {
+		t.Errorf("expected only the other tenant's VM, got %v", ids(tenant))
+	}
+
+	all, err := client.ListVMs(context.Background(), "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(ids(all), []int{101, 102, 103, 104}) {
+		t.Errorf("expected every VM but the template, got %v", ids(all))
+	}
+}
+
+func TestProxmoxClient_CloneVMSMBIOSUUID(t *testing.T) {
+	const uuid = "c19e4985-baab-5fe3-946c-6dc05c015db2"
+
+	tests := []struct {
+		name         string
+		uuid         string
+		smbios       string
+		expectSMBIOS interface{}
+		expectError  string
+	}{
+		{name: "keeps the assigned UUID", expectSMBIOS: nil},
+		{name: "sets the UUID", uuid: uuid, expectSMBIOS: "uuid=" + uuid},
+		{
+			name:         "replaces only the UUID",
+			uuid:         uuid,
+			smbios:       "uuid=0f0a6e1c-3e5b-4c8e-9d59-3a4b6c2d1e0f,manufacturer=SHlwZXJGbGVldA==,base64=1",
+			expectSMBIOS: "uuid=" + uuid + ",manufacturer=SHlwZXJGbGVldA==,base64=1",
+		},
+		{name: "invalid UUID", uuid: "runner-1", expectError: `invalid SMBIOS UUID "runner-1"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := map[string]interface{}{}
+			if tt.smbios != "" {
+				config["smbios1"] = tt.smbios
+			}
+			api := &fakeProxmoxAPI{items: map[string]map[string]interface{}{
+				proxmoxVMResourcesPath:        {"data": []interface{}{}},
+				"/nodes/pve1/qemu/101/config": {"data": config},
+			}}
+			client := newFakeProxmoxClient(api)
+
+			req := &CloneRequest{SourceNode: "pve1", SourceID: 9000, NewID: 101, Name: "runner-1", SMBIOSUUID: tt.uuid}
+			_, err := client.CloneVM(context.Background(), req)
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Errorf("expected error containing %q, got %v", tt.expectError, err)
+				}
+				if api.postURL != "" {
+					t.Errorf("expected no clone, got %s", api.postURL)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if api.putParams["smbios1"] != tt.expectSMBIOS {
+				t.Errorf("expected smbios1 %v, got %v", tt.expectSMBIOS, api.putParams["smbios1"])
+			}
+		})
+	}
+}
+
+func TestProxmoxClient_CloneVMInterfaces(t *testing.T) {
+	items := map[string]map[string]interface{}{
+		proxmoxVMResourcesPath: {"data": []interface{}{}},
+		"/nodes/pve1/qemu/101/config": {"data": map[string]interface{}{
+			"net0": "virtio=BC:24:11:00:00:01,bridge=vmbr0",
+			"net1": "virtio=BC:24:11:00:00:02,bridge=vmbr1,firewall=1",
+		}},
+	}
+	enabled, disabled := true, false
+
+	tests := []struct {
+		name        string
+		interfaces  []InterfaceConfig
+		expected    map[string]interface{}
+		expectError string
+	}{
+		{
+			name:     "keeps the template's interfaces",
+			expected: map[string]interface{}{},
+		},
+		{
+			name:       "enables the firewall",
+			interfaces: []InterfaceConfig{{Name: "net0", Firewall: &enabled}},
+			expected:   map[string]interface{}{"net0": "virtio=BC:24:11:00:00:01,bridge=vmbr0,firewall=1"},
+		},
+		{
+			name:       "sets each interface",
+			interfaces: []InterfaceConfig{{Name: "net0", Firewall: &enabled}, {Name: "net1", Firewall: &disabled}},
+			expected: map[string]interface{}{
+				"net0": "virtio=BC:24:11:00:00:01,bridge=vmbr0,firewall=1",
+				"net1": "virtio=BC:24:11:00:00:02,bridge=vmbr1,firewall=0",
+			},
+		},
+		{
+			name:       "moves an interface to another bridge",
+			interfaces: []InterfaceConfig{{Name: "net0", Bridge: "vmbr2"}},
+			expected:   map[string]interface{}{"net0": "virtio=BC:24:11:00:00:01,bridge=vmbr2"},
+		},
+		{
+			name:        "missing interface",
+			interfaces:  []InterfaceConfig{{Name: "net2", Firewall: &enabled}},
+			expectError: "has no network interface net2",
+		},
+		{
+			name:        "invalid interface",
+			interfaces:  []InterfaceConfig{{Name: "eth0", Firewall: &enabled}},
+			expectError: `invalid network interface "eth0"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeProxmoxAPI{items: items}
+			client := newFakeProxmoxClient(api)
+
+			req := &CloneRequest{SourceNode: "pve1", SourceID: 9000, NewID: 101, Name: "runner-1", Interfaces: tt.interfaces}
+			_, err := client.CloneVM(context.Background(), req)
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Errorf("expected error containing %q, got %v", tt.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, name := range []string{"net0", "net1"} {
+				if api.putParams[name] != tt.expected[name] {
+					t.Errorf("expected %s %v, got %v", name, tt.expected[name], api.putParams[name])
+				}
+			}
+		})
+	}
+}
+
+func TestProxmoxClient_CloneVMBootOrder(t *testing.T) {
+	items := map[string]map[string]interface{}{
+		proxmoxVMResourcesPath: {"data": []interface{}{}},
+	}
+
+	tests := []struct {
+		name       string
+		bootOrder  []string
+		expectBoot string
+	}{
+		{name: "defaults to the primary disk", expectBoot: "order=scsi0"},
+		{name: "requested order", bootOrder: []string{"virtio0", "net0"}, expectBoot: "order=virtio0;net0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeProxmoxAPI{items: items}
+			client := newFakeProxmoxClient(api)
+
+			req := &CloneRequest{SourceNode: "pve1", SourceID: 9000, NewID: 101, Name: "runner-1", BootOrder: tt.bootOrder}
+			if _, err := client.CloneVM(context.Background(), req); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if api.putURL != "/nodes/pve1/qemu/101/config" || api.putParams["boot"] != tt.expectBoot {
+				t.Errorf("unexpected config update %s: %v", api.putURL, api.putParams)
+			}
+		})
+	}
+
+	t.Run("invalid order is rejected before cloning", func(t *testing.T) {
+		api := &fakeProxmoxAPI{items: items}
+		client := newFakeProxmoxClient(api)
+
+		req := &CloneRequest{SourceNode: "pve1", SourceID: 9000, NewID: 101, Name: "runner-1", BootOrder: []string{"cdrom"}}
+		if _, err := client.CloneVM(context.Background(), req); err == nil {
+			t.Errorf("expected validation error")
+		}
+		if api.postURL != "" {
+			t.Errorf("expected no clone request, got %s", api.postURL)
+		}
+	})
+}
+
+func TestProxmoxClient_ReconfigureVM(t *testing.T) {
+	ref := VMRef{Node: "pve1", ID: 101}
+	const (
+		configURL = "/nodes/pve1/qemu/101/config"
+		statusURL = "/nodes/pve1/qemu/101/status/current"
+	)
+	// vmItems returns the API fixtures for VM 101 on pve1 with 2 cores and 4 GiB of memory
+	vmItems := func(status string, config map[string]interface{}) map[string]map[string]interface{} {
+		items := map[string]map[string]interface{}{
+			proxmoxNodesPath: {"data": []interface{}{
+				map[string]interface{}{"node": "pve1", "status": "online", "maxcpu": float64(8)},
+				map[string]interface{}{"node": "pve2", "status": "offline"},
+			}},
+			statusURL: {"data": map[string]interface{}{
+				"status": status, "cpus": float64(2), "maxmem": float64(4 * bytesPerGiB),
+			}},
+			configURL: {"data": config},
+		}
+		// The "node CPUs unknown" case reconfigures the same VM on pve2
+		items["/nodes/pve2/qemu/101/status/current"] = items[statusURL]
+		items["/nodes/pve2/qemu/101/config"] = items[configURL]
+		return items
+	}
+	nodes := vmItems("stopped", map[string]interface{}{"cores": float64(2), "sockets": float64(1)})
+
+	t.Run("sets cores and memory", func(t *testing.T) {
+		api := &fakeProxmoxAPI{items: nodes}
+		client := newFakeProxmoxClient(api)
+
+		if err := client.ReconfigureVM(context.Background(), ref, VMResources{CPUs: 4, MemoryMiB: 8192}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if api.putURL != vmConfigPath(ref) {
+			t.Errorf("unexpected config URL: %s", api.putURL)
+		}
+		if api.putParams["sockets"] != 1 || api.putParams["cores"] != 4 || api.putParams["memory"] != int64(8192) {
+			t.Errorf("unexpected config params: %v", api.putParams)
+		}
+	})
+
+	t.Run("rejects invalid resources", func(t *testing.T) {
+		api := &fakeProxmoxAPI{}
+		client := newFakeProxmoxClient(api)
+
+		if err := client.ReconfigureVM(context.Background(), ref, VMResources{CPUs: 0, MemoryMiB: 8192}); err == nil {
+			t.Errorf("expected error for zero CPUs")
+		}
+		if err := client.ReconfigureVM(context.Background(), ref, VMResources{CPUs: 2}); err == nil {
+			t.Errorf("expected error for zero memory")
+		}
+		if api.putURL != "" {
+			t.Errorf("expected no config update, got %s", api.putURL)
+		}
+	})
+
+	t.Run("checks CPUs against the node", func(t *testing.T) {
+		tests := []struct {
+			name        string
+			ref         VMRef
+			cpus        int
+			expectError string
+		}{
+			{name: "fits", ref: ref, cpus: 4},
+			{name: "exactly the node's CPUs", ref: ref, cpus: 8},
+			{name: "over-commit", ref: ref, cpus: 12, expectError: "VM 101 needs 12 CPUs (12 cores x 1 sockets) but node pve1 has only 8"},
+			{name: "node CPUs unknown", ref: VMRef{Node: "pve2", ID: 101}, cpus: 64},
+			{name: "unknown node", ref: VMRef{Node: "pve9", ID: 101}, cpus: 2, expectError: "node pve9 not found"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				api := &fakeProxmoxAPI{items: nodes}
+				err := newFakeProxmoxClient(api).ReconfigureVM(context.Background(), tt.ref, VMResources{CPUs: tt.cpus, MemoryMiB: 4096})
+
+				if tt.expectError != "" {
+					if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+						t.Errorf("expected error containing %q, got %v", tt.expectError, err)
+					}
+					if api.putURL != "" {
+						t.Errorf("expected no config update, got %s", api.putURL)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if api.putParams["cores"] != tt.cpus {
+					t.Errorf("expected %d cores, got %v", tt.cpus, api.putParams["cores"])
+				}
+			})
+		}
+	})
+
+	t.Run("stopped VM drops the active CPU limit", func(t *testing.T) {
+		api := &fakeProxmoxAPI{items: vmItems("stopped", map[string]interface{}{
+			"cores": float64(4), "sockets": float64(1), "vcpus": float64(2), "hotplug": "network,disk,usb,cpu",
+		})}
+
+		if err := newFakeProxmoxClient(api).ReconfigureVM(context.Background(), ref, VMResources{CPUs: 3, MemoryMiB: 4096}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if api.putParams["cores"] != 3 || api.putParams["delete"] != "vcpus" {
+			t.Errorf("unexpected config params: %v", api.putParams)
+		}
+	})
+
+	t.Run("running VM", func(t *testing.T) {
+		tests := []struct {
+			name           string
+			config         map[string]interface{}
+			resources      VMResources
+			expectParams   map[string]interface{}
+			expectReboot   bool
+			expectNoUpdate bool
+		}{
+			{
+				name:         "hot-plugs CPUs within its topology",
+				config:       map[string]interface{}{"cores": float64(4), "sockets": float64(1), "vcpus": float64(2), "hotplug": "network,disk,usb,cpu"},
+				resources:    VMResources{CPUs: 4, MemoryMiB: 4096},
+				expectParams: map[string]interface{}{"vcpus": 4},
+			},
+			{
+				name:         "hot-plugs a memory increase",
+				config:       map[string]interface{}{"cores": float64(2), "hotplug": "memory,cpu"},
+				resources:    VMResources{CPUs: 2, MemoryMiB: 8192},
+				expectParams: map[string]interface{}{"memory": int64(8192)},
+			},
+			{
+				name:         "CPUs beyond its topology need a reboot",
+				config:       map[string]interface{}{"cores": float64(2), "sockets": float64(1), "hotplug": "network,disk,usb,cpu"},
+				resources:    VMResources{CPUs: 6, MemoryMiB: 4096},
+				expectParams: map[string]interface{}{"sockets": 1, "cores": 6},
+				expectReboot: true,
+			},
+			{
+				name:         "CPUs without hot-plug need a reboot",
+				config:       map[string]interface{}{"cores": float64(4)},
+				resources:    VMResources{CPUs: 3, MemoryMiB: 4096},
+				expectParams: map[string]interface{}{"sockets": 1, "cores": 3},
+				expectReboot: true,
+			},
+			{
+				name:         "memory without hot-plug needs a reboot",
+				config:       map[string]interface{}{"cores": float64(2), "hotplug": "1"},
+				resources:    VMResources{CPUs: 2, MemoryMiB: 8192},
+				expectParams: map[string]interface{}{"memory": int64(8192)},
+				expectReboot: true,
+			},
+			{
+				name:         "a memory decrease needs a reboot",
+				config:       map[string]interface{}{"cores": float64(2), "hotplug": "memory"},
+				resources:    VMResources{CPUs: 2, MemoryMiB: 2048},
+				expectParams: map[string]interface{}{"memory": int64(2048)},
+				expectReboot: true,
+			},
+			{
+				name:           "no change",
+				config:         map[string]interface{}{"cores": float64(2), "hotplug": "0"},
+				resources:      VMResources{CPUs: 2, MemoryMiB: 4096},
+				expectNoUpdate: true,
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				api := &fakeProxmoxAPI{items: vmItems("running", tt.config)}
+				err := newFakeProxmoxClient(api).ReconfigureVM(context.Background(), ref, tt.resources)
+
+				if tt.expectReboot {
+					if !IsRebootRequired(err) {
+						t.Errorf("expected ErrRebootRequired, got %v", err)
+					}
+				} else if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if tt.expectNoUpdate {
+					if api.putURL != "" {
+						t.Errorf("expected no config update, got %v", api.putParams)
+					}
+					return
+				}
+				if !maps.Equal(api.putParams, tt.expectParams) {
+					t.Errorf("expected config params %v, got %v", tt.expectParams, api.putParams)
+				}
+			})
+		}
+	})
+}
+
+func TestProxmoxClient_DeleteVM(t *testing.T) {
+	const upid = "UPID:pve2:0000A1B2:00C3D4E5:67890ABC:qmdestroy:101:root@pam:"
+	resources := map[string]interface{}{"data": []interface{}{
+		map[string]interface{}{"vmid": float64(101), "node": "pve2", "type": "qemu"},
+	}}
+
+	t.Run("starts delete and returns task", func(t *testing.T) {
+		api := &fakeProxmoxAPI{items: map[string]map[string]interface{}{
+			proxmoxVMResourcesPath: resources,
+			proxmoxHAStatusPath:    noHAStatus,
+			"/nodes/pve2/tasks?vmid=101&typefilter=qmdestroy&source=all&limit=1": {"data": []interface{}{
+				map[string]interface{}{"upid": upid, "status": "running"},
+			}},
+		}}
+
+		task, err := newFakeProxmoxClient(api).DeleteVM(context.Background(), VMRef{Node: "pve1", ID: 101})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if task != upid {
+			t.Errorf("expected task %q, got %q", upid, task)
+		}
+		expected := []string{"/nodes/pve2/qemu/101?purge=1&destroy-unreferenced-disks=1"}
+		if !slices.Equal(api.deleteURLs, expected) {
+			t.Errorf("expected deletes %v, got %v", expected, api.deleteURLs)
+		}
+	})
+
+	t.Run("HA-managed VM is removed from HA first", func(t *testing.T) {
+		api := &fakeProxmoxAPI{items: map[string]map[string]interface{}{
+			proxmoxVMResourcesPath: resources,
+			proxmoxHAStatusPath:    haStatusManaging("stopped"),
+			"/nodes/pve2/tasks?vmid=101&typefilter=qmdestroy&source=all&limit=1": {"data": []interface{}{
+				map[string]interface{}{"upid": upid, "status": "running"},
+			}},
+		}}
+
+		if _, err := newFakeProxmoxClient(api).DeleteVM(context.Background(), VMRef{Node: "pve1", ID: 101}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := []string{"/cluster/ha/resources/vm:101", "/nodes/pve2/qemu/101?purge=1&destroy-unreferenced-disks=1"}
+		if !slices.Equal(api.deleteURLs, expected) {
+			t.Errorf("expected deletes %v, got %v", expected, api.deleteURLs)
+		}
+	})
+
+	t.Run("running VM is stopped first", func(t *testing.T) {
+		defer func(interval time.Duration) { PowerStatePollInterval = interval }(PowerStatePollInterval)
+		PowerStatePollInterval = time.Millisecond
+
+		const (
+			statusURL = "/nodes/pve2/qemu/101/status/current"
+			stopURL   = "/nodes/pve2/qemu/101/status/stop"
+		)
+		api := &fakeProxmoxAPI{items: map[string]map[string]interface{}{
+			proxmoxVMResourcesPath: {"data": []interface{}{
+				map[string]interface{}{"vmid": float64(101), "node": "pve2", "type": "qemu", "status": "running"},
+			}},
+			proxmoxHAStatusPath: noHAStatus,
+			statusURL:           {"data": map[string]interface{}{"status": "running", "qmpstatus": "running"}},
+			"/nodes/pve2/tasks?vmid=101&typefilter=qmdestroy&source=all&limit=1": {"data": []interface{}{
+				map[string]interface{}{"upid": upid, "status": "running"},
+			}},
+		}}
+		var posts []string
+		api.onPost = func(url string) {
+			posts = append(posts, url)
+			if url == stopURL {
+				api.items[statusURL] = map[string]interface{}{"data": map[string]interface{}{"status": "stopped", "qmpstatus": "stopped"}}
+			}
+		}
+
+		if _, err := newFakeProxmoxClient(api).DeleteVM(context.Background(), VMRef{Node: "pve1", ID: 101}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !slices.Equal(posts, []string{stopURL}) {
+			t.Errorf("expected the VM to be hard stopped, got posts %v", posts)
+		}
+		expected := []string{"/nodes/pve2/qemu/101?purge=1&destroy-unreferenced-disks=1"}
+		if !slices.Equal(api.deleteURLs, expected) {
+			t.Errorf("expected deletes %v, got %v", expected, api.deleteURLs)
+		}
+	})
+
+	t.Run("VM that does not stop is not deleted", func(t *testing.T) {
+		defer func(interval, timeout time.Duration) {
+			PowerStatePollInterval, HardStopTimeout = interval, timeout
+		}(PowerStatePollInterval, HardStopTimeout)
+		PowerStatePollInterval, HardStopTimeout = time.Millisecond, 20*time.Millisecond
+
+		api := &fakeProxmoxAPI{items: map[string]map[string]interface{}{
+			proxmoxVMResourcesPath: {"data": []interface{}{
+				map[string]interface{}{"vmid": float64(101), "node": "pve2", "type": "qemu", "status": "running"},
+			}},
+			proxmoxHAStatusPath:                   noHAStatus,
+			"/nodes/pve2/qemu/101/status/current": {"data": map[string]interface{}{"status": "running", "qmpstatus": "running"}},
+		}}
+
+		_, err := newFakeProxmoxClient(api).DeleteVM(context.Background(), VMRef{Node: "pve1", ID: 101})
+		if !errors.Is(err, ErrPowerStateTimeout) {
+			t.Errorf("expected a power state timeout, got %v", err)
+		}
+		if len(api.deleteURLs) != 0 {
+			t.Errorf("expected no delete, got %v", api.deleteURLs)
+		}
+	})
+
+	t.Run("VM already gone", func(t *testing.T) {
+		api := &fakeProxmoxAPI{items: map[string]map[string]interface{}{
+			proxmoxVMResourcesPath: {"data": []interface{}{}},
+		}}
+
+		task, err := newFakeProxmoxClient(api).DeleteVM(context.Background(), VMRef{Node: "pve1", ID: 101})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if task != "" || api.deleteURL != "" {
+			t.Errorf("expected no delete, got task %q and URL %q", task, api.deleteURL)
+		}
+	})
+
+	t.Run("delete fails", func(t *testing.T) {
+		api := &fakeProxmoxAPI{
+			items: map[string]map[string]interface{}{
+				proxmoxVMResourcesPath: resources,
+				proxmoxHAStatusPath:    noHAStatus,
+			},
+			deleteErr: errors.New("VM is locked"),
+		}
+
+		_, err := newFakeProxmoxClient(api).DeleteVM(context.Background(), VMRef{Node: "pve1", ID: 101})
+		if err == nil || !strings.Contains(err.Error(), "VM is locked") {
+			t.Errorf("expected delete error, got %v", err)
+		}
+	})
+}
+
+func TestProxmoxClient_GetHAStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		haStatus map[string]interface{}
+		expected HAInfo
+	}{
+		{name: "cluster without HA", haStatus: noHAStatus},
+		{name: "HA-managed VM", haStatus: haStatusManaging("started"), expected: HAInfo{Managed: true, State: "started"}},
+		{
+			name: "VM not managed by HA",
+			haStatus: map[string]interface{}{"data": []interface{}{
+				map[string]interface{}{"id": "master", "type": "master", "node": "pve1", "status": "pve1 (active)"},
+				map[string]interface{}{"id": "service:vm:202", "type": "service", "sid": "vm:202", "state": "started"},
+			}},
+		},
+		{
+			name: "state without request_state",
+			haStatus: map[string]interface{}{"data": []interface{}{
+				map[string]interface{}{"id": "master", "type": "master", "node": "pve1", "status": "pve1 (active)"},
+				map[string]interface{}{"id": "service:vm:101", "type": "service", "sid": "vm:101", "state": "stopped"},
+			}},
+			expected: HAInfo{Managed: true, State: "stopped"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newFakeProxmoxClient(&fakeProxmoxAPI{items: map[string]map[string]interface{}{
+				proxmoxHAStatusPath: tt.haStatus,
+			}})
+
+			info, err := client.GetHAStatus(context.Background(), VMRef{Node: "pve1", ID: 101})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if *info != tt.expected {
+				t.Errorf("expected %+v, got %+v", tt.expected, *info)
+			}
+		})
+	}
+}
+
+func TestProxmoxClient_RebootVM(t *testing.T) {
+	ref := VMRef{Node: "pve1", ID: 101}
+	taskPath := "/nodes/pve1/tasks/OK/status"
+
+	tests := []struct {
+		name        string
+		api         *fakeProxmoxAPI
+		timeout     time.Duration
+		expectError bool
+	}{
+		{
+			name: "reboot completes",
+			api: &fakeProxmoxAPI{items: map[string]map[string]interface{}{
+				taskPath: {"data": map[string]interface{}{"status": "stopped", "exitstatus": "OK"}},
+			}},
+			timeout: time.Minute,
+		},
+		{
+			name: "reboot task fails",
+			api: &fakeProxmoxAPI{items: map[string]map[string]interface{}{
+				taskPath: {"data": map[string]interface{}{"status": "stopped", "exitstatus": "VM quit/powerdown failed"}},
+			}},
+			timeout:     time.Minute,
+			expectError: true,
+		},
+		{
+			name:        "reboot request fails",
+			api:         &fakeProxmoxAPI{postErr: errors.New("VM 101 not running")},
+			timeout:     time.Minute,
+			expectError: true,
+		},
+		{
+			name:        "invalid timeout",
+			api:         &fakeProxmoxAPI{},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newFakeProxmoxClient(tt.api).RebootVM(context.Background(), ref, tt.timeout)
+			if tt.expectError {
+				if err == nil {
+					t.Error("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.api.postURL != "/nodes/pve1/qemu/101/status/reboot" {
+				t.Errorf("expected a reboot request, got %s", tt.api.postURL)
+			}
+			if tt.api.postParams["timeout"] != 60 {
+				t.Errorf("expected a 60s shutdown timeout, got %v", tt.api.postParams["timeout"])
+			}
+		})
+	}
+}
+
+func TestProxmoxClient_WaitForTask(t *testing.T) {
+	const upid = "UPID:pve1:0000A1B2:00C3D4E5:67890ABC:qmdestroy:101:root@pam:"
+	statusPath := "/nodes/pve1/tasks/" + upid + "/status"
+
+	tests := []struct {
+		name        string
+		data        map[string]interface{}
+		expectError string
+		timeout     bool
+	}{
+		{
+			name: "succeeded",
+			data: map[string]interface{}{"status": "stopped", "exitstatus": "OK"},
+		},
+		{
+			name: "succeeded with warnings",
+			data: map[string]interface{}{"status": "stopped", "exitstatus": "WARNINGS: 1"},
+		},
+		{
+			name:        "failed",
+			data:        map[string]interface{}{"status": "stopped", "exitstatus": "can't lock file"},
+			expectError: "can't lock file",
+		},
+		{
+			name:    "still running",
+			data:    map[string]interface{}{"status": "running"},
+			timeout: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeProxmoxAPI{items: map[string]map[string]interface{}{
+				statusPath: {"data": tt.data},
+			}}
+
+			err := newFakeProxmoxClient(api).WaitForTask(context.Background(), "pve1", upid, 10*time.Millisecond)
+			switch {
+			case tt.timeout:
+				if !IsTaskTimeout(err) {
+					t.Errorf("expected ErrTaskTimeout, got %v", err)
+				}
+			case tt.expectError != "":
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Errorf("expected error containing %q, got %v", tt.expectError, err)
+				}
+			case err != nil:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestProxmoxClient_ListSnapshots(t *testing.T) {
+	ref := VMRef{Node: "pve1", ID: 101}
+
+	t.Run("lists snapshots oldest first", func(t *testing.T) {
+		api := &fakeProxmoxAPI{items: map[string]map[string]interface{}{
+			"/nodes/pve1/qemu/101/snapshot": {"data": []interface{}{
+				map[string]interface{}{"name": "current", "parent": "post-job", "running": float64(1)},
+				map[string]interface{}{"name": "post-job", "parent": "clean", "snaptime": float64(1700000600)},
+				map[string]interface{}{"name": "clean", "description": "fresh clone", "snaptime": float64(1700000000)},
+			}},
+		}}
+
+		snapshots, err := newFakeProxmoxClient(api).ListSnapshots(context.Background(), ref)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := []SnapshotInfo{
+			{Name: "clean", Description: "fresh clone", CreatedAt: time.Unix(1700000000, 0).UTC()},
+			{Name: "post-job", Parent: "clean", CreatedAt: time.Unix(1700000600, 0).UTC()},
+		}
+		if !slices.Equal(snapshots, expected) {
+			t.Errorf("expected %+v, got %+v", expected, snapshots)
+		}
+	})
+
+	t.Run("no snapshots", func(t *testing.T) {
+		api := &fakeProxmoxAPI{items: map[string]map[string]interface{}{
+			"/nodes/pve1/qemu/101/snapshot": {"data": []interface{}{
+				map[string]interface{}{"name": "current", "running": float64(1)},
+			}},
+		}}
+
+		snapshots, err := newFakeProxmoxClient(api).ListSnapshots(context.Background(), ref)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(snapshots) != 0 {
+			t.Errorf("expected no snapshots, got %+v", snapshots)
+		}
+	})
+}
+
+func TestProxmoxClient_CreateSnapshot(t *testing.T) {
+	ref := VMRef{Node: "pve1", ID: 101}
+	items := map[string]map[string]interface{}{
+		"/nodes/pve1/qemu/101/snapshot": {"data": []interface{}{
+			map[string]interface{}{"name": "current", "parent": "clean"},
+			map[string]interface{}{"name": "clean", "snaptime": float64(1700000000)},
+		}},
+	}
+
+	t.Run("creates a new snapshot", func(t *testing.T) {
+		api := &fakeProxmoxAPI{items: items}
+
+		if err := newFakeProxmoxClient(api).CreateSnapshot(context.Background(), ref, "post-job", "after job 42"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if api.postURL != "/nodes/pve1/qemu/101/snapshot" {
+			t.Errorf("unexpected snapshot URL %q", api.postURL)
+		}
+		if api.postParams["snapname"] != "post-job" || api.postParams["description"] != "after job 42" {
+			t.Errorf("unexpected snapshot params: %v", api.postParams)
+		}
+	})
+
+	t.Run("existing name is not duplicated", func(t *testing.T) {
+		api := &fakeProxmoxAPI{items: items}
+
+		if err := newFakeProxmoxClient(api).CreateSnapshot(context.Background(), ref, "clean", ""); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if api.postURL != "" {
+			t.Errorf("expected no snapshot request, got %s", api.postURL)
+		}
+	})
+
+	t.Run("reserved name", func(t *testing.T) {
+		api := &fakeProxmoxAPI{items: items}
+
+		if err := newFakeProxmoxClient(api).CreateSnapshot(context.Background(), ref, "current", ""); err == nil {
+			t.Error("expected error for the reserved snapshot name")
+		}
+	})
+}
+
+func TestProxmoxClient_StopVM(t *testing.T) {
+	defer func(interval, timeout time.Duration) {
+		PowerStatePollInterval, HardStopTimeout = interval, timeout
+	}(PowerStatePollInterval, HardStopTimeout)
+	PowerStatePollInterval, HardStopTimeout = time.Millisecond, 50*time.Millisecond
+
+	const (
+		statusURL   = "/nodes/pve1/qemu/101/status/current"
+		shutdownURL = "/nodes/pve1/qemu/101/status/shutdown"
+		stopURL     = "/nodes/pve1/qemu/101/status/stop"
+	)
+	vmStatus := func(status string) map[string]interface{} {
+		return map[string]interface{}{"data": map[string]interface{}{"status": status, "qmpstatus": status}}
+	}
+
+	tests := []struct {
+		name        string
+		status      string
+		graceful    bool
+		stopsOn     string // the POST after which the VM reports stopped, empty if it never stops
+		shutdownErr error  // returned by the shutdown task
+		expectPosts []string
+		expectError error
+	}{
+		{
+			name:        "graceful shutdown succeeds",
+			status:      "running",
+			graceful:    true,
+			stopsOn:     shutdownURL,
+			expectPosts: []string{shutdownURL},
+		},
+		{
+			name:        "graceful shutdown times out, then hard stop",
+			status:      "running",
+			graceful:    true,
+			stopsOn:     stopURL,
+			expectPosts: []string{shutdownURL, stopURL},
+		},
+		{
+			name:        "failed shutdown task escalates to hard stop",
+			status:      "running",
+			graceful:    true,
+			stopsOn:     stopURL,
+			shutdownErr: errors.New("VM quit/powerdown failed - got timeout"),
+			expectPosts: []string{shutdownURL, stopURL},
+		},
+		{
+			name:        "hard stop only",
+			status:      "running",
+			stopsOn:     stopURL,
+			expectPosts: []string{stopURL},
+		},
+		{
+			name:        "VM that never stops",
+			status:      "running",
+			graceful:    true,
+			expectPosts: []string{shutdownURL, stopURL},
+			expectError: ErrPowerStateTimeout,
+		},
+		{
+			name:     "VM already stopped",
+			status:   "stopped",
+			graceful: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeProxmoxAPI{items: map[string]map[string]interface{}{
+				statusURL:           vmStatus(tt.status),
+				proxmoxHAStatusPath: noHAStatus,
+			}}
+			api.onPost = func(url string) {
+				if url == tt.stopsOn {
+					api.items[statusURL] = vmStatus("stopped")
+				}
+				if url == shutdownURL {
+					api.postErr = tt.shutdownErr
+				} else {
+					api.postErr = nil
+				}
+			}
+
+			err := newFakeProxmoxClient(api).StopVM(context.Background(), VMRef{Node: "pve1", ID: 101}, tt.graceful, 20*time.Millisecond)
+			if tt.expectError != nil {
+				if !errors.Is(err, tt.expectError) {
+					t.Errorf("expected %v, got %v", tt.expectError, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(api.postURLs, tt.expectPosts) {
+				t.Errorf("expected posts %v, got %v", tt.expectPosts, api.postURLs)
+			}
+			// The shutdown task's timeout is whole seconds, and the VM is never forced off by it
+			if api.postURL == shutdownURL && (api.postParams["timeout"] != 1 || api.postParams["forceStop"] != 0) {
+				t.Errorf("unexpected shutdown params %v", api.postParams)
+			}
+		})
+	}
+
+	t.Run("HA-managed VM is kept stopped by HA", func(t *testing.T) {
+		api := &fakeProxmoxAPI{items: map[string]map[string]interface{}{
+			statusURL:           vmStatus("running"),
+			proxmoxHAStatusPath: haStatusManaging("started"),
+		}}
+		api.onPost = func(url string) { api.items[statusURL] = vmStatus("stopped") }
+
+		if err := newFakeProxmoxClient(api).StopVM(context.Background(), VMRef{Node: "pve1", ID: 101}, true, 20*time.Millisecond); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if api.putURL != "/cluster/ha/resources/vm:101" || api.putParams["state"] != "stopped" {
+			t.Errorf("expected the HA state to be set to stopped, got %s %v", api.putURL, api.putParams)
+		}
+		if !slices.Equal(api.postURLs, []string{shutdownURL}) {
+			t.Errorf("expected a shutdown, got posts %v", api.postURLs)
+		}
+	})
+
+	t.Run("graceful stop needs a timeout", func(t *testing.T) {
+		api := &fakeProxmoxAPI{}
+		if err := newFakeProxmoxClient(api).StopVM(context.Background(), VMRef{Node: "pve1", ID: 101}, true, 0); err == nil {
+			t.Errorf("expected an error for a zero timeout")
+		}
+		if len(api.postURLs) != 0 {
+			t.Errorf("expected no posts, got %v", api.postURLs)
+		}
+	})
+}
+
+func TestProxmoxClient_ConvertToTemplate(t *testing.T) {
+	defer func(interval, timeout time.Duration) {
+		PowerStatePollInterval, TemplateStopTimeout = interval, timeout
+	}(PowerStatePollInterval, TemplateStopTimeout)
+	PowerStatePollInterval, TemplateStopTimeout = time.Millisecond, 50*time.Millisecond
+
+	const (
+		statusURL   = "/nodes/pve1/qemu/9001/status/current"
+		stopURL     = "/nodes/pve1/qemu/9001/status/stop"
+		templateURL = "/nodes/pve1/qemu/9001/template"
+	)
+	vmStatus := func(status string) map[string]interface{} {
+		return map[string]interface{}{"data": map[string]interface{}{"status": status, "qmpstatus": status}}
+	}
+	guest := func(template float64) map[string]interface{} {
+		return map[string]interface{}{"data": []interface{}{
+			map[string]interface{}{"vmid": float64(9001), "node": "pve1", "type": "qemu", "template": template},
+		}}
+	}
+
+	tests := []struct {
+		name        string
+		template    float64
+		status      string
+		stops       bool // the VM stops once asked to
+		expectPosts []string
+		expectError error
+	}{
+		{
+			name:        "VM already stopped is converted directly",
+			status:      "stopped",
+			expectPosts: []string{templateURL},
+		},
+		{
+			name:        "running VM is stopped then converted",
+			status:      "running",
+			stops:       true,
+			expectPosts: []string{stopURL, templateURL},
+		},
+		{
+			name:        "VM that does not stop is not converted",
+			status:      "running",
+			expectPosts: []string{stopURL},
+			expectError: ErrPowerStateTimeout,
+		},
+		{
+			name:     "template is left as is",
+			template: 1,
+			status:   "stopped",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeProxmoxAPI{items: map[string]map[string]interface{}{
+				proxmoxVMResourcesPath: guest(tt.template),
+				statusURL:              vmStatus(tt.status),
+			}}
+			api.onPost = func(url string) {
+				if url == stopURL && tt.stops {
+					api.items[statusURL] = vmStatus("stopped")
+				}
+			}
+
+			err := newFakeProxmoxClient(api).ConvertToTemplate(context.Background(), VMRef{Node: "pve1", ID: 9001})
+			if tt.expectError != nil {
+				if !errors.Is(err, tt.expectError) {
+					t.Errorf("expected %v, got %v", tt.expectError, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(api.postURLs, tt.expectPosts) {
+				t.Errorf("expected posts %v, got %v", tt.expectPosts, api.postURLs)
+			}
+		})
+	}
+
+	t.Run("missing VM", func(t *testing.T) {
+		api := &fakeProxmoxAPI{items: map[string]map[string]interface{}{proxmoxVMResourcesPath: {"data": []interface{}{}}}}
+		if err := newFakeProxmoxClient(api).ConvertToTemplate(context.Background(), VMRef{Node: "pve1", ID: 9001}); err == nil {
+			t.Errorf("expected error for missing VM")
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package provider
+
+import (
+	"strings"
+)
+
+const (
+	// ManagedByTagKey is the key of the tag marking the VMs an operator manages
+	ManagedByTagKey = "managed-by"
+	// DefaultManagedBy identifies the operator in the managed-by tag when a cluster does not set its own
+	DefaultManagedBy = "hyperfleet"
+	// InstanceTagKey is the key of the tag naming the operator instance that owns a VM, so
+	// instances sharing a hypervisor leave each other's VMs alone
+	InstanceTagKey = "hyperfleet-instance"
+)
+
+// ManagedByTag returns the tag marking VMs managed by the operator identified by managedBy,
+// e.g. "managed-by_hyperfleet". An empty managedBy uses DefaultManagedBy.
+func ManagedByTag(managedBy string) string {
+	if managedBy == "" {
+		managedBy = DefaultManagedBy
+	}
+	return FormatTag(ManagedByTagKey, managedBy)
+}
+
+// InstanceTag returns the tag marking VMs owned by the operator instance instanceID,
+// e.g. "hyperfleet-instance_ci-east"
+func InstanceTag(instanceID string) string {
+	return FormatTag(InstanceTagKey, instanceID)
+}
+
+// isInstanceTag reports whether tag is an InstanceTag
+func isInstanceTag(tag string) bool {
+	return strings.HasPrefix(tag, InstanceTagKey+"_")
+}
+
+// FormatTag flattens a key-value pair into a single VM tag. Hypervisors accept a
+// restricted tag alphabet, so the result is lowercased, characters outside
+// [a-z0-9_+.-] are replaced with "-", and key and value are joined with "_".
+// An empty value yields just the key.
+func FormatTag(key, value string) string {
+	tag := sanitizeTag(key)
+	if value != "" {
+		tag += "_" + sanitizeTag(value)
+	}
+	return tag
+}
+
+// sanitizeTag lowercases s and replaces characters hypervisors reject in tags
+func sanitizeTag(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			return r + ('a' - 'A')
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_', r == '+', r == '.', r == '-':
+			return r
+		default:
+			return '-'
+		}
+	}, s)
+}
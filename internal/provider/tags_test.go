@@ -0,0 +1,43 @@
+package provider
+
+import "testing"
+
+func TestFormatTag(t *testing.T) {
+	tests := []struct {
+		key      string
+		value    string
+		expected string
+	}{
+		{"env", "prod", "env_prod"},
+		{"Team", "CI-Runners", "team_ci-runners"},
+		{"hyperfleet.io/managed-by", "hyperfleet", "hyperfleet.io-managed-by_hyperfleet"},
+		{"gpu", "", "gpu"},
+		{"owner", "a b=c", "owner_a-b-c"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := FormatTag(tt.key, tt.value); got != tt.expected {
+				t.Errorf("FormatTag(%q, %q) = %q, want %q", tt.key, tt.value, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestManagedByTag(t *testing.T) {
+	if got := ManagedByTag(""); got != "managed-by_hyperfleet" {
+		t.Errorf("ManagedByTag(\"\") = %q, want the default managed-by tag", got)
+	}
+	if got := ManagedByTag("Tenant-A"); got != "managed-by_tenant-a" {
+		t.Errorf("ManagedByTag(%q) = %q, want %q", "Tenant-A", got, "managed-by_tenant-a")
+	}
+}
+
+func TestInstanceTag(t *testing.T) {
+	if got := InstanceTag("CI East"); got != "hyperfleet-instance_ci-east" {
+		t.Errorf("InstanceTag(%q) = %q, want %q", "CI East", got, "hyperfleet-instance_ci-east")
+	}
+	if !isInstanceTag(InstanceTag("ci-east")) || isInstanceTag(ManagedByTag("")) {
+		t.Errorf("isInstanceTag should only match instance tags")
+	}
+}
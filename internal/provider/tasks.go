@@ -0,0 +1,71 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// TaskPollInterval is how long WaitForTask waits before first checking a hypervisor task's
+// status again; the wait doubles after each check up to TaskMaxPollInterval
+var TaskPollInterval = 500 * time.Millisecond
+
+// TaskMaxPollInterval caps the wait between checks of a long-running task's status
+var TaskMaxPollInterval = 10 * time.Second
+
+// ErrTaskTimeout reports that a hypervisor task was still running when the wait timed out
+var ErrTaskTimeout = errors.New("timed out waiting for task")
+
+// IsTaskTimeout reports whether err was caused by a task that is still running
+func IsTaskTimeout(err error) bool {
+	return errors.Is(err, ErrTaskTimeout)
+}
+
+// TaskStatus describes the state of a hypervisor task
+type TaskStatus struct {
+	Running bool
+	// Err is the task's failure, nil while it runs or once it succeeded
+	Err error
+}
+
+// waitForTask polls getTask with a backoff from TaskPollInterval to TaskMaxPollInterval until
+// the task finishes, returning the task's failure if it did not succeed. It gives up when the timeout elapses, ctx is done
+// or a lookup fails.
+func waitForTask(ctx context.Context, getTask func(context.Context, string, string) (*TaskStatus, error),
+	node, taskID string, timeout time.Duration) error {
+	if taskID == "" {
+		return fmt.Errorf("task ID is required")
+	}
+	if timeout <= 0 {
+		return fmt.Errorf("invalid task timeout: %v", timeout)
+	}
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	backoff := newPollBackoff(TaskPollInterval, TaskMaxPollInterval)
+	poll := time.NewTimer(backoff.next())
+	defer poll.Stop()
+
+	for {
+		status, err := getTask(ctx, node, taskID)
+		if err != nil {
+			return fmt.Errorf("failed to get status of task %s: %w", taskID, err)
+		}
+		if !status.Running {
+			if status.Err != nil {
+				return fmt.Errorf("task %s failed: %w", taskID, status.Err)
+			}
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("stopped waiting for task %s: %w", taskID, ctx.Err())
+		case <-deadline.C:
+			return fmt.Errorf("%w %s after %v", ErrTaskTimeout, taskID, timeout)
+		case <-poll.C:
+			poll.Reset(backoff.next())
+		}
+	}
+}
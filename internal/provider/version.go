@@ -0,0 +1,47 @@
+package provider
+
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// versionParts is how many numeric components a Version has: major, minor and patch
+const versionParts = 3
+
+// Version is a hypervisor version in structured form, e.g. 8.2.4 for Proxmox VE 8.2.4
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// ParseVersion parses a dotted version such as "8.2.4", "v8.2" or "8". Missing components are zero.
+func ParseVersion(version string) (Version, error) {
+	trimmed := strings.TrimPrefix(strings.TrimSpace(version), "v")
+	parts := strings.Split(trimmed, ".")
+	if trimmed == "" || len(parts) > versionParts {
+		return Version{}, fmt.Errorf("invalid version %q: expected major[.minor[.patch]]", version)
+	}
+
+	var numbers [versionParts]int
+	for i, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil || number < 0 {
+			return Version{}, fmt.Errorf("invalid version %q: component %q is not a number", version, part)
+		}
+		numbers[i] = number
+	}
+	return Version{Major: numbers[0], Minor: numbers[1], Patch: numbers[2]}, nil
+}
+
+// Compare returns -1, 0 or +1 as v is older than, the same as or newer than other
+func (v Version) Compare(other Version) int {
+	return cmp.Or(cmp.Compare(v.Major, other.Major), cmp.Compare(v.Minor, other.Minor), cmp.Compare(v.Patch, other.Patch))
+}
+
+// String formats the version as major.minor.patch
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
@@ -0,0 +1,55 @@
+package provider
+
+import "testing"
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		version   string
+		expected  Version
+		expectErr bool
+	}{
+		{version: "8.2.4", expected: Version{Major: 8, Minor: 2, Patch: 4}},
+		{version: "v7.4", expected: Version{Major: 7, Minor: 4}},
+		{version: "8", expected: Version{Major: 8}},
+		{version: "", expectErr: true},
+		{version: "8.2.4.1", expectErr: true},
+		{version: "8.x", expectErr: true},
+		{version: "8.-1", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			got, err := ParseVersion(tt.version)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatalf("ParseVersion(%q) = %v, want an error", tt.version, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseVersion(%q) unexpected error: %v", tt.version, err)
+			}
+			if got != tt.expected {
+				t.Errorf("ParseVersion(%q) = %v, want %v", tt.version, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	tests := []struct {
+		a, b     Version
+		expected int
+	}{
+		{Version{Major: 8, Minor: 2, Patch: 4}, Version{Major: 8, Minor: 2, Patch: 4}, 0},
+		{Version{Major: 7, Minor: 4, Patch: 17}, Version{Major: 8}, -1},
+		{Version{Major: 8, Minor: 10}, Version{Major: 8, Minor: 9, Patch: 9}, 1},
+		{Version{Major: 8, Minor: 2, Patch: 3}, Version{Major: 8, Minor: 2, Patch: 4}, -1},
+	}
+
+	for _, tt := range tests {
+		if got := tt.a.Compare(tt.b); got != tt.expected {
+			t.Errorf("%v.Compare(%v) = %d, want %d", tt.a, tt.b, got, tt.expected)
+		}
+	}
+}